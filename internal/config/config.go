@@ -3,54 +3,400 @@ package config
 import "gitee.com/dn-jinmin/tlog"
 
 type Config struct {
-	Name string
-	Addr string
+	Name            string
+	Addr            string
+	ShutdownTimeout int    // 退出时等待各服务关闭的总秒数（http请求、异步任务、ws连接），默认30
+	Timezone        string // 服务时区，如 Asia/Shanghai，用于提醒时间、每日总结和"今天"的范围，默认服务器本地时区
+
+	// 可信的反向代理（IP或CIDR），只有来自这些地址的请求才使用 X-Forwarded-For 中的客户端IP，
+	// 默认不信任任何代理，限流和审计使用连接的对端地址
+	TrustedProxies []string `validate:"dive,ip|cidr"`
+
+	// http和ws服务的TLS证书，配置后两者分别以https、wss提供服务
+	TLS struct {
+		CertFile string // 证书文件（含中间证书），与KeyFile同时配置
+		KeyFile  string
+		Autocert struct {
+			Domains  []string // 自动申请Let's Encrypt证书的域名，需对外监听443端口，配置了证书文件时不生效
+			CacheDir string   // 证书缓存目录，默认 ./certs
+			Email    string
+		}
+	}
+
+	// 多语言，请求按 Accept-Language 选择，定时提醒等没有请求的场景使用默认语言
+	I18n struct {
+		Default string // 默认语言 zh-CN/en，默认zh-CN
+	}
+
+	// 链路追踪，通过OTLP/HTTP导出http请求、AI对话、mongo和异步任务的耗时
+	Trace struct {
+		Endpoint    string            // OTLP/HTTP地址，如 http://localhost:4318，为空不启用
+		SampleRatio float64           // 采样比例 0~1，默认1
+		Headers     map[string]string // 导出时附加的http头，如鉴权
+	}
 
 	MySql struct {
 		DataSource string
 	}
 
 	Redis struct {
-		Addr     string
+		Addr     string `validate:"required"`
 		Password string
 		DB       int
 	}
 
 	Asynq struct {
-		Enabled     bool   `yaml:"Enabled"`     // 是否启用
-		Concurrency int    `yaml:"Concurrency"` // Worker 并发数
-		RetryMax    int    `yaml:"RetryMax"`    // 最大重试次数
-		MonitorAddr string `yaml:"MonitorAddr"` // 监控面板地址
+		Enabled         bool   `yaml:"Enabled"`         // 是否启用
+		Concurrency     int    `yaml:"Concurrency"`     // Worker 并发数
+		RetryMax        int    `yaml:"RetryMax"`        // 最大重试次数，0使用各任务的默认值
+		MonitorAddr     string `yaml:"MonitorAddr"`     // 监控面板地址
+		MonitorUser     string `yaml:"MonitorUser"`     // 监控面板Basic认证用户名
+		MonitorPassword string `yaml:"MonitorPassword"` // 监控面板Basic认证密码，为空时面板只读
+		ShutdownTimeout int    `yaml:"ShutdownTimeout"` // 退出时等待执行中任务的时间（秒），默认30
+		PeriodicSync    int    `yaml:"PeriodicSync"`    // 同步接口添加的定时任务的间隔（秒），默认60
+		MetricsInterval int    `yaml:"MetricsInterval"` // 采集队列指标的间隔（秒），默认15
+		Schedules       struct {
+			Timezone         string `yaml:"Timezone"`         // 兼容旧配置，未配置Timezone时作为服务时区
+			TodoReminder     string `yaml:"TodoReminder"`     // 待办提醒，默认 "0 9 * * *"
+			ApprovalReminder string `yaml:"ApprovalReminder"` // 审批超时提醒，默认 "0 10,15 * * *"
+			DailySummary     string `yaml:"DailySummary"`     // 每日总结，默认 "0 18 * * *"
+		} `yaml:"Schedules"`
+		// 按任务类型的重试策略，key为任务类型如 knowledge:process，default为全部任务的默认策略（优先于RetryMax）
+		Retry map[string]struct {
+			MaxRetry  int     `yaml:"MaxRetry"`  // 最大重试次数
+			Timeout   int     `yaml:"Timeout"`   // 单次执行超时（秒）
+			Backoff   string  `yaml:"Backoff"`   // 重试间隔 exponential=指数退避 fixed=固定间隔 为空使用asynq默认
+			BaseDelay int     `yaml:"BaseDelay"` // 首次重试间隔/固定间隔（秒），默认10
+			MaxDelay  int     `yaml:"MaxDelay"`  // 指数退避的最大间隔（秒），默认3600
+			Jitter    float64 `yaml:"Jitter"`    // 随机抖动比例 0~1
+		} `yaml:"Retry"`
+		NotifyBatch struct {
+			Window   int `yaml:"Window"`   // 同一用户的通知在最后一条之后等待的秒数，期间的通知合并为一条摘要，0为不聚合
+			MaxDelay int `yaml:"MaxDelay"` // 第一条通知最多等待的秒数，默认5倍Window
+			MaxSize  int `yaml:"MaxSize"`  // 达到该条数时立即发送，默认50
+		} `yaml:"NotifyBatch"`
 	}
 
 	Mongo struct {
 		User     string
 		Password string
-		Host     []string
+		Host     []string `validate:"required_without=Uri"`
 		Port     int
-		Database string
-		Param    string
-		//MaxPoolSize uint64
+		Database string `validate:"required"`
+		Param    string // 其他连接参数，如 w=majority&retryWrites=true
+
+		Uri            string // 完整连接串，配置后忽略上面的地址和账号
+		Srv            bool   // 使用 mongodb+srv:// 连接，Host只配置一个域名
+		AuthSource     string // 认证数据库
+		ReplicaSet     string // 副本集名称
+		ReadPreference string // 读偏好 primary/primaryPreferred/secondary/secondaryPreferred/nearest
+		Tls            struct {
+			Enabled  bool
+			CAFile   string // 自签名证书的CA文件
+			Insecure bool   // 不校验服务端证书，仅用于测试
+		}
+		MaxPoolSize            uint64
+		MinPoolSize            uint64
+		ConnectTimeout         int // 连接超时（秒）
+		ServerSelectionTimeout int // 选择可用节点的超时（秒）
+		SocketTimeout          int // 读写超时（秒）
+		PingTimeout            int // 启动和定期检查连通性的超时（秒），默认10
+		HealthInterval         int // 定期检查连通性的间隔（秒），结果用于 /readyz，默认10，负数不检查
 	}
 
 	Jwt struct {
-		Secret string
-		Expire int64
+		Secret string   `validate:"required_without=Keys"` // 未配置Keys时用于签发；配置Keys后只用于校验轮换前签发的token（没有kid）
+		Expire int64    `validate:"required"`
+		Keys   []JwtKey `validate:"dive"` // 轮换密钥，按从新到旧排列，第一个用于签发新token，其余只用于校验
+	}
+
+	// 密码哈希，已有密码在下次登录成功时按当前配置重新生成
+	Password struct {
+		Algorithm string // bcrypt/argon2id，默认bcrypt
+		Argon2    struct {
+			Memory      uint32 // 内存（KiB），默认65536
+			Iterations  uint32 // 迭代次数，默认3
+			Parallelism uint8  // 并行度，默认2
+		}
 	}
 
 	Tlog struct {
 		Mode  tlog.LogMod //运行模式
 		Label string      //加载日志输出的标签
+		Body  bool        //请求日志是否记录请求参数和响应内容，密码、令牌等字段已脱敏
 	}
 	Ws struct {
-		Addr string
+		Addr         string
+		DrainTimeout int // 关闭时等待连接排空的秒数
+		RateLimit    struct {
+			ConnRate      float64 // 单连接每秒消息数
+			ConnBurst     int     // 单连接突发消息数
+			UserRate      float64 // 单用户每秒消息数
+			UserBurst     int     // 单用户突发消息数
+			MaxViolations int     // 一分钟内超限次数达到后断开连接
+		}
+	}
+	// 出站http请求（pkg/curl），零值使用默认值
+	Curl struct {
+		Timeout      int // 单次请求超时（秒），默认30
+		Retries      int // GET/PUT/DELETE失败后的重试次数，默认2，-1不重试
+		Backoff      int // 首次重试等待（毫秒），之后每次翻倍，默认200
+		MaxIdleConns int // 每个主机保持的空闲连接数，默认32
+	}
+	// panic上报，捕获http、ws、异步任务中的panic，未配置时只记录日志
+	PanicReport struct {
+		Webhook struct {
+			Url    string
+			Secret string // 配置后对请求体做 HMAC-SHA256 签名，放在 X-Signature 头
+		}
+		Sentry struct {
+			Dsn         string // 如 https://<key>@o0.ingest.sentry.io/<project>
+			Environment string
+		}
+	}
+	// 服务间调用的签名认证，待办、审批接口可使用签名代替Jwt，见 token.StringToSign
+	Signature struct {
+		MaxSkew int            // 时间戳允许的偏差（秒），nonce在两倍偏差内不能重复，默认300
+		Apps    []SignatureApp `validate:"dive"`
+	}
+
+	// HTTP接口限流，计数放在Redis中多实例共享；AI接口使用 LangChain.RateLimit
+	RateLimit struct {
+		Login RateLimitConf // 登录接口，按IP，防止暴力破解
+		Write RateLimitConf // 变更接口（非GET），按用户
 	}
 	LangChain struct {
-		Url    string
+		Url    string // 未配置Providers时作为qwen供应商（兼容旧配置）
 		ApiKey string
+
+		// 模型供应商，按顺序调用，失败或超时自动切换下一个
+		Providers []struct {
+			Name           string
+			Type           string // qwen/openai/deepseek/azure/ollama/vllm
+			Url            string // 为空使用默认地址，azure为资源endpoint
+			ApiKey         string
+			Model          string   // azure为部署名称
+			Models         []string // 允许请求切换的其他模型
+			EmbeddingModel string
+			EmbeddingUrl   string // 向量化接口地址，为空时与Url相同
+			ApiVersion     string // 仅azure
+			Timeout        int    // 单次调用超时（秒）
+		}
+		Embedding    string // 向量化使用的供应商名称，默认第一个；更换后需要重建知识库
+		AgentMode    string // 工具调用方式 functions=原生function calling（默认） mrkl=文本解析
+		ParseRetries int    // 工具输入解析失败时让模型修正的次数，默认2，-1为不修正
+		Debug        bool   // 是否允许请求返回AI执行过程（工具输入输出等），生产环境建议关闭
+		VisionModel  string // 图片理解模型（如qwen-vl-max），需在某个供应商的Models中，为空不支持图片
+		Quota        struct {
+			DailyTokens int64 // 每个用户每天的token额度，0为不限制
+		}
+		RateLimit RateLimitConf // 每个用户的AI请求频率
+		Memory    struct {
+			Store    string // 对话记忆存储 mongo/redis=持久化（重启不丢失、多实例共享） 其他=进程内
+			Limit    int    // 每次加载最近的消息条数，0为不限制
+			RedisTTL int    // redis: 会话无新消息多久（秒）后删除，默认604800（7天），负数不删除
+
+			Strategy   string // 记忆策略 buffer=全量 window=最近N轮 token=按token截断 summary=超出后LLM摘要
+			WindowSize int    // window: 保留的对话轮数
+			MaxTokens  int    // token/summary: 历史消息的token上限
+
+			IdleTTL int // 会话空闲多久（秒）后释放内存，默认7200，负数不释放
+		}
+		Prompt struct {
+			File           string // 提示词yaml文件，为空不加载
+			Mongo          bool   // 是否加载mongo prompt集合中的提示词，优先级高于文件
+			ReloadInterval int    // 热加载间隔（秒），默认30
+		}
+		Router struct {
+			Rules          []RouteRule // 路由规则，配置了某个handler的规则后替换代码中的规则
+			Mongo          bool        // 是否加载mongo route_rule集合中的规则，排在配置的规则之后
+			ReloadInterval int         // 热加载间隔（秒），默认30
+
+			Threshold float64 // 模型选择的置信度低于该值时按Fallback处理，0为不判断
+			Fallback  string  // 不确定或选择了不存在的处理器时 clarify=追问用户 default=使用默认处理器（默认）
+			Log       bool    // 是否将路由决策保存到mongo route_log集合，用于离线评估误路由
+		}
+		Moderation struct {
+			Enabled  bool     // 是否审核AI对话的用户输入和模型回复
+			Action   string   // 命中后的处理 block=拦截（默认） flag=放行，只记录
+			Keywords []string // 敏感词，不区分大小写
+			Model    bool     // 是否调用大模型审核，每次对话会增加两次模型调用
+		}
+		Knowledge struct {
+			Hybrid bool // 是否启用混合检索（向量+BM25关键词），更早入库的文档需重建索引后才能被关键词检索到
+			Rerank struct {
+				Mode       string   // 重排方式 api=cross-encoder重排接口 llm=大模型排序 为空不重排
+				Url        string   // api: 重排接口地址，如 https://api.jina.ai/v1/rerank
+				ApiKey     string   // api
+				Model      string   // api: 重排模型，如 bge-reranker-v2-m3
+				Timeout    int      // api: 超时（秒），默认10
+				Candidates int      // 参与重排的候选数，默认10
+				Indexes    []string // 开启重排的索引，支持通配符如 dept_*，为空时全部索引都重排
+			}
+			Semantic struct {
+				Types      []string // 使用语义分块的文件类型，如 [.docx, .txt]，为空全部使用固定长度分块
+				Percentile float64  // 相邻句子差异超过该百分位时切分，默认95，越小块越多
+			}
+		}
+		Cache struct {
+			Enabled  bool     // 是否缓存知识库等确定性问题的回复，知识库更新后自动失效
+			TTL      int      // 缓存时间（秒），默认3600
+			Handlers []string // 可缓存的handler，默认knowledge
+			Tools    []string // 可缓存的工具，调用了其他工具的回复不缓存，默认knowledge_query
+		}
 	}
 	Upload struct {
-		SavePath string
-		Host     string
+		SavePath   string
+		Host       string
+		MaxSize    int64    // 单个文件大小上限（MB），0为不限制
+		MaxFiles   int      // 多文件上传一次最多的文件数，默认10
+		Extensions []string // 允许的扩展名，如 .pdf，为空不限制
+		MimeTypes  []string // 允许的内容类型（按文件内容识别），支持 image/* 形式，为空不限制
+		UserQuota  int64    // 每个用户的存储空间上限（MB），0为不限制
+		ThumbSize  int      // 图片缩略图最长边（像素），默认320，小于0不生成
+		Clamd      struct {
+			Addr    string // clamd地址 host:port 或 unix socket 路径，为空不做杀毒扫描
+			Timeout int    // 单次扫描超时（秒），默认30
+		}
+	}
+	Notify struct {
+		Enabled bool // 是否启用离线推送
+		Webhook struct {
+			Url    string // webhook 地址
+			Secret string // 签名密钥，为空则不签名
+		}
+		Fcm struct {
+			CredentialsFile string // Firebase 服务账号 json 文件
+		}
+		Apns struct {
+			KeyFile    string // .p8 密钥文件
+			KeyId      string
+			TeamId     string
+			Topic      string // App 的 bundle id
+			Production bool
+		}
+		Email struct {
+			Enabled bool     // 离线时通过邮件投递，需配置Mail
+			Types   []string // 投递的消息类型，为空时全部投递
+		}
+		Sms struct {
+			Enabled  bool   // 紧急通知（如审批拒绝、超时升级）通过短信投递，只发送配置了模板的消息类型
+			Provider string // aliyun/twilio
+			Aliyun   struct {
+				AccessKeyId     string
+				AccessKeySecret string
+				SignName        string // 短信签名
+				RegionId        string // 默认 cn-hangzhou
+			}
+			Twilio struct {
+				AccountSid string
+				AuthToken  string
+				From       string // 发送号码，或以 MG 开头的 Messaging Service SID
+			}
+			RateLimit RateLimitConf // 每个用户每种消息的发送频率，默认每小时3条
+			Templates []struct {
+				Type string // 消息类型，如 approval:result
+				Code string // 阿里云模板编号，Twilio 不需要
+				Text string // 短信内容，${title} ${content} 及消息的data字段会被替换，为空时为"标题：内容"
+			}
+		}
+	}
+	Mail struct {
+		Host     string // SMTP 服务器，为空时不启用邮件
+		Port     int    // 默认465（SSL），587等端口使用STARTTLS
+		Username string
+		Password string // 授权码
+		From     string // 发件地址，默认与Username相同
+		FromName string
+		Domains  []string // 公司邮箱域名（小写），AI发送邮件时其他域名的收件人标记为外部邮箱
+	}
+	Speech struct {
+		Url      string // OpenAI兼容的语音接口地址，为空时不启用语音
+		ApiKey   string
+		AsrModel string // 语音识别模型，如whisper-1，为空不支持语音输入
+		TtsModel string // 语音合成模型，如tts-1，为空不支持语音播报
+		Voice    string // 默认音色
+		Timeout  int    // 单次调用超时（秒），默认60
+	}
+	Calendar struct {
+		Enabled  bool   // 是否启用外部日历同步，凭证使用MsgCrypto的密钥加密存储
+		SyncCron string // 定时同步的cron表达式，默认每15分钟
+		SyncDays int    // 同步未来多少天内的待办和日程，默认30
+		Exchange struct {
+			Tenant       string // Azure AD 租户ID，默认common
+			ClientId     string // 为空时不启用Exchange
+			ClientSecret string
+			RedirectUrl  string // 授权回调页面
+		}
+	}
+	Retention struct {
+		Cron         string // 定时清理的cron表达式，默认每天3:00，需要启用Asynq
+		DryRun       bool   // 只统计将被清理的数量，不删除
+		ChatLogDays  int    // 聊天记录保留天数，0为不清理
+		TodoDays     int    // 已完成待办的保留天数（按最后更新时间），0为不清理
+		ApprovalDays int    // 已结束审批的保留天数（按最后更新时间），0为不清理
+		AuditLogDays int    // 审计记录保留天数，0为不清理
+	}
+	Workflow struct {
+		CallbackSecret string // 配置后对流程回调的请求体做 HMAC-SHA256 签名，放在 X-Signature 头
+	}
+	Attendance struct {
+		WorkStart string `validate:"omitempty,datetime=15:04"` // 上班时间，默认 "09:00"，晚于该时间的上班卡记为迟到
+		WorkEnd   string `validate:"omitempty,datetime=15:04"` // 下班时间，默认 "18:00"，早于该时间的下班卡记为早退
+	}
+	KnowledgeSync struct {
+		Cron       string // 定时同步外部知识库的cron表达式，默认每小时，需要启用Asynq
+		Confluence struct {
+			Url      string // 为空不同步，如 https://xxx.atlassian.net/wiki
+			Username string // 云版为账号邮箱，数据中心版为空（Token为个人访问令牌）
+			Token    string
+			Space    string // 空间key，为空同步全部有权限的空间
+			DepId    string // 写入的部门知识库，为空写入公共知识库
+		}
+		Notion struct {
+			Token string // 内部集成令牌，为空不同步
+			DepId string
+		}
+		Feishu struct {
+			AppId     string // 为空不同步
+			AppSecret string
+			SpaceId   string // 知识空间ID
+			Url       string // 为空使用飞书，Lark为 https://open.larksuite.com/open-apis
+			DepId     string
+		}
 	}
+	MsgCrypto struct {
+		Enabled     bool              // 是否加密存储聊天内容
+		Provider    string            // 密钥来源 static=配置文件 env=环境变量
+		ActiveKeyId string            // 当前用于加密的密钥，旧密钥保留用于解密
+		Keys        map[string]string // static: keyId -> base64(16/24/32字节密钥)
+		EnvPrefix   string            // env: 环境变量名为 EnvPrefix+keyId
+	}
+}
+
+// JwtKey jwt签名密钥，Kid写入token头部用于选择校验的密钥
+type JwtKey struct {
+	Kid    string `validate:"required"`
+	Secret string `validate:"required"`
+}
+
+// SignatureApp 通过签名调用接口的应用
+type SignatureApp struct {
+	Key    string `validate:"required"`
+	Secret string `validate:"required"`
+	UserId string `validate:"required"` // 接口以该用户的身份执行，如创建待办、发起审批
+}
+
+// RateLimitConf 固定窗口限流
+type RateLimitConf struct {
+	Requests int // 每个窗口内的请求数，0为不限制
+	Window   int // 窗口长度（秒），默认60
+}
+
+// RouteRule AI对话的路由规则
+type RouteRule struct {
+	Handler     string   // 处理器名称，如 todo、approval
+	Description string   // 用户意图，写入路由提示词
+	Keywords    []string // 输入包含关键词时直接选择该处理器，不调用模型
+	Examples    []string // 示例输入
 }
@@ -1,18 +1,23 @@
 // Code generated by goctl. DO NOT EDIT.
 package domain
 
+import "aiOffice/pkg/workflow"
+
 type User struct {
-	Id       string `json:"id,omitempty"`       // 用户ID
-	Password string `json:"password,omitempty"` // 密码
-	Name     string `json:"name,omitempty"`     // 用户名
-	Status   int    `json:"status,omitempty"`   // 状态：0=禁用 1=启用
+	Id       string `json:"id,omitempty"`                              // 用户ID
+	Password string `json:"password,omitempty"`                        // 密码
+	Name     string `json:"name,omitempty"`                            // 用户名
+	Email    string `json:"email,omitempty" binding:"omitempty,email"` // 邮箱，用于邮件通知
+	Phone    string `json:"phone,omitempty" binding:"max=20"`          // 手机号，用于紧急通知短信
+	Status   int    `json:"status,omitempty" binding:"oneof=0 1"`      // 状态：0=禁用 1=启用
 }
 
 type UserListReq struct {
-	Ids   []string `json:"ids,omitempty"`   // 用户ID列表
-	Name  string   `json:"name,omitempty"`  // 用户名模糊搜索
-	Page  int      `json:"page,omitempty"`  // 页码
-	Count int      `json:"count,omitempty"` // 每页数量
+	Ids   []string `json:"ids,omitempty"`                   // 用户ID列表
+	Name  string   `json:"name,omitempty"`                  // 用户名模糊搜索
+	Page  int      `json:"page,omitempty" binding:"min=0"`  // 页码
+	Count int      `json:"count,omitempty" binding:"min=0"` // 每页数量，最大100
+	Sort  string   `json:"sort,omitempty"`                  // 排序 name/createAt，前缀-为倒序
 }
 
 type UserListResp struct {
@@ -21,8 +26,8 @@ type UserListResp struct {
 }
 
 type LoginReq struct {
-	Name     string `json:"name,omitempty"`     // 用户名
-	Password string `json:"password,omitempty"` // 密码
+	Name     string `json:"name,omitempty" binding:"required"`     // 用户名
+	Password string `json:"password,omitempty" binding:"required"` // 密码
 }
 
 type LoginResp struct {
@@ -34,14 +39,18 @@ type LoginResp struct {
 	RefreshAfter int64  `json:"refreshAfter,omitempty"` // 刷新时间
 }
 
+type LogoutReq struct {
+	All bool `json:"all,omitempty"` // 是否退出全部设备，此前签发的token均失效
+}
+
 type UpdatePasswordReq struct {
-	Id     string `json:"id"`     // 用户ID
-	OldPwd string `json:"oldPwd"` // 原密码
-	NewPwd string `json:"newPwd"` // 新密码
+	Id     string `json:"id"`                               // 用户ID
+	OldPwd string `json:"oldPwd" binding:"required"`        // 原密码
+	NewPwd string `json:"newPwd" binding:"required,max=64"` // 新密码
 }
 
 type IdPathReq struct {
-	Id string `uri:"id,omitempty"` // uri 标签表示从 URL 路径获取参数
+	Id string `uri:"id,omitempty" binding:"required"` // uri 标签表示从 URL 路径获取参数
 }
 
 type IdResp struct {
@@ -83,18 +92,18 @@ type DepartmentPathReq struct {
 }
 
 type SetDepartmentUser struct {
-	DepId   string   `json:"depId"`   // 部门ID
-	UserIds []string `json:"userIds"` // 用户ID列表
+	DepId   string   `json:"depId" binding:"required"` // 部门ID
+	UserIds []string `json:"userIds"`                  // 用户ID列表
 }
 
 type AddDepartmentUser struct {
-	DepId  string `json:"depId"`  // 部门ID
-	UserId string `json:"userId"` // 要添加的用户ID
+	DepId  string `json:"depId" binding:"required"`  // 部门ID
+	UserId string `json:"userId" binding:"required"` // 要添加的用户ID
 }
 
 type RemoveDepartmentUser struct {
-	DepId  string `json:"depId"`  // 部门ID
-	UserId string `json:"userId"` // 要删除的用户ID
+	DepId  string `json:"depId" binding:"required"`  // 部门ID
+	UserId string `json:"userId" binding:"required"` // 要删除的用户ID
 }
 
 type DepartmentSoaResp struct {
@@ -110,10 +119,10 @@ type DepartmentSoaResp struct {
 }
 
 type TodoRecord struct {
-	TodoId   string `json:"todoId,omitempty"`
+	TodoId   string `json:"todoId,omitempty" binding:"required"`
 	UserId   string `json:"userId,omitempty"`
 	UserName string `json:"userName,omitempty"`
-	Content  string `json:"content,omitempty"`
+	Content  string `json:"content,omitempty" binding:"max=2000"`
 	Image    string `json:"image,omitempty"`
 	CreateAt int64  `json:"createAt,omitempty"`
 }
@@ -122,9 +131,9 @@ type Todo struct {
 	ID          string        `json:"id,omitempty"`
 	CreatorId   string        `json:"creatorId,omitempty"`
 	CreatorName string        `json:"creatorName,omitempty"`
-	Title       string        `json:"title,omitempty"`
-	DeadlineAt  int64         `json:"deadlineAt,omitempty"`
-	Desc        string        `json:"desc,omitempty"`
+	Title       string        `json:"title,omitempty" binding:"max=200"`
+	DeadlineAt  int64         `json:"deadlineAt,omitempty" binding:"omitempty,timestamp"`
+	Desc        string        `json:"desc,omitempty" binding:"max=2000"`
 	Status      int           `json:"status,omitempty"`
 	Records     []*TodoRecord `json:"records,omitempty"`
 	ExecuteIds  []string      `json:"executeIds,omitempty"` // 待办执行人
@@ -154,16 +163,17 @@ type TodoInfoResp struct {
 
 type FinishedTodoReq struct {
 	UserId string `json:"userId"`
-	TodoId string `json:"todoId"`
+	TodoId string `json:"todoId" binding:"required"`
 }
 
 type TodoListReq struct {
 	Id        string `json:"id,omitempty"`
 	UserId    string `json:"userId,omitempty"`
-	Page      int    `json:"page,omitempty"`
-	Count     int    `json:"count,omitempty"`
-	StartTime int64  `json:"startTime,omitempty"`
-	EndTime   int64  `json:"endTime,omitempty"`
+	Page      int    `json:"page,omitempty" binding:"min=0"`
+	Count     int    `json:"count,omitempty" binding:"min=0"` // 每页数量，最大100
+	Sort      string `json:"sort,omitempty"`                  // 排序 createAt/deadlineAt/updateAt，前缀-为倒序，默认 -createAt
+	StartTime int64  `json:"startTime,omitempty" binding:"omitempty,timestamp"`
+	EndTime   int64  `json:"endTime,omitempty" binding:"omitempty,timestamp"`
 }
 
 type TodoListResp struct {
@@ -179,37 +189,40 @@ type Approver struct {
 }
 
 type MakeCard struct {
-	Date      int64  `json:"date,omitempty" mapstructure:"date,omitempty"`                   //补卡时间
-	Reason    string `json:"reason,omitempty" mapstructure:"reason,omitempty"`               //补卡理由
-	Day       int64  `json:"day,omitempty" mapstructure:"day,omitempty"`                     //补卡日期(20221011)
-	CheckType int    `json:"workCheckType,omitempty" mapstructure:"workCheckType,omitempty"` //补卡类型
+	Date      int64  `json:"date,omitempty" mapstructure:"date,omitempty" binding:"omitempty,timestamp"` //补卡时间
+	Reason    string `json:"reason,omitempty" mapstructure:"reason,omitempty"`                           //补卡理由
+	Day       int64  `json:"day,omitempty" mapstructure:"day,omitempty"`                                 //补卡日期(20221011)
+	CheckType int    `json:"workCheckType,omitempty" mapstructure:"workCheckType,omitempty"`             //补卡类型
+
+	Record  *Attendance `json:"record,omitempty" mapstructure:"-"`  // 发起补卡时当天的考勤记录，创建时由服务端填写
+	Skipped bool        `json:"skipped,omitempty" mapstructure:"-"` // 审批通过时当天已有该打卡记录，补卡未写入
 }
 
 type Leave struct {
-	Type      int     `json:"type,omitempty" mapstructure:"type,omitempty"`           //请假类型
-	StartTime int64   `json:"startTime,omitempty" mapstructure:"startTime,omitempty"` //开始时间
-	EndTime   int64   `json:"endTime,omitempty" mapstructure:"endTime,omitempty"`     //结束时间
-	Duration  float32 `json:"duration,omitempty" mapstructure:"duration,omitempty"`   //时长
-	Reason    string  `json:"reason,omitempty" mapstructure:"reason,omitempty"`       //请假原由
-	TimeType  int     `json:"timeType,omitempty" mapstructure:"timeType,omitempty"`   //请假类型  1=小时 2=天
+	Type      int     `json:"type,omitempty" mapstructure:"type,omitempty" binding:"min=1,max=9"`                        //请假类型
+	StartTime int64   `json:"startTime,omitempty" mapstructure:"startTime,omitempty" binding:"timestamp"`                //开始时间
+	EndTime   int64   `json:"endTime,omitempty" mapstructure:"endTime,omitempty" binding:"timestamp,gtefield=StartTime"` //结束时间
+	Duration  float32 `json:"duration,omitempty" mapstructure:"duration,omitempty"`                                      //时长
+	Reason    string  `json:"reason,omitempty" mapstructure:"reason,omitempty"`                                          //请假原由
+	TimeType  int     `json:"timeType,omitempty" mapstructure:"timeType,omitempty" binding:"omitempty,oneof=1 2"`        //请假类型  1=小时 2=天
 }
 
 type GoOut struct {
-	StartTime int64   `json:"startTime,omitempty" mapstructure:"omitempty"` //开始时间
-	EndTime   int64   `json:"endTime,omitempty" mapstructure:"omitempty"`   //结束时间
-	Duration  float32 `json:"duration,omitempty" mapstructure:"omitempty"`  //时长
-	Reason    string  `json:"reason,omitempty" mapstructure:"omitempty"`    //请假原由
+	StartTime int64   `json:"startTime,omitempty" mapstructure:"omitempty" binding:"timestamp"`                  //开始时间
+	EndTime   int64   `json:"endTime,omitempty" mapstructure:"omitempty" binding:"timestamp,gtefield=StartTime"` //结束时间
+	Duration  float32 `json:"duration,omitempty" mapstructure:"omitempty"`                                       //时长
+	Reason    string  `json:"reason,omitempty" mapstructure:"omitempty"`                                         //请假原由
 }
 
 type Approval struct {
 	Id          string    `json:"id,omitempty"`
 	UserId      string    `json:"userId,omitempty"`
 	No          string    `json:"no,omitempty"`
-	Type        int       `json:"type,omitempty"`
+	Type        int       `json:"type,omitempty" binding:"min=1,max=12"`
 	Status      int       `json:"status,omitempty"`
-	Title       string    `json:"title,omitempty"`
-	Abstract    string    `json:"abstract,omitempty"`
-	Reason      string    `json:"reason,omitempty"`
+	Title       string    `json:"title,omitempty" binding:"max=100"`
+	Abstract    string    `json:"abstract,omitempty" binding:"max=500"`
+	Reason      string    `json:"reason,omitempty" binding:"max=2000"`
 	FinishAt    int64     `json:"finishAt,omitempty"`
 	FinishDay   int64     `json:"finishDay,omitempty"`
 	FinishMonth int64     `json:"finishMonth,omitempty"`
@@ -245,16 +258,17 @@ type ApprovalInfoResp struct {
 }
 
 type DisposeReq struct {
-	Status     int
-	Reason     string
-	ApprovalId string
+	Status     int    `binding:"oneof=2 3"` // 2=通过 3=拒绝
+	Reason     string `binding:"max=500"`
+	ApprovalId string `binding:"required"`
 }
 
 type ApprovalListReq struct {
 	UserId string `json:"userId,omitempty"`
 	Type   int    `json:"type,omitempty"`
-	Page   int    `json:"page,omitempty"`
-	Count  int    `json:"count,omitempty"`
+	Page   int    `json:"page,omitempty" binding:"min=0"`
+	Count  int    `json:"count,omitempty" binding:"min=0"` // 每页数量，最大100
+	Sort   string `json:"sort,omitempty"`                  // 排序 createAt/updateAt，前缀-为倒序，默认 -createAt
 }
 
 type ApprovalList struct {
@@ -278,20 +292,528 @@ type ChatReq struct {
 	Prompts    string `json:"prompts,omitempty"`
 	ChatType   int    `json:"chatType,omitempty"`
 	RelationId int    `json:"relationId,omitempty"`
+
+	ConversationId string `json:"conversationId,omitempty"` // 当前所在的会话，群聊总结等需要会话上下文的功能使用
+
+	Images []string `json:"images,omitempty" binding:"max=4"` // 图片，网络地址或上传接口返回的file，最多4张
+
+	// 可选的模型参数，不传使用默认配置
+	Model       string   `json:"model,omitempty"`                                        // 模型名称，需在配置的白名单内
+	Temperature *float64 `json:"temperature,omitempty" binding:"omitempty,min=0,max=2"`  // 0~2
+	MaxTokens   int      `json:"maxTokens,omitempty" binding:"omitempty,min=1,max=8192"` // 最大输出token数
+
+	Debug bool `json:"debug,omitempty"` // 返回路由结果、工具调用和耗时，需配置LangChain.Debug
 }
 
 type ChatResp struct {
 	ChatType int         `json:"chatType,omitempty"`
 	Data     interface{} `json:"data"`
+	Debug    *ChatDebug  `json:"debug,omitempty"` // 请求debug且配置允许时返回
+}
+
+// ChatDebug AI对话的执行过程，用于排查路由和工具选择问题
+type ChatDebug struct {
+	Handler        string        `json:"handler"`        // 最终使用的handler，cache表示命中回复缓存
+	RouterOutput   string        `json:"routerOutput"`   // 路由模型的原始输出
+	RouterDuration int64         `json:"routerDuration"` // 路由耗时（毫秒）
+	ToolCalls      []*AIToolCall `json:"toolCalls"`
+	Duration       int64         `json:"duration"` // 总耗时（毫秒）
+}
+
+type AIUsageReq struct {
+	Days int `json:"days,omitempty" form:"days" binding:"min=0"` // 查询最近几天，默认7天
+}
+
+type AIUsage struct {
+	Date             string `json:"date"`
+	PromptTokens     int64  `json:"promptTokens"`
+	CompletionTokens int64  `json:"completionTokens"`
+	TotalTokens      int64  `json:"totalTokens"`
+	Calls            int64  `json:"calls"`
+}
+
+type AIUsageResp struct {
+	DailyQuota int64      `json:"dailyQuota"` // 每日额度，0为不限制
+	Today      *AIUsage   `json:"today"`
+	List       []*AIUsage `json:"list"`
+}
+
+type AIHistoryReq struct {
+	Page  int `json:"page,omitempty" form:"page" binding:"min=0"`   // 页码
+	Count int `json:"count,omitempty" form:"count" binding:"min=0"` // 每页数量
+}
+
+type AIToolCall struct {
+	Name     string `json:"name"`
+	Input    string `json:"input,omitempty"`
+	Output   string `json:"output,omitempty"`
+	Error    string `json:"error,omitempty"`
+	Duration int64  `json:"duration"` // 耗时（毫秒）
+}
+
+type AIHistory struct {
+	Id        string        `json:"id"`
+	Prompt    string        `json:"prompt"`              // 用户输入
+	Handler   string        `json:"handler,omitempty"`   // 路由选择的handler
+	ToolCalls []*AIToolCall `json:"toolCalls,omitempty"` // 工具调用
+	Answer    string        `json:"answer,omitempty"`    // 最终回复
+	Error     string        `json:"error,omitempty"`     // 失败原因
+	Duration  int64         `json:"duration"`            // 耗时（毫秒）
+	CreateAt  int64         `json:"createAt"`
+}
+
+type AIHistoryResp struct {
+	Count int64        `json:"count"` // 总记录数
+	List  []*AIHistory `json:"data"`
+}
+
+// ClearAIMemoryReq 清空AI记忆，不传时清空私聊助手的记忆
+type ClearAIMemoryReq struct {
+	ConversationId string `json:"conversationId,omitempty" form:"conversationId"`
+	RelationId     int    `json:"relationId,omitempty" form:"relationId"`
+}
+
+// ChatHistoryReq 聊天记录查询，私聊传recvId，群聊和AI对话传conversationId
+type ChatHistoryReq struct {
+	ConversationId string `json:"conversationId,omitempty" form:"conversationId"`
+	RecvId         string `json:"recvId,omitempty" form:"recvId"`               // 私聊对方用户Id
+	Cursor         string `json:"cursor,omitempty" form:"cursor"`               // 上一页返回的nextCursor，为空查询最新消息
+	Limit          int    `json:"limit,omitempty" form:"limit" binding:"min=0"` // 每页数量，最大100
+}
+
+type ChatHistory struct {
+	Id         string `json:"id"`
+	SendId     string `json:"sendId"`
+	RecvId     string `json:"recvId,omitempty"`
+	ChatType   int    `json:"chatType"`
+	MsgContent string `json:"msgContent"`
+	SendTime   int64  `json:"sendTime"`
+}
+
+type ChatHistoryResp struct {
+	List       []*ChatHistory `json:"data"`                 // 按发送时间倒序
+	NextCursor string         `json:"nextCursor,omitempty"` // 为空表示没有更早的消息
+}
+
+// KnowledgeDocument 知识库文档
+type KnowledgeDocument struct {
+	Id         string `json:"id"`
+	Name       string `json:"name"`
+	UploaderId string `json:"uploaderId"`
+	DepId      string `json:"depId"`      // 所属部门，为空表示公共知识库
+	Index      string `json:"index"`      // 向量索引名称
+	Version    int    `json:"version"`    // 同名文件重新上传的次数，首次为1
+	ChunkCount int    `json:"chunkCount"` // 向量块数量
+	Status     int    `json:"status"`     // 1=等待处理 2=处理中 3=已入库 4=失败
+	Error      string `json:"error,omitempty"`
+	CreateAt   int64  `json:"createAt"`
+}
+
+type KnowledgePreviewReq struct {
+	Id     string `uri:"id,omitempty" binding:"required"`
+	Offset int    `form:"offset" binding:"min=0"` // 从第几个字符开始，用于分段加载长文档
+	Limit  int    `form:"limit" binding:"min=0"`  // 返回的字符数，默认5000，最多20000
+}
+
+type KnowledgePreviewResp struct {
+	Id      string `json:"id"`
+	Name    string `json:"name"`
+	Content string `json:"content"` // 提取的文本
+	Total   int    `json:"total"`   // 全文字符数
+	More    bool   `json:"more"`    // 后面是否还有内容
+}
+
+type KnowledgeQueryReq struct {
+	Query string `json:"query" binding:"required,max=2000"`
+	DepId string `json:"depId,omitempty"` // 只检索指定部门的知识库，为空检索公共知识库和所在部门的知识库
+}
+
+type KnowledgeQueryResp struct {
+	Answer     string             `json:"answer"`
+	Confidence float64            `json:"confidence"` // 0-1，最相关的引用内容与问题的语义相似度
+	Sources    []*KnowledgeSource `json:"sources"`
+}
+
+// KnowledgeSource 回答引用的文档片段
+type KnowledgeSource struct {
+	DocumentId string  `json:"documentId,omitempty"` // 知识库文档id，文档记录不存在时为空
+	Name       string  `json:"name"`                 // 文件名
+	Content    string  `json:"content"`
+	Score      float64 `json:"score"` // 与问题的语义相似度
+}
+
+type KnowledgeDocListReq struct {
+	Name  string `json:"name,omitempty" form:"name"`                   // 按文件名模糊查询
+	DepId string `json:"depId,omitempty" form:"depId"`                 // 按部门查询，为空查询有权限的全部文档
+	Page  int    `json:"page,omitempty" form:"page" binding:"min=0"`   // 页码
+	Count int    `json:"count,omitempty" form:"count" binding:"min=0"` // 每页数量
+}
+
+type KnowledgeDocListResp struct {
+	Count int64                `json:"count"`
+	List  []*KnowledgeDocument `json:"data"`
+}
+
+// ASRResp 语音识别结果
+type ASRResp struct {
+	Text string `json:"text"`
+}
+
+// TTSReq 语音合成请求
+type TTSReq struct {
+	Text   string `json:"text" binding:"required,max=1000"`                                 // 合成的文本，最多1000字
+	Voice  string `json:"voice,omitempty"`                                                  // 音色，为空使用配置的默认音色
+	Format string `json:"format,omitempty" binding:"omitempty,oneof=mp3 wav opus aac flac"` // mp3/wav/opus/aac/flac，默认mp3
+}
+
+// ChatStreamChunk 流式对话的增量内容
+type ChatStreamChunk struct {
+	Content string `json:"content"`
 }
 
 type FileResp struct {
-	Host      string `json:"host"`      // 文件访问主机地址
-	File      string `json:"file"`      // 文件相对路径
-	Filename  string `json:"filename"`  // 文件名称
-	Knowledge bool   `json:"knowledge"` // 是否已入知识库
+	Host      string `json:"host"`            // 文件访问主机地址
+	File      string `json:"file"`            // 文件相对路径
+	Filename  string `json:"filename"`        // 文件名称
+	Thumb     string `json:"thumb,omitempty"` // 图片缩略图相对路径，与File使用相同的Host访问
+	Knowledge bool   `json:"knowledge"`       // 是否已提交入知识库
+
+	KnowledgeId string `json:"knowledgeId,omitempty"` // 知识库文档id，用于查询处理状态
 }
 
 type FileListResp struct {
 	List []*FileResp `json:"list"` // 文件列表
 }
+
+type DeviceReq struct {
+	Platform string `json:"platform" binding:"omitempty,oneof=fcm apns"` // 推送平台 fcm/apns，注册时必填
+	Token    string `json:"token" binding:"required"`                    // 设备令牌
+}
+
+// NotifySetting 提醒偏好，时间均为 HH:MM
+type NotifySetting struct {
+	ReminderTime string   `json:"reminderTime" binding:"omitempty,datetime=15:04"` // 待办提醒时间，为空使用全局的提醒时间
+	QuietStart   string   `json:"quietStart" binding:"omitempty,datetime=15:04"`   // 免打扰开始时间，可跨零点，如 22:00
+	QuietEnd     string   `json:"quietEnd" binding:"omitempty,datetime=15:04"`     // 免打扰结束时间，如 08:00
+	Channels     []string `json:"channels"`                                        // 离线推送渠道，为空使用全部渠道
+}
+
+type NotifySettingResp struct {
+	NotifySetting
+	AvailableChannels []string `json:"availableChannels"` // 服务端已配置的离线推送渠道
+}
+
+type ScheduledTask struct {
+	Id       string `json:"id,omitempty" uri:"id,omitempty"`
+	Name     string `json:"name" binding:"required,max=100"`
+	Cron     string `json:"cron" binding:"required"`          // cron 表达式（分 时 日 月 周），按定时任务的时区计算
+	TaskType string `json:"taskType" binding:"required"`      // 任务类型，可选值见列表接口返回的 types
+	Payload  string `json:"payload" binding:"omitempty,json"` // 任务载荷（JSON），为空时为 {}
+	Queue    string `json:"queue"`                            // 为空使用任务类型的默认队列
+	Enabled  bool   `json:"enabled"`
+	UpdateAt int64  `json:"updateAt,omitempty"`
+}
+
+type ScheduledTaskListResp struct {
+	List  []*ScheduledTask `json:"list"`
+	Types []string         `json:"types"` // 可添加的任务类型
+}
+
+// AuditLogListReq 审计记录查询，时间为秒级时间戳
+type AuditLogListReq struct {
+	ActorId    string `json:"actorId,omitempty" form:"actorId"`
+	Resource   string `json:"resource,omitempty" form:"resource"` // 资源类型，如 todo、user、dep
+	ResourceId string `json:"resourceId,omitempty" form:"resourceId"`
+	StartTime  int64  `json:"startTime,omitempty" form:"startTime" binding:"omitempty,timestamp"`
+	EndTime    int64  `json:"endTime,omitempty" form:"endTime" binding:"omitempty,timestamp"`
+	Page       int    `json:"page,omitempty" form:"page" binding:"min=0"`
+	Count      int    `json:"count,omitempty" form:"count" binding:"min=0"` // 每页数量，最大100
+}
+
+type AuditLog struct {
+	Id         string `json:"id"`
+	ActorId    string `json:"actorId"`
+	ActorName  string `json:"actorName,omitempty"`
+	Action     string `json:"action"`
+	Resource   string `json:"resource"`
+	ResourceId string `json:"resourceId,omitempty"`
+	Before     string `json:"before,omitempty"`  // 变更前的数据（json）
+	After      string `json:"after,omitempty"`   // 变更后的数据（json）
+	Payload    string `json:"payload,omitempty"` // 请求参数（json，已脱敏）
+	Ip         string `json:"ip"`
+	RequestId  string `json:"requestId,omitempty"`
+	Status     int    `json:"status"`
+	CreateAt   int64  `json:"createAt"`
+}
+
+type AuditLogListResp struct {
+	Count int64       `json:"count"`
+	List  []*AuditLog `json:"data"`
+}
+
+// RetentionReport 一次过期数据清理的结果，DryRun 时为将被清理的数量
+type RetentionReport struct {
+	DryRun      bool     `json:"dryRun"`
+	ChatLogs    int64    `json:"chatLogs"`
+	Todos       int64    `json:"todos"`
+	UserTodos   int64    `json:"userTodos"`   // 随待办删除的执行人记录
+	TodoRecords int64    `json:"todoRecords"` // 随待办删除的操作记录
+	Approvals   int64    `json:"approvals"`
+	AuditLogs   int64    `json:"auditLogs"`
+	Errors      []string `json:"errors,omitempty"`
+}
+
+// SeedReport 本次写入的示例数据数量，已存在的数据不计入
+type SeedReport struct {
+	Users       int      `json:"users"`
+	Departments int      `json:"departments"`
+	Todos       int      `json:"todos"`
+	Approvals   int      `json:"approvals"`
+	Documents   int      `json:"documents"` // 提交入库的知识库文档，内容未变化时不会重复入库
+	Errors      []string `json:"errors,omitempty"`
+}
+
+type CalendarProviderReq struct {
+	Provider string `json:"provider" form:"provider" binding:"required,oneof=caldav exchange"` // caldav/exchange
+}
+
+type CalendarBindReq struct {
+	Provider string `json:"provider" binding:"required,oneof=caldav exchange"` // caldav/exchange
+
+	// caldav: 日历集合地址和账号，建议使用应用专用密码
+	Url      string `json:"url,omitempty" binding:"required_if=Provider caldav,omitempty,url"`
+	Username string `json:"username,omitempty" binding:"required_if=Provider caldav"`
+	Password string `json:"password,omitempty" binding:"required_if=Provider caldav"`
+
+	// exchange: 授权回调中的code和state
+	Code  string `json:"code,omitempty" binding:"required_if=Provider exchange"`
+	State string `json:"state,omitempty" binding:"required_if=Provider exchange"`
+}
+
+type CalendarAuthUrlResp struct {
+	Url   string `json:"url"`
+	State string `json:"state"` // 回调后随code一起提交到绑定接口，服务端校验防止CSRF
+}
+
+type CalendarAccount struct {
+	Provider   string `json:"provider"`
+	Bound      bool   `json:"bound"`
+	LastSyncAt int64  `json:"lastSyncAt,omitempty"`
+	LastError  string `json:"lastError,omitempty"`
+}
+
+type CalendarAccountResp struct {
+	List []*CalendarAccount `json:"list"`
+}
+
+type CalendarSyncResp struct {
+	Pushed int `json:"pushed"` // 推送到外部日历的待办数
+	Pulled int `json:"pulled"` // 从外部日历更新回来的待办数
+}
+
+type CalendarEventsReq struct {
+	StartTime int64 `json:"startTime,omitempty" form:"startTime" binding:"omitempty,timestamp"` // 默认今天0点
+	EndTime   int64 `json:"endTime,omitempty" form:"endTime" binding:"omitempty,timestamp"`     // 默认7天后
+}
+
+type CalendarEvent struct {
+	Provider  string `json:"provider"`
+	Id        string `json:"id"`
+	Title     string `json:"title"`
+	Desc      string `json:"desc,omitempty"`
+	Location  string `json:"location,omitempty"`
+	StartTime int64  `json:"startTime"`
+	EndTime   int64  `json:"endTime"`
+	TodoId    string `json:"todoId,omitempty"` // 由待办同步过去的事件
+}
+
+type CalendarEventsResp struct {
+	List []*CalendarEvent `json:"list"`
+}
+
+type AttendanceCheckReq struct {
+	CheckType int `json:"workCheckType" binding:"oneof=1 2"` // 1=上班卡 2=下班卡
+}
+
+type AttendanceDayReq struct {
+	Day int64 `json:"day,omitempty" form:"day" binding:"omitempty,min=19700101,max=99991231"` // 日期(20240530)，默认今天
+}
+
+type AttendanceMonthReq struct {
+	Month string `json:"month,omitempty" form:"month" binding:"omitempty,datetime=2006-01"` // 月份(2024-05)，默认本月
+}
+
+type Attendance struct {
+	Day                int64  `json:"day"`
+	CheckIn            int64  `json:"checkIn,omitempty"`
+	CheckInSource      int    `json:"checkInSource,omitempty"` // 1=打卡 2=补卡
+	CheckInApprovalId  string `json:"checkInApprovalId,omitempty"`
+	CheckOut           int64  `json:"checkOut,omitempty"`
+	CheckOutSource     int    `json:"checkOutSource,omitempty"`
+	CheckOutApprovalId string `json:"checkOutApprovalId,omitempty"`
+	Late               bool   `json:"late"`       // 迟到
+	Early              bool   `json:"early"`      // 早退
+	MissingIn          bool   `json:"missingIn"`  // 缺上班卡
+	MissingOut         bool   `json:"missingOut"` // 缺下班卡
+	Normal             bool   `json:"normal"`     // 考勤正常，当天未结束时为false
+}
+
+type AttendanceMonthResp struct {
+	Month    string        `json:"month"`
+	WorkDays int           `json:"workDays"` // 截至今天的工作日（周一至周五）天数
+	Normal   int           `json:"normal"`
+	Late     int           `json:"late"`
+	Early    int           `json:"early"`
+	Missing  int           `json:"missing"` // 缺卡天数，含没有任何打卡的工作日
+	Days     []*Attendance `json:"days"`    // 每个工作日以及有打卡的休息日
+}
+
+type EventRecurrence struct {
+	Freq     string `json:"freq" binding:"oneof=daily weekly monthly yearly"`
+	Interval int    `json:"interval,omitempty" binding:"min=0,max=365"` // 间隔，默认1
+	Until    int64  `json:"until,omitempty" binding:"omitempty,timestamp"`
+	Count    int    `json:"count,omitempty" binding:"min=0,max=1000"` // 共发生几次，0不限
+}
+
+type Event struct {
+	Id           string           `json:"id,omitempty"`
+	UserId       string           `json:"userId,omitempty"` // 创建人，创建时为当前用户
+	Title        string           `json:"title" binding:"required,max=200"`
+	Desc         string           `json:"desc,omitempty" binding:"max=2000"`
+	Location     string           `json:"location,omitempty" binding:"max=200"`
+	StartTime    int64            `json:"startTime" binding:"timestamp"`
+	EndTime      int64            `json:"endTime" binding:"timestamp,gtefield=StartTime"`
+	AllDay       bool             `json:"allDay,omitempty"`
+	Participants []string         `json:"participants,omitempty" binding:"max=100"`
+	DepId        string           `json:"depId,omitempty"` // 共享到部门，部门成员都可查看
+	Recurrence   *EventRecurrence `json:"recurrence,omitempty"`
+	Remind       int              `json:"remind,omitempty" binding:"min=0,max=10080"` // 提前几分钟提醒，0不提醒
+	UpdateAt     int64            `json:"updateAt,omitempty"`
+	CreateAt     int64            `json:"createAt,omitempty"`
+}
+
+type CalendarViewReq struct {
+	StartTime int64 `json:"startTime,omitempty" form:"startTime" binding:"omitempty,timestamp"` // 默认今天0点
+	EndTime   int64 `json:"endTime,omitempty" form:"endTime" binding:"omitempty,timestamp"`     // 默认7天后，最长92天
+}
+
+// 日历视图中的条目类型
+const (
+	CalendarItemEvent = "event" // 日程
+	CalendarItemTodo  = "todo"  // 待办截止
+	CalendarItemLeave = "leave" // 已通过的请假
+)
+
+type CalendarItem struct {
+	Kind      string `json:"kind"` // event todo leave
+	Id        string `json:"id"`   // 日程、待办或审批ID
+	Title     string `json:"title"`
+	Location  string `json:"location,omitempty"`
+	StartTime int64  `json:"startTime"` // 重复日程为这一次的开始时间
+	EndTime   int64  `json:"endTime"`   // 待办为截止时间
+	AllDay    bool   `json:"allDay,omitempty"`
+	Owner     bool   `json:"owner,omitempty"` // 日程是否由当前用户创建
+}
+
+type CalendarViewResp struct {
+	List []*CalendarItem `json:"list"`
+}
+
+type Announcement struct {
+	Id          string   `json:"id,omitempty"`
+	Title       string   `json:"title" binding:"required,max=200"`
+	Content     string   `json:"content" binding:"required,max=20000"`
+	PublisherId string   `json:"publisherId,omitempty"`
+	DepIds      []string `json:"depIds,omitempty" binding:"max=100"` // 目标部门，为空发给全员
+	Pinned      bool     `json:"pinned,omitempty"`
+	PublishAt   int64    `json:"publishAt,omitempty" binding:"omitempty,timestamp"` // 发布时间，默认立即发布
+	ExpireAt    int64    `json:"expireAt,omitempty" binding:"omitempty,timestamp"`  // 过期时间，0不过期
+	Read        bool     `json:"read"`                                              // 当前用户是否已读
+	ReadCount   int64    `json:"readCount,omitempty"`                               // 已读人数，仅管理列表返回
+	UpdateAt    int64    `json:"updateAt,omitempty"`
+	CreateAt    int64    `json:"createAt,omitempty"`
+}
+
+type AnnouncementListReq struct {
+	Page  int `json:"page,omitempty" binding:"min=0"`
+	Count int `json:"count,omitempty" binding:"min=0"` // 每页数量，最大100
+}
+
+type AnnouncementListResp struct {
+	Count  int64           `json:"count"`
+	Unread int             `json:"unread"` // 当前页中的未读数
+	List   []*Announcement `json:"data"`
+}
+
+type NotifyCenterResp struct {
+	UnreadAnnouncements int             `json:"unreadAnnouncements"` // 最近公告中的未读数
+	Announcements       []*Announcement `json:"announcements"`       // 未读公告，置顶在前
+}
+
+type WorkflowCallback struct {
+	Event string `json:"event" binding:"oneof=pass refuse cancel"` // 流程结束的结果
+	Url   string `json:"url" binding:"required,url"`
+}
+
+type WorkflowDefinition struct {
+	Id        string               `json:"id,omitempty"`
+	Key       string               `json:"key" binding:"required,max=50"` // 流程标识，发起时使用，创建后不能修改
+	Name      string               `json:"name" binding:"required,max=100"`
+	Desc      string               `json:"desc,omitempty" binding:"max=500"`
+	Enabled   bool                 `json:"enabled"`
+	Version   int                  `json:"version,omitempty"`
+	Flow      *workflow.Definition `json:"flow" binding:"required"`
+	Callbacks []*WorkflowCallback  `json:"callbacks,omitempty" binding:"max=10,dive"`
+	UpdateAt  int64                `json:"updateAt,omitempty"`
+	CreateAt  int64                `json:"createAt,omitempty"`
+}
+
+type WorkflowDefinitionListReq struct {
+	All bool `json:"all,omitempty" form:"all"` // 包含已停用的流程，仅管理员
+}
+
+type WorkflowDefinitionListResp struct {
+	List []*WorkflowDefinition `json:"list"`
+}
+
+type WorkflowStartReq struct {
+	Key   string         `json:"key" binding:"required"`
+	Title string         `json:"title,omitempty" binding:"max=100"` // 默认使用流程名称
+	Form  map[string]any `json:"form,omitempty"`
+}
+
+type WorkflowDisposeReq struct {
+	InstanceId string `json:"instanceId" binding:"required"`
+	Status     int    `json:"status" binding:"oneof=2 3"` // 2=通过 3=拒绝
+	Reason     string `json:"reason,omitempty" binding:"max=500"`
+}
+
+type WorkflowListReq struct {
+	Type  int    `json:"type,omitempty" binding:"omitempty,oneof=1 2 3"` // 1=我发起的 2=待我处理的 3=我参与过的
+	Key   string `json:"key,omitempty"`
+	Page  int    `json:"page,omitempty" binding:"min=0"`
+	Count int    `json:"count,omitempty" binding:"min=0"` // 每页数量，最大100
+	Sort  string `json:"sort,omitempty"`                  // 排序 createAt/updateAt，前缀-为倒序，默认 -createAt
+}
+
+type WorkflowInstance struct {
+	Id       string             `json:"id"`
+	No       string             `json:"no"`
+	Key      string             `json:"key"`
+	Name     string             `json:"name"`
+	UserId   string             `json:"userId"`
+	Title    string             `json:"title"`
+	Status   int                `json:"status"` // 1=进行中 2=通过 3=拒绝 4=撤销
+	Pending  []string           `json:"pending,omitempty"`
+	Form     map[string]any     `json:"form,omitempty"`
+	Tasks    []*workflow.Task   `json:"tasks,omitempty"`   // 待处理的审批节点，仅详情返回
+	Records  []*workflow.Record `json:"records,omitempty"` // 处理记录，仅详情返回
+	FinishAt int64              `json:"finishAt,omitempty"`
+	UpdateAt int64              `json:"updateAt,omitempty"`
+	CreateAt int64              `json:"createAt,omitempty"`
+}
+
+type WorkflowListResp struct {
+	Count int64               `json:"count"`
+	List  []*WorkflowInstance `json:"data"`
+}
@@ -1,10 +1,18 @@
 package domain
 
 type Message struct {
-	ConversationId string `json:"conversationId"` //会话Id
-	RecvId         string `json:"recvId"`         //接收Id
-	SendId         string `json:"sendId"`         //发送Id
-	ChatType       int    `json:"chatType"`       //chat类型 1=群聊 2=私聊
-	Content        string `json:"content"`        //聊天内容
-	ContentType    int    `json:"contentType"`    //聊天类型 1=文字 2=图片 3=表情包等
+	ConversationId string   `json:"conversationId"`   //会话Id
+	RecvId         string   `json:"recvId"`           //接收Id
+	SendId         string   `json:"sendId"`           //发送Id
+	ChatType       int      `json:"chatType"`         //chat类型 1=群聊 2=私聊 3=AI对话
+	Content        string   `json:"content"`          //聊天内容
+	ContentType    int      `json:"contentType"`      //聊天类型 1=文字 2=图片 3=表情包等
+	Images         []string `json:"images,omitempty"` //AI对话附带的图片
+}
+
+// WsNotice 服务端下发给客户端的提示（限流等）
+type WsNotice struct {
+	Type string `json:"type"` // 固定为 notice
+	Code string `json:"code"` // 提示码，如 rate_limited
+	Msg  string `json:"msg"`  // 提示内容
 }
@@ -1,20 +1,34 @@
 package handler
 
 import (
+	"errors"
+	"net/http"
+
+	"aiOffice/internal/model"
+	"aiOffice/pkg/i18n"
+	"aiOffice/pkg/requestid"
+	"aiOffice/pkg/xerr"
+
+	"gitee.com/dn-jinmin/tlog"
 	"github.com/gin-gonic/gin"
 )
 
-type cause interface {
-	Cause() error
-}
-
+// ErrorHandler 按错误码返回http状态，只向客户端返回最内层的错误信息（去掉说明和调用栈），并按请求语言翻译；
+// 完整的错误链记录在请求日志中，服务端错误另外记录调用栈
 func ErrorHandler(ctx *gin.Context, err error) (int, error) {
-	var e error
-	if ce, ok := err.(cause); ok {
-		e = ce.Cause()
-	} else {
-		e = err
+	code := xerr.CodeOf(err)
+	if code == xerr.Internal && errors.Is(err, model.ErrNotFound) {
+		code = xerr.NotFound
 	}
 
-	return 500, e
+	msg := xerr.Message(err)
+	if ctx.Request != nil {
+		reqCtx := ctx.Request.Context()
+		_ = ctx.Error(err)
+		if code.Status() >= http.StatusInternalServerError {
+			tlog.ErrorCtx(reqCtx, "http.error", requestid.Fields(reqCtx, "err", err.Error(), "stack", xerr.Stack(err))...)
+		}
+		msg = i18n.T(reqCtx, msg)
+	}
+	return code.Status(), xerr.WithCode(errors.New(msg), code)
 }
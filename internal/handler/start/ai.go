@@ -0,0 +1,43 @@
+package start
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"aiOffice/internal/domain"
+	"aiOffice/internal/logic"
+	"aiOffice/internal/svc"
+	"aiOffice/pkg/httpx"
+)
+
+type AI struct {
+	svcCtx *svc.ServiceContext
+	ai     logic.AI
+}
+
+func NewAI(svcCtx *svc.ServiceContext, ai logic.AI) *AI {
+	return &AI{
+		svcCtx: svcCtx,
+		ai:     ai,
+	}
+}
+
+func (h *AI) InitRegister(r *Router) {
+	g := r.Group(V1, "ai", h.svcCtx.Jwt.Handler)
+	g.GET("/usage", h.Usage)
+}
+
+// 当前用户的token用量
+func (h *AI) Usage(ctx *gin.Context) {
+	var req domain.AIUsageReq
+	if err := httpx.BindAndValidate(ctx, &req); err != nil {
+		httpx.FailWithErr(ctx, err)
+		return
+	}
+
+	res, err := h.ai.Usage(ctx.Request.Context(), &req)
+	if err != nil {
+		httpx.FailWithErr(ctx, err)
+	} else {
+		httpx.OkWithData(ctx, res)
+	}
+}
@@ -0,0 +1,128 @@
+package start
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"aiOffice/internal/domain"
+	"aiOffice/internal/logic"
+	"aiOffice/internal/svc"
+	"aiOffice/pkg/httpx"
+)
+
+// Announcement 公司公告，发布和管理只允许管理员操作
+type Announcement struct {
+	svcCtx       *svc.ServiceContext
+	announcement logic.Announcement
+}
+
+func NewAnnouncement(svcCtx *svc.ServiceContext, announcement logic.Announcement) *Announcement {
+	return &Announcement{
+		svcCtx:       svcCtx,
+		announcement: announcement,
+	}
+}
+
+func (h *Announcement) InitRegister(r *Router) {
+	g := r.Group(V1, "announcement", h.svcCtx.Jwt.Handler)
+	g.POST("/list", h.List)
+	g.GET("/:id", h.Info)
+
+	admin := g.Group("", h.svcCtx.Admin.Handler)
+	admin.POST("", h.Create)
+	admin.PUT("", h.Edit)
+	admin.DELETE("/:id", h.Delete)
+	admin.POST("/manage", h.Manage)
+}
+
+// 当前用户可见的公告，置顶在前
+func (h *Announcement) List(ctx *gin.Context) {
+	var req domain.AnnouncementListReq
+	if err := httpx.BindAndValidate(ctx, &req); err != nil {
+		httpx.FailWithErr(ctx, err)
+		return
+	}
+
+	res, err := h.announcement.List(ctx.Request.Context(), &req)
+	if err != nil {
+		httpx.FailWithErr(ctx, err)
+	} else {
+		httpx.OkWithData(ctx, res)
+	}
+}
+
+// 查看公告并标记已读
+func (h *Announcement) Info(ctx *gin.Context) {
+	var req domain.IdPathReq
+	if err := httpx.BindAndValidate(ctx, &req); err != nil {
+		httpx.FailWithErr(ctx, err)
+		return
+	}
+
+	res, err := h.announcement.Info(ctx.Request.Context(), &req)
+	if err != nil {
+		httpx.FailWithErr(ctx, err)
+	} else {
+		httpx.OkWithData(ctx, res)
+	}
+}
+
+func (h *Announcement) Create(ctx *gin.Context) {
+	var req domain.Announcement
+	if err := httpx.BindAndValidate(ctx, &req); err != nil {
+		httpx.FailWithErr(ctx, err)
+		return
+	}
+
+	res, err := h.announcement.Create(ctx.Request.Context(), &req)
+	if err != nil {
+		httpx.FailWithErr(ctx, err)
+	} else {
+		httpx.OkWithData(ctx, res)
+	}
+}
+
+func (h *Announcement) Edit(ctx *gin.Context) {
+	var req domain.Announcement
+	if err := httpx.BindAndValidate(ctx, &req); err != nil {
+		httpx.FailWithErr(ctx, err)
+		return
+	}
+
+	err := h.announcement.Edit(ctx.Request.Context(), &req)
+	if err != nil {
+		httpx.FailWithErr(ctx, err)
+	} else {
+		httpx.Ok(ctx)
+	}
+}
+
+func (h *Announcement) Delete(ctx *gin.Context) {
+	var req domain.IdPathReq
+	if err := httpx.BindAndValidate(ctx, &req); err != nil {
+		httpx.FailWithErr(ctx, err)
+		return
+	}
+
+	err := h.announcement.Delete(ctx.Request.Context(), &req)
+	if err != nil {
+		httpx.FailWithErr(ctx, err)
+	} else {
+		httpx.Ok(ctx)
+	}
+}
+
+// 全部公告及已读人数
+func (h *Announcement) Manage(ctx *gin.Context) {
+	var req domain.AnnouncementListReq
+	if err := httpx.BindAndValidate(ctx, &req); err != nil {
+		httpx.FailWithErr(ctx, err)
+		return
+	}
+
+	res, err := h.announcement.Manage(ctx.Request.Context(), &req)
+	if err != nil {
+		httpx.FailWithErr(ctx, err)
+	} else {
+		httpx.OkWithData(ctx, res)
+	}
+}
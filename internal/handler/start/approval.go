@@ -7,6 +7,7 @@ import (
 	"aiOffice/internal/logic"
 	"aiOffice/internal/svc"
 	"aiOffice/pkg/httpx"
+	"aiOffice/pkg/pagex"
 )
 
 type Approval struct {
@@ -21,8 +22,8 @@ func NewApproval(svcCtx *svc.ServiceContext, approval logic.Approval) *Approval
 	}
 }
 
-func (h *Approval) InitRegister(engine *gin.Engine) {
-	g := engine.Group("v1/approval", h.svcCtx.Jwt.Handler)
+func (h *Approval) InitRegister(r *Router) {
+	g := r.Group(V1, "approval", h.svcCtx.Signature.Handler)
 	g.GET("/:id", h.Info)
 	g.POST("", h.Create)
 	g.PUT("/dispose", h.Dispose)
@@ -85,6 +86,6 @@ func (h *Approval) List(ctx *gin.Context) {
 	if err != nil {
 		httpx.FailWithErr(ctx, err)
 	} else {
-		httpx.OkWithData(ctx, res)
+		httpx.OkWithPage(ctx, res.List, res.Count, pagex.FromRequest(req.Page, req.Count, ""))
 	}
 }
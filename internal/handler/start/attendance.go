@@ -0,0 +1,77 @@
+package start
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"aiOffice/internal/domain"
+	"aiOffice/internal/logic"
+	"aiOffice/internal/svc"
+	"aiOffice/pkg/httpx"
+)
+
+type Attendance struct {
+	svcCtx     *svc.ServiceContext
+	attendance logic.Attendance
+}
+
+func NewAttendance(svcCtx *svc.ServiceContext, attendance logic.Attendance) *Attendance {
+	return &Attendance{
+		svcCtx:     svcCtx,
+		attendance: attendance,
+	}
+}
+
+func (h *Attendance) InitRegister(r *Router) {
+	g := r.Group(V1, "attendance", h.svcCtx.Jwt.Handler)
+	g.POST("/check", h.Check)
+	g.GET("/day", h.Day)
+	g.GET("/month", h.Month)
+}
+
+// 打卡
+func (h *Attendance) Check(ctx *gin.Context) {
+	var req domain.AttendanceCheckReq
+	if err := httpx.BindAndValidate(ctx, &req); err != nil {
+		httpx.FailWithErr(ctx, err)
+		return
+	}
+
+	res, err := h.attendance.Check(ctx.Request.Context(), &req)
+	if err != nil {
+		httpx.FailWithErr(ctx, err)
+	} else {
+		httpx.OkWithData(ctx, res)
+	}
+}
+
+// 某一天的考勤
+func (h *Attendance) Day(ctx *gin.Context) {
+	var req domain.AttendanceDayReq
+	if err := httpx.BindAndValidate(ctx, &req); err != nil {
+		httpx.FailWithErr(ctx, err)
+		return
+	}
+
+	res, err := h.attendance.Day(ctx.Request.Context(), &req)
+	if err != nil {
+		httpx.FailWithErr(ctx, err)
+	} else {
+		httpx.OkWithData(ctx, res)
+	}
+}
+
+// 月度考勤统计
+func (h *Attendance) Month(ctx *gin.Context) {
+	var req domain.AttendanceMonthReq
+	if err := httpx.BindAndValidate(ctx, &req); err != nil {
+		httpx.FailWithErr(ctx, err)
+		return
+	}
+
+	res, err := h.attendance.Month(ctx.Request.Context(), &req)
+	if err != nil {
+		httpx.FailWithErr(ctx, err)
+	} else {
+		httpx.OkWithData(ctx, res)
+	}
+}
@@ -0,0 +1,44 @@
+package start
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"aiOffice/internal/domain"
+	"aiOffice/internal/logic"
+	"aiOffice/internal/svc"
+	"aiOffice/pkg/httpx"
+	"aiOffice/pkg/pagex"
+)
+
+type Audit struct {
+	svcCtx *svc.ServiceContext
+	audit  logic.Audit
+}
+
+func NewAudit(svcCtx *svc.ServiceContext, audit logic.Audit) *Audit {
+	return &Audit{
+		svcCtx: svcCtx,
+		audit:  audit,
+	}
+}
+
+func (h *Audit) InitRegister(r *Router) {
+	g := r.Group(V1, "audit", h.svcCtx.Jwt.Handler, h.svcCtx.Admin.Handler)
+	g.GET("", h.List)
+}
+
+// List 分页查询审计记录
+func (h *Audit) List(ctx *gin.Context) {
+	var req domain.AuditLogListReq
+	if err := httpx.BindAndValidate(ctx, &req); err != nil {
+		httpx.FailWithErr(ctx, err)
+		return
+	}
+
+	res, err := h.audit.List(ctx.Request.Context(), &req)
+	if err != nil {
+		httpx.FailWithErr(ctx, err)
+	} else {
+		httpx.OkWithPage(ctx, res.List, res.Count, pagex.FromRequest(req.Page, req.Count, ""))
+	}
+}
@@ -0,0 +1,114 @@
+package start
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"aiOffice/internal/domain"
+	"aiOffice/internal/logic"
+	"aiOffice/internal/svc"
+	"aiOffice/pkg/httpx"
+)
+
+type Calendar struct {
+	svcCtx   *svc.ServiceContext
+	calendar logic.Calendar
+}
+
+func NewCalendar(svcCtx *svc.ServiceContext, calendar logic.Calendar) *Calendar {
+	return &Calendar{
+		svcCtx:   svcCtx,
+		calendar: calendar,
+	}
+}
+
+func (h *Calendar) InitRegister(r *Router) {
+	g := r.Group(V1, "calendar", h.svcCtx.Jwt.Handler)
+	g.GET("", h.Accounts)
+	g.GET("/auth-url", h.AuthUrl)
+	g.POST("/bind", h.Bind)
+	g.DELETE("/bind", h.Unbind)
+	g.POST("/sync", h.Sync)
+	g.GET("/events", h.Events)
+}
+
+// 日历绑定状态
+func (h *Calendar) Accounts(ctx *gin.Context) {
+	res, err := h.calendar.Accounts(ctx.Request.Context())
+	if err != nil {
+		httpx.FailWithErr(ctx, err)
+	} else {
+		httpx.OkWithData(ctx, res)
+	}
+}
+
+// Exchange授权地址
+func (h *Calendar) AuthUrl(ctx *gin.Context) {
+	var req domain.CalendarProviderReq
+	if err := httpx.BindAndValidate(ctx, &req); err != nil {
+		httpx.FailWithErr(ctx, err)
+		return
+	}
+
+	res, err := h.calendar.AuthUrl(ctx.Request.Context(), &req)
+	if err != nil {
+		httpx.FailWithErr(ctx, err)
+	} else {
+		httpx.OkWithData(ctx, res)
+	}
+}
+
+func (h *Calendar) Bind(ctx *gin.Context) {
+	var req domain.CalendarBindReq
+	if err := httpx.BindAndValidate(ctx, &req); err != nil {
+		httpx.FailWithErr(ctx, err)
+		return
+	}
+
+	err := h.calendar.Bind(ctx.Request.Context(), &req)
+	if err != nil {
+		httpx.FailWithErr(ctx, err)
+	} else {
+		httpx.Ok(ctx)
+	}
+}
+
+func (h *Calendar) Unbind(ctx *gin.Context) {
+	var req domain.CalendarProviderReq
+	if err := httpx.BindAndValidate(ctx, &req); err != nil {
+		httpx.FailWithErr(ctx, err)
+		return
+	}
+
+	err := h.calendar.Unbind(ctx.Request.Context(), &req)
+	if err != nil {
+		httpx.FailWithErr(ctx, err)
+	} else {
+		httpx.Ok(ctx)
+	}
+}
+
+// 立即同步
+func (h *Calendar) Sync(ctx *gin.Context) {
+	res, err := h.calendar.Sync(ctx.Request.Context())
+	if err != nil {
+		httpx.FailWithErr(ctx, err)
+	} else {
+		httpx.OkWithData(ctx, res)
+	}
+}
+
+// 外部日历中的日程
+func (h *Calendar) Events(ctx *gin.Context) {
+	var req domain.CalendarEventsReq
+	if err := httpx.BindAndValidate(ctx, &req); err != nil {
+		httpx.FailWithErr(ctx, err)
+		return
+	}
+
+	res, err := h.calendar.Events(ctx.Request.Context(), &req)
+	if err != nil {
+		httpx.FailWithErr(ctx, err)
+	} else {
+		httpx.OkWithData(ctx, res)
+	}
+}
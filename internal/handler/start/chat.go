@@ -1,29 +1,46 @@
 package start
 
 import (
+	"context"
+	"fmt"
+	"net/http"
+
 	"github.com/gin-gonic/gin"
 
 	"aiOffice/internal/domain"
 	"aiOffice/internal/logic"
 	"aiOffice/internal/svc"
 	"aiOffice/pkg/httpx"
+	"aiOffice/pkg/pagex"
+	"aiOffice/pkg/xerr"
 )
 
+// maxAudioSize 语音识别的音频大小上限
+const maxAudioSize = 25 << 20
+
 type Chat struct {
 	svcCtx *svc.ServiceContext
 	chat   logic.Chat
+	speech logic.Speech
 }
 
-func NewChat(svcCtx *svc.ServiceContext, chat logic.Chat) *Chat {
+func NewChat(svcCtx *svc.ServiceContext, chat logic.Chat, speech logic.Speech) *Chat {
 	return &Chat{
 		svcCtx: svcCtx,
 		chat:   chat,
+		speech: speech,
 	}
 }
 
-func (h *Chat) InitRegister(engine *gin.Engine) {
-	g := engine.Group("v1/chat", h.svcCtx.Jwt.Handler)
+func (h *Chat) InitRegister(r *Router) {
+	g := r.Group(V1, "chat", h.svcCtx.Jwt.Handler)
 	g.POST("", h.Chat)
+	g.POST("/ai/stream", h.AIStream)
+	g.GET("/history", h.History)
+	g.GET("/ai/history", h.AIHistory)
+	g.DELETE("/ai/memory", h.ClearAIMemory)
+	g.POST("/asr", h.ASR)
+	g.POST("/tts", h.TTS)
 }
 
 func (h *Chat) Chat(ctx *gin.Context) {
@@ -40,3 +57,114 @@ func (h *Chat) Chat(ctx *gin.Context) {
 		httpx.OkWithData(ctx, res)
 	}
 }
+
+// History 按游标分页查询聊天记录
+func (h *Chat) History(ctx *gin.Context) {
+	var req domain.ChatHistoryReq
+	if err := httpx.BindAndValidate(ctx, &req); err != nil {
+		httpx.FailWithErr(ctx, err)
+		return
+	}
+
+	res, err := h.chat.History(ctx.Request.Context(), &req)
+	if err != nil {
+		httpx.FailWithErr(ctx, err)
+	} else {
+		httpx.OkWithData(ctx, res)
+	}
+}
+
+// AIHistory 分页查询AI对话历史
+func (h *Chat) AIHistory(ctx *gin.Context) {
+	var req domain.AIHistoryReq
+	if err := httpx.BindAndValidate(ctx, &req); err != nil {
+		httpx.FailWithErr(ctx, err)
+		return
+	}
+
+	res, err := h.chat.AIHistory(ctx.Request.Context(), &req)
+	if err != nil {
+		httpx.FailWithErr(ctx, err)
+	} else {
+		httpx.OkWithPage(ctx, res.List, res.Count, pagex.FromRequest(req.Page, req.Count, ""))
+	}
+}
+
+// ClearAIMemory 清空AI对话记忆
+func (h *Chat) ClearAIMemory(ctx *gin.Context) {
+	var req domain.ClearAIMemoryReq
+	if err := httpx.BindAndValidate(ctx, &req); err != nil {
+		httpx.FailWithErr(ctx, err)
+		return
+	}
+	if err := h.chat.ClearAIMemory(ctx.Request.Context(), &req); err != nil {
+		httpx.FailWithErr(ctx, err)
+	} else {
+		httpx.Ok(ctx)
+	}
+}
+
+// ASR 上传音频（表单字段file）识别为文字
+func (h *Chat) ASR(ctx *gin.Context) {
+	ctx.Request.Body = http.MaxBytesReader(ctx.Writer, ctx.Request.Body, maxAudioSize)
+	file, header, err := ctx.Request.FormFile("file")
+	if err != nil {
+		httpx.FailWithErr(ctx, xerr.WithCode(fmt.Errorf("请上传不超过25MB的音频: %v", err), xerr.Invalid))
+		return
+	}
+	defer file.Close()
+
+	res, err := h.speech.ASR(ctx.Request.Context(), header.Filename, file)
+	if err != nil {
+		httpx.FailWithErr(ctx, err)
+	} else {
+		httpx.OkWithData(ctx, res)
+	}
+}
+
+// TTS 文字转语音，直接返回音频内容
+func (h *Chat) TTS(ctx *gin.Context) {
+	var req domain.TTSReq
+	if err := httpx.BindAndValidate(ctx, &req); err != nil {
+		httpx.FailWithErr(ctx, err)
+		return
+	}
+
+	audio, contentType, err := h.speech.TTS(ctx.Request.Context(), &req)
+	if err != nil {
+		httpx.FailWithErr(ctx, err)
+		return
+	}
+	ctx.Data(http.StatusOK, contentType, audio)
+}
+
+// AIStream 以SSE方式流式返回AI回复
+// 事件: delta=增量内容 done=完整结果 error=错误信息
+func (h *Chat) AIStream(ctx *gin.Context) {
+	var req domain.ChatReq
+	if err := httpx.BindAndValidate(ctx, &req); err != nil {
+		httpx.FailWithErr(ctx, err)
+		return
+	}
+
+	ctx.Header("Content-Type", "text/event-stream")
+	ctx.Header("Cache-Control", "no-cache")
+	ctx.Header("Connection", "keep-alive")
+	ctx.Header("X-Accel-Buffering", "no")
+
+	res, err := h.chat.AIChatStream(ctx.Request.Context(), &req, func(_ context.Context, chunk []byte) error {
+		// 客户端断开时终止生成
+		if err := ctx.Request.Context().Err(); err != nil {
+			return err
+		}
+		ctx.SSEvent("delta", &domain.ChatStreamChunk{Content: string(chunk)})
+		ctx.Writer.Flush()
+		return nil
+	})
+	if err != nil {
+		ctx.SSEvent("error", map[string]string{"msg": xerr.Message(err)})
+	} else {
+		ctx.SSEvent("done", res)
+	}
+	ctx.Writer.Flush()
+}
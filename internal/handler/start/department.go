@@ -21,17 +21,20 @@ func NewDepartment(svcCtx *svc.ServiceContext, department logic.Department) *Dep
 	}
 }
 
-func (h *Department) InitRegister(engine *gin.Engine) {
-	g := engine.Group("v1/dep", h.svcCtx.Jwt.Handler)
+func (h *Department) InitRegister(r *Router) {
+	g := r.Group(V1, "dep", h.svcCtx.Jwt.Handler)
 	g.GET("/soa", h.Soa)
 	g.GET("/:id", h.Info)
-	g.POST("", h.Create)
-	g.PUT("", h.Edit)
-	g.DELETE("/:id", h.Delete)
-	g.POST("/user", h.SetDepartmentUsers)
-	g.POST("/user/add", h.AddDepartmentUser)
-	g.DELETE("/user/remove", h.RemoveDepartmentUser)
 	g.GET("/user/:id", h.DepartmentUserInfo)
+
+	// 部门及成员变更只允许管理员操作
+	admin := g.Group("", h.svcCtx.Admin.Handler)
+	admin.POST("", h.Create)
+	admin.PUT("", h.Edit)
+	admin.DELETE("/:id", h.Delete)
+	admin.POST("/user", h.SetDepartmentUsers)
+	admin.POST("/user/add", h.AddDepartmentUser)
+	admin.DELETE("/user/remove", h.RemoveDepartmentUser)
 }
 
 func (h *Department) Soa(ctx *gin.Context) {
@@ -0,0 +1,108 @@
+package start
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"aiOffice/internal/domain"
+	"aiOffice/internal/logic"
+	"aiOffice/internal/svc"
+	"aiOffice/pkg/httpx"
+)
+
+// Event 个人和共享日程，与外部日历同步的接口同在 /calendar 下
+type Event struct {
+	svcCtx *svc.ServiceContext
+	event  logic.Event
+}
+
+func NewEvent(svcCtx *svc.ServiceContext, event logic.Event) *Event {
+	return &Event{
+		svcCtx: svcCtx,
+		event:  event,
+	}
+}
+
+func (h *Event) InitRegister(r *Router) {
+	g := r.Group(V1, "calendar", h.svcCtx.Jwt.Handler)
+	g.GET("/view", h.View)
+	g.GET("/event/:id", h.Info)
+	g.POST("/event", h.Create)
+	g.PUT("/event", h.Edit)
+	g.DELETE("/event/:id", h.Delete)
+}
+
+// 日历视图：日程、待办截止时间和已通过的请假
+func (h *Event) View(ctx *gin.Context) {
+	var req domain.CalendarViewReq
+	if err := httpx.BindAndValidate(ctx, &req); err != nil {
+		httpx.FailWithErr(ctx, err)
+		return
+	}
+
+	res, err := h.event.View(ctx.Request.Context(), &req)
+	if err != nil {
+		httpx.FailWithErr(ctx, err)
+	} else {
+		httpx.OkWithData(ctx, res)
+	}
+}
+
+func (h *Event) Info(ctx *gin.Context) {
+	var req domain.IdPathReq
+	if err := httpx.BindAndValidate(ctx, &req); err != nil {
+		httpx.FailWithErr(ctx, err)
+		return
+	}
+
+	res, err := h.event.Info(ctx.Request.Context(), &req)
+	if err != nil {
+		httpx.FailWithErr(ctx, err)
+	} else {
+		httpx.OkWithData(ctx, res)
+	}
+}
+
+func (h *Event) Create(ctx *gin.Context) {
+	var req domain.Event
+	if err := httpx.BindAndValidate(ctx, &req); err != nil {
+		httpx.FailWithErr(ctx, err)
+		return
+	}
+
+	res, err := h.event.Create(ctx.Request.Context(), &req)
+	if err != nil {
+		httpx.FailWithErr(ctx, err)
+	} else {
+		httpx.OkWithData(ctx, res)
+	}
+}
+
+func (h *Event) Edit(ctx *gin.Context) {
+	var req domain.Event
+	if err := httpx.BindAndValidate(ctx, &req); err != nil {
+		httpx.FailWithErr(ctx, err)
+		return
+	}
+
+	err := h.event.Edit(ctx.Request.Context(), &req)
+	if err != nil {
+		httpx.FailWithErr(ctx, err)
+	} else {
+		httpx.Ok(ctx)
+	}
+}
+
+func (h *Event) Delete(ctx *gin.Context) {
+	var req domain.IdPathReq
+	if err := httpx.BindAndValidate(ctx, &req); err != nil {
+		httpx.FailWithErr(ctx, err)
+		return
+	}
+
+	err := h.event.Delete(ctx.Request.Context(), &req)
+	if err != nil {
+		httpx.FailWithErr(ctx, err)
+	} else {
+		httpx.Ok(ctx)
+	}
+}
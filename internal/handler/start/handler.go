@@ -1,46 +1,106 @@
 package start
 
 import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net/http"
+
 	"github.com/gin-gonic/gin"
 
 	"aiOffice/internal/handler"
+	"aiOffice/internal/middleware"
 	"aiOffice/internal/svc"
 	"aiOffice/pkg/httpx"
 	"aiOffice/pkg/metrics"
+	"aiOffice/pkg/tlsx"
 )
 
 type Handler interface {
-	InitRegister(*gin.Engine)
+	InitRegister(*Router)
 }
 
 type handle struct {
-	srv  *gin.Engine
-	addr string
+	srv    *gin.Engine
+	addr   string
+	server *http.Server
+	tls    *tls.Config
 }
 
 func NewHandle(svc *svc.ServiceContext) *handle {
 	h := &handle{
-		srv:  gin.Default(),
+		srv:  gin.New(),
 		addr: "0.0.0.0:8080",
+		tls:  svc.TLS,
 	}
 	if len(svc.Config.Addr) > 0 {
 		h.addr = svc.Config.Addr
 	}
 
+	// ClientIP 用于按IP限流和审计，不可信来源的 X-Forwarded-For 可以伪造
+	if err := h.srv.SetTrustedProxies(svc.Config.TrustedProxies); err != nil {
+		panic(fmt.Sprintf("TrustedProxies 配置错误: %v", err))
+	}
 	httpx.SetErrorHandler(handler.ErrorHandler)
+	h.srv.Use(gin.Logger())
+
+	// 请求标识、链路追踪和请求日志，写入ctx后随AI对话、工具调用传递到下游
+	h.srv.Use(middleware.NewRequestId().Handler, middleware.NewTrace().Handler, middleware.NewLog(svc.Config.Tlog.Body).Handler)
+	// 在请求日志之内恢复panic，日志中记录为500
+	h.srv.Use(middleware.NewRecover(svc.Panic).Handler)
+	h.srv.Use(middleware.NewLang().Handler)
+
+	// 审计所有变更请求，查询类的POST接口除外
+	h.srv.Use(svc.Audit.Skip(
+		"POST /v1/user/login",
+		"POST /v1/todo/list",
+		"POST /v1/approval/list",
+		"POST /v1/knowledge/query",
+		"POST /v1/chat",
+		"POST /v1/chat/ai/stream",
+		"POST /v1/chat/asr",
+		"POST /v1/chat/tts",
+	).Handler)
 
 	// 注册 Prometheus 指标中间件和端点
 	h.srv.Use(metrics.MetricsMiddleware())
 	h.srv.GET("/metrics", metrics.PrometheusHandler())
+	// 就绪检查，mongo不可用时返回503，负载均衡据此摘除实例
+	h.srv.GET("/readyz", readyz(svc))
 
+	// 变更请求按用户限流，在各分组的Jwt之后执行
+	router := newRouter(h.srv, svc.WriteLimit.Handler)
 	handlers := initHandler(svc)
 	for _, handler := range handlers {
-		handler.InitRegister(h.srv)
+		handler.InitRegister(router)
 	}
 
+	h.server = &http.Server{Addr: h.addr, Handler: h.srv}
 	return h
 }
 
+// readyz 返回最近一次定期检查的结果，不在请求中访问数据库
+func readyz(svc *svc.ServiceContext) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if err := svc.MongoHealth.Err(); err != nil {
+			ctx.JSON(http.StatusServiceUnavailable, gin.H{"status": "unavailable", "mongo": err.Error()})
+			return
+		}
+		ctx.JSON(http.StatusOK, gin.H{"status": "ok"})
+	}
+}
+
+// Run 启动http服务（阻塞），调用 Shutdown 后返回nil
 func (h *handle) Run() error {
-	return h.srv.Run(h.addr)
+	fmt.Println("http服务正在运行在", tlsx.Scheme(h.tls, "http", "https")+"://"+h.addr)
+	if err := tlsx.ListenAndServe(h.server, h.tls); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// Shutdown 停止接受新请求，等待处理中的请求完成
+func (h *handle) Shutdown(ctx context.Context) error {
+	return h.server.Shutdown(ctx)
 }
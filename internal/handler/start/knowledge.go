@@ -0,0 +1,143 @@
+package start
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"aiOffice/internal/domain"
+	"aiOffice/internal/logic"
+	"aiOffice/internal/svc"
+	"aiOffice/pkg/httpx"
+	"aiOffice/pkg/pagex"
+)
+
+type Knowledge struct {
+	svcCtx    *svc.ServiceContext
+	knowledge logic.Knowledge
+}
+
+func NewKnowledge(svcCtx *svc.ServiceContext, knowledge logic.Knowledge) *Knowledge {
+	return &Knowledge{
+		svcCtx:    svcCtx,
+		knowledge: knowledge,
+	}
+}
+
+func (h *Knowledge) InitRegister(r *Router) {
+	g := r.Group(V1, "knowledge", h.svcCtx.Jwt.Handler)
+	g.GET("/documents", h.List)
+	g.GET("/documents/:id", h.Info)
+	g.DELETE("/documents/:id", h.Delete)
+	g.POST("/query", h.Query)
+	g.GET("/document/:id/file", h.File)
+	g.GET("/document/:id/preview", h.Preview)
+}
+
+// List 分页查询知识库文档
+func (h *Knowledge) List(ctx *gin.Context) {
+	var req domain.KnowledgeDocListReq
+	if err := httpx.BindAndValidate(ctx, &req); err != nil {
+		httpx.FailWithErr(ctx, err)
+		return
+	}
+
+	res, err := h.knowledge.List(ctx.Request.Context(), &req)
+	if err != nil {
+		httpx.FailWithErr(ctx, err)
+	} else {
+		httpx.OkWithPage(ctx, res.List, res.Count, pagex.FromRequest(req.Page, req.Count, ""))
+	}
+}
+
+// Info 查询文档的处理状态
+func (h *Knowledge) Info(ctx *gin.Context) {
+	var req domain.IdPathReq
+	if err := httpx.BindAndValidate(ctx, &req); err != nil {
+		httpx.FailWithErr(ctx, err)
+		return
+	}
+
+	res, err := h.knowledge.Info(ctx.Request.Context(), &req)
+	if err != nil {
+		httpx.FailWithErr(ctx, err)
+	} else {
+		httpx.OkWithData(ctx, res)
+	}
+}
+
+// Delete 删除知识库文档及其向量数据
+func (h *Knowledge) Delete(ctx *gin.Context) {
+	var req domain.IdPathReq
+	if err := httpx.BindAndValidate(ctx, &req); err != nil {
+		httpx.FailWithErr(ctx, err)
+		return
+	}
+
+	if err := h.knowledge.Delete(ctx.Request.Context(), &req); err != nil {
+		httpx.FailWithErr(ctx, err)
+	} else {
+		httpx.Ok(ctx)
+	}
+}
+
+// Query 直接检索知识库并回答，返回回答、可信度和引用来源
+func (h *Knowledge) Query(ctx *gin.Context) {
+	var req domain.KnowledgeQueryReq
+	if err := httpx.BindAndValidate(ctx, &req); err != nil {
+		httpx.FailWithErr(ctx, err)
+		return
+	}
+
+	res, err := h.knowledge.Query(ctx.Request.Context(), &req)
+	if err != nil {
+		httpx.FailWithErr(ctx, err)
+	} else {
+		httpx.OkWithData(ctx, res)
+	}
+}
+
+// File 下载文档的原始文件
+func (h *Knowledge) File(ctx *gin.Context) {
+	var req domain.IdPathReq
+	if err := httpx.BindAndValidate(ctx, &req); err != nil {
+		httpx.FailWithErr(ctx, err)
+		return
+	}
+
+	path, name, err := h.knowledge.File(ctx.Request.Context(), &req)
+	if err != nil {
+		httpx.FailWithErr(ctx, err)
+		return
+	}
+	if contentType, ok := contentTypes[strings.ToLower(filepath.Ext(path))]; ok {
+		ctx.Header("Content-Type", contentType)
+	}
+	ctx.FileAttachment(path, name)
+}
+
+// Preview 预览文档提取出的文本
+func (h *Knowledge) Preview(ctx *gin.Context) {
+	var req domain.KnowledgePreviewReq
+	if err := httpx.BindAndValidate(ctx, &req); err != nil {
+		httpx.FailWithErr(ctx, err)
+		return
+	}
+
+	res, err := h.knowledge.Preview(ctx.Request.Context(), &req)
+	if err != nil {
+		httpx.FailWithErr(ctx, err)
+	} else {
+		httpx.OkWithData(ctx, res)
+	}
+}
+
+// contentTypes 知识库支持的文件格式，系统mime表中通常缺少office和markdown类型
+var contentTypes = map[string]string{
+	".md":       "text/markdown; charset=utf-8",
+	".markdown": "text/markdown; charset=utf-8",
+	".txt":      "text/plain; charset=utf-8",
+	".docx":     "application/vnd.openxmlformats-officedocument.wordprocessingml.document",
+	".xlsx":     "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+}
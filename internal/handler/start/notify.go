@@ -0,0 +1,99 @@
+package start
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"aiOffice/internal/domain"
+	"aiOffice/internal/logic"
+	"aiOffice/internal/svc"
+	"aiOffice/pkg/httpx"
+)
+
+type Notify struct {
+	svcCtx *svc.ServiceContext
+	notify logic.Notify
+}
+
+func NewNotify(svcCtx *svc.ServiceContext, notify logic.Notify) *Notify {
+	return &Notify{
+		svcCtx: svcCtx,
+		notify: notify,
+	}
+}
+
+func (h *Notify) InitRegister(r *Router) {
+	g := r.Group(V1, "notify", h.svcCtx.Jwt.Handler)
+	g.POST("/device", h.RegisterDevice)
+	g.DELETE("/device", h.RemoveDevice)
+	g.GET("/setting", h.Setting)
+	g.PUT("/setting", h.UpdateSetting)
+	g.GET("/center", h.Center)
+}
+
+// 注册推送设备
+func (h *Notify) RegisterDevice(ctx *gin.Context) {
+	var req domain.DeviceReq
+	if err := httpx.BindAndValidate(ctx, &req); err != nil {
+		httpx.FailWithErr(ctx, err)
+		return
+	}
+
+	err := h.notify.RegisterDevice(ctx.Request.Context(), &req)
+	if err != nil {
+		httpx.FailWithErr(ctx, err)
+	} else {
+		httpx.Ok(ctx)
+	}
+}
+
+// 注销推送设备
+func (h *Notify) RemoveDevice(ctx *gin.Context) {
+	var req domain.DeviceReq
+	if err := httpx.BindAndValidate(ctx, &req); err != nil {
+		httpx.FailWithErr(ctx, err)
+		return
+	}
+
+	err := h.notify.RemoveDevice(ctx.Request.Context(), &req)
+	if err != nil {
+		httpx.FailWithErr(ctx, err)
+	} else {
+		httpx.Ok(ctx)
+	}
+}
+
+// 查询提醒偏好
+func (h *Notify) Setting(ctx *gin.Context) {
+	res, err := h.notify.Setting(ctx.Request.Context())
+	if err != nil {
+		httpx.FailWithErr(ctx, err)
+	} else {
+		httpx.OkWithData(ctx, res)
+	}
+}
+
+// 更新提醒偏好
+func (h *Notify) UpdateSetting(ctx *gin.Context) {
+	var req domain.NotifySetting
+	if err := httpx.BindAndValidate(ctx, &req); err != nil {
+		httpx.FailWithErr(ctx, err)
+		return
+	}
+
+	err := h.notify.UpdateSetting(ctx.Request.Context(), &req)
+	if err != nil {
+		httpx.FailWithErr(ctx, err)
+	} else {
+		httpx.Ok(ctx)
+	}
+}
+
+// 通知中心：未读公告
+func (h *Notify) Center(ctx *gin.Context) {
+	res, err := h.notify.Center(ctx.Request.Context())
+	if err != nil {
+		httpx.FailWithErr(ctx, err)
+	} else {
+		httpx.OkWithData(ctx, res)
+	}
+}
@@ -8,21 +8,42 @@ import (
 func initHandler(svc *svc.ServiceContext) []Handler {
 	// new logics
 	var (
-		userLogic       = logic.NewUser(svc)
-		departmentLogic = logic.NewDepartment(svc)
-		todoLogic       = logic.NewTodo(svc)
-		approvalLogic   = logic.NewApproval(svc)
-		chatLogic       = logic.NewChat(svc)
+		userLogic         = logic.NewUser(svc)
+		departmentLogic   = logic.NewDepartment(svc)
+		todoLogic         = logic.NewTodo(svc)
+		approvalLogic     = logic.NewApproval(svc)
+		workflowLogic     = logic.NewWorkflow(svc)
+		attendanceLogic   = logic.NewAttendance(svc)
+		chatLogic         = logic.NewChat(svc)
+		notifyLogic       = logic.NewNotify(svc)
+		aiLogic           = logic.NewAI(svc)
+		calendarLogic     = logic.NewCalendar(svc)
+		eventLogic        = logic.NewEvent(svc)
+		announcementLogic = logic.NewAnnouncement(svc)
+		speechLogic       = logic.NewSpeech(svc)
+		knowledgeLogic    = logic.NewKnowledge(svc)
+		scheduleLogic     = logic.NewSchedule(svc)
+		auditLogic        = logic.NewAudit(svc)
 	)
 
 	// new handlers
 	var (
-		user       = NewUser(svc, userLogic)
-		department = NewDepartment(svc, departmentLogic)
-		todo       = NewTodo(svc, todoLogic)
-		approval   = NewApproval(svc, approvalLogic)
-		chat       = NewChat(svc, chatLogic)
-		upload     = NewUpload(svc, chatLogic)
+		user         = NewUser(svc, userLogic)
+		department   = NewDepartment(svc, departmentLogic)
+		todo         = NewTodo(svc, todoLogic)
+		approval     = NewApproval(svc, approvalLogic)
+		workflow     = NewWorkflow(svc, workflowLogic)
+		attendance   = NewAttendance(svc, attendanceLogic)
+		chat         = NewChat(svc, chatLogic, speechLogic)
+		upload       = NewUpload(svc, chatLogic, knowledgeLogic)
+		notify       = NewNotify(svc, notifyLogic)
+		ai           = NewAI(svc, aiLogic)
+		calendar     = NewCalendar(svc, calendarLogic)
+		event        = NewEvent(svc, eventLogic)
+		announcement = NewAnnouncement(svc, announcementLogic)
+		knowledge    = NewKnowledge(svc, knowledgeLogic)
+		schedule     = NewSchedule(svc, scheduleLogic)
+		audit        = NewAudit(svc, auditLogic)
 	)
 
 	return []Handler{
@@ -30,7 +51,17 @@ func initHandler(svc *svc.ServiceContext) []Handler {
 		department,
 		todo,
 		approval,
+		workflow,
+		attendance,
 		chat,
 		upload,
+		notify,
+		ai,
+		calendar,
+		event,
+		announcement,
+		knowledge,
+		schedule,
+		audit,
 	}
 }
@@ -0,0 +1,88 @@
+package start
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"aiOffice/internal/domain"
+	"aiOffice/internal/logic"
+	"aiOffice/internal/svc"
+	"aiOffice/pkg/httpx"
+)
+
+type Schedule struct {
+	svcCtx   *svc.ServiceContext
+	schedule logic.Schedule
+}
+
+func NewSchedule(svcCtx *svc.ServiceContext, schedule logic.Schedule) *Schedule {
+	return &Schedule{
+		svcCtx:   svcCtx,
+		schedule: schedule,
+	}
+}
+
+func (h *Schedule) InitRegister(r *Router) {
+	g := r.Group(V1, "schedule", h.svcCtx.Jwt.Handler, h.svcCtx.Admin.Handler)
+	g.GET("", h.List)
+	g.POST("", h.Create)
+	g.PUT("/:id", h.Update)
+	g.DELETE("/:id", h.Delete)
+}
+
+// List 定时任务列表
+func (h *Schedule) List(ctx *gin.Context) {
+	res, err := h.schedule.List(ctx.Request.Context())
+	if err != nil {
+		httpx.FailWithErr(ctx, err)
+	} else {
+		httpx.OkWithData(ctx, res)
+	}
+}
+
+// Create 添加定时任务
+func (h *Schedule) Create(ctx *gin.Context) {
+	var req domain.ScheduledTask
+	if err := httpx.BindAndValidate(ctx, &req); err != nil {
+		httpx.FailWithErr(ctx, err)
+		return
+	}
+
+	res, err := h.schedule.Create(ctx.Request.Context(), &req)
+	if err != nil {
+		httpx.FailWithErr(ctx, err)
+	} else {
+		httpx.OkWithData(ctx, res)
+	}
+}
+
+// Update 修改定时任务
+func (h *Schedule) Update(ctx *gin.Context) {
+	var req domain.ScheduledTask
+	if err := httpx.BindAndValidate(ctx, &req); err != nil {
+		httpx.FailWithErr(ctx, err)
+		return
+	}
+
+	err := h.schedule.Update(ctx.Request.Context(), &req)
+	if err != nil {
+		httpx.FailWithErr(ctx, err)
+	} else {
+		httpx.Ok(ctx)
+	}
+}
+
+// Delete 删除定时任务
+func (h *Schedule) Delete(ctx *gin.Context) {
+	var req domain.IdPathReq
+	if err := httpx.BindAndValidate(ctx, &req); err != nil {
+		httpx.FailWithErr(ctx, err)
+		return
+	}
+
+	err := h.schedule.Delete(ctx.Request.Context(), &req)
+	if err != nil {
+		httpx.FailWithErr(ctx, err)
+	} else {
+		httpx.Ok(ctx)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"aiOffice/internal/logic"
 	"aiOffice/internal/svc"
 	"aiOffice/pkg/httpx"
+	"aiOffice/pkg/pagex"
 )
 
 type Todo struct {
@@ -21,8 +22,8 @@ func NewTodo(svcCtx *svc.ServiceContext, todo logic.Todo) *Todo {
 	}
 }
 
-func (h *Todo) InitRegister(engine *gin.Engine) {
-	g := engine.Group("v1/todo", h.svcCtx.Jwt.Handler)
+func (h *Todo) InitRegister(r *Router) {
+	g := r.Group(V1, "todo", h.svcCtx.Signature.Handler)
 	g.GET("/:id", h.Info)
 	g.POST("", h.Create)
 	g.PUT("", h.Edit)
@@ -136,6 +137,6 @@ func (h *Todo) List(ctx *gin.Context) {
 	if err != nil {
 		httpx.FailWithErr(ctx, err)
 	} else {
-		httpx.OkWithData(ctx, res)
+		httpx.OkWithPage(ctx, res.List, res.Count, pagex.FromRequest(req.Page, req.Count, ""))
 	}
 }
@@ -1,54 +1,73 @@
 package start
 
 import (
-	"bytes"
+	"cmp"
 	"context"
 	"fmt"
 	"io"
+	"mime/multipart"
+	"net/http"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/gin-gonic/gin"
-	"github.com/tmc/langchaingo/embeddings"
-	"github.com/tmc/langchaingo/vectorstores/redisvector"
 
 	"aiOffice/internal/domain"
 	"aiOffice/internal/logic"
+	"aiOffice/internal/model"
 	"aiOffice/internal/svc"
 	"aiOffice/pkg/httpx"
-	"aiOffice/pkg/knowledge"
+	"aiOffice/pkg/imagex"
 	"aiOffice/pkg/timeutils"
+	"aiOffice/pkg/token"
+	"aiOffice/pkg/xerr"
 )
 
 type Upload struct {
-	svcCtx *svc.ServiceContext
-	chat   logic.Chat
+	svcCtx    *svc.ServiceContext
+	chat      logic.Chat
+	knowledge logic.Knowledge
 }
 
-func NewUpload(svcCtx *svc.ServiceContext, chat logic.Chat) *Upload {
+func NewUpload(svcCtx *svc.ServiceContext, chat logic.Chat, knowledge logic.Knowledge) *Upload {
 	return &Upload{
-		svcCtx: svcCtx,
-		chat:   chat,
+		svcCtx:    svcCtx,
+		chat:      chat,
+		knowledge: knowledge,
 	}
 }
 
-func (h *Upload) InitRegister(engine *gin.Engine) {
-	g := engine.Group("v1/upload", h.svcCtx.Jwt.Handler)
+func (h *Upload) InitRegister(r *Router) {
+	g := r.Group(V1, "upload", h.svcCtx.Jwt.Handler)
 	g.POST("/file", h.File)
 	g.POST("/files", h.Multiplefiles)
 }
 
+const (
+	formOverhead     = 1 << 20 // 单文件上传时表单中除文件外其他字段的空间
+	defaultThumbSize = 320     // 图片缩略图默认最长边（像素）
+	defaultMaxFiles  = 10      // 多文件上传默认一次最多的文件数
+)
+
 // File 处理单个文件上传请求
 func (h *Upload) File(ctx *gin.Context) {
-	file, header, err := ctx.Request.FormFile("file")
+	// 超过大小上限的请求体不再继续读取，预留表单其他字段的空间
+	if limit := h.svcCtx.Upload.MaxSize(); limit > 0 {
+		ctx.Request.Body = http.MaxBytesReader(ctx.Writer, ctx.Request.Body, limit+formOverhead)
+	}
+	_, header, err := ctx.Request.FormFile("file")
 	if err != nil {
-		httpx.FailWithErr(ctx, err)
+		httpx.FailWithErr(ctx, xerr.WithCode(err, xerr.Invalid))
 		return
 	}
-	defer file.Close()
 
-	var buf = bytes.NewBuffer(nil)
-	if _, err := io.Copy(buf, file); err != nil {
+	f, err := h.read(ctx.Request.Context(), header)
+	if err != nil {
+		httpx.FailWithErr(ctx, err)
+		return
+	}
+	if err := h.checkQuota(ctx.Request.Context(), int64(len(f.data))); err != nil {
 		httpx.FailWithErr(ctx, err)
 		return
 	}
@@ -75,7 +94,16 @@ func (h *Upload) File(ctx *gin.Context) {
 	defer newFile.Close()
 
 	// 写入文件内容
-	if _, err := newFile.Write(buf.Bytes()); err != nil {
+	if _, err := newFile.Write(f.data); err != nil {
+		httpx.FailWithErr(ctx, err)
+		return
+	}
+	thumb, err := h.saveThumb(savePath, filename, f)
+	if err != nil {
+		httpx.FailWithErr(ctx, err)
+		return
+	}
+	if err := h.record(ctx.Request.Context(), header.Filename, savePath+filename, thumb, f.data); err != nil {
 		httpx.FailWithErr(ctx, err)
 		return
 	}
@@ -90,6 +118,7 @@ func (h *Upload) File(ctx *gin.Context) {
 		Host:     host,
 		File:     fmt.Sprintf("%s%s", savePath, filename),
 		Filename: filename,
+		Thumb:    thumb,
 	}
 
 	// 如果指定了chat参数，将文件信息写入记忆机制
@@ -98,14 +127,13 @@ func (h *Upload) File(ctx *gin.Context) {
 		h.chat.File(ctx.Request.Context(), []*domain.FileResp{&resp})
 	}
 
-	// 如果指定了knowledge=1参数，自动入库到知识库
+	// 如果指定了knowledge=1参数，提交到知识库后台入库（可通过depId指定部门知识库）
 	knowledgeFlag := ctx.Request.FormValue("knowledge")
 	if knowledgeFlag == "1" {
-		if err := h.addToKnowledge(ctx.Request.Context(), resp.File); err != nil {
+		if err := h.addToKnowledge(ctx.Request.Context(), &resp, header.Filename, ctx.Request.FormValue("depId")); err != nil {
 			httpx.FailWithErr(ctx, fmt.Errorf("知识库入库失败: %v", err))
 			return
 		}
-		resp.Knowledge = true
 	}
 
 	httpx.OkWithData(ctx, resp)
@@ -113,15 +141,37 @@ func (h *Upload) File(ctx *gin.Context) {
 
 // Multiplefiles 处理多文件上传请求
 func (h *Upload) Multiplefiles(ctx *gin.Context) {
+	// 请求体上限为文件数上限乘以单个文件上限，超过后不再继续读取
+	maxFiles := h.maxFiles()
+	if limit := h.svcCtx.Upload.MaxSize(); limit > 0 {
+		ctx.Request.Body = http.MaxBytesReader(ctx.Writer, ctx.Request.Body, limit*int64(maxFiles)+formOverhead)
+	}
 	form, err := ctx.MultipartForm()
 	if err != nil {
-		httpx.FailWithErr(ctx, err)
+		httpx.FailWithErr(ctx, xerr.WithCode(err, xerr.Invalid))
 		return
 	}
 
 	files := form.File["files"]
 	if len(files) == 0 {
-		httpx.FailWithErr(ctx, fmt.Errorf("请选择要上传的文件"))
+		httpx.FailWithErr(ctx, xerr.NewCode(xerr.Invalid, "请选择要上传的文件"))
+		return
+	}
+	if len(files) > maxFiles {
+		httpx.FailWithErr(ctx, xerr.NewCode(xerr.Invalid, fmt.Sprintf("一次最多上传%d个文件", maxFiles)))
+		return
+	}
+	// 读取内容前先按文件名和大小校验全部文件
+	var declared int64
+	for _, header := range files {
+		if err := h.svcCtx.Upload.CheckHeader(header.Filename, header.Size); err != nil {
+			httpx.FailWithErr(ctx, err)
+			return
+		}
+		declared += header.Size
+	}
+	if err := h.checkQuota(ctx.Request.Context(), declared); err != nil {
+		httpx.FailWithErr(ctx, err)
 		return
 	}
 
@@ -140,22 +190,28 @@ func (h *Upload) Multiplefiles(ctx *gin.Context) {
 		host = h.svcCtx.Config.Addr
 	}
 
-	respList := make([]*domain.FileResp, 0, len(files))
-
+	// 全部校验通过后再写入，避免部分文件落盘
+	contents := make([]*uploaded, 0, len(files))
+	var total int64
 	for _, header := range files {
-		file, err := header.Open()
+		f, err := h.read(ctx.Request.Context(), header)
 		if err != nil {
 			httpx.FailWithErr(ctx, err)
 			return
 		}
+		contents = append(contents, f)
+		total += int64(len(f.data))
+	}
+	if err := h.checkQuota(ctx.Request.Context(), total); err != nil {
+		httpx.FailWithErr(ctx, err)
+		return
+	}
 
-		var buf = bytes.NewBuffer(nil)
-		if _, err := io.Copy(buf, file); err != nil {
-			file.Close()
-			httpx.FailWithErr(ctx, err)
-			return
-		}
-		file.Close()
+	respList := make([]*domain.FileResp, 0, len(files))
+	names := make([]string, 0, len(files)) // 原始文件名，知识库按原始文件名替换旧版本
+
+	for i, header := range files {
+		f := contents[i]
 
 		// 生成唯一文件名（加上索引确保唯一）
 		filename := fmt.Sprintf("%d_%d%s", timeutils.Now(), len(respList), filepath.Ext(header.Filename))
@@ -167,18 +223,29 @@ func (h *Upload) Multiplefiles(ctx *gin.Context) {
 			return
 		}
 
-		if _, err := newFile.Write(buf.Bytes()); err != nil {
+		if _, err := newFile.Write(f.data); err != nil {
 			newFile.Close()
 			httpx.FailWithErr(ctx, err)
 			return
 		}
 		newFile.Close()
+		thumb, err := h.saveThumb(savePath, filename, f)
+		if err != nil {
+			httpx.FailWithErr(ctx, err)
+			return
+		}
+		if err := h.record(ctx.Request.Context(), header.Filename, savePath+filename, thumb, f.data); err != nil {
+			httpx.FailWithErr(ctx, err)
+			return
+		}
 
 		respList = append(respList, &domain.FileResp{
 			Host:     host,
 			File:     fmt.Sprintf("%s%s", savePath, filename),
 			Filename: filename,
+			Thumb:    thumb,
 		})
+		names = append(names, header.Filename)
 	}
 
 	// 如果指定了chat参数，将文件信息写入记忆机制
@@ -187,59 +254,118 @@ func (h *Upload) Multiplefiles(ctx *gin.Context) {
 		h.chat.File(ctx.Request.Context(), respList)
 	}
 
-	// 如果指定了knowledge=1参数，自动入库到知识库
+	// 如果指定了knowledge=1参数，提交到知识库后台入库（可通过depId指定部门知识库）
 	knowledgeFlag := ctx.Request.FormValue("knowledge")
 	if knowledgeFlag == "1" {
-		for _, resp := range respList {
-			if err := h.addToKnowledge(ctx.Request.Context(), resp.File); err != nil {
+		for i, resp := range respList {
+			if err := h.addToKnowledge(ctx.Request.Context(), resp, names[i], ctx.Request.FormValue("depId")); err != nil {
 				httpx.FailWithErr(ctx, fmt.Errorf("知识库入库失败(%s): %v", resp.Filename, err))
 				return
 			}
-			resp.Knowledge = true
 		}
 	}
 
 	httpx.OkWithData(ctx, domain.FileListResp{List: respList})
 }
 
-// addToKnowledge 将文件添加到知识库
-func (h *Upload) addToKnowledge(ctx context.Context, filePath string) error {
-	// 检查文件格式是否支持
-	if !knowledge.IsSupportedFormat(filePath) {
-		return fmt.Errorf("不支持的文件格式，支持: %v", knowledge.SupportedFormats())
+// addToKnowledge 提交文件到知识库，指定depId时写入部门知识库，同名文件替换旧版本；处理状态通过返回的文档id查询
+func (h *Upload) addToKnowledge(ctx context.Context, resp *domain.FileResp, name, depId string) error {
+	doc, err := h.knowledge.Submit(ctx, resp.File, name, depId)
+	if err != nil {
+		return err
 	}
+	resp.Knowledge = true
+	resp.KnowledgeId = doc.Id
+	return nil
+}
 
-	// 使用多格式文档处理器
-	processor := knowledge.NewDocProcessor(500, 50)
-	docs, err := processor.Process(filePath)
-	if err != nil {
-		return fmt.Errorf("文档处理失败: %v", err)
+// uploaded 校验和处理后的上传文件
+type uploaded struct {
+	data     []byte // 图片已去除EXIF等元数据
+	thumb    []byte // 图片缩略图，非图片时为nil
+	thumbExt string
+}
+
+// read 校验并读取上传的文件，图片去除元数据并生成缩略图，校验通过前不写入磁盘
+func (h *Upload) read(ctx context.Context, header *multipart.FileHeader) (*uploaded, error) {
+	if err := h.svcCtx.Upload.CheckHeader(header.Filename, header.Size); err != nil {
+		return nil, err
 	}
 
-	if len(docs) == 0 {
-		return fmt.Errorf("文档中没有提取到有效内容")
+	file, err := header.Open()
+	if err != nil {
+		return nil, err
 	}
+	defer file.Close()
 
-	// 获取向量存储
-	embedder, err := embeddings.NewEmbedder(h.svcCtx.LLM)
+	data, err := io.ReadAll(file)
 	if err != nil {
-		return fmt.Errorf("创建embedder失败: %v", err)
+		return nil, err
+	}
+	if err := h.svcCtx.Upload.Check(ctx, header.Filename, data); err != nil {
+		return nil, err
 	}
 
-	store, err := redisvector.New(ctx,
-		redisvector.WithEmbedder(embedder),
-		redisvector.WithConnectionURL("redis://"+h.svcCtx.Config.Redis.Addr),
-		redisvector.WithIndexName("knowledge", true),
-	)
+	if !imagex.IsImage(http.DetectContentType(data)) {
+		return &uploaded{data: data}, nil
+	}
+	img, err := imagex.Process(data, h.thumbSize())
 	if err != nil {
-		return fmt.Errorf("连接向量存储失败: %v", err)
+		return nil, err
 	}
+	return &uploaded{data: img.Data, thumb: img.Thumb, thumbExt: img.ThumbExt}, nil
+}
 
-	// 使用公共方法分批添加文档
-	if err := knowledge.AddToVectorStore(ctx, store, docs); err != nil {
-		return err
+// maxFiles 多文件上传一次最多的文件数，默认10
+func (h *Upload) maxFiles() int {
+	if n := h.svcCtx.Config.Upload.MaxFiles; n > 0 {
+		return n
+	}
+	return defaultMaxFiles
+}
+
+// thumbSize 缩略图最长边，默认320，配置小于0时不生成
+func (h *Upload) thumbSize() int {
+	size := cmp.Or(h.svcCtx.Config.Upload.ThumbSize, defaultThumbSize)
+	return max(size, 0)
+}
+
+// saveThumb 缩略图保存在原图旁边，如 123.jpg 的缩略图为 123_thumb.jpg，返回缩略图路径
+func (h *Upload) saveThumb(savePath, filename string, f *uploaded) (string, error) {
+	if f.thumb == nil {
+		return "", nil
 	}
+	name := strings.TrimSuffix(filename, filepath.Ext(filename)) + "_thumb" + f.thumbExt
+	if err := os.WriteFile(savePath+name, f.thumb, 0644); err != nil {
+		return "", err
+	}
+	return savePath + name, nil
+}
 
-	fmt.Printf("[Upload] 知识库入库成功: %s, 共 %d 个文档块\n", filepath.Base(filePath), len(docs))
+// checkQuota 校验本次上传后是否超出用户存储空间
+func (h *Upload) checkQuota(ctx context.Context, size int64) error {
+	if !h.svcCtx.Upload.QuotaEnabled() {
+		return nil
+	}
+	used, err := h.svcCtx.UploadFileModel.SumSize(ctx, token.GetUid(ctx))
+	if err != nil {
+		return xerr.WithMessage(err, "查询存储空间失败")
+	}
+	return h.svcCtx.Upload.CheckQuota(used, size)
+}
+
+// record 记录上传的文件，用于统计用户已用空间
+func (h *Upload) record(ctx context.Context, name, path, thumb string, data []byte) error {
+	err := h.svcCtx.UploadFileModel.Insert(ctx, &model.UploadFile{
+		UserId:      token.GetUid(ctx),
+		Name:        name,
+		File:        path,
+		Thumb:       thumb,
+		Size:        int64(len(data)),
+		ContentType: http.DetectContentType(data),
+	})
+	if err != nil {
+		return xerr.WithMessage(err, "记录上传文件失败")
+	}
 	return nil
 }
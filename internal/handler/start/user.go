@@ -7,6 +7,7 @@ import (
 	"aiOffice/internal/logic"
 	"aiOffice/internal/svc"
 	"aiOffice/pkg/httpx"
+	"aiOffice/pkg/pagex"
 )
 
 type User struct {
@@ -21,17 +22,18 @@ func NewUser(svcCtx *svc.ServiceContext, user logic.User) *User {
 	}
 }
 
-func (h *User) InitRegister(engine *gin.Engine) {
-	g0 := engine.Group("v1/user")
-	g0.POST("/login", h.Login)
+func (h *User) InitRegister(r *Router) {
+	g0 := r.Group(V1, "user")
+	g0.POST("/login", h.svcCtx.LoginLimit.Handler, h.Login)
 
-	g1 := engine.Group("v1/user", h.svcCtx.Jwt.Handler)
+	g1 := r.Group(V1, "user", h.svcCtx.Jwt.Handler)
 	g1.GET("/:id", h.Info)
-	g1.POST("", h.Create)
-	g1.PUT("", h.Edit)
-	g1.DELETE("/:id", h.Delete)
+	g1.POST("", h.svcCtx.Admin.Handler, h.Create)
+	g1.PUT("", h.svcCtx.Admin.Handler, h.Edit)
+	g1.DELETE("/:id", h.svcCtx.Admin.Handler, h.Delete)
 	g1.GET("/list", h.List)
 	g1.POST("/password", h.UpdatePassword)
+	g1.POST("/logout", h.Logout)
 }
 
 // 用户登录
@@ -126,7 +128,7 @@ func (h *User) List(ctx *gin.Context) {
 	if err != nil {
 		httpx.FailWithErr(ctx, err)
 	} else {
-		httpx.OkWithData(ctx, res)
+		httpx.OkWithPage(ctx, res.List, res.Count, pagex.FromRequest(req.Page, req.Count, ""))
 	}
 }
 
@@ -145,3 +147,19 @@ func (h *User) UpdatePassword(ctx *gin.Context) {
 		httpx.Ok(ctx)
 	}
 }
+
+// 退出登录
+func (h *User) Logout(ctx *gin.Context) {
+	var req domain.LogoutReq
+	if err := httpx.BindAndValidate(ctx, &req); err != nil {
+		httpx.FailWithErr(ctx, err)
+		return
+	}
+
+	err := h.user.Logout(ctx.Request.Context(), &req)
+	if err != nil {
+		httpx.FailWithErr(ctx, err)
+	} else {
+		httpx.Ok(ctx)
+	}
+}
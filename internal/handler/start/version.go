@@ -0,0 +1,47 @@
+package start
+
+import (
+	"slices"
+
+	"github.com/gin-gonic/gin"
+)
+
+// VersionHeader 响应中返回处理请求的接口版本
+const VersionHeader = "X-Api-Version"
+
+// Version 接口版本，响应格式有破坏性变更的接口在新版本下发布，旧版本保持不变
+type Version string
+
+const (
+	V1 Version = "v1" // 现有客户端使用，保持稳定
+	V2 Version = "v2" // 响应格式不兼容v1的接口
+)
+
+// Router 按版本注册路由，同一资源的新旧版本可以同时存在；
+// 只有不兼容的接口需要在新版本下注册，其余接口客户端继续使用v1
+type Router struct {
+	engine *gin.Engine
+	groups map[Version]*gin.RouterGroup
+	after  []gin.HandlerFunc
+}
+
+// newRouter after 在每个分组自身的中间件之后执行，用于依赖Jwt解析出用户的中间件，如按用户限流
+func newRouter(engine *gin.Engine, after ...gin.HandlerFunc) *Router {
+	return &Router{
+		engine: engine,
+		groups: make(map[Version]*gin.RouterGroup),
+		after:  after,
+	}
+}
+
+// Group 在指定版本下创建路由分组，如 Group(V2, "todo", jwt) 对应 /v2/todo
+func (r *Router) Group(v Version, path string, handlers ...gin.HandlerFunc) *gin.RouterGroup {
+	g, ok := r.groups[v]
+	if !ok {
+		g = r.engine.Group(string(v), func(ctx *gin.Context) {
+			ctx.Header(VersionHeader, string(v))
+		})
+		r.groups[v] = g
+	}
+	return g.Group(path, slices.Concat(handlers, r.after)...)
+}
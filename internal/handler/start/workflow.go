@@ -0,0 +1,177 @@
+package start
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"aiOffice/internal/domain"
+	"aiOffice/internal/logic"
+	"aiOffice/internal/svc"
+	"aiOffice/pkg/httpx"
+)
+
+// Workflow 按流程定义配置的审批流程，定义的新建和修改只允许管理员操作
+type Workflow struct {
+	svcCtx   *svc.ServiceContext
+	workflow logic.Workflow
+}
+
+func NewWorkflow(svcCtx *svc.ServiceContext, workflow logic.Workflow) *Workflow {
+	return &Workflow{
+		svcCtx:   svcCtx,
+		workflow: workflow,
+	}
+}
+
+func (h *Workflow) InitRegister(r *Router) {
+	g := r.Group(V1, "workflow", h.svcCtx.Jwt.Handler)
+	g.GET("/definitions", h.Definitions)
+	g.GET("/definition/:id", h.DefinitionInfo)
+	g.POST("/start", h.Start)
+	g.GET("/:id", h.Info)
+	g.PUT("/dispose", h.Dispose)
+	g.PUT("/cancel/:id", h.Cancel)
+	g.POST("/list", h.List)
+
+	admin := g.Group("", h.svcCtx.Admin.Handler)
+	admin.POST("/definition", h.CreateDefinition)
+	admin.PUT("/definition", h.EditDefinition)
+}
+
+// 可发起的流程，all=true 时返回全部（仅管理员）
+func (h *Workflow) Definitions(ctx *gin.Context) {
+	var req domain.WorkflowDefinitionListReq
+	if err := httpx.BindAndValidate(ctx, &req); err != nil {
+		httpx.FailWithErr(ctx, err)
+		return
+	}
+
+	res, err := h.workflow.Definitions(ctx.Request.Context(), &req)
+	if err != nil {
+		httpx.FailWithErr(ctx, err)
+	} else {
+		httpx.OkWithData(ctx, res)
+	}
+}
+
+func (h *Workflow) DefinitionInfo(ctx *gin.Context) {
+	var req domain.IdPathReq
+	if err := httpx.BindAndValidate(ctx, &req); err != nil {
+		httpx.FailWithErr(ctx, err)
+		return
+	}
+
+	res, err := h.workflow.DefinitionInfo(ctx.Request.Context(), &req)
+	if err != nil {
+		httpx.FailWithErr(ctx, err)
+	} else {
+		httpx.OkWithData(ctx, res)
+	}
+}
+
+func (h *Workflow) CreateDefinition(ctx *gin.Context) {
+	var req domain.WorkflowDefinition
+	if err := httpx.BindAndValidate(ctx, &req); err != nil {
+		httpx.FailWithErr(ctx, err)
+		return
+	}
+
+	res, err := h.workflow.CreateDefinition(ctx.Request.Context(), &req)
+	if err != nil {
+		httpx.FailWithErr(ctx, err)
+	} else {
+		httpx.OkWithData(ctx, res)
+	}
+}
+
+func (h *Workflow) EditDefinition(ctx *gin.Context) {
+	var req domain.WorkflowDefinition
+	if err := httpx.BindAndValidate(ctx, &req); err != nil {
+		httpx.FailWithErr(ctx, err)
+		return
+	}
+
+	err := h.workflow.EditDefinition(ctx.Request.Context(), &req)
+	if err != nil {
+		httpx.FailWithErr(ctx, err)
+	} else {
+		httpx.Ok(ctx)
+	}
+}
+
+// 发起流程
+func (h *Workflow) Start(ctx *gin.Context) {
+	var req domain.WorkflowStartReq
+	if err := httpx.BindAndValidate(ctx, &req); err != nil {
+		httpx.FailWithErr(ctx, err)
+		return
+	}
+
+	res, err := h.workflow.Start(ctx.Request.Context(), &req)
+	if err != nil {
+		httpx.FailWithErr(ctx, err)
+	} else {
+		httpx.OkWithData(ctx, res)
+	}
+}
+
+func (h *Workflow) Info(ctx *gin.Context) {
+	var req domain.IdPathReq
+	if err := httpx.BindAndValidate(ctx, &req); err != nil {
+		httpx.FailWithErr(ctx, err)
+		return
+	}
+
+	res, err := h.workflow.Info(ctx.Request.Context(), &req)
+	if err != nil {
+		httpx.FailWithErr(ctx, err)
+	} else {
+		httpx.OkWithData(ctx, res)
+	}
+}
+
+// 处理当前节点（通过/拒绝）
+func (h *Workflow) Dispose(ctx *gin.Context) {
+	var req domain.WorkflowDisposeReq
+	if err := httpx.BindAndValidate(ctx, &req); err != nil {
+		httpx.FailWithErr(ctx, err)
+		return
+	}
+
+	err := h.workflow.Dispose(ctx.Request.Context(), &req)
+	if err != nil {
+		httpx.FailWithErr(ctx, err)
+	} else {
+		httpx.Ok(ctx)
+	}
+}
+
+// 申请人撤销
+func (h *Workflow) Cancel(ctx *gin.Context) {
+	var req domain.IdPathReq
+	if err := httpx.BindAndValidate(ctx, &req); err != nil {
+		httpx.FailWithErr(ctx, err)
+		return
+	}
+
+	err := h.workflow.Cancel(ctx.Request.Context(), &req)
+	if err != nil {
+		httpx.FailWithErr(ctx, err)
+	} else {
+		httpx.Ok(ctx)
+	}
+}
+
+func (h *Workflow) List(ctx *gin.Context) {
+	var req domain.WorkflowListReq
+	if err := httpx.BindAndValidate(ctx, &req); err != nil {
+		httpx.FailWithErr(ctx, err)
+		return
+	}
+
+	res, err := h.workflow.List(ctx.Request.Context(), &req)
+	if err != nil {
+		httpx.FailWithErr(ctx, err)
+	} else {
+		httpx.OkWithData(ctx, res)
+	}
+}
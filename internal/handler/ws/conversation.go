@@ -2,8 +2,15 @@ package ws
 
 import (
 	"aiOffice/internal/domain"
+	"aiOffice/internal/model"
+	"aiOffice/pkg/metrics"
+	"aiOffice/pkg/panicx"
+	"aiOffice/pkg/xerr"
 	"context"
+	"encoding/json"
+	"time"
 
+	"gitee.com/dn-jinmin/tlog"
 	"github.com/gorilla/websocket"
 )
 
@@ -20,3 +27,38 @@ func (ws *Ws) groupChat(ctx context.Context, conn *websocket.Conn, req *domain.M
 	}
 	return ws.SendByUids(ctx, req)
 }
+
+// aiChat AI对话（可附带图片），模型调用耗时较长，异步处理不阻塞读循环
+func (ws *Ws) aiChat(ctx context.Context, req *domain.Message) {
+	defer ws.svc.Panic.Recover(ctx, panicx.SourceWs, "aiChat")
+	start := time.Now()
+	resp, err := ws.chat.AIChat(ctx, &domain.ChatReq{
+		Prompts:        req.Content,
+		ConversationId: req.ConversationId,
+		Images:         req.Images,
+	})
+	metrics.WebsocketHandleDuration.WithLabelValues("ai").Observe(time.Since(start).Seconds())
+	if err != nil {
+		tlog.ErrorfCtx(ctx, "aiChat", "ai chat fail %v, uid:%v", err.Error(), req.SendId)
+		ws.SendByUids(ctx, &domain.WsNotice{
+			Type: "notice",
+			Code: "ai_failed",
+			Msg:  xerr.Message(err),
+		}, req.SendId)
+		return
+	}
+
+	content, ok := resp.Data.(string)
+	if !ok {
+		b, _ := json.Marshal(resp.Data)
+		content = string(b)
+	}
+	ws.SendByUids(ctx, &domain.Message{
+		ConversationId: "ai_" + req.SendId,
+		SendId:         "ai",
+		RecvId:         req.SendId,
+		ChatType:       int(model.AIChatType),
+		Content:        content,
+		ContentType:    1,
+	}, req.SendId)
+}
@@ -0,0 +1,106 @@
+package ws
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"aiOffice/internal/config"
+	"aiOffice/pkg/limiter"
+)
+
+const (
+	defaultConnRate      = 5  // 单连接每秒消息数
+	defaultConnBurst     = 10 // 单连接突发消息数
+	defaultUserRate      = 10 // 单用户每秒消息数
+	defaultUserBurst     = 20 // 单用户突发消息数
+	defaultMaxViolations = 20 // 一分钟内超限次数达到后断开连接
+
+	violationWindow = time.Minute
+)
+
+// rateLimit WS消息限流，单连接和单用户两个维度，防止刷屏打爆后端的LLM处理
+type rateLimit struct {
+	users         *limiter.KeyedLimiter
+	connRate      float64
+	connBurst     int
+	maxViolations int
+}
+
+func newRateLimit(c config.Config) *rateLimit {
+	cfg := c.Ws.RateLimit
+
+	r := &rateLimit{
+		connRate:      cfg.ConnRate,
+		connBurst:     cfg.ConnBurst,
+		maxViolations: cfg.MaxViolations,
+	}
+	if r.connRate <= 0 {
+		r.connRate = defaultConnRate
+	}
+	if r.connBurst <= 0 {
+		r.connBurst = defaultConnBurst
+	}
+	if r.maxViolations <= 0 {
+		r.maxViolations = defaultMaxViolations
+	}
+
+	userRate, userBurst := cfg.UserRate, cfg.UserBurst
+	if userRate <= 0 {
+		userRate = defaultUserRate
+	}
+	if userBurst <= 0 {
+		userBurst = defaultUserBurst
+	}
+	r.users = limiter.NewKeyedLimiter(userRate, userBurst)
+
+	return r
+}
+
+// connLimit 单个连接的限流状态
+type connLimit struct {
+	*rateLimit
+	uid         string
+	bucket      *limiter.TokenBucket
+	violations  int
+	windowStart time.Time
+}
+
+func (r *rateLimit) newConnLimit(uid string) *connLimit {
+	return &connLimit{
+		rateLimit:   r,
+		uid:         uid,
+		bucket:      limiter.NewTokenBucket(r.connRate, r.connBurst),
+		windowStart: time.Now(),
+	}
+}
+
+// allow 判断消息是否放行，abusive 为 true 表示需要断开连接
+func (c *connLimit) allow() (ok bool, abusive bool) {
+	if c.bucket.Allow() && c.users.Allow(c.uid) {
+		return true, false
+	}
+
+	now := time.Now()
+	if now.Sub(c.windowStart) > violationWindow {
+		c.violations = 0
+		c.windowStart = now
+	}
+	c.violations++
+	return false, c.violations >= c.maxViolations
+}
+
+// closeReason 结构化的关闭原因，放在 close frame 中（最多123字节）
+type closeReason struct {
+	Code string `json:"code"`
+	Msg  string `json:"msg"`
+}
+
+// closeWithReason 发送 close frame 后关闭连接
+func (ws *Ws) closeWithReason(conn *websocket.Conn, code int, reason closeReason) {
+	b, _ := json.Marshal(reason)
+	_ = conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, string(b)),
+		time.Now().Add(time.Second))
+	ws.closeConn(conn)
+}
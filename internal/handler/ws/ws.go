@@ -5,6 +5,12 @@ import (
 	"aiOffice/internal/logic"
 	"aiOffice/internal/model"
 	"aiOffice/internal/svc"
+	"aiOffice/pkg/i18n"
+	"aiOffice/pkg/metrics"
+	"aiOffice/pkg/notify"
+	"aiOffice/pkg/panicx"
+	"aiOffice/pkg/requestid"
+	"aiOffice/pkg/tlsx"
 	"aiOffice/pkg/token"
 	"context"
 	"encoding/json"
@@ -12,6 +18,7 @@ import (
 	"fmt"
 	"net/http"
 	"sync"
+	"time"
 
 	"gitee.com/dn-jinmin/tlog"
 	"github.com/gorilla/websocket"
@@ -26,17 +33,17 @@ type Ws struct {
 	sync.RWMutex
 	tokenparse *token.Parse
 	chat       logic.Chat
+	limit      *rateLimit
+
+	srv      *http.Server
+	draining bool           // 关闭中，不再接受新连接
+	conns    sync.WaitGroup // 正在处理的连接和AI回复
+
+	cancel context.CancelFunc // 停止接收其他进程转发的广播
 }
 
 func NewWs(svc *svc.ServiceContext) *Ws {
-	// 初始化日志
-	tlog.Init(
-		tlog.WithLoggerWriter(tlog.NewLoggerWriter()),
-		tlog.WithLabel(svc.Config.Tlog.Label),
-		tlog.WithMode(svc.Config.Tlog.Mode),
-	)
-
-	return &Ws{
+	ws := &Ws{
 		Upgrader: websocket.Upgrader{
 			CheckOrigin: func(r *http.Request) bool {
 				return true
@@ -44,24 +51,30 @@ func NewWs(svc *svc.ServiceContext) *Ws {
 		},
 		svc:        svc,
 		chat:       logic.NewChat(svc),
-		tokenparse: token.NewTokenParse(svc.Config.Jwt.Secret),
+		tokenparse: token.NewKeysParse(svc.JwtKeys),
+		limit:      newRateLimit(svc.Config),
 		uidToConn:  make(map[string]*websocket.Conn), // 初始化用户ID到连接的映射
 		connToUid:  make(map[*websocket.Conn]string), // 初始化连接到用户ID的映射
 	}
+
+	// 作为通知网关的在线通道，用户在线时提醒直接走WebSocket
+	svc.Notifier.SetPresence(ws)
+
+	return ws
 }
 
 func (ws *Ws) ServeWs(w http.ResponseWriter, r *http.Request) {
-	defer func() {
-		if e := recover(); e != nil {
-			tlog.ErrorCtx(r.Context(), "serverWs", e)
-		}
-	}()
+	defer ws.svc.Panic.Recover(r.Context(), panicx.SourceWs, "ServeWs")
 	// 鉴权
 	uid, token, err := ws.auth(r)
 	if err != nil {
 		tlog.ErrorfCtx(r.Context(), "serverWs", "auth fail %v", err.Error())
 		return
 	}
+	if ws.isDraining() {
+		http.Error(w, "server is shutting down", http.StatusServiceUnavailable)
+		return
+	}
 	respHeader := http.Header{
 		"websocket": []string{token},
 	}
@@ -70,31 +83,89 @@ func (ws *Ws) ServeWs(w http.ResponseWriter, r *http.Request) {
 		tlog.ErrorfCtx(r.Context(), "serverWs", "ugrade fail %v", err)
 		return
 	}
-	ws.addConn(conn, uid)
-	go ws.HandleConn(conn, uid, token)
+	// 升级期间开始关闭时，连接不会收到close frame，直接要求重连
+	if !ws.addConn(conn, uid) {
+		reason, _ := json.Marshal(closeReason{
+			Code: "reconnect",
+			Msg:  "服务重启，请稍后重连",
+		})
+		_ = conn.WriteControl(websocket.CloseMessage,
+			websocket.FormatCloseMessage(websocket.CloseServiceRestart, string(reason)),
+			time.Now().Add(time.Second))
+		conn.Close()
+		return
+	}
+	// 记录在线状态，api、worker进程的通知经转发通道推送到这里
+	ws.svc.Notifier.SetOnline(r.Context(), uid, true)
+	lang := i18n.Parse(r.Header.Get("Accept-Language"))
+	go func() {
+		defer ws.conns.Done()
+		// 读循环panic时上报并关闭连接，不影响其他连接
+		defer func() {
+			if v := recover(); v != nil {
+				ws.svc.Panic.Report(ws.context(uid, token, lang), panicx.SourceWs, "HandleConn", v)
+				ws.closeConn(conn)
+			}
+		}()
+		ws.HandleConn(conn, uid, token, lang)
+	}()
 }
 
-func (ws *Ws) HandleConn(conn *websocket.Conn, uid string, token string) {
+func (ws *Ws) HandleConn(conn *websocket.Conn, uid string, token string, lang i18n.Lang) {
+	// 任何原因退出读循环都要移除连接，closeConn可重复调用
+	defer ws.closeConn(conn)
+
+	limit := ws.limit.newConnLimit(uid)
 	for {
 		_, msg, err := conn.ReadMessage()
 		if err != nil {
 			tlog.Errorf("serverWs", "conn.ReadMessage fail %v, uid:%v", err.Error(), uid)
-			ws.closeConn(conn)
 			return
 		}
+		metrics.WebsocketMessagesTotal.WithLabelValues("in").Inc()
+
+		ctx := ws.context(uid, token, lang)
+
+		// 限流：超限的消息直接丢弃，持续刷屏则断开连接
+		if ok, abusive := limit.allow(); !ok {
+			metrics.WebsocketMessagesTotal.WithLabelValues("dropped").Inc()
+			if abusive {
+				tlog.ErrorfCtx(ctx, "HandleConn", "rate limited, close conn, uid:%v", uid)
+				ws.closeWithReason(conn, websocket.ClosePolicyViolation, closeReason{
+					Code: "rate_limited",
+					Msg:  "消息发送过于频繁",
+				})
+				return
+			}
+			ws.SendByUids(ctx, &domain.WsNotice{
+				Type: "notice",
+				Code: "rate_limited",
+				Msg:  "消息发送过于频繁，请稍后再试",
+			}, uid)
+			continue
+		}
 
-		ctx := ws.context(uid, token)
 		var req domain.Message
 		if err := json.Unmarshal(msg, &req); err != nil {
 			tlog.ErrorfCtx(ctx, "HandleConn", "Unmarshal fail: %v", err.Error())
 			return
 		}
 		req.SendId = uid
+		start := time.Now()
 		switch model.ChatType(req.ChatType) {
 		case model.SingleChatType:
 			err = ws.privateChat(ctx, conn, &req)
+			metrics.WebsocketHandleDuration.WithLabelValues("private").Observe(time.Since(start).Seconds())
 		case model.GroupChatType:
 			err = ws.groupChat(ctx, conn, &req)
+			metrics.WebsocketHandleDuration.WithLabelValues("group").Observe(time.Since(start).Seconds())
+		case model.AIChatType:
+			// 每条AI消息作为一次请求，生成请求标识；关闭时等待回复和聊天记录写完
+			ws.conns.Add(1)
+			go func(req domain.Message) {
+				defer ws.conns.Done()
+				ws.aiChat(requestid.Ensure(ctx), &req)
+			}(req)
 		}
 		// 处理消息发送过程中的错误
 		if err != nil {
@@ -104,35 +175,133 @@ func (ws *Ws) HandleConn(conn *websocket.Conn, uid string, token string) {
 	}
 }
 
-func (ws *Ws) Run() {
-	http.HandleFunc("/ws", ws.ServeWs)
-	fmt.Println("ws服务正在运行在", ws.svc.Config.Ws.Addr)
-	http.ListenAndServe(ws.svc.Config.Ws.Addr, nil)
+// Run 启动ws服务（阻塞），调用 Shutdown 后返回nil
+func (ws *Ws) Run() error {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ws", ws.ServeWs)
+
+	srv := &http.Server{Addr: ws.svc.Config.Ws.Addr, Handler: mux}
+	ctx, cancel := context.WithCancel(context.Background())
+	ws.RWMutex.Lock()
+	ws.srv = srv
+	ws.cancel = cancel
+	ws.RWMutex.Unlock()
+
+	// 公告等广播由api、worker进程发布，在这里推送给本网关的在线用户
+	go func() {
+		if err := ws.svc.Notifier.Subscribe(ctx); err != nil {
+			fmt.Printf("[Ws] 接收广播失败: %v\n", err)
+		}
+	}()
+
+	fmt.Println("ws服务正在运行在", tlsx.Scheme(ws.svc.TLS, "ws", "wss")+"://"+ws.svc.Config.Ws.Addr)
+	if err := tlsx.ListenAndServe(srv, ws.svc.TLS); err != nil && err != http.ErrServerClosed {
+		return err
+	}
+	return nil
+}
+
+// Shutdown 优雅关闭：停止接受新连接，通知所有客户端重连，等待连接排空
+func (ws *Ws) Shutdown(ctx context.Context) error {
+	ws.RWMutex.Lock()
+	ws.draining = true
+	srv := ws.srv
+	if ws.cancel != nil {
+		ws.cancel()
+	}
+	ws.RWMutex.Unlock()
+
+	// 1.停止监听，不再接受新的升级请求（已升级的连接不受影响）
+	if srv != nil {
+		if err := srv.Shutdown(ctx); err != nil {
+			return err
+		}
+	}
+
+	// 2.持有写锁下发close frame，正在进行的发送会先完成
+	reason, _ := json.Marshal(closeReason{
+		Code: "reconnect",
+		Msg:  "服务重启，请稍后重连",
+	})
+	ws.RWMutex.Lock()
+	for conn := range ws.connToUid {
+		_ = conn.WriteControl(websocket.CloseMessage,
+			websocket.FormatCloseMessage(websocket.CloseServiceRestart, string(reason)),
+			time.Now().Add(time.Second))
+	}
+	ws.RWMutex.Unlock()
+
+	// 3.等待客户端回应关闭、读循环退出以及进行中的AI回复完成；超时则强制关闭
+	done := make(chan struct{})
+	go func() {
+		ws.conns.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		fmt.Println("ws连接已全部关闭")
+		return nil
+	case <-ctx.Done():
+		ws.RWMutex.Lock()
+		for conn := range ws.connToUid {
+			conn.Close()
+		}
+		ws.RWMutex.Unlock()
+		return ctx.Err()
+	}
+}
+
+func (ws *Ws) isDraining() bool {
+	ws.RWMutex.RLock()
+	defer ws.RWMutex.RUnlock()
+	return ws.draining
 }
 
-func (ws *Ws) addConn(conn *websocket.Conn, uid string) {
+// addConn 在锁内检查是否关闭中并增加连接计数，避免与 Shutdown 的下发close frame和等待竞争，关闭中返回false
+func (ws *Ws) addConn(conn *websocket.Conn, uid string) bool {
 	ws.RWMutex.Lock()
 	defer ws.RWMutex.Unlock()
 
+	if ws.draining {
+		return false
+	}
+
 	if conn := ws.uidToConn[uid]; conn != nil {
 		conn.Close()
 	}
 	ws.connToUid[conn] = uid
 	ws.uidToConn[uid] = conn
+	metrics.WebsocketConnections.Inc()
+	ws.conns.Add(1)
+	return true
 }
 
+// closeConn 关闭连接，用户在本网关没有其他连接时清除在线状态；可重复调用
 func (ws *Ws) closeConn(conn *websocket.Conn) {
+	if uid, offline := ws.removeConn(conn); offline {
+		ws.svc.Notifier.SetOnline(context.Background(), uid, false)
+	}
+}
+
+func (ws *Ws) removeConn(conn *websocket.Conn) (uid string, offline bool) {
 	ws.RWMutex.Lock()
 	defer ws.RWMutex.Unlock()
 
-	uid := ws.connToUid[conn]
+	uid = ws.connToUid[conn]
 	if uid == "" {
-		return
+		return "", false
 	}
-	fmt.Printf("关闭%s连接\n", uid)
+	tlog.InfoCtx(context.Background(), "ws.close", "uid", uid)
 	delete(ws.connToUid, conn)
-	delete(ws.uidToConn, uid)
+	// 用户重连后旧连接才退出读循环，此时不能删掉新连接
+	if ws.uidToConn[uid] == conn {
+		delete(ws.uidToConn, uid)
+		offline = true
+	}
 	conn.Close()
+	metrics.WebsocketConnections.Dec()
+	return uid, offline
 }
 
 func (ws *Ws) SendByConn(ctx context.Context, conn *websocket.Conn, v interface{}) error {
@@ -141,7 +310,11 @@ func (ws *Ws) SendByConn(ctx context.Context, conn *websocket.Conn, v interface{
 		tlog.ErrorCtx(ctx, "conn.send", err.Error())
 		return err
 	}
-	return conn.WriteMessage(websocket.TextMessage, buff)
+	if err := conn.WriteMessage(websocket.TextMessage, buff); err != nil {
+		return err
+	}
+	metrics.WebsocketMessagesTotal.WithLabelValues("out").Inc()
+	return nil
 }
 
 func (ws *Ws) SendByUids(ctx context.Context, msg interface{}, uids ...string) error {
@@ -170,6 +343,39 @@ func (ws *Ws) SendByUids(ctx context.Context, msg interface{}, uids ...string) e
 	return nil
 }
 
+// IsOnline 用户是否在线
+func (ws *Ws) IsOnline(uid string) bool {
+	ws.RWMutex.RLock()
+	defer ws.RWMutex.RUnlock()
+
+	_, ok := ws.uidToConn[uid]
+	return ok
+}
+
+// OnlineUsers 当前连接在本进程的用户
+func (ws *Ws) OnlineUsers() []string {
+	ws.RWMutex.RLock()
+	defer ws.RWMutex.RUnlock()
+
+	uids := make([]string, 0, len(ws.uidToConn))
+	for uid := range ws.uidToConn {
+		uids = append(uids, uid)
+	}
+	return uids
+}
+
+// Push 向在线用户推送通知
+func (ws *Ws) Push(ctx context.Context, uid string, msg *notify.Message) error {
+	ws.RWMutex.Lock()
+	defer ws.RWMutex.Unlock()
+
+	conn, ok := ws.uidToConn[uid]
+	if !ok {
+		return errors.New("用户不在线")
+	}
+	return ws.SendByConn(ctx, conn, msg)
+}
+
 func (ws *Ws) auth(r *http.Request) (uid string, tokenStr string, err error) {
 	tok := r.Header.Get("websocket")
 	if tok == "" {
@@ -179,11 +385,15 @@ func (ws *Ws) auth(r *http.Request) (uid string, tokenStr string, err error) {
 	if err != nil {
 		return "", "", err
 	}
+	if err := ws.svc.TokenBlacklist.Check(r.Context(), claim); err != nil {
+		return "", "", err
+	}
 	return claim[token.Identify].(string), tokenStr, nil
 }
 
-func (ws *Ws) context(uid, tok string) context.Context {
+func (ws *Ws) context(uid, tok string, lang i18n.Lang) context.Context {
 	ctx := context.WithValue(context.Background(), token.Identify, uid)
 	ctx = context.WithValue(ctx, token.Authorization, tok)
+	ctx = i18n.WithLang(ctx, lang)
 	return tlog.TraceStart(ctx)
 }
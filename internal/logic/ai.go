@@ -0,0 +1,104 @@
+package logic
+
+import (
+	"context"
+
+	"aiOffice/internal/domain"
+	"aiOffice/internal/model"
+	"aiOffice/internal/svc"
+	"aiOffice/pkg/timeutils"
+	"aiOffice/pkg/token"
+	"aiOffice/pkg/xerr"
+)
+
+var (
+	ErrQuotaExceeded = xerr.NewCode(xerr.TooManyRequests, "今日AI使用额度已用完，请明天再试")
+	ErrAIRateLimited = xerr.NewCode(xerr.TooManyRequests, "AI请求过于频繁，请稍后再试")
+)
+
+type AI interface {
+	// token用量
+	Usage(ctx context.Context, req *domain.AIUsageReq) (resp *domain.AIUsageResp, err error)
+}
+
+type ai struct {
+	svcCtx *svc.ServiceContext
+}
+
+func NewAI(svcCtx *svc.ServiceContext) AI {
+	return &ai{
+		svcCtx: svcCtx,
+	}
+}
+
+func (l *ai) Usage(ctx context.Context, req *domain.AIUsageReq) (resp *domain.AIUsageResp, err error) {
+	uid := token.GetUid(ctx)
+
+	days := req.Days
+	if days <= 0 || days > 90 {
+		days = 7
+	}
+	now := timeutils.NowTime()
+	today := now.Format("2006-01-02")
+	start := now.AddDate(0, 0, 1-days).Format("2006-01-02")
+
+	list, err := l.svcCtx.AIUsageModel.List(ctx, uid, start, today)
+	if err != nil {
+		return nil, xerr.WithMessage(err, "查询AI用量失败")
+	}
+
+	resp = &domain.AIUsageResp{
+		DailyQuota: l.svcCtx.Config.LangChain.Quota.DailyTokens,
+		Today:      &domain.AIUsage{Date: today},
+		List:       make([]*domain.AIUsage, 0, len(list)),
+	}
+	for _, v := range list {
+		item := toAIUsage(v)
+		if v.Date == today {
+			resp.Today = item
+		}
+		resp.List = append(resp.List, item)
+	}
+	return resp, nil
+}
+
+// checkQuota 校验用户当天的token额度，额度按调用后累计，最后一次请求可能略微超出
+func checkQuota(ctx context.Context, svcCtx *svc.ServiceContext, uid string) error {
+	quota := svcCtx.Config.LangChain.Quota.DailyTokens
+	if quota <= 0 || uid == "" {
+		return nil
+	}
+
+	usage, err := svcCtx.AIUsageModel.FindOne(ctx, uid, timeutils.Format(timeutils.Now()))
+	if err != nil {
+		return xerr.WithMessage(err, "查询AI用量失败")
+	}
+	if usage.TotalTokens >= quota {
+		// 额度在次日零点恢复
+		now := timeutils.NowTime()
+		tomorrow := timeutils.StartOfDay(now).AddDate(0, 0, 1)
+		return xerr.WithRetryAfter(ErrQuotaExceeded, tomorrow.Sub(now))
+	}
+	return nil
+}
+
+// checkRateLimit 校验用户的AI请求频率
+func checkRateLimit(ctx context.Context, svcCtx *svc.ServiceContext, uid string) error {
+	if svcCtx.AILimiter == nil || uid == "" {
+		return nil
+	}
+	if ok, after := svcCtx.AILimiter.Reserve(ctx, uid); !ok {
+		return xerr.WithRetryAfter(ErrAIRateLimited, after)
+	}
+	return nil
+}
+
+func toAIUsage(v *model.AIUsage) *domain.AIUsage {
+	return &domain.AIUsage{
+		Date:             v.Date,
+		PromptTokens:     v.PromptTokens,
+		CompletionTokens: v.CompletionTokens,
+		TotalTokens:      v.TotalTokens,
+		Calls:            v.Calls,
+	}
+}
@@ -0,0 +1,297 @@
+package logic
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"aiOffice/internal/domain"
+	"aiOffice/internal/model"
+	"aiOffice/internal/svc"
+	"aiOffice/pkg/notify"
+	"aiOffice/pkg/pagex"
+	"aiOffice/pkg/timeutils"
+	"aiOffice/pkg/token"
+	"aiOffice/pkg/xerr"
+)
+
+var (
+	ErrAnnouncementNotFound = xerr.NewCode(xerr.NotFound, "公告不存在")
+	ErrAnnouncementExpire   = xerr.NewCode(xerr.Invalid, "过期时间需晚于发布时间")
+)
+
+const (
+	notifyTypeAnnouncement = "announcement:publish"
+
+	announcementDeliverBatch = 100 // 每批推送的公告数
+	notifyCenterScan         = 100 // 通知中心统计未读时查看的最近公告数
+	notifyCenterSize         = 5   // 通知中心展示的未读公告数
+)
+
+type Announcement interface {
+	// 发布公告，发布时间已到时立即推送给在线用户
+	Create(ctx context.Context, req *domain.Announcement) (resp *domain.IdResp, err error)
+	Edit(ctx context.Context, req *domain.Announcement) (err error)
+	Delete(ctx context.Context, req *domain.IdPathReq) (err error)
+	// 全部公告及已读人数，供管理员查看
+	Manage(ctx context.Context, req *domain.AnnouncementListReq) (resp *domain.AnnouncementListResp, err error)
+	// 当前用户可见的公告
+	List(ctx context.Context, req *domain.AnnouncementListReq) (resp *domain.AnnouncementListResp, err error)
+	// 查看公告并标记已读
+	Info(ctx context.Context, req *domain.IdPathReq) (resp *domain.Announcement, err error)
+	// 推送发布时间已到的公告，由定时任务每分钟调用
+	DeliverDue(ctx context.Context) (err error)
+}
+
+type announcement struct {
+	svcCtx *svc.ServiceContext
+}
+
+func NewAnnouncement(svcCtx *svc.ServiceContext) Announcement {
+	return &announcement{
+		svcCtx: svcCtx,
+	}
+}
+
+func (l *announcement) Create(ctx context.Context, req *domain.Announcement) (resp *domain.IdResp, err error) {
+	data, err := l.toModel(req)
+	if err != nil {
+		return nil, err
+	}
+	data.PublisherId = token.GetUid(ctx)
+
+	if err := l.svcCtx.AnnouncementModel.Insert(ctx, data); err != nil {
+		return nil, xerr.WithMessage(err, "发布公告失败")
+	}
+	if data.PublishAt <= timeutils.Now() {
+		go l.deliver(context.Background(), data)
+	}
+	return &domain.IdResp{Id: data.ID.Hex()}, nil
+}
+
+// Edit 已推送的公告修改后不再重复推送，发布时间改到以后时按新的时间推送
+func (l *announcement) Edit(ctx context.Context, req *domain.Announcement) (err error) {
+	old, err := l.find(ctx, req.Id)
+	if err != nil {
+		return err
+	}
+
+	data, err := l.toModel(req)
+	if err != nil {
+		return err
+	}
+	data.ID, data.PublisherId, data.CreateAt = old.ID, old.PublisherId, old.CreateAt
+	data.Delivered = old.Delivered && data.PublishAt <= timeutils.Now()
+	if err := l.svcCtx.AnnouncementModel.Update(ctx, data); err != nil {
+		return xerr.WithMessage(err, "更新公告失败")
+	}
+	return nil
+}
+
+func (l *announcement) Delete(ctx context.Context, req *domain.IdPathReq) (err error) {
+	if _, err := l.find(ctx, req.Id); err != nil {
+		return err
+	}
+	if err := l.svcCtx.AnnouncementModel.Delete(ctx, req.Id); err != nil {
+		return xerr.WithMessage(err, "删除公告失败")
+	}
+	if err := l.svcCtx.AnnouncementReadModel.DeleteByAnnouncementId(ctx, req.Id); err != nil {
+		return xerr.WithMessage(err, "删除公告已读记录失败")
+	}
+	return nil
+}
+
+func (l *announcement) Manage(ctx context.Context, req *domain.AnnouncementListReq) (resp *domain.AnnouncementListResp, err error) {
+	list, total, err := l.svcCtx.AnnouncementModel.List(ctx, pagex.FromRequest(req.Page, req.Count, ""))
+	if err != nil {
+		return nil, xerr.WithMessage(err, "查询公告失败")
+	}
+
+	resp = &domain.AnnouncementListResp{Count: total, List: make([]*domain.Announcement, 0, len(list))}
+	for _, v := range list {
+		item := toDomainAnnouncement(v)
+		if item.ReadCount, err = l.svcCtx.AnnouncementReadModel.Count(ctx, item.Id); err != nil {
+			return nil, xerr.WithMessage(err, "查询公告已读人数失败")
+		}
+		resp.List = append(resp.List, item)
+	}
+	return resp, nil
+}
+
+func (l *announcement) List(ctx context.Context, req *domain.AnnouncementListReq) (resp *domain.AnnouncementListResp, err error) {
+	list, total, err := userAnnouncements(ctx, l.svcCtx, token.GetUid(ctx), pagex.FromRequest(req.Page, req.Count, ""))
+	if err != nil {
+		return nil, err
+	}
+
+	resp = &domain.AnnouncementListResp{Count: total, List: list}
+	for _, v := range list {
+		if !v.Read {
+			resp.Unread++
+		}
+	}
+	return resp, nil
+}
+
+// Info 只能查看对自己可见的公告，管理员可以查看全部
+func (l *announcement) Info(ctx context.Context, req *domain.IdPathReq) (resp *domain.Announcement, err error) {
+	uid := token.GetUid(ctx)
+	data, err := l.find(ctx, req.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	depIds, err := userDepIds(ctx, l.svcCtx, uid)
+	if err != nil {
+		return nil, err
+	}
+	if !data.Visible(depIds, timeutils.Now()) {
+		user, err := l.svcCtx.UserModel.FindOne(ctx, uid)
+		if err != nil || !user.IsAdmin {
+			return nil, ErrAnnouncementNotFound
+		}
+		return toDomainAnnouncement(data), nil
+	}
+
+	if err := l.svcCtx.AnnouncementReadModel.MarkRead(ctx, req.Id, uid); err != nil {
+		return nil, xerr.WithMessage(err, "记录公告已读失败")
+	}
+	resp = toDomainAnnouncement(data)
+	resp.Read = true
+	return resp, nil
+}
+
+func (l *announcement) DeliverDue(ctx context.Context) (err error) {
+	for {
+		list, err := l.svcCtx.AnnouncementModel.FindUndelivered(ctx, timeutils.Now(), announcementDeliverBatch)
+		if err != nil {
+			return xerr.WithMessage(err, "查询待推送公告失败")
+		}
+
+		for _, v := range list {
+			l.deliver(ctx, v)
+		}
+		if len(list) < announcementDeliverBatch {
+			return nil
+		}
+	}
+}
+
+// deliver 标记为已推送后通过WS网关推送给目标部门的在线用户，推送失败时取消标记等待下次检查；
+// 离线用户上线后在通知中心查看
+func (l *announcement) deliver(ctx context.Context, data *model.Announcement) {
+	var uids []string
+	if len(data.DepIds) > 0 {
+		users, err := l.svcCtx.DepartmentuserModel.FindByDepIds(ctx, data.DepIds)
+		if err != nil {
+			fmt.Printf("[Announcement] 查询目标部门用户失败, id: %s, err: %v\n", data.ID.Hex(), err)
+			return
+		}
+		seen := make(map[string]bool, len(users))
+		uids = make([]string, 0, len(users))
+		for _, v := range users {
+			if !seen[v.UserId] {
+				seen[v.UserId] = true
+				uids = append(uids, v.UserId)
+			}
+		}
+	}
+
+	ok, err := l.svcCtx.AnnouncementModel.SetDelivered(ctx, data.ID)
+	if err != nil {
+		fmt.Printf("[Announcement] 更新推送状态失败, id: %s, err: %v\n", data.ID.Hex(), err)
+		return
+	}
+	if !ok {
+		return
+	}
+
+	err = l.svcCtx.Notifier.Broadcast(ctx, uids, &notify.Message{
+		Type:    notifyTypeAnnouncement,
+		Title:   "公司公告",
+		Content: data.Title,
+		Data:    map[string]string{"announcementId": data.ID.Hex()},
+		Time:    time.Now().Unix(),
+	})
+	if err != nil {
+		fmt.Printf("[Announcement] 推送公告失败, id: %s, err: %v\n", data.ID.Hex(), err)
+		if err := l.svcCtx.AnnouncementModel.ResetDelivered(ctx, data.ID); err != nil {
+			fmt.Printf("[Announcement] 取消推送状态失败, id: %s, err: %v\n", data.ID.Hex(), err)
+		}
+	}
+}
+
+func (l *announcement) find(ctx context.Context, id string) (*model.Announcement, error) {
+	data, err := l.svcCtx.AnnouncementModel.FindOne(ctx, id)
+	if err != nil {
+		if errors.Is(err, model.ErrNotFound) || errors.Is(err, model.ErrInvalidObjectId) {
+			return nil, ErrAnnouncementNotFound
+		}
+		return nil, xerr.WithMessage(err, "查询公告失败")
+	}
+	return data, nil
+}
+
+// toModel 校验请求并转换为数据模型，发布时间默认为当前时间
+func (l *announcement) toModel(req *domain.Announcement) (*model.Announcement, error) {
+	data := &model.Announcement{
+		Title:     req.Title,
+		Content:   req.Content,
+		DepIds:    req.DepIds,
+		Pinned:    req.Pinned,
+		PublishAt: req.PublishAt,
+		ExpireAt:  req.ExpireAt,
+	}
+	if data.PublishAt <= 0 {
+		data.PublishAt = timeutils.Now()
+	}
+	if data.ExpireAt > 0 && data.ExpireAt <= data.PublishAt {
+		return nil, ErrAnnouncementExpire
+	}
+	return data, nil
+}
+
+// userAnnouncements 用户可见的公告，附带是否已读
+func userAnnouncements(ctx context.Context, svcCtx *svc.ServiceContext, uid string, page pagex.Page) ([]*domain.Announcement, int64, error) {
+	depIds, err := userDepIds(ctx, svcCtx, uid)
+	if err != nil {
+		return nil, 0, err
+	}
+	list, total, err := svcCtx.AnnouncementModel.ListVisible(ctx, depIds, timeutils.Now(), page)
+	if err != nil {
+		return nil, 0, xerr.WithMessage(err, "查询公告失败")
+	}
+
+	ids := make([]string, 0, len(list))
+	for _, v := range list {
+		ids = append(ids, v.ID.Hex())
+	}
+	read, err := svcCtx.AnnouncementReadModel.FindRead(ctx, uid, ids)
+	if err != nil {
+		return nil, 0, xerr.WithMessage(err, "查询公告已读记录失败")
+	}
+
+	res := make([]*domain.Announcement, 0, len(list))
+	for _, v := range list {
+		item := toDomainAnnouncement(v)
+		item.Read = read[item.Id]
+		res = append(res, item)
+	}
+	return res, total, nil
+}
+
+func toDomainAnnouncement(data *model.Announcement) *domain.Announcement {
+	return &domain.Announcement{
+		Id:          data.ID.Hex(),
+		Title:       data.Title,
+		Content:     data.Content,
+		PublisherId: data.PublisherId,
+		DepIds:      data.DepIds,
+		Pinned:      data.Pinned,
+		PublishAt:   data.PublishAt,
+		ExpireAt:    data.ExpireAt,
+		UpdateAt:    data.UpdateAt,
+		CreateAt:    data.CreateAt,
+	}
+}
@@ -2,11 +2,15 @@ package logic
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
 	"aiOffice/internal/domain"
 	"aiOffice/internal/model"
 	"aiOffice/internal/svc"
+	"aiOffice/pkg/mongoutils"
+	"aiOffice/pkg/notify"
+	"aiOffice/pkg/pagex"
 	"aiOffice/pkg/timeutils"
 	"aiOffice/pkg/xerr"
 )
@@ -15,6 +19,9 @@ var (
 	ErrApprovalNotFound = fmt.Errorf("审批不存在")
 )
 
+// 审批结果通知的消息类型
+const notifyTypeApprovalResult = "approval:result"
+
 type Approval interface {
 	Info(ctx context.Context, req *domain.IdPathReq) (resp *domain.ApprovalInfoResp, err error)
 	Create(ctx context.Context, req *domain.Approval) (resp *domain.IdResp, err error)
@@ -36,7 +43,7 @@ func NewApproval(svcCtx *svc.ServiceContext) Approval {
 func (l *approval) Info(ctx context.Context, req *domain.IdPathReq) (resp *domain.ApprovalInfoResp, err error) {
 	approvalData, err := l.svcCtx.ApprovalModel.FindOne(ctx, req.Id)
 	if err != nil {
-		if err == model.ErrNotFound {
+		if errors.Is(err, model.ErrNotFound) {
 			return nil, ErrApprovalNotFound
 		}
 		return nil, xerr.WithMessage(err, "查询审批失败")
@@ -44,6 +51,9 @@ func (l *approval) Info(ctx context.Context, req *domain.IdPathReq) (resp *domai
 
 	// 使用model中的转换方法
 	resp = approvalData.ToDomainApprovalInfo()
+	if resp.MakeCard != nil && resp.MakeCard.Record != nil {
+		attendanceStatus(l.svcCtx, resp.MakeCard.Record)
+	}
 
 	// 获取申请人信息
 	user, err := l.svcCtx.UserModel.FindOne(ctx, approvalData.UserId)
@@ -123,6 +133,9 @@ func (l *approval) Create(ctx context.Context, req *domain.Approval) (resp *doma
 				Day:       req.MakeCard.Day,
 				CheckType: model.WorkCheckType(req.MakeCard.CheckType),
 			}
+			if err := prepareMakeCard(ctx, l.svcCtx, req.UserId, approvalData.MakeCard); err != nil {
+				return nil, err
+			}
 			approvalData.Title = model.ApprovalType(req.Type).ToString()
 		}
 	case model.GoOutApproval:
@@ -149,55 +162,90 @@ func (l *approval) Create(ctx context.Context, req *domain.Approval) (resp *doma
 
 // Dispose 处理审批（通过/拒绝）
 func (l *approval) Dispose(ctx context.Context, req *domain.DisposeReq) (err error) {
-	approvalData, err := l.svcCtx.ApprovalModel.FindOne(ctx, req.ApprovalId)
-	if err != nil {
-		if err == model.ErrNotFound {
-			return ErrApprovalNotFound
+	// 读取和更新在同一事务中，并发处理同一审批时只有一个生效
+	var approvalData *model.Approval
+	err = mongoutils.WithTransaction(ctx, l.svcCtx.Mongo, func(ctx context.Context) error {
+		approvalData, err = l.svcCtx.ApprovalModel.FindOne(ctx, req.ApprovalId)
+		if err != nil {
+			if errors.Is(err, model.ErrNotFound) {
+				return ErrApprovalNotFound
+			}
+			return xerr.WithMessage(err, "查询审批失败")
 		}
-		return xerr.WithMessage(err, "查询审批失败")
-	}
 
-	// 检查审批状态是否为处理中
-	if approvalData.Status != model.Processed {
-		return xerr.New(fmt.Errorf("审批已处理"))
-	}
+		// 检查审批状态是否为处理中
+		if approvalData.Status != model.Processed {
+			return xerr.New(xerr.NewCode(xerr.Conflict, "审批已处理"))
+		}
 
-	// 更新当前审批人的状态
-	if approvalData.ApprovalIdx < len(approvalData.Approvers) {
-		approvalData.Approvers[approvalData.ApprovalIdx].Status = model.ApprovalStatus(req.Status)
-		approvalData.Approvers[approvalData.ApprovalIdx].Reason = req.Reason
-	}
-
-	// 根据处理结果更新审批状态
-	switch model.ApprovalStatus(req.Status) {
-	case model.Pass:
-		// 检查是否还有下一个审批人
-		if approvalData.ApprovalIdx+1 < len(approvalData.Approvers) {
-			// 移动到下一个审批人
-			approvalData.ApprovalIdx++
-			approvalData.ApprovalId = approvalData.Approvers[approvalData.ApprovalIdx].UserId
-		} else {
-			// 所有审批人都通过，审批完成
-			approvalData.Status = model.Pass
+		// 更新当前审批人的状态
+		if approvalData.ApprovalIdx < len(approvalData.Approvers) {
+			approvalData.Approvers[approvalData.ApprovalIdx].Status = model.ApprovalStatus(req.Status)
+			approvalData.Approvers[approvalData.ApprovalIdx].Reason = req.Reason
+		}
+
+		// 根据处理结果更新审批状态
+		switch model.ApprovalStatus(req.Status) {
+		case model.Pass:
+			// 检查是否还有下一个审批人
+			if approvalData.ApprovalIdx+1 < len(approvalData.Approvers) {
+				// 移动到下一个审批人
+				approvalData.ApprovalIdx++
+				approvalData.ApprovalId = approvalData.Approvers[approvalData.ApprovalIdx].UserId
+			} else {
+				// 所有审批人都通过，审批完成
+				approvalData.Status = model.Pass
+				approvalData.FinishAt, approvalData.FinishDay, approvalData.FinishMonth, approvalData.FinishYeas = timeutils.FinishTime()
+				if approvalData.Type == model.MakeCardApproval {
+					if err := applyMakeCard(ctx, l.svcCtx, approvalData); err != nil {
+						return err
+					}
+				}
+			}
+		case model.Refuse:
+			// 拒绝，审批结束
+			approvalData.Status = model.Refuse
 			approvalData.FinishAt, approvalData.FinishDay, approvalData.FinishMonth, approvalData.FinishYeas = timeutils.FinishTime()
 		}
-	case model.Refuse:
-		// 拒绝，审批结束
-		approvalData.Status = model.Refuse
-		approvalData.FinishAt, approvalData.FinishDay, approvalData.FinishMonth, approvalData.FinishYeas = timeutils.FinishTime()
-	}
 
-	err = l.svcCtx.ApprovalModel.Update(ctx, approvalData)
+		if err := l.svcCtx.ApprovalModel.Update(ctx, approvalData); err != nil {
+			return xerr.WithMessage(err, "更新审批失败")
+		}
+		return nil
+	})
 	if err != nil {
-		return xerr.WithMessage(err, "更新审批失败")
+		return err
 	}
 
+	if approvalData.Status == model.Pass || approvalData.Status == model.Refuse {
+		go l.notifyResult(approvalData, req.Reason)
+	}
 	return nil
 }
 
+// notifyResult 审批结束后通知申请人，失败只记录
+func (l *approval) notifyResult(approvalData *model.Approval, reason string) {
+	result := "已通过"
+	if approvalData.Status == model.Refuse {
+		result = "被拒绝"
+	}
+	content := fmt.Sprintf("您的审批「%s」%s", approvalData.Title, result)
+	if reason != "" {
+		content += "，审批意见：" + reason
+	}
+
+	pushNotify(context.Background(), l.svcCtx, approvalData.UserId, &notify.Message{
+		Type:    notifyTypeApprovalResult,
+		Title:   "审批结果",
+		Content: content,
+		Data:    map[string]string{"approvalId": approvalData.ID.Hex()},
+		Urgent:  approvalData.Status == model.Refuse, // 被拒绝时离线可通过短信送达
+	})
+}
+
 // List 审批列表
 func (l *approval) List(ctx context.Context, req *domain.ApprovalListReq) (resp *domain.ApprovalListResp, err error) {
-	approvals, total, err := l.svcCtx.ApprovalModel.List(ctx, req.UserId, req.Type, req.Page, req.Count)
+	approvals, total, err := l.svcCtx.ApprovalModel.List(ctx, req.UserId, req.Type, pagex.FromRequest(req.Page, req.Count, req.Sort))
 	if err != nil {
 		return nil, xerr.WithMessage(err, "查询审批列表失败")
 	}
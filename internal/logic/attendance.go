@@ -0,0 +1,250 @@
+package logic
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"aiOffice/internal/domain"
+	"aiOffice/internal/model"
+	"aiOffice/internal/svc"
+	"aiOffice/pkg/timeutils"
+	"aiOffice/pkg/token"
+	"aiOffice/pkg/xerr"
+)
+
+var (
+	ErrAttendanceChecked = xerr.NewCode(xerr.Conflict, "今天已打过上班卡")
+	ErrAttendanceDay     = xerr.NewCode(xerr.Invalid, "日期格式不正确")
+	ErrMakeCardType      = xerr.NewCode(xerr.Invalid, "请选择补上班卡还是下班卡")
+	ErrMakeCardFuture    = xerr.NewCode(xerr.Invalid, "不能为以后的日期补卡")
+	ErrMakeCardNotNeeded = xerr.NewCode(xerr.Conflict, "当天已有该打卡记录，无需补卡")
+)
+
+// 未配置时的上下班时间
+const (
+	defaultWorkStart = "09:00"
+	defaultWorkEnd   = "18:00"
+)
+
+type Attendance interface {
+	// 打卡
+	Check(ctx context.Context, req *domain.AttendanceCheckReq) (resp *domain.Attendance, err error)
+	// 某一天的考勤
+	Day(ctx context.Context, req *domain.AttendanceDayReq) (resp *domain.Attendance, err error)
+	// 月度考勤统计
+	Month(ctx context.Context, req *domain.AttendanceMonthReq) (resp *domain.AttendanceMonthResp, err error)
+}
+
+type attendance struct {
+	svcCtx *svc.ServiceContext
+}
+
+func NewAttendance(svcCtx *svc.ServiceContext) Attendance {
+	return &attendance{
+		svcCtx: svcCtx,
+	}
+}
+
+// Check 上班卡只记录第一次，下班卡以最后一次为准
+func (l *attendance) Check(ctx context.Context, req *domain.AttendanceCheckReq) (resp *domain.Attendance, err error) {
+	uid := token.GetUid(ctx)
+	now := timeutils.NowTime()
+	day := timeutils.DayNumber(now)
+	checkType := model.WorkCheckType(req.CheckType)
+
+	if checkType == model.OnWorkCheck {
+		// 上班卡只记录第一次，同时提交多次时只有一次写入成功
+		ok, err := l.svcCtx.AttendanceModel.SetCheckIfEmpty(ctx, uid, day, checkType, now.Unix(), model.PunchSource, "")
+		if err != nil {
+			return nil, xerr.WithMessage(err, "打卡失败")
+		}
+		if !ok {
+			return nil, ErrAttendanceChecked
+		}
+	} else if err := l.svcCtx.AttendanceModel.SetCheck(ctx, uid, day, checkType, now.Unix(), model.PunchSource, ""); err != nil {
+		return nil, xerr.WithMessage(err, "打卡失败")
+	}
+	return l.Day(ctx, &domain.AttendanceDayReq{Day: day})
+}
+
+// Day 当前用户某一天的考勤，默认今天
+func (l *attendance) Day(ctx context.Context, req *domain.AttendanceDayReq) (resp *domain.Attendance, err error) {
+	day := req.Day
+	if day == 0 {
+		day = timeutils.DayNumber(timeutils.NowTime())
+	}
+	if _, err := timeutils.ParseDayNumber(day); err != nil {
+		return nil, ErrAttendanceDay
+	}
+
+	record, err := findAttendance(ctx, l.svcCtx, token.GetUid(ctx), day)
+	if err != nil {
+		return nil, err
+	}
+	return attendanceStatus(l.svcCtx, record.ToDomainAttendance()), nil
+}
+
+// Month 统计到今天为止的工作日，没有任何打卡的工作日按缺卡统计
+func (l *attendance) Month(ctx context.Context, req *domain.AttendanceMonthReq) (resp *domain.AttendanceMonthResp, err error) {
+	now := timeutils.NowTime()
+	start := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, timeutils.Location())
+	if req.Month != "" {
+		if start, err = time.ParseInLocation("2006-01", req.Month, timeutils.Location()); err != nil {
+			return nil, ErrAttendanceDay
+		}
+	}
+	end := start.AddDate(0, 1, -1)
+
+	list, err := l.svcCtx.AttendanceModel.ListByUserDays(ctx, token.GetUid(ctx), timeutils.DayNumber(start), timeutils.DayNumber(end))
+	if err != nil {
+		return nil, xerr.WithMessage(err, "查询考勤记录失败")
+	}
+	records := make(map[int64]*model.Attendance, len(list))
+	for _, v := range list {
+		records[v.Day] = v
+	}
+
+	resp = &domain.AttendanceMonthResp{
+		Month: start.Format("2006-01"),
+		Days:  make([]*domain.Attendance, 0, end.Day()),
+	}
+	today := timeutils.DayNumber(now)
+	for d := start; !d.After(end); d = d.AddDate(0, 0, 1) {
+		day := timeutils.DayNumber(d)
+		if day > today {
+			break
+		}
+		record, ok := records[day]
+		workday := d.Weekday() != time.Saturday && d.Weekday() != time.Sunday
+		if !workday && !ok {
+			continue
+		}
+		if !ok {
+			record = &model.Attendance{Day: day}
+		}
+
+		item := attendanceStatus(l.svcCtx, record.ToDomainAttendance())
+		if workday {
+			resp.WorkDays++
+		}
+		if item.Normal {
+			resp.Normal++
+		}
+		if item.Late {
+			resp.Late++
+		}
+		if item.Early {
+			resp.Early++
+		}
+		if item.MissingIn || item.MissingOut {
+			resp.Missing++
+		}
+		resp.Days = append(resp.Days, item)
+	}
+	return resp, nil
+}
+
+// findAttendance 没有记录时返回当天的空记录
+func findAttendance(ctx context.Context, svcCtx *svc.ServiceContext, userId string, day int64) (*model.Attendance, error) {
+	record, err := svcCtx.AttendanceModel.FindByUserDay(ctx, userId, day)
+	switch {
+	case err == nil:
+		return record, nil
+	case errors.Is(err, model.ErrNotFound):
+		return &model.Attendance{UserId: userId, Day: day}, nil
+	default:
+		return nil, xerr.WithMessage(err, "查询考勤记录失败")
+	}
+}
+
+// attendanceStatus 按上下班时间计算迟到、早退和缺卡，当天未到上下班时间的不算缺卡
+func attendanceStatus(svcCtx *svc.ServiceContext, a *domain.Attendance) *domain.Attendance {
+	day, err := timeutils.ParseDayNumber(a.Day)
+	if err != nil {
+		return a
+	}
+	now := timeutils.Now()
+	start := workTime(svcCtx, day, model.OnWorkCheck).Unix()
+	end := workTime(svcCtx, day, model.OffWorkCheck).Unix()
+
+	a.Late = a.CheckIn > start
+	a.Early = a.CheckOut > 0 && a.CheckOut < end
+	a.MissingIn = a.CheckIn == 0 && now > start
+	a.MissingOut = a.CheckOut == 0 && now > end
+	a.Normal = now > end && !a.Late && !a.Early && !a.MissingIn && !a.MissingOut
+	return a
+}
+
+// workTime 当天的上班或下班时间，配置已在启动时校验
+func workTime(svcCtx *svc.ServiceContext, day time.Time, checkType model.WorkCheckType) time.Time {
+	hm := cmp.Or(svcCtx.Config.Attendance.WorkStart, defaultWorkStart)
+	if checkType == model.OffWorkCheck {
+		hm = cmp.Or(svcCtx.Config.Attendance.WorkEnd, defaultWorkEnd)
+	}
+	t, _ := time.Parse("15:04", hm)
+	return time.Date(day.Year(), day.Month(), day.Day(), t.Hour(), t.Minute(), 0, 0, day.Location())
+}
+
+// prepareMakeCard 校验补卡申请并附上当天的考勤记录，供审批人查看
+func prepareMakeCard(ctx context.Context, svcCtx *svc.ServiceContext, userId string, card *model.MakeCard) error {
+	if card.CheckType != model.OnWorkCheck && card.CheckType != model.OffWorkCheck {
+		return ErrMakeCardType
+	}
+	if card.Day == 0 {
+		card.Day = timeutils.DayNumber(timeutils.Unix(card.Date))
+	}
+	if _, err := timeutils.ParseDayNumber(card.Day); err != nil {
+		return ErrAttendanceDay
+	}
+	if card.Day > timeutils.DayNumber(timeutils.NowTime()) {
+		return ErrMakeCardFuture
+	}
+
+	record, err := findAttendance(ctx, svcCtx, userId, card.Day)
+	if err != nil {
+		return err
+	}
+	if record.Checked(card.CheckType) {
+		return ErrMakeCardNotNeeded
+	}
+	card.Record = record
+	return nil
+}
+
+// applyMakeCard 补卡审批通过后写入考勤记录，申请后已经打过该卡时不再覆盖，只在审批中标记未写入；
+// 补卡时间没有具体时刻时按上下班时间记录，上班卡不早于上班时间，下班卡不晚于下班时间
+func applyMakeCard(ctx context.Context, svcCtx *svc.ServiceContext, approval *model.Approval) error {
+	card := approval.MakeCard
+	if card == nil {
+		return nil
+	}
+	day, err := timeutils.ParseDayNumber(card.Day)
+	if err != nil {
+		return ErrAttendanceDay
+	}
+
+	work := workTime(svcCtx, day, card.CheckType).Unix()
+	at := card.Date
+	switch {
+	case at == 0 || timeutils.DayNumber(timeutils.Unix(at)) != card.Day || at == day.Unix():
+		at = work
+	case card.CheckType == model.OnWorkCheck:
+		at = max(at, work)
+	default:
+		at = min(at, work)
+	}
+	ok, err := svcCtx.AttendanceModel.SetCheckIfEmpty(ctx, approval.UserId, card.Day, card.CheckType, at, model.MakeCardSource, approval.ID.Hex())
+	if err != nil {
+		return xerr.WithMessage(err, "写入补卡记录失败")
+	}
+	if !ok {
+		// 不影响审批通过，审批详情中提示补卡未生效
+		card.Skipped = true
+		fmt.Printf("[Attendance] 当天已有打卡记录，补卡未写入, approval: %s, uid: %s, day: %d\n",
+			approval.ID.Hex(), approval.UserId, card.Day)
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package logic
+
+import (
+	"context"
+
+	"aiOffice/internal/domain"
+	"aiOffice/internal/model"
+	"aiOffice/internal/svc"
+	"aiOffice/pkg/pagex"
+	"aiOffice/pkg/xerr"
+)
+
+// Audit 审计记录查询，记录由审计中间件写入
+type Audit interface {
+	List(ctx context.Context, req *domain.AuditLogListReq) (resp *domain.AuditLogListResp, err error)
+}
+
+type audit struct {
+	svcCtx *svc.ServiceContext
+}
+
+func NewAudit(svcCtx *svc.ServiceContext) Audit {
+	return &audit{
+		svcCtx: svcCtx,
+	}
+}
+
+func (l *audit) List(ctx context.Context, req *domain.AuditLogListReq) (*domain.AuditLogListResp, error) {
+	list, total, err := l.svcCtx.AuditLogModel.List(ctx, &model.AuditLogFilter{
+		ActorId:    req.ActorId,
+		Resource:   req.Resource,
+		ResourceId: req.ResourceId,
+		StartTime:  req.StartTime,
+		EndTime:    req.EndTime,
+	}, pagex.FromRequest(req.Page, req.Count, ""))
+	if err != nil {
+		return nil, xerr.WithMessage(err, "查询审计记录失败")
+	}
+
+	// 补充操作人名称
+	ids := make([]string, 0, len(list))
+	for _, v := range list {
+		if v.ActorId != "" {
+			ids = append(ids, v.ActorId)
+		}
+	}
+	names := make(map[string]string, len(ids))
+	if len(ids) > 0 {
+		users, _, err := l.svcCtx.UserModel.List(ctx, ids, "", pagex.Page{Count: len(ids)})
+		if err != nil {
+			return nil, xerr.WithMessage(err, "查询用户失败")
+		}
+		for _, u := range users {
+			names[u.ID.Hex()] = u.Name
+		}
+	}
+
+	resp := &domain.AuditLogListResp{
+		Count: total,
+		List:  make([]*domain.AuditLog, 0, len(list)),
+	}
+	for _, v := range list {
+		resp.List = append(resp.List, &domain.AuditLog{
+			Id:         v.ID.Hex(),
+			ActorId:    v.ActorId,
+			ActorName:  names[v.ActorId],
+			Action:     v.Action,
+			Resource:   v.Resource,
+			ResourceId: v.ResourceId,
+			Before:     v.Before,
+			After:      v.After,
+			Payload:    v.Payload,
+			Ip:         v.Ip,
+			RequestId:  v.RequestId,
+			Status:     v.Status,
+			CreateAt:   v.CreateAt,
+		})
+	}
+	return resp, nil
+}
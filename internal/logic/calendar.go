@@ -0,0 +1,490 @@
+package logic
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"aiOffice/internal/domain"
+	"aiOffice/internal/model"
+	"aiOffice/internal/svc"
+	"aiOffice/pkg/calendar"
+	"aiOffice/pkg/timeutils"
+	"aiOffice/pkg/token"
+	"aiOffice/pkg/xerr"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	defaultCalendarSyncDays = 30
+	todoEventDuration       = 30 * time.Minute // 待办在日历中占用截止时间前的半小时
+
+	calendarStatePrefix = "aioffice:calendar:state:" // Exchange授权的state，按用户保存
+	calendarStateTTL    = 10 * time.Minute
+)
+
+var (
+	ErrCalendarDisabled = xerr.NewCode(xerr.Invalid, "未启用外部日历")
+	ErrCalendarNotBound = xerr.NewCode(xerr.NotFound, "未绑定该日历")
+	ErrCalendarState    = xerr.NewCode(xerr.Invalid, "授权已过期或state不匹配，请重新授权")
+)
+
+type Calendar interface {
+	// 已配置的日历及绑定状态
+	Accounts(ctx context.Context) (resp *domain.CalendarAccountResp, err error)
+	// Exchange授权地址
+	AuthUrl(ctx context.Context, req *domain.CalendarProviderReq) (resp *domain.CalendarAuthUrlResp, err error)
+	// 绑定日历
+	Bind(ctx context.Context, req *domain.CalendarBindReq) (err error)
+	// 解绑日历
+	Unbind(ctx context.Context, req *domain.CalendarProviderReq) (err error)
+	// 立即同步当前用户的日历
+	Sync(ctx context.Context) (resp *domain.CalendarSyncResp, err error)
+	// 外部日历中的日程（会议等）
+	Events(ctx context.Context, req *domain.CalendarEventsReq) (resp *domain.CalendarEventsResp, err error)
+	// 同步全部用户，定时任务调用
+	SyncAll(ctx context.Context) error
+}
+
+type calendarLogic struct {
+	svcCtx *svc.ServiceContext
+}
+
+func NewCalendar(svcCtx *svc.ServiceContext) Calendar {
+	return &calendarLogic{
+		svcCtx: svcCtx,
+	}
+}
+
+func (l *calendarLogic) Accounts(ctx context.Context) (resp *domain.CalendarAccountResp, err error) {
+	if l.svcCtx.Calendars == nil {
+		return nil, ErrCalendarDisabled
+	}
+
+	accounts, err := l.svcCtx.CalendarAccountModel.FindByUserId(ctx, token.GetUid(ctx))
+	if err != nil {
+		return nil, xerr.WithMessage(err, "查询日历绑定失败")
+	}
+	bound := make(map[string]*model.CalendarAccount, len(accounts))
+	for _, v := range accounts {
+		bound[v.Provider] = v
+	}
+
+	names := l.svcCtx.Calendars.Names()
+	sort.Strings(names)
+	resp = &domain.CalendarAccountResp{List: make([]*domain.CalendarAccount, 0, len(names))}
+	for _, name := range names {
+		item := &domain.CalendarAccount{Provider: name}
+		if v, ok := bound[name]; ok {
+			item.Bound = true
+			item.LastSyncAt = v.LastSyncAt
+			item.LastError = v.LastError
+		}
+		resp.List = append(resp.List, item)
+	}
+	return resp, nil
+}
+
+func (l *calendarLogic) AuthUrl(ctx context.Context, req *domain.CalendarProviderReq) (resp *domain.CalendarAuthUrlResp, err error) {
+	exchange, err := l.exchange()
+	if err != nil {
+		return nil, err
+	}
+
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+	state := hex.EncodeToString(b)
+	err = l.svcCtx.Redis.Set(ctx, calendarStatePrefix+token.GetUid(ctx), state, calendarStateTTL).Err()
+	if err != nil {
+		return nil, xerr.WithMessage(err, "保存授权状态失败")
+	}
+	return &domain.CalendarAuthUrlResp{
+		Url:   exchange.AuthUrl(state),
+		State: state,
+	}, nil
+}
+
+func (l *calendarLogic) Bind(ctx context.Context, req *domain.CalendarBindReq) (err error) {
+	if l.svcCtx.Calendars == nil {
+		return ErrCalendarDisabled
+	}
+
+	var cred *calendar.Credential
+	switch req.Provider {
+	case calendar.ProviderCalDav:
+		if err := calendar.CheckUrl(req.Url); err != nil {
+			return xerr.WithCode(err, xerr.Invalid)
+		}
+		cred = &calendar.Credential{Url: req.Url, Username: req.Username, Password: req.Password}
+	case calendar.ProviderExchange:
+		exchange, err := l.exchange()
+		if err != nil {
+			return err
+		}
+		// state只能使用一次，且必须是当前用户申请授权地址时生成的
+		state, err := l.svcCtx.Redis.GetDel(ctx, calendarStatePrefix+token.GetUid(ctx)).Result()
+		if err != nil && !errors.Is(err, redis.Nil) {
+			return xerr.WithMessage(err, "查询授权状态失败")
+		}
+		if state == "" || subtle.ConstantTimeCompare([]byte(state), []byte(req.State)) != 1 {
+			return ErrCalendarState
+		}
+		if cred, err = exchange.ExchangeCode(ctx, req.Code); err != nil {
+			return xerr.WithMessage(err, "Exchange授权失败")
+		}
+	default:
+		return calendar.ErrUnknownProvider
+	}
+
+	// 绑定前先访问一次，账号或地址错误时直接提示
+	p, err := l.svcCtx.Calendars.Get(req.Provider)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	if _, err := p.List(ctx, cred, now, now.Add(time.Hour)); err != nil {
+		return xerr.WithMessage(err, "访问日历失败")
+	}
+
+	b, err := json.Marshal(cred)
+	if err != nil {
+		return err
+	}
+	err = l.svcCtx.CalendarAccountModel.Upsert(ctx, &model.CalendarAccount{
+		UserId:     token.GetUid(ctx),
+		Provider:   req.Provider,
+		Credential: string(b),
+	})
+	return xerr.WithMessage(err, "绑定日历失败")
+}
+
+// Unbind 解绑日历，已同步到外部日历的事件保留
+func (l *calendarLogic) Unbind(ctx context.Context, req *domain.CalendarProviderReq) (err error) {
+	uid := token.GetUid(ctx)
+	if err := l.svcCtx.CalendarAccountModel.Delete(ctx, uid, req.Provider); err != nil {
+		return xerr.WithMessage(err, "解绑日历失败")
+	}
+	return xerr.WithMessage(l.svcCtx.CalendarEventModel.DeleteByProvider(ctx, uid, req.Provider), "解绑日历失败")
+}
+
+func (l *calendarLogic) Sync(ctx context.Context) (resp *domain.CalendarSyncResp, err error) {
+	if l.svcCtx.Calendars == nil {
+		return nil, ErrCalendarDisabled
+	}
+
+	accounts, err := l.svcCtx.CalendarAccountModel.FindByUserId(ctx, token.GetUid(ctx))
+	if err != nil {
+		return nil, xerr.WithMessage(err, "查询日历绑定失败")
+	}
+	if len(accounts) == 0 {
+		return nil, ErrCalendarNotBound
+	}
+
+	resp = &domain.CalendarSyncResp{}
+	for _, acc := range accounts {
+		res, err := l.syncAccount(ctx, acc)
+		if err != nil {
+			return nil, xerr.WithMessagef(err, "同步%s日历失败", acc.Provider)
+		}
+		resp.Pushed += res.Pushed
+		resp.Pulled += res.Pulled
+	}
+	return resp, nil
+}
+
+func (l *calendarLogic) Events(ctx context.Context, req *domain.CalendarEventsReq) (resp *domain.CalendarEventsResp, err error) {
+	if l.svcCtx.Calendars == nil {
+		return nil, ErrCalendarDisabled
+	}
+
+	uid := token.GetUid(ctx)
+	start, end := timeutils.Unix(req.StartTime), timeutils.Unix(req.EndTime)
+	if req.StartTime <= 0 {
+		start = timeutils.StartOfDay(timeutils.NowTime())
+	}
+	if req.EndTime <= 0 || !end.After(start) {
+		end = start.AddDate(0, 0, 7)
+	}
+
+	accounts, err := l.svcCtx.CalendarAccountModel.FindByUserId(ctx, uid)
+	if err != nil {
+		return nil, xerr.WithMessage(err, "查询日历绑定失败")
+	}
+
+	resp = &domain.CalendarEventsResp{List: make([]*domain.CalendarEvent, 0)}
+	for _, acc := range accounts {
+		p, cred, err := l.open(acc)
+		if err != nil {
+			return nil, err
+		}
+		events, err := p.List(ctx, cred, start, end)
+		if err != nil {
+			return nil, xerr.WithMessagef(err, "查询%s日历失败", acc.Provider)
+		}
+		l.saveCredential(ctx, acc, cred, acc.LastSyncAt, nil)
+
+		links, err := l.svcCtx.CalendarEventModel.FindByUserId(ctx, uid, acc.Provider)
+		if err != nil {
+			return nil, xerr.WithMessage(err, "查询日历同步记录失败")
+		}
+		todoIds := make(map[string]string, len(links))
+		for _, v := range links {
+			todoIds[v.EventId] = v.TodoId
+		}
+
+		for _, ev := range events {
+			resp.List = append(resp.List, &domain.CalendarEvent{
+				Provider:  acc.Provider,
+				Id:        ev.Id,
+				Title:     ev.Title,
+				Desc:      ev.Desc,
+				Location:  ev.Location,
+				StartTime: ev.Start.Unix(),
+				EndTime:   ev.End.Unix(),
+				TodoId:    todoIds[ev.Id],
+			})
+		}
+	}
+
+	sort.Slice(resp.List, func(i, j int) bool {
+		return resp.List[i].StartTime < resp.List[j].StartTime
+	})
+	return resp, nil
+}
+
+// SyncAll 逐个同步全部已绑定的日历，单个账号失败不影响其他账号
+func (l *calendarLogic) SyncAll(ctx context.Context) error {
+	if l.svcCtx.Calendars == nil {
+		return nil
+	}
+
+	accounts, err := l.svcCtx.CalendarAccountModel.FindAll(ctx)
+	if err != nil {
+		return err
+	}
+	for _, acc := range accounts {
+		res, err := l.syncAccount(ctx, acc)
+		if err != nil {
+			fmt.Printf("[Calendar] 同步失败, uid: %s, provider: %s, err: %v\n", acc.UserId, acc.Provider, err)
+			continue
+		}
+		fmt.Printf("[Calendar] 同步完成, uid: %s, provider: %s, pushed: %d, pulled: %d\n",
+			acc.UserId, acc.Provider, res.Pushed, res.Pulled)
+	}
+	return nil
+}
+
+// syncAccount 双向同步一个日历账号：
+// 外部日历中事件时间被修改时，更新待办截止时间；否则把待办的变化推送到外部日历
+func (l *calendarLogic) syncAccount(ctx context.Context, acc *model.CalendarAccount) (res *domain.CalendarSyncResp, err error) {
+	p, cred, err := l.open(acc)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		l.saveCredential(ctx, acc, cred, time.Now().Unix(), err)
+	}()
+
+	days := l.svcCtx.Config.Calendar.SyncDays
+	if days <= 0 {
+		days = defaultCalendarSyncDays
+	}
+	now := time.Now()
+	start, end := now.AddDate(0, 0, -1), now.AddDate(0, 0, days)
+
+	todos, err := userTodos(ctx, l.svcCtx, acc.UserId)
+	if err != nil {
+		return nil, err
+	}
+	links, err := l.svcCtx.CalendarEventModel.FindByUserId(ctx, acc.UserId, acc.Provider)
+	if err != nil {
+		return nil, err
+	}
+	list, err := p.List(ctx, cred, start.Add(-todoEventDuration), end)
+	if err != nil {
+		return nil, err
+	}
+	events := make(map[string]*calendar.Event, len(list))
+	for _, ev := range list {
+		events[ev.Id] = ev
+	}
+
+	res = &domain.CalendarSyncResp{}
+	linked := make(map[string]bool, len(links))
+	for _, link := range links {
+		linked[link.TodoId] = true
+
+		todo, ok := todos[link.TodoId]
+		if !ok {
+			// 待办已删除或已完成，移除日历中的事件
+			if err := p.Delete(ctx, cred, link.EventId); err != nil {
+				return nil, err
+			}
+			if err := l.svcCtx.CalendarEventModel.Delete(ctx, acc.UserId, acc.Provider, link.TodoId); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		ev, ok := events[link.EventId]
+		if ok && ev.End.Unix() != link.DeadlineAt {
+			if todo.CreatorId != acc.UserId {
+				// 执行人不能修改共享待办的截止时间，以待办为准覆盖外部日历
+				if err := l.push(ctx, p, cred, acc, todo, link.EventId); err != nil {
+					return nil, err
+				}
+				res.Pushed++
+				continue
+			}
+
+			// 创建人在外部日历中修改了时间，按该用户的身份走待办的修改流程
+			uctx := context.WithValue(ctx, token.Identify, acc.UserId)
+			err := NewTodo(l.svcCtx).Edit(uctx, &domain.Todo{ID: link.TodoId, DeadlineAt: ev.End.Unix()})
+			if err != nil {
+				return nil, err
+			}
+			link.DeadlineAt = ev.End.Unix()
+			link.SyncAt = time.Now().Unix()
+			if err := l.svcCtx.CalendarEventModel.Upsert(ctx, link); err != nil {
+				return nil, err
+			}
+			res.Pulled++
+			continue
+		}
+
+		// 待办有修改，或事件在外部被删除（且仍在同步范围内）时重新推送
+		inRange := todo.DeadlineAt > start.Unix() && todo.DeadlineAt < end.Unix()
+		if todo.UpdateAt > link.SyncAt || todo.DeadlineAt != link.DeadlineAt || (!ok && inRange) {
+			if !ok {
+				link.EventId = ""
+			}
+			if err := l.push(ctx, p, cred, acc, todo, link.EventId); err != nil {
+				return nil, err
+			}
+			res.Pushed++
+		}
+	}
+
+	// 新的待办
+	for id, todo := range todos {
+		if linked[id] || todo.DeadlineAt < start.Unix() || todo.DeadlineAt > end.Unix() {
+			continue
+		}
+		if err := l.push(ctx, p, cred, acc, todo, ""); err != nil {
+			return nil, err
+		}
+		res.Pushed++
+	}
+	return res, nil
+}
+
+// push 创建或更新待办对应的日历事件
+func (l *calendarLogic) push(ctx context.Context, p calendar.Provider, cred *calendar.Credential,
+	acc *model.CalendarAccount, todo *model.Todo, eventId string) error {
+
+	deadline := time.Unix(todo.DeadlineAt, 0)
+	if eventId == "" && p.Name() == calendar.ProviderCalDav {
+		eventId = "aioffice-todo-" + todo.ID.Hex()
+	}
+	id, err := p.Put(ctx, cred, &calendar.Event{
+		Id:    eventId,
+		Title: "[待办] " + todo.Title,
+		Desc:  todo.Desc,
+		Start: deadline.Add(-todoEventDuration),
+		End:   deadline,
+	})
+	if err != nil {
+		return err
+	}
+
+	return l.svcCtx.CalendarEventModel.Upsert(ctx, &model.CalendarEvent{
+		UserId:     acc.UserId,
+		Provider:   acc.Provider,
+		TodoId:     todo.ID.Hex(),
+		EventId:    id,
+		DeadlineAt: todo.DeadlineAt,
+		SyncAt:     time.Now().Unix(),
+	})
+}
+
+// userTodos 用户作为执行人、尚未完成且设置了截止时间的待办
+func userTodos(ctx context.Context, svcCtx *svc.ServiceContext, uid string) (map[string]*model.Todo, error) {
+	userTodos, err := svcCtx.UserTodoModel.FindByUserId(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, 0, len(userTodos))
+	for _, ut := range userTodos {
+		if ut.TodoStatus != 1 {
+			ids = append(ids, ut.TodoId)
+		}
+	}
+	if len(ids) == 0 {
+		return map[string]*model.Todo{}, nil
+	}
+
+	list, err := svcCtx.TodoModel.FindByIds(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	todos := make(map[string]*model.Todo, len(list))
+	for _, v := range list {
+		if v.DeadlineAt > 0 {
+			todos[v.ID.Hex()] = v
+		}
+	}
+	return todos, nil
+}
+
+// open 解析账号凭证
+func (l *calendarLogic) open(acc *model.CalendarAccount) (calendar.Provider, *calendar.Credential, error) {
+	p, err := l.svcCtx.Calendars.Get(acc.Provider)
+	if err != nil {
+		return nil, nil, err
+	}
+	var cred calendar.Credential
+	if err := json.Unmarshal([]byte(acc.Credential), &cred); err != nil {
+		return nil, nil, fmt.Errorf("parse calendar credential: %w", err)
+	}
+	return p, &cred, nil
+}
+
+// saveCredential 保存刷新后的令牌和同步结果
+func (l *calendarLogic) saveCredential(ctx context.Context, acc *model.CalendarAccount, cred *calendar.Credential,
+	syncAt int64, syncErr error) {
+
+	b, err := json.Marshal(cred)
+	if err != nil {
+		return
+	}
+	acc.Credential = string(b)
+	acc.LastSyncAt = syncAt
+	acc.LastError = ""
+	if syncErr != nil {
+		acc.LastError = syncErr.Error()
+	}
+	if err := l.svcCtx.CalendarAccountModel.UpdateSync(ctx, acc); err != nil {
+		fmt.Printf("[Calendar] 保存同步状态失败, uid: %s, err: %v\n", acc.UserId, err)
+	}
+}
+
+func (l *calendarLogic) exchange() (*calendar.Exchange, error) {
+	if l.svcCtx.Calendars == nil {
+		return nil, ErrCalendarDisabled
+	}
+	p, err := l.svcCtx.Calendars.Get(calendar.ProviderExchange)
+	if err != nil {
+		return nil, err
+	}
+	return p.(*calendar.Exchange), nil
+}
@@ -5,62 +5,181 @@ import (
 	"crypto/sha256"
 	"encoding/base64"
 	"encoding/json"
+	"fmt"
+	"slices"
 	"sort"
+	"strconv"
 	"strings"
+	"time"
 
 	"aiOffice/internal/domain"
 	chatinternal "aiOffice/internal/logic/chatinternal/handler"
 	"aiOffice/internal/model"
 	"aiOffice/internal/svc"
 	"aiOffice/pkg/langchain"
-	langhandler "aiOffice/pkg/langchain/handler"
+	"aiOffice/pkg/langchain/cachex"
 	"aiOffice/pkg/langchain/memoryx"
+	"aiOffice/pkg/langchain/moderation"
+	"aiOffice/pkg/langchain/promptx"
 	"aiOffice/pkg/langchain/router"
+	"aiOffice/pkg/pagex"
 	"aiOffice/pkg/timeutils"
 	"aiOffice/pkg/token"
+	"aiOffice/pkg/xerr"
 
 	"github.com/tmc/langchaingo/chains"
+	"github.com/tmc/langchaingo/llms"
 	"github.com/tmc/langchaingo/memory"
 	"github.com/tmc/langchaingo/schema"
 )
 
+var (
+	ErrModelNotAllowed    = xerr.NewCode(xerr.Invalid, "不支持的模型")
+	ErrPromptBlocked      = xerr.NewCode(xerr.Invalid, "输入内容包含不当信息，请修改后重试")
+	ErrNoConversation     = xerr.NewCode(xerr.Invalid, "请指定会话")
+	ErrConversationDenied = xerr.NewCode(xerr.Forbidden, "无权查看该会话")
+	ErrAnswerBlocked      = xerr.NewCode(xerr.Invalid, "回复内容未通过审核，请换个问法")
+)
+
 type Chat interface {
 	PrivateChat(ctx context.Context, req *domain.Message) error
 	GroupChat(ctx context.Context, req *domain.Message) (uids []string, err error)
 	AIChat(ctx context.Context, req *domain.ChatReq) (*domain.ChatResp, error)
+	AIChatStream(ctx context.Context, req *domain.ChatReq, stream langchain.StreamFunc) (*domain.ChatResp, error)
 	File(ctx context.Context, files []*domain.FileResp) error
+	AIHistory(ctx context.Context, req *domain.AIHistoryReq) (*domain.AIHistoryResp, error)
+	History(ctx context.Context, req *domain.ChatHistoryReq) (*domain.ChatHistoryResp, error)
+	ClearAIMemory(ctx context.Context, req *domain.ClearAIMemoryReq) error
 }
 
 type chat struct {
 	svc    *svc.ServiceContext
 	router *router.Router
-	memory schema.Memory
+	memory *memoryx.Memoryx
+	vision *chatinternal.VisionHandler // 带图片的消息直接调用，不经过路由
 }
 
+// NewChat 创建聊天逻辑，AI工具依赖的业务逻辑需先由 Inject 注入
 func NewChat(svc *svc.ServiceContext) Chat {
-	// 1.创建handler
-	handlers := []langhandler.Handler{
-		chatinternal.NewDefaultHandler(svc),
-		chatinternal.NewTodoHandler(svc),
-		chatinternal.NewApprovalHandler(svc),
-		chatinternal.NewKnowledgeHandler(svc),
+	// 1.创建handler（各handler在init中自注册）
+	handlers := chatinternal.Handlers(svc)
+
+	// 2.memory（LRU淘汰，最多保留200个会话）启动时创建，多个Chat共用
+	m := svc.AIMemory
+
+	// 3.创建router，用户正在补充审批、待办信息时优先路由回对应handler
+	conf := svc.Config.LangChain.Router
+	opts := []router.Option{
+		router.WithPrompts(svc.Prompts),
+		router.WithRuleStore(svc.RouteRules),
+		router.WithRules(draftRules(svc)),
+		router.WithConfidence(conf.Threshold, conf.Fallback),
 	}
-
-	// 2.创建memory（LRU淘汰，最多保留200个会话）
-	m := memoryx.NewMemoryx(func() schema.Memory {
-		return memory.NewConversationBuffer()
-	}, memoryx.WithMaxSize(200))
-
-	// 3.创建router
-	r := router.NewRouter(svc.LLM, handlers, m)
+	if conf.Log {
+		opts = append(opts, router.WithDecision(routeLogger(svc)))
+	}
+	r := router.NewRouter(svc.LLM, handlers, m, opts...)
 
 	return &chat{
 		svc:    svc,
 		memory: m,
 		router: r,
+		vision: chatinternal.NewVisionHandler(svc),
+	}
+}
+
+// routeLogger 异步保存路由决策，用于离线评估误路由
+func routeLogger(svc *svc.ServiceContext) router.DecisionFunc {
+	return func(ctx context.Context, d *router.Decision) {
+		log := &model.RouteLog{
+			UserId:     token.GetUid(ctx),
+			Input:      d.Input,
+			Output:     d.Output,
+			Handler:    d.Handler,
+			Confidence: d.Confidence,
+			Fallback:   d.Fallback,
+			Duration:   d.Duration.Milliseconds(),
+		}
+		go func() {
+			if err := svc.RouteLogModel.Insert(context.Background(), log); err != nil {
+				fmt.Printf("[Router] 保存路由决策失败: %v\n", err)
+			}
+		}()
 	}
 }
 
+// draftRules 根据用户未完成的草稿生成路由规则
+func draftRules(svc *svc.ServiceContext) router.RulesFunc {
+	return func(ctx context.Context) []router.Rule {
+		d, err := svc.Slots.Get(ctx, token.GetUid(ctx))
+		if err != nil || d == nil {
+			return nil
+		}
+
+		rule := fmt.Sprintf("用户在回答上一轮关于%s的提问、确认或修改%s", d.Title, d.Title)
+		if len(d.Missing) > 0 {
+			rule = fmt.Sprintf("用户在补充%s的%s，或确认、修改%s", d.Title, strings.Join(d.Missing, "、"), d.Title)
+		}
+		return []router.Rule{{Handler: d.Handler, Rule: rule}}
+	}
+}
+
+// newMemory 根据配置创建对话记忆，mongo/redis存储时上下文在重启和多实例间保持
+func newMemory(svc *svc.ServiceContext) *memoryx.Memoryx {
+	conf := svc.Config.LangChain.Memory
+	if conf.MaxTokens <= 0 {
+		conf.MaxTokens = 2000
+	}
+
+	var store memoryx.HistoryStore
+	switch conf.Store {
+	case "mongo":
+		store = svc.AIMemoryModel
+	case "redis":
+		ttl := 7 * 24 * time.Hour
+		if conf.RedisTTL != 0 {
+			ttl = time.Duration(conf.RedisTTL) * time.Second
+		}
+		store = memoryx.NewRedisStore(svc.Redis, "aioffice:ai:memory:", ttl)
+	}
+
+	create := func(chatId string) schema.Memory {
+		var opts []memory.ConversationBufferOption
+		if store != nil {
+			opts = append(opts, memory.WithChatHistory(memoryx.NewHistory(store, chatId, conf.Limit)))
+		}
+
+		// 全量buffer会随着对话无限增长，最终超出模型上下文
+		switch conf.Strategy {
+		case "window":
+			return memory.NewConversationWindowBuffer(conf.WindowSize, opts...)
+		case "token":
+			return memory.NewConversationTokenBuffer(svc.LLM, conf.MaxTokens, opts...)
+		case "summary":
+			return memoryx.NewSummaryBuffer(svc.LLM, conf.MaxTokens, opts...)
+		default:
+			return memory.NewConversationBuffer(opts...)
+		}
+	}
+
+	// 空闲的会话到期释放，不必等到缓存满
+	idleTTL := 2 * time.Hour
+	if conf.IdleTTL != 0 {
+		idleTTL = time.Duration(conf.IdleTTL) * time.Second
+	}
+	opts := []memoryx.MemoryxOption{memoryx.WithMaxSize(200), memoryx.WithIdleTTL(idleTTL)}
+
+	var mem *memoryx.Memoryx
+	if store == nil {
+		mem = memoryx.NewMemoryx(func() schema.Memory {
+			return create("")
+		}, opts...)
+	} else {
+		mem = memoryx.NewMemoryxByChatId(create, opts...)
+	}
+	return mem
+}
+
 // PrivateChat 处理私聊消息，将消息保存到数据库
 func (l *chat) PrivateChat(ctx context.Context, req *domain.Message) error {
 	// 调用通用的聊天日志保存方法
@@ -84,15 +203,244 @@ func (l *chat) GroupChat(ctx context.Context, req *domain.Message) (uids []strin
 
 func (l *chat) AIChat(ctx context.Context, req *domain.ChatReq) (resp *domain.ChatResp, err error) {
 	uid := token.GetUid(ctx)
-	ctx = context.WithValue(ctx, langchain.ChatId, uid)
+	ctx = context.WithValue(ctx, langchain.ChatId, memoryKey(uid, req.ConversationId, req.RelationId))
+
+	if err := checkRateLimit(ctx, l.svc, uid); err != nil {
+		return nil, err
+	}
+	if err := checkQuota(ctx, l.svc, uid); err != nil {
+		return nil, err
+	}
+
+	params, err := l.callParams(req)
+	if err != nil {
+		return nil, err
+	}
+	if params != nil {
+		ctx = langchain.WithCallParams(ctx, params)
+	}
+
+	// 以用户所在部门作为提示词的租户
+	if deps, err := l.svc.DepartmentuserModel.FindByUserId(ctx, uid); err == nil {
+		tenants := make([]string, 0, len(deps))
+		for _, v := range deps {
+			tenants = append(tenants, v.DepId)
+		}
+		ctx = promptx.WithTenants(ctx, tenants...)
+	}
 
 	// if req.ChatType > 0 {
 	// 	return l.basicService(ctx, req)
 	// }
-	return l.aiService(ctx, req)
+	if l.svc.Moderator.Check(ctx, moderation.StageInput, req.Prompts).Blocked() {
+		return nil, ErrPromptBlocked
+	}
+
+	// 指定了模型参数、带图片或会话已有上下文的请求不使用缓存
+	cacheable := params == nil && len(req.Images) == 0 && !l.hasHistory(ctx)
+	if cacheable {
+		// 可检索的知识库不同（部门知识库）时不共用缓存
+		if indexes, err := l.svc.KnowledgeLogic.Namespaces(ctx); err == nil {
+			ctx = cachex.WithScope(ctx, strings.Join(indexes, ","))
+		} else {
+			cacheable = false
+		}
+	}
+	if cacheable {
+		if resp, ok := l.cachedAnswer(ctx, uid, req.Prompts); ok {
+			if l.debug(req) {
+				resp.Debug = &domain.ChatDebug{Handler: "cache", ToolCalls: []*domain.AIToolCall{}}
+			}
+			return resp, nil
+		}
+	}
+
+	ctx, trace := langchain.WithTrace(ctx)
+	start := time.Now()
+	resp, err = l.aiService(ctx, req)
+	if err == nil && l.svc.Moderator.Check(ctx, moderation.StageOutput, answerText(resp)).Blocked() {
+		resp, err = nil, ErrAnswerBlocked
+	}
+	if err == nil && cacheable {
+		l.cacheAnswer(ctx, req.Prompts, trace, resp)
+	}
+	cost := time.Since(start)
+	l.saveAIHistory(uid, req.Prompts, trace, resp, err, cost)
+	if err == nil && l.debug(req) {
+		resp.Debug = traceDebug(trace, cost)
+	}
+	return resp, err
+}
+
+// debug 请求是否返回执行过程
+func (l *chat) debug(req *domain.ChatReq) bool {
+	return req.Debug && l.svc.Config.LangChain.Debug
+}
+
+// traceDebug 将执行过程转换为调试信息
+func traceDebug(trace *langchain.Trace, cost time.Duration) *domain.ChatDebug {
+	trace.Lock()
+	defer trace.Unlock()
+
+	res := &domain.ChatDebug{
+		Handler:        trace.Handler,
+		RouterOutput:   trace.RouterOutput,
+		RouterDuration: trace.RouterDuration.Milliseconds(),
+		ToolCalls:      make([]*domain.AIToolCall, 0, len(trace.ToolCalls)),
+		Duration:       cost.Milliseconds(),
+	}
+	for _, v := range trace.ToolCalls {
+		res.ToolCalls = append(res.ToolCalls, &domain.AIToolCall{
+			Name:     v.Name,
+			Input:    v.Input,
+			Output:   v.Output,
+			Error:    v.Error,
+			Duration: v.Duration.Milliseconds(),
+		})
+	}
+	return res
+}
+
+// hasHistory 会话是否已有上下文，同一个问题在不同上下文中（如"那第二条呢"）的回答不同，
+// 缓存的key只有问题本身，有上下文时既不读取也不写入缓存
+func (l *chat) hasHistory(ctx context.Context) bool {
+	vars, err := l.memory.LoadMemoryVariables(ctx, map[string]any{})
+	if err != nil {
+		return true
+	}
+	switch v := vars[l.memory.GetMemoryKey(ctx)].(type) {
+	case nil:
+		return false
+	case string:
+		return v != ""
+	case []llms.ChatMessage:
+		return len(v) > 0
+	}
+	return true
+}
+
+// cachedAnswer 查询缓存的回复，命中时同样记录对话和记忆，保证后续对话的上下文完整
+func (l *chat) cachedAnswer(ctx context.Context, uid, prompt string) (*domain.ChatResp, bool) {
+	data, ok := l.svc.AnswerCache.Get(ctx, prompt)
+	if !ok {
+		return nil, false
+	}
+
+	var resp domain.ChatResp
+	if err := json.Unmarshal([]byte(data), &resp); err != nil {
+		return nil, false
+	}
+	answer := answerText(&resp)
+
+	l.saveAIChatLog(ctx, uid, "user", prompt)
+	l.saveAIChatLog(ctx, uid, "assistant", answer)
+	if err := l.memory.SaveContext(ctx, map[string]any{
+		langchain.Input: prompt,
+	}, map[string]any{
+		langchain.Output: answer,
+	}); err != nil {
+		fmt.Printf("[AIChat] 缓存回复写入记忆失败: %v\n", err)
+	}
+
+	trace := &langchain.Trace{Handler: "cache"}
+	l.saveAIHistory(uid, prompt, trace, &resp, nil, 0)
+	return &resp, true
+}
+
+// cacheAnswer 只缓存由指定handler、且仅调用了只读工具得到的回复，
+// 如知识库问答；闲聊、待办等与用户和时间相关的回复不缓存
+func (l *chat) cacheAnswer(ctx context.Context, prompt string, trace *langchain.Trace, resp *domain.ChatResp) {
+	conf := l.svc.Config.LangChain.Cache
+	handlers, tools := conf.Handlers, conf.Tools
+	if len(handlers) == 0 {
+		handlers = []string{"knowledge"}
+	}
+	if len(tools) == 0 {
+		tools = []string{"knowledge_query"}
+	}
+
+	trace.Lock()
+	ok := slices.Contains(handlers, trace.Handler) && len(trace.ToolCalls) > 0
+	for _, v := range trace.ToolCalls {
+		if v.Error != "" || !slices.Contains(tools, v.Name) {
+			ok = false
+		}
+	}
+	trace.Unlock()
+	if !ok {
+		return
+	}
+
+	b, err := json.Marshal(resp)
+	if err != nil {
+		return
+	}
+	if err := l.svc.AnswerCache.Set(ctx, prompt, string(b)); err != nil {
+		fmt.Printf("[AIChat] 缓存回复失败: %v\n", err)
+	}
+}
+
+// answerText 回复内容的文本形式，非字符串的数据序列化后返回
+func answerText(resp *domain.ChatResp) string {
+	if resp == nil {
+		return ""
+	}
+	if content, ok := resp.Data.(string); ok {
+		return content
+	}
+	b, err := json.Marshal(resp.Data)
+	if err != nil {
+		return ""
+	}
+	return string(b)
+}
+
+// callParams 校验请求指定的模型参数
+func (l *chat) callParams(req *domain.ChatReq) (*langchain.CallParams, error) {
+	if req.Model == "" && req.Temperature == nil && req.MaxTokens == 0 {
+		return nil, nil
+	}
+
+	if req.Model != "" && !slices.Contains(l.svc.LLM.Models(), req.Model) {
+		return nil, ErrModelNotAllowed
+	}
+
+	return &langchain.CallParams{
+		Model:       req.Model,
+		Temperature: req.Temperature,
+		MaxTokens:   req.MaxTokens,
+	}, nil
+}
+
+// AIChatStream 流式AI对话，生成过程中通过stream推送增量内容，结束后返回完整结果
+func (l *chat) AIChatStream(ctx context.Context, req *domain.ChatReq, stream langchain.StreamFunc) (*domain.ChatResp, error) {
+	streamed := false
+	ctx = langchain.WithStream(ctx, func(ctx context.Context, chunk []byte) error {
+		streamed = true
+		return stream(ctx, chunk)
+	})
+
+	resp, err := l.AIChat(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	// 没有产生流式输出时（如命中缓存、追问）一次性推送最终结果
+	if !streamed {
+		if data, ok := resp.Data.(string); ok && data != "" {
+			if err := stream(ctx, []byte(data)); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return resp, nil
 }
 
 func (l *chat) aiService(ctx context.Context, req *domain.ChatReq) (output *domain.ChatResp, err error) {
+	if len(req.Images) > 0 {
+		return l.visionService(ctx, req)
+	}
+
 	uid := token.GetUid(ctx)
 
 	// 保存用户输入到数据库
@@ -100,6 +448,7 @@ func (l *chat) aiService(ctx context.Context, req *domain.ChatReq) (output *doma
 
 	// 将chatlog相关参数通过context传递,避免影响memory的保存逻辑
 	ctx = context.WithValue(ctx, "relationId", req.RelationId)
+	ctx = context.WithValue(ctx, "conversationId", req.ConversationId)
 	// ctx = context.WithValue(ctx, "startTime", req.StartTime)
 	// ctx = context.WithValue(ctx, "endTime", req.EndTime)
 	v, err := chains.Call(ctx, l.router, map[string]any{
@@ -177,6 +526,36 @@ func (l *chat) aiService(ctx context.Context, req *domain.ChatReq) (output *doma
 	return &res, nil
 }
 
+// visionService 带图片的消息交给视觉模型，问答同样写入记忆，后续对话可以继续追问
+func (l *chat) visionService(ctx context.Context, req *domain.ChatReq) (*domain.ChatResp, error) {
+	uid := token.GetUid(ctx)
+	l.saveAIChatLog(ctx, uid, "user", req.Prompts)
+
+	langchain.GetTrace(ctx).SetRoute(l.vision.Name(), "", 0)
+	v, err := chains.Call(ctx, l.vision.Chains(), map[string]any{
+		langchain.Input:        req.Prompts,
+		chatinternal.ImagesKey: req.Images,
+	}, chains.WithCallback(l.svc.Cb))
+	if err != nil {
+		return nil, err
+	}
+
+	answer, _ := v[langchain.Output].(string)
+	l.saveAIChatLog(ctx, uid, "assistant", answer)
+	if err := l.memory.SaveContext(ctx, map[string]any{
+		langchain.Input: fmt.Sprintf("%s（附图片%d张）", req.Prompts, len(req.Images)),
+	}, map[string]any{
+		langchain.Output: answer,
+	}); err != nil {
+		fmt.Printf("[AIChat] 保存图片对话记忆失败: %v\n", err)
+	}
+
+	return &domain.ChatResp{
+		ChatType: domain.DefaultHandler,
+		Data:     answer,
+	}, nil
+}
+
 // saveAIChatLog 保存AI对话记录到数据库
 func (l *chat) saveAIChatLog(ctx context.Context, uid, role, content string) {
 	// AI对话使用固定的conversationId格式: ai_{uid}
@@ -185,8 +564,8 @@ func (l *chat) saveAIChatLog(ctx context.Context, uid, role, content string) {
 	chatlog := model.ChatLog{
 		ConversationId: conversationId,
 		SendId:         uid,
-		RecvId:         "ai",              // AI作为接收方
-		ChatType:       model.ChatType(3), // 3=AI对话
+		RecvId:         "ai", // AI作为接收方
+		ChatType:       model.AIChatType,
 		MsgContent:     content,
 		SendTime:       timeutils.Now(),
 	}
@@ -206,6 +585,154 @@ func (l *chat) saveAIChatLog(ctx context.Context, uid, role, content string) {
 	}()
 }
 
+// saveAIHistory 保存一轮AI对话的执行过程，异步写入不阻塞主流程
+func (l *chat) saveAIHistory(uid, prompt string, trace *langchain.Trace, resp *domain.ChatResp, err error, cost time.Duration) {
+	trace.Lock()
+	history := model.AIHistory{
+		UserId:    uid,
+		Prompt:    prompt,
+		Handler:   trace.Handler,
+		ToolCalls: make([]*model.AIToolCall, 0, len(trace.ToolCalls)),
+		Duration:  cost.Milliseconds(),
+	}
+	for _, v := range trace.ToolCalls {
+		history.ToolCalls = append(history.ToolCalls, &model.AIToolCall{
+			Name:     v.Name,
+			Input:    v.Input,
+			Output:   v.Output,
+			Error:    v.Error,
+			Duration: v.Duration.Milliseconds(),
+		})
+	}
+	trace.Unlock()
+
+	if err != nil {
+		history.Error = err.Error()
+	} else {
+		history.Answer = answerText(resp)
+	}
+
+	go func() {
+		if err := l.svc.AIHistoryModel.Insert(context.Background(), &history); err != nil {
+			fmt.Printf("[AIChat] 保存对话历史失败: %v\n", err)
+		}
+	}()
+}
+
+// AIHistory 分页查询当前用户的AI对话历史
+func (l *chat) AIHistory(ctx context.Context, req *domain.AIHistoryReq) (*domain.AIHistoryResp, error) {
+	uid := token.GetUid(ctx)
+
+	list, count, err := l.svc.AIHistoryModel.List(ctx, uid, pagex.FromRequest(req.Page, req.Count, ""))
+	if err != nil {
+		return nil, xerr.WithMessage(err, "查询AI对话历史失败")
+	}
+
+	resp := &domain.AIHistoryResp{
+		Count: count,
+		List:  make([]*domain.AIHistory, 0, len(list)),
+	}
+	for _, v := range list {
+		item := &domain.AIHistory{
+			Id:        v.ID.Hex(),
+			Prompt:    v.Prompt,
+			Handler:   v.Handler,
+			ToolCalls: make([]*domain.AIToolCall, 0, len(v.ToolCalls)),
+			Answer:    v.Answer,
+			Error:     v.Error,
+			Duration:  v.Duration,
+			CreateAt:  v.CreateAt,
+		}
+		for _, c := range v.ToolCalls {
+			item.ToolCalls = append(item.ToolCalls, &domain.AIToolCall{
+				Name:     c.Name,
+				Input:    c.Input,
+				Output:   c.Output,
+				Error:    c.Error,
+				Duration: c.Duration,
+			})
+		}
+		resp.List = append(resp.List, item)
+	}
+	return resp, nil
+}
+
+// History 按游标倒序分页查询聊天记录，私聊只能查询自己参与的会话，AI对话只能查询自己的
+func (l *chat) History(ctx context.Context, req *domain.ChatHistoryReq) (*domain.ChatHistoryResp, error) {
+	uid := token.GetUid(ctx)
+
+	var conversationId string
+	var chatType model.ChatType
+	switch {
+	case req.RecvId != "":
+		conversationId, chatType = GenerateUniqueID(uid, req.RecvId), model.SingleChatType
+	case req.ConversationId == "":
+		return nil, ErrNoConversation
+	case strings.HasPrefix(req.ConversationId, "ai_"):
+		if req.ConversationId != "ai_"+uid {
+			return nil, ErrConversationDenied
+		}
+		conversationId, chatType = req.ConversationId, model.AIChatType
+	default:
+		conversationId, chatType = req.ConversationId, model.GroupChatType
+	}
+
+	cursor, err := pagex.DecodeCursor(req.Cursor)
+	if err != nil {
+		return nil, err
+	}
+	limit := pagex.FromRequest(1, req.Limit, "").Limit()
+
+	list, err := l.svc.ChatLogModel.ListHistory(ctx, conversationId, chatType, cursor, limit)
+	if err != nil {
+		return nil, xerr.WithMessage(err, "查询聊天记录失败")
+	}
+
+	resp := &domain.ChatHistoryResp{
+		List: make([]*domain.ChatHistory, 0, len(list)),
+	}
+	for _, v := range list {
+		resp.List = append(resp.List, &domain.ChatHistory{
+			Id:         v.ID.Hex(),
+			SendId:     v.SendId,
+			RecvId:     v.RecvId,
+			ChatType:   int(v.ChatType),
+			MsgContent: v.MsgContent,
+			SendTime:   v.SendTime,
+		})
+	}
+	// 取满一页时可能还有更早的消息
+	if n := len(list); int64(n) == limit {
+		resp.NextCursor = pagex.Cursor{Time: list[n-1].SendTime, Id: list[n-1].ID}.Encode()
+	}
+	return resp, nil
+}
+
+// ClearAIMemory 清空当前用户在指定会话中的AI对话记忆，之后的对话不再带有之前的上下文，对话历史保留
+func (l *chat) ClearAIMemory(ctx context.Context, req *domain.ClearAIMemoryReq) error {
+	key := memoryKey(token.GetUid(ctx), req.ConversationId, req.RelationId)
+	ctx = context.WithValue(ctx, langchain.ChatId, key)
+
+	// 先清空存储中的消息，再移除缓存的会话
+	if err := l.memory.Clear(ctx); err != nil {
+		return xerr.WithMessage(err, "清空AI记忆失败")
+	}
+	l.memory.Remove(key)
+	return nil
+}
+
+// memoryKey AI记忆的会话键，同一用户在私聊助手、群聊总结、审批对话等不同会话中的上下文互不影响；
+// 私聊助手（未指定会话或会话为ai_{uid}）使用用户ID，与之前保存的记忆兼容
+func memoryKey(uid, conversationId string, relationId int) string {
+	switch {
+	case conversationId != "" && conversationId != "ai_"+uid:
+		return uid + ":" + conversationId
+	case relationId != 0:
+		return uid + ":" + strconv.Itoa(relationId)
+	}
+	return uid
+}
+
 // chatlog 通用的聊天消息保存方法，将消息记录到数据库
 func (l *chat) chatlog(ctx context.Context, req *domain.Message) error {
 	sendId := req.SendId
@@ -3,24 +3,26 @@ package chatinternal
 import (
 	"aiOffice/internal/logic/chatinternal/toolx"
 	"aiOffice/internal/svc"
+	langhandler "aiOffice/pkg/langchain/handler"
 
 	"github.com/tmc/langchaingo/chains"
-	"github.com/tmc/langchaingo/tools"
 )
 
+func init() {
+	Register(Registration{
+		Name:  "approval",
+		Order: 20,
+		New:   func(svc *svc.ServiceContext) langhandler.Handler { return NewApprovalHandler(svc) },
+	})
+}
+
 type ApprovalHandler struct {
 	*basechat
 }
 
 func NewApprovalHandler(svc *svc.ServiceContext) *ApprovalHandler {
-	// 创建审批工具
-	approvalTools := []tools.Tool{
-		toolx.NewApprovalTool(svc),      // 创建审批
-		toolx.NewApprovalQueryTool(svc), // 查询审批
-	}
-
 	return &ApprovalHandler{
-		basechat: NewBaseChat(svc, approvalTools),
+		basechat: NewBaseChat(svc, toolx.Tools(svc, toolx.GroupApproval)),
 	}
 }
 
@@ -29,7 +31,14 @@ func (t *ApprovalHandler) Name() string {
 }
 
 func (t *ApprovalHandler) Description() string {
-	return "suitable for approval processing, such as leave request, make-up card, go out, query approval records, etc"
+	return "suitable for approval processing and attendance, such as leave request, make-up card, forgot to clock in, go out, query approval or attendance records, etc"
+}
+
+func (t *ApprovalHandler) Rules() []string {
+	return []string{
+		"用户要请假、补卡、外出、查询审批等",
+		"用户说忘打卡了，或查询打卡、迟到等考勤记录",
+	}
 }
 
 func (t *ApprovalHandler) Chains() chains.Chain {
@@ -2,10 +2,22 @@ package chatinternal
 
 import (
 	"context"
+	"fmt"
+	"maps"
+	"slices"
 	"strings"
+	"time"
 
 	"aiOffice/internal/svc"
 	"aiOffice/pkg/langchain"
+	"aiOffice/pkg/langchain/callbackx"
+	"aiOffice/pkg/langchain/promptx"
+	"aiOffice/pkg/redact"
+	"aiOffice/pkg/requestid"
+	"aiOffice/pkg/token"
+	"aiOffice/pkg/tracex"
+
+	"gitee.com/dn-jinmin/tlog"
 
 	"github.com/tmc/langchaingo/agents"
 	"github.com/tmc/langchaingo/chains"
@@ -17,16 +29,48 @@ const _defaultMrklPrefix = `Answer the following questions as best you can. You
 {{.tool_descriptions}}
 `
 
+const _defaultSystemMessage = `你是企业办公助手，根据用户的需求调用合适的工具完成任务，并用中文回答。
+工具的输入参数是一个JSON字符串，请严格按照工具描述中的格式填写。`
+
 type basechat struct {
-	agentsChain chains.Chain
+	svc   *svc.ServiceContext
+	tools []tools.Tool
 }
 
 func NewBaseChat(svc *svc.ServiceContext, ts []tools.Tool) *basechat {
+	traced := make([]tools.Tool, 0, len(ts))
+	for _, t := range ts {
+		traced = append(traced, traceTool{t})
+	}
 	return &basechat{
-		agentsChain: agents.NewExecutor(agents.NewOneShotAgent(svc.LLM, ts, agents.WithPromptPrefix(_defaultMrklPrefix))),
+		svc:   svc,
+		tools: traced,
 	}
 }
 
+// newAgent 默认使用模型原生的function calling选择工具，
+// 不支持function calling的模型可配置为mrkl（基于文本解析，容易出现输出格式错误）。
+// 每次调用时创建，以便使用最新的、按租户覆盖的提示词；流式请求只推送最终回答，不推送工具调用等中间步骤
+func (b *basechat) newAgent(ctx context.Context) agents.Agent {
+	mrkl := b.svc.Config.LangChain.AgentMode == "mrkl"
+
+	var opts []agents.Option
+	if langchain.GetStream(ctx) != nil {
+		keyword := ""
+		if mrkl {
+			keyword = callbackx.MrklFinalKeyword
+		}
+		opts = append(opts, agents.WithCallbacksHandler(callbackx.NewStreamHandler(keyword)))
+	}
+
+	if mrkl {
+		opts = append(opts, agents.WithPromptPrefix(b.svc.Prompts.Get(ctx, promptx.KeyAgentMrkl, _defaultMrklPrefix)))
+		return agents.NewOneShotAgent(b.svc.LLM, b.tools, opts...)
+	}
+	opts = append(opts, agents.NewOpenAIOption().WithSystemMessage(b.svc.Prompts.Get(ctx, promptx.KeyAgentSystem, _defaultSystemMessage)))
+	return agents.NewOpenAIFunctionsAgent(b.svc.LLM, b.tools, opts...)
+}
+
 func (b *basechat) Chains() chains.Chain {
 	return chains.NewTransform(b.transform, nil, nil)
 }
@@ -41,7 +85,9 @@ func (b *basechat) transform(ctx context.Context, inputs map[string]any,
 		}
 	}
 
-	outPut, err := b.agentsChain.Call(ctx, inputs, opts...)
+	inputs = b.withDraft(ctx, inputs)
+
+	outPut, err := agents.NewExecutor(b.newAgent(ctx)).Call(ctx, inputs)
 	if err != nil {
 		return nil, err
 	}
@@ -72,3 +118,62 @@ func (b *basechat) transform(ctx context.Context, inputs map[string]any,
 		langchain.Output: withoutJSONEnd[0],
 	}, nil
 }
+
+// withDraft 用户有本handler工具的未完成草稿时，在输入中说明，
+// 使模型知道本轮输入是对上一轮追问的回答。返回新的map，不影响记忆中保存的用户输入
+func (b *basechat) withDraft(ctx context.Context, inputs map[string]any) map[string]any {
+	d, err := b.svc.Slots.Get(ctx, token.GetUid(ctx))
+	if err != nil || d == nil {
+		return inputs
+	}
+	if !slices.ContainsFunc(b.tools, func(t tools.Tool) bool { return t.Name() == d.Tool }) {
+		return inputs
+	}
+
+	hint := fmt.Sprintf("（用户正在通过%s创建%s", d.Tool, d.Title)
+	if len(d.Missing) > 0 {
+		hint += "，尚需补充: " + strings.Join(d.Missing, "、")
+	} else {
+		hint += "，等待用户确认"
+	}
+	// 需要按草稿ID确认的工具（如发送邮件），确认时传入该ID
+	if id, _ := d.Data["draftId"].(string); id != "" {
+		hint += "，草稿ID: " + id
+	}
+	res := maps.Clone(inputs)
+	res[langchain.Input] = hint + "）\n" + fmt.Sprint(inputs[langchain.Input])
+	return res
+}
+
+// toolLogMaxString 工具调用日志中输入字段的长度上限
+const toolLogMaxString = 512
+
+// traceTool 将工具调用的输入输出记录到对话的执行过程中，并输出脱敏后的调用日志
+type traceTool struct {
+	tools.Tool
+}
+
+func (t traceTool) Call(ctx context.Context, input string) (string, error) {
+	start := time.Now()
+	ctx, span := tracex.Start(ctx, "ai.tool "+t.Name(), tracex.KindInternal)
+	output, err := t.Tool.Call(ctx, input)
+	span.SetError(err)
+	span.End()
+
+	call := langchain.ToolCall{Name: t.Name(), Input: input, Output: output, Duration: time.Since(start)}
+	fields := []any{
+		"tool", t.Name(),
+		"uid", token.GetUid(ctx),
+		"input", redact.Text(input, toolLogMaxString),
+		"outputSize", len(output),
+		"time", tlog.RTField(start, time.Now()),
+	}
+	if err != nil {
+		call.Error = err.Error()
+		tlog.ErrorCtx(ctx, "ai.tool", requestid.Fields(ctx, append(fields, "err", call.Error)...)...)
+	} else {
+		tlog.InfoCtx(ctx, "ai.tool", requestid.Fields(ctx, fields...)...)
+	}
+	langchain.GetTrace(ctx).AddToolCall(call)
+	return output, err
+}
@@ -1,20 +1,19 @@
 package chatinternal
 
 import (
+	"context"
+	"fmt"
+
 	"aiOffice/internal/svc"
 	"aiOffice/pkg/langchain"
-	"fmt"
+	langhandler "aiOffice/pkg/langchain/handler"
+	"aiOffice/pkg/langchain/promptx"
 
 	"github.com/tmc/langchaingo/chains"
 	"github.com/tmc/langchaingo/prompts"
 )
 
-type DefaultHandler struct {
-	chain chains.Chain
-}
-
-func NewDefaultHandler(svc *svc.ServiceContext) *DefaultHandler {
-	template := `你是一个全能助手，请根据对话历史和用户问题进行回答。
+const _defaultPrompt = `你是一个全能助手，请根据对话历史和用户问题进行回答。
 
 对话历史:
 {{.history}}
@@ -22,17 +21,22 @@ func NewDefaultHandler(svc *svc.ServiceContext) *DefaultHandler {
 用户问题: {{.input}}
 
 请用中文回答:`
-	prompt := prompts.PromptTemplate{
-		Template:       template,
-		InputVariables: []string{langchain.Input, "history"},
-		TemplateFormat: prompts.TemplateFormatGoTemplate,
-		PartialVariables: map[string]any{
-			"chatType": fmt.Sprintf("%d", langchain.DefaultHandler),
-			"data":     "solution",
-		},
-	}
+
+func init() {
+	Register(Registration{
+		Name:  "default",
+		Order: 100,
+		New:   func(svc *svc.ServiceContext) langhandler.Handler { return NewDefaultHandler(svc) },
+	})
+}
+
+type DefaultHandler struct {
+	svc *svc.ServiceContext
+}
+
+func NewDefaultHandler(svc *svc.ServiceContext) *DefaultHandler {
 	return &DefaultHandler{
-		chain: chains.NewLLMChain(svc.LLM, prompt),
+		svc: svc,
 	}
 }
 
@@ -44,6 +48,22 @@ func (d *DefaultHandler) Description() string {
 	return "suitable for answering multiple questions"
 }
 
+// Chains 每次调用时读取提示词，修改后无需重启
 func (d *DefaultHandler) Chains() chains.Chain {
-	return d.chain
+	return chains.NewTransform(d.transform, []string{langchain.Input}, []string{langchain.Output})
+}
+
+func (d *DefaultHandler) transform(ctx context.Context, inputs map[string]any,
+	opts ...chains.ChainCallOption) (map[string]any, error) {
+
+	prompt := prompts.PromptTemplate{
+		Template:       d.svc.Prompts.Get(ctx, promptx.KeyDefault, _defaultPrompt),
+		InputVariables: []string{langchain.Input, "history"},
+		TemplateFormat: prompts.TemplateFormatGoTemplate,
+		PartialVariables: map[string]any{
+			"chatType": fmt.Sprintf("%d", langchain.DefaultHandler),
+			"data":     "solution",
+		},
+	}
+	return chains.Call(ctx, chains.NewLLMChain(d.svc.LLM, prompt), inputs, opts...)
 }
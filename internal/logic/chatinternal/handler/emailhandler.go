@@ -0,0 +1,45 @@
+package chatinternal
+
+import (
+	"aiOffice/internal/logic/chatinternal/toolx"
+	"aiOffice/internal/svc"
+	langhandler "aiOffice/pkg/langchain/handler"
+
+	"github.com/tmc/langchaingo/chains"
+)
+
+func init() {
+	Register(Registration{
+		Name:  "email",
+		Order: 60,
+		New:   func(svc *svc.ServiceContext) langhandler.Handler { return NewEmailHandler(svc) },
+	})
+}
+
+type EmailHandler struct {
+	*basechat
+}
+
+func NewEmailHandler(svc *svc.ServiceContext) *EmailHandler {
+	return &EmailHandler{
+		basechat: NewBaseChat(svc, toolx.Tools(svc, toolx.GroupEmail)),
+	}
+}
+
+func (e *EmailHandler) Name() string {
+	return "email"
+}
+
+func (e *EmailHandler) Description() string {
+	return "suitable for sending emails to colleagues or email addresses"
+}
+
+func (e *EmailHandler) Rules() []string {
+	return []string{
+		"用户要给某人发邮件，或确认、取消之前待发送的邮件",
+	}
+}
+
+func (e *EmailHandler) Chains() chains.Chain {
+	return e.basechat.Chains()
+}
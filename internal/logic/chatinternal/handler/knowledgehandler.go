@@ -3,23 +3,26 @@ package chatinternal
 import (
 	"aiOffice/internal/logic/chatinternal/toolx"
 	"aiOffice/internal/svc"
+	langhandler "aiOffice/pkg/langchain/handler"
 
 	"github.com/tmc/langchaingo/chains"
-	"github.com/tmc/langchaingo/tools"
 )
 
+func init() {
+	Register(Registration{
+		Name:  "knowledge",
+		Order: 30,
+		New:   func(svc *svc.ServiceContext) langhandler.Handler { return NewKnowledgeHandler(svc) },
+	})
+}
+
 type KnowledgeHandler struct {
 	*basechat
 }
 
 func NewKnowledgeHandler(svc *svc.ServiceContext) *KnowledgeHandler {
-	knowledgeTools := []tools.Tool{
-		toolx.NewKnowledgeUpdate(svc),
-		toolx.NewKnowledgeQuery(svc),
-	}
-
 	return &KnowledgeHandler{
-		basechat: NewBaseChat(svc, knowledgeTools),
+		basechat: NewBaseChat(svc, toolx.Tools(svc, toolx.GroupKnowledge)),
 	}
 }
 
@@ -33,6 +36,13 @@ Can answer employee consultation questions about company systems such as approva
 Can also be used for updating the knowledge base.`
 }
 
+func (k *KnowledgeHandler) Rules() []string {
+	return []string{
+		"用户询问公司制度、员工手册、考勤规则、请假流程、报销流程等知识库内容",
+		"用户要更新知识库、添加文档到知识库",
+	}
+}
+
 func (k *KnowledgeHandler) Chains() chains.Chain {
 	return k.basechat.Chains()
 }
@@ -0,0 +1,45 @@
+package chatinternal
+
+import (
+	"aiOffice/internal/logic/chatinternal/toolx"
+	"aiOffice/internal/svc"
+	langhandler "aiOffice/pkg/langchain/handler"
+
+	"github.com/tmc/langchaingo/chains"
+)
+
+func init() {
+	Register(Registration{
+		Name:  "org",
+		Order: 40,
+		New:   func(svc *svc.ServiceContext) langhandler.Handler { return NewOrgHandler(svc) },
+	})
+}
+
+type OrgHandler struct {
+	*basechat
+}
+
+func NewOrgHandler(svc *svc.ServiceContext) *OrgHandler {
+	return &OrgHandler{
+		basechat: NewBaseChat(svc, toolx.Tools(svc, toolx.GroupOrg)),
+	}
+}
+
+func (o *OrgHandler) Name() string {
+	return "org"
+}
+
+func (o *OrgHandler) Description() string {
+	return "suitable for organization queries, such as which department someone belongs to, department leader, department members and headcount"
+}
+
+func (o *OrgHandler) Rules() []string {
+	return []string{
+		"用户询问某人在哪个部门、部门有多少人、部门成员、我的领导是谁等组织架构问题",
+	}
+}
+
+func (o *OrgHandler) Chains() chains.Chain {
+	return o.basechat.Chains()
+}
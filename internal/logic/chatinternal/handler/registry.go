@@ -0,0 +1,51 @@
+package chatinternal
+
+import (
+	"sort"
+	"sync"
+
+	"aiOffice/internal/svc"
+	langhandler "aiOffice/pkg/langchain/handler"
+)
+
+// Registration handler的注册信息
+type Registration struct {
+	Name  string
+	Order int // 在路由提示词中的顺序，越小越靠前
+	New   func(svc *svc.ServiceContext) langhandler.Handler
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[string]Registration)
+)
+
+// Register 注册handler，在handler文件的init中调用，新增handler无需修改NewChat和router
+func Register(r Registration) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	registry[r.Name] = r
+}
+
+// Handlers 按顺序创建全部已注册的handler
+func Handlers(svc *svc.ServiceContext) []langhandler.Handler {
+	registryMu.RLock()
+	regs := make([]Registration, 0, len(registry))
+	for _, r := range registry {
+		regs = append(regs, r)
+	}
+	registryMu.RUnlock()
+
+	sort.Slice(regs, func(i, j int) bool {
+		if regs[i].Order != regs[j].Order {
+			return regs[i].Order < regs[j].Order
+		}
+		return regs[i].Name < regs[j].Name
+	})
+
+	handlers := make([]langhandler.Handler, 0, len(regs))
+	for _, r := range regs {
+		handlers = append(handlers, r.New(svc))
+	}
+	return handlers
+}
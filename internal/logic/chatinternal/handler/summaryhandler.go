@@ -0,0 +1,45 @@
+package chatinternal
+
+import (
+	"aiOffice/internal/logic/chatinternal/toolx"
+	"aiOffice/internal/svc"
+	langhandler "aiOffice/pkg/langchain/handler"
+
+	"github.com/tmc/langchaingo/chains"
+)
+
+func init() {
+	Register(Registration{
+		Name:  "summary",
+		Order: 50,
+		New:   func(svc *svc.ServiceContext) langhandler.Handler { return NewSummaryHandler(svc) },
+	})
+}
+
+type SummaryHandler struct {
+	*basechat
+}
+
+func NewSummaryHandler(svc *svc.ServiceContext) *SummaryHandler {
+	return &SummaryHandler{
+		basechat: NewBaseChat(svc, toolx.Tools(svc, toolx.GroupSummary)),
+	}
+}
+
+func (s *SummaryHandler) Name() string {
+	return "summary"
+}
+
+func (s *SummaryHandler) Description() string {
+	return "suitable for summarizing group chat history and extracting action items"
+}
+
+func (s *SummaryHandler) Rules() []string {
+	return []string{
+		"用户要总结群聊、聊天记录，或询问群里讨论了什么",
+	}
+}
+
+func (s *SummaryHandler) Chains() chains.Chain {
+	return s.basechat.Chains()
+}
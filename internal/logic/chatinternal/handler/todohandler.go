@@ -3,24 +3,26 @@ package chatinternal
 import (
 	"aiOffice/internal/logic/chatinternal/toolx"
 	"aiOffice/internal/svc"
+	langhandler "aiOffice/pkg/langchain/handler"
 
 	"github.com/tmc/langchaingo/chains"
-	"github.com/tmc/langchaingo/tools"
 )
 
+func init() {
+	Register(Registration{
+		Name:  "todo",
+		Order: 10,
+		New:   func(svc *svc.ServiceContext) langhandler.Handler { return NewTodoHandler(svc) },
+	})
+}
+
 type TodoHandler struct {
 	*basechat
 }
 
 func NewTodoHandler(svc *svc.ServiceContext) *TodoHandler {
-	// 创建待办工具
-	todoTools := []tools.Tool{
-		toolx.NewTodoTool(svc),      // 创建待办
-		toolx.NewTodoQueryTool(svc), // 查询待办
-	}
-
 	return &TodoHandler{
-		basechat: NewBaseChat(svc, todoTools),
+		basechat: NewBaseChat(svc, toolx.Tools(svc, toolx.GroupTodo)),
 	}
 }
 
@@ -32,6 +34,12 @@ func (t *TodoHandler) Description() string {
 	return "suitable for todo processing, such as todo creation, query, modification, dele tion, etc"
 }
 
+func (t *TodoHandler) Rules() []string {
+	return []string{
+		"用户要创建待办、任务、提醒、查询待办等",
+	}
+}
+
 func (t *TodoHandler) Chains() chains.Chain {
 	return t.basechat.Chains()
 }
@@ -0,0 +1,156 @@
+package chatinternal
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"mime"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"aiOffice/internal/svc"
+	"aiOffice/pkg/langchain"
+	"aiOffice/pkg/langchain/promptx"
+
+	"github.com/tmc/langchaingo/chains"
+	"github.com/tmc/langchaingo/llms"
+)
+
+const (
+	// ImagesKey 图片列表的输入key，值为[]string
+	ImagesKey = "images"
+
+	maxImages    = 4
+	maxImageSize = 10 << 20
+)
+
+const _visionPrompt = `你是一个办公助手，请结合用户发送的图片回答问题。
+如果图片是发票、收据等票据，请识别开票日期、金额、开票方和类目，并说明是否符合常见的报销要求（抬头、日期、金额是否清晰完整等）。
+请用中文回答。`
+
+var (
+	ErrVisionDisabled = errors.New("未配置图片理解模型")
+	ErrTooManyImages  = errors.New("每次最多发送4张图片")
+	ErrImagePath      = errors.New("图片地址无效，请先上传图片")
+	ErrImageTooLarge  = errors.New("图片不能超过10MB")
+)
+
+// VisionHandler 图片理解，消息带图片时直接调用，不参与路由
+type VisionHandler struct {
+	svc *svc.ServiceContext
+}
+
+func NewVisionHandler(svc *svc.ServiceContext) *VisionHandler {
+	return &VisionHandler{
+		svc: svc,
+	}
+}
+
+func (v *VisionHandler) Name() string {
+	return "vision"
+}
+
+func (v *VisionHandler) Description() string {
+	return "suitable for questions about the attached images"
+}
+
+func (v *VisionHandler) Chains() chains.Chain {
+	return chains.NewTransform(v.transform, []string{langchain.Input, ImagesKey}, []string{langchain.Output})
+}
+
+func (v *VisionHandler) transform(ctx context.Context, inputs map[string]any,
+	opts ...chains.ChainCallOption) (map[string]any, error) {
+
+	model := v.svc.Config.LangChain.VisionModel
+	if model == "" {
+		return nil, ErrVisionDisabled
+	}
+
+	images, _ := inputs[ImagesKey].([]string)
+	if len(images) > maxImages {
+		return nil, ErrTooManyImages
+	}
+
+	prompt, _ := inputs[langchain.Input].(string)
+	if strings.TrimSpace(prompt) == "" {
+		prompt = "请描述这张图片"
+	}
+
+	parts := make([]llms.ContentPart, 0, len(images)+1)
+	parts = append(parts, llms.TextPart(prompt))
+	for _, img := range images {
+		url, err := v.imageURL(img)
+		if err != nil {
+			return nil, err
+		}
+		parts = append(parts, llms.ImageURLPart(url))
+	}
+
+	messages := []llms.MessageContent{
+		llms.TextParts(llms.ChatMessageTypeSystem, v.svc.Prompts.Get(ctx, promptx.KeyVision, _visionPrompt)),
+		{Role: llms.ChatMessageTypeHuman, Parts: parts},
+	}
+
+	// 请求指定了模型时以请求为准（由LLM按调用参数覆盖）
+	callOpts := []llms.CallOption{llms.WithModel(model)}
+	if stream := langchain.GetStream(ctx); stream != nil {
+		callOpts = append(callOpts, llms.WithStreamingFunc(stream))
+	}
+
+	resp, err := v.svc.LLM.GenerateContent(ctx, messages, callOpts...)
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Choices) == 0 {
+		return nil, errors.New("图片理解模型未返回结果")
+	}
+	return map[string]any{
+		langchain.Output: resp.Choices[0].Content,
+	}, nil
+}
+
+// imageURL 网络图片直接传给模型，上传目录下的图片转为base64
+func (v *VisionHandler) imageURL(img string) (string, error) {
+	if strings.HasPrefix(img, "http://") || strings.HasPrefix(img, "https://") ||
+		strings.HasPrefix(img, "data:image/") {
+		return img, nil
+	}
+
+	savePath := v.svc.Config.Upload.SavePath
+	if savePath == "" {
+		savePath = "./uploads/"
+	}
+
+	// 只允许读取上传目录下的文件
+	root, err := filepath.Abs(savePath)
+	if err != nil {
+		return "", err
+	}
+	path, err := filepath.Abs(img)
+	if err != nil {
+		return "", ErrImagePath
+	}
+	if rel, err := filepath.Rel(root, path); err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+		return "", ErrImagePath
+	}
+
+	mt := mime.TypeByExtension(strings.ToLower(filepath.Ext(path)))
+	if !strings.HasPrefix(mt, "image/") {
+		return "", ErrImagePath
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", ErrImagePath
+	}
+	if info.Size() > maxImageSize {
+		return "", ErrImageTooLarge
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return "data:" + mt + ";base64," + base64.StdEncoding.EncodeToString(b), nil
+}
@@ -2,16 +2,16 @@ package toolx
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"strings"
-	"time"
 
 	"aiOffice/internal/domain"
 	"aiOffice/internal/svc"
-	"aiOffice/pkg/curl"
 	"aiOffice/pkg/langchain/outputparserx"
+	"aiOffice/pkg/timeutils"
 	"aiOffice/pkg/token"
+
+	"github.com/tmc/langchaingo/tools"
 )
 
 // ApprovalQueryTool 审批查询工具
@@ -20,6 +20,10 @@ type ApprovalQueryTool struct {
 	outputparser outputparserx.Structured
 }
 
+func init() {
+	Register(GroupApproval, func(svc *svc.ServiceContext) tools.Tool { return NewApprovalQueryTool(svc) })
+}
+
 // NewApprovalQueryTool 创建审批查询工具实例
 func NewApprovalQueryTool(svc *svc.ServiceContext) *ApprovalQueryTool {
 	return &ApprovalQueryTool{
@@ -29,6 +33,7 @@ func NewApprovalQueryTool(svc *svc.ServiceContext) *ApprovalQueryTool {
 				Name:        "type",
 				Description: "审批类型过滤: 0=全部, 2=请假, 3=补卡, 4=外出, 5=报销, 6=付款, 7=采购, 8=收款",
 				Type:        "int",
+				Enum:        []string{"0", "2", "3", "4", "5", "6", "7", "8"},
 			},
 			{
 				Name:        "userId",
@@ -49,76 +54,48 @@ func (t *ApprovalQueryTool) Description() string {
 	return `an approval query interface.
 use when you need to find, query, search or list approvals.
 use when user asks: "我的审批", "查询审批", "审批记录", "请假记录", "补卡记录", etc.
-If user specifies a userId, use that userId. Otherwise query current user's approvals.
+If user specifies a userId, use that userId. If user refers to someone by name, call user_find first to get the userId. Otherwise query current user's approvals.
 keep Chinese output.
 ` + t.outputparser.GetFormatInstructions()
 }
 
 // Call 执行审批查询
 func (t *ApprovalQueryTool) Call(ctx context.Context, input string) (string, error) {
-	fmt.Printf("[ApprovalQueryTool] 被调用，输入: %s\n", input)
-
 	// 解析输入
-	out, err := t.outputparser.Parse(input)
+	data, err := parseInput(ctx, t.svc, t.outputparser, input)
 	if err != nil {
-		out = make(map[string]any)
+		return "", err
 	}
 
-	data := out.(map[string]any)
-	if data == nil {
-		data = make(map[string]any)
+	req := &domain.ApprovalListReq{
+		UserId: getString(data, "userId"),
+		Type:   int(getFloat64(data, "type")),
+		Count:  10,
 	}
 
-	// 获取当前用户
-	uid := token.GetUid(ctx)
-	tokenStr, _ := ctx.Value("Authorization").(string)
-
 	// 如果没有指定userId，使用当前用户
-	if _, ok := data["userId"]; !ok || data["userId"] == "" {
-		data["userId"] = uid
+	if req.UserId == "" {
+		req.UserId = token.GetUid(ctx)
 	}
-	data["count"] = 10
-
-	// 调用API查询审批
-	apiUrl := fmt.Sprintf("http://%s/v1/approval/list", t.svc.Config.Addr)
-	fmt.Printf("[ApprovalQueryTool] 调用API: %s, params: %+v\n", apiUrl, data)
 
-	res, err := curl.PostRequest(tokenStr, apiUrl, data)
+	res, err := t.svc.ApprovalLogic.List(ctx, req)
 	if err != nil {
 		return "", fmt.Errorf("查询失败: %v", err)
 	}
-	fmt.Printf("[ApprovalQueryTool] API响应: %s\n", string(res))
 
-	return t.formatApprovalList(res)
+	return t.formatApprovalList(res), nil
 }
 
 // formatApprovalList 格式化审批列表输出
-func (t *ApprovalQueryTool) formatApprovalList(res []byte) (string, error) {
-	var apiResponse struct {
-		Code int    `json:"code"`
-		Msg  string `json:"msg"`
-		Data struct {
-			Count int64                  `json:"count"`
-			List  []*domain.ApprovalList `json:"data"`
-		} `json:"data"`
-	}
-
-	if err := json.Unmarshal(res, &apiResponse); err != nil {
-		return "", err
-	}
-
-	if apiResponse.Code != 200 {
-		return "", fmt.Errorf(apiResponse.Msg)
-	}
-
-	if len(apiResponse.Data.List) == 0 {
-		return "您当前没有审批记录。", nil
+func (t *ApprovalQueryTool) formatApprovalList(res *domain.ApprovalListResp) string {
+	if len(res.List) == 0 {
+		return "您当前没有审批记录。"
 	}
 
 	var result strings.Builder
 	result.WriteString("您的审批记录:\n\n")
 
-	for i, approval := range apiResponse.Data.List {
+	for i, approval := range res.List {
 		result.WriteString(fmt.Sprintf("%d. %s\n", i+1, approval.Title))
 		result.WriteString(fmt.Sprintf("   编号: %s\n", approval.No))
 		result.WriteString(fmt.Sprintf("   类型: %s\n", getApprovalTypeName(approval.Type)))
@@ -130,7 +107,7 @@ func (t *ApprovalQueryTool) formatApprovalList(res []byte) (string, error) {
 		result.WriteString("\n")
 	}
 
-	return result.String(), nil
+	return result.String()
 }
 
 // getApprovalTypeName 获取审批类型名称
@@ -190,5 +167,5 @@ func formatTime(timestamp int64) string {
 	if timestamp == 0 {
 		return "未设置"
 	}
-	return time.Unix(timestamp, 0).Format("2006-01-02 15:04")
+	return timeutils.Unix(timestamp).Format("2006-01-02 15:04")
 }
@@ -2,14 +2,18 @@ package toolx
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
-	"time"
+	"strings"
 
+	"aiOffice/internal/domain"
 	"aiOffice/internal/svc"
-	"aiOffice/pkg/curl"
+	"aiOffice/pkg/i18n"
 	"aiOffice/pkg/langchain/outputparserx"
+	"aiOffice/pkg/langchain/slotx"
+	"aiOffice/pkg/timeutils"
 	"aiOffice/pkg/token"
+
+	"github.com/tmc/langchaingo/tools"
 )
 
 // ApprovalTool 审批创建工具
@@ -18,6 +22,10 @@ type ApprovalTool struct {
 	outputparser outputparserx.Structured
 }
 
+func init() {
+	Register(GroupApproval, func(svc *svc.ServiceContext) tools.Tool { return NewApprovalTool(svc) })
+}
+
 // NewApprovalTool 创建审批工具实例
 func NewApprovalTool(svc *svc.ServiceContext) *ApprovalTool {
 	return &ApprovalTool{
@@ -32,6 +40,7 @@ func NewApprovalTool(svc *svc.ServiceContext) *ApprovalTool {
 				Name:        "leaveType",
 				Description: "请假类型(仅type=2时需要): 1=事假, 2=调休, 3=病假, 4=年假, 5=产假, 6=陪产假, 7=婚假, 8=丧假, 9=哺乳假",
 				Type:        "int",
+				Enum:        []string{"1", "2", "3", "4", "5", "6", "7", "8", "9"},
 			},
 			{
 				Name:        "startTime",
@@ -52,12 +61,18 @@ func NewApprovalTool(svc *svc.ServiceContext) *ApprovalTool {
 				Name:        "checkType",
 				Description: "补卡类型(仅type=3时需要): 1=上班卡, 2=下班卡",
 				Type:        "int",
+				Enum:        []string{"1", "2"},
 			},
 			{
 				Name:        "date",
 				Description: "补卡日期 Unix timestamp (仅type=3时需要)",
 				Type:        "int64",
 			},
+			{
+				Name:        "confirm",
+				Description: "false until the user has confirmed the summary returned by this tool",
+				Type:        "bool",
+			},
 		}),
 	}
 }
@@ -73,147 +88,249 @@ func (t *ApprovalTool) Description() string {
 use when you need to create an approval request.
 支持的审批类型:
 - 请假审批(type=2): 需要leaveType, startTime, endTime, reason
-- 补卡审批(type=3): 需要date, checkType, reason
+- 补卡审批(type=3): 需要date, checkType, reason；用户说忘打卡时使用该类型，checkType未说明时留空，由考勤记录判断
 - 外出审批(type=4): 需要startTime, endTime, reason
+only fill in fields the user has actually provided, leave unknown fields empty, never guess.
+the tool keeps what has been provided; if it returns questions, ask the user, then call again with only the newly provided fields.
+call with confirm=true only after the user has confirmed the returned summary.
 keep Chinese output.
 ` + t.outputparser.GetFormatInstructions()
 }
 
-// Call 执行审批创建
-func (t *ApprovalTool) Call(ctx context.Context, input string) (string, error) {
-	fmt.Printf("[ApprovalTool] 被调用，输入: %s\n", input)
+// approvalSlots 各审批类型需要用户提供的信息
+var approvalSlots = map[int][]slotx.Slot{
+	2: {
+		{Name: "leaveType", Question: "请假类型（事假、调休、病假、年假等）"},
+		{Name: "startTime", Question: "请假开始时间"},
+		{Name: "endTime", Question: "请假结束时间"},
+		{Name: "reason", Question: "请假理由"},
+	},
+	3: {
+		{Name: "date", Question: "补卡日期"},
+		{Name: "checkType", Question: "补上班卡还是下班卡"},
+		{Name: "reason", Question: "补卡理由"},
+	},
+	4: {
+		{Name: "startTime", Question: "外出开始时间"},
+		{Name: "endTime", Question: "外出结束时间"},
+		{Name: "reason", Question: "外出事由"},
+	},
+}
 
+// Call 执行审批创建，信息不全时追问，信息齐全后需用户确认才创建
+func (t *ApprovalTool) Call(ctx context.Context, input string) (string, error) {
 	// 解析输入
-	out, err := t.outputparser.Parse(input)
+	data, err := parseInput(ctx, t.svc, t.outputparser, input)
 	if err != nil {
-		return "", fmt.Errorf("解析输入失败: %v", err)
-	}
-
-	data := out.(map[string]any)
-	if data == nil {
-		return "", fmt.Errorf("无效的输入数据")
+		return "", err
 	}
+	confirm, _ := data["confirm"].(bool)
+	delete(data, "confirm")
 
-	// 获取当前用户
+	// 合并之前轮次已提供的信息，切换审批类型时重新开始
 	uid := token.GetUid(ctx)
-	tokenStr, _ := ctx.Value("Authorization").(string)
+	draft := loadDraft(ctx, t.svc, uid, t.Name())
+	if draft != nil && !slotx.IsEmpty(data["type"]) && data["type"] != draft.Data["type"] {
+		draft = nil
+	}
+	if draft != nil {
+		data = slotx.Merge(draft.Data, data)
+	}
 
-	// 获取审批类型和理由
 	approvalType := int(getFloat64(data, "type"))
-	reason := getString(data, "reason")
+	slots, ok := approvalSlots[approvalType]
+	if approvalType == 0 {
+		slots = []slotx.Slot{{Name: "type", Question: "审批类型（请假、补卡、外出）"}}
+	} else if !ok {
+		return fmt.Sprintf("不支持的审批类型: %d，目前支持请假、补卡、外出。", approvalType), nil
+	}
 
-	// 构建审批请求
-	approvalReq := map[string]any{
-		"userId": uid,
-		"type":   approvalType,
-		"reason": reason,
+	if approvalType == 3 {
+		t.inferCheckType(ctx, data)
+	}
+
+	title := "审批"
+	if approvalType != 0 {
+		title = getApprovalTypeName(approvalType)
+	}
+	if missing := slotx.Missing(data, slots); len(missing) > 0 {
+		saveDraft(ctx, t.svc, uid, &slotx.Draft{Handler: GroupApproval, Tool: t.Name(), Title: title, Data: data}, missing)
+		return askMissing(t.Name(), missing), nil
+	}
+	if getFloat64(data, "endTime") < getFloat64(data, "startTime") {
+		delete(data, "endTime")
+		saveDraft(ctx, t.svc, uid, &slotx.Draft{Handler: GroupApproval, Tool: t.Name(), Title: title, Data: data}, nil)
+		return "结束时间早于开始时间，请向用户重新确认结束时间。", nil
+	}
+
+	req := t.build(ctx, approvalType, data)
+	if !confirm {
+		saveDraft(ctx, t.svc, uid, &slotx.Draft{Handler: GroupApproval, Tool: t.Name(), Title: title, Data: data}, nil)
+		return askConfirm(ctx, t.Name(), title, t.describe(ctx, approvalType, data)), nil
+	}
+
+	if _, err := t.svc.ApprovalLogic.Create(ctx, req); err != nil {
+		return "", fmt.Errorf("创建审批失败: %v", err)
+	}
+	clearDraft(ctx, t.svc, uid)
+
+	// 返回成功信息
+	return t.formatResult(ctx, approvalType, data), nil
+}
+
+// build 根据收集到的信息构建审批请求
+func (t *ApprovalTool) build(ctx context.Context, approvalType int, data map[string]any) *domain.Approval {
+	reason := getString(data, "reason")
+	req := &domain.Approval{
+		UserId: token.GetUid(ctx),
+		Type:   approvalType,
+		Reason: reason,
 	}
 
 	switch approvalType {
 	case 2: // 请假
-		leaveType := int(getFloat64(data, "leaveType"))
-		if leaveType == 0 {
-			leaveType = 1 // 默认事假
-		}
 		startTime := int64(getFloat64(data, "startTime"))
 		endTime := int64(getFloat64(data, "endTime"))
 		// 计算请假天数
 		days := float64(endTime-startTime) / 86400
 		if days < 1 {
-			approvalReq["abstract"] = reason
+			req.Abstract = reason
 		} else {
-			approvalReq["abstract"] = fmt.Sprintf("请假%.0f天", days)
+			req.Abstract = fmt.Sprintf("请假%.0f天", days)
 		}
-		approvalReq["leave"] = map[string]any{
-			"type":      leaveType,
-			"startTime": startTime,
-			"endTime":   endTime,
-			"reason":    reason,
-			"timeType":  1, // 默认按小时
+		req.Leave = &domain.Leave{
+			Type:      int(getFloat64(data, "leaveType")),
+			StartTime: startTime,
+			EndTime:   endTime,
+			Reason:    reason,
+			TimeType:  1, // 默认按小时
 		}
 	case 3: // 补卡
 		checkType := int(getFloat64(data, "checkType"))
-		if checkType == 0 {
-			checkType = 1 // 默认上班卡
-		}
 		date := int64(getFloat64(data, "date"))
-		if date == 0 {
-			date = time.Now().Unix()
-		}
 		// day 需要是 int64 格式，如 20240530
-		tm := time.Unix(date, 0)
-		day := int64(tm.Year()*10000 + int(tm.Month())*100 + tm.Day())
-		checkTypeName := "上班"
-		if checkType == 2 {
-			checkTypeName = "下班"
-		}
+		tm := timeutils.Unix(date)
+		day := timeutils.DayNumber(tm)
 		// 格式: 12月8日上班补卡
-		approvalReq["abstract"] = fmt.Sprintf("%d月%d日%s补卡", tm.Month(), tm.Day(), checkTypeName)
-		approvalReq["makeCard"] = map[string]any{
-			"date":          date,
-			"reason":        reason,
-			"day":           day,
-			"workCheckType": checkType,
+		req.Abstract = fmt.Sprintf("%d月%d日%s补卡", tm.Month(), tm.Day(), getCheckTypeName(checkType))
+		req.MakeCard = &domain.MakeCard{
+			Date:      date,
+			Reason:    reason,
+			Day:       day,
+			CheckType: checkType,
 		}
 	case 4: // 外出
 		startTime := int64(getFloat64(data, "startTime"))
 		endTime := int64(getFloat64(data, "endTime"))
 		duration := float32(endTime-startTime) / 3600 // 计算时长(小时)
 		// 格式: 外出拜访客户
-		approvalReq["abstract"] = fmt.Sprintf("外出%s", reason)
-		approvalReq["goOut"] = map[string]any{
-			"startTime": startTime,
-			"endTime":   endTime,
-			"duration":  duration,
-			"reason":    reason,
+		req.Abstract = fmt.Sprintf("外出%s", reason)
+		req.GoOut = &domain.GoOut{
+			StartTime: startTime,
+			EndTime:   endTime,
+			Duration:  duration,
+			Reason:    reason,
 		}
-	default:
-		return "", fmt.Errorf("不支持的审批类型: %d", approvalType)
 	}
+	return req
+}
 
-	// 调用API创建审批
-	apiUrl := fmt.Sprintf("http://%s/v1/approval", t.svc.Config.Addr)
-	fmt.Printf("[ApprovalTool] 调用API: %s, body: %+v\n", apiUrl, approvalReq)
+// describe 生成供用户确认的审批内容
+func (t *ApprovalTool) describe(ctx context.Context, approvalType int, data map[string]any) string {
+	lines := []string{i18n.T(ctx, "类型: ") + i18n.T(ctx, getApprovalTypeName(approvalType))}
+	switch approvalType {
+	case 2:
+		lines = append(lines,
+			i18n.T(ctx, "请假类型: ")+i18n.T(ctx, getLeaveTypeName(int(getFloat64(data, "leaveType")))),
+			i18n.T(ctx, "开始时间: ")+formatTimestamp(ctx, int64(getFloat64(data, "startTime"))),
+			i18n.T(ctx, "结束时间: ")+formatTimestamp(ctx, int64(getFloat64(data, "endTime"))))
+	case 3:
+		lines = append(lines,
+			i18n.T(ctx, "补卡日期: ")+timeutils.Unix(int64(getFloat64(data, "date"))).Format("2006-01-02"),
+			i18n.T(ctx, "补卡类型: ")+i18n.T(ctx, getCheckTypeName(int(getFloat64(data, "checkType")))+"卡"))
+		if record := t.attendance(ctx, data); record != nil {
+			lines = append(lines, i18n.T(ctx, "当天考勤: ")+formatAttendance(ctx, record))
+		}
+	case 4:
+		lines = append(lines,
+			i18n.T(ctx, "开始时间: ")+formatTimestamp(ctx, int64(getFloat64(data, "startTime"))),
+			i18n.T(ctx, "结束时间: ")+formatTimestamp(ctx, int64(getFloat64(data, "endTime"))))
+	}
+	lines = append(lines, i18n.T(ctx, "理由: ")+getString(data, "reason"))
+	return strings.Join(lines, "\n")
+}
 
-	res, err := curl.PostRequest(tokenStr, apiUrl, approvalReq)
+// attendance 补卡日期当天的考勤记录，查询失败时返回nil
+func (t *ApprovalTool) attendance(ctx context.Context, data map[string]any) *domain.Attendance {
+	date := int64(getFloat64(data, "date"))
+	if date == 0 {
+		return nil
+	}
+	record, err := t.svc.AttendanceLogic.Day(ctx, &domain.AttendanceDayReq{Day: timeutils.DayNumber(timeutils.Unix(date))})
 	if err != nil {
-		return "", fmt.Errorf("创建审批失败: %v", err)
+		return nil
 	}
-	fmt.Printf("[ApprovalTool] API响应: %s\n", string(res))
+	return record
+}
 
-	// 解析响应
-	var apiResponse struct {
-		Code int    `json:"code"`
-		Msg  string `json:"msg"`
-		Data struct {
-			Id string `json:"id"`
-		} `json:"data"`
+// inferCheckType 用户没有说明补哪张卡时，按当天的考勤记录补缺少的卡，都缺或都不缺时仍需追问
+func (t *ApprovalTool) inferCheckType(ctx context.Context, data map[string]any) {
+	if !slotx.IsEmpty(data["checkType"]) {
+		return
 	}
-
-	if err := json.Unmarshal(res, &apiResponse); err != nil {
-		return "", err
+	record := t.attendance(ctx, data)
+	if record == nil {
+		return
 	}
-
-	if apiResponse.Code != 200 {
-		return "", fmt.Errorf(apiResponse.Msg)
+	switch {
+	case record.CheckIn == 0 && record.CheckOut > 0:
+		data["checkType"] = float64(1)
+	case record.CheckIn > 0 && record.CheckOut == 0 && record.MissingOut:
+		data["checkType"] = float64(2)
+	case record.CheckIn == 0 && record.CheckOut == 0 && !record.MissingOut:
+		// 当天还没到下班时间，只可能是忘了上班卡
+		data["checkType"] = float64(1)
 	}
-
-	// 返回成功信息
-	return t.formatResult(approvalType, data), nil
 }
 
 // formatResult 格式化创建结果
-func (t *ApprovalTool) formatResult(approvalType int, data map[string]any) string {
+func (t *ApprovalTool) formatResult(ctx context.Context, approvalType int, data map[string]any) string {
 	switch approvalType {
 	case 2:
-		return fmt.Sprintf("请假审批已创建成功！\n理由: %s", getString(data, "reason"))
+		return i18n.T(ctx, "请假审批已创建成功！\n理由: %s", getString(data, "reason"))
 	case 3:
-		return fmt.Sprintf("补卡审批已创建成功！\n理由: %s", getString(data, "reason"))
+		return i18n.T(ctx, "补卡审批已创建成功！\n理由: %s", getString(data, "reason"))
 	case 4:
-		return fmt.Sprintf("外出审批已创建成功！\n理由: %s", getString(data, "reason"))
+		return i18n.T(ctx, "外出审批已创建成功！\n理由: %s", getString(data, "reason"))
 	default:
-		return "审批已创建成功！"
+		return i18n.T(ctx, "审批已创建成功！")
+	}
+}
+
+// getCheckTypeName 获取补卡类型名称
+func getCheckTypeName(checkType int) string {
+	if checkType == 2 {
+		return "下班"
+	}
+	return "上班"
+}
+
+// parseInput 解析工具输入，解析失败时让模型根据错误修正；
+// 输入为空表示没有条件，返回空map
+func parseInput(ctx context.Context, svc *svc.ServiceContext, p outputparserx.Structured, input string) (map[string]any, error) {
+	if strings.TrimSpace(input) == "" {
+		return map[string]any{}, nil
+	}
+
+	retries := svc.Config.LangChain.ParseRetries
+	if retries == 0 {
+		retries = 2
+	}
+	out, err := p.Fix(ctx, svc.LLM, input, max(retries, 0))
+	if err != nil {
+		return nil, fmt.Errorf("解析输入失败: %v", err)
 	}
+	return out.(map[string]any), nil
 }
 
 // getFloat64 安全获取float64值
@@ -236,6 +353,26 @@ func getString(data map[string]any, key string) string {
 	return ""
 }
 
+// getStrings 安全获取[]string值，兼容模型只给出单个字符串的情况
+func getStrings(data map[string]any, key string) []string {
+	var res []string
+	switch v := data[key].(type) {
+	case []any:
+		for _, s := range v {
+			if s, ok := s.(string); ok && s != "" {
+				res = append(res, s)
+			}
+		}
+	case []string:
+		res = v
+	case string:
+		if v != "" {
+			res = append(res, v)
+		}
+	}
+	return res
+}
+
 // getLeaveTypeName 获取请假类型名称
 func getLeaveTypeName(leaveType int) string {
 	switch leaveType {
@@ -0,0 +1,107 @@
+package toolx
+
+import (
+	"context"
+	"fmt"
+
+	"aiOffice/internal/domain"
+	"aiOffice/internal/svc"
+	"aiOffice/pkg/i18n"
+	"aiOffice/pkg/langchain/outputparserx"
+	"aiOffice/pkg/timeutils"
+
+	"github.com/tmc/langchaingo/tools"
+)
+
+// AttendanceTool 考勤查询工具
+type AttendanceTool struct {
+	svc          *svc.ServiceContext
+	outputparser outputparserx.Structured
+}
+
+func init() {
+	Register(GroupApproval, func(svc *svc.ServiceContext) tools.Tool { return NewAttendanceTool(svc) })
+}
+
+// NewAttendanceTool 创建考勤查询工具实例
+func NewAttendanceTool(svc *svc.ServiceContext) *AttendanceTool {
+	return &AttendanceTool{
+		svc: svc,
+		outputparser: outputparserx.NewStructured([]outputparserx.ResponseSchema{
+			{
+				Name:        "date",
+				Description: "要查询的日期 Unix timestamp，留空查询今天",
+				Type:        "int64",
+			},
+		}),
+	}
+}
+
+// Name 返回工具名称
+func (t *AttendanceTool) Name() string {
+	return "attendance_find"
+}
+
+// Description 返回工具描述
+func (t *AttendanceTool) Description() string {
+	return `an attendance query interface.
+use when user asks about clock-in records: "我今天打卡了吗", "昨天的考勤", "我有没有迟到", etc.
+if the result shows a missing check and the user wants to fix it, use approval_add with type=3.
+keep Chinese output.
+` + t.outputparser.GetFormatInstructions()
+}
+
+// Call 查询某一天的考勤
+func (t *AttendanceTool) Call(ctx context.Context, input string) (string, error) {
+	data, err := parseInput(ctx, t.svc, t.outputparser, input)
+	if err != nil {
+		return "", err
+	}
+
+	req := &domain.AttendanceDayReq{}
+	if date := int64(getFloat64(data, "date")); date > 0 {
+		req.Day = timeutils.DayNumber(timeutils.Unix(date))
+	}
+	res, err := t.svc.AttendanceLogic.Day(ctx, req)
+	if err != nil {
+		return "", fmt.Errorf("查询失败: %v", err)
+	}
+
+	result := fmt.Sprintf("%d %s", res.Day, formatAttendance(ctx, res))
+	if res.MissingIn || res.MissingOut {
+		result += "\n" + i18n.T(ctx, "缺卡可以发起补卡审批。")
+	}
+	return result, nil
+}
+
+// formatAttendance 格式化一天的打卡情况，如：上班 09:05（迟到），下班 未打卡
+func formatAttendance(ctx context.Context, a *domain.Attendance) string {
+	var late, early string
+	if a.Late {
+		late = "（迟到）"
+	}
+	if a.Early {
+		early = "（早退）"
+	}
+	return formatCheck(ctx, "上班 ", a.CheckIn, a.CheckInSource, a.MissingIn, late) + i18n.T(ctx, "，") +
+		formatCheck(ctx, "下班 ", a.CheckOut, a.CheckOutSource, a.MissingOut, early)
+}
+
+// formatCheck 格式化一次打卡，abnormal为迟到、早退的标记
+func formatCheck(ctx context.Context, label string, at int64, source int, missing bool, abnormal string) string {
+	s := i18n.T(ctx, label)
+	switch {
+	case at == 0 && missing:
+		return s + i18n.T(ctx, "缺卡")
+	case at == 0:
+		return s + i18n.T(ctx, "未打卡")
+	}
+	s += timeutils.Unix(at).Format("15:04")
+	if source == 2 {
+		s += i18n.T(ctx, "（补卡）")
+	}
+	if abnormal != "" {
+		s += i18n.T(ctx, abnormal)
+	}
+	return s
+}
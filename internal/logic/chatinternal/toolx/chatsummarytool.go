@@ -0,0 +1,162 @@
+package toolx
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"aiOffice/internal/model"
+	"aiOffice/internal/svc"
+	"aiOffice/pkg/langchain/outputparserx"
+	"aiOffice/pkg/pagex"
+	"aiOffice/pkg/timeutils"
+
+	"github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/tools"
+)
+
+const (
+	defaultSummaryCount = 50
+	maxSummaryCount     = 200
+
+	// 前端未传会话时使用的全员群聊
+	defaultGroupConversationId = "all"
+)
+
+const _chatSummaryPrompt = `以下是一段群聊记录，每行格式为"[时间] 发送人: 内容"。
+请用中文总结讨论的主要内容，然后列出其中的待办事项（负责人、事项、截止时间，没有则写"未提及"），没有待办事项时写"无"。
+
+聊天记录:
+%s
+
+输出格式:
+【讨论要点】
+1. ...
+【待办事项】
+1. ...`
+
+// ChatSummaryTool 群聊总结工具，读取会话最近的聊天记录交给LLM总结
+type ChatSummaryTool struct {
+	svc          *svc.ServiceContext
+	outputparser outputparserx.Structured
+}
+
+func init() {
+	Register(GroupSummary, func(svc *svc.ServiceContext) tools.Tool { return NewChatSummaryTool(svc) })
+}
+
+// NewChatSummaryTool 创建群聊总结工具实例
+func NewChatSummaryTool(svc *svc.ServiceContext) *ChatSummaryTool {
+	return &ChatSummaryTool{
+		svc: svc,
+		outputparser: outputparserx.NewStructured([]outputparserx.ResponseSchema{
+			{
+				Name:        "count",
+				Description: fmt.Sprintf("number of recent messages to summarize, default %d, max %d", defaultSummaryCount, maxSummaryCount),
+				Type:        "int",
+			},
+			{
+				Name:        "today",
+				Description: "true if the user only cares about today's messages (今天/今日)",
+				Type:        "bool",
+			},
+		}),
+	}
+}
+
+// Name 返回工具名称
+func (t *ChatSummaryTool) Name() string {
+	return "chat_summary"
+}
+
+// Description 返回工具描述
+func (t *ChatSummaryTool) Description() string {
+	return `a group chat summary interface.
+use when user wants to summarize what was discussed in the current group chat and extract action items.
+use when user asks: "总结一下今天群里聊了什么", "群里刚才说了什么", "总结聊天记录", "有哪些待办被提到", etc.
+return the tool output to the user as is.
+keep Chinese output.
+` + t.outputparser.GetFormatInstructions()
+}
+
+// Call 执行群聊总结
+func (t *ChatSummaryTool) Call(ctx context.Context, input string) (string, error) {
+	data, err := parseInput(ctx, t.svc, t.outputparser, input)
+	if err != nil {
+		return "", err
+	}
+
+	count := int(getFloat64(data, "count"))
+	if count <= 0 {
+		count = defaultSummaryCount
+	}
+	if count > maxSummaryCount {
+		count = maxSummaryCount
+	}
+
+	var startTime int64
+	if today, _ := data["today"].(bool); today {
+		startTime, _ = timeutils.TodayRange()
+	}
+
+	// 会话由发起AI对话时所在的聊天窗口决定
+	conversationId, _ := ctx.Value("conversationId").(string)
+	if conversationId == "" {
+		conversationId = defaultGroupConversationId
+	}
+
+	// 只总结群聊，私聊和AI对话的会话ID可被推算，不能通过该工具读取他人的记录
+	if strings.HasPrefix(conversationId, "ai_") {
+		return "只能总结群聊的聊天记录。", nil
+	}
+
+	list, err := t.svc.ChatLogModel.ListByConversationId(ctx, conversationId, model.GroupChatType, startTime, count)
+	if err != nil {
+		return "", fmt.Errorf("查询聊天记录失败: %v", err)
+	}
+	if len(list) == 0 {
+		return "该会话在指定时间内没有聊天记录。", nil
+	}
+
+	transcript, err := t.transcript(ctx, list)
+	if err != nil {
+		return "", err
+	}
+
+	res, err := llms.GenerateFromSinglePrompt(ctx, t.svc.LLM, fmt.Sprintf(_chatSummaryPrompt, transcript))
+	if err != nil {
+		return "", fmt.Errorf("生成总结失败: %v", err)
+	}
+	return res, nil
+}
+
+// transcript 将聊天记录拼接为文本，发送人ID替换为姓名
+func (t *ChatSummaryTool) transcript(ctx context.Context, list []*model.ChatLog) (string, error) {
+	uids := make([]string, 0, len(list))
+	seen := make(map[string]bool, len(list))
+	for _, v := range list {
+		if !seen[v.SendId] {
+			seen[v.SendId] = true
+			uids = append(uids, v.SendId)
+		}
+	}
+
+	users, _, err := t.svc.UserModel.List(ctx, uids, "", pagex.Page{Count: len(uids)})
+	if err != nil {
+		return "", fmt.Errorf("查询用户失败: %v", err)
+	}
+	names := make(map[string]string, len(users))
+	for _, u := range users {
+		names[u.ID.Hex()] = u.Name
+	}
+
+	var sb strings.Builder
+	for _, v := range list {
+		name, ok := names[v.SendId]
+		if !ok {
+			name = v.SendId
+		}
+		sb.WriteString(fmt.Sprintf("[%s] %s: %s\n", formatTimestamp(ctx, v.SendTime), name, v.MsgContent))
+	}
+	return sb.String(), nil
+}
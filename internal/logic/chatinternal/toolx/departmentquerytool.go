@@ -0,0 +1,221 @@
+package toolx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"aiOffice/internal/model"
+	"aiOffice/internal/svc"
+	"aiOffice/pkg/langchain/outputparserx"
+	"aiOffice/pkg/pagex"
+	"aiOffice/pkg/token"
+
+	"github.com/tmc/langchaingo/tools"
+)
+
+// 部门成员最多列出的人数，避免输出过长
+const maxListMembers = 20
+
+// DepartmentQueryTool 部门与组织架构查询工具
+type DepartmentQueryTool struct {
+	svc          *svc.ServiceContext
+	outputparser outputparserx.Structured
+}
+
+func init() {
+	Register(GroupOrg, func(svc *svc.ServiceContext) tools.Tool { return NewDepartmentQueryTool(svc) })
+}
+
+// NewDepartmentQueryTool 创建部门查询工具实例
+func NewDepartmentQueryTool(svc *svc.ServiceContext) *DepartmentQueryTool {
+	return &DepartmentQueryTool{
+		svc: svc,
+		outputparser: outputparserx.NewStructured([]outputparserx.ResponseSchema{
+			{
+				Name:        "depName",
+				Description: "department name to query, such as '研发部'. empty if the user asks about a person",
+				Type:        "string",
+			},
+			{
+				Name:        "userName",
+				Description: "name of the person to query, such as '张三'. empty if the user asks about himself (我/我的) or about a department",
+				Type:        "string",
+			},
+		}),
+	}
+}
+
+// Name 返回工具名称
+func (t *DepartmentQueryTool) Name() string {
+	return "department_find"
+}
+
+// Description 返回工具描述
+func (t *DepartmentQueryTool) Description() string {
+	return `a department and organization query interface.
+use when you need to know which department someone belongs to, who the leader is, or how many members a department has.
+use when user asks: "张三在哪个部门", "研发部有多少人", "研发部有哪些人", "我的领导是谁", "我在哪个部门", etc.
+fill depName when asking about a department, fill userName when asking about a person, leave both empty when asking about the current user.
+keep Chinese output.
+` + t.outputparser.GetFormatInstructions()
+}
+
+// Call 执行部门查询
+func (t *DepartmentQueryTool) Call(ctx context.Context, input string) (string, error) {
+	data, err := parseInput(ctx, t.svc, t.outputparser, input)
+	if err != nil {
+		return "", err
+	}
+	depName, _ := data["depName"].(string)
+	userName, _ := data["userName"].(string)
+
+	if depName = strings.TrimSpace(depName); depName != "" {
+		return t.findDepartment(ctx, depName)
+	}
+	return t.findUserDepartment(ctx, strings.TrimSpace(userName))
+}
+
+// findDepartment 查询部门的负责人和成员
+func (t *DepartmentQueryTool) findDepartment(ctx context.Context, depName string) (string, error) {
+	deps, err := t.svc.DepartmentModel.FindAll(ctx)
+	if err != nil {
+		return "", fmt.Errorf("查询部门失败: %v", err)
+	}
+
+	var result strings.Builder
+	for _, dep := range deps {
+		if !strings.Contains(dep.Name, depName) {
+			continue
+		}
+
+		members, err := t.memberNames(ctx, dep.ID.Hex())
+		if err != nil {
+			return "", err
+		}
+
+		result.WriteString(fmt.Sprintf("部门: %s\n", dep.Name))
+		result.WriteString(fmt.Sprintf("   负责人: %s\n", leaderName(dep)))
+		result.WriteString(fmt.Sprintf("   人数: %d\n", len(members)))
+		if len(members) > maxListMembers {
+			result.WriteString(fmt.Sprintf("   成员: %s 等\n", strings.Join(members[:maxListMembers], "、")))
+		} else if len(members) > 0 {
+			result.WriteString(fmt.Sprintf("   成员: %s\n", strings.Join(members, "、")))
+		}
+		result.WriteString("\n")
+	}
+
+	if result.Len() == 0 {
+		return fmt.Sprintf("没有找到名称包含“%s”的部门。", depName), nil
+	}
+	return result.String(), nil
+}
+
+// findUserDepartment 查询用户所在部门及其领导，userName为空时查询当前用户，有同名用户时逐一列出
+func (t *DepartmentQueryTool) findUserDepartment(ctx context.Context, userName string) (string, error) {
+	var users []*model.User
+	if userName == "" {
+		user, err := t.svc.UserModel.FindOne(ctx, token.GetUid(ctx))
+		if err != nil && !errors.Is(err, model.ErrNotFound) {
+			return "", fmt.Errorf("查询用户失败: %v", err)
+		}
+		if user != nil {
+			users = append(users, user)
+		}
+	} else {
+		var err error
+		if users, err = t.svc.UserModel.FindAllByName(ctx, userName); err != nil {
+			return "", fmt.Errorf("查询用户失败: %v", err)
+		}
+	}
+	if len(users) == 0 {
+		return fmt.Sprintf("没有找到用户“%s”。", userName), nil
+	}
+	if len(users) == 1 {
+		return t.userDepartment(ctx, users[0])
+	}
+
+	var result strings.Builder
+	result.WriteString(fmt.Sprintf("有%d位名为“%s”的用户，请向用户确认是哪一位:\n\n", len(users), userName))
+	for _, user := range users {
+		res, err := t.userDepartment(ctx, user)
+		if err != nil {
+			return "", err
+		}
+		result.WriteString(fmt.Sprintf("userId=%s，", user.ID.Hex()))
+		result.WriteString(res)
+	}
+	return result.String(), nil
+}
+
+// userDepartment 查询一个用户所在部门及其领导
+func (t *DepartmentQueryTool) userDepartment(ctx context.Context, user *model.User) (string, error) {
+	uid := user.ID.Hex()
+	relations, err := t.svc.DepartmentuserModel.FindByUserId(ctx, uid)
+	if err != nil {
+		return "", fmt.Errorf("查询部门失败: %v", err)
+	}
+	if len(relations) == 0 {
+		return fmt.Sprintf("%s 目前不属于任何部门。", user.Name), nil
+	}
+
+	depIds := make([]string, 0, len(relations))
+	for _, r := range relations {
+		depIds = append(depIds, r.DepId)
+	}
+	deps, err := t.svc.DepartmentModel.FindByIds(ctx, depIds)
+	if err != nil {
+		return "", fmt.Errorf("查询部门失败: %v", err)
+	}
+
+	var result strings.Builder
+	result.WriteString(fmt.Sprintf("%s 所在部门:\n\n", user.Name))
+	for i, dep := range deps {
+		result.WriteString(fmt.Sprintf("%d. %s\n", i+1, dep.Name))
+		result.WriteString(fmt.Sprintf("   部门负责人: %s\n", leaderName(dep)))
+
+		// 本人是负责人时，领导为上级部门的负责人
+		if dep.LeaderId == uid && dep.ParentId != "" {
+			if parent, err := t.svc.DepartmentModel.FindOne(ctx, dep.ParentId); err == nil {
+				result.WriteString(fmt.Sprintf("   直属领导: %s（%s负责人）\n", leaderName(parent), parent.Name))
+			}
+		}
+		result.WriteString("\n")
+	}
+	return result.String(), nil
+}
+
+// memberNames 查询部门成员的姓名
+func (t *DepartmentQueryTool) memberNames(ctx context.Context, depId string) ([]string, error) {
+	relations, err := t.svc.DepartmentuserModel.FindByDepId(ctx, depId)
+	if err != nil {
+		return nil, fmt.Errorf("查询部门成员失败: %v", err)
+	}
+	if len(relations) == 0 {
+		return nil, nil
+	}
+
+	uids := make([]string, 0, len(relations))
+	for _, r := range relations {
+		uids = append(uids, r.UserId)
+	}
+	users, _, err := t.svc.UserModel.List(ctx, uids, "", pagex.Page{Count: len(uids)})
+	if err != nil {
+		return nil, fmt.Errorf("查询部门成员失败: %v", err)
+	}
+
+	names := make([]string, 0, len(users))
+	for _, u := range users {
+		names = append(names, u.Name)
+	}
+	return names, nil
+}
+
+// leaderName 部门负责人姓名
+func leaderName(dep *model.Department) string {
+	if dep.Leader == "" {
+		return "未设置"
+	}
+	return dep.Leader
+}
@@ -0,0 +1,200 @@
+package toolx
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"aiOffice/internal/svc"
+	"aiOffice/pkg/langchain/outputparserx"
+	"aiOffice/pkg/langchain/slotx"
+	"aiOffice/pkg/mailer"
+	"aiOffice/pkg/requestid"
+	"aiOffice/pkg/token"
+
+	"github.com/tmc/langchaingo/tools"
+)
+
+// 单封邮件最多的收件人数
+const maxEmailRecipients = 20
+
+// EmailSendTool 邮件发送工具：第一次调用在服务端保存待发送的邮件，
+// 用户在之后的一轮对话中确认该草稿后才发送草稿中的内容，模型不能在同一轮中直接确认
+type EmailSendTool struct {
+	svc          *svc.ServiceContext
+	outputparser outputparserx.Structured
+}
+
+func init() {
+	// 不注册到群聊总结：群聊内容不可信，其中的指令可能诱导模型把内容发到外部邮箱
+	Register(GroupEmail, func(svc *svc.ServiceContext) tools.Tool { return NewEmailSendTool(svc) })
+}
+
+// NewEmailSendTool 创建邮件发送工具实例
+func NewEmailSendTool(svc *svc.ServiceContext) *EmailSendTool {
+	return &EmailSendTool{
+		svc: svc,
+		outputparser: outputparserx.NewStructured([]outputparserx.ResponseSchema{
+			{
+				Name:        "to",
+				Description: "recipients, each item is a user name such as \"李四\" or an email address such as \"lisi@example.com\"",
+				Type:        "[]string",
+				Require:     true,
+			},
+			{
+				Name:        "subject",
+				Description: "email subject",
+				Require:     true,
+			},
+			{
+				Name:        "content",
+				Description: "email body in plain text",
+				Require:     true,
+			},
+			{
+				Name:        "confirm",
+				Description: "false for the first call; true only after the user has explicitly confirmed the recipients",
+				Type:        "bool",
+			},
+			{
+				Name:        "draftId",
+				Description: "the draft id returned by the first call, required when confirm=true",
+			},
+		}),
+	}
+}
+
+// Name 返回工具名称
+func (t *EmailSendTool) Name() string {
+	return "email_send"
+}
+
+// Description 返回工具描述
+func (t *EmailSendTool) Description() string {
+	return `an email sending interface.
+use when user wants to send something by email, such as "把总结发邮件给李四", "给王五发封邮件说明天开会".
+always call with confirm=false first, show the returned recipients to the user and ask for confirmation.
+only call again with confirm=true and the returned draftId after the user explicitly confirms in a new message.
+keep Chinese output.
+` + t.outputparser.GetFormatInstructions()
+}
+
+// Call 执行邮件发送
+func (t *EmailSendTool) Call(ctx context.Context, input string) (string, error) {
+	if t.svc.Mailer == nil {
+		return "系统未配置邮件服务，无法发送邮件。", nil
+	}
+
+	data, err := parseInput(ctx, t.svc, t.outputparser, input)
+	if err != nil {
+		return "", err
+	}
+
+	uid := token.GetUid(ctx)
+	if confirm, _ := data["confirm"].(bool); confirm {
+		return t.send(ctx, uid, getString(data, "draftId"))
+	}
+
+	subject, content := getString(data, "subject"), getString(data, "content")
+	if strings.TrimSpace(subject) == "" || strings.TrimSpace(content) == "" {
+		return "请提供邮件主题和内容。", nil
+	}
+
+	to := getStrings(data, "to")
+	if len(to) == 0 {
+		return "请提供收件人。", nil
+	}
+	if len(to) > maxEmailRecipients {
+		return fmt.Sprintf("收件人不能超过%d个。", maxEmailRecipients), nil
+	}
+
+	addrs, display, problems := t.resolve(ctx, to)
+	if len(problems) > 0 {
+		return "以下收件人无法发送邮件:\n" + strings.Join(problems, "\n") + "\n请与用户确认后重试。", nil
+	}
+	if len(addrs) == 0 {
+		return "请提供收件人。", nil
+	}
+
+	// 草稿记录创建时的请求，确认必须来自之后的请求
+	draftId := requestid.New()[:8]
+	saveDraft(ctx, t.svc, uid, &slotx.Draft{Handler: GroupEmail, Tool: t.Name(), Title: "邮件", Data: map[string]any{
+		"draftId":   draftId,
+		"requestId": requestid.FromContext(ctx),
+		"to":        addrs,
+		"display":   display,
+		"subject":   subject,
+		"content":   content,
+	}}, nil)
+
+	return fmt.Sprintf("待确认的邮件（草稿 %s）:\n收件人: %s\n主题: %s\n内容:\n%s\n\n请向用户确认收件人和内容，用户在下一条消息中确认后再以confirm=true、draftId=%s调用发送。",
+		draftId, strings.Join(display, "、"), subject, content, draftId), nil
+}
+
+// send 发送用户已确认的草稿，收件人、主题和内容以草稿为准
+func (t *EmailSendTool) send(ctx context.Context, uid, draftId string) (string, error) {
+	d := loadDraft(ctx, t.svc, uid, t.Name())
+	if d == nil || draftId == "" || getString(d.Data, "draftId") != draftId {
+		return "没有找到待发送的邮件草稿，请先以confirm=false调用生成草稿并请用户确认。", nil
+	}
+	if reqId := requestid.FromContext(ctx); reqId == "" || reqId == getString(d.Data, "requestId") {
+		return "邮件需要用户在新的消息中确认后才能发送，请先向用户展示草稿并等待确认。", nil
+	}
+
+	addrs, display := getStrings(d.Data, "to"), getStrings(d.Data, "display")
+	if err := t.svc.Mailer.Send(ctx, addrs, getString(d.Data, "subject"), getString(d.Data, "content")); err != nil {
+		return "", fmt.Errorf("发送邮件失败: %v", err)
+	}
+	clearDraft(ctx, t.svc, uid)
+	return fmt.Sprintf("邮件已发送给: %s", strings.Join(display, "、")), nil
+}
+
+// resolve 将姓名解析为用户邮箱，邮箱地址原样使用；不属于 Mail.Domains 的地址标记为外部邮箱
+func (t *EmailSendTool) resolve(ctx context.Context, to []string) (addrs, display, problems []string) {
+	seen := make(map[string]bool, len(to))
+	for _, v := range to {
+		if v = strings.TrimSpace(v); v == "" {
+			continue
+		}
+		addr, name := v, v
+		if !strings.Contains(v, "@") {
+			users, err := t.svc.UserModel.FindAllByName(ctx, v)
+			if err != nil || len(users) == 0 {
+				problems = append(problems, fmt.Sprintf("- %s: 未找到该用户", v))
+				continue
+			}
+			if len(users) > 1 {
+				problems = append(problems, fmt.Sprintf("- %s: 有%d位同名用户 %s，请让用户直接提供邮箱地址", v, len(users), userCandidates(users)))
+				continue
+			}
+			user := users[0]
+			if user.Email == "" {
+				problems = append(problems, fmt.Sprintf("- %s: 未设置邮箱", v))
+				continue
+			}
+			addr = user.Email
+			name = fmt.Sprintf("%s<%s>", v, addr)
+		}
+		if !mailer.ValidAddress(addr) {
+			problems = append(problems, fmt.Sprintf("- %s: %v", v, mailer.ErrBadAddress))
+			continue
+		}
+		if name == addr && t.external(addr) {
+			name += "（外部邮箱）"
+		}
+		if seen[addr] {
+			continue
+		}
+		seen[addr] = true
+		addrs = append(addrs, addr)
+		display = append(display, name)
+	}
+	return addrs, display, problems
+}
+
+// external 未配置公司邮箱域名时，直接填写的邮箱地址都视为外部邮箱
+func (t *EmailSendTool) external(addr string) bool {
+	domain := strings.ToLower(addr[strings.LastIndex(addr, "@")+1:])
+	return !slices.Contains(t.svc.Config.Mail.Domains, domain)
+}
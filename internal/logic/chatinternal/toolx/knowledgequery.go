@@ -7,19 +7,22 @@ import (
 	"aiOffice/internal/svc"
 
 	"github.com/tmc/langchaingo/chains"
-	"github.com/tmc/langchaingo/vectorstores"
-	"github.com/tmc/langchaingo/vectorstores/redisvector"
+	"github.com/tmc/langchaingo/tools"
 )
 
-// KnowledgeQuery 知识库查询工具
+// KnowledgeQuery 知识库查询工具，检索公共知识库和用户所在部门的知识库
 type KnowledgeQuery struct {
-	svc   *svc.ServiceContext
-	store *redisvector.Store
-	qa    chains.Chain
+	svc *svc.ServiceContext
+}
+
+func init() {
+	Register(GroupKnowledge, func(svc *svc.ServiceContext) tools.Tool { return NewKnowledgeQuery(svc) })
 }
 
 func NewKnowledgeQuery(svc *svc.ServiceContext) *KnowledgeQuery {
-	return &KnowledgeQuery{svc: svc}
+	return &KnowledgeQuery{
+		svc: svc,
+	}
 }
 
 func (k *KnowledgeQuery) Name() string {
@@ -34,21 +37,21 @@ keep Chinese output.`
 }
 
 func (k *KnowledgeQuery) Call(ctx context.Context, input string) (string, error) {
-	fmt.Printf("[KnowledgeQuery] 被调用，输入: %s\n", input)
-
-	var err error
-	if k.qa == nil {
-		k.store, err = getKnowledgeStore(ctx, k.svc)
-		if err != nil {
-			return "", fmt.Errorf("获取向量存储失败: %v", err)
-		}
-
-		// 创建检索QA链
-		k.qa = chains.NewRetrievalQAFromLLM(k.svc.LLM, vectorstores.ToRetriever(k.store, 3))
+	// 按用户的部门成员关系确定可检索的索引
+	indexes, err := k.svc.KnowledgeLogic.Namespaces(ctx)
+	if err != nil {
+		return "", err
+	}
+	retriever, err := k.svc.Knowledge.Retriever(ctx, indexes, 3)
+	if err != nil {
+		return "", err
 	}
 
+	// 创建检索QA链
+	qa := chains.NewRetrievalQAFromLLM(k.svc.LLM, retriever)
+
 	// 执行查询
-	res, err := chains.Predict(ctx, k.qa, map[string]any{
+	res, err := chains.Predict(ctx, qa, map[string]any{
 		"query": input,
 	})
 	if err != nil {
@@ -0,0 +1,46 @@
+package toolx
+
+import (
+	"sync"
+
+	"aiOffice/internal/svc"
+
+	"github.com/tmc/langchaingo/tools"
+)
+
+// 工具分组，对应使用这组工具的handler
+const (
+	GroupTodo      = "todo"
+	GroupApproval  = "approval"
+	GroupKnowledge = "knowledge"
+	GroupOrg       = "org"
+	GroupSummary   = "summary"
+	GroupEmail     = "email"
+)
+
+// Factory 工具构造函数
+type Factory func(svc *svc.ServiceContext) tools.Tool
+
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[string][]Factory)
+)
+
+// Register 注册工具到分组，在工具文件的init中调用
+func Register(group string, factory Factory) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	registry[group] = append(registry[group], factory)
+}
+
+// Tools 创建分组下的全部工具
+func Tools(svc *svc.ServiceContext, group string) []tools.Tool {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	ts := make([]tools.Tool, 0, len(registry[group]))
+	for _, factory := range registry[group] {
+		ts = append(ts, factory(svc))
+	}
+	return ts
+}
@@ -0,0 +1,60 @@
+package toolx
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"aiOffice/internal/svc"
+	"aiOffice/pkg/i18n"
+	"aiOffice/pkg/langchain/slotx"
+	"aiOffice/pkg/requestid"
+
+	"gitee.com/dn-jinmin/tlog"
+)
+
+// loadDraft 获取用户在该工具上未完成的草稿，读取失败时当作没有草稿
+func loadDraft(ctx context.Context, svc *svc.ServiceContext, uid, tool string) *slotx.Draft {
+	d, err := svc.Slots.Get(ctx, uid)
+	if err != nil {
+		tlog.ErrorCtx(ctx, "slot.load", requestid.Fields(ctx, "uid", uid, "err", err.Error())...)
+		return nil
+	}
+	if d == nil || d.Tool != tool {
+		return nil
+	}
+	return d
+}
+
+// saveDraft 保存草稿，missing为仍需用户补充的信息
+func saveDraft(ctx context.Context, svc *svc.ServiceContext, uid string, d *slotx.Draft, missing []slotx.Slot) {
+	for _, v := range missing {
+		d.Missing = append(d.Missing, v.Question)
+	}
+	if err := svc.Slots.Save(ctx, uid, d); err != nil {
+		tlog.ErrorCtx(ctx, "slot.save", requestid.Fields(ctx, "uid", uid, "err", err.Error())...)
+	}
+}
+
+// clearDraft 创建完成后删除草稿
+func clearDraft(ctx context.Context, svc *svc.ServiceContext, uid string) {
+	if err := svc.Slots.Clear(ctx, uid); err != nil {
+		tlog.ErrorCtx(ctx, "slot.clear", requestid.Fields(ctx, "uid", uid, "err", err.Error())...)
+	}
+}
+
+// askMissing 提示模型向用户追问缺少的信息
+func askMissing(tool string, missing []slotx.Slot) string {
+	questions := make([]string, 0, len(missing))
+	for _, v := range missing {
+		questions = append(questions, v.Question)
+	}
+	return fmt.Sprintf("还缺少以下信息: %s。\n请向用户询问这些信息，不要自行猜测或使用默认值。用户回答后再次调用%s，只需传入新补充的字段，已提供的信息会保留。",
+		strings.Join(questions, "、"), tool)
+}
+
+// askConfirm 提示模型请用户确认
+func askConfirm(ctx context.Context, tool, title, summary string) string {
+	return i18n.T(ctx, "待确认的%s:\n%s\n\n请向用户确认以上信息，用户确认后以confirm=true调用%s；用户要修改时只传入修改的字段。",
+		i18n.T(ctx, title), summary, tool)
+}
@@ -2,16 +2,17 @@ package toolx
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"strings"
-	"time"
 
 	"aiOffice/internal/domain"
 	"aiOffice/internal/svc"
-	"aiOffice/pkg/curl"
+	"aiOffice/pkg/i18n"
 	"aiOffice/pkg/langchain/outputparserx"
+	"aiOffice/pkg/timeutils"
 	"aiOffice/pkg/token"
+
+	"github.com/tmc/langchaingo/tools"
 )
 
 // TodoQueryTool 待办事项查询工具
@@ -20,6 +21,10 @@ type TodoQueryTool struct {
 	outputparser outputparserx.Structured
 }
 
+func init() {
+	Register(GroupTodo, func(svc *svc.ServiceContext) tools.Tool { return NewTodoQueryTool(svc) })
+}
+
 // NewTodoQueryTool 创建待办事项查询工具实例
 func NewTodoQueryTool(svc *svc.ServiceContext) *TodoQueryTool {
 	return &TodoQueryTool{
@@ -60,6 +65,7 @@ func (t *TodoQueryTool) Description() string {
 use when you need to find, query, search or list todos.
 use when user asks: "我的待办", "查询待办", "有哪些待办", "待办事项", etc.
 IMPORTANT: If user specifies a userId or user id (like "用户id是xxx" or "查询xxx的待办"), you MUST extract and use that exact userId value.
+If user refers to someone by name, call user_find first to get the userId.
 If user doesn't provide specific conditions, query all todos by leaving fields empty.
 keep Chinese output.
 ` + t.outputparser.GetFormatInstructions()
@@ -67,123 +73,74 @@ keep Chinese output.
 
 // Call 执行待办事项查询
 func (t *TodoQueryTool) Call(ctx context.Context, input string) (string, error) {
-	fmt.Printf("[TodoQueryTool] 被调用，输入: %s\n", input)
-
 	// 解析输入
-	out, err := t.outputparser.Parse(input)
+	data, err := parseInput(ctx, t.svc, t.outputparser, input)
 	if err != nil {
-		// 如果解析失败，使用空条件查询
-		out = make(map[string]any)
+		return "", err
 	}
 
-	// 构建查询参数
-	data := out.(map[string]any)
-	if data == nil {
-		data = make(map[string]any)
+	req := &domain.TodoListReq{
+		Id:        getString(data, "id"),
+		UserId:    getString(data, "userId"),
+		Count:     10,
+		StartTime: int64(getFloat64(data, "startTime")),
+		EndTime:   int64(getFloat64(data, "endTime")),
 	}
 
-	// 设置当前用户ID
-	uid := token.GetUid(ctx)
-	tokenStr, _ := ctx.Value("Authorization").(string)
-
-	// 打印解析出的userId
-	fmt.Printf("[TodoQueryTool] 解析出的userId: %v (类型: %T)\n", data["userId"], data["userId"])
-
 	// 如果没有指定userId，使用当前用户
-	userIdVal, ok := data["userId"]
-	if !ok || userIdVal == nil || userIdVal == "" {
-		data["userId"] = uid
-		fmt.Printf("[TodoQueryTool] 使用当前用户ID: %s\n", uid)
-	} else {
-		fmt.Printf("[TodoQueryTool] 使用指定的用户ID: %v\n", userIdVal)
+	if req.UserId == "" {
+		req.UserId = token.GetUid(ctx)
 	}
-	data["count"] = 10
-
-	// 转换时间字段
-	conversionTime("startTime", data)
-	conversionTime("endTime", data)
 
-	// 调用API查询待办
-	apiUrl := fmt.Sprintf("http://%s/v1/todo/list", t.svc.Config.Addr)
-	fmt.Printf("[TodoQueryTool] 调用API: %s, params: %+v\n", apiUrl, data)
-
-	res, err := curl.GetRequest(tokenStr, apiUrl, data)
+	res, err := t.svc.TodoLogic.List(ctx, req)
 	if err != nil {
 		return "", fmt.Errorf("查询失败: %v", err)
 	}
-	fmt.Printf("[TodoQueryTool] API响应: %s\n", string(res))
 
 	// 格式化输出
-	return t.formatTodoList(res)
-}
-
-// conversionTime 转换时间字段格式
-func conversionTime(field string, data map[string]any) {
-	if v, ok := data[field]; ok {
-		if tmp, ok := v.(float64); ok {
-			data[field] = int64(tmp)
-		}
-	}
+	return t.formatTodoList(ctx, res), nil
 }
 
 // formatTodoList 格式化待办列表输出
-func (t *TodoQueryTool) formatTodoList(res []byte) (string, error) {
-	// 解析响应
-	var apiResponse struct {
-		Code int    `json:"code"`
-		Msg  string `json:"msg"`
-		Data struct {
-			Count int64          `json:"count"`
-			List  []*domain.Todo `json:"data"`
-		} `json:"data"`
-	}
-
-	if err := json.Unmarshal(res, &apiResponse); err != nil {
-		return "", err
-	}
-
-	if apiResponse.Code != 200 {
-		return "", fmt.Errorf(apiResponse.Msg)
-	}
-
+func (t *TodoQueryTool) formatTodoList(ctx context.Context, res *domain.TodoListResp) string {
 	// 如果没有待办
-	if apiResponse.Data.List == nil || len(apiResponse.Data.List) == 0 {
-		return "您当前没有待办事项。", nil
+	if len(res.List) == 0 {
+		return i18n.T(ctx, "您当前没有待办事项。")
 	}
 
 	// 格式化输出
 	var result strings.Builder
-	result.WriteString("您的待办事项:\n\n")
+	result.WriteString(i18n.T(ctx, "您的待办事项:") + "\n\n")
 
-	for i, todo := range apiResponse.Data.List {
+	for i, todo := range res.List {
 		result.WriteString(fmt.Sprintf("%d. %s\n", i+1, todo.Title))
-		result.WriteString(fmt.Sprintf("   状态: %s\n", getTodoStatusName(todo.TodoStatus)))
-		result.WriteString(fmt.Sprintf("   截止时间: %s\n", formatTimestamp(todo.DeadlineAt)))
+		result.WriteString("   " + i18n.T(ctx, "状态: ") + getTodoStatusName(ctx, todo.TodoStatus) + "\n")
+		result.WriteString("   " + i18n.T(ctx, "截止时间: ") + formatTimestamp(ctx, todo.DeadlineAt) + "\n")
 		if todo.Desc != "" {
-			result.WriteString(fmt.Sprintf("   描述: %s\n", todo.Desc))
+			result.WriteString("   " + i18n.T(ctx, "描述: ") + todo.Desc + "\n")
 		}
 		result.WriteString("\n")
 	}
 
-	return result.String(), nil
+	return result.String()
 }
 
 // getTodoStatusName 获取待办状态名称
-func getTodoStatusName(status int) string {
+func getTodoStatusName(ctx context.Context, status int) string {
 	switch status {
 	case 0:
-		return "未完成"
+		return i18n.T(ctx, "未完成")
 	case 1:
-		return "已完成"
+		return i18n.T(ctx, "已完成")
 	default:
-		return "未知状态"
+		return i18n.T(ctx, "未知状态")
 	}
 }
 
 // formatTimestamp 格式化时间戳
-func formatTimestamp(timestamp int64) string {
+func formatTimestamp(ctx context.Context, timestamp int64) string {
 	if timestamp == 0 {
-		return "未设置"
+		return i18n.T(ctx, "未设置")
 	}
-	return time.Unix(timestamp, 0).Format("2006-01-02 15:04")
+	return timeutils.Unix(timestamp).Format("2006-01-02 15:04")
 }
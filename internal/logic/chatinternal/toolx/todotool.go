@@ -2,14 +2,17 @@ package toolx
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
+	"strings"
 
 	"aiOffice/internal/domain"
 	"aiOffice/internal/svc"
-	"aiOffice/pkg/curl"
+	"aiOffice/pkg/i18n"
 	"aiOffice/pkg/langchain/outputparserx"
+	"aiOffice/pkg/langchain/slotx"
 	"aiOffice/pkg/token"
+
+	"github.com/tmc/langchaingo/tools"
 )
 
 const Success = "success"
@@ -20,6 +23,10 @@ type TodoTool struct {
 	outputparser outputparserx.Structured
 }
 
+func init() {
+	Register(GroupTodo, func(svc *svc.ServiceContext) tools.Tool { return NewTodoTool(svc) })
+}
+
 // NewTodoTool 创建待办事项添加工具实例
 func NewTodoTool(svc *svc.ServiceContext) *TodoTool {
 	return &TodoTool{
@@ -28,7 +35,6 @@ func NewTodoTool(svc *svc.ServiceContext) *TodoTool {
 			{
 				Name:        "title",
 				Description: "todo title",
-				Require:     true,
 			},
 			{
 				Name:        "deadlineAt",
@@ -43,7 +49,11 @@ func NewTodoTool(svc *svc.ServiceContext) *TodoTool {
 				Name:        "executeIds",
 				Description: "list of user IDs for the todo executors, data type is []string. If user does not specify executors, use current user's ID as the default executor.",
 				Type:        "[]string",
-				Require:     true,
+			},
+			{
+				Name:        "confirm",
+				Description: "false until the user has confirmed the summary returned by this tool",
+				Type:        "bool",
 			},
 		}),
 	}
@@ -59,53 +69,93 @@ func (t *TodoTool) Description() string {
 	return `a todo add interface.
 use when you need to create a todo.
 keep Chinese output.
-IMPORTANT: executeIds is required. If user does not specify who should execute the todo, use the current user's ID (provided in context) as the default executor.
+If user does not specify who should execute the todo, the current user is the executor.
+If user names the executors (such as "分给李四"), call user_find first and use the returned userIds.
+only fill in fields the user has actually provided, never guess the deadline.
+the tool keeps what has been provided; if it returns questions, ask the user, then call again with only the newly provided fields.
+call with confirm=true only after the user has confirmed the returned summary.
 ` + t.outputparser.GetFormatInstructions()
 }
 
-// Call 执行待办事项创建
-func (t *TodoTool) Call(ctx context.Context, input string) (string, error) {
-	fmt.Printf("[TodoTool] 被调用，输入: %s\n", input)
+// todoSlots 创建待办需要用户提供的信息，执行人未指定时为自己
+var todoSlots = []slotx.Slot{
+	{Name: "title", Question: "待办内容"},
+	{Name: "deadlineAt", Question: "截止时间"},
+}
 
+// Call 执行待办事项创建，信息不全时追问，信息齐全后需用户确认才创建
+func (t *TodoTool) Call(ctx context.Context, input string) (string, error) {
 	// 解析输入
-	data, err := t.outputparser.Parse(input)
+	dataMap, err := parseInput(ctx, t.svc, t.outputparser, input)
 	if err != nil {
 		return "", err
 	}
+	confirm, _ := dataMap["confirm"].(bool)
+	delete(dataMap, "confirm")
 
-	// 获取当前用户信息
+	// 合并之前轮次已提供的信息
 	uid := token.GetUid(ctx)
-	tokenStr, _ := ctx.Value("Authorization").(string)
+	if draft := loadDraft(ctx, t.svc, uid, t.Name()); draft != nil {
+		dataMap = slotx.Merge(draft.Data, dataMap)
+	}
+
+	draft := &slotx.Draft{Handler: GroupTodo, Tool: t.Name(), Title: "待办", Data: dataMap}
+	if missing := slotx.Missing(dataMap, todoSlots); len(missing) > 0 {
+		saveDraft(ctx, t.svc, uid, draft, missing)
+		return askMissing(t.Name(), missing), nil
+	}
+
+	req := &domain.Todo{
+		Title:      getString(dataMap, "title"),
+		DeadlineAt: int64(getFloat64(dataMap, "deadlineAt")),
+		Desc:       getString(dataMap, "desc"),
+		ExecuteIds: getStrings(dataMap, "executeIds"),
+	}
 
 	// 设置创建者信息
-	dataMap := data.(map[string]any)
 	if uid != "" {
-		dataMap["creatorId"] = uid
-		user, err := t.svc.UserModel.FindOne(ctx, uid)
-		if err == nil {
-			dataMap["creatorName"] = user.Name
+		req.CreatorId = uid
+		if user, err := t.svc.UserModel.FindOne(ctx, uid); err == nil {
+			req.CreatorName = user.Name
 		}
 		// 如果AI没有传executeIds，默认使用当前用户
-		if dataMap["executeIds"] == nil {
-			dataMap["executeIds"] = []string{uid}
+		if len(req.ExecuteIds) == 0 {
+			req.ExecuteIds = []string{uid}
 		}
 	}
 
-	// 调用API创建待办
-	apiUrl := fmt.Sprintf("http://%s/v1/todo", t.svc.Config.Addr)
-	fmt.Printf("[TodoTool] 调用API: %s\n", apiUrl)
+	if !confirm {
+		saveDraft(ctx, t.svc, uid, draft, nil)
+		return askConfirm(ctx, t.Name(), "待办", t.describe(ctx, req)), nil
+	}
 
-	res, err := curl.PostRequest(tokenStr, apiUrl, dataMap)
+	res, err := t.svc.TodoLogic.Create(ctx, req)
 	if err != nil {
-		return "", fmt.Errorf("调用API失败: %v", err)
+		return "", fmt.Errorf("创建待办失败: %v", err)
 	}
-	fmt.Printf("[TodoTool] API响应: %s\n", string(res))
+	clearDraft(ctx, t.svc, uid)
 
-	// 解析响应
-	var idResp domain.IdRespInfo
-	if err := json.Unmarshal(res, &idResp); err != nil {
-		return "", err
+	return Success + "\n创建的待办ID: " + res.Id, nil
+}
+
+// describe 生成供用户确认的待办内容，执行人显示为姓名
+func (t *TodoTool) describe(ctx context.Context, req *domain.Todo) string {
+	names := make([]string, 0, len(req.ExecuteIds))
+	for _, id := range req.ExecuteIds {
+		if user, err := t.svc.UserModel.FindOne(ctx, id); err == nil {
+			names = append(names, user.Name)
+		} else {
+			names = append(names, id)
+		}
 	}
 
-	return Success + "\n创建的待办ID: " + idResp.Data.Id, nil
+	lines := []string{
+		i18n.T(ctx, "内容: ") + req.Title,
+		i18n.T(ctx, "截止时间: ") + formatTimestamp(ctx, req.DeadlineAt),
+		i18n.T(ctx, "执行人: ") + strings.Join(names, i18n.T(ctx, "、")),
+	}
+	if req.Desc != "" {
+		lines = append(lines, i18n.T(ctx, "描述: ")+req.Desc)
+	}
+	return strings.Join(lines, "\n")
 }
@@ -0,0 +1,135 @@
+package toolx
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"aiOffice/internal/model"
+	"aiOffice/internal/svc"
+	"aiOffice/pkg/langchain/outputparserx"
+	"aiOffice/pkg/pagex"
+
+	"github.com/tmc/langchaingo/tools"
+)
+
+// 姓名没有精确匹配时最多给出的候选人数
+const maxUserCandidates = 5
+
+// UserQueryTool 用户查询工具，将姓名解析为用户ID供其他工具使用
+type UserQueryTool struct {
+	svc          *svc.ServiceContext
+	outputparser outputparserx.Structured
+}
+
+func init() {
+	factory := func(svc *svc.ServiceContext) tools.Tool { return NewUserQueryTool(svc) }
+	Register(GroupTodo, factory)
+	Register(GroupApproval, factory)
+}
+
+// NewUserQueryTool 创建用户查询工具实例
+func NewUserQueryTool(svc *svc.ServiceContext) *UserQueryTool {
+	return &UserQueryTool{
+		svc: svc,
+		outputparser: outputparserx.NewStructured([]outputparserx.ResponseSchema{
+			{
+				Name:        "names",
+				Description: "list of user names to look up, such as [\"李四\", \"王五\"]",
+				Type:        "[]string",
+				Require:     true,
+			},
+		}),
+	}
+}
+
+// Name 返回工具名称
+func (t *UserQueryTool) Name() string {
+	return "user_find"
+}
+
+// Description 返回工具描述
+func (t *UserQueryTool) Description() string {
+	return `a user lookup interface that resolves user names to user IDs.
+use BEFORE calling other tools whenever the user refers to people by name, such as "把待办分给李四", "查询王五的待办", "张三的审批记录".
+other tools only accept user IDs, never pass a name as an ID.
+if a name has several candidates, ask the user which one is meant.
+keep Chinese output.
+` + t.outputparser.GetFormatInstructions()
+}
+
+// Call 执行用户查询
+func (t *UserQueryTool) Call(ctx context.Context, input string) (string, error) {
+	data, err := parseInput(ctx, t.svc, t.outputparser, input)
+	if err != nil {
+		return "", err
+	}
+
+	var names []string
+	switch v := data["names"].(type) {
+	case []any:
+		for _, n := range v {
+			if s, ok := n.(string); ok && strings.TrimSpace(s) != "" {
+				names = append(names, strings.TrimSpace(s))
+			}
+		}
+	case string:
+		if strings.TrimSpace(v) != "" {
+			names = append(names, strings.TrimSpace(v))
+		}
+	}
+	if len(names) == 0 {
+		return "", fmt.Errorf("请提供要查询的用户姓名")
+	}
+
+	var result strings.Builder
+	for _, name := range names {
+		line, err := t.lookup(ctx, name)
+		if err != nil {
+			return "", err
+		}
+		result.WriteString(line)
+	}
+	return result.String(), nil
+}
+
+// lookup 优先按姓名精确匹配，有同名用户时全部作为候选，找不到时模糊匹配给出候选
+func (t *UserQueryTool) lookup(ctx context.Context, name string) (string, error) {
+	users, err := t.svc.UserModel.FindAllByName(ctx, name)
+	if err != nil {
+		return "", fmt.Errorf("查询用户失败: %v", err)
+	}
+	switch len(users) {
+	case 0:
+	case 1:
+		return fmt.Sprintf("%s: userId=%s\n", users[0].Name, users[0].ID.Hex()), nil
+	default:
+		return fmt.Sprintf("%s: 有%d位同名用户 %s，请向用户确认是哪一位\n", name, len(users), userCandidates(users)), nil
+	}
+
+	users, _, err = t.svc.UserModel.List(ctx, nil, name, pagex.Page{Count: maxUserCandidates})
+	if err != nil {
+		return "", fmt.Errorf("查询用户失败: %v", err)
+	}
+	switch len(users) {
+	case 0:
+		return fmt.Sprintf("%s: 未找到该用户\n", name), nil
+	case 1:
+		return fmt.Sprintf("%s: userId=%s（匹配到 %s）\n", name, users[0].ID.Hex(), users[0].Name), nil
+	}
+
+	return fmt.Sprintf("%s: 找到多个候选 %s\n", name, userCandidates(users)), nil
+}
+
+// userCandidates 候选用户列表，附带邮箱便于用户区分同名的人
+func userCandidates(users []*model.User) string {
+	candidates := make([]string, 0, len(users))
+	for _, u := range users {
+		if u.Email != "" {
+			candidates = append(candidates, fmt.Sprintf("%s(userId=%s, 邮箱=%s)", u.Name, u.ID.Hex(), u.Email))
+			continue
+		}
+		candidates = append(candidates, fmt.Sprintf("%s(userId=%s)", u.Name, u.ID.Hex()))
+	}
+	return strings.Join(candidates, "、")
+}
@@ -0,0 +1,211 @@
+package logic
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"aiOffice/internal/model"
+	"aiOffice/internal/svc"
+	"aiOffice/pkg/langchain/promptx"
+	"aiOffice/pkg/timeutils"
+
+	"github.com/tmc/langchaingo/llms"
+)
+
+// dailyMessageLimit 每个用户参与总结的最近聊天消息数
+const dailyMessageLimit = 50
+
+const dailySummaryPrompt = `你是办公助手，请根据用户今天的工作记录，用中文写一段简短的工作总结（200字以内），
+概括完成的工作、处理的审批和沟通中的重点事项，最后给出一条明天的工作建议。记录中没有的内容不要编造。
+
+完成的待办:
+%s
+审批:
+%s
+发送的聊天消息:
+%s`
+
+// DailySummary 每日工作总结，由定时任务调用
+type DailySummary interface {
+	// 为day当天有工作记录的用户生成并保存总结，uid不为空时只处理该用户；
+	// 当天已生成过的用户跳过，只返回本次新生成的总结
+	Generate(ctx context.Context, uid string, day time.Time) ([]*model.DailySummary, error)
+}
+
+type dailySummary struct {
+	svcCtx *svc.ServiceContext
+}
+
+func NewDailySummary(svcCtx *svc.ServiceContext) DailySummary {
+	return &dailySummary{
+		svcCtx: svcCtx,
+	}
+}
+
+// dailyActivity 用户当天完成的待办和处理的审批
+type dailyActivity struct {
+	todos     []string
+	approvals []string
+}
+
+func (l *dailySummary) Generate(ctx context.Context, uid string, day time.Time) ([]*model.DailySummary, error) {
+	start := timeutils.StartOfDay(day)
+	startTime, endTime := timeutils.DayRange(day)
+
+	activities, err := l.activities(ctx, startTime, endTime)
+	if err != nil {
+		return nil, err
+	}
+	if uid != "" {
+		activities = map[string]*dailyActivity{uid: activities[uid]}
+	}
+
+	var list []*model.DailySummary
+	for id, act := range activities {
+		if err := ctx.Err(); err != nil {
+			return list, err
+		}
+
+		summary, err := l.generate(ctx, id, start, act)
+		if err != nil {
+			fmt.Printf("[DailySummary] 生成用户 %s 的总结失败: %v\n", id, err)
+			continue
+		}
+		if summary != nil {
+			list = append(list, summary)
+		}
+	}
+	return list, nil
+}
+
+// activities 当天有工作记录的用户，只发送过聊天消息的用户活动为空
+func (l *dailySummary) activities(ctx context.Context, startTime, endTime int64) (map[string]*dailyActivity, error) {
+	res := make(map[string]*dailyActivity)
+	get := func(uid string) *dailyActivity {
+		if res[uid] == nil {
+			res[uid] = &dailyActivity{}
+		}
+		return res[uid]
+	}
+
+	todos, err := l.svcCtx.TodoModel.FindFinishedBetween(ctx, startTime, endTime)
+	if err != nil {
+		return nil, fmt.Errorf("查询完成的待办失败: %v", err)
+	}
+	for _, todo := range todos {
+		// 创建人和执行人都计入
+		uids := append([]string{todo.CreatorId}, todo.ExecuteIds...)
+		slices.Sort(uids)
+		for _, id := range slices.Compact(uids) {
+			if id != "" {
+				get(id).todos = append(get(id).todos, todo.Title)
+			}
+		}
+	}
+
+	approvals, err := l.svcCtx.ApprovalModel.FindUpdatedBetween(ctx, startTime, endTime)
+	if err != nil {
+		return nil, fmt.Errorf("查询审批失败: %v", err)
+	}
+	for _, a := range approvals {
+		for _, v := range a.Approvers {
+			if v.UserId != "" && (v.Status == model.Pass || v.Status == model.Refuse) {
+				get(v.UserId).approvals = append(get(v.UserId).approvals,
+					fmt.Sprintf("审批了[%s] %s：%s", a.Type.ToString(), a.Title, approvalResult(v.Status)))
+			}
+		}
+		if a.UserId != "" && a.FinishAt >= startTime && a.FinishAt <= endTime {
+			get(a.UserId).approvals = append(get(a.UserId).approvals,
+				fmt.Sprintf("提交的[%s] %s：%s", a.Type.ToString(), a.Title, approvalResult(a.Status)))
+		}
+	}
+
+	senders, err := l.svcCtx.ChatLogModel.FindSenderIds(ctx, startTime, endTime)
+	if err != nil {
+		return nil, fmt.Errorf("查询聊天记录失败: %v", err)
+	}
+	for _, id := range senders {
+		get(id)
+	}
+	return res, nil
+}
+
+// generate 生成单个用户的总结，模型调用失败时使用统计数据
+func (l *dailySummary) generate(ctx context.Context, uid string, start time.Time, act *dailyActivity) (*model.DailySummary, error) {
+	date := start.Format("20060102")
+	if _, err := l.svcCtx.DailySummaryModel.FindByUserIdAndDate(ctx, uid, date); err == nil {
+		return nil, nil
+	} else if !errors.Is(err, model.ErrNotFound) {
+		return nil, err
+	}
+
+	if act == nil {
+		act = &dailyActivity{}
+	}
+	logs, err := l.svcCtx.ChatLogModel.ListBySendId(ctx, uid, start.Unix(), start.AddDate(0, 0, 1).Unix()-1, dailyMessageLimit)
+	if err != nil {
+		return nil, err
+	}
+	if len(act.todos) == 0 && len(act.approvals) == 0 && len(logs) == 0 {
+		return nil, nil
+	}
+
+	summary := &model.DailySummary{
+		UserId:    uid,
+		Date:      date,
+		Todos:     len(act.todos),
+		Approvals: len(act.approvals),
+		Messages:  len(logs),
+		Content: fmt.Sprintf("📊 今日工作总结\n- 完成待办: %d 项\n- 处理审批: %d 项",
+			len(act.todos), len(act.approvals)),
+	}
+
+	chat := make([]string, 0, len(logs))
+	for _, v := range logs {
+		chat = append(chat, fmt.Sprintf("[%s] %s", time.Unix(v.SendTime, 0).In(start.Location()).Format("15:04"), v.MsgContent))
+	}
+	prompt := fmt.Sprintf(l.svcCtx.Prompts.Get(ctx, promptx.KeyDaily, dailySummaryPrompt),
+		listLines(act.todos), listLines(act.approvals), listLines(chat))
+	content, err := llms.GenerateFromSinglePrompt(ctx, l.svcCtx.LLM, prompt)
+	if err != nil {
+		fmt.Printf("[DailySummary] 模型生成用户 %s 的总结失败，使用统计数据: %v\n", uid, err)
+	} else if content = strings.TrimSpace(content); content != "" {
+		summary.Content = content
+	}
+
+	if err := l.svcCtx.DailySummaryModel.Upsert(ctx, summary); err != nil {
+		return nil, err
+	}
+	return summary, nil
+}
+
+// listLines 拼接为每行一项，为空时返回"无"
+func listLines(items []string) string {
+	if len(items) == 0 {
+		return "无\n"
+	}
+	var sb strings.Builder
+	for _, v := range items {
+		sb.WriteString("- " + v + "\n")
+	}
+	return sb.String()
+}
+
+func approvalResult(status model.ApprovalStatus) string {
+	switch status {
+	case model.Pass:
+		return "通过"
+	case model.Refuse:
+		return "拒绝"
+	case model.AutoPass:
+		return "自动通过"
+	case model.Cancel:
+		return "撤销"
+	default:
+		return "处理中"
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"aiOffice/internal/model"
 	"aiOffice/internal/svc"
 	"aiOffice/pkg/xerr"
+	"errors"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
@@ -113,7 +114,7 @@ func (l *department) Soa(ctx context.Context) (resp *domain.DepartmentSoaResp, e
 func (l *department) Info(ctx context.Context, req *domain.IdPathReq) (resp *domain.Department, err error) {
 	dep, err := l.svcCtx.DepartmentModel.FindOne(ctx, req.Id)
 	if err != nil {
-		if err == model.ErrNotFound {
+		if errors.Is(err, model.ErrNotFound) {
 			return nil, model.ErrNotFindDepartment
 		}
 		return nil, xerr.WithMessage(err, "查询部门失败")
@@ -150,7 +151,7 @@ func (l *department) Create(ctx context.Context, req *domain.Department) (err er
 	if req.ParentId != "" && req.ParentId != "0" {
 		_, err := l.svcCtx.DepartmentModel.FindOne(ctx, req.ParentId)
 		if err != nil {
-			if err == model.ErrNotFound {
+			if errors.Is(err, model.ErrNotFound) {
 				return model.ErrNotFindDepartment
 			}
 			return xerr.WithMessage(err, "查询父部门失败")
@@ -170,7 +171,7 @@ func (l *department) Edit(ctx context.Context, req *domain.Department) (err erro
 	// 查询部门是否存在
 	dep, err := l.svcCtx.DepartmentModel.FindOne(ctx, req.Id)
 	if err != nil {
-		if err == model.ErrNotFound {
+		if errors.Is(err, model.ErrNotFound) {
 			return model.ErrNotFindDepartment
 		}
 		return xerr.WithMessage(err, "查询部门失败")
@@ -212,7 +213,7 @@ func (l *department) Delete(ctx context.Context, req *domain.IdPathReq) (err err
 		return xerr.WithMessage(err, "查询子部门失败")
 	}
 	if len(children) > 0 {
-		return xerr.New(model.ErrNotFindDepartment)
+		return xerr.New(model.ErrDepartmentHasChild)
 	}
 
 	// 删除部门用户关联
@@ -235,7 +236,7 @@ func (l *department) SetDepartmentUsers(ctx context.Context, req *domain.SetDepa
 	// 验证部门是否存在
 	dep, err := l.svcCtx.DepartmentModel.FindOne(ctx, req.DepId)
 	if err != nil {
-		if err == model.ErrNotFound {
+		if errors.Is(err, model.ErrNotFound) {
 			return model.ErrNotFindDepartment
 		}
 		return xerr.WithMessage(err, "查询部门失败")
@@ -280,7 +281,7 @@ func (l *department) AddDepartmentUser(ctx context.Context, req *domain.AddDepar
 	// 验证部门是否存在
 	dep, err := l.svcCtx.DepartmentModel.FindOne(ctx, req.DepId)
 	if err != nil {
-		if err == model.ErrNotFound {
+		if errors.Is(err, model.ErrNotFound) {
 			return model.ErrNotFindDepartment
 		}
 		return xerr.WithMessage(err, "查询部门失败")
@@ -294,7 +295,7 @@ func (l *department) AddDepartmentUser(ctx context.Context, req *domain.AddDepar
 	// 验证用户是否存在
 	_, err = l.svcCtx.UserModel.FindOne(ctx, req.UserId)
 	if err != nil {
-		if err == model.ErrNotFound {
+		if errors.Is(err, model.ErrNotFound) {
 			return model.ErrNotFindUser
 		}
 		return xerr.WithMessage(err, "查询用户失败")
@@ -337,7 +338,7 @@ func (l *department) RemoveDepartmentUser(ctx context.Context, req *domain.Remov
 	// 验证部门是否存在
 	dep, err := l.svcCtx.DepartmentModel.FindOne(ctx, req.DepId)
 	if err != nil {
-		if err == model.ErrNotFound {
+		if errors.Is(err, model.ErrNotFound) {
 			return model.ErrNotFindDepartment
 		}
 		return xerr.WithMessage(err, "查询部门失败")
@@ -381,7 +382,7 @@ func (l *department) DepartmentUserInfo(ctx context.Context, req *domain.IdPathR
 	// 获取第一个部门信息
 	dep, err := l.svcCtx.DepartmentModel.FindOne(ctx, depUsers[0].DepId)
 	if err != nil {
-		if err == model.ErrNotFound {
+		if errors.Is(err, model.ErrNotFound) {
 			return nil, model.ErrNotFindDepartment
 		}
 		return nil, xerr.WithMessage(err, "查询部门失败")
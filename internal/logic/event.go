@@ -0,0 +1,362 @@
+package logic
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"time"
+
+	"aiOffice/internal/domain"
+	"aiOffice/internal/model"
+	"aiOffice/internal/svc"
+	"aiOffice/pkg/notify"
+	"aiOffice/pkg/timeutils"
+	"aiOffice/pkg/token"
+	"aiOffice/pkg/xerr"
+)
+
+var (
+	ErrEventNotFound   = xerr.NewCode(xerr.NotFound, "日程不存在")
+	ErrEventForbidden  = xerr.NewCode(xerr.Forbidden, "只有创建人可以修改日程")
+	ErrEventDepartment = xerr.NewCode(xerr.Forbidden, "只能共享到自己所在的部门")
+	ErrEventRecurrence = xerr.NewCode(xerr.Invalid, "重复规则不正确")
+)
+
+const (
+	// 日程相关的消息类型
+	notifyTypeEventRemind = "event:remind"
+	notifyTypeEventInvite = "event:invite"
+
+	maxCalendarViewDays = 92               // 日历视图一次最多查询的天数
+	eventRemindBatch    = 100              // 每批处理的到期提醒数
+	eventRemindExpire   = 30 * time.Minute // 超过该时间未发出的提醒（如任务停止期间）不再补发
+)
+
+type Event interface {
+	Create(ctx context.Context, req *domain.Event) (resp *domain.IdResp, err error)
+	Info(ctx context.Context, req *domain.IdPathReq) (resp *domain.Event, err error)
+	Edit(ctx context.Context, req *domain.Event) (err error)
+	Delete(ctx context.Context, req *domain.IdPathReq) (err error)
+	// 日历视图：日程（重复日程按次展开）、待办截止时间和已通过的请假
+	View(ctx context.Context, req *domain.CalendarViewReq) (resp *domain.CalendarViewResp, err error)
+	// 发送到期的日程提醒，由定时任务每分钟调用
+	RemindDue(ctx context.Context) (err error)
+}
+
+type event struct {
+	svcCtx *svc.ServiceContext
+}
+
+func NewEvent(svcCtx *svc.ServiceContext) Event {
+	return &event{
+		svcCtx: svcCtx,
+	}
+}
+
+func (l *event) Create(ctx context.Context, req *domain.Event) (resp *domain.IdResp, err error) {
+	uid := token.GetUid(ctx)
+	data, err := l.toModel(ctx, uid, req)
+	if err != nil {
+		return nil, err
+	}
+	data.UserId = uid
+
+	if err := l.svcCtx.EventModel.Insert(ctx, data); err != nil {
+		return nil, xerr.WithMessage(err, "创建日程失败")
+	}
+
+	go l.notifyInvite(data, data.Participants)
+	return &domain.IdResp{Id: data.ID.Hex()}, nil
+}
+
+// Info 创建人、参与人和共享部门的成员可以查看
+func (l *event) Info(ctx context.Context, req *domain.IdPathReq) (resp *domain.Event, err error) {
+	uid := token.GetUid(ctx)
+	data, err := l.find(ctx, req.Id)
+	if err != nil {
+		return nil, err
+	}
+	depIds, err := userDepIds(ctx, l.svcCtx, uid)
+	if err != nil {
+		return nil, err
+	}
+	if !data.Visible(uid, depIds) {
+		return nil, ErrEventNotFound
+	}
+	return toDomainEvent(data), nil
+}
+
+func (l *event) Edit(ctx context.Context, req *domain.Event) (err error) {
+	uid := token.GetUid(ctx)
+	old, err := l.find(ctx, req.Id)
+	if err != nil {
+		return err
+	}
+	if old.UserId != uid {
+		return ErrEventForbidden
+	}
+
+	data, err := l.toModel(ctx, uid, req)
+	if err != nil {
+		return err
+	}
+	data.ID, data.UserId, data.CreateAt = old.ID, old.UserId, old.CreateAt
+	if err := l.svcCtx.EventModel.Update(ctx, data); err != nil {
+		return xerr.WithMessage(err, "更新日程失败")
+	}
+
+	// 只通知新加入的参与人
+	var added []string
+	for _, v := range data.Participants {
+		if !slices.Contains(old.Participants, v) {
+			added = append(added, v)
+		}
+	}
+	go l.notifyInvite(data, added)
+	return nil
+}
+
+func (l *event) Delete(ctx context.Context, req *domain.IdPathReq) (err error) {
+	data, err := l.find(ctx, req.Id)
+	if err != nil {
+		return err
+	}
+	if data.UserId != token.GetUid(ctx) {
+		return ErrEventForbidden
+	}
+	if err := l.svcCtx.EventModel.Delete(ctx, req.Id); err != nil {
+		return xerr.WithMessage(err, "删除日程失败")
+	}
+	return nil
+}
+
+func (l *event) View(ctx context.Context, req *domain.CalendarViewReq) (resp *domain.CalendarViewResp, err error) {
+	uid := token.GetUid(ctx)
+	start, end := timeutils.Unix(req.StartTime), timeutils.Unix(req.EndTime)
+	if req.StartTime <= 0 {
+		start = timeutils.StartOfDay(timeutils.NowTime())
+	}
+	if req.EndTime <= 0 || !end.After(start) {
+		end = start.AddDate(0, 0, 7)
+	}
+	if limit := start.AddDate(0, 0, maxCalendarViewDays); end.After(limit) {
+		end = limit
+	}
+	from, to := start.Unix(), end.Unix()
+
+	depIds, err := userDepIds(ctx, l.svcCtx, uid)
+	if err != nil {
+		return nil, err
+	}
+	events, err := l.svcCtx.EventModel.FindVisible(ctx, uid, depIds, from, to)
+	if err != nil {
+		return nil, xerr.WithMessage(err, "查询日程失败")
+	}
+
+	resp = &domain.CalendarViewResp{List: make([]*domain.CalendarItem, 0, len(events))}
+	for _, ev := range events {
+		for _, at := range ev.Occurrences(from, to) {
+			resp.List = append(resp.List, &domain.CalendarItem{
+				Kind:      domain.CalendarItemEvent,
+				Id:        ev.ID.Hex(),
+				Title:     ev.Title,
+				Location:  ev.Location,
+				StartTime: at,
+				EndTime:   at + ev.EndTime - ev.StartTime,
+				AllDay:    ev.AllDay,
+				Owner:     ev.UserId == uid,
+			})
+		}
+	}
+
+	todos, err := userTodos(ctx, l.svcCtx, uid)
+	if err != nil {
+		return nil, xerr.WithMessage(err, "查询待办失败")
+	}
+	for id, todo := range todos {
+		if todo.DeadlineAt < from || todo.DeadlineAt >= to {
+			continue
+		}
+		resp.List = append(resp.List, &domain.CalendarItem{
+			Kind:      domain.CalendarItemTodo,
+			Id:        id,
+			Title:     todo.Title,
+			StartTime: todo.DeadlineAt,
+			EndTime:   todo.DeadlineAt,
+		})
+	}
+
+	leaves, err := l.svcCtx.ApprovalModel.FindPassedLeaves(ctx, uid, from, to)
+	if err != nil {
+		return nil, xerr.WithMessage(err, "查询请假失败")
+	}
+	for _, v := range leaves {
+		resp.List = append(resp.List, &domain.CalendarItem{
+			Kind:      domain.CalendarItemLeave,
+			Id:        v.ID.Hex(),
+			Title:     v.Title,
+			StartTime: v.Leave.StartTime,
+			EndTime:   v.Leave.EndTime,
+			AllDay:    v.Leave.TimeType == model.DayTimeFormatType,
+		})
+	}
+
+	sort.SliceStable(resp.List, func(i, j int) bool {
+		return resp.List[i].StartTime < resp.List[j].StartTime
+	})
+	return resp, nil
+}
+
+// RemindDue 先把提醒时间推进到下一次再发送，多个worker同时处理时每次提醒只发送一次
+func (l *event) RemindDue(ctx context.Context) (err error) {
+	now, expire := timeutils.Now(), int64(eventRemindExpire/time.Second)
+	for {
+		list, err := l.svcCtx.EventModel.FindRemindDue(ctx, now, eventRemindBatch)
+		if err != nil {
+			return xerr.WithMessage(err, "查询到期日程提醒失败")
+		}
+
+		for _, ev := range list {
+			// 长时间未处理的重复日程直接跳到最近的一次，过期的提醒不再补发
+			next := ev.NextRemindAt(max(ev.RemindAt, now-expire))
+			ok, err := l.svcCtx.EventModel.SetRemindAt(ctx, ev.ID, ev.RemindAt, next)
+			if err != nil {
+				return xerr.WithMessage(err, "更新日程提醒失败")
+			}
+			if !ok || now-ev.RemindAt > expire {
+				continue
+			}
+			l.remind(ctx, ev, ev.RemindAt+int64(ev.Remind)*60)
+		}
+
+		if len(list) < eventRemindBatch {
+			return nil
+		}
+	}
+}
+
+// remind 提醒创建人和参与人，at为这一次的开始时间
+func (l *event) remind(ctx context.Context, ev *model.Event, at int64) {
+	content := fmt.Sprintf("「%s」将于%s开始", ev.Title, timeutils.Unix(at).Format("01-02 15:04"))
+	if ev.Location != "" {
+		content += "，地点：" + ev.Location
+	}
+	for _, uid := range append([]string{ev.UserId}, ev.Participants...) {
+		pushNotify(ctx, l.svcCtx, uid, &notify.Message{
+			Type:    notifyTypeEventRemind,
+			Title:   "日程提醒",
+			Content: content,
+			Data:    map[string]string{"eventId": ev.ID.Hex(), "startTime": fmt.Sprint(at)},
+		})
+	}
+}
+
+// notifyInvite 通知被加入日程的参与人，失败只记录
+func (l *event) notifyInvite(ev *model.Event, userIds []string) {
+	content := fmt.Sprintf("您被邀请参加日程「%s」，时间：%s", ev.Title, timeutils.Unix(ev.StartTime).Format("2006-01-02 15:04"))
+	for _, uid := range userIds {
+		pushNotify(context.Background(), l.svcCtx, uid, &notify.Message{
+			Type:    notifyTypeEventInvite,
+			Title:   "日程邀请",
+			Content: content,
+			Data:    map[string]string{"eventId": ev.ID.Hex()},
+		})
+	}
+}
+
+func (l *event) find(ctx context.Context, id string) (*model.Event, error) {
+	data, err := l.svcCtx.EventModel.FindOne(ctx, id)
+	if err != nil {
+		if errors.Is(err, model.ErrNotFound) || errors.Is(err, model.ErrInvalidObjectId) {
+			return nil, ErrEventNotFound
+		}
+		return nil, xerr.WithMessage(err, "查询日程失败")
+	}
+	return data, nil
+}
+
+// toModel 校验请求并转换为数据模型，参与人去重并去掉创建人自己
+func (l *event) toModel(ctx context.Context, uid string, req *domain.Event) (*model.Event, error) {
+	data := &model.Event{
+		Title:     req.Title,
+		Desc:      req.Desc,
+		Location:  req.Location,
+		StartTime: req.StartTime,
+		EndTime:   req.EndTime,
+		AllDay:    req.AllDay,
+		DepId:     req.DepId,
+		Remind:    req.Remind,
+	}
+	for _, v := range req.Participants {
+		if v != "" && v != uid && !slices.Contains(data.Participants, v) {
+			data.Participants = append(data.Participants, v)
+		}
+	}
+
+	if r := req.Recurrence; r != nil {
+		data.Recurrence = &model.EventRecurrence{
+			Freq:     r.Freq,
+			Interval: r.Interval,
+			Until:    r.Until,
+			Count:    r.Count,
+		}
+		if data.Rule().Validate() != nil || (r.Until > 0 && r.Until < req.StartTime) {
+			return nil, ErrEventRecurrence
+		}
+	}
+
+	if data.DepId != "" {
+		depIds, err := userDepIds(ctx, l.svcCtx, uid)
+		if err != nil {
+			return nil, err
+		}
+		if !slices.Contains(depIds, data.DepId) {
+			return nil, ErrEventDepartment
+		}
+	}
+
+	data.RemindAt = data.NextRemindAt(timeutils.Now())
+	return data, nil
+}
+
+func toDomainEvent(m *model.Event) *domain.Event {
+	res := &domain.Event{
+		Id:           m.ID.Hex(),
+		UserId:       m.UserId,
+		Title:        m.Title,
+		Desc:         m.Desc,
+		Location:     m.Location,
+		StartTime:    m.StartTime,
+		EndTime:      m.EndTime,
+		AllDay:       m.AllDay,
+		Participants: m.Participants,
+		DepId:        m.DepId,
+		Remind:       m.Remind,
+		UpdateAt:     m.UpdateAt,
+		CreateAt:     m.CreateAt,
+	}
+	if r := m.Recurrence; r != nil {
+		res.Recurrence = &domain.EventRecurrence{
+			Freq:     r.Freq,
+			Interval: r.Interval,
+			Until:    r.Until,
+			Count:    r.Count,
+		}
+	}
+	return res
+}
+
+// userDepIds 用户所在的部门
+func userDepIds(ctx context.Context, svcCtx *svc.ServiceContext, uid string) ([]string, error) {
+	list, err := svcCtx.DepartmentuserModel.FindByUserId(ctx, uid)
+	if err != nil {
+		return nil, xerr.WithMessage(err, "查询用户部门失败")
+	}
+	ids := make([]string, 0, len(list))
+	for _, v := range list {
+		ids = append(ids, v.DepId)
+	}
+	return ids, nil
+}
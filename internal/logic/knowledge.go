@@ -0,0 +1,647 @@
+package logic
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"aiOffice/internal/domain"
+	"aiOffice/internal/model"
+	"aiOffice/internal/svc"
+	"aiOffice/pkg/asynqx"
+	"aiOffice/pkg/knowledge"
+	"aiOffice/pkg/pagex"
+	"aiOffice/pkg/token"
+	"aiOffice/pkg/xerr"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/tmc/langchaingo/chains"
+	"github.com/tmc/langchaingo/schema"
+	"github.com/tmc/langchaingo/vectorstores/redisvector"
+)
+
+var (
+	ErrKnowledgeDocNotFound = xerr.NewCode(xerr.NotFound, "知识库文档不存在")
+	ErrKnowledgeDocDenied   = xerr.NewCode(xerr.Forbidden, "只有上传人或管理员可以删除该文档")
+	ErrKnowledgeDeptDenied  = xerr.NewCode(xerr.Forbidden, "不是该部门成员，无权访问部门知识库")
+	ErrKnowledgeDocBusy     = xerr.NewCode(xerr.Conflict, "文档正在处理中，请稍后再删除")
+	ErrKnowledgeDocUpdating = xerr.NewCode(xerr.Conflict, "同名文档正在处理中，请稍后再上传")
+	ErrKnowledgeDocReplace  = xerr.NewCode(xerr.Forbidden, "知识库中已有同名文档，只有上传人或管理员可以更新")
+	ErrKnowledgeFileMissing = xerr.NewCode(xerr.NotFound, "文档的原始文件不存在")
+)
+
+type Knowledge interface {
+	// 记录文档并提交异步入库；name为原始文件名，同名文档替换旧版本；depId为空写入公共知识库
+	Submit(ctx context.Context, filePath, name, depId string) (*domain.KnowledgeDocument, error)
+	// 解析文件并写入向量库，由异步任务调用
+	Process(ctx context.Context, id string) error
+	Info(ctx context.Context, req *domain.IdPathReq) (*domain.KnowledgeDocument, error)
+	// 原始文件的保存路径和文件名，用于下载
+	File(ctx context.Context, req *domain.IdPathReq) (path, name string, err error)
+	// 预览文档提取出的文本
+	Preview(ctx context.Context, req *domain.KnowledgePreviewReq) (*domain.KnowledgePreviewResp, error)
+	List(ctx context.Context, req *domain.KnowledgeDocListReq) (*domain.KnowledgeDocListResp, error)
+	// 删除文档及其向量块
+	Delete(ctx context.Context, req *domain.IdPathReq) error
+	// 同步全部已配置的外部知识库，由定时任务调用
+	SyncAll(ctx context.Context) error
+	// 当前用户可以检索的向量索引
+	Namespaces(ctx context.Context) ([]string, error)
+	// 直接检索知识库并回答，不经过AI对话
+	Query(ctx context.Context, req *domain.KnowledgeQueryReq) (*domain.KnowledgeQueryResp, error)
+}
+
+type knowledgeLogic struct {
+	svcCtx *svc.ServiceContext
+}
+
+func NewKnowledge(svcCtx *svc.ServiceContext) Knowledge {
+	return &knowledgeLogic{
+		svcCtx: svcCtx,
+	}
+}
+
+// Submit 记录文档并提交到异步任务处理，未启用asynq时在请求内处理；
+// 同一索引下已有同名文档时替换旧版本，内容未变化则不重新入库
+func (l *knowledgeLogic) Submit(ctx context.Context, filePath, name, depId string) (*domain.KnowledgeDocument, error) {
+	if !knowledge.IsSupportedFormat(filePath) {
+		return nil, fmt.Errorf("不支持的文件格式，支持: %v", knowledge.SupportedFormats())
+	}
+	if name == "" {
+		name = filepath.Base(filePath)
+	}
+
+	uid := token.GetUid(ctx)
+	if depId != "" {
+		if err := l.checkDepartment(ctx, uid, depId); err != nil {
+			return nil, err
+		}
+	}
+
+	hash, err := fileHash(filePath)
+	if err != nil {
+		return nil, xerr.WithMessage(err, "读取文件失败")
+	}
+
+	index := knowledge.DepartmentIndex(depId)
+	doc, err := l.svcCtx.KnowledgeDocModel.FindByName(ctx, index, name)
+	switch err {
+	case nil:
+		if doc.Hash == hash && doc.Status == model.KnowledgeDocDone {
+			return toKnowledgeDocument(doc), nil
+		}
+		if err := l.checkReplace(ctx, uid, doc); err != nil {
+			return nil, err
+		}
+		doc.Path = filePath
+		doc.Hash = hash
+		doc.Version++
+		doc.Status, doc.Error = model.KnowledgeDocPending, ""
+		if err := l.svcCtx.KnowledgeDocModel.Update(ctx, doc); err != nil {
+			return nil, xerr.WithMessage(err, "保存知识库文档失败")
+		}
+	case model.ErrNotFound:
+		doc = &model.KnowledgeDocument{
+			Name:       name,
+			Path:       filePath,
+			Hash:       hash,
+			Version:    1,
+			UploaderId: uid,
+			DepId:      depId,
+			Index:      index,
+			Status:     model.KnowledgeDocPending,
+		}
+		if err := l.svcCtx.KnowledgeDocModel.Insert(ctx, doc); err != nil {
+			return nil, xerr.WithMessage(err, "保存知识库文档失败")
+		}
+	default:
+		return nil, xerr.WithMessage(err, "查询知识库文档失败")
+	}
+
+	return l.enqueue(ctx, doc, uid)
+}
+
+// enqueue 提交已保存的文档记录到异步任务，未启用asynq时直接处理
+func (l *knowledgeLogic) enqueue(ctx context.Context, doc *model.KnowledgeDocument, uid string) (*domain.KnowledgeDocument, error) {
+	if !l.svcCtx.AsynqClient.IsEnabled() {
+		if err := l.Process(ctx, doc.ID.Hex()); err != nil {
+			return nil, err
+		}
+		return l.info(ctx, doc.ID.Hex())
+	}
+
+	_, err := l.svcCtx.AsynqClient.EnqueueKnowledgeProcess(ctx, &asynqx.KnowledgeProcessPayload{
+		DocumentID: doc.ID.Hex(),
+		UserID:     uid,
+		FilePath:   doc.Path,
+		FileName:   doc.Name,
+	})
+	if err != nil {
+		doc.Status = model.KnowledgeDocFailed
+		doc.Error = err.Error()
+		if uerr := l.svcCtx.KnowledgeDocModel.Update(ctx, doc); uerr != nil {
+			fmt.Printf("[Knowledge] 更新文档状态失败: %v\n", uerr)
+		}
+		return nil, xerr.WithMessage(err, "提交知识库任务失败")
+	}
+	return toKnowledgeDocument(doc), nil
+}
+
+// checkReplace 处理中的文档不能替换，只有上传人或管理员可以上传新版本
+func (l *knowledgeLogic) checkReplace(ctx context.Context, uid string, doc *model.KnowledgeDocument) error {
+	if doc.Status == model.KnowledgeDocPending || doc.Status == model.KnowledgeDocProcessing {
+		return ErrKnowledgeDocUpdating
+	}
+	if doc.UploaderId == uid {
+		return nil
+	}
+	admin, err := l.isAdmin(ctx, uid)
+	if err != nil {
+		return err
+	}
+	if !admin {
+		return ErrKnowledgeDocReplace
+	}
+	return nil
+}
+
+// Process 解析文档并写入向量库，由异步任务调用，返回错误时任务重试
+func (l *knowledgeLogic) Process(ctx context.Context, id string) error {
+	doc, err := l.svcCtx.KnowledgeDocModel.FindOne(ctx, id)
+	if errors.Is(err, model.ErrNotFound) {
+		// 处理前文档已被删除
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if doc.Status == model.KnowledgeDocDone {
+		return nil
+	}
+
+	// 上次失败时已写入的向量块先清理，避免重试后重复
+	if len(doc.StaleIds) > 0 {
+		if err := l.deleteChunks(ctx, doc.StaleIds); err != nil {
+			return err
+		}
+		doc.StaleIds = nil
+	}
+	doc.Status, doc.Error = model.KnowledgeDocProcessing, ""
+	if err := l.svcCtx.KnowledgeDocModel.Update(ctx, doc); err != nil {
+		return err
+	}
+
+	// 新版本全部写入后才替换，期间旧版本的向量块仍可检索；失败时保留旧版本
+	ids, err := l.ingest(ctx, doc.Path, doc.Index)
+	if err != nil {
+		doc.StaleIds = ids
+		doc.Status = model.KnowledgeDocFailed
+		doc.Error = err.Error()
+		if uerr := l.svcCtx.KnowledgeDocModel.Update(ctx, doc); uerr != nil {
+			fmt.Printf("[Knowledge] 更新文档状态失败: %v\n", uerr)
+		}
+		return err
+	}
+
+	old := doc.ChunkIds
+	doc.ChunkIds, doc.StaleIds = ids, old
+	doc.ChunkCount = len(ids)
+	doc.Status = model.KnowledgeDocDone
+	if err := l.svcCtx.KnowledgeDocModel.Update(ctx, doc); err != nil {
+		// 记录未更新，新写入的向量块无法追踪，清理后重试
+		if derr := l.deleteChunks(ctx, ids); derr != nil {
+			fmt.Printf("[Knowledge] 清理向量块失败: %v\n", derr)
+		}
+		return err
+	}
+
+	// 清理被替换的旧版本，失败时留在StaleIds中，下次替换或删除时再清理
+	if len(old) > 0 {
+		if err := l.deleteChunks(ctx, old); err != nil {
+			fmt.Printf("[Knowledge] 清理旧版本向量块失败: %v\n", err)
+		} else {
+			doc.StaleIds = nil
+			if err := l.svcCtx.KnowledgeDocModel.Update(ctx, doc); err != nil {
+				fmt.Printf("[Knowledge] 更新文档状态失败: %v\n", err)
+			}
+		}
+	}
+
+	// 知识库内容变化，已缓存的回复失效
+	if err := l.svcCtx.AnswerCache.Invalidate(ctx); err != nil {
+		fmt.Printf("[Knowledge] 清除AI回复缓存失败: %v\n", err)
+	}
+
+	fmt.Printf("[Knowledge] 知识库入库成功: %s(v%d), 共 %d 个文档块\n", doc.Name, doc.Version, doc.ChunkCount)
+	return nil
+}
+
+// Info 查询文档处理状态，只能查看有权限的文档
+func (l *knowledgeLogic) Info(ctx context.Context, req *domain.IdPathReq) (*domain.KnowledgeDocument, error) {
+	doc, err := l.readable(ctx, req.Id)
+	if err != nil {
+		return nil, err
+	}
+	return toKnowledgeDocument(doc), nil
+}
+
+func (l *knowledgeLogic) File(ctx context.Context, req *domain.IdPathReq) (string, string, error) {
+	doc, err := l.readable(ctx, req.Id)
+	if err != nil {
+		return "", "", err
+	}
+	if _, err := os.Stat(doc.Path); err != nil {
+		return "", "", ErrKnowledgeFileMissing
+	}
+	return doc.Path, doc.Name, nil
+}
+
+// Preview 按字符分段返回提取的文本，长文档由前端分段加载
+func (l *knowledgeLogic) Preview(ctx context.Context, req *domain.KnowledgePreviewReq) (*domain.KnowledgePreviewResp, error) {
+	doc, err := l.readable(ctx, req.Id)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(doc.Path); err != nil {
+		return nil, ErrKnowledgeFileMissing
+	}
+
+	text, err := knowledge.NewDocProcessor(0, 0).Extract(doc.Path)
+	if err != nil {
+		return nil, xerr.WithMessage(err, "提取文档内容失败")
+	}
+
+	limit := req.Limit
+	if limit <= 0 {
+		limit = 5000
+	}
+	limit = min(limit, 20000)
+
+	runes := []rune(text)
+	start := min(max(req.Offset, 0), len(runes))
+	end := min(start+limit, len(runes))
+	return &domain.KnowledgePreviewResp{
+		Id:      req.Id,
+		Name:    doc.Name,
+		Content: string(runes[start:end]),
+		Total:   len(runes),
+		More:    end < len(runes),
+	}, nil
+}
+
+// readable 查询有权限查看的文档：公共知识库的文档、自己上传的文档、所在部门的文档，管理员可以查看全部
+func (l *knowledgeLogic) readable(ctx context.Context, id string) (*model.KnowledgeDocument, error) {
+	doc, err := l.svcCtx.KnowledgeDocModel.FindOne(ctx, id)
+	if err != nil {
+		if errors.Is(err, model.ErrNotFound) || errors.Is(err, model.ErrInvalidObjectId) {
+			return nil, ErrKnowledgeDocNotFound
+		}
+		return nil, xerr.WithMessage(err, "查询知识库文档失败")
+	}
+
+	uid := token.GetUid(ctx)
+	if doc.DepId != "" && doc.UploaderId != uid {
+		if err := l.checkDepartment(ctx, uid, doc.DepId); err != nil {
+			return nil, err
+		}
+	}
+	return doc, nil
+}
+
+func (l *knowledgeLogic) info(ctx context.Context, id string) (*domain.KnowledgeDocument, error) {
+	doc, err := l.svcCtx.KnowledgeDocModel.FindOne(ctx, id)
+	if err != nil {
+		if errors.Is(err, model.ErrNotFound) {
+			return nil, ErrKnowledgeDocNotFound
+		}
+		return nil, xerr.WithMessage(err, "查询知识库文档失败")
+	}
+	return toKnowledgeDocument(doc), nil
+}
+
+// ingest 解析、分块并写入向量库，返回写入的向量块key
+func (l *knowledgeLogic) ingest(ctx context.Context, filePath, index string) ([]string, error) {
+	processor := knowledge.NewDocProcessor(500, 50)
+	if semantic := l.svcCtx.Config.LangChain.Knowledge.Semantic; len(semantic.Types) > 0 {
+		processor.WithSemantic(knowledge.NewSemanticSplitter(l.svcCtx.Embedder, semantic.Percentile), semantic.Types...)
+	}
+	docs, err := processor.ProcessContext(ctx, filePath)
+	if err != nil {
+		return nil, fmt.Errorf("文档处理失败: %v", err)
+	}
+	if len(docs) == 0 {
+		return nil, fmt.Errorf("文档中没有提取到有效内容")
+	}
+
+	store, err := redisvector.New(ctx,
+		redisvector.WithEmbedder(l.svcCtx.Embedder),
+		redisvector.WithConnectionURL(l.redisURL()),
+		redisvector.WithIndexName(index, true),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("连接向量存储失败: %v", err)
+	}
+
+	return knowledge.AddToVectorStore(ctx, store, docs)
+}
+
+// List 管理员可以查看全部文档，其他用户只能查看公共知识库和所在部门的文档
+func (l *knowledgeLogic) List(ctx context.Context, req *domain.KnowledgeDocListReq) (*domain.KnowledgeDocListResp, error) {
+	uid := token.GetUid(ctx)
+	admin, err := l.isAdmin(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+
+	var depIds []string
+	if req.DepId != "" {
+		if !admin {
+			if err := l.checkDepartment(ctx, uid, req.DepId); err != nil {
+				return nil, err
+			}
+		}
+		depIds = []string{req.DepId}
+	} else if !admin {
+		deps, err := l.departments(ctx, uid)
+		if err != nil {
+			return nil, err
+		}
+		depIds = append([]string{""}, deps...)
+	}
+
+	list, total, err := l.svcCtx.KnowledgeDocModel.List(ctx, req.Name, depIds, pagex.FromRequest(req.Page, req.Count, ""))
+	if err != nil {
+		return nil, xerr.WithMessage(err, "查询知识库文档失败")
+	}
+
+	resp := &domain.KnowledgeDocListResp{
+		Count: total,
+		List:  make([]*domain.KnowledgeDocument, 0, len(list)),
+	}
+	for _, v := range list {
+		resp.List = append(resp.List, toKnowledgeDocument(v))
+	}
+	return resp, nil
+}
+
+func (l *knowledgeLogic) Delete(ctx context.Context, req *domain.IdPathReq) error {
+	doc, err := l.svcCtx.KnowledgeDocModel.FindOne(ctx, req.Id)
+	if err != nil {
+		if errors.Is(err, model.ErrNotFound) {
+			return ErrKnowledgeDocNotFound
+		}
+		return xerr.WithMessage(err, "查询知识库文档失败")
+	}
+
+	// 处理中的文档向量块还未全部记录，删除会留下残余数据
+	if doc.Status == model.KnowledgeDocProcessing {
+		return ErrKnowledgeDocBusy
+	}
+
+	uid := token.GetUid(ctx)
+	if doc.UploaderId != uid {
+		admin, err := l.isAdmin(ctx, uid)
+		if err != nil {
+			return err
+		}
+		if !admin {
+			return ErrKnowledgeDocDenied
+		}
+	}
+
+	// 先删除向量块，失败时保留记录以便重试
+	if err := l.deleteChunks(ctx, append(doc.ChunkIds, doc.StaleIds...)); err != nil {
+		return xerr.WithMessage(err, "删除向量数据失败")
+	}
+	if err := l.svcCtx.KnowledgeDocModel.Delete(ctx, req.Id); err != nil {
+		return xerr.WithMessage(err, "删除知识库文档失败")
+	}
+
+	if err := l.svcCtx.AnswerCache.Invalidate(ctx); err != nil {
+		fmt.Printf("[Knowledge] 清除AI回复缓存失败: %v\n", err)
+	}
+	return nil
+}
+
+// Namespaces 公共知识库和用户所在部门中已有文档的知识库，检索前按成员关系确定范围
+func (l *knowledgeLogic) Namespaces(ctx context.Context) ([]string, error) {
+	indexes := []string{knowledge.IndexName}
+
+	deps, err := l.departments(ctx, token.GetUid(ctx))
+	if err != nil || len(deps) == 0 {
+		return indexes, err
+	}
+	exists, err := l.svcCtx.KnowledgeDocModel.ExistsByDepIds(ctx, deps)
+	if err != nil {
+		return nil, xerr.WithMessage(err, "查询部门知识库失败")
+	}
+	for _, depId := range exists {
+		indexes = append(indexes, knowledge.DepartmentIndex(depId))
+	}
+	return indexes, nil
+}
+
+// Query 检索并回答，返回引用的文档片段；可信度为最相关片段与问题的语义相似度
+func (l *knowledgeLogic) Query(ctx context.Context, req *domain.KnowledgeQueryReq) (*domain.KnowledgeQueryResp, error) {
+	uid := token.GetUid(ctx)
+	if err := checkRateLimit(ctx, l.svcCtx, uid); err != nil {
+		return nil, err
+	}
+	if err := checkQuota(ctx, l.svcCtx, uid); err != nil {
+		return nil, err
+	}
+
+	indexes := []string{knowledge.DepartmentIndex(req.DepId)}
+	if req.DepId != "" {
+		if err := l.checkDepartment(ctx, uid, req.DepId); err != nil {
+			return nil, err
+		}
+	} else {
+		var err error
+		if indexes, err = l.Namespaces(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	retriever, err := l.svcCtx.Knowledge.Retriever(ctx, indexes, 3)
+	if err != nil {
+		return nil, err
+	}
+	docs, err := retriever.GetRelevantDocuments(ctx, req.Query)
+	if err != nil {
+		return nil, xerr.WithMessage(err, "检索知识库失败")
+	}
+
+	resp := &domain.KnowledgeQueryResp{
+		Answer:  "知识库中没有找到相关内容",
+		Sources: make([]*domain.KnowledgeSource, 0, len(docs)),
+	}
+	if len(docs) == 0 {
+		return resp, nil
+	}
+
+	res, err := chains.Call(ctx, chains.LoadStuffQA(l.svcCtx.LLM), map[string]any{
+		"input_documents": docs,
+		"question":        req.Query,
+	})
+	if err != nil {
+		return nil, xerr.WithMessage(err, "生成回答失败")
+	}
+	resp.Answer, _ = res["text"].(string)
+
+	scores, err := l.svcCtx.Knowledge.Similarity(ctx, req.Query, docs)
+	if err != nil {
+		// 只影响可信度，不影响回答
+		fmt.Printf("[Knowledge] 计算相似度失败: %v\n", err)
+	}
+	resp.Sources = l.sources(ctx, docs, scores)
+	for _, v := range resp.Sources {
+		resp.Confidence = max(resp.Confidence, v.Score)
+	}
+	return resp, nil
+}
+
+// sources 由向量块metadata中的文件路径找到所属文档
+func (l *knowledgeLogic) sources(ctx context.Context, docs []schema.Document, scores []float64) []*domain.KnowledgeSource {
+	paths := make([]string, 0, len(docs))
+	for _, doc := range docs {
+		if p, ok := doc.Metadata["source"].(string); ok {
+			paths = append(paths, p)
+		}
+	}
+	byPath := make(map[string]*model.KnowledgeDocument, len(paths))
+	if len(paths) > 0 {
+		list, err := l.svcCtx.KnowledgeDocModel.FindByPaths(ctx, paths)
+		if err != nil {
+			fmt.Printf("[Knowledge] 查询引用文档失败: %v\n", err)
+		}
+		for _, v := range list {
+			byPath[v.Path] = v
+		}
+	}
+
+	list := make([]*domain.KnowledgeSource, 0, len(docs))
+	for i, doc := range docs {
+		source := &domain.KnowledgeSource{Content: doc.PageContent}
+		p, _ := doc.Metadata["source"].(string)
+		if v, ok := byPath[p]; ok {
+			source.DocumentId = v.ID.Hex()
+			source.Name = v.Name
+		} else {
+			source.Name = filepath.Base(p)
+		}
+		if i < len(scores) {
+			source.Score = math.Round(scores[i]*100) / 100
+		}
+		list = append(list, source)
+	}
+	return list
+}
+
+// checkDepartment 部门知识库只允许部门成员和管理员访问
+func (l *knowledgeLogic) checkDepartment(ctx context.Context, uid, depId string) error {
+	deps, err := l.departments(ctx, uid)
+	if err != nil {
+		return err
+	}
+	if slices.Contains(deps, depId) {
+		return nil
+	}
+
+	admin, err := l.isAdmin(ctx, uid)
+	if err != nil {
+		return err
+	}
+	if !admin {
+		return ErrKnowledgeDeptDenied
+	}
+	if _, err := l.svcCtx.DepartmentModel.FindOne(ctx, depId); err != nil {
+		return model.ErrNotFindDepartment
+	}
+	return nil
+}
+
+// departments 用户所在的部门
+func (l *knowledgeLogic) departments(ctx context.Context, uid string) ([]string, error) {
+	list, err := l.svcCtx.DepartmentuserModel.FindByUserId(ctx, uid)
+	if err != nil {
+		return nil, xerr.WithMessage(err, "查询用户部门失败")
+	}
+	deps := make([]string, 0, len(list))
+	for _, v := range list {
+		deps = append(deps, v.DepId)
+	}
+	return deps, nil
+}
+
+func (l *knowledgeLogic) isAdmin(ctx context.Context, uid string) (bool, error) {
+	user, err := l.svcCtx.UserModel.FindOne(ctx, uid)
+	if err != nil {
+		return false, xerr.WithMessage(err, "查询用户失败")
+	}
+	return user.IsAdmin, nil
+}
+
+// redisURL 向量库的连接地址
+func (l *knowledgeLogic) redisURL() string {
+	return "redis://" + l.svcCtx.Config.Redis.Addr
+}
+
+// deleteChunks 删除向量块，向量块是索引前缀下的hash，删除后自动从索引中移除；
+// 使用与向量库相同的连接，svc.Redis可能配置了其他库
+func (l *knowledgeLogic) deleteChunks(ctx context.Context, ids []string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	opt, err := redis.ParseURL(l.redisURL())
+	if err != nil {
+		return err
+	}
+	rds := redis.NewClient(opt)
+	defer rds.Close()
+
+	for i := 0; i < len(ids); i += 500 {
+		end := min(i+500, len(ids))
+		if err := rds.Del(ctx, ids[i:end]...).Err(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fileHash 文件内容的sha256
+func fileHash(filePath string) (string, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func toKnowledgeDocument(v *model.KnowledgeDocument) *domain.KnowledgeDocument {
+	return &domain.KnowledgeDocument{
+		Id:         v.ID.Hex(),
+		Name:       v.Name,
+		UploaderId: v.UploaderId,
+		DepId:      v.DepId,
+		Index:      v.Index,
+		Version:    v.Version,
+		ChunkCount: v.ChunkCount,
+		Status:     v.Status,
+		Error:      v.Error,
+		CreateAt:   v.CreateAt,
+	}
+}
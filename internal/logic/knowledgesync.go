@@ -0,0 +1,189 @@
+package logic
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"aiOffice/internal/model"
+	"aiOffice/pkg/knowledge"
+	"aiOffice/pkg/timeutils"
+	"aiOffice/pkg/wiki"
+)
+
+// errPageBusy 页面的上一版本还在处理，本次不提交
+var errPageBusy = errors.New("page is processing")
+
+// SyncAll 依次同步每个外部知识库，单个失败记录在同步状态中，不影响其他连接器
+func (l *knowledgeLogic) SyncAll(ctx context.Context) error {
+	var errs []error
+	for _, conn := range l.svcCtx.Wikis {
+		if err := l.sync(ctx, conn); err != nil {
+			fmt.Printf("[KnowledgeSync] %s 同步失败: %v\n", conn.Name(), err)
+			errs = append(errs, fmt.Errorf("%s: %w", conn.Name(), err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// sync 拉取游标之后变化的页面写入文件并提交入库，全部提交成功后才推进游标，
+// 有页面因上一版本处理中而跳过时同样保留游标，下次重新拉取
+func (l *knowledgeLogic) sync(ctx context.Context, conn wiki.Connector) error {
+	state, err := l.svcCtx.KnowledgeSyncModel.FindByConnector(ctx, conn.Name())
+	if errors.Is(err, model.ErrNotFound) {
+		state, err = &model.KnowledgeSync{Connector: conn.Name()}, nil
+	}
+	if err != nil {
+		return err
+	}
+
+	pages, cursor, err := conn.Changes(ctx, state.Cursor)
+	busy := false
+	if err == nil {
+		state.Pages = 0
+		for _, page := range pages {
+			submitted, perr := l.syncPage(ctx, conn.Name(), page)
+			if perr == errPageBusy {
+				busy = true
+				continue
+			}
+			if perr != nil {
+				err = fmt.Errorf("页面 %s(%s): %w", page.Title, page.Id, perr)
+				break
+			}
+			if submitted {
+				state.Pages++
+			}
+		}
+	}
+
+	state.SyncAt = timeutils.Now()
+	state.Error = ""
+	if err != nil {
+		state.Error = err.Error()
+	} else if !busy {
+		state.Cursor = cursor
+	}
+	if uerr := l.svcCtx.KnowledgeSyncModel.Upsert(ctx, state); uerr != nil {
+		return uerr
+	}
+	if err == nil {
+		fmt.Printf("[KnowledgeSync] %s 同步完成，拉取 %d 个页面，提交入库 %d 个\n", conn.Name(), len(pages), state.Pages)
+	}
+	return err
+}
+
+// syncPage 页面以外部ID关联文档记录，内容未变化时跳过，返回是否提交了入库
+func (l *knowledgeLogic) syncPage(ctx context.Context, source string, page *wiki.Page) (bool, error) {
+	if strings.TrimSpace(page.Content) == "" {
+		return false, nil
+	}
+
+	content := pageMarkdown(page)
+	sum := sha256.Sum256(content)
+	hash := hex.EncodeToString(sum[:])
+
+	name := page.Title
+	if name == "" {
+		name = page.Id
+	}
+	name += ".md"
+
+	key := source + ":" + page.Id
+	doc, err := l.svcCtx.KnowledgeDocModel.FindBySource(ctx, key)
+	switch err {
+	case nil:
+		if doc.Hash == hash && doc.Status == model.KnowledgeDocDone {
+			return false, nil
+		}
+		if doc.Status == model.KnowledgeDocPending || doc.Status == model.KnowledgeDocProcessing {
+			// 上一版本还在处理，文件不能覆盖，下次同步时再提交
+			return false, errPageBusy
+		}
+	case model.ErrNotFound:
+		doc = nil
+	default:
+		return false, err
+	}
+
+	filePath, err := l.writePage(source, page.Id, content)
+	if err != nil {
+		return false, err
+	}
+
+	if doc != nil {
+		doc.Name = name
+		doc.Path = filePath
+		doc.Hash = hash
+		doc.Version++
+		doc.Status, doc.Error = model.KnowledgeDocPending, ""
+		if err := l.svcCtx.KnowledgeDocModel.Update(ctx, doc); err != nil {
+			return false, err
+		}
+	} else {
+		depId := l.syncDepId(source)
+		doc = &model.KnowledgeDocument{
+			Name:    name,
+			Path:    filePath,
+			Hash:    hash,
+			Version: 1,
+			DepId:   depId,
+			Index:   knowledge.DepartmentIndex(depId),
+			Source:  key,
+			Status:  model.KnowledgeDocPending,
+		}
+		if err := l.svcCtx.KnowledgeDocModel.Insert(ctx, doc); err != nil {
+			return false, err
+		}
+	}
+
+	if _, err := l.enqueue(ctx, doc, ""); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// pageMarkdown 页面转换为markdown，标题和链接写在开头，便于回答时引用
+func pageMarkdown(page *wiki.Page) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\n", page.Title)
+	if page.Url != "" {
+		fmt.Fprintf(&b, "来源: %s\n\n", page.Url)
+	}
+	b.WriteString(page.Content)
+	return []byte(b.String())
+}
+
+// writePage 页面保存为markdown文件，同一页面覆盖写入
+func (l *knowledgeLogic) writePage(source, id string, content []byte) (string, error) {
+	savePath := l.svcCtx.Config.Upload.SavePath
+	if savePath == "" {
+		savePath = "./uploads/"
+	}
+	dir := filepath.Join(savePath, "wiki", source)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+
+	filePath := filepath.Join(dir, filepath.Base(id)+".md")
+	return filePath, os.WriteFile(filePath, content, 0644)
+}
+
+// syncDepId 连接器写入的部门知识库
+func (l *knowledgeLogic) syncDepId(source string) string {
+	conf := l.svcCtx.Config.KnowledgeSync
+	switch source {
+	case wiki.SourceConfluence:
+		return conf.Confluence.DepId
+	case wiki.SourceNotion:
+		return conf.Notion.DepId
+	case wiki.SourceFeishu:
+		return conf.Feishu.DepId
+	}
+	return ""
+}
@@ -0,0 +1,14 @@
+package logic
+
+import "aiOffice/internal/svc"
+
+// Inject 注入AI工具调用的业务逻辑和对话记忆，启动时创建服务上下文后调用一次；
+// api、ws和worker进程中的AI对话共用同一组实例，记忆的空闲清理由 main 随服务启停
+func Inject(svc *svc.ServiceContext) {
+	svc.AIMemory = newMemory(svc)
+
+	svc.TodoLogic = NewTodo(svc)
+	svc.ApprovalLogic = NewApproval(svc)
+	svc.AttendanceLogic = NewAttendance(svc)
+	svc.KnowledgeLogic = NewKnowledge(svc)
+}
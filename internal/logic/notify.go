@@ -0,0 +1,170 @@
+package logic
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"aiOffice/internal/domain"
+	"aiOffice/internal/model"
+	"aiOffice/internal/svc"
+	"aiOffice/pkg/asynqx"
+	"aiOffice/pkg/notify"
+	"aiOffice/pkg/pagex"
+	"aiOffice/pkg/token"
+	"aiOffice/pkg/xerr"
+)
+
+var (
+	ErrInvalidPlatform = xerr.NewCode(xerr.Invalid, "不支持的推送平台")
+	ErrInvalidChannel  = xerr.NewCode(xerr.Invalid, "不支持的推送渠道")
+	ErrQuietHours      = xerr.NewCode(xerr.Invalid, "免打扰开始和结束时间需同时设置且不能相同")
+	ErrReminderInQuiet = xerr.NewCode(xerr.Invalid, "提醒时间不能在免打扰时段内")
+)
+
+type Notify interface {
+	// 注册推送设备
+	RegisterDevice(ctx context.Context, req *domain.DeviceReq) (err error)
+	// 注销推送设备
+	RemoveDevice(ctx context.Context, req *domain.DeviceReq) (err error)
+	// 查询提醒偏好
+	Setting(ctx context.Context) (resp *domain.NotifySettingResp, err error)
+	// 更新提醒偏好
+	UpdateSetting(ctx context.Context, req *domain.NotifySetting) (err error)
+	// 通知中心：未读公告
+	Center(ctx context.Context) (resp *domain.NotifyCenterResp, err error)
+}
+
+type notifyLogic struct {
+	svcCtx *svc.ServiceContext
+}
+
+func NewNotify(svcCtx *svc.ServiceContext) Notify {
+	return &notifyLogic{
+		svcCtx: svcCtx,
+	}
+}
+
+// RegisterDevice 注册推送设备
+func (l *notifyLogic) RegisterDevice(ctx context.Context, req *domain.DeviceReq) (err error) {
+	if req.Platform != notify.PlatformFcm && req.Platform != notify.PlatformApns {
+		return ErrInvalidPlatform
+	}
+
+	err = l.svcCtx.DeviceTokenModel.Upsert(ctx, &model.DeviceToken{
+		UserId:   token.GetUid(ctx),
+		Platform: req.Platform,
+		Token:    req.Token,
+	})
+	return xerr.WithMessage(err, "注册推送设备失败")
+}
+
+// RemoveDevice 注销推送设备（退出登录时调用）
+func (l *notifyLogic) RemoveDevice(ctx context.Context, req *domain.DeviceReq) (err error) {
+	err = l.svcCtx.DeviceTokenModel.DeleteByUserIdAndToken(ctx, token.GetUid(ctx), req.Token)
+	return xerr.WithMessage(err, "注销推送设备失败")
+}
+
+// Setting 未设置过时返回空设置，即使用全局提醒时间、全部推送渠道
+func (l *notifyLogic) Setting(ctx context.Context) (*domain.NotifySettingResp, error) {
+	setting, err := l.svcCtx.UserSettingModel.FindByUserId(ctx, token.GetUid(ctx))
+	if err != nil && !errors.Is(err, model.ErrNotFound) {
+		return nil, xerr.WithMessage(err, "查询提醒设置失败")
+	}
+
+	resp := &domain.NotifySettingResp{AvailableChannels: l.svcCtx.Notifier.Senders()}
+	if setting != nil {
+		resp.NotifySetting = domain.NotifySetting{
+			ReminderTime: setting.ReminderTime,
+			QuietStart:   setting.QuietStart,
+			QuietEnd:     setting.QuietEnd,
+			Channels:     setting.Channels,
+		}
+	}
+	return resp, nil
+}
+
+// UpdateSetting 修改提醒时间后，从下一次提醒开始生效
+func (l *notifyLogic) UpdateSetting(ctx context.Context, req *domain.NotifySetting) error {
+	// 统一为 HH:MM，按分钟投递提醒时直接比较字符串
+	for _, v := range []*string{&req.ReminderTime, &req.QuietStart, &req.QuietEnd} {
+		if *v == "" {
+			continue
+		}
+		m, err := notify.ParseClock(*v)
+		if err != nil {
+			return err
+		}
+		*v = fmt.Sprintf("%02d:%02d", m/60, m%60)
+	}
+	if (req.QuietStart == "") != (req.QuietEnd == "") || (req.QuietStart != "" && req.QuietStart == req.QuietEnd) {
+		return ErrQuietHours
+	}
+	if req.ReminderTime != "" {
+		at, _ := time.Parse("15:04", req.ReminderTime)
+		if _, quiet := notify.QuietUntil(req.QuietStart, req.QuietEnd, at); quiet {
+			return ErrReminderInQuiet
+		}
+	}
+
+	available := l.svcCtx.Notifier.Senders()
+	for _, v := range req.Channels {
+		if !slices.Contains(available, v) {
+			return ErrInvalidChannel
+		}
+	}
+
+	err := l.svcCtx.UserSettingModel.Upsert(ctx, &model.UserSetting{
+		UserId:       token.GetUid(ctx),
+		ReminderTime: req.ReminderTime,
+		QuietStart:   req.QuietStart,
+		QuietEnd:     req.QuietEnd,
+		Channels:     req.Channels,
+	})
+	return xerr.WithMessage(err, "更新提醒设置失败")
+}
+
+// Center 统计最近公告中的未读数，离线期间发布的公告在这里查看
+func (l *notifyLogic) Center(ctx context.Context) (*domain.NotifyCenterResp, error) {
+	list, _, err := userAnnouncements(ctx, l.svcCtx, token.GetUid(ctx), pagex.Page{Page: 1, Count: notifyCenterScan})
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &domain.NotifyCenterResp{Announcements: make([]*domain.Announcement, 0, notifyCenterSize)}
+	for _, v := range list {
+		if v.Read {
+			continue
+		}
+		resp.UnreadAnnouncements++
+		if len(resp.Announcements) < notifyCenterSize {
+			resp.Announcements = append(resp.Announcements, v)
+		}
+	}
+	return resp, nil
+}
+
+// pushNotify 发送业务通知：启用Asynq时提交通知任务，按用户偏好发送并可按用户聚合，否则直接发送；失败只记录
+func pushNotify(ctx context.Context, svcCtx *svc.ServiceContext, uid string, msg *notify.Message) {
+	if svcCtx.AsynqClient.IsEnabled() {
+		_, err := svcCtx.AsynqClient.EnqueueNotify(ctx, &asynqx.NotifyPayload{
+			UserID:  uid,
+			Type:    msg.Type,
+			Title:   msg.Title,
+			Content: msg.Content,
+			Data:    msg.Data,
+			Urgent:  msg.Urgent,
+		})
+		if err == nil {
+			return
+		}
+		fmt.Printf("[Notify] 提交通知任务失败，改为直接发送, uid: %s, err: %v\n", uid, err)
+	}
+
+	msg.Time = time.Now().Unix()
+	if err := svcCtx.Notifier.Notify(ctx, uid, msg); err != nil {
+		fmt.Printf("[Notify] 通知失败, uid: %s, type: %s, err: %v\n", uid, msg.Type, err)
+	}
+}
@@ -0,0 +1,110 @@
+package logic
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"aiOffice/internal/domain"
+	"aiOffice/internal/svc"
+)
+
+// retentionBatch 每批删除的待办数，关联的执行人和操作记录按同一批待办删除
+const retentionBatch = 500
+
+// Retention 按配置的保留天数清理过期数据，由定时任务调用
+type Retention interface {
+	// 单项失败不影响其他项，返回已清理的数量和合并的错误
+	Purge(ctx context.Context) (*domain.RetentionReport, error)
+}
+
+type retention struct {
+	svcCtx *svc.ServiceContext
+}
+
+func NewRetention(svcCtx *svc.ServiceContext) Retention {
+	return &retention{
+		svcCtx: svcCtx,
+	}
+}
+
+func (l *retention) Purge(ctx context.Context) (*domain.RetentionReport, error) {
+	c := l.svcCtx.Config.Retention
+	report := &domain.RetentionReport{DryRun: c.DryRun}
+
+	var errs []error
+	if c.ChatLogDays > 0 {
+		n, err := l.svcCtx.ChatLogModel.PurgeBefore(ctx, before(c.ChatLogDays), c.DryRun)
+		report.ChatLogs = n
+		if err != nil {
+			errs = append(errs, fmt.Errorf("chat_log: %w", err))
+		}
+	}
+	if c.TodoDays > 0 {
+		if err := l.purgeTodos(ctx, before(c.TodoDays), c.DryRun, report); err != nil {
+			errs = append(errs, fmt.Errorf("todo: %w", err))
+		}
+	}
+	if c.ApprovalDays > 0 {
+		n, err := l.svcCtx.ApprovalModel.PurgeFinished(ctx, before(c.ApprovalDays), c.DryRun)
+		report.Approvals = n
+		if err != nil {
+			errs = append(errs, fmt.Errorf("approval: %w", err))
+		}
+	}
+
+	if c.AuditLogDays > 0 {
+		n, err := l.svcCtx.AuditLogModel.PurgeBefore(ctx, before(c.AuditLogDays), c.DryRun)
+		report.AuditLogs = n
+		if err != nil {
+			errs = append(errs, fmt.Errorf("audit_log: %w", err))
+		}
+	}
+
+	for _, err := range errs {
+		report.Errors = append(report.Errors, err.Error())
+	}
+	fmt.Printf("[Retention] dryRun=%v 聊天记录 %d, 待办 %d, 审批 %d, 审计记录 %d\n", report.DryRun, report.ChatLogs, report.Todos, report.Approvals, report.AuditLogs)
+	return report, errors.Join(errs...)
+}
+
+// purgeTodos 先删除待办的执行人和操作记录，再删除待办，中途失败时下次清理会继续
+func (l *retention) purgeTodos(ctx context.Context, before int64, dryRun bool, report *domain.RetentionReport) error {
+	if dryRun {
+		n, err := l.svcCtx.TodoModel.CountFinished(ctx, before)
+		report.Todos = n
+		return err
+	}
+
+	for {
+		ids, err := l.svcCtx.TodoModel.FindFinishedIds(ctx, before, retentionBatch)
+		if err != nil || len(ids) == 0 {
+			return err
+		}
+
+		n, err := l.svcCtx.UserTodoModel.DeleteByTodoIds(ctx, ids)
+		report.UserTodos += n
+		if err != nil {
+			return err
+		}
+		n, err = l.svcCtx.TodoRecordModel.DeleteByTodoIds(ctx, ids)
+		report.TodoRecords += n
+		if err != nil {
+			return err
+		}
+		n, err = l.svcCtx.TodoModel.DeleteByIds(ctx, ids)
+		report.Todos += n
+		if err != nil {
+			return err
+		}
+		if len(ids) < retentionBatch {
+			return nil
+		}
+	}
+}
+
+// before 保留天数对应的截止时间戳
+func before(days int) int64 {
+	return time.Now().AddDate(0, 0, -days).Unix()
+}
@@ -0,0 +1,157 @@
+package logic
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+
+	"aiOffice/internal/domain"
+	"aiOffice/internal/model"
+	"aiOffice/internal/svc"
+	"aiOffice/pkg/asynqx"
+	"aiOffice/pkg/token"
+	"aiOffice/pkg/xerr"
+)
+
+var (
+	ErrScheduleForbidden = xerr.NewCode(xerr.Forbidden, "只有管理员可以管理定时任务")
+	ErrScheduleNotFound  = xerr.NewCode(xerr.NotFound, "定时任务不存在")
+)
+
+// Schedule 运行时添加的定时任务，保存后由 PeriodicTaskManager 定期同步生效
+type Schedule interface {
+	List(ctx context.Context) (resp *domain.ScheduledTaskListResp, err error)
+	Create(ctx context.Context, req *domain.ScheduledTask) (resp *domain.ScheduledTask, err error)
+	Update(ctx context.Context, req *domain.ScheduledTask) (err error)
+	Delete(ctx context.Context, req *domain.IdPathReq) (err error)
+}
+
+type schedule struct {
+	svcCtx *svc.ServiceContext
+}
+
+func NewSchedule(svcCtx *svc.ServiceContext) Schedule {
+	return &schedule{
+		svcCtx: svcCtx,
+	}
+}
+
+func (l *schedule) List(ctx context.Context) (*domain.ScheduledTaskListResp, error) {
+	if err := l.checkAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	list, err := l.svcCtx.ScheduledTaskModel.List(ctx, false)
+	if err != nil {
+		return nil, xerr.WithMessage(err, "查询定时任务失败")
+	}
+
+	resp := &domain.ScheduledTaskListResp{
+		List:  make([]*domain.ScheduledTask, 0, len(list)),
+		Types: slices.Sorted(maps.Keys(asynqx.PeriodicTypes)),
+	}
+	for _, v := range list {
+		resp.List = append(resp.List, toScheduledTask(v))
+	}
+	return resp, nil
+}
+
+func (l *schedule) Create(ctx context.Context, req *domain.ScheduledTask) (*domain.ScheduledTask, error) {
+	if err := l.checkAdmin(ctx); err != nil {
+		return nil, err
+	}
+	if err := validateSchedule(req); err != nil {
+		return nil, err
+	}
+
+	data := &model.ScheduledTask{
+		Name:      req.Name,
+		Cron:      req.Cron,
+		TaskType:  req.TaskType,
+		Payload:   req.Payload,
+		Queue:     req.Queue,
+		Enabled:   req.Enabled,
+		CreatorId: token.GetUid(ctx),
+	}
+	if err := l.svcCtx.ScheduledTaskModel.Insert(ctx, data); err != nil {
+		return nil, xerr.WithMessage(err, "添加定时任务失败")
+	}
+	return toScheduledTask(data), nil
+}
+
+func (l *schedule) Update(ctx context.Context, req *domain.ScheduledTask) error {
+	if err := l.checkAdmin(ctx); err != nil {
+		return err
+	}
+	if err := validateSchedule(req); err != nil {
+		return err
+	}
+
+	data, err := l.svcCtx.ScheduledTaskModel.FindOne(ctx, req.Id)
+	if err != nil {
+		if errors.Is(err, model.ErrNotFound) || errors.Is(err, model.ErrInvalidObjectId) {
+			return ErrScheduleNotFound
+		}
+		return xerr.WithMessage(err, "查询定时任务失败")
+	}
+
+	data.Name = req.Name
+	data.Cron = req.Cron
+	data.TaskType = req.TaskType
+	data.Payload = req.Payload
+	data.Queue = req.Queue
+	data.Enabled = req.Enabled
+	return xerr.WithMessage(l.svcCtx.ScheduledTaskModel.Update(ctx, data), "更新定时任务失败")
+}
+
+func (l *schedule) Delete(ctx context.Context, req *domain.IdPathReq) error {
+	if err := l.checkAdmin(ctx); err != nil {
+		return err
+	}
+
+	err := l.svcCtx.ScheduledTaskModel.Delete(ctx, req.Id)
+	if errors.Is(err, model.ErrInvalidObjectId) {
+		return ErrScheduleNotFound
+	}
+	return xerr.WithMessage(err, "删除定时任务失败")
+}
+
+func (l *schedule) checkAdmin(ctx context.Context) error {
+	user, err := l.svcCtx.UserModel.FindOne(ctx, token.GetUid(ctx))
+	if err != nil {
+		return xerr.WithMessage(err, "查询用户失败")
+	}
+	if !user.IsAdmin {
+		return ErrScheduleForbidden
+	}
+	return nil
+}
+
+// validateSchedule 补全默认的载荷和队列后检查
+func validateSchedule(req *domain.ScheduledTask) error {
+	if req.Payload == "" {
+		req.Payload = "{}"
+	}
+	if req.Queue == "" {
+		req.Queue = asynqx.PeriodicTypes[req.TaskType]
+	}
+	if err := asynqx.ValidatePeriodic(req.Cron, req.TaskType, req.Payload); err != nil {
+		return fmt.Errorf("定时任务配置错误: %v", err)
+	}
+	return nil
+}
+
+func toScheduledTask(v *model.ScheduledTask) *domain.ScheduledTask {
+	return &domain.ScheduledTask{
+		Id:       v.ID.Hex(),
+		Name:     v.Name,
+		Cron:     v.Cron,
+		TaskType: v.TaskType,
+		Payload:  v.Payload,
+		Queue:    v.Queue,
+		Enabled:  v.Enabled,
+		UpdateAt: v.UpdateAt,
+	}
+}
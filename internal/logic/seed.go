@@ -0,0 +1,322 @@
+package logic
+
+import (
+	"context"
+	"crypto/sha1"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"aiOffice/internal/domain"
+	"aiOffice/internal/model"
+	"aiOffice/internal/svc"
+	"aiOffice/pkg/encrypt"
+	"aiOffice/pkg/token"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// seedPassword 示例用户的密码
+const seedPassword = "123456"
+
+// Seed 写入演示和集成测试用的组织架构、用户、待办、审批和知识库文档；
+// 示例数据使用固定id，已存在的记录跳过，可重复执行
+type Seed interface {
+	Run(ctx context.Context) (*domain.SeedReport, error)
+}
+
+type seed struct {
+	svcCtx *svc.ServiceContext
+	report *domain.SeedReport
+}
+
+func NewSeed(svcCtx *svc.ServiceContext) Seed {
+	return &seed{
+		svcCtx: svcCtx,
+	}
+}
+
+type seedDep struct {
+	key, name, parent string
+	leader            string   // 负责人的用户key
+	users             []string // 成员的用户key
+}
+
+type seedUser struct {
+	key, email string
+}
+
+type seedTodo struct {
+	key, title, desc string
+	creator          string
+	executors        []string
+	deadlineDays     int
+}
+
+type seedDoc struct {
+	name, dep, content string
+	uploader           string // 部门文档需由部门成员上传
+}
+
+var (
+	seedUsers = []seedUser{
+		{"zhangsan", "zhangsan@example.com"},
+		{"lisi", "lisi@example.com"},
+		{"wangwu", "wangwu@example.com"},
+		{"zhaoliu", "zhaoliu@example.com"},
+		{"sunqi", "sunqi@example.com"},
+	}
+	seedDeps = []seedDep{
+		{key: "root", name: "示例科技有限公司", leader: "zhangsan", users: []string{"zhangsan"}},
+		{key: "rd", name: "研发部", parent: "root", leader: "lisi", users: []string{"lisi", "wangwu"}},
+		{key: "product", name: "产品部", parent: "root", leader: "zhaoliu", users: []string{"zhaoliu"}},
+		{key: "hr", name: "人事部", parent: "root", leader: "sunqi", users: []string{"sunqi"}},
+	}
+	seedTodos = []seedTodo{
+		{"weekly", "提交本周周报", "汇总本周工作进展和下周计划", "lisi", []string{"lisi", "wangwu"}, 2},
+		{"review", "评审新版需求文档", "重点关注审批流程的交互设计", "zhaoliu", []string{"lisi", "zhaoliu"}, 5},
+		{"onboard", "准备新员工入职材料", "包括工位、账号和入职培训安排", "sunqi", []string{"sunqi"}, 7},
+	}
+	seedDocs = []seedDoc{
+		{"员工手册.md", "", "# 员工手册\n\n## 考勤\n\n工作时间为周一至周五 9:00-18:00，午休 12:00-13:00。\n迟到或漏打卡需在3个工作日内提交补卡审批。\n\n## 请假\n\n年假、事假、病假需提前在系统中提交请假审批，由部门负责人审批。\n", "zhangsan"},
+		{"研发规范.md", "rd", "# 研发规范\n\n## 代码评审\n\n所有合并到主干的代码需要至少一位同事评审。\n\n## 发布\n\n每周四下午发布，紧急修复需研发负责人同意。\n", "lisi"},
+	}
+)
+
+// seedID 由key生成固定的ObjectID，重复执行时得到相同的id
+func seedID(kind, key string) primitive.ObjectID {
+	sum := sha1.Sum([]byte("aiOffice:seed:" + kind + ":" + key))
+	var id primitive.ObjectID
+	copy(id[:], sum[:])
+	return id
+}
+
+func (l *seed) Run(ctx context.Context) (*domain.SeedReport, error) {
+	l.report = &domain.SeedReport{}
+
+	users, err := l.users(ctx)
+	if err != nil {
+		return l.report, fmt.Errorf("seed users: %w", err)
+	}
+	deps, err := l.departments(ctx, users)
+	if err != nil {
+		return l.report, fmt.Errorf("seed departments: %w", err)
+	}
+	if err := l.todos(ctx, users); err != nil {
+		return l.report, fmt.Errorf("seed todos: %w", err)
+	}
+	if err := l.approvals(ctx, users); err != nil {
+		return l.report, fmt.Errorf("seed approvals: %w", err)
+	}
+	// 知识库依赖向量模型，失败时保留已写入的其他数据
+	if err := l.knowledge(ctx, users, deps); err != nil {
+		l.report.Errors = append(l.report.Errors, fmt.Sprintf("knowledge: %v", err))
+	}
+	return l.report, nil
+}
+
+func (l *seed) users(ctx context.Context) (map[string]*model.User, error) {
+	password, err := encrypt.GenPasswordHash([]byte(seedPassword))
+	if err != nil {
+		return nil, err
+	}
+
+	res := make(map[string]*model.User, len(seedUsers))
+	for _, u := range seedUsers {
+		// 已存在同名用户（包括手动创建的）时直接使用
+		if user, err := l.svcCtx.UserModel.FindByName(ctx, u.key); err == nil {
+			res[u.key] = user
+			continue
+		}
+		user := &model.User{
+			ID:       seedID("user", u.key),
+			Name:     u.key,
+			Password: string(password),
+			Email:    u.email,
+			Status:   1,
+			CreateAt: time.Now().Unix(),
+			UpdateAt: time.Now().Unix(),
+		}
+		if err := l.svcCtx.UserModel.Insert(ctx, user); err != nil {
+			return nil, err
+		}
+		res[u.key] = user
+		l.report.Users++
+	}
+	return res, nil
+}
+
+func (l *seed) departments(ctx context.Context, users map[string]*model.User) (map[string]string, error) {
+	ids := make(map[string]string, len(seedDeps))
+	for level, d := range seedDeps {
+		id := seedID("dep", d.key)
+		ids[d.key] = id.Hex()
+		if _, err := l.svcCtx.DepartmentModel.FindOne(ctx, id.Hex()); err == nil {
+			continue
+		}
+
+		dep := &model.Department{
+			ID:       id,
+			Name:     d.name,
+			Level:    min(level, 1) + 1,
+			LeaderId: users[d.leader].ID.Hex(),
+			Leader:   users[d.leader].Name,
+			Count:    int64(len(d.users)),
+			CreateAt: time.Now().Unix(),
+			UpdateAt: time.Now().Unix(),
+		}
+		if d.parent != "" {
+			dep.ParentId = ids[d.parent]
+			dep.ParentPath = ids[d.parent]
+		}
+		if err := l.svcCtx.DepartmentModel.Insert(ctx, dep); err != nil {
+			return nil, err
+		}
+		for _, key := range d.users {
+			err := l.svcCtx.DepartmentuserModel.Insert(ctx, &model.Departmentuser{
+				ID:       seedID("depuser", d.key+":"+key),
+				DepId:    id.Hex(),
+				UserId:   users[key].ID.Hex(),
+				CreateAt: time.Now().Unix(),
+				UpdateAt: time.Now().Unix(),
+			})
+			if err != nil {
+				return nil, err
+			}
+		}
+		l.report.Departments++
+	}
+	return ids, nil
+}
+
+func (l *seed) todos(ctx context.Context, users map[string]*model.User) error {
+	for _, t := range seedTodos {
+		id := seedID("todo", t.key)
+		if _, err := l.svcCtx.TodoModel.FindOne(ctx, id.Hex()); err == nil {
+			continue
+		}
+
+		executeIds := make([]string, 0, len(t.executors))
+		for _, key := range t.executors {
+			executeIds = append(executeIds, users[key].ID.Hex())
+		}
+		creator := users[t.creator]
+		todo := &model.Todo{
+			ID:          id,
+			CreatorId:   creator.ID.Hex(),
+			CreatorName: creator.Name,
+			Title:       t.title,
+			DeadlineAt:  time.Now().AddDate(0, 0, t.deadlineDays).Unix(),
+			Desc:        t.desc,
+			ExecuteIds:  executeIds,
+			CreateAt:    time.Now().Unix(),
+			UpdateAt:    time.Now().Unix(),
+		}
+		if err := l.svcCtx.TodoModel.Insert(ctx, todo); err != nil {
+			return err
+		}
+		for _, key := range t.executors {
+			err := l.svcCtx.UserTodoModel.Insert(ctx, &model.UserTodo{
+				ID:       seedID("usertodo", t.key+":"+key),
+				UserId:   users[key].ID.Hex(),
+				UserName: users[key].Name,
+				TodoId:   id.Hex(),
+				CreateAt: time.Now().Unix(),
+				UpdateAt: time.Now().Unix(),
+			})
+			if err != nil {
+				return err
+			}
+		}
+		l.report.Todos++
+	}
+	return nil
+}
+
+func (l *seed) approvals(ctx context.Context, users map[string]*model.User) error {
+	now := time.Now()
+	approver := func(key string) *model.Approver {
+		return &model.Approver{UserId: users[key].ID.Hex(), UserName: users[key].Name}
+	}
+
+	list := []*model.Approval{
+		{
+			ID:       seedID("approval", "leave"),
+			UserId:   users["wangwu"].ID.Hex(),
+			Type:     model.LeaveApproval,
+			Title:    model.Matter.ToString(),
+			Abstract: "事假1天",
+			Reason:   "家中有事",
+			Leave: &model.Leave{
+				Type:      model.Matter,
+				StartTime: now.AddDate(0, 0, 3).Unix(),
+				EndTime:   now.AddDate(0, 0, 4).Unix(),
+				Reason:    "家中有事",
+				TimeType:  model.DayTimeFormatType,
+			},
+			Approvers:   []*model.Approver{approver("lisi"), approver("zhangsan")},
+			CopyPersons: []*model.Approver{approver("sunqi")},
+		},
+		{
+			ID:       seedID("approval", "goout"),
+			UserId:   users["zhaoliu"].ID.Hex(),
+			Type:     model.GoOutApproval,
+			Title:    model.GoOutApproval.ToString(),
+			Abstract: "拜访客户",
+			Reason:   "拜访客户收集需求",
+			GoOut: &model.GoOut{
+				StartTime: now.AddDate(0, 0, 1).Unix(),
+				EndTime:   now.AddDate(0, 0, 1).Add(3 * time.Hour).Unix(),
+				Duration:  3,
+				Reason:    "拜访客户收集需求",
+			},
+			Approvers: []*model.Approver{approver("zhangsan")},
+		},
+	}
+
+	for i, a := range list {
+		if _, err := l.svcCtx.ApprovalModel.FindOne(ctx, a.ID.Hex()); err == nil {
+			continue
+		}
+		a.No = fmt.Sprintf("SP-SEED-%d", i+1)
+		a.Status = model.Processed
+		a.ApprovalId = a.Approvers[0].UserId
+		a.Participation = []string{a.UserId}
+		for _, p := range append(a.Approvers, a.CopyPersons...) {
+			a.Participation = append(a.Participation, p.UserId)
+		}
+		a.CreateAt, a.UpdateAt = now.Unix(), now.Unix()
+		if err := l.svcCtx.ApprovalModel.Insert(ctx, a); err != nil {
+			return err
+		}
+		l.report.Approvals++
+	}
+	return nil
+}
+
+// knowledge 文档写入上传目录后提交入库，内容未变化时不会重复入库
+func (l *seed) knowledge(ctx context.Context, users map[string]*model.User, deps map[string]string) error {
+	savePath := l.svcCtx.Config.Upload.SavePath
+	if savePath == "" {
+		savePath = "./uploads/"
+	}
+	dir := filepath.Join(savePath, "seed")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	knowledge := NewKnowledge(l.svcCtx)
+	for _, d := range seedDocs {
+		path := filepath.Join(dir, d.name)
+		if err := os.WriteFile(path, []byte(d.content), 0644); err != nil {
+			return err
+		}
+		uctx := context.WithValue(ctx, token.Identify, users[d.uploader].ID.Hex())
+		if _, err := knowledge.Submit(uctx, path, d.name, deps[d.dep]); err != nil {
+			return fmt.Errorf("%s: %w", d.name, err)
+		}
+		l.report.Documents++
+	}
+	return nil
+}
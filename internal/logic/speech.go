@@ -0,0 +1,67 @@
+package logic
+
+import (
+	"context"
+	"io"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"aiOffice/internal/domain"
+	"aiOffice/internal/svc"
+	"aiOffice/pkg/token"
+	"aiOffice/pkg/xerr"
+)
+
+var (
+	ErrSpeechDisabled = xerr.NewCode(xerr.Invalid, "未开启语音功能")
+	ErrAudioFormat    = xerr.NewCode(xerr.Invalid, "不支持的音频格式")
+	audioExts         = []string{".mp3", ".mp4", ".mpeg", ".mpga", ".m4a", ".wav", ".webm", ".ogg", ".flac"}
+)
+
+type Speech interface {
+	// 语音转文字
+	ASR(ctx context.Context, filename string, audio io.Reader) (*domain.ASRResp, error)
+	// 文字转语音，返回音频内容和Content-Type
+	TTS(ctx context.Context, req *domain.TTSReq) ([]byte, string, error)
+}
+
+type speech struct {
+	svcCtx *svc.ServiceContext
+}
+
+func NewSpeech(svcCtx *svc.ServiceContext) Speech {
+	return &speech{
+		svcCtx: svcCtx,
+	}
+}
+
+func (l *speech) ASR(ctx context.Context, filename string, audio io.Reader) (*domain.ASRResp, error) {
+	if l.svcCtx.Speech == nil {
+		return nil, ErrSpeechDisabled
+	}
+	if !slices.Contains(audioExts, strings.ToLower(filepath.Ext(filename))) {
+		return nil, ErrAudioFormat
+	}
+	// 语音接口同样按AI请求计入限流
+	if err := checkRateLimit(ctx, l.svcCtx, token.GetUid(ctx)); err != nil {
+		return nil, err
+	}
+
+	text, err := l.svcCtx.Speech.Transcribe(ctx, filepath.Base(filename), audio)
+	if err != nil {
+		return nil, err
+	}
+	return &domain.ASRResp{Text: text}, nil
+}
+
+func (l *speech) TTS(ctx context.Context, req *domain.TTSReq) ([]byte, string, error) {
+	if l.svcCtx.Speech == nil {
+		return nil, "", ErrSpeechDisabled
+	}
+	if err := checkRateLimit(ctx, l.svcCtx, token.GetUid(ctx)); err != nil {
+		return nil, "", err
+	}
+
+	return l.svcCtx.Speech.Synthesize(ctx, req.Text, req.Voice, req.Format)
+}
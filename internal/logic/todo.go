@@ -2,14 +2,25 @@ package logic
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"time"
 
 	"aiOffice/internal/domain"
 	"aiOffice/internal/model"
 	"aiOffice/internal/svc"
+	"aiOffice/pkg/mongoutils"
+	"aiOffice/pkg/notify"
+	"aiOffice/pkg/pagex"
+	"aiOffice/pkg/token"
 	"aiOffice/pkg/xerr"
 )
 
+// 新待办通知的消息类型
+const notifyTypeTodoAssigned = "todo:assigned"
+
+var ErrTodoForbidden = xerr.NewCode(xerr.Forbidden, "只有创建人可以修改待办")
+
 type Todo interface {
 	Info(ctx context.Context, req *domain.IdPathReq) (resp *domain.TodoInfoResp, err error)
 	Create(ctx context.Context, req *domain.Todo) (resp *domain.IdResp, err error)
@@ -34,7 +45,7 @@ func NewTodo(svcCtx *svc.ServiceContext) Todo {
 func (l *todo) Info(ctx context.Context, req *domain.IdPathReq) (resp *domain.TodoInfoResp, err error) {
 	todoData, err := l.svcCtx.TodoModel.FindOne(ctx, req.Id)
 	if err != nil {
-		if err == model.ErrNotFound {
+		if errors.Is(err, model.ErrNotFound) {
 			return nil, model.ErrTodoNotFound
 		}
 		return nil, xerr.WithMessage(err, "查询待办失败")
@@ -110,27 +121,50 @@ func (l *todo) Create(ctx context.Context, req *domain.Todo) (resp *domain.IdRes
 		}
 	}
 
-	err = l.svcCtx.TodoModel.Insert(ctx, todoData)
+	// 待办和执行人关联同时写入，避免部分失败留下孤立的关联
+	var notifyIds []string
+	err = mongoutils.WithTransaction(ctx, l.svcCtx.Mongo, func(ctx context.Context) error {
+		notifyIds = notifyIds[:0]
+		if err := l.svcCtx.TodoModel.Insert(ctx, todoData); err != nil {
+			return err
+		}
+
+		// 创建执行人关联
+		for _, userId := range req.ExecuteIds {
+			user, err := l.svcCtx.UserModel.FindOne(ctx, userId)
+			if err != nil {
+				continue
+			}
+
+			userTodo := &model.UserTodo{
+				UserId:     userId,
+				UserName:   user.Name,
+				TodoId:     todoData.ID.Hex(),
+				TodoStatus: 0,
+			}
+			if err := l.svcCtx.UserTodoModel.Insert(ctx, userTodo); err != nil {
+				return err
+			}
+			if userId != req.CreatorId {
+				notifyIds = append(notifyIds, userId)
+			}
+		}
+		return nil
+	})
 	if err != nil {
 		return nil, xerr.WithMessage(err, "创建待办失败")
 	}
 
 	todoId := todoData.ID.Hex()
 
-	// 创建执行人关联
-	for _, userId := range req.ExecuteIds {
-		user, err := l.svcCtx.UserModel.FindOne(ctx, userId)
-		if err != nil {
-			continue
-		}
-
-		userTodo := &model.UserTodo{
-			UserId:     userId,
-			UserName:   user.Name,
-			TodoId:     todoId,
-			TodoStatus: 0,
-		}
-		_ = l.svcCtx.UserTodoModel.Insert(ctx, userTodo)
+	// 提交后再通知执行人，一次分配多个待办时按用户聚合为一条
+	for _, userId := range notifyIds {
+		pushNotify(ctx, l.svcCtx, userId, &notify.Message{
+			Type:    notifyTypeTodoAssigned,
+			Title:   "新待办",
+			Content: fmt.Sprintf("%s 给您分配了待办「%s」", req.CreatorName, req.Title),
+			Data:    map[string]string{"todoId": todoId},
+		})
 	}
 
 	return &domain.IdResp{Id: todoId}, nil
@@ -140,11 +174,14 @@ func (l *todo) Create(ctx context.Context, req *domain.Todo) (resp *domain.IdRes
 func (l *todo) Edit(ctx context.Context, req *domain.Todo) (err error) {
 	todoData, err := l.svcCtx.TodoModel.FindOne(ctx, req.ID)
 	if err != nil {
-		if err == model.ErrNotFound {
+		if errors.Is(err, model.ErrNotFound) {
 			return model.ErrTodoNotFound
 		}
 		return xerr.WithMessage(err, "查询待办失败")
 	}
+	if todoData.CreatorId != token.GetUid(ctx) {
+		return ErrTodoForbidden
+	}
 
 	// 更新字段
 	if req.Title != "" {
@@ -194,18 +231,20 @@ func (l *todo) Edit(ctx context.Context, req *domain.Todo) (err error) {
 
 // Delete 删除待办
 func (l *todo) Delete(ctx context.Context, req *domain.IdPathReq) (err error) {
-	// 删除待办
-	err = l.svcCtx.TodoModel.Delete(ctx, req.Id)
+	// 待办、操作记录和执行人关联一起删除
+	err = mongoutils.WithTransaction(ctx, l.svcCtx.Mongo, func(ctx context.Context) error {
+		if err := l.svcCtx.TodoModel.Delete(ctx, req.Id); err != nil {
+			return err
+		}
+		if err := l.svcCtx.TodoRecordModel.DeleteByTodoId(ctx, req.Id); err != nil {
+			return err
+		}
+		return l.svcCtx.UserTodoModel.DeleteByTodoId(ctx, req.Id)
+	})
 	if err != nil {
 		return xerr.WithMessage(err, "删除待办失败")
 	}
 
-	// 删除操作记录
-	_ = l.svcCtx.TodoRecordModel.DeleteByTodoId(ctx, req.Id)
-
-	// 删除执行人关联
-	_ = l.svcCtx.UserTodoModel.DeleteByTodoId(ctx, req.Id)
-
 	return nil
 }
 
@@ -214,7 +253,7 @@ func (l *todo) Finish(ctx context.Context, req *domain.FinishedTodoReq) (err err
 	// 查询用户待办关联
 	userTodo, err := l.svcCtx.UserTodoModel.FindByUserIdAndTodoId(ctx, req.UserId, req.TodoId)
 	if err != nil {
-		if err == model.ErrNotFound {
+		if errors.Is(err, model.ErrNotFound) {
 			return model.ErrTodoNotFound
 		}
 		return xerr.WithMessage(err, "查询用户待办关联失败")
@@ -262,7 +301,7 @@ func (l *todo) CreateRecord(ctx context.Context, req *domain.TodoRecord) (err er
 	// 查询待办
 	todoData, err := l.svcCtx.TodoModel.FindOne(ctx, req.TodoId)
 	if err != nil {
-		if err == model.ErrNotFound {
+		if errors.Is(err, model.ErrNotFound) {
 			return model.ErrTodoNotFound
 		}
 		return xerr.WithMessage(err, "查询待办失败")
@@ -294,6 +333,7 @@ func (l *todo) CreateRecord(ctx context.Context, req *domain.TodoRecord) (err er
 func (l *todo) List(ctx context.Context, req *domain.TodoListReq) (resp *domain.TodoListResp, err error) {
 	var todos []*model.Todo
 	var total int64
+	page := pagex.FromRequest(req.Page, req.Count, req.Sort)
 
 	// 如果指定了用户ID，先查询用户关联的待办
 	if req.UserId != "" {
@@ -311,13 +351,12 @@ func (l *todo) List(ctx context.Context, req *domain.TodoListReq) (resp *domain.
 			todoIds = append(todoIds, ut.TodoId)
 		}
 
-		todos, err = l.svcCtx.TodoModel.FindByIds(ctx, todoIds)
+		todos, total, err = l.svcCtx.TodoModel.List(ctx, todoIds, req.StartTime, req.EndTime, page)
 		if err != nil {
 			return nil, xerr.WithMessage(err, "查询待办列表失败")
 		}
-		total = int64(len(todos))
 	} else {
-		todos, total, err = l.svcCtx.TodoModel.List(ctx, "", req.StartTime, req.EndTime, req.Page, req.Count)
+		todos, total, err = l.svcCtx.TodoModel.List(ctx, nil, req.StartTime, req.EndTime, page)
 		if err != nil {
 			return nil, xerr.WithMessage(err, "查询待办列表失败")
 		}
@@ -2,13 +2,16 @@ package logic
 
 import (
 	"context"
-	"errors"
+	"fmt"
 	"time"
 
 	"aiOffice/internal/domain"
 	"aiOffice/internal/model"
 	"aiOffice/internal/svc"
 	"aiOffice/pkg/encrypt"
+	"aiOffice/pkg/mailer"
+	"aiOffice/pkg/pagex"
+	"aiOffice/pkg/sms"
 	"aiOffice/pkg/token"
 	"aiOffice/pkg/xerr"
 )
@@ -28,6 +31,8 @@ type User interface {
 	List(ctx context.Context, req *domain.UserListReq) (resp *domain.UserListResp, err error)
 	// 更新用户密码
 	UpdatePassword(ctx context.Context, req *domain.UpdatePasswordReq) (err error)
+	// 退出登录，注销当前token或全部设备的token
+	Logout(ctx context.Context, req *domain.LogoutReq) (err error)
 }
 
 type user struct {
@@ -49,10 +54,11 @@ func (l *user) Login(ctx context.Context, req *domain.LoginReq) (resp *domain.Lo
 	}
 	// 验证密码
 	if !encrypt.ValidatePasswordHash(req.Password, (user.Password)) {
-		return nil, errors.New("密码错误")
+		return nil, xerr.NewCode(xerr.Unauthorized, "密码错误")
 	}
+	l.rehash(ctx, user, req.Password)
 	now := time.Now().Unix()
-	token, err := token.GetJwtToken(l.svcCtx.Config.Jwt.Secret, now, l.svcCtx.Config.Jwt.Expire, user.ID.Hex())
+	token, err := token.SignToken(l.svcCtx.JwtKeys[0], now, l.svcCtx.Config.Jwt.Expire, user.ID.Hex())
 	if err != nil {
 		return nil, xerr.WithMessagef(err, "GetToken Fail with %s", req.Name)
 	}
@@ -74,6 +80,8 @@ func (l *user) Info(ctx context.Context, req *domain.IdPathReq) (resp *domain.Us
 	return &domain.User{
 		Id:     user.ID.Hex(),
 		Name:   user.Name,
+		Email:  user.Email,
+		Phone:  user.Phone,
 		Status: user.Status,
 	}, nil
 }
@@ -83,7 +91,13 @@ func (l *user) Create(ctx context.Context, req *domain.User) (err error) {
 	// 检查用户名是否已存在
 	_, err = l.svcCtx.UserModel.FindByName(ctx, req.Name)
 	if err == nil {
-		return errors.New("用户名已存在")
+		return xerr.NewCode(xerr.Conflict, "用户名已存在")
+	}
+	if req.Email != "" && !mailer.ValidAddress(req.Email) {
+		return mailer.ErrBadAddress
+	}
+	if req.Phone != "" && !sms.ValidPhone(req.Phone) {
+		return sms.ErrBadPhone
 	}
 
 	// 密码加密
@@ -96,6 +110,8 @@ func (l *user) Create(ctx context.Context, req *domain.User) (err error) {
 	return l.svcCtx.UserModel.Insert(ctx, &model.User{
 		Name:     req.Name,
 		Password: string(hashedPassword),
+		Email:    req.Email,
+		Phone:    req.Phone,
 		Status:   req.Status,
 	})
 }
@@ -112,6 +128,18 @@ func (l *user) Edit(ctx context.Context, req *domain.User) (err error) {
 	if req.Name != "" {
 		user.Name = req.Name
 	}
+	if req.Email != "" {
+		if !mailer.ValidAddress(req.Email) {
+			return mailer.ErrBadAddress
+		}
+		user.Email = req.Email
+	}
+	if req.Phone != "" {
+		if !sms.ValidPhone(req.Phone) {
+			return sms.ErrBadPhone
+		}
+		user.Phone = req.Phone
+	}
 	if req.Status != 0 {
 		user.Status = req.Status
 	}
@@ -134,7 +162,7 @@ func (l *user) Delete(ctx context.Context, req *domain.IdPathReq) (err error) {
 
 // 分页查询用户
 func (l *user) List(ctx context.Context, req *domain.UserListReq) (resp *domain.UserListResp, err error) {
-	users, total, err := l.svcCtx.UserModel.List(ctx, req.Ids, req.Name, req.Page, req.Count)
+	users, total, err := l.svcCtx.UserModel.List(ctx, req.Ids, req.Name, pagex.FromRequest(req.Page, req.Count, req.Sort))
 	if err != nil {
 		return nil, err
 	}
@@ -145,6 +173,8 @@ func (l *user) List(ctx context.Context, req *domain.UserListReq) (resp *domain.
 		list = append(list, &domain.User{
 			Id:     user.ID.Hex(),
 			Name:   user.Name,
+			Email:  user.Email,
+			Phone:  user.Phone,
 			Status: user.Status,
 		})
 	}
@@ -165,7 +195,7 @@ func (l *user) UpdatePassword(ctx context.Context, req *domain.UpdatePasswordReq
 
 	// 验证旧密码
 	if !encrypt.ValidatePasswordHash(req.OldPwd, user.Password) {
-		return errors.New("原密码错误")
+		return xerr.NewCode(xerr.Invalid, "原密码错误")
 	}
 
 	// 加密新密码
@@ -178,3 +208,32 @@ func (l *user) UpdatePassword(ctx context.Context, req *domain.UpdatePasswordReq
 	user.Password = string(hashedPassword)
 	return l.svcCtx.UserModel.Update(ctx, user)
 }
+
+// 退出登录
+func (l *user) Logout(ctx context.Context, req *domain.LogoutReq) (err error) {
+	if err := l.svcCtx.TokenBlacklist.Revoke(ctx, token.GetClaims(ctx)); err != nil {
+		return xerr.WithMessage(err, "注销token失败")
+	}
+	if req.All {
+		ttl := time.Duration(l.svcCtx.Config.Jwt.Expire) * time.Second
+		if err := l.svcCtx.TokenBlacklist.RevokeUser(ctx, token.GetUid(ctx), ttl); err != nil {
+			return xerr.WithMessage(err, "注销全部设备失败")
+		}
+	}
+	return nil
+}
+
+// rehash 密码哈希的算法或参数与配置不一致时（如bcrypt迁移到argon2id）重新生成，失败不影响登录
+func (l *user) rehash(ctx context.Context, user *model.User, password string) {
+	if !encrypt.NeedsRehash(user.Password) {
+		return
+	}
+	hashed, err := encrypt.GenPasswordHash([]byte(password))
+	if err == nil {
+		user.Password = string(hashed)
+		err = l.svcCtx.UserModel.Update(ctx, user)
+	}
+	if err != nil {
+		fmt.Printf("[User] 重新生成密码哈希失败, uid: %s, err: %v\n", user.ID.Hex(), err)
+	}
+}
@@ -0,0 +1,583 @@
+package logic
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"aiOffice/internal/domain"
+	"aiOffice/internal/model"
+	"aiOffice/internal/svc"
+	"aiOffice/pkg/notify"
+	"aiOffice/pkg/pagex"
+	"aiOffice/pkg/timeutils"
+	"aiOffice/pkg/token"
+	"aiOffice/pkg/workflow"
+	"aiOffice/pkg/xerr"
+)
+
+var (
+	ErrWorkflowNotFound    = xerr.NewCode(xerr.NotFound, "流程不存在")
+	ErrWorkflowDisabled    = xerr.NewCode(xerr.Forbidden, "流程已停用")
+	ErrWorkflowKeyExists   = xerr.NewCode(xerr.Conflict, "流程标识已存在")
+	ErrWorkflowKeyChanged  = xerr.NewCode(xerr.Invalid, "流程标识不能修改")
+	ErrWorkflowApprover    = xerr.NewCode(xerr.Invalid, "审批人配置不正确，支持 leader、user:用户ID、dep:部门ID")
+	ErrWorkflowInstance    = xerr.NewCode(xerr.NotFound, "流程实例不存在")
+	ErrWorkflowFinished    = xerr.NewCode(xerr.Conflict, "流程已结束")
+	ErrWorkflowNotApprover = xerr.NewCode(xerr.Forbidden, "不是当前节点的审批人")
+	ErrWorkflowCancel      = xerr.NewCode(xerr.Forbidden, "只有申请人可以撤销")
+	ErrWorkflowAdmin       = xerr.NewCode(xerr.Forbidden, "需要管理员权限")
+)
+
+const (
+	workflowUpdateRetries   = 3 // 并发修改同一实例时的重试次数
+	workflowCallbackTimeout = 10 * time.Second
+)
+
+// 流程相关的消息类型
+const (
+	notifyTypeWorkflowTodo   = "workflow:todo"
+	notifyTypeWorkflowResult = "workflow:result"
+)
+
+// 审批人配置
+const (
+	approverLeader = "leader" // 申请人所在部门的负责人
+	approverUser   = "user:"  // 指定用户
+	approverDep    = "dep:"   // 指定部门的负责人
+)
+
+type Workflow interface {
+	// 新建流程定义
+	CreateDefinition(ctx context.Context, req *domain.WorkflowDefinition) (resp *domain.IdResp, err error)
+	// 修改流程定义，进行中的实例不受影响
+	EditDefinition(ctx context.Context, req *domain.WorkflowDefinition) (err error)
+	DefinitionInfo(ctx context.Context, req *domain.IdPathReq) (resp *domain.WorkflowDefinition, err error)
+	// 可发起的流程，管理员可查看全部
+	Definitions(ctx context.Context, req *domain.WorkflowDefinitionListReq) (resp *domain.WorkflowDefinitionListResp, err error)
+	// 发起流程
+	Start(ctx context.Context, req *domain.WorkflowStartReq) (resp *domain.IdResp, err error)
+	Info(ctx context.Context, req *domain.IdPathReq) (resp *domain.WorkflowInstance, err error)
+	// 处理当前节点（通过/拒绝）
+	Dispose(ctx context.Context, req *domain.WorkflowDisposeReq) (err error)
+	// 申请人撤销
+	Cancel(ctx context.Context, req *domain.IdPathReq) (err error)
+	List(ctx context.Context, req *domain.WorkflowListReq) (resp *domain.WorkflowListResp, err error)
+}
+
+type workflowLogic struct {
+	svcCtx *svc.ServiceContext
+}
+
+func NewWorkflow(svcCtx *svc.ServiceContext) Workflow {
+	return &workflowLogic{
+		svcCtx: svcCtx,
+	}
+}
+
+func (l *workflowLogic) CreateDefinition(ctx context.Context, req *domain.WorkflowDefinition) (resp *domain.IdResp, err error) {
+	if err := checkWorkflowDefinition(req.Flow); err != nil {
+		return nil, err
+	}
+	if _, err := l.svcCtx.WorkflowDefModel.FindByKey(ctx, req.Key); err == nil {
+		return nil, ErrWorkflowKeyExists
+	} else if !errors.Is(err, model.ErrNotFound) {
+		return nil, xerr.WithMessage(err, "查询流程定义失败")
+	}
+
+	data := &model.WorkflowDefinition{
+		Key:       req.Key,
+		Name:      req.Name,
+		Desc:      req.Desc,
+		Enabled:   req.Enabled,
+		Version:   1,
+		Flow:      *req.Flow,
+		Callbacks: toModelWorkflowCallbacks(req.Callbacks),
+		CreatorId: token.GetUid(ctx),
+	}
+	if err := l.svcCtx.WorkflowDefModel.Insert(ctx, data); err != nil {
+		return nil, xerr.WithMessage(err, "创建流程定义失败")
+	}
+	return &domain.IdResp{Id: data.ID.Hex()}, nil
+}
+
+func (l *workflowLogic) EditDefinition(ctx context.Context, req *domain.WorkflowDefinition) (err error) {
+	data, err := l.findDefinition(ctx, req.Id)
+	if err != nil {
+		return err
+	}
+	if data.Key != req.Key {
+		return ErrWorkflowKeyChanged
+	}
+	if err := checkWorkflowDefinition(req.Flow); err != nil {
+		return err
+	}
+
+	data.Name, data.Desc, data.Enabled = req.Name, req.Desc, req.Enabled
+	data.Flow, data.Callbacks = *req.Flow, toModelWorkflowCallbacks(req.Callbacks)
+	data.Version++
+	if err := l.svcCtx.WorkflowDefModel.Update(ctx, data); err != nil {
+		return xerr.WithMessage(err, "更新流程定义失败")
+	}
+	return nil
+}
+
+func (l *workflowLogic) DefinitionInfo(ctx context.Context, req *domain.IdPathReq) (resp *domain.WorkflowDefinition, err error) {
+	data, err := l.findDefinition(ctx, req.Id)
+	if err != nil {
+		return nil, err
+	}
+	return toDomainWorkflowDefinition(data), nil
+}
+
+func (l *workflowLogic) Definitions(ctx context.Context, req *domain.WorkflowDefinitionListReq) (resp *domain.WorkflowDefinitionListResp, err error) {
+	if req.All {
+		admin, err := l.isAdmin(ctx, token.GetUid(ctx))
+		if err != nil {
+			return nil, err
+		}
+		if !admin {
+			return nil, ErrWorkflowAdmin
+		}
+	}
+
+	list, err := l.svcCtx.WorkflowDefModel.List(ctx, !req.All)
+	if err != nil {
+		return nil, xerr.WithMessage(err, "查询流程定义失败")
+	}
+	resp = &domain.WorkflowDefinitionListResp{List: make([]*domain.WorkflowDefinition, 0, len(list))}
+	for _, v := range list {
+		resp.List = append(resp.List, toDomainWorkflowDefinition(v))
+	}
+	return resp, nil
+}
+
+// Start 按当前版本的流程定义发起，表单按定义中的字段校验
+func (l *workflowLogic) Start(ctx context.Context, req *domain.WorkflowStartReq) (resp *domain.IdResp, err error) {
+	uid := token.GetUid(ctx)
+	def, err := l.svcCtx.WorkflowDefModel.FindByKey(ctx, req.Key)
+	if err != nil {
+		if errors.Is(err, model.ErrNotFound) {
+			return nil, ErrWorkflowNotFound
+		}
+		return nil, xerr.WithMessage(err, "查询流程定义失败")
+	}
+	if !def.Enabled {
+		return nil, ErrWorkflowDisabled
+	}
+	if err := def.Flow.ValidateForm(req.Form); err != nil {
+		return nil, xerr.WithCode(err, xerr.Invalid)
+	}
+
+	data := &model.WorkflowInstance{
+		No:           fmt.Sprintf("WF%d", timeutils.Now()),
+		DefinitionId: def.ID.Hex(),
+		Key:          def.Key,
+		Name:         def.Name,
+		Version:      def.Version,
+		Flow:         def.Flow,
+		Callbacks:    def.Callbacks,
+		UserId:       uid,
+		Title:        req.Title,
+		Form:         req.Form,
+	}
+	if data.Title == "" {
+		data.Title = def.Name
+	}
+
+	engine, err := l.engine(ctx, data)
+	if err != nil {
+		return nil, err
+	}
+	inst, err := engine.Start(timeutils.Now())
+	if err != nil {
+		return nil, workflowError(err)
+	}
+	data.Instance = *inst
+	l.refresh(data)
+
+	if err := l.svcCtx.WorkflowInstanceModel.Insert(ctx, data); err != nil {
+		return nil, xerr.WithMessage(err, "发起流程失败")
+	}
+
+	go l.afterChange(data, nil, "")
+	return &domain.IdResp{Id: data.ID.Hex()}, nil
+}
+
+// Info 申请人、审批人和管理员可以查看
+func (l *workflowLogic) Info(ctx context.Context, req *domain.IdPathReq) (resp *domain.WorkflowInstance, err error) {
+	uid := token.GetUid(ctx)
+	data, err := l.findInstance(ctx, req.Id)
+	if err != nil {
+		return nil, err
+	}
+	if data.UserId != uid && !slices.Contains(data.Participation, uid) {
+		admin, err := l.isAdmin(ctx, uid)
+		if err != nil {
+			return nil, err
+		}
+		if !admin {
+			return nil, ErrWorkflowInstance
+		}
+	}
+
+	resp = toDomainWorkflowInstance(data)
+	resp.Form, resp.Tasks, resp.Records = data.Form, data.Tasks, data.Records
+	return resp, nil
+}
+
+func (l *workflowLogic) Dispose(ctx context.Context, req *domain.WorkflowDisposeReq) (err error) {
+	uid := token.GetUid(ctx)
+
+	var before []string
+	data, err := l.updateInstance(ctx, req.InstanceId, "更新流程失败", func(data *model.WorkflowInstance) error {
+		before = data.Pending
+
+		engine, err := l.engine(ctx, data)
+		if err != nil {
+			return err
+		}
+		pass := req.Status == int(workflow.Passed)
+		if err := engine.Act(&data.Instance, uid, pass, req.Reason, timeutils.Now()); err != nil {
+			return workflowError(err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	go l.afterChange(data, before, req.Reason)
+	return nil
+}
+
+func (l *workflowLogic) Cancel(ctx context.Context, req *domain.IdPathReq) (err error) {
+	uid := token.GetUid(ctx)
+	data, err := l.updateInstance(ctx, req.Id, "撤销流程失败", func(data *model.WorkflowInstance) error {
+		if data.UserId != uid {
+			return ErrWorkflowCancel
+		}
+		return workflowError(data.Instance.Cancel(uid, timeutils.Now()))
+	})
+	if err != nil {
+		return err
+	}
+
+	go l.callback(data)
+	return nil
+}
+
+// updateInstance 读取实例、修改后按revision比较写回；并行分支的审批人同时处理时，
+// 后写入的一方重新读取最新数据再处理，重试用尽返回冲突
+func (l *workflowLogic) updateInstance(ctx context.Context, id, msg string, fn func(data *model.WorkflowInstance) error) (*model.WorkflowInstance, error) {
+	for attempt := 0; ; attempt++ {
+		data, err := l.findInstance(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if err := fn(data); err != nil {
+			return nil, err
+		}
+		l.refresh(data)
+
+		err = l.svcCtx.WorkflowInstanceModel.Update(ctx, data)
+		if err == nil {
+			return data, nil
+		}
+		if !errors.Is(err, model.ErrUpdateConflict) {
+			return nil, xerr.WithMessage(err, msg)
+		}
+		if attempt >= workflowUpdateRetries {
+			return nil, err
+		}
+	}
+}
+
+func (l *workflowLogic) List(ctx context.Context, req *domain.WorkflowListReq) (resp *domain.WorkflowListResp, err error) {
+	list, total, err := l.svcCtx.WorkflowInstanceModel.List(ctx, token.GetUid(ctx), req.Type, req.Key, pagex.FromRequest(req.Page, req.Count, req.Sort))
+	if err != nil {
+		return nil, xerr.WithMessage(err, "查询流程列表失败")
+	}
+
+	resp = &domain.WorkflowListResp{Count: total, List: make([]*domain.WorkflowInstance, 0, len(list))}
+	for _, v := range list {
+		resp.List = append(resp.List, toDomainWorkflowInstance(v))
+	}
+	return resp, nil
+}
+
+// engine 按实例保存的定义快照创建，审批人按申请人解析
+func (l *workflowLogic) engine(ctx context.Context, data *model.WorkflowInstance) (*workflow.Engine, error) {
+	engine, err := workflow.New(&data.Flow, data.Form, func(node *workflow.Node) ([]string, error) {
+		return l.resolveApprovers(ctx, data.UserId, node.Approvers)
+	})
+	if err != nil {
+		return nil, workflowError(err)
+	}
+	return engine, nil
+}
+
+// resolveApprovers 解析审批人配置，申请人自己不作为审批人；找不到审批人的节点由引擎自动跳过
+func (l *workflowLogic) resolveApprovers(ctx context.Context, applicant string, specs []string) ([]string, error) {
+	var depIds, uids []string
+	for _, spec := range specs {
+		switch {
+		case spec == approverLeader:
+			ids, err := userDepIds(ctx, l.svcCtx, applicant)
+			if err != nil {
+				return nil, err
+			}
+			depIds = append(depIds, ids...)
+		case strings.HasPrefix(spec, approverUser):
+			uids = append(uids, strings.TrimPrefix(spec, approverUser))
+		case strings.HasPrefix(spec, approverDep):
+			depIds = append(depIds, strings.TrimPrefix(spec, approverDep))
+		default:
+			return nil, ErrWorkflowApprover
+		}
+	}
+
+	if len(depIds) > 0 {
+		deps, err := l.svcCtx.DepartmentModel.FindByIds(ctx, depIds)
+		if err != nil {
+			return nil, xerr.WithMessage(err, "查询部门负责人失败")
+		}
+		for _, dep := range deps {
+			uids = append(uids, dep.LeaderId)
+		}
+	}
+	return slices.DeleteFunc(uids, func(uid string) bool { return uid == applicant }), nil
+}
+
+// refresh 同步待处理人、参与人和完成时间，用于列表查询
+func (l *workflowLogic) refresh(data *model.WorkflowInstance) {
+	data.Pending = data.Instance.Pending()
+	for _, uid := range data.Pending {
+		if !slices.Contains(data.Participation, uid) {
+			data.Participation = append(data.Participation, uid)
+		}
+	}
+	if data.Status != workflow.Running && data.FinishAt == 0 {
+		data.FinishAt = timeutils.Now()
+	}
+}
+
+// afterChange 通知新的待处理人，流程结束时通知申请人并回调
+func (l *workflowLogic) afterChange(data *model.WorkflowInstance, before []string, reason string) {
+	ctx := context.Background()
+	for _, uid := range data.Pending {
+		if slices.Contains(before, uid) {
+			continue
+		}
+		pushNotify(ctx, l.svcCtx, uid, &notify.Message{
+			Type:    notifyTypeWorkflowTodo,
+			Title:   "待审批",
+			Content: fmt.Sprintf("「%s」等待您审批", data.Title),
+			Data:    map[string]string{"instanceId": data.ID.Hex()},
+		})
+	}
+
+	if data.Status != workflow.Passed && data.Status != workflow.Refused {
+		return
+	}
+	result := "已通过"
+	if data.Status == workflow.Refused {
+		result = "被拒绝"
+	}
+	content := fmt.Sprintf("您的申请「%s」%s", data.Title, result)
+	if reason != "" && data.Status == workflow.Refused {
+		content += "，审批意见：" + reason
+	}
+	pushNotify(ctx, l.svcCtx, data.UserId, &notify.Message{
+		Type:    notifyTypeWorkflowResult,
+		Title:   "审批结果",
+		Content: content,
+		Data:    map[string]string{"instanceId": data.ID.Hex()},
+		Urgent:  data.Status == workflow.Refused,
+	})
+	l.callback(data)
+}
+
+// callback 流程结束时按定义中的回调地址推送结果，失败只记录
+func (l *workflowLogic) callback(data *model.WorkflowInstance) {
+	event := workflowEvent(data.Status)
+	if event == "" {
+		return
+	}
+
+	payload := map[string]any{
+		"event":      event,
+		"instanceId": data.ID.Hex(),
+		"no":         data.No,
+		"key":        data.Key,
+		"userId":     data.UserId,
+		"title":      data.Title,
+		"form":       data.Form,
+		"records":    data.Records,
+		"finishAt":   data.FinishAt,
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return
+	}
+	for _, cb := range data.Callbacks {
+		if cb.Event != event {
+			continue
+		}
+		if err := l.postCallback(cb.Url, body); err != nil {
+			fmt.Printf("[Workflow] 回调失败, instance: %s, url: %s, err: %v\n", data.ID.Hex(), cb.Url, err)
+		}
+	}
+}
+
+// postCallback 配置了CallbackSecret时对请求体做 HMAC-SHA256 签名，接收方据此校验来源
+func (l *workflowLogic) postCallback(url string, body []byte) error {
+	ctx, cancel := context.WithTimeout(context.Background(), workflowCallbackTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if secret := l.svcCtx.Config.Workflow.CallbackSecret; secret != "" {
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write(body)
+		req.Header.Set("X-Signature", hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func (l *workflowLogic) findDefinition(ctx context.Context, id string) (*model.WorkflowDefinition, error) {
+	data, err := l.svcCtx.WorkflowDefModel.FindOne(ctx, id)
+	if err != nil {
+		if errors.Is(err, model.ErrNotFound) || errors.Is(err, model.ErrInvalidObjectId) {
+			return nil, ErrWorkflowNotFound
+		}
+		return nil, xerr.WithMessage(err, "查询流程定义失败")
+	}
+	return data, nil
+}
+
+func (l *workflowLogic) findInstance(ctx context.Context, id string) (*model.WorkflowInstance, error) {
+	data, err := l.svcCtx.WorkflowInstanceModel.FindOne(ctx, id)
+	if err != nil {
+		if errors.Is(err, model.ErrNotFound) || errors.Is(err, model.ErrInvalidObjectId) {
+			return nil, ErrWorkflowInstance
+		}
+		return nil, xerr.WithMessage(err, "查询流程失败")
+	}
+	return data, nil
+}
+
+func (l *workflowLogic) isAdmin(ctx context.Context, uid string) (bool, error) {
+	user, err := l.svcCtx.UserModel.FindOne(ctx, uid)
+	if err != nil {
+		return false, xerr.WithMessage(err, "查询用户失败")
+	}
+	return user.IsAdmin, nil
+}
+
+// checkWorkflowDefinition 校验节点图和审批人配置
+func checkWorkflowDefinition(def *workflow.Definition) error {
+	if err := def.Validate(); err != nil {
+		return xerr.WithCode(err, xerr.Invalid)
+	}
+	for _, n := range def.Nodes {
+		for _, spec := range n.Approvers {
+			valid := spec == approverLeader ||
+				(strings.HasPrefix(spec, approverUser) && len(spec) > len(approverUser)) ||
+				(strings.HasPrefix(spec, approverDep) && len(spec) > len(approverDep))
+			if !valid {
+				return ErrWorkflowApprover
+			}
+		}
+	}
+	return nil
+}
+
+// workflowError 将引擎的错误转换为业务错误
+func workflowError(err error) error {
+	switch {
+	case errors.Is(err, workflow.ErrFinished):
+		return ErrWorkflowFinished
+	case errors.Is(err, workflow.ErrNotApprover):
+		return ErrWorkflowNotApprover
+	case errors.Is(err, workflow.ErrInvalidDefinition), errors.Is(err, workflow.ErrNoBranch):
+		return xerr.WithCode(err, xerr.Invalid)
+	}
+	return err
+}
+
+func workflowEvent(status workflow.Status) string {
+	switch status {
+	case workflow.Passed:
+		return "pass"
+	case workflow.Refused:
+		return "refuse"
+	case workflow.Canceled:
+		return "cancel"
+	}
+	return ""
+}
+
+func toModelWorkflowCallbacks(list []*domain.WorkflowCallback) []*model.WorkflowCallback {
+	res := make([]*model.WorkflowCallback, 0, len(list))
+	for _, v := range list {
+		res = append(res, &model.WorkflowCallback{Event: v.Event, Url: v.Url})
+	}
+	return res
+}
+
+func toDomainWorkflowDefinition(data *model.WorkflowDefinition) *domain.WorkflowDefinition {
+	flow := data.Flow
+	res := &domain.WorkflowDefinition{
+		Id:       data.ID.Hex(),
+		Key:      data.Key,
+		Name:     data.Name,
+		Desc:     data.Desc,
+		Enabled:  data.Enabled,
+		Version:  data.Version,
+		Flow:     &flow,
+		UpdateAt: data.UpdateAt,
+		CreateAt: data.CreateAt,
+	}
+	for _, v := range data.Callbacks {
+		res.Callbacks = append(res.Callbacks, &domain.WorkflowCallback{Event: v.Event, Url: v.Url})
+	}
+	return res
+}
+
+func toDomainWorkflowInstance(data *model.WorkflowInstance) *domain.WorkflowInstance {
+	return &domain.WorkflowInstance{
+		Id:       data.ID.Hex(),
+		No:       data.No,
+		Key:      data.Key,
+		Name:     data.Name,
+		UserId:   data.UserId,
+		Title:    data.Title,
+		Status:   int(data.Status),
+		Pending:  data.Pending,
+		FinishAt: data.FinishAt,
+		UpdateAt: data.UpdateAt,
+		CreateAt: data.CreateAt,
+	}
+}
@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"context"
+
+	"aiOffice/pkg/httpx"
+	"aiOffice/pkg/token"
+	"aiOffice/pkg/xerr"
+
+	"github.com/gin-gonic/gin"
+)
+
+var ErrAdminRequired = xerr.NewCode(xerr.Forbidden, "需要管理员权限")
+
+// AdminChecker 查询用户是否为管理员
+type AdminChecker func(ctx context.Context, uid string) (bool, error)
+
+type Admin struct {
+	isAdmin AdminChecker
+}
+
+func NewAdmin(isAdmin AdminChecker) *Admin {
+	return &Admin{
+		isAdmin: isAdmin,
+	}
+}
+
+// 管理员中间件 需放在Jwt之后，每次请求重新查询用户，撤销管理员后立即生效
+func (m *Admin) Handler(ctx *gin.Context) {
+	ok, err := m.isAdmin(ctx.Request.Context(), token.GetUid(ctx.Request.Context()))
+	if err != nil {
+		httpx.FailWithErr(ctx, xerr.WithMessage(err, "查询用户失败"))
+		ctx.Abort()
+		return
+	}
+	if !ok {
+		httpx.FailWithErr(ctx, ErrAdminRequired)
+		ctx.Abort()
+		return
+	}
+	ctx.Next()
+}
@@ -0,0 +1,167 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+
+	"aiOffice/pkg/redact"
+	"aiOffice/pkg/requestid"
+	"aiOffice/pkg/token"
+
+	"github.com/gin-gonic/gin"
+)
+
+// auditMaxBody 记录的请求体上限，超过时不记录请求参数
+const auditMaxBody = 64 << 10
+
+// 请求体中作为资源id的字段，按顺序查找，不区分大小写
+var auditIdFields = []string{"id", "approvalid", "todoid"}
+
+// AuditEntry 一次变更操作，Before/After/Payload 为脱敏后的json
+type AuditEntry struct {
+	ActorId    string
+	Action     string // 方法和路由，如 DELETE /v1/todo/:id
+	Resource   string // 路由中版本后的第一段，如 todo
+	ResourceId string
+	Before     string // 变更前的数据
+	After      string // 变更后的数据，删除后为空
+	Payload    string // 请求参数
+	Ip         string
+	RequestId  string
+	Status     int // http状态码
+}
+
+// AuditRecorder 保存审计记录
+type AuditRecorder func(ctx context.Context, e *AuditEntry)
+
+// AuditLoader 按id加载资源，用于记录变更前后的数据
+type AuditLoader func(ctx context.Context, id string) (any, error)
+
+type Audit struct {
+	record  AuditRecorder
+	loaders map[string]AuditLoader
+	skip    map[string]bool
+}
+
+func NewAudit(record AuditRecorder, loaders map[string]AuditLoader) *Audit {
+	return &Audit{
+		record:  record,
+		loaders: loaders,
+		skip:    make(map[string]bool),
+	}
+}
+
+// Skip 不记录的路由，如查询类的POST接口，格式为 方法 路由
+func (m *Audit) Skip(actions ...string) *Audit {
+	for _, a := range actions {
+		m.skip[a] = true
+	}
+	return m
+}
+
+// 审计中间件 记录所有变更请求的操作人、资源和变更前后的数据
+func (m *Audit) Handler(ctx *gin.Context) {
+	action := ctx.Request.Method + " " + ctx.FullPath()
+	if ctx.Request.Method == http.MethodGet || ctx.Request.Method == http.MethodHead ||
+		ctx.Request.Method == http.MethodOptions || ctx.FullPath() == "" || m.skip[action] {
+		ctx.Next()
+		return
+	}
+
+	e := &AuditEntry{
+		Action:   action,
+		Resource: auditResource(ctx.FullPath()),
+		Ip:       ctx.ClientIP(),
+	}
+	var body map[string]any
+	e.Payload, body = m.payload(ctx)
+	e.ResourceId = ctx.Param("id")
+	if e.ResourceId == "" {
+		e.ResourceId = auditId(body)
+	}
+
+	load := m.loaders[e.Resource]
+	if load != nil && e.ResourceId != "" {
+		e.Before = auditLoad(ctx.Request.Context(), load, e.ResourceId)
+	}
+
+	ctx.Next()
+
+	// Jwt在路由分组中执行，处理完成后才能从ctx取到用户
+	reqCtx := ctx.Request.Context()
+	e.ActorId = token.GetUid(reqCtx)
+	e.RequestId = requestid.FromContext(reqCtx)
+	e.Status = ctx.Writer.Status()
+	if load != nil && e.ResourceId != "" && e.Status < http.StatusBadRequest {
+		e.After = auditLoad(reqCtx, load, e.ResourceId)
+	}
+	m.record(reqCtx, e)
+}
+
+// payload 读取json请求体并放回，返回脱敏后的内容
+func (m *Audit) payload(ctx *gin.Context) (string, map[string]any) {
+	if ctx.Request.Body == nil || !strings.HasPrefix(ctx.ContentType(), "application/json") {
+		return "", nil
+	}
+	buf, err := io.ReadAll(io.LimitReader(ctx.Request.Body, auditMaxBody+1))
+	ctx.Request.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(buf), ctx.Request.Body), ctx.Request.Body}
+	if err != nil || len(buf) > auditMaxBody {
+		return "", nil
+	}
+
+	var v any
+	if json.Unmarshal(buf, &v) != nil {
+		return "", nil
+	}
+	body, _ := v.(map[string]any)
+	return auditJson(v), body
+}
+
+// auditResource /v1/todo/:id -> todo
+func auditResource(path string) string {
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	if len(parts) < 2 {
+		return parts[0]
+	}
+	return parts[1]
+}
+
+func auditId(body map[string]any) string {
+	for _, field := range auditIdFields {
+		for k, v := range body {
+			if s, ok := v.(string); ok && s != "" && strings.EqualFold(k, field) {
+				return s
+			}
+		}
+	}
+	return ""
+}
+
+func auditLoad(ctx context.Context, load AuditLoader, id string) string {
+	v, err := load(ctx, id)
+	if err != nil || v == nil {
+		return ""
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return ""
+	}
+	var data any
+	if json.Unmarshal(b, &data) != nil {
+		return ""
+	}
+	return auditJson(data)
+}
+
+// auditJson 去掉密码、密钥等敏感字段后编码
+func auditJson(v any) string {
+	b, _ := json.Marshal(redact.Value(v, 0))
+	return string(b)
+}
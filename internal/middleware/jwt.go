@@ -1,29 +1,42 @@
 package middleware
 
 import (
+	"errors"
+
 	"aiOffice/pkg/httpx"
 	"aiOffice/pkg/token"
+	"aiOffice/pkg/xerr"
 
 	"github.com/gin-gonic/gin"
 )
 
 type Jwt struct {
 	tokenParse *token.Parse
+	blacklist  *token.Blacklist
 }
 
-func NewJwt(secrety string) *Jwt {
+// NewJwt keys 包含轮换期间仍然有效的全部密钥，blacklist 为已注销的token
+func NewJwt(keys []token.Key, blacklist *token.Blacklist) *Jwt {
 	return &Jwt{
-		tokenParse: token.NewTokenParse(secrety),
+		tokenParse: token.NewKeysParse(keys),
+		blacklist:  blacklist,
 	}
 }
 
 func (m *Jwt) Handler(ctx *gin.Context) {
-	r, err := m.tokenParse.ParseWithContext(ctx.Request)
+	claims, tokenStr, err := m.tokenParse.Parse(ctx.Request)
+	if err == nil {
+		err = m.blacklist.Check(ctx.Request.Context(), claims)
+	}
 	if err != nil {
+		// 已注销和解析失败返回401，黑名单查询失败返回500
+		if errors.Is(err, token.ErrTokenRevoked) || claims == nil {
+			err = xerr.WithCode(err, xerr.Unauthorized)
+		}
 		httpx.FailWithErr(ctx, err)
 		ctx.Abort()
 		return
 	}
-	ctx.Request = r
+	ctx.Request = ctx.Request.WithContext(token.NewContext(ctx.Request.Context(), claims, tokenStr))
 	ctx.Next()
 }
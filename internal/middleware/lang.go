@@ -0,0 +1,21 @@
+package middleware
+
+import (
+	"aiOffice/pkg/i18n"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Lang struct{}
+
+func NewLang() *Lang {
+	return &Lang{}
+}
+
+// 语言中间件 按 Accept-Language 选择错误信息和AI工具输出的语言
+func (m *Lang) Handler(ctx *gin.Context) {
+	lang := i18n.Parse(ctx.GetHeader("Accept-Language"))
+	ctx.Request = ctx.Request.WithContext(i18n.WithLang(ctx.Request.Context(), lang))
+	ctx.Header("Content-Language", string(lang))
+	ctx.Next()
+}
@@ -1,20 +1,38 @@
 package middleware
 
 import (
+	"bytes"
 	"fmt"
+	"io"
+	"net/http"
+	"strings"
 	"time"
 
+	"aiOffice/pkg/redact"
+	"aiOffice/pkg/requestid"
+	"aiOffice/pkg/token"
+
 	"gitee.com/dn-jinmin/tlog"
 	"github.com/gin-gonic/gin"
 )
 
-type Log struct{}
+const (
+	logMaxBody   = 16 << 10 // 记录的请求/响应内容上限，超过时只记录长度
+	logMaxString = 512      // 单个字段的长度上限
+)
+
+type Log struct {
+	body bool // 是否记录请求和响应内容
+}
 
-func NewLog() *Log {
-	return &Log{}
+// NewLog body 为true时记录脱敏后的请求参数和json响应，文件只记录文件名和大小
+func NewLog(body bool) *Log {
+	return &Log{
+		body: body,
+	}
 }
 
-// 日志中间件处理函数 为每个http请求生成链路追踪
+// 日志中间件处理函数 为每个http请求生成链路追踪，请求完成后记录状态、耗时和用户
 func (w *Log) Handler(ctx *gin.Context) {
 	//请求开始时间
 	startTime := time.Now()
@@ -22,10 +40,134 @@ func (w *Log) Handler(ctx *gin.Context) {
 	url := fmt.Sprintf("%s:%s", ctx.Request.URL.Path, ctx.Request.Method)
 	//启动链路
 	ctx.Request = ctx.Request.WithContext(tlog.TraceStart(ctx.Request.Context()))
-	defer func() {
-		// 记录请求完成日志和响应时间
-		tlog.InfoCtx(ctx.Request.Context(), url, "time", tlog.RTField(startTime, time.Now()))
-	}()
+
+	var body string
+	var resp *logWriter
+	if w.body {
+		body = logJsonBody(ctx)
+		resp = &logWriter{ResponseWriter: ctx.Writer}
+		ctx.Writer = resp
+	}
+
+	// 记录请求完成日志和响应时间
+	defer w.record(ctx, url, startTime, body, resp)
 	//继续执行后续中间件处理
 	ctx.Next()
 }
+
+func (w *Log) record(ctx *gin.Context, url string, startTime time.Time, body string, resp *logWriter) {
+	// Jwt在路由分组中执行，处理完成后才能从ctx取到用户
+	reqCtx := ctx.Request.Context()
+	fields := []any{
+		"method", ctx.Request.Method,
+		"route", ctx.FullPath(),
+		"status", ctx.Writer.Status(),
+		"size", ctx.Writer.Size(),
+		"ip", ctx.ClientIP(),
+		"uid", token.GetUid(reqCtx),
+		"time", tlog.RTField(startTime, time.Now()),
+	}
+	if w.body {
+		if query := redact.Values(ctx.Request.URL.Query(), logMaxString); query != nil {
+			fields = append(fields, "query", query)
+		}
+		if body == "" {
+			body = logFormBody(ctx)
+		}
+		if body != "" {
+			fields = append(fields, "req", body)
+		}
+		if out := resp.body(); out != "" {
+			fields = append(fields, "resp", out)
+		}
+	}
+	if len(ctx.Errors) > 0 {
+		fields = append(fields, "errors", ctx.Errors.String())
+	}
+
+	if ctx.Writer.Status() >= http.StatusInternalServerError {
+		tlog.ErrorCtx(reqCtx, url, requestid.Fields(reqCtx, fields...)...)
+	} else {
+		tlog.InfoCtx(reqCtx, url, requestid.Fields(reqCtx, fields...)...)
+	}
+}
+
+// logJsonBody 读取json请求体并放回，返回脱敏后的内容
+func logJsonBody(ctx *gin.Context) string {
+	if ctx.Request.Body == nil || !strings.HasPrefix(ctx.ContentType(), "application/json") {
+		return ""
+	}
+	buf, err := io.ReadAll(io.LimitReader(ctx.Request.Body, logMaxBody+1))
+	ctx.Request.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(buf), ctx.Request.Body), ctx.Request.Body}
+	if err != nil {
+		return ""
+	}
+	if len(buf) > logMaxBody {
+		return fmt.Sprintf("[more than %d bytes]", logMaxBody)
+	}
+	return redact.JSON(buf, logMaxString)
+}
+
+// logFormBody 记录处理过程中已解析的表单，文件只记录文件名和大小，不读取未解析的请求体
+func logFormBody(ctx *gin.Context) string {
+	form := map[string]any{}
+	if ctx.Request.MultipartForm != nil {
+		for k, v := range redact.Values(ctx.Request.MultipartForm.Value, logMaxString) {
+			form[k] = v
+		}
+		for k, files := range ctx.Request.MultipartForm.File {
+			list := make([]string, 0, len(files))
+			for _, f := range files {
+				list = append(list, fmt.Sprintf("%s(%d bytes)", f.Filename, f.Size))
+			}
+			form[k] = list
+		}
+	} else {
+		for k, v := range redact.Values(ctx.Request.PostForm, logMaxString) {
+			form[k] = v
+		}
+	}
+	if len(form) == 0 {
+		return ""
+	}
+	return fmt.Sprint(form)
+}
+
+// logWriter 记录json响应的内容，文件下载、SSE等其他响应只记录大小
+type logWriter struct {
+	gin.ResponseWriter
+	buf      bytes.Buffer
+	overflow bool
+}
+
+func (w *logWriter) Write(b []byte) (int, error) {
+	w.capture(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *logWriter) WriteString(s string) (int, error) {
+	w.capture([]byte(s))
+	return w.ResponseWriter.WriteString(s)
+}
+
+func (w *logWriter) capture(b []byte) {
+	if w.overflow || !strings.HasPrefix(w.Header().Get("Content-Type"), "application/json") {
+		return
+	}
+	if w.buf.Len()+len(b) > logMaxBody {
+		w.overflow = true
+		w.buf.Reset()
+		return
+	}
+	w.buf.Write(b)
+}
+
+func (w *logWriter) body() string {
+	if w.overflow {
+		return fmt.Sprintf("[more than %d bytes]", logMaxBody)
+	}
+	return redact.JSON(w.buf.Bytes(), logMaxString)
+}
@@ -0,0 +1,75 @@
+package middleware
+
+import (
+	"net/http"
+
+	"aiOffice/pkg/httpx"
+	"aiOffice/pkg/limiter"
+	"aiOffice/pkg/token"
+	"aiOffice/pkg/xerr"
+
+	"github.com/gin-gonic/gin"
+)
+
+var ErrRateLimited = xerr.NewCode(xerr.TooManyRequests, "请求过于频繁，请稍后再试")
+
+// RateLimitKey 限流的维度，返回空时不限流
+type RateLimitKey func(ctx *gin.Context) string
+
+// ByIP 按客户端IP限流，用于登录等未鉴权的接口
+func ByIP(ctx *gin.Context) string {
+	return "ip:" + ctx.ClientIP()
+}
+
+// ByUser 按用户限流，需在Jwt之后使用，未登录的请求不限流
+func ByUser(ctx *gin.Context) string {
+	uid := token.GetUid(ctx.Request.Context())
+	if uid == "" {
+		return ""
+	}
+	return "user:" + uid
+}
+
+type RateLimit struct {
+	limiter   *limiter.RedisLimiter
+	key       RateLimitKey
+	writeOnly bool
+}
+
+// NewRateLimit limiter 为nil时不限流
+func NewRateLimit(limiter *limiter.RedisLimiter, key RateLimitKey) *RateLimit {
+	return &RateLimit{
+		limiter: limiter,
+		key:     key,
+	}
+}
+
+// WriteOnly 只限制变更请求，查询请求不计数
+func (m *RateLimit) WriteOnly() *RateLimit {
+	m.writeOnly = true
+	return m
+}
+
+// 限流中间件 超出限制时返回429，并通过 Retry-After 告知客户端多久后重试
+func (m *RateLimit) Handler(ctx *gin.Context) {
+	if m.limiter == nil || (m.writeOnly && isRead(ctx.Request.Method)) {
+		ctx.Next()
+		return
+	}
+	key := m.key(ctx)
+	if key == "" {
+		ctx.Next()
+		return
+	}
+
+	if ok, after := m.limiter.Reserve(ctx.Request.Context(), key); !ok {
+		httpx.FailWithErr(ctx, xerr.WithRetryAfter(ErrRateLimited, after))
+		ctx.Abort()
+		return
+	}
+	ctx.Next()
+}
+
+func isRead(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions
+}
@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"net/http"
+
+	"aiOffice/pkg/httpx"
+	"aiOffice/pkg/panicx"
+	"aiOffice/pkg/xerr"
+
+	"github.com/gin-gonic/gin"
+)
+
+var ErrInternal = xerr.NewCode(xerr.Internal, "服务器内部错误")
+
+type Recover struct {
+	reporter *panicx.Reporter
+}
+
+func NewRecover(reporter *panicx.Reporter) *Recover {
+	return &Recover{
+		reporter: reporter,
+	}
+}
+
+// 恢复中间件 捕获处理请求时的panic并上报，返回统一格式的500响应
+func (m *Recover) Handler(ctx *gin.Context) {
+	defer func() {
+		v := recover()
+		if v == nil {
+			return
+		}
+		// http.ErrAbortHandler 用于主动中断响应，交给http.Server处理
+		if v == http.ErrAbortHandler {
+			panic(v)
+		}
+		m.reporter.Report(ctx.Request.Context(), panicx.SourceHttp, ctx.Request.Method+" "+ctx.FullPath(), v)
+		if !ctx.Writer.Written() {
+			httpx.FailWithErr(ctx, ErrInternal)
+		}
+		ctx.Abort()
+	}()
+	ctx.Next()
+}
@@ -0,0 +1,24 @@
+package middleware
+
+import (
+	"aiOffice/pkg/requestid"
+
+	"github.com/gin-gonic/gin"
+)
+
+type RequestId struct{}
+
+func NewRequestId() *RequestId {
+	return &RequestId{}
+}
+
+// 请求标识中间件 沿用客户端传入的 X-Request-Id，没有或格式不合法时生成，写入ctx和响应头
+func (m *RequestId) Handler(ctx *gin.Context) {
+	id := ctx.GetHeader(requestid.Header)
+	if !requestid.Valid(id) {
+		id = requestid.New()
+	}
+	ctx.Request = ctx.Request.WithContext(requestid.WithContext(ctx.Request.Context(), id))
+	ctx.Header(requestid.Header, id)
+	ctx.Next()
+}
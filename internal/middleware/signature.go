@@ -0,0 +1,75 @@
+package middleware
+
+import (
+	"bytes"
+	"errors"
+	"io"
+
+	"aiOffice/pkg/httpx"
+	"aiOffice/pkg/token"
+	"aiOffice/pkg/xerr"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt"
+)
+
+const signMaxBody = 1 << 20 // 签名请求的请求体上限
+
+var ErrSignBodyTooLarge = xerr.NewCode(xerr.Invalid, "请求体过大")
+
+// Signature 服务间调用的签名认证，作为Jwt的替代：请求带 X-App-Key 时校验签名，以应用绑定的用户身份执行，否则按Jwt认证
+type Signature struct {
+	sign *token.Signature
+	jwt  *Jwt
+}
+
+func NewSignature(sign *token.Signature, jwt *Jwt) *Signature {
+	return &Signature{
+		sign: sign,
+		jwt:  jwt,
+	}
+}
+
+func (m *Signature) Handler(ctx *gin.Context) {
+	if !m.sign.Enabled() || ctx.GetHeader(token.HeaderAppKey) == "" {
+		m.jwt.Handler(ctx)
+		return
+	}
+
+	body, err := signBody(ctx)
+	if err != nil {
+		httpx.FailWithErr(ctx, err)
+		ctx.Abort()
+		return
+	}
+	app, err := m.sign.Verify(ctx.Request.Context(), ctx.Request, body)
+	if err != nil {
+		// 签名不正确返回401，nonce记录失败返回500
+		if errors.Is(err, token.ErrSignature) {
+			err = xerr.WithCode(err, xerr.Unauthorized)
+		}
+		httpx.FailWithErr(ctx, err)
+		ctx.Abort()
+		return
+	}
+
+	claims := jwt.MapClaims{token.Identify: app.UserId, "appKey": app.Key}
+	ctx.Request = ctx.Request.WithContext(token.NewContext(ctx.Request.Context(), claims, ""))
+	ctx.Next()
+}
+
+// signBody 读取请求体用于校验签名，读取后放回供后续绑定参数
+func signBody(ctx *gin.Context) ([]byte, error) {
+	if ctx.Request.Body == nil {
+		return nil, nil
+	}
+	body, err := io.ReadAll(io.LimitReader(ctx.Request.Body, signMaxBody+1))
+	if err != nil {
+		return nil, xerr.WithCode(err, xerr.Invalid)
+	}
+	if len(body) > signMaxBody {
+		return nil, ErrSignBodyTooLarge
+	}
+	ctx.Request.Body = io.NopCloser(bytes.NewReader(body))
+	return body, nil
+}
@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"fmt"
+
+	"aiOffice/pkg/requestid"
+	"aiOffice/pkg/tracex"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Trace struct{}
+
+func NewTrace() *Trace {
+	return &Trace{}
+}
+
+// 链路追踪中间件 为每个http请求记录跨度，沿用上游的traceparent
+func (m *Trace) Handler(ctx *gin.Context) {
+	route := ctx.FullPath()
+	if route == "" {
+		route = "unknown"
+	}
+	c := tracex.Extract(ctx.Request.Context(), ctx.Request.Header)
+	c, span := tracex.Start(c, ctx.Request.Method+" "+route, tracex.KindServer)
+	defer span.End()
+
+	span.SetAttr("http.method", ctx.Request.Method)
+	span.SetAttr("http.route", route)
+	if id := requestid.FromContext(c); id != "" {
+		span.SetAttr(requestid.Field, id)
+	}
+	ctx.Request = ctx.Request.WithContext(c)
+	ctx.Next()
+
+	status := ctx.Writer.Status()
+	span.SetAttr("http.status_code", status)
+	if status >= 500 {
+		span.SetError(fmt.Errorf("http status %d", status))
+	}
+}
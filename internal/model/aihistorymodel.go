@@ -0,0 +1,118 @@
+package model
+
+import (
+	"context"
+	"time"
+
+	"aiOffice/pkg/pagex"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type AIHistoryModel interface {
+	Insert(ctx context.Context, data *AIHistory) error
+	List(ctx context.Context, userId string, page pagex.Page) ([]*AIHistory, int64, error)
+	DeleteByUserId(ctx context.Context, userId string) error
+}
+
+type defaultAIHistoryModel struct {
+	col    *mongo.Collection
+	cipher MsgCipher
+}
+
+// NewAIHistoryModel 对话内容与聊天记录使用同一个加密器，cipher 为 nil 时明文存储
+func NewAIHistoryModel(db *mongo.Database, cipher MsgCipher) AIHistoryModel {
+	col := db.Collection("ai_history")
+	return &defaultAIHistoryModel{
+		col:    col,
+		cipher: cipher,
+	}
+}
+
+func (m *defaultAIHistoryModel) Insert(ctx context.Context, data *AIHistory) error {
+	if data.ID.IsZero() {
+		data.ID = primitive.NewObjectID()
+		data.CreateAt = time.Now().Unix()
+	}
+
+	doc, err := m.encrypt(data)
+	if err != nil {
+		return err
+	}
+	_, err = m.col.InsertOne(ctx, doc)
+	return err
+}
+
+// List 按时间倒序分页查询
+func (m *defaultAIHistoryModel) List(ctx context.Context, userId string, page pagex.Page) ([]*AIHistory, int64, error) {
+	filter := bson.M{"userId": userId}
+
+	total, err := m.col.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var list []*AIHistory
+	if err := entityList(ctx, m.col, filter, &list, page.Options("-_id")); err != nil {
+		return nil, 0, err
+	}
+	for _, v := range list {
+		if err := m.decrypt(v); err != nil {
+			return nil, 0, err
+		}
+	}
+	return list, total, nil
+}
+
+func (m *defaultAIHistoryModel) DeleteByUserId(ctx context.Context, userId string) error {
+	_, err := m.col.DeleteMany(ctx, bson.M{"userId": userId})
+	return err
+}
+
+// encrypt 返回加密后的副本，不修改调用方持有的明文
+func (m *defaultAIHistoryModel) encrypt(data *AIHistory) (*AIHistory, error) {
+	if m.cipher == nil {
+		return data, nil
+	}
+
+	doc := *data
+	if err := m.crypt(&doc, m.cipher.Encrypt); err != nil {
+		return nil, err
+	}
+	return &doc, nil
+}
+
+// decrypt 读取时透明解密
+func (m *defaultAIHistoryModel) decrypt(data *AIHistory) error {
+	if m.cipher == nil {
+		return nil
+	}
+	return m.crypt(data, m.cipher.Decrypt)
+}
+
+// crypt 对用户输入、回复和工具调用的输入输出做加密或解密，工具调用复制后再修改
+func (m *defaultAIHistoryModel) crypt(data *AIHistory, fn func(string) (string, error)) error {
+	fields := []*string{&data.Prompt, &data.Answer}
+
+	calls := make([]*AIToolCall, 0, len(data.ToolCalls))
+	for _, v := range data.ToolCalls {
+		c := *v
+		calls = append(calls, &c)
+		fields = append(fields, &c.Input, &c.Output)
+	}
+	data.ToolCalls = calls
+
+	for _, f := range fields {
+		if *f == "" {
+			continue
+		}
+		v, err := fn(*f)
+		if err != nil {
+			return err
+		}
+		*f = v
+	}
+	return nil
+}
@@ -0,0 +1,29 @@
+package model
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// AIHistory 一轮AI对话：用户输入、选择的handler、工具调用和最终回复
+type AIHistory struct {
+	ID primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+
+	UserId    string        `bson:"userId" json:"userId"`
+	Prompt    string        `bson:"prompt" json:"prompt"`                           // 用户输入
+	Handler   string        `bson:"handler,omitempty" json:"handler,omitempty"`     // 路由选择的handler
+	ToolCalls []*AIToolCall `bson:"toolCalls,omitempty" json:"toolCalls,omitempty"` // 工具调用
+	Answer    string        `bson:"answer,omitempty" json:"answer,omitempty"`       // 最终回复
+	Error     string        `bson:"error,omitempty" json:"error,omitempty"`         // 失败原因
+	Duration  int64         `bson:"duration,omitempty" json:"duration,omitempty"`   // 耗时（毫秒）
+
+	CreateAt int64 `bson:"createAt,omitempty" json:"createAt,omitempty"`
+}
+
+// AIToolCall 工具调用记录
+type AIToolCall struct {
+	Name     string `bson:"name" json:"name"`
+	Input    string `bson:"input,omitempty" json:"input,omitempty"`
+	Output   string `bson:"output,omitempty" json:"output,omitempty"`
+	Error    string `bson:"error,omitempty" json:"error,omitempty"`
+	Duration int64  `bson:"duration,omitempty" json:"duration,omitempty"` // 耗时（毫秒）
+}
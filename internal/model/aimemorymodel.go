@@ -0,0 +1,85 @@
+package model
+
+import (
+	"context"
+	"time"
+
+	"github.com/tmc/langchaingo/llms"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type AIMemoryModel interface {
+	Append(ctx context.Context, sessionId string, msgs ...llms.ChatMessage) error
+	List(ctx context.Context, sessionId string, limit int) ([]llms.ChatMessage, error)
+	Clear(ctx context.Context, sessionId string) error
+}
+
+type defaultAIMemoryModel struct {
+	col *mongo.Collection
+}
+
+func NewAIMemoryModel(db *mongo.Database) AIMemoryModel {
+	col := db.Collection("ai_memory")
+	return &defaultAIMemoryModel{
+		col: col,
+	}
+}
+
+func (m *defaultAIMemoryModel) Append(ctx context.Context, sessionId string, msgs ...llms.ChatMessage) error {
+	if len(msgs) == 0 {
+		return nil
+	}
+
+	now := time.Now().Unix()
+	docs := make([]interface{}, 0, len(msgs))
+	for _, msg := range msgs {
+		docs = append(docs, &AIMemory{
+			SessionId: sessionId,
+			Type:      string(msg.GetType()),
+			Content:   msg.GetContent(),
+			CreateAt:  now,
+		})
+	}
+
+	_, err := m.col.InsertMany(ctx, docs, options.InsertMany().SetOrdered(true))
+	return err
+}
+
+// List 返回最近的limit条消息（按时间正序），limit<=0时返回全部
+func (m *defaultAIMemoryModel) List(ctx context.Context, sessionId string, limit int) ([]llms.ChatMessage, error) {
+	opt := options.Find().SetSort(bson.M{"_id": -1})
+	if limit > 0 {
+		opt.SetLimit(int64(limit))
+	}
+
+	var list []*AIMemory
+	if err := entityList(ctx, m.col, bson.M{"sessionId": sessionId}, &list, opt); err != nil {
+		return nil, err
+	}
+
+	msgs := make([]llms.ChatMessage, 0, len(list))
+	for i := len(list) - 1; i >= 0; i-- {
+		msgs = append(msgs, list[i].chatMessage())
+	}
+	return msgs, nil
+}
+
+func (m *defaultAIMemoryModel) Clear(ctx context.Context, sessionId string) error {
+	_, err := m.col.DeleteMany(ctx, bson.M{"sessionId": sessionId})
+	return err
+}
+
+func (a *AIMemory) chatMessage() llms.ChatMessage {
+	switch llms.ChatMessageType(a.Type) {
+	case llms.ChatMessageTypeAI:
+		return llms.AIChatMessage{Content: a.Content}
+	case llms.ChatMessageTypeSystem:
+		return llms.SystemChatMessage{Content: a.Content}
+	case llms.ChatMessageTypeHuman:
+		return llms.HumanChatMessage{Content: a.Content}
+	default:
+		return llms.GenericChatMessage{Role: a.Type, Content: a.Content}
+	}
+}
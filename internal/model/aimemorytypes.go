@@ -0,0 +1,16 @@
+package model
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// AIMemory AI对话记忆，每条消息一个文档，按_id排序还原对话顺序
+type AIMemory struct {
+	ID primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+
+	SessionId string `bson:"sessionId" json:"sessionId"` // 会话标识（用户+会话）
+	Type      string `bson:"type" json:"type"`           // 消息类型 human/ai/system
+	Content   string `bson:"content" json:"content"`     // 消息内容
+
+	CreateAt int64 `bson:"createAt,omitempty" json:"createAt,omitempty"`
+}
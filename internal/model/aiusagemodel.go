@@ -0,0 +1,69 @@
+package model
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type AIUsageModel interface {
+	Incr(ctx context.Context, userId, date string, promptTokens, completionTokens int64) error
+	FindOne(ctx context.Context, userId, date string) (*AIUsage, error)
+	List(ctx context.Context, userId, startDate, endDate string) ([]*AIUsage, error)
+}
+
+type defaultAIUsageModel struct {
+	col *mongo.Collection
+}
+
+func NewAIUsageModel(db *mongo.Database) AIUsageModel {
+	col := db.Collection("ai_usage")
+	return &defaultAIUsageModel{
+		col: col,
+	}
+}
+
+// Incr 累加用户当天的token用量
+func (m *defaultAIUsageModel) Incr(ctx context.Context, userId, date string, promptTokens, completionTokens int64) error {
+	now := time.Now().Unix()
+	return entityUpdateOrInsert(ctx, m.col, bson.M{"userId": userId, "date": date}, bson.M{
+		"$inc": bson.M{
+			"promptTokens":     promptTokens,
+			"completionTokens": completionTokens,
+			"totalTokens":      promptTokens + completionTokens,
+			"calls":            1,
+		},
+		"$set":         bson.M{"updateAt": now},
+		"$setOnInsert": bson.M{"createAt": now},
+	})
+}
+
+// FindOne 没有记录时返回空用量
+func (m *defaultAIUsageModel) FindOne(ctx context.Context, userId, date string) (*AIUsage, error) {
+	var data AIUsage
+	err := m.col.FindOne(ctx, bson.M{"userId": userId, "date": date}).Decode(&data)
+	switch err {
+	case nil:
+		return &data, nil
+	case mongo.ErrNoDocuments:
+		return &AIUsage{UserId: userId, Date: date}, nil
+	default:
+		return nil, err
+	}
+}
+
+// List 按日期倒序返回 [startDate, endDate] 的用量
+func (m *defaultAIUsageModel) List(ctx context.Context, userId, startDate, endDate string) ([]*AIUsage, error) {
+	var list []*AIUsage
+	filter := bson.M{
+		"userId": userId,
+		"date":   bson.M{"$gte": startDate, "$lte": endDate},
+	}
+	if err := entityList(ctx, m.col, filter, &list, options.Find().SetSort(bson.M{"date": -1})); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
@@ -0,0 +1,20 @@
+package model
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// AIUsage 用户每日的LLM token用量
+type AIUsage struct {
+	ID primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+
+	UserId           string `bson:"userId" json:"userId"`
+	Date             string `bson:"date" json:"date"` // 日期 2006-01-02
+	PromptTokens     int64  `bson:"promptTokens" json:"promptTokens"`
+	CompletionTokens int64  `bson:"completionTokens" json:"completionTokens"`
+	TotalTokens      int64  `bson:"totalTokens" json:"totalTokens"`
+	Calls            int64  `bson:"calls" json:"calls"` // LLM调用次数
+
+	UpdateAt int64 `bson:"updateAt,omitempty" json:"updateAt,omitempty"`
+	CreateAt int64 `bson:"createAt,omitempty" json:"createAt,omitempty"`
+}
@@ -0,0 +1,149 @@
+package model
+
+import (
+	"context"
+	"time"
+
+	"aiOffice/pkg/pagex"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type AnnouncementModel interface {
+	Insert(ctx context.Context, data *Announcement) error
+	FindOne(ctx context.Context, id string) (*Announcement, error)
+	Update(ctx context.Context, data *Announcement) error
+	Delete(ctx context.Context, id string) error
+	List(ctx context.Context, page pagex.Page) ([]*Announcement, int64, error)
+	ListVisible(ctx context.Context, depIds []string, now int64, page pagex.Page) ([]*Announcement, int64, error)
+	FindUndelivered(ctx context.Context, now int64, limit int) ([]*Announcement, error)
+	SetDelivered(ctx context.Context, id primitive.ObjectID) (bool, error)
+	ResetDelivered(ctx context.Context, id primitive.ObjectID) error
+}
+
+type defaultAnnouncementModel struct {
+	col *mongo.Collection
+}
+
+func NewAnnouncementModel(db *mongo.Database) AnnouncementModel {
+	col := db.Collection("announcement")
+	return &defaultAnnouncementModel{
+		col: col,
+	}
+}
+
+// 置顶在前，再按发布时间倒序
+var announcementSort = bson.D{{Key: "pinned", Value: -1}, {Key: "publishAt", Value: -1}, {Key: "_id", Value: -1}}
+
+func (m *defaultAnnouncementModel) Insert(ctx context.Context, data *Announcement) error {
+	if data.ID.IsZero() {
+		data.ID = primitive.NewObjectID()
+		data.CreateAt = time.Now().Unix()
+		data.UpdateAt = time.Now().Unix()
+	}
+
+	_, err := m.col.InsertOne(ctx, data)
+	return err
+}
+
+func (m *defaultAnnouncementModel) FindOne(ctx context.Context, id string) (*Announcement, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, ErrInvalidObjectId
+	}
+
+	var data Announcement
+	err = m.col.FindOne(ctx, bson.M{"_id": oid}).Decode(&data)
+	switch err {
+	case nil:
+		return &data, nil
+	case mongo.ErrNoDocuments:
+		return nil, ErrNotFound
+	default:
+		return nil, err
+	}
+}
+
+// Update 整体替换，清空目标部门、过期时间时同样生效
+func (m *defaultAnnouncementModel) Update(ctx context.Context, data *Announcement) error {
+	data.UpdateAt = time.Now().Unix()
+	_, err := m.col.ReplaceOne(ctx, bson.M{"_id": data.ID}, data)
+	return err
+}
+
+func (m *defaultAnnouncementModel) Delete(ctx context.Context, id string) error {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return ErrInvalidObjectId
+	}
+	_, err = m.col.DeleteOne(ctx, bson.M{"_id": oid})
+	return err
+}
+
+// List 全部公告，含未到发布时间和已过期的，供管理员查看
+func (m *defaultAnnouncementModel) List(ctx context.Context, page pagex.Page) ([]*Announcement, int64, error) {
+	return m.list(ctx, bson.M{}, page)
+}
+
+// ListVisible 已发布、未过期，且发给全员或用户所在部门的公告
+func (m *defaultAnnouncementModel) ListVisible(ctx context.Context, depIds []string, now int64, page pagex.Page) ([]*Announcement, int64, error) {
+	target := bson.A{bson.M{"depIds": bson.M{"$exists": false}}}
+	if len(depIds) > 0 {
+		target = append(target, bson.M{"depIds": bson.M{"$in": depIds}})
+	}
+	filter := bson.M{
+		"publishAt": bson.M{"$lte": now},
+		"$and": bson.A{
+			bson.M{"$or": bson.A{bson.M{"expireAt": bson.M{"$exists": false}}, bson.M{"expireAt": bson.M{"$gt": now}}}},
+			bson.M{"$or": target},
+		},
+	}
+	return m.list(ctx, filter, page)
+}
+
+func (m *defaultAnnouncementModel) list(ctx context.Context, filter bson.M, page pagex.Page) ([]*Announcement, int64, error) {
+	total, err := m.col.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var list []*Announcement
+	opt := options.Find().SetSkip(page.Skip()).SetLimit(page.Limit()).SetSort(announcementSort)
+	if err := entityList(ctx, m.col, filter, &list, opt); err != nil {
+		return nil, 0, err
+	}
+	return list, total, nil
+}
+
+// FindUndelivered 发布时间已到、还未推送且未过期的公告
+func (m *defaultAnnouncementModel) FindUndelivered(ctx context.Context, now int64, limit int) ([]*Announcement, error) {
+	filter := bson.M{
+		"delivered": bson.M{"$ne": true},
+		"publishAt": bson.M{"$lte": now},
+		"$or":       bson.A{bson.M{"expireAt": bson.M{"$exists": false}}, bson.M{"expireAt": bson.M{"$gt": now}}},
+	}
+
+	var list []*Announcement
+	if err := entityList(ctx, m.col, filter, &list, options.Find().SetSort(bson.M{"publishAt": 1}).SetLimit(int64(limit))); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// SetDelivered 标记为已推送，多个实例同时处理时只有一个成功
+func (m *defaultAnnouncementModel) SetDelivered(ctx context.Context, id primitive.ObjectID) (bool, error) {
+	res, err := m.col.UpdateOne(ctx, bson.M{"_id": id, "delivered": bson.M{"$ne": true}}, bson.M{"$set": bson.M{"delivered": true}})
+	if err != nil {
+		return false, err
+	}
+	return res.ModifiedCount > 0, nil
+}
+
+// ResetDelivered 推送失败时取消已推送标记，下次检查时重新推送
+func (m *defaultAnnouncementModel) ResetDelivered(ctx context.Context, id primitive.ObjectID) error {
+	_, err := m.col.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{"delivered": false}})
+	return err
+}
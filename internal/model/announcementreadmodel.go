@@ -0,0 +1,62 @@
+package model
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type AnnouncementReadModel interface {
+	MarkRead(ctx context.Context, announcementId, userId string) error
+	FindRead(ctx context.Context, userId string, announcementIds []string) (map[string]bool, error)
+	Count(ctx context.Context, announcementId string) (int64, error)
+	DeleteByAnnouncementId(ctx context.Context, announcementId string) error
+}
+
+type defaultAnnouncementReadModel struct {
+	col *mongo.Collection
+}
+
+func NewAnnouncementReadModel(db *mongo.Database) AnnouncementReadModel {
+	col := db.Collection("announcement_read")
+	return &defaultAnnouncementReadModel{
+		col: col,
+	}
+}
+
+// MarkRead 记录已读，重复阅读保留第一次的时间
+func (m *defaultAnnouncementReadModel) MarkRead(ctx context.Context, announcementId, userId string) error {
+	filter := bson.M{"announcementId": announcementId, "userId": userId}
+	update := bson.M{"$setOnInsert": bson.M{"readAt": time.Now().Unix()}}
+	return entityUpdateOrInsert(ctx, m.col, filter, update)
+}
+
+// FindRead 用户已读的公告
+func (m *defaultAnnouncementReadModel) FindRead(ctx context.Context, userId string, announcementIds []string) (map[string]bool, error) {
+	res := make(map[string]bool)
+	if len(announcementIds) == 0 {
+		return res, nil
+	}
+
+	var list []*AnnouncementRead
+	filter := bson.M{"userId": userId, "announcementId": bson.M{"$in": announcementIds}}
+	if err := entityList(ctx, m.col, filter, &list); err != nil {
+		return nil, err
+	}
+	for _, v := range list {
+		res[v.AnnouncementId] = true
+	}
+	return res, nil
+}
+
+// Count 公告的已读人数
+func (m *defaultAnnouncementReadModel) Count(ctx context.Context, announcementId string) (int64, error) {
+	return m.col.CountDocuments(ctx, bson.M{"announcementId": announcementId})
+}
+
+func (m *defaultAnnouncementReadModel) DeleteByAnnouncementId(ctx context.Context, announcementId string) error {
+	_, err := m.col.DeleteMany(ctx, bson.M{"announcementId": announcementId})
+	return err
+}
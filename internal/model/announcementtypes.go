@@ -0,0 +1,49 @@
+package model
+
+import (
+	"slices"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Announcement 公司公告，发布时间到达后对目标部门可见，到期后不再展示
+type Announcement struct {
+	ID primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+
+	Title       string   `bson:"title" json:"title"`
+	Content     string   `bson:"content" json:"content"`
+	PublisherId string   `bson:"publisherId" json:"publisherId"`
+	DepIds      []string `bson:"depIds,omitempty" json:"depIds,omitempty"` // 目标部门，为空发给全员
+	Pinned      bool     `bson:"pinned,omitempty" json:"pinned,omitempty"` // 置顶
+	PublishAt   int64    `bson:"publishAt" json:"publishAt"`
+	ExpireAt    int64    `bson:"expireAt,omitempty" json:"expireAt,omitempty"`   // 过期时间，0不过期
+	Delivered   bool     `bson:"delivered,omitempty" json:"delivered,omitempty"` // 是否已推送给在线用户
+
+	UpdateAt int64 `bson:"updateAt,omitempty" json:"updateAt,omitempty"`
+	CreateAt int64 `bson:"createAt,omitempty" json:"createAt,omitempty"`
+}
+
+// Visible 当前是否对该部门的用户可见
+func (a *Announcement) Visible(depIds []string, now int64) bool {
+	if a.PublishAt > now || (a.ExpireAt > 0 && a.ExpireAt <= now) {
+		return false
+	}
+	if len(a.DepIds) == 0 {
+		return true
+	}
+	for _, id := range depIds {
+		if slices.Contains(a.DepIds, id) {
+			return true
+		}
+	}
+	return false
+}
+
+// AnnouncementRead 公告已读记录
+type AnnouncementRead struct {
+	ID primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+
+	AnnouncementId string `bson:"announcementId" json:"announcementId"`
+	UserId         string `bson:"userId" json:"userId"`
+	ReadAt         int64  `bson:"readAt" json:"readAt"`
+}
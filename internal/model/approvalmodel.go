@@ -5,10 +5,11 @@ import (
 	"context"
 	"time"
 
+	"aiOffice/pkg/pagex"
+
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
-	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type ApprovalModel interface {
@@ -16,7 +17,13 @@ type ApprovalModel interface {
 	FindOne(ctx context.Context, id string) (*Approval, error)
 	Update(ctx context.Context, data *Approval) error
 	Delete(ctx context.Context, id string) error
-	List(ctx context.Context, userId string, approvalType int, page, count int) ([]*Approval, int64, error)
+	List(ctx context.Context, userId string, approvalType int, page pagex.Page) ([]*Approval, int64, error)
+	// 删除before之前结束（通过、拒绝、撤销）的审批，dryRun时只统计数量
+	PurgeFinished(ctx context.Context, before int64, dryRun bool) (int64, error)
+	// 在[startTime, endTime]内有更新的审批，用于统计当天的审批处理
+	FindUpdatedBetween(ctx context.Context, startTime, endTime int64) ([]*Approval, error)
+	// 用户已通过、与[startTime, endTime)有交集的请假
+	FindPassedLeaves(ctx context.Context, userId string, startTime, endTime int64) ([]*Approval, error)
 }
 
 type defaultApprovalModel struct {
@@ -74,7 +81,7 @@ func (m *defaultApprovalModel) Delete(ctx context.Context, id string) error {
 	return err
 }
 
-func (m *defaultApprovalModel) List(ctx context.Context, userId string, approvalType int, page, count int) ([]*Approval, int64, error) {
+func (m *defaultApprovalModel) List(ctx context.Context, userId string, approvalType int, page pagex.Page) ([]*Approval, int64, error) {
 	var conditions []bson.M
 
 	if userId != "" {
@@ -101,16 +108,7 @@ func (m *defaultApprovalModel) List(ctx context.Context, userId string, approval
 		return nil, 0, err
 	}
 
-	if page < 1 {
-		page = 1
-	}
-	if count < 1 {
-		count = 10
-	}
-	skip := int64((page - 1) * count)
-
-	opts := options.Find().SetSkip(skip).SetLimit(int64(count)).SetSort(bson.M{"createAt": -1})
-	cursor, err := m.col.Find(ctx, filter, opts)
+	cursor, err := m.col.Find(ctx, filter, page.Options("-createAt", "createAt", "updateAt"))
 	if err != nil {
 		return nil, 0, err
 	}
@@ -123,3 +121,43 @@ func (m *defaultApprovalModel) List(ctx context.Context, userId string, approval
 
 	return approvals, total, nil
 }
+
+func (m *defaultApprovalModel) PurgeFinished(ctx context.Context, before int64, dryRun bool) (int64, error) {
+	// 撤销的审批没有完成时间，按最后更新时间判断
+	filter := bson.M{
+		"status":   bson.M{"$in": []ApprovalStatus{Pass, Refuse, Cancel, AutoPass}},
+		"updateAt": bson.M{"$lt": before},
+	}
+	if dryRun {
+		return m.col.CountDocuments(ctx, filter)
+	}
+	res, err := m.col.DeleteMany(ctx, filter)
+	if err != nil {
+		return 0, err
+	}
+	return res.DeletedCount, nil
+}
+
+func (m *defaultApprovalModel) FindUpdatedBetween(ctx context.Context, startTime, endTime int64) ([]*Approval, error) {
+	var list []*Approval
+	err := entityList(ctx, m.col, bson.M{"updateAt": bson.M{"$gte": startTime, "$lte": endTime}}, &list)
+	if err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+func (m *defaultApprovalModel) FindPassedLeaves(ctx context.Context, userId string, startTime, endTime int64) ([]*Approval, error) {
+	var list []*Approval
+	err := entityList(ctx, m.col, bson.M{
+		"userId":          userId,
+		"type":            LeaveApproval,
+		"status":          bson.M{"$in": bson.A{Pass, AutoPass}},
+		"leave.startTime": bson.M{"$lt": endTime},
+		"leave.endTime":   bson.M{"$gt": startTime},
+	}, &list)
+	if err != nil {
+		return nil, err
+	}
+	return list, nil
+}
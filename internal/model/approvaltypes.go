@@ -178,6 +178,9 @@ type (
 		Reason    string        `bson:"reason,omitempty"`        //补卡理由
 		Day       int64         `bson:"day,omitempty"`           //补卡日期(20221011)
 		CheckType WorkCheckType `bson:"workCheckType,omitempty"` //补卡类型
+
+		Record  *Attendance `bson:"record,omitempty"`  // 发起补卡时当天的考勤记录
+		Skipped bool        `bson:"skipped,omitempty"` // 审批通过时当天已有该打卡记录，补卡未写入
 	}
 
 	// Leave 请假
@@ -234,6 +237,8 @@ func (m *Approval) ToDomainApprovalInfo() *domain.ApprovalInfoResp {
 			Reason:    m.MakeCard.Reason,
 			Day:       m.MakeCard.Day,
 			CheckType: int(m.MakeCard.CheckType),
+			Record:    m.MakeCard.Record.ToDomainAttendance(),
+			Skipped:   m.MakeCard.Skipped,
 		}
 	case GoOutApproval:
 		res.GoOut = &domain.GoOut{
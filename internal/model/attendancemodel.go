@@ -0,0 +1,95 @@
+package model
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type AttendanceModel interface {
+	FindByUserDay(ctx context.Context, userId string, day int64) (*Attendance, error)
+	ListByUserDays(ctx context.Context, userId string, startDay, endDay int64) ([]*Attendance, error)
+	SetCheck(ctx context.Context, userId string, day int64, checkType WorkCheckType, at int64, source AttendanceSource, approvalId string) error
+	SetCheckIfEmpty(ctx context.Context, userId string, day int64, checkType WorkCheckType, at int64, source AttendanceSource, approvalId string) (bool, error)
+}
+
+type defaultAttendanceModel struct {
+	col *mongo.Collection
+}
+
+func NewAttendanceModel(db *mongo.Database) AttendanceModel {
+	col := db.Collection("attendance")
+	return &defaultAttendanceModel{
+		col: col,
+	}
+}
+
+func (m *defaultAttendanceModel) FindByUserDay(ctx context.Context, userId string, day int64) (*Attendance, error) {
+	var data Attendance
+	err := m.col.FindOne(ctx, bson.M{"userId": userId, "day": day}).Decode(&data)
+	switch err {
+	case nil:
+		return &data, nil
+	case mongo.ErrNoDocuments:
+		return nil, ErrNotFound
+	default:
+		return nil, err
+	}
+}
+
+// ListByUserDays 按日期正序返回 [startDay, endDay] 的考勤记录
+func (m *defaultAttendanceModel) ListByUserDays(ctx context.Context, userId string, startDay, endDay int64) ([]*Attendance, error) {
+	var list []*Attendance
+	filter := bson.M{
+		"userId": userId,
+		"day":    bson.M{"$gte": startDay, "$lte": endDay},
+	}
+	if err := entityList(ctx, m.col, filter, &list, options.Find().SetSort(bson.M{"day": 1})); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// SetCheck 记录上班或下班卡，当天没有记录时创建
+func (m *defaultAttendanceModel) SetCheck(ctx context.Context, userId string, day int64, checkType WorkCheckType, at int64, source AttendanceSource, approvalId string) error {
+	field := checkField(checkType)
+	return entityUpdateOrInsert(ctx, m.col, bson.M{"userId": userId, "day": day}, checkUpdate(field, at, source, approvalId))
+}
+
+// SetCheckIfEmpty 只在当天还没有该打卡记录时写入，已有记录时返回false；
+// 已有当天记录但不满足条件时upsert会违反 userId+day 唯一索引，同样视为已有记录
+func (m *defaultAttendanceModel) SetCheckIfEmpty(ctx context.Context, userId string, day int64, checkType WorkCheckType, at int64, source AttendanceSource, approvalId string) (bool, error) {
+	field := checkField(checkType)
+	filter := bson.M{"userId": userId, "day": day, field: bson.M{"$in": bson.A{nil, 0}}}
+	res, err := m.col.UpdateOne(ctx, filter, checkUpdate(field, at, source, approvalId), options.Update().SetUpsert(true))
+	if mongo.IsDuplicateKeyError(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return res.MatchedCount > 0 || res.UpsertedCount > 0, nil
+}
+
+func checkField(checkType WorkCheckType) string {
+	if checkType == OffWorkCheck {
+		return "checkOut"
+	}
+	return "checkIn"
+}
+
+func checkUpdate(field string, at int64, source AttendanceSource, approvalId string) bson.M {
+	now := time.Now().Unix()
+	return bson.M{
+		"$set": bson.M{
+			field:                at,
+			field + "Source":     source,
+			field + "ApprovalId": approvalId,
+			"updateAt":           now,
+		},
+		"$setOnInsert": bson.M{"createAt": now},
+	}
+}
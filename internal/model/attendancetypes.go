@@ -0,0 +1,61 @@
+package model
+
+import (
+	"aiOffice/internal/domain"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// AttendanceSource 打卡来源
+// 1. 打卡  2. 补卡审批通过
+type AttendanceSource int
+
+const (
+	PunchSource    AttendanceSource = 1 // 打卡
+	MakeCardSource AttendanceSource = 2 // 补卡
+)
+
+// Attendance 用户每天的考勤记录，每人每天一条
+type Attendance struct {
+	ID primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+
+	UserId string `bson:"userId" json:"userId"`
+	Day    int64  `bson:"day" json:"day"` // 日期(20240530)，按服务时区计算
+
+	CheckIn            int64            `bson:"checkIn,omitempty" json:"checkIn,omitempty"`                       // 上班打卡时间
+	CheckInSource      AttendanceSource `bson:"checkInSource,omitempty" json:"checkInSource,omitempty"`           // 上班卡来源
+	CheckInApprovalId  string           `bson:"checkInApprovalId,omitempty" json:"checkInApprovalId,omitempty"`   // 补上班卡的审批ID
+	CheckOut           int64            `bson:"checkOut,omitempty" json:"checkOut,omitempty"`                     // 下班打卡时间
+	CheckOutSource     AttendanceSource `bson:"checkOutSource,omitempty" json:"checkOutSource,omitempty"`         // 下班卡来源
+	CheckOutApprovalId string           `bson:"checkOutApprovalId,omitempty" json:"checkOutApprovalId,omitempty"` // 补下班卡的审批ID
+
+	UpdateAt int64 `bson:"updateAt,omitempty" json:"updateAt,omitempty"`
+	CreateAt int64 `bson:"createAt,omitempty" json:"createAt,omitempty"`
+}
+
+// Checked 是否已有该类型的打卡
+func (m *Attendance) Checked(checkType WorkCheckType) bool {
+	if m == nil {
+		return false
+	}
+	if checkType == OffWorkCheck {
+		return m.CheckOut > 0
+	}
+	return m.CheckIn > 0
+}
+
+// ToDomainAttendance 转换为响应模型，迟到、缺卡等状态由业务逻辑按上下班时间计算
+func (m *Attendance) ToDomainAttendance() *domain.Attendance {
+	if m == nil {
+		return nil
+	}
+	return &domain.Attendance{
+		Day:                m.Day,
+		CheckIn:            m.CheckIn,
+		CheckInSource:      int(m.CheckInSource),
+		CheckInApprovalId:  m.CheckInApprovalId,
+		CheckOut:           m.CheckOut,
+		CheckOutSource:     int(m.CheckOutSource),
+		CheckOutApprovalId: m.CheckOutApprovalId,
+	}
+}
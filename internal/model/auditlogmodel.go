@@ -0,0 +1,86 @@
+package model
+
+import (
+	"context"
+	"time"
+
+	"aiOffice/pkg/pagex"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type AuditLogModel interface {
+	Insert(ctx context.Context, data *AuditLog) error
+	List(ctx context.Context, filter *AuditLogFilter, page pagex.Page) ([]*AuditLog, int64, error)
+	// 删除创建时间早于before的记录，dryRun时只统计数量
+	PurgeBefore(ctx context.Context, before int64, dryRun bool) (int64, error)
+}
+
+type defaultAuditLogModel struct {
+	col *mongo.Collection
+}
+
+func NewAuditLogModel(db *mongo.Database) AuditLogModel {
+	col := db.Collection("audit_log")
+	return &defaultAuditLogModel{
+		col: col,
+	}
+}
+
+func (m *defaultAuditLogModel) Insert(ctx context.Context, data *AuditLog) error {
+	if data.ID.IsZero() {
+		data.ID = primitive.NewObjectID()
+		data.CreateAt = time.Now().Unix()
+	}
+
+	_, err := m.col.InsertOne(ctx, data)
+	return err
+}
+
+func (m *defaultAuditLogModel) List(ctx context.Context, filter *AuditLogFilter, page pagex.Page) ([]*AuditLog, int64, error) {
+	query := bson.M{}
+	if filter.ActorId != "" {
+		query["actorId"] = filter.ActorId
+	}
+	if filter.Resource != "" {
+		query["resource"] = filter.Resource
+	}
+	if filter.ResourceId != "" {
+		query["resourceId"] = filter.ResourceId
+	}
+	if filter.StartTime > 0 || filter.EndTime > 0 {
+		createAt := bson.M{}
+		if filter.StartTime > 0 {
+			createAt["$gte"] = filter.StartTime
+		}
+		if filter.EndTime > 0 {
+			createAt["$lte"] = filter.EndTime
+		}
+		query["createAt"] = createAt
+	}
+
+	total, err := m.col.CountDocuments(ctx, query)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var list []*AuditLog
+	if err := entityList(ctx, m.col, query, &list, page.Options("-createAt")); err != nil {
+		return nil, 0, err
+	}
+	return list, total, nil
+}
+
+func (m *defaultAuditLogModel) PurgeBefore(ctx context.Context, before int64, dryRun bool) (int64, error) {
+	filter := bson.M{"createAt": bson.M{"$lt": before}}
+	if dryRun {
+		return m.col.CountDocuments(ctx, filter)
+	}
+	res, err := m.col.DeleteMany(ctx, filter)
+	if err != nil {
+		return 0, err
+	}
+	return res.DeletedCount, nil
+}
@@ -0,0 +1,32 @@
+package model
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// AuditLog 变更操作的审计记录
+type AuditLog struct {
+	ID primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+
+	ActorId    string `bson:"actorId" json:"actorId"`                           // 操作人
+	Action     string `bson:"action" json:"action"`                             // 方法和路由，如 DELETE /v1/todo/:id
+	Resource   string `bson:"resource" json:"resource"`                         // 资源类型，如 todo
+	ResourceId string `bson:"resourceId,omitempty" json:"resourceId,omitempty"` // 资源id
+	Before     string `bson:"before,omitempty" json:"before,omitempty"`         // 变更前的数据（json）
+	After      string `bson:"after,omitempty" json:"after,omitempty"`           // 变更后的数据（json）
+	Payload    string `bson:"payload,omitempty" json:"payload,omitempty"`       // 请求参数（json，已脱敏）
+	Ip         string `bson:"ip" json:"ip"`
+	RequestId  string `bson:"requestId,omitempty" json:"requestId,omitempty"`
+	Status     int    `bson:"status" json:"status"` // http状态码
+
+	CreateAt int64 `bson:"createAt,omitempty" json:"createAt,omitempty"`
+}
+
+// AuditLogFilter 审计记录查询条件，零值表示不限制
+type AuditLogFilter struct {
+	ActorId    string
+	Resource   string
+	ResourceId string
+	StartTime  int64
+	EndTime    int64
+}
@@ -0,0 +1,129 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var ErrCalendarCipher = errors.New("未配置加密密钥，无法保存日历凭证")
+
+type CalendarAccountModel interface {
+	Upsert(ctx context.Context, data *CalendarAccount) error
+	FindOne(ctx context.Context, userId, provider string) (*CalendarAccount, error)
+	FindByUserId(ctx context.Context, userId string) ([]*CalendarAccount, error)
+	FindAll(ctx context.Context) ([]*CalendarAccount, error)
+	UpdateSync(ctx context.Context, data *CalendarAccount) error
+	Delete(ctx context.Context, userId, provider string) error
+}
+
+type defaultCalendarAccountModel struct {
+	col    *mongo.Collection
+	cipher MsgCipher
+}
+
+// NewCalendarAccountModel 日历凭证必须加密存储，cipher 为 nil 时无法绑定日历
+func NewCalendarAccountModel(db *mongo.Database, cipher MsgCipher) CalendarAccountModel {
+	col := db.Collection("calendar_account")
+	return &defaultCalendarAccountModel{
+		col:    col,
+		cipher: cipher,
+	}
+}
+
+// Upsert 绑定日历，重复绑定覆盖凭证
+func (m *defaultCalendarAccountModel) Upsert(ctx context.Context, data *CalendarAccount) error {
+	credential, err := m.encrypt(data.Credential)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().Unix()
+	return entityUpdateOrInsert(ctx, m.col, bson.M{"userId": data.UserId, "provider": data.Provider}, bson.M{
+		"$set": bson.M{
+			"credential": credential,
+			"lastError":  "",
+			"updateAt":   now,
+		},
+		"$setOnInsert": bson.M{"createAt": now},
+	})
+}
+
+func (m *defaultCalendarAccountModel) FindOne(ctx context.Context, userId, provider string) (*CalendarAccount, error) {
+	var data CalendarAccount
+	err := m.col.FindOne(ctx, bson.M{"userId": userId, "provider": provider}).Decode(&data)
+	switch err {
+	case nil:
+		return &data, m.decrypt(&data)
+	case mongo.ErrNoDocuments:
+		return nil, ErrNotFound
+	default:
+		return nil, err
+	}
+}
+
+func (m *defaultCalendarAccountModel) FindByUserId(ctx context.Context, userId string) ([]*CalendarAccount, error) {
+	return m.list(ctx, bson.M{"userId": userId})
+}
+
+func (m *defaultCalendarAccountModel) FindAll(ctx context.Context) ([]*CalendarAccount, error) {
+	return m.list(ctx, bson.M{})
+}
+
+// UpdateSync 保存同步结果，令牌刷新后的凭证一并保存
+func (m *defaultCalendarAccountModel) UpdateSync(ctx context.Context, data *CalendarAccount) error {
+	credential, err := m.encrypt(data.Credential)
+	if err != nil {
+		return err
+	}
+
+	_, err = m.col.UpdateOne(ctx, bson.M{"userId": data.UserId, "provider": data.Provider}, bson.M{
+		"$set": bson.M{
+			"credential": credential,
+			"lastSyncAt": data.LastSyncAt,
+			"lastError":  data.LastError,
+			"updateAt":   time.Now().Unix(),
+		},
+	})
+	return err
+}
+
+func (m *defaultCalendarAccountModel) Delete(ctx context.Context, userId, provider string) error {
+	_, err := m.col.DeleteOne(ctx, bson.M{"userId": userId, "provider": provider})
+	return err
+}
+
+func (m *defaultCalendarAccountModel) list(ctx context.Context, filter bson.M) ([]*CalendarAccount, error) {
+	var list []*CalendarAccount
+	if err := entityList(ctx, m.col, filter, &list); err != nil {
+		return nil, err
+	}
+	for _, v := range list {
+		if err := m.decrypt(v); err != nil {
+			return nil, err
+		}
+	}
+	return list, nil
+}
+
+func (m *defaultCalendarAccountModel) encrypt(credential string) (string, error) {
+	if m.cipher == nil {
+		return "", ErrCalendarCipher
+	}
+	return m.cipher.Encrypt(credential)
+}
+
+func (m *defaultCalendarAccountModel) decrypt(data *CalendarAccount) error {
+	if m.cipher == nil {
+		return ErrCalendarCipher
+	}
+	credential, err := m.cipher.Decrypt(data.Credential)
+	if err != nil {
+		return err
+	}
+	data.Credential = credential
+	return nil
+}
@@ -0,0 +1,34 @@
+package model
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// CalendarAccount 用户绑定的外部日历，每个用户每种日历一个
+type CalendarAccount struct {
+	ID primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+
+	UserId     string `bson:"userId" json:"userId"`                             // 用户ID
+	Provider   string `bson:"provider" json:"provider"`                         // caldav/exchange
+	Credential string `bson:"credential" json:"-"`                              // 凭证JSON，落库时加密
+	LastSyncAt int64  `bson:"lastSyncAt,omitempty" json:"lastSyncAt,omitempty"` // 最后同步时间
+	LastError  string `bson:"lastError,omitempty" json:"lastError,omitempty"`   // 最后一次同步失败原因
+
+	UpdateAt int64 `bson:"updateAt,omitempty" json:"updateAt,omitempty"`
+	CreateAt int64 `bson:"createAt,omitempty" json:"createAt,omitempty"`
+}
+
+// CalendarEvent 待办与外部日历事件的对应关系
+type CalendarEvent struct {
+	ID primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+
+	UserId     string `bson:"userId" json:"userId"`
+	Provider   string `bson:"provider" json:"provider"`
+	TodoId     string `bson:"todoId" json:"todoId"`
+	EventId    string `bson:"eventId" json:"eventId"`       // 外部日历的事件ID
+	DeadlineAt int64  `bson:"deadlineAt" json:"deadlineAt"` // 上次同步时双方一致的截止时间，用于判断哪一方做了修改
+	SyncAt     int64  `bson:"syncAt" json:"syncAt"`
+
+	UpdateAt int64 `bson:"updateAt,omitempty" json:"updateAt,omitempty"`
+	CreateAt int64 `bson:"createAt,omitempty" json:"createAt,omitempty"`
+}
@@ -0,0 +1,62 @@
+package model
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type CalendarEventModel interface {
+	Upsert(ctx context.Context, data *CalendarEvent) error
+	FindByUserId(ctx context.Context, userId, provider string) ([]*CalendarEvent, error)
+	Delete(ctx context.Context, userId, provider, todoId string) error
+	DeleteByProvider(ctx context.Context, userId, provider string) error
+}
+
+type defaultCalendarEventModel struct {
+	col *mongo.Collection
+}
+
+func NewCalendarEventModel(db *mongo.Database) CalendarEventModel {
+	col := db.Collection("calendar_event")
+	return &defaultCalendarEventModel{
+		col: col,
+	}
+}
+
+func (m *defaultCalendarEventModel) Upsert(ctx context.Context, data *CalendarEvent) error {
+	now := time.Now().Unix()
+	return entityUpdateOrInsert(ctx, m.col, bson.M{
+		"userId":   data.UserId,
+		"provider": data.Provider,
+		"todoId":   data.TodoId,
+	}, bson.M{
+		"$set": bson.M{
+			"eventId":    data.EventId,
+			"deadlineAt": data.DeadlineAt,
+			"syncAt":     data.SyncAt,
+			"updateAt":   now,
+		},
+		"$setOnInsert": bson.M{"createAt": now},
+	})
+}
+
+func (m *defaultCalendarEventModel) FindByUserId(ctx context.Context, userId, provider string) ([]*CalendarEvent, error) {
+	var list []*CalendarEvent
+	if err := entityList(ctx, m.col, bson.M{"userId": userId, "provider": provider}, &list); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+func (m *defaultCalendarEventModel) Delete(ctx context.Context, userId, provider, todoId string) error {
+	_, err := m.col.DeleteOne(ctx, bson.M{"userId": userId, "provider": provider, "todoId": todoId})
+	return err
+}
+
+func (m *defaultCalendarEventModel) DeleteByProvider(ctx context.Context, userId, provider string) error {
+	_, err := m.col.DeleteMany(ctx, bson.M{"userId": userId, "provider": provider})
+	return err
+}
@@ -5,9 +5,12 @@ import (
 	"context"
 	"time"
 
+	"aiOffice/pkg/pagex"
+
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type ChatLogModel interface {
@@ -15,16 +18,33 @@ type ChatLogModel interface {
 	FindOne(ctx context.Context, id string) (*ChatLog, error)
 	Update(ctx context.Context, data *ChatLog) error
 	Delete(ctx context.Context, id string) error
+	ListByConversationId(ctx context.Context, conversationId string, chatType ChatType, startTime int64, limit int) ([]*ChatLog, error)
+	// 删除创建时间早于before的消息，dryRun时只统计数量
+	PurgeBefore(ctx context.Context, before int64, dryRun bool) (int64, error)
+	// 在[startTime, endTime]内发送过群聊或私聊消息的用户
+	FindSenderIds(ctx context.Context, startTime, endTime int64) ([]string, error)
+	// 用户在[startTime, endTime]内发送的最近limit条群聊和私聊消息，按发送时间正序返回
+	ListBySendId(ctx context.Context, sendId string, startTime, endTime int64, limit int) ([]*ChatLog, error)
+	// 会话中游标之前的limit条消息，按发送时间倒序返回
+	ListHistory(ctx context.Context, conversationId string, chatType ChatType, cursor pagex.Cursor, limit int64) ([]*ChatLog, error)
+}
+
+// MsgCipher 消息内容加解密，为 nil 时明文存储
+type MsgCipher interface {
+	Encrypt(plaintext string) (string, error)
+	Decrypt(text string) (string, error)
 }
 
 type defaultChatLogModel struct {
-	col *mongo.Collection
+	col    *mongo.Collection
+	cipher MsgCipher
 }
 
-func NewChatLogModel(db *mongo.Database) ChatLogModel {
+func NewChatLogModel(db *mongo.Database, cipher MsgCipher) ChatLogModel {
 	col := db.Collection("chat_log")
 	return &defaultChatLogModel{
-		col: col,
+		col:    col,
+		cipher: cipher,
 	}
 }
 
@@ -35,7 +55,11 @@ func (m *defaultChatLogModel) Insert(ctx context.Context, data *ChatLog) error {
 		data.UpdateAt = time.Now().Unix()
 	}
 
-	_, err := m.col.InsertOne(ctx, data)
+	doc, err := m.encrypt(data)
+	if err != nil {
+		return err
+	}
+	_, err = m.col.InsertOne(ctx, doc)
 	return err
 }
 
@@ -49,7 +73,7 @@ func (m *defaultChatLogModel) FindOne(ctx context.Context, id string) (*ChatLog,
 	err = m.col.FindOne(ctx, bson.M{"_id": oid}).Decode(&data)
 	switch err {
 	case nil:
-		return &data, nil
+		return &data, m.decrypt(&data)
 	case mongo.ErrNoDocuments:
 		return nil, ErrNotFound
 	default:
@@ -59,7 +83,11 @@ func (m *defaultChatLogModel) FindOne(ctx context.Context, id string) (*ChatLog,
 
 func (m *defaultChatLogModel) Update(ctx context.Context, data *ChatLog) error {
 	data.UpdateAt = time.Now().Unix()
-	_, err := m.col.UpdateOne(ctx, bson.M{"_id": data.ID}, bson.M{"$set": data})
+	doc, err := m.encrypt(data)
+	if err != nil {
+		return err
+	}
+	_, err = m.col.UpdateOne(ctx, bson.M{"_id": data.ID}, bson.M{"$set": doc})
 	return err
 }
 
@@ -71,3 +99,151 @@ func (m *defaultChatLogModel) Delete(ctx context.Context, id string) error {
 	_, err = m.col.DeleteOne(ctx, bson.M{"_id": oid})
 	return err
 }
+
+// ListByConversationId 查询会话中startTime之后最近的limit条chatType类型的消息，按发送时间正序返回
+func (m *defaultChatLogModel) ListByConversationId(ctx context.Context, conversationId string, chatType ChatType,
+	startTime int64, limit int) ([]*ChatLog, error) {
+
+	filter := bson.M{"conversationId": conversationId, "chatType": chatType}
+	if startTime > 0 {
+		filter["SendTime"] = bson.M{"$gte": startTime}
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "SendTime", Value: -1}})
+	if limit > 0 {
+		opts.SetLimit(int64(limit))
+	}
+
+	cursor, err := m.col.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var list []*ChatLog
+	if err = cursor.All(ctx, &list); err != nil {
+		return nil, err
+	}
+
+	for i, j := 0, len(list)-1; i < j; i, j = i+1, j-1 {
+		list[i], list[j] = list[j], list[i]
+	}
+	for _, v := range list {
+		if err := m.decrypt(v); err != nil {
+			return nil, err
+		}
+	}
+	return list, nil
+}
+
+// encrypt 返回加密后的副本，不修改调用方持有的明文
+func (m *defaultChatLogModel) encrypt(data *ChatLog) (*ChatLog, error) {
+	if m.cipher == nil || data.MsgContent == "" {
+		return data, nil
+	}
+	content, err := m.cipher.Encrypt(data.MsgContent)
+	if err != nil {
+		return nil, err
+	}
+	doc := *data
+	doc.MsgContent = content
+	return &doc, nil
+}
+
+// decrypt 读取时透明解密，历史明文数据原样返回
+func (m *defaultChatLogModel) decrypt(data *ChatLog) error {
+	if m.cipher == nil || data.MsgContent == "" {
+		return nil
+	}
+	content, err := m.cipher.Decrypt(data.MsgContent)
+	if err != nil {
+		return err
+	}
+	data.MsgContent = content
+	return nil
+}
+
+func (m *defaultChatLogModel) PurgeBefore(ctx context.Context, before int64, dryRun bool) (int64, error) {
+	filter := bson.M{"createAt": bson.M{"$lt": before}}
+	if dryRun {
+		return m.col.CountDocuments(ctx, filter)
+	}
+	res, err := m.col.DeleteMany(ctx, filter)
+	if err != nil {
+		return 0, err
+	}
+	return res.DeletedCount, nil
+}
+
+// sentBetweenFilter AI对话不算作用户之间的沟通，不参与统计
+func sentBetweenFilter(startTime, endTime int64) bson.M {
+	return bson.M{
+		"chatType": bson.M{"$in": []ChatType{GroupChatType, SingleChatType}},
+		"SendTime": bson.M{"$gte": startTime, "$lte": endTime},
+	}
+}
+
+func (m *defaultChatLogModel) FindSenderIds(ctx context.Context, startTime, endTime int64) ([]string, error) {
+	values, err := m.col.Distinct(ctx, "sendId", sentBetweenFilter(startTime, endTime))
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, 0, len(values))
+	for _, v := range values {
+		if id, ok := v.(string); ok && id != "" {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+func (m *defaultChatLogModel) ListBySendId(ctx context.Context, sendId string, startTime, endTime int64,
+	limit int) ([]*ChatLog, error) {
+
+	filter := sentBetweenFilter(startTime, endTime)
+	filter["sendId"] = sendId
+
+	opts := options.Find().SetSort(bson.D{{Key: "SendTime", Value: -1}})
+	if limit > 0 {
+		opts.SetLimit(int64(limit))
+	}
+
+	var list []*ChatLog
+	if err := entityList(ctx, m.col, filter, &list, opts); err != nil {
+		return nil, err
+	}
+
+	for i, j := 0, len(list)-1; i < j; i, j = i+1, j-1 {
+		list[i], list[j] = list[j], list[i]
+	}
+	for _, v := range list {
+		if err := m.decrypt(v); err != nil {
+			return nil, err
+		}
+	}
+	return list, nil
+}
+
+func (m *defaultChatLogModel) ListHistory(ctx context.Context, conversationId string, chatType ChatType,
+	cursor pagex.Cursor, limit int64) ([]*ChatLog, error) {
+
+	filter := cursor.Before("SendTime")
+	filter["conversationId"] = conversationId
+	filter["chatType"] = chatType
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "SendTime", Value: -1}, {Key: "_id", Value: -1}}).
+		SetLimit(limit)
+
+	var list []*ChatLog
+	if err := entityList(ctx, m.col, filter, &list, opts); err != nil {
+		return nil, err
+	}
+	for _, v := range list {
+		if err := m.decrypt(v); err != nil {
+			return nil, err
+		}
+	}
+	return list, nil
+}
@@ -10,6 +10,7 @@ type ChatType int
 const (
 	GroupChatType  ChatType = iota + 1 // 群聊类型，值为1
 	SingleChatType                     // 私聊类型，值为2
+	AIChatType                         // AI对话类型，值为3
 )
 
 type ChatLog struct {
@@ -18,7 +19,7 @@ type ChatLog struct {
 	ConversationId string   `bson:"conversationId,omitempty" json:"conversationId"` //会话Id
 	RecvId         string   `bson:"revcId,omitempty" json:"revcId"`                 //接收Id
 	SendId         string   `bson:"sendId,omitempty" json:"sendId"`                 //发送Id
-	ChatType       ChatType `bson:"chatType,omitempty" json:"chatType"`             //chat类型 1=群聊 2=私聊
+	ChatType       ChatType `bson:"chatType,omitempty" json:"chatType"`             //chat类型 1=群聊 2=私聊 3=AI对话
 	MsgContent     string   `bson:"msgContent,omitempty" json:"msgContent"`         //聊天内容
 	SendTime       int64    `bson:"SendTime,omitempty" json:"SendTime"`             //发送时间戳
 
@@ -0,0 +1,56 @@
+package model
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type DailySummaryModel interface {
+	FindByUserIdAndDate(ctx context.Context, userId, date string) (*DailySummary, error)
+	Upsert(ctx context.Context, data *DailySummary) error
+}
+
+type defaultDailySummaryModel struct {
+	col *mongo.Collection
+}
+
+func NewDailySummaryModel(db *mongo.Database) DailySummaryModel {
+	col := db.Collection("daily_summary")
+	return &defaultDailySummaryModel{
+		col: col,
+	}
+}
+
+func (m *defaultDailySummaryModel) FindByUserIdAndDate(ctx context.Context, userId, date string) (*DailySummary, error) {
+	var data DailySummary
+	err := m.col.FindOne(ctx, bson.M{"userId": userId, "date": date}).Decode(&data)
+	switch err {
+	case nil:
+		return &data, nil
+	case mongo.ErrNoDocuments:
+		return nil, ErrNotFound
+	default:
+		return nil, err
+	}
+}
+
+// Upsert 同一用户每天只保留一份总结
+func (m *defaultDailySummaryModel) Upsert(ctx context.Context, data *DailySummary) error {
+	now := time.Now().Unix()
+	return entityUpdateOrInsert(ctx, m.col, bson.M{
+		"userId": data.UserId,
+		"date":   data.Date,
+	}, bson.M{
+		"$set": bson.M{
+			"content":   data.Content,
+			"todos":     data.Todos,
+			"approvals": data.Approvals,
+			"messages":  data.Messages,
+			"updateAt":  now,
+		},
+		"$setOnInsert": bson.M{"createAt": now},
+	})
+}
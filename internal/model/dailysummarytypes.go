@@ -0,0 +1,20 @@
+package model
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// DailySummary 用户的每日工作总结
+type DailySummary struct {
+	ID primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+
+	UserId    string `bson:"userId" json:"userId"`
+	Date      string `bson:"date" json:"date"`           // 日期 yyyymmdd，按定时任务的时区计算
+	Content   string `bson:"content" json:"content"`     // 总结内容，模型不可用时为统计数据
+	Todos     int    `bson:"todos" json:"todos"`         // 完成的待办数
+	Approvals int    `bson:"approvals" json:"approvals"` // 处理的审批数
+	Messages  int    `bson:"messages" json:"messages"`   // 参与总结的聊天消息数
+
+	UpdateAt int64 `bson:"updateAt,omitempty" json:"updateAt,omitempty"`
+	CreateAt int64 `bson:"createAt,omitempty" json:"createAt,omitempty"`
+}
@@ -0,0 +1,73 @@
+package model
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type DeviceTokenModel interface {
+	Upsert(ctx context.Context, data *DeviceToken) error
+	FindByUserId(ctx context.Context, userId string) ([]*DeviceToken, error)
+	FindTokens(ctx context.Context, userId, platform string) ([]string, error)
+	RemoveToken(ctx context.Context, token string) error
+	DeleteByUserIdAndToken(ctx context.Context, userId, token string) error
+}
+
+type defaultDeviceTokenModel struct {
+	col *mongo.Collection
+}
+
+func NewDeviceTokenModel(db *mongo.Database) DeviceTokenModel {
+	col := db.Collection("device_token")
+	return &defaultDeviceTokenModel{
+		col: col,
+	}
+}
+
+// Upsert 同一个设备令牌只归属于最后登录的用户
+func (m *defaultDeviceTokenModel) Upsert(ctx context.Context, data *DeviceToken) error {
+	now := time.Now().Unix()
+	return entityUpdateOrInsert(ctx, m.col, bson.M{"token": data.Token}, bson.M{
+		"$set": bson.M{
+			"userId":   data.UserId,
+			"platform": data.Platform,
+			"token":    data.Token,
+			"updateAt": now,
+		},
+		"$setOnInsert": bson.M{"createAt": now},
+	})
+}
+
+func (m *defaultDeviceTokenModel) FindByUserId(ctx context.Context, userId string) ([]*DeviceToken, error) {
+	var list []*DeviceToken
+	if err := entityList(ctx, m.col, bson.M{"userId": userId}, &list); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+func (m *defaultDeviceTokenModel) FindTokens(ctx context.Context, userId, platform string) ([]string, error) {
+	var list []*DeviceToken
+	if err := entityList(ctx, m.col, bson.M{"userId": userId, "platform": platform}, &list); err != nil {
+		return nil, err
+	}
+
+	tokens := make([]string, 0, len(list))
+	for _, v := range list {
+		tokens = append(tokens, v.Token)
+	}
+	return tokens, nil
+}
+
+func (m *defaultDeviceTokenModel) RemoveToken(ctx context.Context, token string) error {
+	_, err := m.col.DeleteMany(ctx, bson.M{"token": token})
+	return err
+}
+
+func (m *defaultDeviceTokenModel) DeleteByUserIdAndToken(ctx context.Context, userId, token string) error {
+	_, err := m.col.DeleteOne(ctx, bson.M{"userId": userId, "token": token})
+	return err
+}
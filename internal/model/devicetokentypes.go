@@ -0,0 +1,17 @@
+package model
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// DeviceToken 用户推送设备令牌
+type DeviceToken struct {
+	ID primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+
+	UserId   string `bson:"userId" json:"userId"`     // 用户ID
+	Platform string `bson:"platform" json:"platform"` // 推送平台 fcm/apns
+	Token    string `bson:"token" json:"token"`       // 设备令牌
+
+	UpdateAt int64 `bson:"updateAt,omitempty" json:"updateAt,omitempty"`
+	CreateAt int64 `bson:"createAt,omitempty" json:"createAt,omitempty"`
+}
@@ -3,14 +3,18 @@ package model
 import (
 	"errors"
 
+	"aiOffice/pkg/xerr"
+
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
 var (
-	ErrNotFound          = mongo.ErrNoDocuments
-	ErrInvalidObjectId   = errors.New("invalid objectId")
-	ErrNotFindUser       = errors.New("找不到该用户")
-	ErrNotFindDepartment = errors.New("找不到该部门")
-	ErrTodoNotFound      = errors.New("待办事项不存在")
-	ErrNotHandles        = errors.New("没有合适的处理器")
+	ErrNotFound           = mongo.ErrNoDocuments
+	ErrInvalidObjectId    = xerr.NewCode(xerr.Invalid, "invalid objectId")
+	ErrNotFindUser        = xerr.NewCode(xerr.NotFound, "找不到该用户")
+	ErrNotFindDepartment  = xerr.NewCode(xerr.NotFound, "找不到该部门")
+	ErrTodoNotFound       = xerr.NewCode(xerr.NotFound, "待办事项不存在")
+	ErrNotHandles         = errors.New("没有合适的处理器")
+	ErrDepartmentHasChild = xerr.NewCode(xerr.Conflict, "该部门下还有子部门，无法删除")
+	ErrUpdateConflict     = xerr.NewCode(xerr.Conflict, "数据已被其他操作修改，请刷新后重试")
 )
@@ -0,0 +1,120 @@
+package model
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type EventModel interface {
+	Insert(ctx context.Context, data *Event) error
+	FindOne(ctx context.Context, id string) (*Event, error)
+	Update(ctx context.Context, data *Event) error
+	Delete(ctx context.Context, id string) error
+	FindVisible(ctx context.Context, userId string, depIds []string, startTime, endTime int64) ([]*Event, error)
+	FindRemindDue(ctx context.Context, now int64, limit int) ([]*Event, error)
+	SetRemindAt(ctx context.Context, id primitive.ObjectID, old, next int64) (bool, error)
+}
+
+type defaultEventModel struct {
+	col *mongo.Collection
+}
+
+func NewEventModel(db *mongo.Database) EventModel {
+	col := db.Collection("event")
+	return &defaultEventModel{
+		col: col,
+	}
+}
+
+func (m *defaultEventModel) Insert(ctx context.Context, data *Event) error {
+	if data.ID.IsZero() {
+		data.ID = primitive.NewObjectID()
+		data.CreateAt = time.Now().Unix()
+		data.UpdateAt = time.Now().Unix()
+	}
+
+	_, err := m.col.InsertOne(ctx, data)
+	return err
+}
+
+func (m *defaultEventModel) FindOne(ctx context.Context, id string) (*Event, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, ErrInvalidObjectId
+	}
+
+	var data Event
+	err = m.col.FindOne(ctx, bson.M{"_id": oid}).Decode(&data)
+	switch err {
+	case nil:
+		return &data, nil
+	case mongo.ErrNoDocuments:
+		return nil, ErrNotFound
+	default:
+		return nil, err
+	}
+}
+
+// Update 整体替换，清空参与人、重复规则等字段时同样生效
+func (m *defaultEventModel) Update(ctx context.Context, data *Event) error {
+	data.UpdateAt = time.Now().Unix()
+	_, err := m.col.ReplaceOne(ctx, bson.M{"_id": data.ID}, data)
+	return err
+}
+
+func (m *defaultEventModel) Delete(ctx context.Context, id string) error {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return ErrInvalidObjectId
+	}
+	_, err = m.col.DeleteOne(ctx, bson.M{"_id": oid})
+	return err
+}
+
+// FindVisible 用户创建、参与或共享到所在部门，且可能与 [startTime, endTime) 有交集的日程；
+// 重复日程只按第一次开始时间和截止时间过滤，具体每次的时间由调用方展开
+func (m *defaultEventModel) FindVisible(ctx context.Context, userId string, depIds []string, startTime, endTime int64) ([]*Event, error) {
+	visible := bson.A{bson.M{"userId": userId}, bson.M{"participants": userId}}
+	if len(depIds) > 0 {
+		visible = append(visible, bson.M{"depId": bson.M{"$in": depIds}})
+	}
+	filter := bson.M{
+		"$or":       visible,
+		"startTime": bson.M{"$lt": endTime},
+		"$and": bson.A{bson.M{"$or": bson.A{
+			bson.M{"endTime": bson.M{"$gt": startTime}},
+			bson.M{"recurrence.freq": bson.M{"$exists": true}, "recurrence.until": bson.M{"$exists": false}},
+			bson.M{"recurrence.until": bson.M{"$gte": startTime}},
+		}}},
+	}
+
+	var list []*Event
+	if err := entityList(ctx, m.col, filter, &list, options.Find().SetSort(bson.M{"startTime": 1})); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// FindRemindDue 提醒时间已到的日程
+func (m *defaultEventModel) FindRemindDue(ctx context.Context, now int64, limit int) ([]*Event, error) {
+	var list []*Event
+	filter := bson.M{"remindAt": bson.M{"$gt": 0, "$lte": now}}
+	if err := entityList(ctx, m.col, filter, &list, options.Find().SetSort(bson.M{"remindAt": 1}).SetLimit(int64(limit))); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// SetRemindAt 提醒时间仍为old时更新为next，多个实例同时处理时只有一个成功
+func (m *defaultEventModel) SetRemindAt(ctx context.Context, id primitive.ObjectID, old, next int64) (bool, error) {
+	res, err := m.col.UpdateOne(ctx, bson.M{"_id": id, "remindAt": old}, bson.M{"$set": bson.M{"remindAt": next}})
+	if err != nil {
+		return false, err
+	}
+	return res.ModifiedCount > 0, nil
+}
@@ -0,0 +1,92 @@
+package model
+
+import (
+	"slices"
+	"time"
+
+	"aiOffice/pkg/calendar"
+	"aiOffice/pkg/timeutils"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Event 日程，创建人和参与人可见，设置了部门时部门成员都可见（共享日程）
+type Event struct {
+	ID primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+
+	UserId       string           `bson:"userId" json:"userId"` // 创建人
+	Title        string           `bson:"title" json:"title"`
+	Desc         string           `bson:"desc,omitempty" json:"desc,omitempty"`
+	Location     string           `bson:"location,omitempty" json:"location,omitempty"`
+	StartTime    int64            `bson:"startTime" json:"startTime"` // 第一次的开始时间
+	EndTime      int64            `bson:"endTime" json:"endTime"`     // 第一次的结束时间
+	AllDay       bool             `bson:"allDay,omitempty" json:"allDay,omitempty"`
+	Participants []string         `bson:"participants,omitempty" json:"participants,omitempty"` // 参与人ID
+	DepId        string           `bson:"depId,omitempty" json:"depId,omitempty"`               // 共享到的部门
+	Recurrence   *EventRecurrence `bson:"recurrence,omitempty" json:"recurrence,omitempty"`     // 重复规则，为空不重复
+	Remind       int              `bson:"remind,omitempty" json:"remind,omitempty"`             // 提前几分钟提醒，0不提醒
+	RemindAt     int64            `bson:"remindAt,omitempty" json:"remindAt,omitempty"`         // 下一次提醒时间，没有待发送的提醒时为0
+
+	UpdateAt int64 `bson:"updateAt,omitempty" json:"updateAt,omitempty"`
+	CreateAt int64 `bson:"createAt,omitempty" json:"createAt,omitempty"`
+}
+
+// EventRecurrence 日程的重复规则
+type EventRecurrence struct {
+	Freq     string `bson:"freq" json:"freq"`                             // daily weekly monthly yearly
+	Interval int    `bson:"interval,omitempty" json:"interval,omitempty"` // 间隔，默认1
+	Until    int64  `bson:"until,omitempty" json:"until,omitempty"`       // 重复截止时间，0不限
+	Count    int    `bson:"count,omitempty" json:"count,omitempty"`       // 共发生几次，0不限
+}
+
+// Rule 转换为重复规则，不重复时返回零值
+func (m *Event) Rule() calendar.Recurrence {
+	if m.Recurrence == nil {
+		return calendar.Recurrence{}
+	}
+	r := calendar.Recurrence{
+		Freq:     m.Recurrence.Freq,
+		Interval: m.Recurrence.Interval,
+		Count:    m.Recurrence.Count,
+	}
+	if m.Recurrence.Until > 0 {
+		r.Until = timeutils.Unix(m.Recurrence.Until)
+	}
+	return r
+}
+
+// Occurrences 与 [from, to) 有交集的各次开始时间，按服务时区计算重复
+func (m *Event) Occurrences(from, to int64) []int64 {
+	list := m.Rule().Occurrences(timeutils.Unix(m.StartTime), m.Duration(), timeutils.Unix(from), timeutils.Unix(to))
+	res := make([]int64, 0, len(list))
+	for _, t := range list {
+		res = append(res, t.Unix())
+	}
+	return res
+}
+
+// NextRemindAt 晚于after的下一次提醒时间，没有需要提醒的时返回0
+func (m *Event) NextRemindAt(after int64) int64 {
+	if m.Remind <= 0 {
+		return 0
+	}
+	before := time.Duration(m.Remind) * time.Minute
+	next, ok := m.Rule().Next(timeutils.Unix(m.StartTime), timeutils.Unix(after).Add(before))
+	if !ok {
+		return 0
+	}
+	return next.Add(-before).Unix()
+}
+
+// Duration 每次的时长
+func (m *Event) Duration() time.Duration {
+	return time.Duration(m.EndTime-m.StartTime) * time.Second
+}
+
+// Visible 用户能否查看
+func (m *Event) Visible(userId string, depIds []string) bool {
+	if m.UserId == userId || (m.DepId != "" && slices.Contains(depIds, m.DepId)) {
+		return true
+	}
+	return slices.Contains(m.Participants, userId)
+}
@@ -0,0 +1,103 @@
+package model
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// indexes 各集合依赖的索引，key为集合名
+var indexes = map[string][]mongo.IndexModel{
+	// 用户：登录和AI解析姓名时按姓名查询
+	"user": {
+		{Keys: bson.D{{Key: "name", Value: 1}}},
+	},
+	// 部门成员：按部门、按用户以及部门+用户查询
+	"departmentuser": {
+		{Keys: bson.D{{Key: "depId", Value: 1}, {Key: "userId", Value: 1}}},
+		{Keys: bson.D{{Key: "userId", Value: 1}}},
+	},
+	// 用户待办关联：按用户、按待办以及用户+待办查询
+	"user_todo": {
+		{Keys: bson.D{{Key: "userId", Value: 1}, {Key: "todoId", Value: 1}}},
+		{Keys: bson.D{{Key: "todoId", Value: 1}}},
+	},
+	// 聊天记录：按会话倒序翻页
+	"chat_log": {
+		{Keys: bson.D{{Key: "conversationId", Value: 1}, {Key: "SendTime", Value: -1}}},
+	},
+	// AI对话记忆：每轮对话按会话加载最近的消息，清空时按会话删除
+	"ai_memory": {
+		{Keys: bson.D{{Key: "sessionId", Value: 1}, {Key: "_id", Value: -1}}},
+	},
+	// 审批：当前审批人的待处理审批
+	"approval": {
+		{Keys: bson.D{{Key: "approvalId", Value: 1}, {Key: "status", Value: 1}}},
+	},
+	// 上传文件：统计用户已用空间
+	"upload_file": {
+		{Keys: bson.D{{Key: "userId", Value: 1}}},
+	},
+	// 审计记录：按时间、操作人和资源查询
+	"audit_log": {
+		{Keys: bson.D{{Key: "createAt", Value: -1}}},
+		{Keys: bson.D{{Key: "actorId", Value: 1}, {Key: "createAt", Value: -1}}},
+		{Keys: bson.D{{Key: "resource", Value: 1}, {Key: "resourceId", Value: 1}, {Key: "createAt", Value: -1}}},
+	},
+	// 考勤：每人每天一条记录，按月份范围查询
+	"attendance": {
+		{Keys: bson.D{{Key: "userId", Value: 1}, {Key: "day", Value: 1}}, Options: options.Index().SetUnique(true)},
+	},
+	// 日程：按创建人、参与人和共享部门查询，按提醒时间扫描到期提醒
+	"event": {
+		{Keys: bson.D{{Key: "userId", Value: 1}, {Key: "startTime", Value: 1}}},
+		{Keys: bson.D{{Key: "participants", Value: 1}, {Key: "startTime", Value: 1}}},
+		{Keys: bson.D{{Key: "depId", Value: 1}, {Key: "startTime", Value: 1}}},
+		{Keys: bson.D{{Key: "remindAt", Value: 1}}},
+	},
+	// 公告：按置顶和发布时间排序，按发布时间扫描待推送的公告
+	"announcement": {
+		{Keys: bson.D{{Key: "pinned", Value: -1}, {Key: "publishAt", Value: -1}}},
+		{Keys: bson.D{{Key: "delivered", Value: 1}, {Key: "publishAt", Value: 1}}},
+	},
+	// 公告已读：每人每条公告一条记录
+	"announcement_read": {
+		{Keys: bson.D{{Key: "announcementId", Value: 1}, {Key: "userId", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "userId", Value: 1}}},
+	},
+	// 流程定义：按标识发起
+	"workflow_definition": {
+		{Keys: bson.D{{Key: "key", Value: 1}}, Options: options.Index().SetUnique(true)},
+	},
+	// 流程实例：我发起的、待我处理的、我参与过的
+	"workflow_instance": {
+		{Keys: bson.D{{Key: "userId", Value: 1}, {Key: "createAt", Value: -1}}},
+		{Keys: bson.D{{Key: "pending", Value: 1}, {Key: "createAt", Value: -1}}},
+		{Keys: bson.D{{Key: "participation", Value: 1}, {Key: "createAt", Value: -1}}},
+	},
+	// 待办：按截止时间扫描到期提醒
+	"todo": {
+		{Keys: bson.D{{Key: "deadlineAt", Value: 1}}},
+	},
+}
+
+// EnsureIndexes 启动时创建缺失的索引，已存在的相同索引不会重复创建；
+// 同名但定义不同的索引会返回错误，需要人工处理
+func EnsureIndexes(ctx context.Context, db *mongo.Database) error {
+	names := make([]string, 0, len(indexes))
+	for name := range indexes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if _, err := db.Collection(name).Indexes().CreateMany(ctx, indexes[name]); err != nil {
+			return fmt.Errorf("ensure indexes of %s: %w", name, err)
+		}
+	}
+	return nil
+}
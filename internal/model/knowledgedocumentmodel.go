@@ -0,0 +1,161 @@
+package model
+
+import (
+	"context"
+	"regexp"
+	"time"
+
+	"aiOffice/pkg/pagex"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type KnowledgeDocumentModel interface {
+	Insert(ctx context.Context, data *KnowledgeDocument) error
+	FindOne(ctx context.Context, id string) (*KnowledgeDocument, error)
+	Update(ctx context.Context, data *KnowledgeDocument) error
+	Delete(ctx context.Context, id string) error
+	List(ctx context.Context, name string, depIds []string, page pagex.Page) ([]*KnowledgeDocument, int64, error)
+	ExistsByDepIds(ctx context.Context, depIds []string) ([]string, error)
+	FindByName(ctx context.Context, index, name string) (*KnowledgeDocument, error)
+	FindByPaths(ctx context.Context, paths []string) ([]*KnowledgeDocument, error)
+	FindBySource(ctx context.Context, source string) (*KnowledgeDocument, error)
+}
+
+type defaultKnowledgeDocumentModel struct {
+	col *mongo.Collection
+}
+
+func NewKnowledgeDocumentModel(db *mongo.Database) KnowledgeDocumentModel {
+	col := db.Collection("knowledge_document")
+	return &defaultKnowledgeDocumentModel{
+		col: col,
+	}
+}
+
+func (m *defaultKnowledgeDocumentModel) Insert(ctx context.Context, data *KnowledgeDocument) error {
+	if data.ID.IsZero() {
+		data.ID = primitive.NewObjectID()
+		data.CreateAt = time.Now().Unix()
+		data.UpdateAt = time.Now().Unix()
+	}
+
+	_, err := m.col.InsertOne(ctx, data)
+	return err
+}
+
+func (m *defaultKnowledgeDocumentModel) FindOne(ctx context.Context, id string) (*KnowledgeDocument, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, ErrInvalidObjectId
+	}
+
+	var data KnowledgeDocument
+	err = m.col.FindOne(ctx, bson.M{"_id": oid}).Decode(&data)
+	switch err {
+	case nil:
+		return &data, nil
+	case mongo.ErrNoDocuments:
+		return nil, ErrNotFound
+	default:
+		return nil, err
+	}
+}
+
+func (m *defaultKnowledgeDocumentModel) Update(ctx context.Context, data *KnowledgeDocument) error {
+	data.UpdateAt = time.Now().Unix()
+	_, err := m.col.UpdateOne(ctx, bson.M{"_id": data.ID}, bson.M{"$set": data})
+	return err
+}
+
+func (m *defaultKnowledgeDocumentModel) Delete(ctx context.Context, id string) error {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return ErrInvalidObjectId
+	}
+	_, err = m.col.DeleteOne(ctx, bson.M{"_id": oid})
+	return err
+}
+
+// FindByName 查询索引中上传的同名文档（不含外部同步的页面），用于重新上传时替换
+func (m *defaultKnowledgeDocumentModel) FindByName(ctx context.Context, index, name string) (*KnowledgeDocument, error) {
+	var data KnowledgeDocument
+	err := m.col.FindOne(ctx, bson.M{"index": index, "name": name, "source": bson.M{"$exists": false}},
+		options.FindOne().SetSort(bson.D{{Key: "_id", Value: -1}})).Decode(&data)
+	switch err {
+	case nil:
+		return &data, nil
+	case mongo.ErrNoDocuments:
+		return nil, ErrNotFound
+	default:
+		return nil, err
+	}
+}
+
+// FindBySource 查询外部知识库同步的页面
+func (m *defaultKnowledgeDocumentModel) FindBySource(ctx context.Context, source string) (*KnowledgeDocument, error) {
+	var data KnowledgeDocument
+	err := m.col.FindOne(ctx, bson.M{"source": source}).Decode(&data)
+	switch err {
+	case nil:
+		return &data, nil
+	case mongo.ErrNoDocuments:
+		return nil, ErrNotFound
+	default:
+		return nil, err
+	}
+}
+
+// FindByPaths 按文件保存路径查询，用于由向量块的source找到所属文档
+func (m *defaultKnowledgeDocumentModel) FindByPaths(ctx context.Context, paths []string) ([]*KnowledgeDocument, error) {
+	var list []*KnowledgeDocument
+	err := entityList(ctx, m.col, bson.M{"path": bson.M{"$in": paths}}, &list,
+		options.Find().SetProjection(bson.M{"chunkIds": 0, "staleIds": 0}))
+	return list, err
+}
+
+// List 按上传时间倒序分页查询，name为空不按名称过滤，depIds为nil不按部门过滤（""为公共知识库）
+func (m *defaultKnowledgeDocumentModel) List(ctx context.Context, name string, depIds []string, page pagex.Page) ([]*KnowledgeDocument, int64, error) {
+	filter := bson.M{}
+	if depIds != nil {
+		filter["depId"] = bson.M{"$in": depIds}
+	}
+	if name != "" {
+		filter["name"] = bson.M{"$regex": primitive.Regex{Pattern: regexp.QuoteMeta(name), Options: "i"}}
+	}
+
+	total, err := m.col.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	opts := page.Options("-_id").SetProjection(bson.M{"chunkIds": 0, "staleIds": 0})
+
+	var list []*KnowledgeDocument
+	if err := entityList(ctx, m.col, filter, &list, opts); err != nil {
+		return nil, 0, err
+	}
+	return list, total, nil
+}
+
+// ExistsByDepIds 返回已有入库文档的部门
+func (m *defaultKnowledgeDocumentModel) ExistsByDepIds(ctx context.Context, depIds []string) ([]string, error) {
+	res, err := m.col.Distinct(ctx, "depId", bson.M{
+		"depId":  bson.M{"$in": depIds},
+		"status": KnowledgeDocDone,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	list := make([]string, 0, len(res))
+	for _, v := range res {
+		if id, ok := v.(string); ok {
+			list = append(list, id)
+		}
+	}
+	return list, nil
+}
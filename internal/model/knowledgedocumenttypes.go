@@ -0,0 +1,35 @@
+package model
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// 知识库文档状态
+const (
+	KnowledgeDocPending    = iota + 1 // 等待处理
+	KnowledgeDocProcessing            // 处理中
+	KnowledgeDocDone                  // 已入库
+	KnowledgeDocFailed                // 入库失败
+)
+
+// KnowledgeDocument 知识库中的文档，记录向量块的key用于删除
+type KnowledgeDocument struct {
+	ID primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+
+	Name       string   `bson:"name" json:"name"`                         // 文件名（上传时的原始文件名）
+	Path       string   `bson:"path" json:"path"`                         // 文件保存路径
+	Hash       string   `bson:"hash" json:"hash"`                         // 文件内容sha256，重复上传相同内容时不重新入库
+	Version    int      `bson:"version" json:"version"`                   // 同名文件每次重新上传加1
+	UploaderId string   `bson:"uploaderId" json:"uploaderId"`             // 上传人
+	DepId      string   `bson:"depId" json:"depId"`                       // 所属部门，为空表示公共知识库
+	Source     string   `bson:"source,omitempty" json:"source,omitempty"` // 外部知识库同步的页面，如 confluence:12345
+	Index      string   `bson:"index" json:"index"`                       // 向量索引名称
+	ChunkCount int      `bson:"chunkCount" json:"chunkCount"`
+	ChunkIds   []string `bson:"chunkIds,omitempty" json:"-"` // 向量块在Redis中的key
+	StaleIds   []string `bson:"staleIds,omitempty" json:"-"` // 待清理的向量块：被替换的旧版本或入库失败时已写入的部分
+	Status     int      `bson:"status" json:"status"`        // 1=等待处理 2=处理中 3=已入库 4=失败
+	Error      string   `bson:"error,omitempty" json:"error,omitempty"`
+
+	UpdateAt int64 `bson:"updateAt,omitempty" json:"updateAt,omitempty"`
+	CreateAt int64 `bson:"createAt,omitempty" json:"createAt,omitempty"`
+}
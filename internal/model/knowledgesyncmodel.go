@@ -0,0 +1,54 @@
+package model
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type KnowledgeSyncModel interface {
+	FindByConnector(ctx context.Context, connector string) (*KnowledgeSync, error)
+	Upsert(ctx context.Context, data *KnowledgeSync) error
+}
+
+type defaultKnowledgeSyncModel struct {
+	col *mongo.Collection
+}
+
+func NewKnowledgeSyncModel(db *mongo.Database) KnowledgeSyncModel {
+	col := db.Collection("knowledge_sync")
+	return &defaultKnowledgeSyncModel{
+		col: col,
+	}
+}
+
+func (m *defaultKnowledgeSyncModel) FindByConnector(ctx context.Context, connector string) (*KnowledgeSync, error) {
+	var data KnowledgeSync
+	err := m.col.FindOne(ctx, bson.M{"connector": connector}).Decode(&data)
+	switch err {
+	case nil:
+		return &data, nil
+	case mongo.ErrNoDocuments:
+		return nil, ErrNotFound
+	default:
+		return nil, err
+	}
+}
+
+func (m *defaultKnowledgeSyncModel) Upsert(ctx context.Context, data *KnowledgeSync) error {
+	now := time.Now().Unix()
+	return entityUpdateOrInsert(ctx, m.col, bson.M{
+		"connector": data.Connector,
+	}, bson.M{
+		"$set": bson.M{
+			"cursor":   data.Cursor,
+			"pages":    data.Pages,
+			"error":    data.Error,
+			"syncAt":   data.SyncAt,
+			"updateAt": now,
+		},
+		"$setOnInsert": bson.M{"createAt": now},
+	})
+}
@@ -0,0 +1,19 @@
+package model
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// KnowledgeSync 外部知识库的同步状态，每个连接器一条
+type KnowledgeSync struct {
+	ID primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+
+	Connector string `bson:"connector" json:"connector"`             // confluence/notion/feishu
+	Cursor    string `bson:"cursor" json:"cursor"`                   // 连接器返回的增量游标
+	Pages     int    `bson:"pages" json:"pages"`                     // 最近一次同步提交入库的页面数
+	Error     string `bson:"error,omitempty" json:"error,omitempty"` // 最近一次同步的错误
+	SyncAt    int64  `bson:"syncAt" json:"syncAt"`                   // 最近一次同步时间
+
+	UpdateAt int64 `bson:"updateAt,omitempty" json:"updateAt,omitempty"`
+	CreateAt int64 `bson:"createAt,omitempty" json:"createAt,omitempty"`
+}
@@ -0,0 +1,45 @@
+package model
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type ModerationLogModel interface {
+	Insert(ctx context.Context, data *ModerationLog) error
+}
+
+type defaultModerationLogModel struct {
+	col    *mongo.Collection
+	cipher MsgCipher
+}
+
+// NewModerationLogModel 命中内容与聊天记录使用同一个加密器，cipher 为 nil 时明文存储
+func NewModerationLogModel(db *mongo.Database, cipher MsgCipher) ModerationLogModel {
+	col := db.Collection("moderation_log")
+	return &defaultModerationLogModel{
+		col:    col,
+		cipher: cipher,
+	}
+}
+
+func (m *defaultModerationLogModel) Insert(ctx context.Context, data *ModerationLog) error {
+	if data.ID.IsZero() {
+		data.ID = primitive.NewObjectID()
+		data.CreateAt = time.Now().Unix()
+	}
+
+	doc := *data
+	if m.cipher != nil && doc.Content != "" {
+		content, err := m.cipher.Encrypt(doc.Content)
+		if err != nil {
+			return err
+		}
+		doc.Content = content
+	}
+	_, err := m.col.InsertOne(ctx, &doc)
+	return err
+}
@@ -0,0 +1,19 @@
+package model
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ModerationLog 内容审核命中记录
+type ModerationLog struct {
+	ID primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+
+	UserId  string `bson:"userId" json:"userId"`
+	Stage   string `bson:"stage" json:"stage"`     // input=用户输入 output=模型回复
+	Action  string `bson:"action" json:"action"`   // block=拦截 flag=放行
+	Checker string `bson:"checker" json:"checker"` // 命中的审核方式
+	Reason  string `bson:"reason" json:"reason"`
+	Content string `bson:"content" json:"content"` // 命中的内容
+
+	CreateAt int64 `bson:"createAt,omitempty" json:"createAt,omitempty"`
+}
@@ -0,0 +1,52 @@
+package model
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type PromptModel interface {
+	Upsert(ctx context.Context, data *Prompt) error
+	FindAll(ctx context.Context) ([]*Prompt, error)
+	Delete(ctx context.Context, key, tenant string) error
+}
+
+type defaultPromptModel struct {
+	col *mongo.Collection
+}
+
+func NewPromptModel(db *mongo.Database) PromptModel {
+	col := db.Collection("prompt")
+	return &defaultPromptModel{
+		col: col,
+	}
+}
+
+func (m *defaultPromptModel) Upsert(ctx context.Context, data *Prompt) error {
+	now := time.Now().Unix()
+	return entityUpdateOrInsert(ctx, m.col, bson.M{"key": data.Key, "tenant": data.Tenant}, bson.M{
+		"$set": bson.M{
+			"key":      data.Key,
+			"tenant":   data.Tenant,
+			"template": data.Template,
+			"updateAt": now,
+		},
+		"$setOnInsert": bson.M{"createAt": now},
+	})
+}
+
+func (m *defaultPromptModel) FindAll(ctx context.Context) ([]*Prompt, error) {
+	var list []*Prompt
+	if err := entityList(ctx, m.col, bson.M{}, &list); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+func (m *defaultPromptModel) Delete(ctx context.Context, key, tenant string) error {
+	_, err := m.col.DeleteOne(ctx, bson.M{"key": key, "tenant": tenant})
+	return err
+}
@@ -0,0 +1,17 @@
+package model
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Prompt 提示词模板，覆盖代码中的默认提示词
+type Prompt struct {
+	ID primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+
+	Key      string `bson:"key" json:"key"`                 // 提示词名称，如 router、default、agent.system
+	Tenant   string `bson:"tenant" json:"tenant,omitempty"` // 租户（部门ID），为空表示全局
+	Template string `bson:"template" json:"template"`       // Go template 格式
+
+	UpdateAt int64 `bson:"updateAt,omitempty" json:"updateAt,omitempty"`
+	CreateAt int64 `bson:"createAt,omitempty" json:"createAt,omitempty"`
+}
@@ -0,0 +1,45 @@
+package model
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type RouteLogModel interface {
+	Insert(ctx context.Context, data *RouteLog) error
+}
+
+type defaultRouteLogModel struct {
+	col    *mongo.Collection
+	cipher MsgCipher
+}
+
+// NewRouteLogModel 用户输入与聊天记录使用同一个加密器，cipher 为 nil 时明文存储
+func NewRouteLogModel(db *mongo.Database, cipher MsgCipher) RouteLogModel {
+	col := db.Collection("route_log")
+	return &defaultRouteLogModel{
+		col:    col,
+		cipher: cipher,
+	}
+}
+
+func (m *defaultRouteLogModel) Insert(ctx context.Context, data *RouteLog) error {
+	if data.ID.IsZero() {
+		data.ID = primitive.NewObjectID()
+		data.CreateAt = time.Now().Unix()
+	}
+
+	doc := *data
+	if m.cipher != nil && doc.Input != "" {
+		input, err := m.cipher.Encrypt(doc.Input)
+		if err != nil {
+			return err
+		}
+		doc.Input = input
+	}
+	_, err := m.col.InsertOne(ctx, &doc)
+	return err
+}
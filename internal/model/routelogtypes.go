@@ -0,0 +1,20 @@
+package model
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// RouteLog AI对话的路由决策，用于离线评估误路由
+type RouteLog struct {
+	ID primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+
+	UserId     string  `bson:"userId" json:"userId"`
+	Input      string  `bson:"input" json:"input"`
+	Output     string  `bson:"output" json:"output"`                         // 模型的原始输出，关键词命中时为 keyword:<关键词>
+	Handler    string  `bson:"handler,omitempty" json:"handler,omitempty"`   // 最终使用的处理器，追问时为空
+	Confidence float64 `bson:"confidence" json:"confidence"`                 // 模型给出的置信度
+	Fallback   string  `bson:"fallback,omitempty" json:"fallback,omitempty"` // 不确定时的处理 clarify=追问 default=默认处理器
+	Duration   int64   `bson:"duration" json:"duration"`                     // 路由耗时（毫秒）
+
+	CreateAt int64 `bson:"createAt,omitempty" json:"createAt,omitempty"`
+}
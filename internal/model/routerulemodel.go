@@ -0,0 +1,32 @@
+package model
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type RouteRuleModel interface {
+	FindAll(ctx context.Context) ([]*RouteRule, error)
+}
+
+type defaultRouteRuleModel struct {
+	col *mongo.Collection
+}
+
+func NewRouteRuleModel(db *mongo.Database) RouteRuleModel {
+	col := db.Collection("route_rule")
+	return &defaultRouteRuleModel{
+		col: col,
+	}
+}
+
+func (m *defaultRouteRuleModel) FindAll(ctx context.Context) ([]*RouteRule, error) {
+	var list []*RouteRule
+	if err := entityList(ctx, m.col, bson.M{}, &list, options.Find().SetSort(bson.D{{Key: "sort", Value: 1}, {Key: "_id", Value: 1}})); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
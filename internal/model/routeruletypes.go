@@ -0,0 +1,19 @@
+package model
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// RouteRule AI对话的路由规则，配置了某个handler的规则后替换handler代码中的规则
+type RouteRule struct {
+	ID primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+
+	Handler     string   `bson:"handler" json:"handler"`                       // 处理器名称，如 todo、approval
+	Description string   `bson:"description" json:"description"`               // 用户意图，写入路由提示词
+	Keywords    []string `bson:"keywords,omitempty" json:"keywords,omitempty"` // 输入包含关键词时直接选择该处理器
+	Examples    []string `bson:"examples,omitempty" json:"examples,omitempty"` // 示例输入
+	Sort        int      `bson:"sort" json:"sort"`                             // 排序，小的在前，关键词按此顺序匹配
+
+	UpdateAt int64 `bson:"updateAt,omitempty" json:"updateAt,omitempty"`
+	CreateAt int64 `bson:"createAt,omitempty" json:"createAt,omitempty"`
+}
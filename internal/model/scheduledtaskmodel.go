@@ -0,0 +1,88 @@
+package model
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type ScheduledTaskModel interface {
+	Insert(ctx context.Context, data *ScheduledTask) error
+	FindOne(ctx context.Context, id string) (*ScheduledTask, error)
+	Update(ctx context.Context, data *ScheduledTask) error
+	Delete(ctx context.Context, id string) error
+	// enabledOnly 为 true 时只返回启用的任务
+	List(ctx context.Context, enabledOnly bool) ([]*ScheduledTask, error)
+}
+
+type defaultScheduledTaskModel struct {
+	col *mongo.Collection
+}
+
+func NewScheduledTaskModel(db *mongo.Database) ScheduledTaskModel {
+	col := db.Collection("scheduled_task")
+	return &defaultScheduledTaskModel{
+		col: col,
+	}
+}
+
+func (m *defaultScheduledTaskModel) Insert(ctx context.Context, data *ScheduledTask) error {
+	if data.ID.IsZero() {
+		data.ID = primitive.NewObjectID()
+		data.CreateAt = time.Now().Unix()
+		data.UpdateAt = time.Now().Unix()
+	}
+
+	_, err := m.col.InsertOne(ctx, data)
+	return err
+}
+
+func (m *defaultScheduledTaskModel) FindOne(ctx context.Context, id string) (*ScheduledTask, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, ErrInvalidObjectId
+	}
+
+	var data ScheduledTask
+	err = m.col.FindOne(ctx, bson.M{"_id": oid}).Decode(&data)
+	switch err {
+	case nil:
+		return &data, nil
+	case mongo.ErrNoDocuments:
+		return nil, ErrNotFound
+	default:
+		return nil, err
+	}
+}
+
+func (m *defaultScheduledTaskModel) Update(ctx context.Context, data *ScheduledTask) error {
+	data.UpdateAt = time.Now().Unix()
+	_, err := m.col.UpdateOne(ctx, bson.M{"_id": data.ID}, bson.M{"$set": data})
+	return err
+}
+
+func (m *defaultScheduledTaskModel) Delete(ctx context.Context, id string) error {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return ErrInvalidObjectId
+	}
+	_, err = m.col.DeleteOne(ctx, bson.M{"_id": oid})
+	return err
+}
+
+func (m *defaultScheduledTaskModel) List(ctx context.Context, enabledOnly bool) ([]*ScheduledTask, error) {
+	filter := bson.M{}
+	if enabledOnly {
+		filter["enabled"] = true
+	}
+
+	var list []*ScheduledTask
+	if err := entityList(ctx, m.col, filter, &list, options.Find().SetSort(bson.D{{Key: "_id", Value: 1}})); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
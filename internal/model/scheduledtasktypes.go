@@ -0,0 +1,21 @@
+package model
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ScheduledTask 管理员在运行时添加的定时任务，由 PeriodicTaskManager 定期同步注册
+type ScheduledTask struct {
+	ID primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+
+	Name      string `bson:"name" json:"name"`
+	Cron      string `bson:"cron" json:"cron"`         // cron 表达式（分 时 日 月 周）
+	TaskType  string `bson:"taskType" json:"taskType"` // 任务类型，如 reminder:todo
+	Payload   string `bson:"payload" json:"payload"`   // 任务载荷（JSON）
+	Queue     string `bson:"queue" json:"queue"`
+	Enabled   bool   `bson:"enabled" json:"enabled"`
+	CreatorId string `bson:"creatorId" json:"creatorId"`
+
+	UpdateAt int64 `bson:"updateAt,omitempty" json:"updateAt,omitempty"`
+	CreateAt int64 `bson:"createAt,omitempty" json:"createAt,omitempty"`
+}
@@ -5,6 +5,8 @@ import (
 	"context"
 	"time"
 
+	"aiOffice/pkg/pagex"
+
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
@@ -16,8 +18,14 @@ type TodoModel interface {
 	FindOne(ctx context.Context, id string) (*Todo, error)
 	Update(ctx context.Context, data *Todo) error
 	Delete(ctx context.Context, id string) error
-	List(ctx context.Context, userId string, startTime, endTime int64, page, count int) ([]*Todo, int64, error)
+	List(ctx context.Context, ids []string, startTime, endTime int64, page pagex.Page) ([]*Todo, int64, error)
 	FindByIds(ctx context.Context, ids []string) ([]*Todo, error)
+	// 最后更新早于before的已完成待办，最多返回limit个id
+	FindFinishedIds(ctx context.Context, before int64, limit int) ([]string, error)
+	CountFinished(ctx context.Context, before int64) (int64, error)
+	DeleteByIds(ctx context.Context, ids []string) (int64, error)
+	// 在[startTime, endTime]内完成的待办
+	FindFinishedBetween(ctx context.Context, startTime, endTime int64) ([]*Todo, error)
 }
 
 type defaultTodoModel struct {
@@ -75,11 +83,18 @@ func (m *defaultTodoModel) Delete(ctx context.Context, id string) error {
 	return err
 }
 
-func (m *defaultTodoModel) List(ctx context.Context, userId string, startTime, endTime int64, page, count int) ([]*Todo, int64, error) {
+// List 分页查询，ids不为nil时只查询其中的待办，默认按创建时间倒序
+func (m *defaultTodoModel) List(ctx context.Context, ids []string, startTime, endTime int64, page pagex.Page) ([]*Todo, int64, error) {
 	filter := bson.M{}
 
-	if userId != "" {
-		filter["creatorId"] = userId
+	if ids != nil {
+		oids := make([]primitive.ObjectID, 0, len(ids))
+		for _, id := range ids {
+			if oid, err := primitive.ObjectIDFromHex(id); err == nil {
+				oids = append(oids, oid)
+			}
+		}
+		filter["_id"] = bson.M{"$in": oids}
 	}
 
 	if startTime > 0 {
@@ -98,16 +113,7 @@ func (m *defaultTodoModel) List(ctx context.Context, userId string, startTime, e
 		return nil, 0, err
 	}
 
-	if page < 1 {
-		page = 1
-	}
-	if count < 1 {
-		count = 10
-	}
-	skip := int64((page - 1) * count)
-
-	opts := options.Find().SetSkip(skip).SetLimit(int64(count)).SetSort(bson.M{"createAt": -1})
-	cursor, err := m.col.Find(ctx, filter, opts)
+	cursor, err := m.col.Find(ctx, filter, page.Options("-createAt", "createAt", "deadlineAt", "updateAt"))
 	if err != nil {
 		return nil, 0, err
 	}
@@ -143,3 +149,56 @@ func (m *defaultTodoModel) FindByIds(ctx context.Context, ids []string) ([]*Todo
 	}
 	return todos, nil
 }
+
+func finishedTodoFilter(before int64) bson.M {
+	return bson.M{
+		"todoStatus": 2, // 已完成
+		"updateAt":   bson.M{"$lt": before},
+	}
+}
+
+func (m *defaultTodoModel) FindFinishedIds(ctx context.Context, before int64, limit int) ([]string, error) {
+	var list []*Todo
+	err := entityList(ctx, m.col, finishedTodoFilter(before), &list,
+		options.Find().SetProjection(bson.M{"_id": 1}).SetLimit(int64(limit)))
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, 0, len(list))
+	for _, v := range list {
+		ids = append(ids, v.ID.Hex())
+	}
+	return ids, nil
+}
+
+func (m *defaultTodoModel) CountFinished(ctx context.Context, before int64) (int64, error) {
+	return m.col.CountDocuments(ctx, finishedTodoFilter(before))
+}
+
+func (m *defaultTodoModel) DeleteByIds(ctx context.Context, ids []string) (int64, error) {
+	oids := make([]primitive.ObjectID, 0, len(ids))
+	for _, id := range ids {
+		if oid, err := primitive.ObjectIDFromHex(id); err == nil {
+			oids = append(oids, oid)
+		}
+	}
+
+	res, err := m.col.DeleteMany(ctx, bson.M{"_id": bson.M{"$in": oids}})
+	if err != nil {
+		return 0, err
+	}
+	return res.DeletedCount, nil
+}
+
+func (m *defaultTodoModel) FindFinishedBetween(ctx context.Context, startTime, endTime int64) ([]*Todo, error) {
+	var list []*Todo
+	err := entityList(ctx, m.col, bson.M{
+		"todoStatus": 2, // 已完成
+		"updateAt":   bson.M{"$gte": startTime, "$lte": endTime},
+	}, &list)
+	if err != nil {
+		return nil, err
+	}
+	return list, nil
+}
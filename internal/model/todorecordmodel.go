@@ -17,6 +17,7 @@ type TodoRecordModel interface {
 	Delete(ctx context.Context, id string) error
 	FindByTodoId(ctx context.Context, todoId string) ([]*TodoRecord, error)
 	DeleteByTodoId(ctx context.Context, todoId string) error
+	DeleteByTodoIds(ctx context.Context, todoIds []string) (int64, error)
 }
 
 type defaultTodoRecordModel struct {
@@ -92,3 +93,11 @@ func (m *defaultTodoRecordModel) DeleteByTodoId(ctx context.Context, todoId stri
 	_, err := m.col.DeleteMany(ctx, bson.M{"todoId": todoId})
 	return err
 }
+
+func (m *defaultTodoRecordModel) DeleteByTodoIds(ctx context.Context, todoIds []string) (int64, error) {
+	res, err := m.col.DeleteMany(ctx, bson.M{"todoId": bson.M{"$in": todoIds}})
+	if err != nil {
+		return 0, err
+	}
+	return res.DeletedCount, nil
+}
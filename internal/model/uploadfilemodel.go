@@ -0,0 +1,59 @@
+package model
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type UploadFileModel interface {
+	Insert(ctx context.Context, data *UploadFile) error
+	// 用户已占用的存储空间（字节）
+	SumSize(ctx context.Context, userId string) (int64, error)
+}
+
+type defaultUploadFileModel struct {
+	col *mongo.Collection
+}
+
+func NewUploadFileModel(db *mongo.Database) UploadFileModel {
+	col := db.Collection("upload_file")
+	return &defaultUploadFileModel{
+		col: col,
+	}
+}
+
+func (m *defaultUploadFileModel) Insert(ctx context.Context, data *UploadFile) error {
+	if data.ID.IsZero() {
+		data.ID = primitive.NewObjectID()
+		data.CreateAt = time.Now().Unix()
+	}
+
+	_, err := m.col.InsertOne(ctx, data)
+	return err
+}
+
+func (m *defaultUploadFileModel) SumSize(ctx context.Context, userId string) (int64, error) {
+	cur, err := m.col.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"userId": userId}}},
+		{{Key: "$group", Value: bson.M{"_id": nil, "size": bson.M{"$sum": "$size"}}}},
+	})
+	if err != nil {
+		return 0, err
+	}
+	defer cur.Close(ctx)
+
+	var res []struct {
+		Size int64 `bson:"size"`
+	}
+	if err := cur.All(ctx, &res); err != nil {
+		return 0, err
+	}
+	if len(res) == 0 {
+		return 0, nil
+	}
+	return res[0].Size, nil
+}
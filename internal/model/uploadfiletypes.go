@@ -0,0 +1,19 @@
+package model
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// UploadFile 用户上传的文件，用于统计存储空间
+type UploadFile struct {
+	ID primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+
+	UserId      string `bson:"userId" json:"userId"`                   // 上传用户ID
+	Name        string `bson:"name" json:"name"`                       // 原始文件名
+	File        string `bson:"file" json:"file"`                       // 保存路径
+	Thumb       string `bson:"thumb,omitempty" json:"thumb,omitempty"` // 图片缩略图路径
+	Size        int64  `bson:"size" json:"size"`                       // 文件大小（字节）
+	ContentType string `bson:"contentType" json:"contentType"`         // 按内容识别的类型
+
+	CreateAt int64 `bson:"createAt,omitempty" json:"createAt,omitempty"`
+}
@@ -5,6 +5,8 @@ import (
 	"context"
 	"time"
 
+	"aiOffice/pkg/pagex"
+
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
@@ -15,10 +17,13 @@ type UserModel interface {
 	Insert(ctx context.Context, data *User) error
 	FindOne(ctx context.Context, id string) (*User, error)
 	FindByName(ctx context.Context, name string) (*User, error)
+	FindAllByName(ctx context.Context, name string) ([]*User, error)
 	FindAdminUser(ctx context.Context) (*User, error)
+	FindEmail(ctx context.Context, id string) (string, error)
+	FindPhone(ctx context.Context, id string) (string, error)
 	Update(ctx context.Context, data *User) error
 	Delete(ctx context.Context, id string) error
-	List(ctx context.Context, ids []string, name string, page, count int) ([]*User, int64, error)
+	List(ctx context.Context, ids []string, name string, page pagex.Page) ([]*User, int64, error)
 }
 
 type defaultUserModel struct {
@@ -89,6 +94,13 @@ func (m *defaultUserModel) FindByName(ctx context.Context, name string) (*User,
 	}
 }
 
+// FindAllByName 查询全部同名用户，按创建顺序返回，AI解析姓名时用于区分同名的人
+func (m *defaultUserModel) FindAllByName(ctx context.Context, name string) ([]*User, error) {
+	var list []*User
+	err := entityList(ctx, m.col, bson.M{"name": name}, &list, options.Find().SetSort(bson.M{"_id": 1}))
+	return list, err
+}
+
 func (m *defaultUserModel) FindAdminUser(ctx context.Context) (*User, error) {
 	var user User
 	err := m.col.FindOne(ctx, bson.M{"isAdmin": true}).Decode(&user)
@@ -102,7 +114,25 @@ func (m *defaultUserModel) FindAdminUser(ctx context.Context) (*User, error) {
 	}
 }
 
-func (m *defaultUserModel) List(ctx context.Context, ids []string, name string, page, count int) ([]*User, int64, error) {
+// FindEmail 查询用户邮箱，供邮件通知渠道使用
+func (m *defaultUserModel) FindEmail(ctx context.Context, id string) (string, error) {
+	user, err := m.FindOne(ctx, id)
+	if err != nil {
+		return "", err
+	}
+	return user.Email, nil
+}
+
+// FindPhone 查询用户手机号，供短信通知渠道使用
+func (m *defaultUserModel) FindPhone(ctx context.Context, id string) (string, error) {
+	user, err := m.FindOne(ctx, id)
+	if err != nil {
+		return "", err
+	}
+	return user.Phone, nil
+}
+
+func (m *defaultUserModel) List(ctx context.Context, ids []string, name string, page pagex.Page) ([]*User, int64, error) {
 	filter := bson.M{}
 
 	// 按ID列表查询
@@ -131,20 +161,8 @@ func (m *defaultUserModel) List(ctx context.Context, ids []string, name string,
 		return nil, 0, err
 	}
 
-	// 分页查询
-	if page < 1 {
-		page = 1
-	}
-	if count < 1 {
-		count = 10
-	}
-	skip := int64((page - 1) * count)
-
-	findOptions := options.Find()
-	findOptions.SetSkip(skip)
-	findOptions.SetLimit(int64(count))
-
-	cursor, err := m.col.Find(ctx, filter, findOptions)
+	// 分页查询，默认按创建顺序
+	cursor, err := m.col.Find(ctx, filter, page.Options("_id", "name", "createAt"))
 	if err != nil {
 		return nil, 0, err
 	}
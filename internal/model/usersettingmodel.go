@@ -0,0 +1,69 @@
+package model
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type UserSettingModel interface {
+	FindByUserId(ctx context.Context, userId string) (*UserSetting, error)
+	// 指定了待办提醒时间的用户，reminderTime为空时返回全部指定了时间的用户
+	FindByReminderTime(ctx context.Context, reminderTime string) ([]*UserSetting, error)
+	Upsert(ctx context.Context, data *UserSetting) error
+}
+
+type defaultUserSettingModel struct {
+	col *mongo.Collection
+}
+
+func NewUserSettingModel(db *mongo.Database) UserSettingModel {
+	col := db.Collection("user_setting")
+	return &defaultUserSettingModel{
+		col: col,
+	}
+}
+
+func (m *defaultUserSettingModel) FindByUserId(ctx context.Context, userId string) (*UserSetting, error) {
+	var data UserSetting
+	err := m.col.FindOne(ctx, bson.M{"userId": userId}).Decode(&data)
+	switch err {
+	case nil:
+		return &data, nil
+	case mongo.ErrNoDocuments:
+		return nil, ErrNotFound
+	default:
+		return nil, err
+	}
+}
+
+func (m *defaultUserSettingModel) FindByReminderTime(ctx context.Context, reminderTime string) ([]*UserSetting, error) {
+	filter := bson.M{"reminderTime": bson.M{"$nin": bson.A{"", nil}}}
+	if reminderTime != "" {
+		filter = bson.M{"reminderTime": reminderTime}
+	}
+
+	var list []*UserSetting
+	if err := entityList(ctx, m.col, filter, &list); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+func (m *defaultUserSettingModel) Upsert(ctx context.Context, data *UserSetting) error {
+	now := time.Now().Unix()
+	return entityUpdateOrInsert(ctx, m.col, bson.M{
+		"userId": data.UserId,
+	}, bson.M{
+		"$set": bson.M{
+			"reminderTime": data.ReminderTime,
+			"quietStart":   data.QuietStart,
+			"quietEnd":     data.QuietEnd,
+			"channels":     data.Channels,
+			"updateAt":     now,
+		},
+		"$setOnInsert": bson.M{"createAt": now},
+	})
+}
@@ -0,0 +1,19 @@
+package model
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// UserSetting 用户的提醒偏好，时间均为 HH:MM，按定时任务的时区计算
+type UserSetting struct {
+	ID primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+
+	UserId       string   `bson:"userId" json:"userId"`
+	ReminderTime string   `bson:"reminderTime,omitempty" json:"reminderTime,omitempty"` // 待办提醒时间，为空使用全局的提醒时间
+	QuietStart   string   `bson:"quietStart,omitempty" json:"quietStart,omitempty"`     // 免打扰开始时间，可跨零点，如 22:00
+	QuietEnd     string   `bson:"quietEnd,omitempty" json:"quietEnd,omitempty"`         // 免打扰结束时间，如 08:00
+	Channels     []string `bson:"channels,omitempty" json:"channels,omitempty"`         // 离线推送渠道 fcm/apns/email/webhook，为空使用全部渠道
+
+	UpdateAt int64 `bson:"updateAt,omitempty" json:"updateAt,omitempty"`
+	CreateAt int64 `bson:"createAt,omitempty" json:"createAt,omitempty"`
+}
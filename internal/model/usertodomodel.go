@@ -19,6 +19,7 @@ type UserTodoModel interface {
 	FindByUserId(ctx context.Context, userId string) ([]*UserTodo, error)
 	FindByUserIdAndTodoId(ctx context.Context, userId, todoId string) (*UserTodo, error)
 	DeleteByTodoId(ctx context.Context, todoId string) error
+	DeleteByTodoIds(ctx context.Context, todoIds []string) (int64, error)
 }
 
 type defaultUserTodoModel struct {
@@ -121,3 +122,11 @@ func (m *defaultUserTodoModel) DeleteByTodoId(ctx context.Context, todoId string
 	_, err := m.col.DeleteMany(ctx, bson.M{"todoId": todoId})
 	return err
 }
+
+func (m *defaultUserTodoModel) DeleteByTodoIds(ctx context.Context, todoIds []string) (int64, error) {
+	res, err := m.col.DeleteMany(ctx, bson.M{"todoId": bson.M{"$in": todoIds}})
+	if err != nil {
+		return 0, err
+	}
+	return res.DeletedCount, nil
+}
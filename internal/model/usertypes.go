@@ -9,6 +9,8 @@ type User struct {
 	// TODO: Fill your own fields
 	Name     string `bson:"name" json:"name"`
 	Password string `bson:"password" json:"password"`
+	Email    string `bson:"email,omitempty" json:"email,omitempty"`
+	Phone    string `bson:"phone,omitempty" json:"phone,omitempty"`
 	Status   int    `bson:"status" json:"status"`
 	IsAdmin  bool   `bson:"isAdmin" json:"isAdmin"`
 	UpdateAt int64  `bson:"updateAt,omitempty" json:"updateAt,omitempty"`
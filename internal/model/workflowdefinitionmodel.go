@@ -0,0 +1,87 @@
+package model
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type WorkflowDefinitionModel interface {
+	Insert(ctx context.Context, data *WorkflowDefinition) error
+	FindOne(ctx context.Context, id string) (*WorkflowDefinition, error)
+	FindByKey(ctx context.Context, key string) (*WorkflowDefinition, error)
+	Update(ctx context.Context, data *WorkflowDefinition) error
+	List(ctx context.Context, enabledOnly bool) ([]*WorkflowDefinition, error)
+}
+
+type defaultWorkflowDefinitionModel struct {
+	col *mongo.Collection
+}
+
+func NewWorkflowDefinitionModel(db *mongo.Database) WorkflowDefinitionModel {
+	col := db.Collection("workflow_definition")
+	return &defaultWorkflowDefinitionModel{
+		col: col,
+	}
+}
+
+func (m *defaultWorkflowDefinitionModel) Insert(ctx context.Context, data *WorkflowDefinition) error {
+	if data.ID.IsZero() {
+		data.ID = primitive.NewObjectID()
+		data.CreateAt = time.Now().Unix()
+		data.UpdateAt = time.Now().Unix()
+	}
+
+	_, err := m.col.InsertOne(ctx, data)
+	return err
+}
+
+func (m *defaultWorkflowDefinitionModel) FindOne(ctx context.Context, id string) (*WorkflowDefinition, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, ErrInvalidObjectId
+	}
+	return m.findOne(ctx, bson.M{"_id": oid})
+}
+
+func (m *defaultWorkflowDefinitionModel) FindByKey(ctx context.Context, key string) (*WorkflowDefinition, error) {
+	return m.findOne(ctx, bson.M{"key": key})
+}
+
+func (m *defaultWorkflowDefinitionModel) findOne(ctx context.Context, filter bson.M) (*WorkflowDefinition, error) {
+	var data WorkflowDefinition
+	err := m.col.FindOne(ctx, filter).Decode(&data)
+	switch err {
+	case nil:
+		return &data, nil
+	case mongo.ErrNoDocuments:
+		return nil, ErrNotFound
+	default:
+		return nil, err
+	}
+}
+
+// Update 整体替换
+func (m *defaultWorkflowDefinitionModel) Update(ctx context.Context, data *WorkflowDefinition) error {
+	data.UpdateAt = time.Now().Unix()
+	_, err := m.col.ReplaceOne(ctx, bson.M{"_id": data.ID}, data)
+	return err
+}
+
+// List 流程定义数量有限，不分页
+func (m *defaultWorkflowDefinitionModel) List(ctx context.Context, enabledOnly bool) ([]*WorkflowDefinition, error) {
+	filter := bson.M{}
+	if enabledOnly {
+		filter["enabled"] = true
+	}
+
+	var list []*WorkflowDefinition
+	if err := entityList(ctx, m.col, filter, &list, options.Find().SetSort(bson.M{"createAt": 1})); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
@@ -0,0 +1,113 @@
+package model
+
+import (
+	"context"
+	"time"
+
+	"aiOffice/pkg/pagex"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// 流程实例列表的查询方式
+const (
+	WorkflowSubmitted = 1 // 我发起的
+	WorkflowPending   = 2 // 待我处理的
+	WorkflowHandled   = 3 // 我参与过的
+)
+
+type WorkflowInstanceModel interface {
+	Insert(ctx context.Context, data *WorkflowInstance) error
+	FindOne(ctx context.Context, id string) (*WorkflowInstance, error)
+	Update(ctx context.Context, data *WorkflowInstance) error
+	List(ctx context.Context, userId string, listType int, key string, page pagex.Page) ([]*WorkflowInstance, int64, error)
+}
+
+type defaultWorkflowInstanceModel struct {
+	col *mongo.Collection
+}
+
+func NewWorkflowInstanceModel(db *mongo.Database) WorkflowInstanceModel {
+	col := db.Collection("workflow_instance")
+	return &defaultWorkflowInstanceModel{
+		col: col,
+	}
+}
+
+func (m *defaultWorkflowInstanceModel) Insert(ctx context.Context, data *WorkflowInstance) error {
+	if data.ID.IsZero() {
+		data.ID = primitive.NewObjectID()
+		data.CreateAt = time.Now().Unix()
+		data.UpdateAt = time.Now().Unix()
+	}
+
+	_, err := m.col.InsertOne(ctx, data)
+	return err
+}
+
+func (m *defaultWorkflowInstanceModel) FindOne(ctx context.Context, id string) (*WorkflowInstance, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, ErrInvalidObjectId
+	}
+
+	var data WorkflowInstance
+	err = m.col.FindOne(ctx, bson.M{"_id": oid}).Decode(&data)
+	switch err {
+	case nil:
+		return &data, nil
+	case mongo.ErrNoDocuments:
+		return nil, ErrNotFound
+	default:
+		return nil, err
+	}
+}
+
+// Update 整体替换，待处理人清空时同样生效；
+// 只在revision与读取时一致时替换，期间被其他请求修改过时返回ErrUpdateConflict
+func (m *defaultWorkflowInstanceModel) Update(ctx context.Context, data *WorkflowInstance) error {
+	filter := bson.M{"_id": data.ID, "revision": data.Revision}
+	if data.Revision == 0 {
+		// 兼容没有revision字段的旧数据
+		filter["revision"] = bson.M{"$in": bson.A{0, nil}}
+	}
+
+	data.Revision++
+	data.UpdateAt = time.Now().Unix()
+	res, err := m.col.ReplaceOne(ctx, filter, data)
+	if err == nil && res.MatchedCount == 0 {
+		err = ErrUpdateConflict
+	}
+	if err != nil {
+		data.Revision--
+	}
+	return err
+}
+
+func (m *defaultWorkflowInstanceModel) List(ctx context.Context, userId string, listType int, key string, page pagex.Page) ([]*WorkflowInstance, int64, error) {
+	filter := bson.M{}
+	switch listType {
+	case WorkflowPending:
+		filter["pending"] = userId
+	case WorkflowHandled:
+		filter["participation"] = userId
+	default:
+		filter["userId"] = userId
+	}
+	if key != "" {
+		filter["key"] = key
+	}
+
+	total, err := m.col.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var list []*WorkflowInstance
+	if err := entityList(ctx, m.col, filter, &list, page.Options("-createAt", "createAt", "updateAt")); err != nil {
+		return nil, 0, err
+	}
+	return list, total, nil
+}
@@ -0,0 +1,57 @@
+package model
+
+import (
+	"aiOffice/pkg/workflow"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// WorkflowDefinition 流程定义，新的审批流程（资产申领、权限开通等）以数据的形式配置
+type WorkflowDefinition struct {
+	ID primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+
+	Key       string              `bson:"key" json:"key"` // 流程标识，唯一
+	Name      string              `bson:"name" json:"name"`
+	Desc      string              `bson:"desc,omitempty" json:"desc,omitempty"`
+	Enabled   bool                `bson:"enabled" json:"enabled"`
+	Version   int                 `bson:"version" json:"version"` // 每次修改加1，进行中的实例使用发起时的定义
+	Flow      workflow.Definition `bson:"flow" json:"flow"`
+	Callbacks []*WorkflowCallback `bson:"callbacks,omitempty" json:"callbacks,omitempty"`
+	CreatorId string              `bson:"creatorId,omitempty" json:"creatorId,omitempty"`
+
+	UpdateAt int64 `bson:"updateAt,omitempty" json:"updateAt,omitempty"`
+	CreateAt int64 `bson:"createAt,omitempty" json:"createAt,omitempty"`
+}
+
+// WorkflowCallback 流程结束时回调的地址
+type WorkflowCallback struct {
+	Event string `bson:"event" json:"event"` // pass refuse cancel
+	Url   string `bson:"url" json:"url"`
+}
+
+// WorkflowInstance 流程实例，保存发起时的流程定义快照
+type WorkflowInstance struct {
+	ID primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+
+	No           string              `bson:"no" json:"no"`
+	DefinitionId string              `bson:"definitionId" json:"definitionId"`
+	Key          string              `bson:"key" json:"key"`
+	Name         string              `bson:"name" json:"name"`
+	Version      int                 `bson:"version" json:"version"`
+	Flow         workflow.Definition `bson:"flow" json:"flow"`
+	Callbacks    []*WorkflowCallback `bson:"callbacks,omitempty" json:"callbacks,omitempty"`
+
+	UserId string         `bson:"userId" json:"userId"` // 申请人
+	Title  string         `bson:"title" json:"title"`
+	Form   map[string]any `bson:"form,omitempty" json:"form,omitempty"`
+
+	workflow.Instance `bson:",inline"`
+	Pending           []string `bson:"pending,omitempty" json:"pending,omitempty"`             // 当前待处理的审批人
+	Participation     []string `bson:"participation,omitempty" json:"participation,omitempty"` // 处理过或正在处理的审批人
+
+	FinishAt int64 `bson:"finishAt,omitempty" json:"finishAt,omitempty"`
+	UpdateAt int64 `bson:"updateAt,omitempty" json:"updateAt,omitempty"`
+	CreateAt int64 `bson:"createAt,omitempty" json:"createAt,omitempty"`
+
+	Revision int64 `bson:"revision" json:"-"` // 每次更新加1，用于并发更新时的比较
+}
@@ -0,0 +1,33 @@
+package svc
+
+import (
+	"context"
+
+	"aiOffice/internal/domain"
+)
+
+// 以下接口由 logic 包实现，启动时由 logic.Inject 注入 ServiceContext，
+// 供AI工具直接调用业务逻辑（toolx 被 logic 引用，不能反向依赖 logic）
+
+// TodoLogic 待办业务逻辑
+type TodoLogic interface {
+	Create(ctx context.Context, req *domain.Todo) (resp *domain.IdResp, err error)
+	List(ctx context.Context, req *domain.TodoListReq) (resp *domain.TodoListResp, err error)
+}
+
+// ApprovalLogic 审批业务逻辑
+type ApprovalLogic interface {
+	Create(ctx context.Context, req *domain.Approval) (resp *domain.IdResp, err error)
+	List(ctx context.Context, req *domain.ApprovalListReq) (resp *domain.ApprovalListResp, err error)
+}
+
+// AttendanceLogic 考勤业务逻辑
+type AttendanceLogic interface {
+	Day(ctx context.Context, req *domain.AttendanceDayReq) (resp *domain.Attendance, err error)
+}
+
+// KnowledgeLogic 知识库业务逻辑
+type KnowledgeLogic interface {
+	Submit(ctx context.Context, filePath, name, depId string) (*domain.KnowledgeDocument, error)
+	Namespaces(ctx context.Context) ([]string, error)
+}
@@ -5,14 +5,45 @@ import (
 	"aiOffice/internal/middleware"
 	"aiOffice/internal/model"
 	"aiOffice/pkg/asynqx"
+	"aiOffice/pkg/calendar"
+	"aiOffice/pkg/curl"
 	"aiOffice/pkg/encrypt"
+	"aiOffice/pkg/i18n"
+	"aiOffice/pkg/knowledge"
+	"aiOffice/pkg/langchain/cachex"
 	"aiOffice/pkg/langchain/callbackx"
+	"aiOffice/pkg/langchain/llmx"
+	"aiOffice/pkg/langchain/memoryx"
+	"aiOffice/pkg/langchain/moderation"
+	"aiOffice/pkg/langchain/promptx"
+	"aiOffice/pkg/langchain/router"
+	"aiOffice/pkg/langchain/slotx"
+	"aiOffice/pkg/limiter"
+	"aiOffice/pkg/mailer"
 	"aiOffice/pkg/mongoutils"
+	"aiOffice/pkg/notify"
+	"aiOffice/pkg/panicx"
+	"aiOffice/pkg/sms"
+	"aiOffice/pkg/speech"
+	"aiOffice/pkg/timeutils"
+	"aiOffice/pkg/tlsx"
+	"aiOffice/pkg/token"
+	"aiOffice/pkg/tracex"
+	"aiOffice/pkg/uploadx"
+	"aiOffice/pkg/wiki"
+	"cmp"
 	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"time"
 
 	"gitee.com/dn-jinmin/tlog"
+	"github.com/hibiken/asynq"
+	"github.com/redis/go-redis/v9"
 	"github.com/tmc/langchaingo/callbacks"
-	"github.com/tmc/langchaingo/llms/openai"
+	"github.com/tmc/langchaingo/embeddings"
+	"github.com/tmc/langchaingo/llms"
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
@@ -20,78 +51,292 @@ type ServiceContext struct {
 	Config config.Config
 
 	// todo repo and pkg object instance
-	Mongo               *mongo.Database
-	UserModel           model.UserModel
-	DepartmentModel     model.DepartmentModel
-	DepartmentuserModel model.DepartmentuserModel
-	TodoRecordModel     model.TodoRecordModel
-	UserTodoModel       model.UserTodoModel
-	TodoModel           model.TodoModel
-	ApprovalModel       model.ApprovalModel
-	ChatLogModel        model.ChatLogModel
-	Jwt                 *middleware.Jwt
-	LLM                 *openai.LLM
-	Cb                  callbacks.Handler
+	Mongo                 *mongo.Database
+	MongoHealth           *mongoutils.Health // 定期检查的mongo连通性，用于 /readyz
+	UserModel             model.UserModel
+	DepartmentModel       model.DepartmentModel
+	DepartmentuserModel   model.DepartmentuserModel
+	TodoRecordModel       model.TodoRecordModel
+	UserTodoModel         model.UserTodoModel
+	TodoModel             model.TodoModel
+	ApprovalModel         model.ApprovalModel
+	ChatLogModel          model.ChatLogModel
+	AIMemoryModel         model.AIMemoryModel
+	AIHistoryModel        model.AIHistoryModel
+	RouteLogModel         model.RouteLogModel
+	AIUsageModel          model.AIUsageModel
+	DeviceTokenModel      model.DeviceTokenModel
+	UserSettingModel      model.UserSettingModel
+	DailySummaryModel     model.DailySummaryModel
+	ScheduledTaskModel    model.ScheduledTaskModel
+	CalendarAccountModel  model.CalendarAccountModel
+	CalendarEventModel    model.CalendarEventModel
+	KnowledgeDocModel     model.KnowledgeDocumentModel
+	KnowledgeSyncModel    model.KnowledgeSyncModel
+	UploadFileModel       model.UploadFileModel
+	AuditLogModel         model.AuditLogModel
+	AttendanceModel       model.AttendanceModel
+	EventModel            model.EventModel
+	AnnouncementModel     model.AnnouncementModel
+	AnnouncementReadModel model.AnnouncementReadModel
+	WorkflowDefModel      model.WorkflowDefinitionModel
+	WorkflowInstanceModel model.WorkflowInstanceModel
+	Jwt                   *middleware.Jwt
+	JwtKeys               []token.Key           // 第一个用于签发token，全部用于校验
+	TokenBlacklist        *token.Blacklist      // 已注销的token
+	Admin                 *middleware.Admin     // 管理员权限，需在Jwt之后使用
+	Signature             *middleware.Signature // 服务间调用的签名认证，未带签名的请求按Jwt认证
+	Audit                 *middleware.Audit     // 变更操作审计
+	LoginLimit            *middleware.RateLimit // 登录按IP限流
+	WriteLimit            *middleware.RateLimit // 变更请求按用户限流，需在Jwt之后使用
+	LLM                   *llmx.Fallback        // 多供应商自动切换
+	Embedder              embeddings.Embedder
+	Cb                    callbacks.Handler
+	Prompts               *promptx.Store        // 可热加载的提示词
+	RouteRules            *router.RuleStore     // 可热加载的路由规则
+	Moderator             *moderation.Moderator // AI输入输出审核，未启用时为nil
+	AnswerCache           *cachex.Cache         // AI回复缓存，未启用时为nil
+	Slots                 *slotx.Store          // 多轮对话中未填完的审批、待办草稿
+	Knowledge             *knowledge.Searcher   // 知识库检索（向量/混合检索、重排）
 
 	// Asynq 异步任务
 	AsynqClient    *asynqx.Client
 	AsynqServer    *asynqx.Server
 	AsynqScheduler *asynqx.Scheduler
 	AsynqMonitor   *asynqx.Monitor
+	AsynqPeriodic  *asynqx.Periodic  // 运行时通过接口添加的定时任务
+	AsynqCollector *asynqx.Collector // 队列指标采集
+
+	// 通知网关
+	Notifier *notify.Notifier
+	Mailer   *mailer.Mailer // 未配置SMTP时为nil
+	Speech   *speech.Client // 未配置语音接口时为nil
+	Upload   *uploadx.Validator
+	Panic    *panicx.Reporter // panic上报
+
+	Redis     redis.UniversalClient
+	TLS       *tls.Config           // http和ws服务的TLS配置，未配置证书时为nil
+	Tracer    *tracex.Provider      // 链路追踪，未配置时为nil
+	AILimiter *limiter.RedisLimiter // AI请求限流，未配置时为nil
+
+	// 外部日历，未启用时为nil
+	Calendars *calendar.Registry
+	// 外部知识库同步，未配置时为空
+	Wikis []wiki.Connector
+
+	// 业务逻辑，启动时由 logic.Inject 注入
+	TodoLogic       TodoLogic
+	ApprovalLogic   ApprovalLogic
+	AttendanceLogic AttendanceLogic
+	KnowledgeLogic  KnowledgeLogic
+
+	// AI对话记忆，启动时由 logic.Inject 创建，api和ws中的AI对话共用
+	AIMemory *memoryx.Memoryx
 }
 
 func NewServiceContext(c config.Config) (*ServiceContext, error) {
+	if c.I18n.Default != "" {
+		if !i18n.Supported(i18n.Lang(c.I18n.Default)) {
+			return nil, fmt.Errorf("unsupported I18n.Default %q", c.I18n.Default)
+		}
+		i18n.SetDefault(i18n.Lang(c.I18n.Default))
+	}
+	timezone := cmp.Or(c.Timezone, c.Asynq.Schedules.Timezone)
+	if err := timeutils.SetTimezone(timezone); err != nil {
+		return nil, fmt.Errorf("invalid Timezone %q: %w", timezone, err)
+	}
+	jwtKeys, err := newJwtKeys(c)
+	if err != nil {
+		return nil, err
+	}
+	err = encrypt.SetPasswordAlgorithm(c.Password.Algorithm, encrypt.Argon2Params{
+		Memory:      c.Password.Argon2.Memory,
+		Iterations:  c.Password.Argon2.Iterations,
+		Parallelism: c.Password.Argon2.Parallelism,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	curl.SetDefault(curl.NewClient(curl.Options{
+		Timeout:      time.Duration(c.Curl.Timeout) * time.Second,
+		Retries:      c.Curl.Retries,
+		Backoff:      time.Duration(c.Curl.Backoff) * time.Millisecond,
+		MaxIdleConns: c.Curl.MaxIdleConns,
+	}))
+
+	tracer := tracex.NewProvider(tracex.Conf{
+		Endpoint:    c.Trace.Endpoint,
+		ServiceName: cmp.Or(c.Name, "aiOffice"),
+		SampleRatio: c.Trace.SampleRatio,
+		Headers:     c.Trace.Headers,
+	})
+	tracex.SetProvider(tracer)
 
+	mongoMonitor := mongoutils.MetricsMonitor()
+	if tracer != nil {
+		mongoMonitor = mongoutils.ChainMonitors(tracex.MongoMonitor(), mongoMonitor)
+	}
 	mongoDB, err := mongoutils.MongoDatabase(&mongoutils.MongodbConfig{
 		User:     c.Mongo.User,
 		Password: c.Mongo.Password,
 		Host:     c.Mongo.Host,
 		Port:     c.Mongo.Port,
 		Database: c.Mongo.Database,
+		Params:   c.Mongo.Param,
+
+		Uri:            c.Mongo.Uri,
+		Srv:            c.Mongo.Srv,
+		AuthSource:     c.Mongo.AuthSource,
+		ReplicaSet:     c.Mongo.ReplicaSet,
+		ReadPreference: c.Mongo.ReadPreference,
+		TLS:            c.Mongo.Tls.Enabled,
+		TLSCAFile:      c.Mongo.Tls.CAFile,
+		TLSInsecure:    c.Mongo.Tls.Insecure,
+
+		MaxPoolSize:            c.Mongo.MaxPoolSize,
+		MinPoolSize:            c.Mongo.MinPoolSize,
+		ConnectTimeout:         time.Duration(c.Mongo.ConnectTimeout) * time.Second,
+		ServerSelectionTimeout: time.Duration(c.Mongo.ServerSelectionTimeout) * time.Second,
+		SocketTimeout:          time.Duration(c.Mongo.SocketTimeout) * time.Second,
+		PingTimeout:            time.Duration(c.Mongo.PingTimeout) * time.Second,
+
+		Monitor: mongoMonitor,
 	})
 	if err != nil {
 		return nil, err
 	}
 
+	// 集合查询依赖的索引，启动时创建或校验
+	indexCtx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	err = model.EnsureIndexes(indexCtx, mongoDB)
+	cancel()
+	if err != nil {
+		return nil, err
+	}
+
+	mongoHealth := newMongoHealth(c, mongoDB)
+	aiUsageModel := model.NewAIUsageModel(mongoDB)
+
 	log := tlog.NewLogger()
 	callbacks := callbacks.CombiningHandler{
 		Callbacks: []callbacks.Handler{
 			callbackx.NewLogHandler(log),
+			callbackx.NewUsageHandler(usageRecorder(aiUsageModel)),
+			callbackx.NewMetricsHandler(),
 		},
 	}
 
-	options := []openai.Option{
-		openai.WithBaseURL(c.LangChain.Url),
-		openai.WithToken(c.LangChain.ApiKey),
-		openai.WithCallback(callbacks),
-		openai.WithEmbeddingModel("text-embedding-v3"),
-		openai.WithModel("qwen3-max"),
+	llm, embedder, err := newLLM(c, callbacks)
+	if err != nil {
+		return nil, err
+	}
+
+	deviceTokenModel := model.NewDeviceTokenModel(mongoDB)
+	userModel := model.NewUserModel(mongoDB)
+	auditLogModel := model.NewAuditLogModel(mongoDB)
+	mail := newMailer(c)
+
+	msgCipher, err := newMsgCipher(c)
+	if err != nil {
+		return nil, err
 	}
-	llm, err := openai.New(options...)
+
+	schedules := asynqx.Schedules{
+		Timezone:         timezone,
+		TodoReminder:     c.Asynq.Schedules.TodoReminder,
+		ApprovalReminder: c.Asynq.Schedules.ApprovalReminder,
+		DailySummary:     c.Asynq.Schedules.DailySummary,
+	}
+	policies := newRetryPolicies(c)
+	batch := asynqx.NotifyBatch{
+		Window:   time.Duration(c.Asynq.NotifyBatch.Window) * time.Second,
+		MaxDelay: time.Duration(c.Asynq.NotifyBatch.MaxDelay) * time.Second,
+		MaxSize:  c.Asynq.NotifyBatch.MaxSize,
+	}
+	if c.Asynq.Enabled {
+		if err := schedules.Validate(); err != nil {
+			return nil, err
+		}
+		if err := policies.Validate(); err != nil {
+			return nil, err
+		}
+	}
+
+	tlsConf, err := tlsx.NewConfig(tlsx.Conf{
+		CertFile: c.TLS.CertFile,
+		KeyFile:  c.TLS.KeyFile,
+		Autocert: tlsx.AutocertConf{
+			Domains:  c.TLS.Autocert.Domains,
+			CacheDir: c.TLS.Autocert.CacheDir,
+			Email:    c.TLS.Autocert.Email,
+		},
+	})
 	if err != nil {
 		return nil, err
 	}
 
+	rds := redis.NewClient(&redis.Options{
+		Addr:     c.Redis.Addr,
+		Password: c.Redis.Password,
+		DB:       c.Redis.DB,
+	})
+	blacklist := token.NewBlacklist(rds, "aioffice:token:revoked:")
+	jwtAuth := middleware.NewJwt(jwtKeys, blacklist)
+
 	svc := &ServiceContext{
-		Config:              c,
-		Mongo:               mongoDB,
-		UserModel:           model.NewUserModel(mongoDB),
-		DepartmentModel:     model.NewDepartmentModel(mongoDB),
-		DepartmentuserModel: model.NewDepartmentuserModel(mongoDB),
-		TodoRecordModel:     model.NewTodoRecordModel(mongoDB),
-		UserTodoModel:       model.NewUserTodoModel(mongoDB),
-		TodoModel:           model.NewTodoModel(mongoDB),
-		ApprovalModel:       model.NewApprovalModel(mongoDB),
-		ChatLogModel:        model.NewChatLogModel(mongoDB),
-		Jwt:                 middleware.NewJwt(c.Jwt.Secret),
-		LLM:                 llm,
-		Cb:                  callbacks,
+		Config:                c,
+		Mongo:                 mongoDB,
+		MongoHealth:           mongoHealth,
+		UserModel:             userModel,
+		DepartmentModel:       model.NewDepartmentModel(mongoDB),
+		DepartmentuserModel:   model.NewDepartmentuserModel(mongoDB),
+		TodoRecordModel:       model.NewTodoRecordModel(mongoDB),
+		UserTodoModel:         model.NewUserTodoModel(mongoDB),
+		TodoModel:             model.NewTodoModel(mongoDB),
+		ApprovalModel:         model.NewApprovalModel(mongoDB),
+		ChatLogModel:          model.NewChatLogModel(mongoDB, msgCipher),
+		AIMemoryModel:         model.NewAIMemoryModel(mongoDB),
+		AIHistoryModel:        model.NewAIHistoryModel(mongoDB, msgCipher),
+		RouteLogModel:         model.NewRouteLogModel(mongoDB, msgCipher),
+		AIUsageModel:          aiUsageModel,
+		DeviceTokenModel:      deviceTokenModel,
+		UserSettingModel:      model.NewUserSettingModel(mongoDB),
+		DailySummaryModel:     model.NewDailySummaryModel(mongoDB),
+		ScheduledTaskModel:    model.NewScheduledTaskModel(mongoDB),
+		CalendarAccountModel:  model.NewCalendarAccountModel(mongoDB, msgCipher),
+		CalendarEventModel:    model.NewCalendarEventModel(mongoDB),
+		KnowledgeDocModel:     model.NewKnowledgeDocumentModel(mongoDB),
+		KnowledgeSyncModel:    model.NewKnowledgeSyncModel(mongoDB),
+		UploadFileModel:       model.NewUploadFileModel(mongoDB),
+		AuditLogModel:         auditLogModel,
+		AttendanceModel:       model.NewAttendanceModel(mongoDB),
+		EventModel:            model.NewEventModel(mongoDB),
+		AnnouncementModel:     model.NewAnnouncementModel(mongoDB),
+		AnnouncementReadModel: model.NewAnnouncementReadModel(mongoDB),
+		WorkflowDefModel:      model.NewWorkflowDefinitionModel(mongoDB),
+		WorkflowInstanceModel: model.NewWorkflowInstanceModel(mongoDB),
+		Jwt:                   jwtAuth,
+		JwtKeys:               jwtKeys,
+		TokenBlacklist:        blacklist,
+		Admin:                 middleware.NewAdmin(adminChecker(userModel)),
+		Signature:             middleware.NewSignature(newSignature(c, rds), jwtAuth),
+		Audit:                 newAudit(mongoDB, auditLogModel, userModel),
+		LoginLimit:            middleware.NewRateLimit(newLimiter(c.RateLimit.Login, rds, "aioffice:login:limit:"), middleware.ByIP),
+		WriteLimit:            middleware.NewRateLimit(newLimiter(c.RateLimit.Write, rds, "aioffice:write:limit:"), middleware.ByUser).WriteOnly(),
+		LLM:                   llm,
+		Embedder:              embedder,
+		Cb:                    callbacks,
+		Prompts:               newPrompts(c, model.NewPromptModel(mongoDB)),
+		RouteRules:            newRouteRules(c, model.NewRouteRuleModel(mongoDB)),
 
 		// 初始化 Asynq
 		AsynqClient: asynqx.NewClient(
 			c.Redis.Addr,
 			c.Redis.Password,
 			c.Redis.DB,
+			policies,
+			batch,
 			c.Asynq.Enabled,
 		),
 		AsynqServer: asynqx.NewServer(
@@ -99,12 +344,17 @@ func NewServiceContext(c config.Config) (*ServiceContext, error) {
 			c.Redis.Password,
 			c.Redis.DB,
 			c.Asynq.Concurrency,
+			time.Duration(c.Asynq.ShutdownTimeout)*time.Second,
+			policies,
+			batch,
 			c.Asynq.Enabled,
 		),
 		AsynqScheduler: asynqx.NewScheduler(
 			c.Redis.Addr,
 			c.Redis.Password,
 			c.Redis.DB,
+			schedules,
+			policies,
 			c.Asynq.Enabled,
 		),
 		AsynqMonitor: asynqx.NewMonitor(
@@ -112,8 +362,39 @@ func NewServiceContext(c config.Config) (*ServiceContext, error) {
 			c.Redis.Password,
 			c.Redis.DB,
 			c.Asynq.MonitorAddr,
+			c.Asynq.MonitorUser,
+			c.Asynq.MonitorPassword,
 			c.Asynq.Enabled,
 		),
+		AsynqCollector: asynqx.NewCollector(
+			c.Redis.Addr,
+			c.Redis.Password,
+			c.Redis.DB,
+			time.Duration(c.Asynq.MetricsInterval)*time.Second,
+			c.Asynq.Enabled,
+		),
+
+		Notifier: newNotifier(c, deviceTokenModel, mail, userModel, rds),
+		Mailer:   mail,
+		Speech:   newSpeech(c),
+		Upload:   newUpload(c),
+		Panic:    newPanicReporter(c),
+
+		Redis:       rds,
+		TLS:         tlsConf,
+		Tracer:      tracer,
+		AILimiter:   newLimiter(c.LangChain.RateLimit, rds, "aioffice:ai:limit:"),
+		AnswerCache: newAnswerCache(c, rds),
+		Slots:       slotx.NewStore(rds, "aioffice:ai:slot:", 30*time.Minute),
+
+		Calendars: newCalendars(c),
+		Wikis:     newWikis(c),
+	}
+	svc.Moderator = newModerator(c, llm, svc.Prompts, model.NewModerationLogModel(mongoDB, msgCipher))
+	svc.Knowledge = newKnowledgeSearcher(c, embedder, llm, svc.Prompts)
+	svc.AsynqServer.Use(asynqx.Trace(tlog.TraceStart), asynqx.Tracing())
+	if svc.AsynqPeriodic, err = newPeriodic(c, schedules, policies, svc.ScheduledTaskModel); err != nil {
+		return nil, err
 	}
 
 	return svc, initAdminUser(svc)
@@ -124,7 +405,7 @@ func initAdminUser(svc *ServiceContext) error {
 
 	// 检查管理员是否存在
 	admin, err := svc.UserModel.FindAdminUser(ctx)
-	if err != nil && err != model.ErrNotFindUser {
+	if err != nil && !errors.Is(err, model.ErrNotFindUser) {
 		return err
 	}
 	if admin != nil {
@@ -141,3 +422,654 @@ func initAdminUser(svc *ServiceContext) error {
 		IsAdmin:  true,
 	})
 }
+
+// adminChecker 用户不存在时视为非管理员
+func adminChecker(users model.UserModel) middleware.AdminChecker {
+	return func(ctx context.Context, uid string) (bool, error) {
+		user, err := users.FindOne(ctx, uid)
+		if errors.Is(err, model.ErrNotFound) || errors.Is(err, model.ErrInvalidObjectId) {
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		return user.IsAdmin, nil
+	}
+}
+
+// newAudit 审计记录写入失败只打印日志，不影响请求
+func newAudit(db *mongo.Database, logModel model.AuditLogModel, userModel model.UserModel) *middleware.Audit {
+	record := func(ctx context.Context, e *middleware.AuditEntry) {
+		err := logModel.Insert(context.WithoutCancel(ctx), &model.AuditLog{
+			ActorId:    e.ActorId,
+			Action:     e.Action,
+			Resource:   e.Resource,
+			ResourceId: e.ResourceId,
+			Before:     e.Before,
+			After:      e.After,
+			Payload:    e.Payload,
+			Ip:         e.Ip,
+			RequestId:  e.RequestId,
+			Status:     e.Status,
+		})
+		if err != nil {
+			tlog.ErrorfCtx(ctx, "audit", "insert audit log fail: %v, action: %s", err, e.Action)
+		}
+	}
+
+	// 按资源类型（路由中版本后的第一段）加载变更前后的数据
+	return middleware.NewAudit(record, map[string]middleware.AuditLoader{
+		"user":      auditLoader(userModel.FindOne),
+		"dep":       auditLoader(model.NewDepartmentModel(db).FindOne),
+		"todo":      auditLoader(model.NewTodoModel(db).FindOne),
+		"approval":  auditLoader(model.NewApprovalModel(db).FindOne),
+		"schedule":  auditLoader(model.NewScheduledTaskModel(db).FindOne),
+		"knowledge": auditLoader(model.NewKnowledgeDocumentModel(db).FindOne),
+	})
+}
+
+func auditLoader[T any](find func(ctx context.Context, id string) (T, error)) middleware.AuditLoader {
+	return func(ctx context.Context, id string) (any, error) {
+		return find(ctx, id)
+	}
+}
+
+// newNotifier 根据配置创建通知网关，未启用时只保留在线推送；在线推送和广播通过Redis转发给WS网关
+func newNotifier(c config.Config, tokens notify.TokenStore, mail *mailer.Mailer, userModel model.UserModel, rds redis.UniversalClient) *notify.Notifier {
+	relay := notify.NewRelay(rds, "aioffice:notify:broadcast")
+	if !c.Notify.Enabled {
+		n := notify.NewNotifier()
+		n.SetRelay(relay)
+		return n
+	}
+
+	var senders []notify.Sender
+	if len(c.Notify.Fcm.CredentialsFile) > 0 {
+		fcm, err := notify.NewFcm(c.Notify.Fcm.CredentialsFile, tokens)
+		if err != nil {
+			fmt.Printf("[Notify] FCM 初始化失败: %v\n", err)
+		} else {
+			senders = append(senders, fcm)
+		}
+	}
+	if len(c.Notify.Apns.KeyFile) > 0 {
+		apns, err := notify.NewApns(c.Notify.Apns.KeyFile, c.Notify.Apns.KeyId, c.Notify.Apns.TeamId,
+			c.Notify.Apns.Topic, c.Notify.Apns.Production, tokens)
+		if err != nil {
+			fmt.Printf("[Notify] APNs 初始化失败: %v\n", err)
+		} else {
+			senders = append(senders, apns)
+		}
+	}
+	if len(c.Notify.Webhook.Url) > 0 {
+		senders = append(senders, notify.NewWebhook(c.Notify.Webhook.Url, c.Notify.Webhook.Secret))
+	}
+	if c.Notify.Email.Enabled {
+		if mail == nil {
+			fmt.Println("[Notify] 未配置Mail, 跳过邮件渠道")
+		} else {
+			senders = append(senders, notify.NewEmail(mail, userModel, c.Notify.Email.Types))
+		}
+	}
+	if c.Notify.Sms.Enabled {
+		smsSender, err := newSms(c, userModel, rds)
+		if err != nil {
+			fmt.Printf("[Notify] 短信初始化失败: %v\n", err)
+		} else {
+			senders = append(senders, smsSender)
+		}
+	}
+
+	n := notify.NewNotifier(senders...)
+	n.SetRelay(relay)
+	fmt.Printf("[Notify] 离线推送渠道: %v\n", n.Senders())
+	return n
+}
+
+// newSms 根据配置创建短信渠道，未配置频率限制时默认每个用户每种消息每小时3条
+func newSms(c config.Config, book notify.PhoneBook, rds redis.UniversalClient) (*notify.Sms, error) {
+	conf := c.Notify.Sms
+
+	var provider sms.Provider
+	switch conf.Provider {
+	case sms.ProviderAliyun:
+		provider = sms.NewAliyun(sms.AliyunConf{
+			AccessKeyId:     conf.Aliyun.AccessKeyId,
+			AccessKeySecret: conf.Aliyun.AccessKeySecret,
+			SignName:        conf.Aliyun.SignName,
+			RegionId:        conf.Aliyun.RegionId,
+		})
+	case sms.ProviderTwilio:
+		provider = sms.NewTwilio(sms.TwilioConf{
+			AccountSid: conf.Twilio.AccountSid,
+			AuthToken:  conf.Twilio.AuthToken,
+			From:       conf.Twilio.From,
+		})
+	default:
+		return nil, fmt.Errorf("%w: %s", sms.ErrBadProvider, conf.Provider)
+	}
+
+	templates := make(map[string]sms.Template, len(conf.Templates))
+	for _, t := range conf.Templates {
+		templates[t.Type] = sms.Template{Code: t.Code, Text: t.Text}
+	}
+
+	rate := conf.RateLimit
+	if rate.Requests <= 0 {
+		rate = config.RateLimitConf{Requests: 3, Window: 3600}
+	}
+	// 避免把nil的*RedisLimiter作为非nil接口传入
+	var limit notify.RateLimiter
+	if l := newLimiter(rate, rds, "aioffice:sms:limit:"); l != nil {
+		limit = l
+	}
+	return notify.NewSms(provider, book, templates, limit), nil
+}
+
+// newPanicReporter 根据配置创建panic上报渠道
+func newPanicReporter(c config.Config) *panicx.Reporter {
+	var sinks []panicx.Sink
+	if len(c.PanicReport.Webhook.Url) > 0 {
+		sinks = append(sinks, panicx.NewWebhook(c.PanicReport.Webhook.Url, c.PanicReport.Webhook.Secret))
+	}
+	if len(c.PanicReport.Sentry.Dsn) > 0 {
+		sentry, err := panicx.NewSentry(c.PanicReport.Sentry.Dsn, c.PanicReport.Sentry.Environment)
+		if err != nil {
+			fmt.Printf("[Panic] Sentry 初始化失败: %v\n", err)
+		} else {
+			sinks = append(sinks, sentry)
+		}
+	}
+	r := panicx.NewReporter(sinks...)
+	if len(sinks) > 0 {
+		fmt.Printf("[Panic] 上报渠道: %v\n", r.Sinks())
+	}
+	return r
+}
+
+// newModerator 根据配置创建内容审核，敏感词在前、审核模型在后，命中内容异步写入审核记录
+func newModerator(c config.Config, llm llms.Model, store *promptx.Store, logModel model.ModerationLogModel) *moderation.Moderator {
+	conf := c.LangChain.Moderation
+	if !conf.Enabled {
+		return nil
+	}
+
+	var checkers []moderation.Checker
+	if len(conf.Keywords) > 0 {
+		checkers = append(checkers, moderation.NewKeywords(conf.Keywords...))
+	}
+	if conf.Model {
+		checkers = append(checkers, moderation.NewModel(llm, store))
+	}
+
+	audit := func(ctx context.Context, text string, r *moderation.Result) {
+		log := &model.ModerationLog{
+			UserId:  token.GetUid(ctx),
+			Stage:   r.Stage,
+			Action:  r.Action,
+			Checker: r.Checker,
+			Reason:  r.Reason,
+			Content: text,
+		}
+		fmt.Printf("[Moderation] 用户 %s %s 命中 %s: %s\n", log.UserId, log.Stage, log.Checker, log.Reason)
+		go func() {
+			if err := logModel.Insert(context.Background(), log); err != nil {
+				fmt.Printf("[Moderation] 保存审核记录失败: %v\n", err)
+			}
+		}()
+	}
+
+	m := moderation.New(conf.Action, audit, checkers...)
+	fmt.Printf("[Moderation] 内容审核: 敏感词 %d 个, 模型审核 %v, 处理方式 %s\n", len(conf.Keywords), conf.Model, conf.Action)
+	return m
+}
+
+// newPrompts 创建提示词存储并定时热加载，文件在前、mongo在后，后者覆盖前者
+func newPrompts(c config.Config, promptModel model.PromptModel) *promptx.Store {
+	conf := c.LangChain.Prompt
+
+	var sources []promptx.Source
+	if conf.File != "" {
+		sources = append(sources, promptx.NewFile(conf.File))
+	}
+	if conf.Mongo {
+		sources = append(sources, promptx.SourceFunc{
+			SourceName: "mongo",
+			LoadFunc: func(ctx context.Context) (promptx.Templates, error) {
+				list, err := promptModel.FindAll(ctx)
+				if err != nil {
+					return nil, err
+				}
+				ts := promptx.Templates{}
+				for _, v := range list {
+					if ts[v.Tenant] == nil {
+						ts[v.Tenant] = make(map[string]string)
+					}
+					ts[v.Tenant][v.Key] = v.Template
+				}
+				return ts, nil
+			},
+		})
+	}
+
+	store := promptx.NewStore(sources...)
+	if len(sources) == 0 {
+		return store
+	}
+	if err := store.Reload(context.Background()); err != nil {
+		fmt.Printf("[Prompt] 加载提示词失败, 使用默认提示词: %v\n", err)
+	}
+
+	interval := time.Duration(conf.ReloadInterval) * time.Second
+	if interval <= 0 {
+		interval = 30 * time.Second
+	}
+	go store.Watch(context.Background(), interval)
+	return store
+}
+
+// newRouteRules 创建路由规则并定时热加载，配置的规则在前、mongo在后
+func newRouteRules(c config.Config, ruleModel model.RouteRuleModel) *router.RuleStore {
+	conf := c.LangChain.Router
+
+	var sources []router.RuleSource
+	if len(conf.Rules) > 0 {
+		rules := make([]router.RouteRule, 0, len(conf.Rules))
+		for _, v := range conf.Rules {
+			rules = append(rules, router.RouteRule(v))
+		}
+		sources = append(sources, func(context.Context) ([]router.RouteRule, error) {
+			return rules, nil
+		})
+	}
+	if conf.Mongo {
+		sources = append(sources, func(ctx context.Context) ([]router.RouteRule, error) {
+			list, err := ruleModel.FindAll(ctx)
+			if err != nil {
+				return nil, err
+			}
+			rules := make([]router.RouteRule, 0, len(list))
+			for _, v := range list {
+				rules = append(rules, router.RouteRule{
+					Handler:     v.Handler,
+					Description: v.Description,
+					Keywords:    v.Keywords,
+					Examples:    v.Examples,
+				})
+			}
+			return rules, nil
+		})
+	}
+
+	store := router.NewRuleStore(sources...)
+	if len(sources) == 0 {
+		return store
+	}
+	if err := store.Reload(context.Background()); err != nil {
+		fmt.Printf("[Router] 加载路由规则失败, 使用代码中的规则: %v\n", err)
+	}
+	if conf.Mongo {
+		interval := time.Duration(conf.ReloadInterval) * time.Second
+		if interval <= 0 {
+			interval = 30 * time.Second
+		}
+		go store.Watch(context.Background(), interval)
+	}
+	return store
+}
+
+// newMailer 配置了SMTP服务器时创建邮件发送
+func newMailer(c config.Config) *mailer.Mailer {
+	if c.Mail.Host == "" {
+		return nil
+	}
+	return mailer.New(mailer.Conf{
+		Host:     c.Mail.Host,
+		Port:     c.Mail.Port,
+		Username: c.Mail.Username,
+		Password: c.Mail.Password,
+		From:     c.Mail.From,
+		FromName: c.Mail.FromName,
+	})
+}
+
+// newUpload 上传校验，配置了clamd时进行杀毒扫描
+func newUpload(c config.Config) *uploadx.Validator {
+	var scanner uploadx.Scanner
+	if c.Upload.Clamd.Addr != "" {
+		scanner = uploadx.NewClamd(c.Upload.Clamd.Addr, time.Duration(c.Upload.Clamd.Timeout)*time.Second)
+	}
+	return uploadx.New(uploadx.Conf{
+		MaxSize:    c.Upload.MaxSize << 20,
+		Extensions: c.Upload.Extensions,
+		MimeTypes:  c.Upload.MimeTypes,
+		UserQuota:  c.Upload.UserQuota << 20,
+	}, scanner)
+}
+
+// newSpeech 配置了语音接口时创建语音识别与合成
+func newSpeech(c config.Config) *speech.Client {
+	if c.Speech.Url == "" {
+		return nil
+	}
+	return speech.New(speech.Conf{
+		Url:      c.Speech.Url,
+		ApiKey:   c.Speech.ApiKey,
+		AsrModel: c.Speech.AsrModel,
+		TtsModel: c.Speech.TtsModel,
+		Voice:    c.Speech.Voice,
+		Timeout:  time.Duration(c.Speech.Timeout) * time.Second,
+	})
+}
+
+// newKnowledgeSearcher 根据配置创建知识库检索，向量库固定使用Redis 0号库
+func newKnowledgeSearcher(c config.Config, embedder embeddings.Embedder, llm llms.Model, store *promptx.Store) *knowledge.Searcher {
+	conf := c.LangChain.Knowledge
+
+	var reranker knowledge.Reranker
+	switch conf.Rerank.Mode {
+	case "api":
+		reranker = knowledge.NewAPIReranker(conf.Rerank.Url, conf.Rerank.ApiKey, conf.Rerank.Model,
+			time.Duration(conf.Rerank.Timeout)*time.Second)
+	case "llm":
+		reranker = knowledge.NewLLMReranker(llm, store)
+	}
+
+	return knowledge.NewSearcher(embedder, knowledge.SearcherConf{
+		Url:           "redis://" + c.Redis.Addr,
+		Hybrid:        conf.Hybrid,
+		Reranker:      reranker,
+		RerankIndexes: conf.Rerank.Indexes,
+		Candidates:    conf.Rerank.Candidates,
+	})
+}
+
+// newSignature 服务间调用的签名应用，未配置时只支持Jwt
+func newSignature(c config.Config, rds redis.UniversalClient) *token.Signature {
+	apps := make([]token.App, 0, len(c.Signature.Apps))
+	for _, v := range c.Signature.Apps {
+		apps = append(apps, token.App{Key: v.Key, Secret: v.Secret, UserId: v.UserId})
+	}
+	return token.NewSignature(apps, rds, "aioffice:sign:", time.Duration(c.Signature.MaxSkew)*time.Second)
+}
+
+// newMongoHealth 定期检查mongo连通性，HealthInterval为负数时不检查，/readyz 始终认为mongo可用
+func newMongoHealth(c config.Config, db *mongo.Database) *mongoutils.Health {
+	health := mongoutils.NewHealth(db.Client(), time.Duration(c.Mongo.PingTimeout)*time.Second)
+	if c.Mongo.HealthInterval < 0 {
+		return health
+	}
+	interval := time.Duration(c.Mongo.HealthInterval) * time.Second
+	if interval == 0 {
+		interval = 10 * time.Second
+	}
+	go health.Run(context.Background(), interval)
+	return health
+}
+
+// newJwtKeys 轮换密钥在前，未配置轮换密钥时使用Secret签发
+func newJwtKeys(c config.Config) ([]token.Key, error) {
+	keys := make([]token.Key, 0, len(c.Jwt.Keys)+1)
+	kids := make(map[string]bool, len(c.Jwt.Keys))
+	for i, k := range c.Jwt.Keys {
+		if k.Kid == "" || k.Secret == "" {
+			return nil, fmt.Errorf("Jwt.Keys[%d]: Kid and Secret are required", i)
+		}
+		if kids[k.Kid] {
+			return nil, fmt.Errorf("Jwt.Keys[%d]: duplicate Kid %q", i, k.Kid)
+		}
+		kids[k.Kid] = true
+		keys = append(keys, token.Key{Kid: k.Kid, Secret: k.Secret})
+	}
+	if c.Jwt.Secret != "" {
+		keys = append(keys, token.Key{Secret: c.Jwt.Secret})
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("Jwt.Secret or Jwt.Keys is required")
+	}
+	return keys, nil
+}
+
+// newLimiter 固定窗口限流，计数放在Redis中多实例共享，未配置时为nil
+func newLimiter(conf config.RateLimitConf, rds redis.UniversalClient, prefix string) *limiter.RedisLimiter {
+	if conf.Requests <= 0 {
+		return nil
+	}
+	window := time.Duration(conf.Window) * time.Second
+	if window <= 0 {
+		window = time.Minute
+	}
+	return limiter.NewRedisLimiter(rds, prefix, conf.Requests, window)
+}
+
+// newAnswerCache 根据配置创建AI回复缓存
+func newAnswerCache(c config.Config, rds redis.UniversalClient) *cachex.Cache {
+	conf := c.LangChain.Cache
+	if !conf.Enabled {
+		return nil
+	}
+	ttl := time.Duration(conf.TTL) * time.Second
+	if ttl <= 0 {
+		ttl = time.Hour
+	}
+	return cachex.New(rds, "aioffice:ai:cache:", ttl)
+}
+
+// newCalendars 根据配置创建外部日历供应商，CalDAV无需额外配置
+func newCalendars(c config.Config) *calendar.Registry {
+	if !c.Calendar.Enabled {
+		return nil
+	}
+
+	providers := []calendar.Provider{calendar.NewCalDav()}
+	if conf := c.Calendar.Exchange; conf.ClientId != "" {
+		providers = append(providers, calendar.NewExchange(calendar.ExchangeConf{
+			Tenant:       conf.Tenant,
+			ClientId:     conf.ClientId,
+			ClientSecret: conf.ClientSecret,
+			RedirectUrl:  conf.RedirectUrl,
+		}))
+	}
+
+	r := calendar.NewRegistry(providers...)
+	fmt.Printf("[Calendar] 外部日历: %v\n", r.Names())
+	return r
+}
+
+// newMsgCipher 根据配置创建聊天内容加密器，未启用时返回 nil
+func newMsgCipher(c config.Config) (model.MsgCipher, error) {
+	if !c.MsgCrypto.Enabled {
+		return nil, nil
+	}
+
+	var provider encrypt.KeyProvider
+	switch c.MsgCrypto.Provider {
+	case "env":
+		provider = encrypt.EnvKeys{Prefix: c.MsgCrypto.EnvPrefix}
+	default:
+		provider = encrypt.StaticKeys(c.MsgCrypto.Keys)
+	}
+
+	cipher, err := encrypt.NewAesGcm(provider, c.MsgCrypto.ActiveKeyId)
+	if err != nil {
+		return nil, fmt.Errorf("init msg cipher: %w", err)
+	}
+	return cipher, nil
+}
+
+// newLLM 根据配置创建多供应商模型和向量化模型
+// 向量化固定使用一个供应商，不同模型的向量不能混用，因此不做切换
+func newLLM(c config.Config, cb callbacks.Handler) (*llmx.Fallback, embeddings.Embedder, error) {
+	confs := make([]llmx.ProviderConf, 0, len(c.LangChain.Providers))
+	for _, p := range c.LangChain.Providers {
+		confs = append(confs, llmx.ProviderConf{
+			Name:           p.Name,
+			Type:           p.Type,
+			Url:            p.Url,
+			ApiKey:         p.ApiKey,
+			Model:          p.Model,
+			Models:         p.Models,
+			EmbeddingModel: p.EmbeddingModel,
+			EmbeddingUrl:   p.EmbeddingUrl,
+			ApiVersion:     p.ApiVersion,
+			Timeout:        time.Duration(p.Timeout) * time.Second,
+		})
+	}
+	if len(confs) == 0 {
+		confs = append(confs, llmx.ProviderConf{
+			Type:   llmx.TypeQwen,
+			Url:    c.LangChain.Url,
+			ApiKey: c.LangChain.ApiKey,
+		})
+	}
+
+	providers := make([]*llmx.Provider, 0, len(confs))
+	for _, conf := range confs {
+		p, err := llmx.NewProvider(conf, cb)
+		if err != nil {
+			return nil, nil, err
+		}
+		providers = append(providers, p)
+	}
+
+	embedProvider := providers[0]
+	for _, p := range providers {
+		if p.Name == c.LangChain.Embedding {
+			embedProvider = p
+		}
+	}
+	if embedProvider.EmbeddingModel == "" {
+		return nil, nil, fmt.Errorf("llm provider %s has no embedding model, set EmbeddingModel or LangChain.Embedding", embedProvider.Name)
+	}
+	embedder, err := embeddings.NewEmbedder(embedProvider.Embedding)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	llm := llmx.NewFallback(providers...)
+	fmt.Printf("[LLM] 模型供应商: %v, 向量化: %s\n", llm.Providers(), embedProvider.Name)
+	return llm, embedder, nil
+}
+
+// Close 关闭共享的客户端连接，在各服务停止后调用
+func (svc *ServiceContext) Close(ctx context.Context) error {
+	var errs []error
+	if err := svc.AsynqClient.Close(); err != nil {
+		errs = append(errs, fmt.Errorf("close asynq client: %w", err))
+	}
+	if err := svc.Redis.Close(); err != nil {
+		errs = append(errs, fmt.Errorf("close redis: %w", err))
+	}
+	if err := svc.Mongo.Client().Disconnect(ctx); err != nil {
+		errs = append(errs, fmt.Errorf("disconnect mongo: %w", err))
+	}
+	if err := svc.Tracer.Shutdown(ctx); err != nil {
+		errs = append(errs, fmt.Errorf("flush trace: %w", err))
+	}
+	return errors.Join(errs...)
+}
+
+// usageRecorder 按用户按天累计token用量，没有用户信息的调用（定时任务等）不计入
+func usageRecorder(m model.AIUsageModel) callbackx.UsageRecorder {
+	return func(ctx context.Context, promptTokens, completionTokens int) {
+		uid := token.GetUid(ctx)
+		if uid == "" {
+			return
+		}
+		// 请求结束后ctx可能已取消，用量仍需落库
+		err := m.Incr(context.WithoutCancel(ctx), uid, timeutils.Format(timeutils.Now()),
+			int64(promptTokens), int64(completionTokens))
+		if err != nil {
+			fmt.Printf("[AIUsage] 记录token用量失败: %v\n", err)
+		}
+	}
+}
+
+// newRetryPolicies 任务重试策略，default 的配置优先于 RetryMax
+func newRetryPolicies(c config.Config) asynqx.RetryPolicies {
+	policies := asynqx.RetryPolicies{
+		Default: asynqx.RetryPolicy{MaxRetry: c.Asynq.RetryMax},
+		Types:   make(map[string]asynqx.RetryPolicy, len(c.Asynq.Retry)),
+	}
+	for name, v := range c.Asynq.Retry {
+		policy := asynqx.RetryPolicy{
+			MaxRetry:  v.MaxRetry,
+			Timeout:   time.Duration(v.Timeout) * time.Second,
+			Backoff:   v.Backoff,
+			BaseDelay: time.Duration(v.BaseDelay) * time.Second,
+			MaxDelay:  time.Duration(v.MaxDelay) * time.Second,
+			Jitter:    v.Jitter,
+		}
+		if name == "default" {
+			policy.MaxRetry = cmp.Or(policy.MaxRetry, c.Asynq.RetryMax)
+			policies.Default = policy
+			continue
+		}
+		policies.Types[name] = policy
+	}
+	return policies
+}
+
+// newPeriodic 动态定时任务，配置读取自数据库，格式错误的任务跳过，不影响其他任务
+func newPeriodic(c config.Config, schedules asynqx.Schedules, policies asynqx.RetryPolicies,
+	m model.ScheduledTaskModel) (*asynqx.Periodic, error) {
+	provider := asynqx.PeriodicConfigFunc(func() ([]*asynq.PeriodicTaskConfig, error) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		list, err := m.List(ctx, true)
+		if err != nil {
+			return nil, err
+		}
+		configs := make([]*asynq.PeriodicTaskConfig, 0, len(list))
+		for _, v := range list {
+			if err := asynqx.ValidatePeriodic(v.Cron, v.TaskType, v.Payload); err != nil {
+				fmt.Printf("[Periodic] 跳过配置错误的定时任务 %s: %v\n", v.ID.Hex(), err)
+				continue
+			}
+			configs = append(configs, &asynq.PeriodicTaskConfig{
+				Cronspec: v.Cron,
+				Task:     asynq.NewTask(v.TaskType, []byte(v.Payload)),
+				Opts:     append([]asynq.Option{asynq.Queue(v.Queue)}, policies.Options(v.TaskType)...),
+			})
+		}
+		return configs, nil
+	})
+
+	return asynqx.NewPeriodic(
+		c.Redis.Addr,
+		c.Redis.Password,
+		c.Redis.DB,
+		provider,
+		schedules,
+		time.Duration(c.Asynq.PeriodicSync)*time.Second,
+		c.Asynq.Enabled,
+	)
+}
+
+// newWikis 根据配置创建外部知识库连接器
+func newWikis(c config.Config) []wiki.Connector {
+	conf := c.KnowledgeSync
+
+	var list []wiki.Connector
+	if conf.Confluence.Url != "" {
+		list = append(list, wiki.NewConfluence(wiki.ConfluenceConf{
+			Url:      conf.Confluence.Url,
+			Username: conf.Confluence.Username,
+			Token:    conf.Confluence.Token,
+			Space:    conf.Confluence.Space,
+		}))
+	}
+	if conf.Notion.Token != "" {
+		list = append(list, wiki.NewNotion(wiki.NotionConf{Token: conf.Notion.Token}))
+	}
+	if conf.Feishu.AppId != "" {
+		list = append(list, wiki.NewFeishu(wiki.FeishuConf{
+			AppId:     conf.Feishu.AppId,
+			AppSecret: conf.Feishu.AppSecret,
+			SpaceId:   conf.Feishu.SpaceId,
+			Url:       conf.Feishu.Url,
+		}))
+	}
+	return list
+}
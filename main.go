@@ -1,16 +1,25 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"flag"
 	"fmt"
-	"sync"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"aiOffice/internal/config"
 	"aiOffice/internal/handler/start"
 	"aiOffice/internal/handler/ws"
+	"aiOffice/internal/logic"
 	"aiOffice/internal/svc"
 	"aiOffice/pkg/asynqx/handlers"
 	"aiOffice/pkg/conf"
+
+	"gitee.com/dn-jinmin/tlog"
+	"golang.org/x/sync/errgroup"
 )
 
 // @title AIOffice API
@@ -34,86 +43,275 @@ import (
 
 var (
 	configFile = flag.String("f", "./etc/local/config.yaml", "the config file")
-	sw         sync.WaitGroup
+	seed       = flag.Bool("seed", false, "写入演示数据后退出，可重复执行")
 )
 
+// 子命令，指定进程运行的服务，便于按负载分别扩容；不指定时为 all
+const (
+	roleApi       = "api"       // http接口，包括asynq监控面板和队列指标采集
+	roleWs        = "ws"        // websocket网关
+	roleWorker    = "worker"    // asynq worker，执行异步任务
+	roleScheduler = "scheduler" // 投递定时任务，多实例部署时只需运行一个
+	roleAll       = "all"
+
+	cmdConfig = "config" // config check 校验配置并输出生效的配置（密钥已隐藏）后退出
+)
+
+func usage() {
+	fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] [api|ws|worker|scheduler|all|config check]\n", os.Args[0])
+	flag.PrintDefaults()
+}
+
 func main() {
+	flag.Usage = usage
 	flag.Parse()
 
+	// 子命令之后仍可以跟参数，如 aiOffice api -f config.yaml
+	role := roleAll
+	if flag.NArg() > 0 {
+		role = flag.Arg(0)
+		flag.CommandLine.Parse(flag.Args()[1:])
+	}
+	if role == cmdConfig {
+		os.Exit(configCheck(flag.Args()))
+	}
+	switch role {
+	case roleApi, roleWs, roleWorker, roleScheduler, roleAll:
+	default:
+		fmt.Printf("未知的子命令: %s\n", role)
+		usage()
+		os.Exit(2)
+	}
+	run := func(r string) bool {
+		return role == roleAll || role == r
+	}
+
 	var cfg config.Config
 	conf.MustLoad(*configFile, &cfg)
 
+	// 初始化日志
+	tlog.Init(
+		tlog.WithLoggerWriter(tlog.NewLoggerWriter()),
+		tlog.WithLabel(cfg.Tlog.Label),
+		tlog.WithMode(cfg.Tlog.Mode),
+	)
+
 	// 初始化唯一服务上下文
 	svcContext, err := svc.NewServiceContext(cfg)
 	if err != nil {
 		panic(err)
 	}
+	logic.Inject(svcContext)
+
+	if *seed {
+		runSeed(svcContext)
+		return
+	}
+
+	// 单独运行时对应的服务必须启用，否则进程什么也不做
+	if role == roleWorker && !svcContext.AsynqServer.IsEnabled() {
+		fmt.Println("Asynq Worker 未启用，请检查 Asynq 配置")
+		os.Exit(1)
+	}
+	if role == roleScheduler && !svcContext.AsynqScheduler.IsEnabled() && !svcContext.AsynqPeriodic.IsEnabled() {
+		fmt.Println("Asynq Scheduler 未启用，请检查 Asynq 配置")
+		os.Exit(1)
+	}
+	fmt.Println("运行服务:", role)
+
+	// 收到退出信号或任一服务异常退出时，依次关闭全部服务
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	g, gctx := errgroup.WithContext(ctx)
+
+	// 定时释放空闲的AI对话记忆，退出时停止
+	if run(roleApi) || run(roleWs) {
+		go svcContext.AIMemory.RunSweeper(gctx, time.Minute)
+	}
 
-	sw.Add(1)
 	// 运行http服务
-	go func() {
-		defer sw.Done()
+	var httpSrv interface{ Shutdown(context.Context) error }
+	if run(roleApi) {
 		srv := start.NewHandle(svcContext)
-		srv.Run()
-	}()
+		g.Go(srv.Run)
+		httpSrv = srv
+	}
 
-	sw.Add(1)
 	// 运行websocket服务
-	go func() {
-		defer sw.Done()
-		srv := ws.NewWs(svcContext)
-		srv.Run()
-	}()
+	var wsSrv *ws.Ws
+	if run(roleWs) {
+		wsSrv = ws.NewWs(svcContext)
+		g.Go(wsSrv.Run)
+	}
 
 	// 运行 Asynq 监控面板（如果启用）
-	if svcContext.AsynqMonitor.IsEnabled() {
-		sw.Add(1)
-		go func() {
-			defer sw.Done()
-			if err := svcContext.AsynqMonitor.Run(); err != nil {
-				panic(err)
-			}
-		}()
+	if run(roleApi) && svcContext.AsynqMonitor.IsEnabled() {
+		g.Go(svcContext.AsynqMonitor.Run)
 	}
 
 	// 运行 Asynq Worker（如果启用）
-	if svcContext.AsynqServer.IsEnabled() {
+	if run(roleWorker) && svcContext.AsynqServer.IsEnabled() {
 		// 注册任务处理器
 		h := handlers.NewHandlers(svcContext)
 		h.Register(svcContext.AsynqServer)
-
-		sw.Add(1)
-		go func() {
-			defer sw.Done()
-			fmt.Println("[Asynq] Worker starting...")
-			if err := svcContext.AsynqServer.Run(); err != nil {
-				fmt.Printf("[Asynq] Worker error: %v\n", err)
-			}
-		}()
+		g.Go(svcContext.AsynqServer.Run)
 	}
 
 	// 运行 Asynq Scheduler（如果启用）
-	if svcContext.AsynqScheduler.IsEnabled() {
-		// 注册定时任务
-		if _, err := svcContext.AsynqScheduler.RegisterTodoReminder(); err != nil {
-			fmt.Printf("[Scheduler] 注册待办提醒失败: %v\n", err)
+	if run(roleScheduler) && svcContext.AsynqScheduler.IsEnabled() {
+		registerSchedules(svcContext)
+		g.Go(svcContext.AsynqScheduler.Run)
+	}
+
+	// 运行接口添加的定时任务（如果启用）
+	if run(roleScheduler) && svcContext.AsynqPeriodic.IsEnabled() {
+		g.Go(svcContext.AsynqPeriodic.Run)
+	}
+
+	// 采集 Asynq 队列指标（如果启用），指标通过http服务的 /metrics 暴露
+	if run(roleApi) && svcContext.AsynqCollector.IsEnabled() {
+		g.Go(func() error {
+			svcContext.AsynqCollector.Run()
+			return nil
+		})
+	}
+
+	g.Go(func() error {
+		<-gctx.Done()
+		return shutdown(svcContext, httpSrv, wsSrv)
+	})
+
+	if err := g.Wait(); err != nil {
+		fmt.Printf("服务退出: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// configCheck 校验配置文件，通过后输出生效的配置，密钥和连接串中的密码已隐藏
+func configCheck(args []string) int {
+	if len(args) == 0 || args[0] != "check" {
+		usage()
+		return 2
+	}
+	flag.CommandLine.Parse(args[1:])
+
+	var cfg config.Config
+	if err := conf.Load(*configFile, &cfg); err != nil {
+		fmt.Printf("配置 %s 校验失败:\n%v\n", *configFile, err)
+		return 1
+	}
+	out, err := conf.Masked(cfg)
+	if err != nil {
+		fmt.Printf("输出配置失败: %v\n", err)
+		return 1
+	}
+	fmt.Printf("# 配置 %s 校验通过\n%s", *configFile, out)
+	return 0
+}
+
+// registerSchedules 注册内置的定时任务
+func registerSchedules(svcContext *svc.ServiceContext) {
+	cfg := svcContext.Config
+	if _, err := svcContext.AsynqScheduler.RegisterTodoReminder(); err != nil {
+		fmt.Printf("[Scheduler] 注册待办提醒失败: %v\n", err)
+	}
+	if _, err := svcContext.AsynqScheduler.RegisterReminderDispatch(); err != nil {
+		fmt.Printf("[Scheduler] 注册个人提醒投递失败: %v\n", err)
+	}
+	if _, err := svcContext.AsynqScheduler.RegisterEventReminder(); err != nil {
+		fmt.Printf("[Scheduler] 注册日程提醒失败: %v\n", err)
+	}
+	if _, err := svcContext.AsynqScheduler.RegisterAnnouncementPublish(); err != nil {
+		fmt.Printf("[Scheduler] 注册公告推送失败: %v\n", err)
+	}
+	if _, err := svcContext.AsynqScheduler.RegisterApprovalReminder(); err != nil {
+		fmt.Printf("[Scheduler] 注册审批提醒失败: %v\n", err)
+	}
+	if _, err := svcContext.AsynqScheduler.RegisterDailySummary(); err != nil {
+		fmt.Printf("[Scheduler] 注册每日总结失败: %v\n", err)
+	}
+	if svcContext.Calendars != nil {
+		if _, err := svcContext.AsynqScheduler.RegisterCalendarSync(cfg.Calendar.SyncCron); err != nil {
+			fmt.Printf("[Scheduler] 注册日历同步失败: %v\n", err)
+		}
+	}
+	if cfg.Retention.ChatLogDays > 0 || cfg.Retention.TodoDays > 0 || cfg.Retention.ApprovalDays > 0 ||
+		cfg.Retention.AuditLogDays > 0 {
+		if _, err := svcContext.AsynqScheduler.RegisterRetentionPurge(cfg.Retention.Cron); err != nil {
+			fmt.Printf("[Scheduler] 注册数据清理失败: %v\n", err)
+		}
+	}
+	if len(svcContext.Wikis) > 0 {
+		if _, err := svcContext.AsynqScheduler.RegisterKnowledgeSync(cfg.KnowledgeSync.Cron); err != nil {
+			fmt.Printf("[Scheduler] 注册知识库同步失败: %v\n", err)
+		}
+	}
+}
+
+// runSeed 写入组织架构、用户、待办、审批和知识库示例数据，示例用户密码为 123456
+func runSeed(svcContext *svc.ServiceContext) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	report, err := logic.NewSeed(svcContext).Run(ctx)
+	fmt.Printf("示例数据: 用户 %d, 部门 %d, 待办 %d, 审批 %d, 知识库文档 %d\n",
+		report.Users, report.Departments, report.Todos, report.Approvals, report.Documents)
+	for _, e := range report.Errors {
+		fmt.Printf("示例数据部分失败: %s\n", e)
+	}
+	svcContext.Close(ctx)
+	if err != nil {
+		fmt.Printf("写入示例数据失败: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// shutdown 先停止接收http请求和定时投递，再等待执行中的异步任务，排空ws连接，最后关闭数据库连接；
+// 整体不超过配置的 ShutdownTimeout，超时后剩余步骤仍会执行，但不再等待；当前进程未运行的http/ws服务为nil
+func shutdown(svcContext *svc.ServiceContext, httpSrv interface{ Shutdown(context.Context) error }, wsSrv *ws.Ws) error {
+	fmt.Println("开始关闭服务...")
+	timeout := time.Duration(svcContext.Config.ShutdownTimeout) * time.Second
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	var errs []error
+	if httpSrv != nil {
+		if err := httpSrv.Shutdown(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("http: %w", err))
 		}
-		if _, err := svcContext.AsynqScheduler.RegisterApprovalReminder(); err != nil {
-			fmt.Printf("[Scheduler] 注册审批提醒失败: %v\n", err)
+	}
+
+	svcContext.AsynqScheduler.Shutdown()
+	svcContext.AsynqPeriodic.Shutdown()
+	svcContext.AsynqServer.Shutdown()
+	svcContext.AsynqCollector.Shutdown()
+	if err := svcContext.AsynqMonitor.Shutdown(ctx); err != nil {
+		errs = append(errs, fmt.Errorf("asynq monitor: %w", err))
+	}
+
+	if wsSrv != nil {
+		drain := time.Duration(svcContext.Config.Ws.DrainTimeout) * time.Second
+		if drain <= 0 {
+			drain = 10 * time.Second
 		}
-		if _, err := svcContext.AsynqScheduler.RegisterDailySummary(); err != nil {
-			fmt.Printf("[Scheduler] 注册每日总结失败: %v\n", err)
+		wsCtx, wsCancel := context.WithTimeout(ctx, drain)
+		defer wsCancel()
+		if err := wsSrv.Shutdown(wsCtx); err != nil {
+			errs = append(errs, fmt.Errorf("ws: %w", err))
 		}
+	}
 
-		sw.Add(1)
-		go func() {
-			defer sw.Done()
-			fmt.Println("[Scheduler] Scheduler starting...")
-			if err := svcContext.AsynqScheduler.Run(); err != nil {
-				fmt.Printf("[Scheduler] Scheduler error: %v\n", err)
-			}
-		}()
+	if err := svcContext.Close(ctx); err != nil {
+		errs = append(errs, err)
 	}
 
-	sw.Wait()
+	if err := errors.Join(errs...); err != nil {
+		fmt.Printf("服务关闭超时或失败: %v\n", err)
+	} else {
+		fmt.Println("服务已关闭")
+	}
+	return nil
 }
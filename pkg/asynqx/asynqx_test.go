@@ -1,11 +1,19 @@
 package asynqx
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
 	"testing"
+	"time"
+
+	"github.com/hibiken/asynq"
 )
 
 func TestClient_Disabled(t *testing.T) {
-	client := NewClient("localhost:6379", "", 0, false)
+	client := NewClient("localhost:6379", "", 0, RetryPolicies{}, NotifyBatch{}, false)
 
 	if client.IsEnabled() {
 		t.Error("client should be disabled")
@@ -13,7 +21,7 @@ func TestClient_Disabled(t *testing.T) {
 }
 
 func TestClient_Enabled(t *testing.T) {
-	client := NewClient("localhost:6379", "", 0, true)
+	client := NewClient("localhost:6379", "", 0, RetryPolicies{}, NotifyBatch{}, true)
 	defer client.Close()
 
 	if !client.IsEnabled() {
@@ -22,17 +30,228 @@ func TestClient_Enabled(t *testing.T) {
 }
 
 func TestServer_Disabled(t *testing.T) {
-	server := NewServer("localhost:6379", "", 0, 10, false)
+	server := NewServer("localhost:6379", "", 0, 10, 0, RetryPolicies{}, NotifyBatch{}, false)
 
 	if server.IsEnabled() {
 		t.Error("server should be disabled")
 	}
+	// 未启用时关闭不应阻塞或panic
+	server.Shutdown()
 }
 
 func TestScheduler_Disabled(t *testing.T) {
-	scheduler := NewScheduler("localhost:6379", "", 0, false)
+	scheduler := NewScheduler("localhost:6379", "", 0, Schedules{}, RetryPolicies{}, false)
 
 	if scheduler.IsEnabled() {
 		t.Error("scheduler should be disabled")
 	}
+	scheduler.Shutdown()
+}
+
+func TestPageParams(t *testing.T) {
+	cases := []struct {
+		page, size string
+		wantPage   int
+		wantSize   int
+	}{
+		{"", "", 1, 20},
+		{"3", "50", 3, 50},
+		{"-1", "1000", 1, 100},
+		{"x", "y", 1, 20},
+	}
+	for _, c := range cases {
+		page, size := pageParams(c.page, c.size)
+		if page != c.wantPage || size != c.wantSize {
+			t.Errorf("pageParams(%q, %q) = %d, %d", c.page, c.size, page, size)
+		}
+	}
+}
+
+func TestPayloadJSON(t *testing.T) {
+	if got := string(payloadJSON([]byte(`{"id":"1"}`))); got != `{"id":"1"}` {
+		t.Errorf("json payload = %s", got)
+	}
+	if got := string(payloadJSON([]byte("plain"))); got != `"plain"` {
+		t.Errorf("text payload = %s", got)
+	}
+	if payloadJSON(nil) != nil {
+		t.Error("empty payload should be omitted")
+	}
+}
+
+func TestMonitorActionRoutes(t *testing.T) {
+	mux := (&Monitor{}).routes()
+
+	cases := []struct {
+		method, url string
+		want        int
+	}{
+		{http.MethodGet, "/api/task/delete?queue=default&id=1", http.StatusOK}, // GET落到首页，不会执行操作
+		{http.MethodPost, "/api/task/delete?queue=default", http.StatusBadRequest},
+		{http.MethodPost, "/api/task/unknown?queue=default&id=1", http.StatusNotFound},
+		{http.MethodPost, "/api/queue/pause", http.StatusBadRequest},
+		{http.MethodPost, "/api/queue/unknown?queue=default", http.StatusNotFound},
+	}
+	for _, c := range cases {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest(c.method, c.url, nil))
+		if w.Code != c.want {
+			t.Errorf("%s %s = %d, want %d", c.method, c.url, w.Code, c.want)
+		}
+	}
+}
+
+func TestMonitorAuth(t *testing.T) {
+	cases := []struct {
+		pass        string
+		method, url string
+		auth, xhr   bool
+		want        int
+	}{
+		{"", http.MethodGet, "/api/task/delete", false, false, http.StatusOK},
+		{"", http.MethodPost, "/api/task/delete", false, true, http.StatusForbidden}, // 未配置密码只读
+		{"secret", http.MethodGet, "/", false, false, http.StatusUnauthorized},
+		{"secret", http.MethodGet, "/health", false, false, http.StatusOK},
+		{"secret", http.MethodPost, "/api/task/delete", true, false, http.StatusForbidden}, // 跨站提交没有请求头
+		{"secret", http.MethodPost, "/api/task/delete", true, true, http.StatusBadRequest},
+	}
+	for _, c := range cases {
+		m := &Monitor{user: "admin", pass: c.pass}
+		r := httptest.NewRequest(c.method, c.url, nil)
+		if c.auth {
+			r.SetBasicAuth("admin", c.pass)
+		}
+		if c.xhr {
+			r.Header.Set("X-Requested-With", "XMLHttpRequest")
+		}
+		w := httptest.NewRecorder()
+		m.auth(m.routes()).ServeHTTP(w, r)
+		if w.Code != c.want {
+			t.Errorf("pass=%q %s %s = %d, want %d", c.pass, c.method, c.url, w.Code, c.want)
+		}
+	}
+}
+
+func TestSchedulesValidate(t *testing.T) {
+	if err := (Schedules{}).Validate(); err != nil {
+		t.Errorf("empty schedules: %v", err)
+	}
+	if err := (Schedules{Timezone: "Asia/Shanghai", TodoReminder: "30 8 * * 1-5"}).Validate(); err != nil {
+		t.Errorf("valid schedules: %v", err)
+	}
+	if err := (Schedules{Timezone: "Mars/Base"}).Validate(); err == nil {
+		t.Error("invalid timezone should fail")
+	}
+	if err := (Schedules{DailySummary: "0 25 * * *"}).Validate(); err == nil {
+		t.Error("invalid cron should fail")
+	}
+}
+
+func TestValidatePeriodic(t *testing.T) {
+	if err := ValidatePeriodic("0 9 * * 1", TypeReminderTodo, `{"user_id":"u1"}`); err != nil {
+		t.Errorf("valid config: %v", err)
+	}
+	if err := ValidatePeriodic("0 9 * * 1", TypeKnowledgeProcess, "{}"); err == nil {
+		t.Error("knowledge:process should not be schedulable")
+	}
+	if err := ValidatePeriodic("every day", TypeReminderTodo, "{}"); err == nil {
+		t.Error("invalid cron should fail")
+	}
+	if err := ValidatePeriodic("0 9 * * *", TypeReminderTodo, "{"); err == nil {
+		t.Error("invalid payload should fail")
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	var order []string
+	mark := func(name string) Middleware {
+		return func(next HandlerFunc) HandlerFunc {
+			return func(ctx context.Context, task *asynq.Task) error {
+				order = append(order, name)
+				return next(ctx, task)
+			}
+		}
+	}
+
+	var reported any
+	h := HandlerFunc(func(ctx context.Context, task *asynq.Task) error { panic("boom") })
+	h = mark("outer")(mark("inner")(Recovery(func(ctx context.Context, task *asynq.Task, v any) { reported = v })(h)))
+
+	err := h(context.Background(), asynq.NewTask("test", nil))
+	if err == nil || err.Error() != "panic: boom" || reported != "boom" {
+		t.Fatalf("Recovery err = %v, reported = %v", err, reported)
+	}
+	if len(order) != 2 || order[0] != "outer" || order[1] != "inner" {
+		t.Fatalf("order = %v", order)
+	}
+
+	want := errors.New("failed")
+	h = Metrics()(func(ctx context.Context, task *asynq.Task) error { return want })
+	if err := h(context.Background(), asynq.NewTask("test", nil)); err != want {
+		t.Fatalf("Metrics err = %v", err)
+	}
+	if meta := GetTaskMeta(context.Background()); meta.ID != "" {
+		t.Fatalf("GetTaskMeta = %+v", meta)
+	}
+}
+
+func TestRetryPolicies(t *testing.T) {
+	p := RetryPolicies{
+		Default: RetryPolicy{MaxRetry: 3, Backoff: BackoffExponential, BaseDelay: time.Second, MaxDelay: time.Minute},
+		Types: map[string]RetryPolicy{
+			TypeKnowledgeProcess: {MaxRetry: 5, Backoff: BackoffFixed, Jitter: 0.5},
+		},
+	}
+	if err := p.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := p.Get(TypeReminderTodo); got.MaxRetry != 3 || got.Backoff != BackoffExponential {
+		t.Fatalf("Get default = %+v", got)
+	}
+	if got := p.Get(TypeKnowledgeProcess); got.MaxRetry != 5 || got.BaseDelay != time.Second {
+		t.Fatalf("Get merged = %+v", got)
+	}
+
+	task := asynq.NewTask(TypeReminderTodo, nil)
+	for n, want := range map[int]time.Duration{0: time.Second, 3: 8 * time.Second, 10: time.Minute, 100: time.Minute} {
+		if got := p.RetryDelay(n, nil, task); got != want {
+			t.Fatalf("RetryDelay(%d) = %v, want %v", n, got, want)
+		}
+	}
+	task = asynq.NewTask(TypeKnowledgeProcess, nil)
+	for i := 0; i < 100; i++ {
+		if got := p.RetryDelay(i, nil, task); got < 500*time.Millisecond || got > 1500*time.Millisecond {
+			t.Fatalf("RetryDelay with jitter = %v", got)
+		}
+	}
+
+	p.Types["bad"] = RetryPolicy{Backoff: "linear"}
+	if err := p.Validate(); err == nil {
+		t.Fatal("expected invalid backoff error")
+	}
+}
+
+func TestAggregateNotify(t *testing.T) {
+	newTask := func(title string) *asynq.Task {
+		b, _ := json.Marshal(&NotifyPayload{UserID: "u1", Type: "todo:assigned", Title: title})
+		return asynq.NewTask(TypeNotify, b)
+	}
+
+	single := newTask("a")
+	if got := aggregateNotify("u1", []*asynq.Task{single}); got != single {
+		t.Fatalf("single task should be sent as is, got %s", got.Type())
+	}
+
+	got := aggregateNotify("u1", []*asynq.Task{newTask("a"), asynq.NewTask(TypeNotify, []byte("{")), newTask("b")})
+	if got.Type() != TypeNotifyDigest {
+		t.Fatalf("type = %s", got.Type())
+	}
+	var digest NotifyDigestPayload
+	if err := json.Unmarshal(got.Payload(), &digest); err != nil {
+		t.Fatal(err)
+	}
+	if digest.UserID != "u1" || len(digest.Items) != 2 || digest.Items[0].Title != "a" || digest.Items[1].Title != "b" {
+		t.Fatalf("digest = %+v", digest)
+	}
 }
@@ -0,0 +1,54 @@
+package asynqx
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/hibiken/asynq"
+)
+
+// NotifyBatch 通知聚合：同一用户在窗口内的多条通知合并为一条摘要发送，
+// 避免一次分配大量待办时连续推送
+type NotifyBatch struct {
+	Window   time.Duration // 收到最后一条通知后等待的时间，窗口内没有新通知时发送，0为不聚合
+	MaxDelay time.Duration // 第一条通知最多等待的时间，默认5倍Window
+	MaxSize  int           // 达到该条数时立即发送，默认50
+}
+
+// Enabled 是否开启聚合
+func (b NotifyBatch) Enabled() bool {
+	return b.Window > 0
+}
+
+// apply 开启聚合时设置 Server 的分组聚合配置
+func (b NotifyBatch) apply(conf *asynq.Config) {
+	if !b.Enabled() {
+		return
+	}
+	conf.GroupGracePeriod = max(b.Window, time.Second)
+	conf.GroupMaxDelay = cmp.Or(b.MaxDelay, 5*b.Window)
+	conf.GroupMaxSize = cmp.Or(b.MaxSize, 50)
+	conf.GroupAggregator = asynq.GroupAggregatorFunc(aggregateNotify)
+}
+
+// aggregateNotify 分组为用户ID，只有一条时原样发送，多条时合并为摘要任务
+func aggregateNotify(group string, tasks []*asynq.Task) *asynq.Task {
+	if len(tasks) == 1 {
+		return tasks[0]
+	}
+
+	digest := NotifyDigestPayload{UserID: group, Items: make([]*NotifyPayload, 0, len(tasks))}
+	for _, t := range tasks {
+		var item NotifyPayload
+		if err := json.Unmarshal(t.Payload(), &item); err != nil {
+			fmt.Printf("[Asynq] 跳过无法解析的通知, user: %s, err: %v\n", group, err)
+			continue
+		}
+		digest.Items = append(digest.Items, &item)
+	}
+
+	payload, _ := json.Marshal(digest)
+	return asynq.NewTask(TypeNotifyDigest, payload)
+}
@@ -11,12 +11,15 @@ import (
 
 // Client Asynq 客户端封装
 type Client struct {
-	client  *asynq.Client
-	enabled bool
+	client   *asynq.Client
+	policies RetryPolicies
+	batch    NotifyBatch
+	enabled  bool
 }
 
-// NewClient 创建 Asynq 客户端
-func NewClient(redisAddr, password string, db int, enabled bool) *Client {
+// NewClient 创建 Asynq 客户端，policies 中配置的重试次数和超时覆盖各任务的默认值，
+// batch 开启时通知按用户分组，由 Server 聚合后发送
+func NewClient(redisAddr, password string, db int, policies RetryPolicies, batch NotifyBatch, enabled bool) *Client {
 	if !enabled {
 		return &Client{enabled: false}
 	}
@@ -28,8 +31,10 @@ func NewClient(redisAddr, password string, db int, enabled bool) *Client {
 	})
 
 	return &Client{
-		client:  client,
-		enabled: true,
+		client:   client,
+		policies: policies,
+		batch:    batch,
+		enabled:  true,
 	}
 }
 
@@ -58,7 +63,7 @@ func (c *Client) Enqueue(ctx context.Context, taskType string, payload any, opts
 	}
 
 	task := asynq.NewTask(taskType, data)
-	return c.client.EnqueueContext(ctx, task, opts...)
+	return c.client.EnqueueContext(ctx, task, append(opts, c.policies.Options(taskType)...)...)
 }
 
 // EnqueueKnowledgeProcess 提交知识库处理任务
@@ -70,13 +75,13 @@ func (c *Client) EnqueueKnowledgeProcess(ctx context.Context, payload *Knowledge
 	)
 }
 
-// EnqueueReminderTodo 提交待办提醒任务
-func (c *Client) EnqueueReminderTodo(ctx context.Context, payload *ReminderTodoPayload) (*asynq.TaskInfo, error) {
-	return c.Enqueue(ctx, TypeReminderTodo, payload,
+// EnqueueReminderTodo 提交待办提醒任务，opts 追加在默认选项之后
+func (c *Client) EnqueueReminderTodo(ctx context.Context, payload *ReminderTodoPayload, opts ...asynq.Option) (*asynq.TaskInfo, error) {
+	return c.Enqueue(ctx, TypeReminderTodo, payload, append([]asynq.Option{
 		asynq.MaxRetry(2),
-		asynq.Timeout(5*time.Minute),
+		asynq.Timeout(5 * time.Minute),
 		asynq.Queue("reminder"),
-	)
+	}, opts...)...)
 }
 
 // EnqueueReminderApproval 提交审批提醒任务
@@ -96,3 +101,15 @@ func (c *Client) EnqueueDailySummary(ctx context.Context, payload *DailySummaryP
 		asynq.Queue("reminder"),
 	)
 }
+
+// EnqueueNotify 提交业务通知，开启聚合时同一用户窗口内的通知合并发送，紧急通知不参与聚合
+func (c *Client) EnqueueNotify(ctx context.Context, payload *NotifyPayload) (*asynq.TaskInfo, error) {
+	opts := []asynq.Option{
+		asynq.MaxRetry(2),
+		asynq.Queue("default"),
+	}
+	if c.batch.Enabled() && !payload.Urgent {
+		opts = append(opts, asynq.Group(payload.UserID))
+	}
+	return c.Enqueue(ctx, TypeNotify, payload, opts...)
+}
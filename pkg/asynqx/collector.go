@@ -0,0 +1,130 @@
+package asynqx
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"aiOffice/pkg/metrics"
+
+	"github.com/hibiken/asynq"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Collector 定期从 Inspector 读取各队列的积压、处理数和延迟写入 Prometheus 指标，
+// 用于在提醒等队列积压时告警
+type Collector struct {
+	inspector *asynq.Inspector
+	interval  time.Duration
+	queues    map[string]bool // 上次采集到的队列，队列被删除后清理其指标
+	enabled   bool
+	done      chan struct{}
+	shutdown  sync.Once
+}
+
+// NewCollector interval 为采集间隔，默认15秒
+func NewCollector(redisAddr, password string, db int, interval time.Duration, enabled bool) *Collector {
+	if !enabled {
+		return &Collector{enabled: false}
+	}
+
+	if interval <= 0 {
+		interval = 15 * time.Second
+	}
+	return &Collector{
+		inspector: asynq.NewInspector(asynq.RedisClientOpt{
+			Addr:     redisAddr,
+			Password: password,
+			DB:       db,
+		}),
+		interval: interval,
+		queues:   make(map[string]bool),
+		enabled:  true,
+		done:     make(chan struct{}),
+	}
+}
+
+// IsEnabled 是否启用
+func (c *Collector) IsEnabled() bool {
+	return c.enabled
+}
+
+// Run 启动采集（阻塞），直到调用 Shutdown
+func (c *Collector) Run() {
+	if !c.enabled {
+		return
+	}
+
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+	for {
+		if err := c.collect(); err != nil {
+			fmt.Printf("[Asynq] 采集队列指标失败: %v\n", err)
+		}
+		select {
+		case <-ticker.C:
+		case <-c.done:
+			return
+		}
+	}
+}
+
+// Shutdown 停止采集
+func (c *Collector) Shutdown() {
+	if c.inspector == nil {
+		return
+	}
+	c.shutdown.Do(func() {
+		close(c.done)
+		c.inspector.Close()
+	})
+}
+
+func (c *Collector) collect() error {
+	queues, err := c.inspector.Queues()
+	if err != nil {
+		return err
+	}
+
+	current := make(map[string]bool, len(queues))
+	for _, q := range queues {
+		info, err := c.inspector.GetQueueInfo(q)
+		if err != nil {
+			fmt.Printf("[Asynq] 查询队列 %s 失败: %v\n", q, err)
+			continue
+		}
+		current[q] = true
+
+		for state, n := range map[string]int{
+			"pending":   info.Pending,
+			"active":    info.Active,
+			"scheduled": info.Scheduled,
+			"retry":     info.Retry,
+			"archived":  info.Archived,
+			"completed": info.Completed,
+		} {
+			metrics.AsynqQueueSize.WithLabelValues(q, state).Set(float64(n))
+		}
+		metrics.AsynqQueueProcessed.WithLabelValues(q).Set(float64(info.Processed))
+		metrics.AsynqQueueFailed.WithLabelValues(q).Set(float64(info.Failed))
+		metrics.AsynqQueueLatency.WithLabelValues(q).Set(info.Latency.Seconds())
+		paused := 0.0
+		if info.Paused {
+			paused = 1
+		}
+		metrics.AsynqQueuePaused.WithLabelValues(q).Set(paused)
+	}
+
+	for q := range c.queues {
+		if !current[q] {
+			labels := prometheus.Labels{"queue": q}
+			metrics.AsynqQueueSize.DeletePartialMatch(labels)
+			metrics.AsynqQueueProcessed.DeletePartialMatch(labels)
+			metrics.AsynqQueueFailed.DeletePartialMatch(labels)
+			metrics.AsynqQueueLatency.DeletePartialMatch(labels)
+			metrics.AsynqQueuePaused.DeletePartialMatch(labels)
+		}
+	}
+	c.queues = current
+	return nil
+}
@@ -3,12 +3,20 @@ package handlers
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"slices"
+	"strconv"
 	"time"
 
+	"aiOffice/internal/logic"
 	"aiOffice/internal/model"
 	"aiOffice/internal/svc"
 	"aiOffice/pkg/asynqx"
+	"aiOffice/pkg/i18n"
+	"aiOffice/pkg/notify"
+	"aiOffice/pkg/panicx"
+	"aiOffice/pkg/timeutils"
 
 	"github.com/hibiken/asynq"
 	"go.mongodb.org/mongo-driver/bson"
@@ -26,10 +34,46 @@ func NewHandlers(svc *svc.ServiceContext) *Handlers {
 
 // Register 注册所有任务处理器到 Server
 func (h *Handlers) Register(server *asynqx.Server) {
-	server.HandleFunc(asynqx.TypeReminderTodo, h.HandleTodoReminder)
-	server.HandleFunc(asynqx.TypeReminderApproval, h.HandleApprovalReminder)
-	server.HandleFunc(asynqx.TypeDailySummary, h.HandleDailySummary)
+	server.HandleFunc(asynqx.TypeReminderTodo, h.once(h.HandleTodoReminder))
+	server.HandleFunc(asynqx.TypeReminderApproval, h.once(h.HandleApprovalReminder))
+	server.HandleFunc(asynqx.TypeDailySummary, h.once(h.HandleDailySummary))
+	server.HandleFunc(asynqx.TypeReminderDispatch, h.HandleReminderDispatch)
+	server.HandleFunc(asynqx.TypeReminderEvent, h.HandleEventReminder)
+	server.HandleFunc(asynqx.TypeAnnouncementPublish, h.HandleAnnouncementPublish)
+	server.HandleFunc(asynqx.TypeNotifyDelayed, h.HandleNotifyDelayed)
+	server.HandleFunc(asynqx.TypeNotify, h.HandleNotify)
+	server.HandleFunc(asynqx.TypeNotifyDigest, h.HandleNotifyDigest)
 	server.HandleFunc(asynqx.TypeKnowledgeProcess, h.HandleKnowledgeProcess)
+	server.HandleFunc(asynqx.TypeCalendarSync, h.HandleCalendarSync)
+	server.HandleFunc(asynqx.TypeKnowledgeSync, h.HandleKnowledgeSync)
+	server.HandleFunc(asynqx.TypeRetentionPurge, h.HandleRetentionPurge)
+
+	server.OnPanic(func(ctx context.Context, task *asynq.Task, v any) {
+		h.svc.Panic.Report(ctx, panicx.SourceAsynq, task.Type(), v)
+	})
+}
+
+// once 同一类型、同一载荷的任务在一个去重窗口内只执行一次；asynq 的唯一锁在任务完成后即释放，
+// 窗口内稍晚入队的重复任务仍会执行，这里再按窗口加锁。执行失败时释放，不影响重试
+func (h *Handlers) once(next asynqx.HandlerFunc) asynqx.HandlerFunc {
+	return func(ctx context.Context, task *asynq.Task) error {
+		key := fmt.Sprintf("asynqx:once:%s:%s:%d", task.Type(), task.Payload(), time.Now().Truncate(asynqx.UniqueWindow).Unix())
+		id, _ := asynq.GetTaskID(ctx)
+		ok, err := h.svc.Redis.SetNX(ctx, key, id, 2*asynqx.UniqueWindow).Result()
+		if err != nil {
+			return fmt.Errorf("lock task failed: %w", err)
+		}
+		if !ok {
+			fmt.Printf("[Asynq] %s 在当前时段已执行，跳过重复任务 %s\n", task.Type(), id)
+			return nil
+		}
+
+		if err := next(ctx, task); err != nil {
+			h.svc.Redis.Del(context.WithoutCancel(ctx), key)
+			return err
+		}
+		return nil
+	}
 }
 
 // HandleTodoReminder 处理待办提醒任务
@@ -39,12 +83,8 @@ func (h *Handlers) HandleTodoReminder(ctx context.Context, task *asynq.Task) err
 		return fmt.Errorf("unmarshal payload failed: %w", err)
 	}
 
-	fmt.Printf("[TodoReminder] 开始执行待办提醒任务, userID: %s\n", payload.UserID)
-
 	// 获取今天的时间范围
-	now := time.Now()
-	todayStart := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location()).Unix()
-	todayEnd := time.Date(now.Year(), now.Month(), now.Day(), 23, 59, 59, 0, now.Location()).Unix()
+	todayStart, todayEnd := timeutils.TodayRange()
 
 	// 查询今天到期的待办
 	todos, err := h.findTodayTodos(ctx, payload.UserID, todayStart, todayEnd)
@@ -52,6 +92,19 @@ func (h *Handlers) HandleTodoReminder(ctx context.Context, task *asynq.Task) err
 		return fmt.Errorf("query todos failed: %w", err)
 	}
 
+	// 全局提醒跳过设置了个人提醒时间的用户，由 HandleReminderDispatch 按时单独投递
+	if payload.UserID == "" {
+		settings, err := h.svc.UserSettingModel.FindByReminderTime(ctx, "")
+		if err != nil {
+			return fmt.Errorf("query user settings failed: %w", err)
+		}
+		custom := make(map[string]bool, len(settings))
+		for _, v := range settings {
+			custom[v.UserId] = true
+		}
+		todos = slices.DeleteFunc(todos, func(todo *model.Todo) bool { return custom[todo.CreatorId] })
+	}
+
 	if len(todos) == 0 {
 		fmt.Println("[TodoReminder] 没有今天到期的待办")
 		return nil
@@ -64,15 +117,34 @@ func (h *Handlers) HandleTodoReminder(ctx context.Context, task *asynq.Task) err
 	}
 
 	for userID, userTodoList := range userTodos {
-		msg := h.buildTodoReminderMessage(userTodoList)
+		msg := h.buildTodoReminderMessage(ctx, userTodoList)
 		fmt.Printf("[TodoReminder] 向用户 %s 发送提醒: %s\n", userID, msg)
-		// TODO: 通过 WebSocket 发送消息给用户
+		h.notify(ctx, userID, asynqx.TypeReminderTodo, i18n.T(ctx, "待办提醒"), msg)
 	}
 
 	fmt.Printf("[TodoReminder] 完成，共提醒 %d 个待办\n", len(todos))
 	return nil
 }
 
+// HandleReminderDispatch 为提醒时间是当前这一分钟的用户投递各自的待办提醒
+func (h *Handlers) HandleReminderDispatch(ctx context.Context, task *asynq.Task) error {
+	now := timeutils.NowTime()
+	settings, err := h.svc.UserSettingModel.FindByReminderTime(ctx, now.Format("15:04"))
+	if err != nil {
+		return fmt.Errorf("query user settings failed: %w", err)
+	}
+
+	for _, v := range settings {
+		// 任务ID按用户和日期生成，重复投递时入队失败
+		_, err := h.svc.AsynqClient.EnqueueReminderTodo(ctx, &asynqx.ReminderTodoPayload{UserID: v.UserId},
+			asynq.TaskID(fmt.Sprintf("%s:%s:%s", asynqx.TypeReminderTodo, v.UserId, now.Format("20060102"))))
+		if err != nil && !errors.Is(err, asynq.ErrTaskIDConflict) {
+			fmt.Printf("[ReminderDispatch] 投递用户 %s 的待办提醒失败: %v\n", v.UserId, err)
+		}
+	}
+	return nil
+}
+
 // HandleApprovalReminder 处理审批超时提醒任务
 func (h *Handlers) HandleApprovalReminder(ctx context.Context, task *asynq.Task) error {
 	var payload asynqx.ReminderApprovalPayload
@@ -80,8 +152,6 @@ func (h *Handlers) HandleApprovalReminder(ctx context.Context, task *asynq.Task)
 		return fmt.Errorf("unmarshal payload failed: %w", err)
 	}
 
-	fmt.Printf("[ApprovalReminder] 开始执行审批提醒任务, userID: %s\n", payload.UserID)
-
 	// 查询待处理超过24小时的审批
 	approvals, err := h.findPendingApprovals(ctx, payload.UserID)
 	if err != nil {
@@ -100,70 +170,199 @@ func (h *Handlers) HandleApprovalReminder(ctx context.Context, task *asynq.Task)
 	}
 
 	for userID, userApprovalList := range userApprovals {
-		msg := h.buildApprovalReminderMessage(userApprovalList)
+		msg := h.buildApprovalReminderMessage(ctx, userApprovalList)
 		fmt.Printf("[ApprovalReminder] 向用户 %s 发送提醒: %s\n", userID, msg)
-		// TODO: 通过 WebSocket 发送消息给用户
+		// 审批超时属于紧急通知，离线时可通过短信送达
+		h.send(ctx, userID, &notify.Message{
+			Type:    asynqx.TypeReminderApproval,
+			Title:   i18n.T(ctx, "审批提醒"),
+			Content: msg,
+			Urgent:  true,
+		})
 	}
 
 	fmt.Printf("[ApprovalReminder] 完成，共提醒 %d 个审批\n", len(approvals))
 	return nil
 }
 
-// HandleDailySummary 处理每日工作总结任务
+// HandleDailySummary 为当天有工作记录的用户生成每日工作总结并推送
 func (h *Handlers) HandleDailySummary(ctx context.Context, task *asynq.Task) error {
 	var payload asynqx.DailySummaryPayload
 	if err := json.Unmarshal(task.Payload(), &payload); err != nil {
 		return fmt.Errorf("unmarshal payload failed: %w", err)
 	}
 
-	fmt.Printf("[DailySummary] 开始生成每日工作总结, userID: %s\n", payload.UserID)
+	// 已生成的总结会保存，重试时不会重复生成和推送
+	list, err := logic.NewDailySummary(h.svc).Generate(ctx, payload.UserID, timeutils.NowTime())
+	for _, v := range list {
+		h.notify(ctx, v.UserId, asynqx.TypeDailySummary, i18n.T(ctx, "今日工作总结"), v.Content)
+	}
+	if err != nil {
+		return fmt.Errorf("generate daily summary failed: %w", err)
+	}
 
-	// 获取今天的时间范围
-	now := time.Now()
-	todayStart := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location()).Unix()
-	todayEnd := now.Unix()
+	fmt.Printf("[DailySummary] 完成，共生成 %d 份总结\n", len(list))
+	return nil
+}
 
-	// 统计今日完成的待办
-	completedTodos, err := h.countCompletedTodos(ctx, payload.UserID, todayStart, todayEnd)
-	if err != nil {
-		fmt.Printf("[DailySummary] 统计待办失败: %v\n", err)
+// HandleKnowledgeProcess 解析、分块、向量化并写入知识库，完成或最终失败时通知上传人
+func (h *Handlers) HandleKnowledgeProcess(ctx context.Context, task *asynq.Task) error {
+	var payload asynqx.KnowledgeProcessPayload
+	if err := json.Unmarshal(task.Payload(), &payload); err != nil {
+		return fmt.Errorf("unmarshal payload failed: %w", err)
 	}
 
-	// 统计今日处理的审批
-	processedApprovals, err := h.countProcessedApprovals(ctx, payload.UserID, todayStart, todayEnd)
-	if err != nil {
-		fmt.Printf("[DailySummary] 统计审批失败: %v\n", err)
+	if err := logic.NewKnowledge(h.svc).Process(ctx, payload.DocumentID); err != nil {
+		retried, _ := asynq.GetRetryCount(ctx)
+		maxRetry, _ := asynq.GetMaxRetry(ctx)
+		if retried >= maxRetry && payload.UserID != "" {
+			h.notify(ctx, payload.UserID, asynqx.TypeKnowledgeProcess, i18n.T(ctx, "知识库入库失败"),
+				i18n.T(ctx, "文档 %s 入库失败: %v", payload.FileName, err))
+		}
+		return fmt.Errorf("process knowledge document failed: %w", err)
+	}
+
+	if payload.UserID != "" {
+		h.notify(ctx, payload.UserID, asynqx.TypeKnowledgeProcess, i18n.T(ctx, "知识库入库完成"),
+			i18n.T(ctx, "文档 %s 已加入知识库", payload.FileName))
+	}
+	return nil
+}
+
+// HandleCalendarSync 同步全部用户的外部日历
+func (h *Handlers) HandleCalendarSync(ctx context.Context, task *asynq.Task) error {
+	if h.svc.Calendars == nil {
+		return nil
 	}
 
-	summary := fmt.Sprintf("📊 今日工作总结\n- 完成待办: %d 项\n- 处理审批: %d 项",
-		completedTodos, processedApprovals)
+	return logic.NewCalendar(h.svc).SyncAll(ctx)
+}
+
+// HandleEventReminder 发送到期的日程提醒
+func (h *Handlers) HandleEventReminder(ctx context.Context, task *asynq.Task) error {
+	return logic.NewEvent(h.svc).RemindDue(ctx)
+}
+
+// HandleAnnouncementPublish 推送发布时间已到的公告
+func (h *Handlers) HandleAnnouncementPublish(ctx context.Context, task *asynq.Task) error {
+	return logic.NewAnnouncement(h.svc).DeliverDue(ctx)
+}
+
+// HandleKnowledgeSync 增量拉取外部知识库，变化的页面提交到知识库处理任务
+func (h *Handlers) HandleKnowledgeSync(ctx context.Context, task *asynq.Task) error {
+	if len(h.svc.Wikis) == 0 {
+		return nil
+	}
+
+	return logic.NewKnowledge(h.svc).SyncAll(ctx)
+}
+
+// HandleRetentionPurge 清理过期数据，各项数量写入任务结果，可在监控面板的任务详情中查看
+func (h *Handlers) HandleRetentionPurge(ctx context.Context, task *asynq.Task) error {
+	report, err := logic.NewRetention(h.svc).Purge(ctx)
+	if b, merr := json.Marshal(report); merr == nil {
+		if _, werr := task.ResultWriter().Write(b); werr != nil {
+			fmt.Printf("[Retention] 写入清理结果失败: %v\n", werr)
+		}
+	}
+	return err
+}
 
-	fmt.Printf("[DailySummary] %s\n", summary)
-	// TODO: 通过 WebSocket 发送给用户或保存到数据库
+// HandleNotifyDelayed 免打扰结束后补发通知
+func (h *Handlers) HandleNotifyDelayed(ctx context.Context, task *asynq.Task) error {
+	var payload asynqx.NotifyDelayedPayload
+	if err := json.Unmarshal(task.Payload(), &payload); err != nil {
+		return fmt.Errorf("unmarshal payload failed: %w", err)
+	}
 
+	h.send(ctx, payload.UserID, &notify.Message{
+		Type:    payload.Type,
+		Title:   payload.Title,
+		Content: payload.Content,
+		Data:    payload.Data,
+		Urgent:  payload.Urgent,
+	})
 	return nil
 }
 
-// HandleKnowledgeProcess 处理知识库文档任务（预留）
-func (h *Handlers) HandleKnowledgeProcess(ctx context.Context, task *asynq.Task) error {
-	var payload asynqx.KnowledgeProcessPayload
+// HandleNotify 发送单条业务通知
+func (h *Handlers) HandleNotify(ctx context.Context, task *asynq.Task) error {
+	var payload asynqx.NotifyPayload
 	if err := json.Unmarshal(task.Payload(), &payload); err != nil {
 		return fmt.Errorf("unmarshal payload failed: %w", err)
 	}
 
-	fmt.Printf("[KnowledgeProcess] 开始处理文档: %s\n", payload.FileName)
+	h.send(ctx, payload.UserID, &notify.Message{
+		Type:    payload.Type,
+		Title:   payload.Title,
+		Content: payload.Content,
+		Data:    payload.Data,
+		Urgent:  payload.Urgent,
+	})
+	return nil
+}
 
-	// TODO: 实现文档处理逻辑
-	// 1. 读取文件
-	// 2. 解析文档
-	// 3. 分块
-	// 4. 向量化
-	// 5. 存储到 Redis
+// HandleNotifyDigest 将聚合的多条通知合并为一条摘要发送
+func (h *Handlers) HandleNotifyDigest(ctx context.Context, task *asynq.Task) error {
+	var payload asynqx.NotifyDigestPayload
+	if err := json.Unmarshal(task.Payload(), &payload); err != nil {
+		return fmt.Errorf("unmarshal payload failed: %w", err)
+	}
+	if len(payload.Items) == 0 {
+		return nil
+	}
 
-	fmt.Printf("[KnowledgeProcess] 文档处理完成: %s\n", payload.FileName)
+	h.send(ctx, payload.UserID, &notify.Message{
+		Type:    asynqx.TypeNotifyDigest,
+		Title:   i18n.T(ctx, "您有 %d 条新通知", len(payload.Items)),
+		Content: h.buildDigestMessage(ctx, payload.Items),
+		Data:    map[string]string{"count": strconv.Itoa(len(payload.Items))},
+	})
 	return nil
 }
 
+// notify 发送提醒类通知，见 send
+func (h *Handlers) notify(ctx context.Context, userID, msgType, title, content string) {
+	h.send(ctx, userID, &notify.Message{
+		Type:    msgType,
+		Title:   title,
+		Content: content,
+	})
+}
+
+// send 按用户的提醒偏好发送：免打扰时段内延迟到时段结束后发送，离线推送只使用用户选择的渠道；
+// 失败只记录不影响任务结果
+func (h *Handlers) send(ctx context.Context, userID string, msg *notify.Message) {
+	setting, err := h.svc.UserSettingModel.FindByUserId(ctx, userID)
+	if err != nil && !errors.Is(err, model.ErrNotFound) {
+		fmt.Printf("[Notify] 查询用户 %s 的提醒设置失败: %v\n", userID, err)
+	}
+	if setting == nil {
+		setting = &model.UserSetting{}
+	}
+
+	if until, ok := notify.QuietUntil(setting.QuietStart, setting.QuietEnd, timeutils.NowTime()); ok {
+		_, err := h.svc.AsynqClient.Enqueue(ctx, asynqx.TypeNotifyDelayed, &asynqx.NotifyDelayedPayload{
+			UserID:  userID,
+			Type:    msg.Type,
+			Title:   msg.Title,
+			Content: msg.Content,
+			Data:    msg.Data,
+			Urgent:  msg.Urgent,
+		}, asynq.ProcessAt(until), asynq.Queue("reminder"), asynq.MaxRetry(2))
+		if err == nil {
+			return
+		}
+		fmt.Printf("[Notify] 用户 %s 处于免打扰时段，延迟发送失败，改为立即发送: %v\n", userID, err)
+	}
+
+	msg.Time = time.Now().Unix()
+	err = h.svc.Notifier.NotifyChannels(ctx, userID, msg, setting.Channels)
+	if err != nil {
+		fmt.Printf("[Notify] 向用户 %s 发送提醒失败: %v\n", userID, err)
+	}
+}
+
 // findTodayTodos 查询今天到期的待办
 func (h *Handlers) findTodayTodos(ctx context.Context, userID string, startTime, endTime int64) ([]*model.Todo, error) {
 	col := h.svc.Mongo.Collection("todo")
@@ -224,54 +423,16 @@ func (h *Handlers) findPendingApprovals(ctx context.Context, userID string) ([]*
 	return approvals, nil
 }
 
-// countCompletedTodos 统计今日完成的待办数量
-func (h *Handlers) countCompletedTodos(ctx context.Context, userID string, startTime, endTime int64) (int64, error) {
-	col := h.svc.Mongo.Collection("todo")
-
-	filter := bson.M{
-		"todoStatus": 2, // 已完成
-		"updateAt": bson.M{
-			"$gte": startTime,
-			"$lte": endTime,
-		},
-	}
-
-	if userID != "" {
-		filter["creatorId"] = userID
-	}
-
-	return col.CountDocuments(ctx, filter)
-}
-
-// countProcessedApprovals 统计今日处理的审批数量
-func (h *Handlers) countProcessedApprovals(ctx context.Context, userID string, startTime, endTime int64) (int64, error) {
-	col := h.svc.Mongo.Collection("approval")
-
-	filter := bson.M{
-		"status": bson.M{"$in": []model.ApprovalStatus{model.Pass, model.Refuse}},
-		"finishAt": bson.M{
-			"$gte": startTime,
-			"$lte": endTime,
-		},
-	}
-
-	if userID != "" {
-		filter["userId"] = userID
-	}
-
-	return col.CountDocuments(ctx, filter)
-}
-
 // buildTodoReminderMessage 构建待办提醒消息
-func (h *Handlers) buildTodoReminderMessage(todos []*model.Todo) string {
+func (h *Handlers) buildTodoReminderMessage(ctx context.Context, todos []*model.Todo) string {
 	if len(todos) == 0 {
 		return ""
 	}
 
-	msg := fmt.Sprintf("📋 您有 %d 个待办今天到期：\n", len(todos))
+	msg := "📋 " + i18n.T(ctx, "您有 %d 个待办今天到期：", len(todos)) + "\n"
 	for i, todo := range todos {
 		if i >= 5 {
-			msg += fmt.Sprintf("... 还有 %d 个\n", len(todos)-5)
+			msg += i18n.T(ctx, "... 还有 %d 个", len(todos)-5) + "\n"
 			break
 		}
 		msg += fmt.Sprintf("- %s\n", todo.Title)
@@ -280,18 +441,31 @@ func (h *Handlers) buildTodoReminderMessage(todos []*model.Todo) string {
 }
 
 // buildApprovalReminderMessage 构建审批提醒消息
-func (h *Handlers) buildApprovalReminderMessage(approvals []*model.Approval) string {
+func (h *Handlers) buildApprovalReminderMessage(ctx context.Context, approvals []*model.Approval) string {
 	if len(approvals) == 0 {
 		return ""
 	}
 
-	msg := fmt.Sprintf("⏰ 您有 %d 个审批待处理（超过24小时）：\n", len(approvals))
+	msg := "⏰ " + i18n.T(ctx, "您有 %d 个审批待处理（超过24小时）：", len(approvals)) + "\n"
 	for i, approval := range approvals {
 		if i >= 5 {
-			msg += fmt.Sprintf("... 还有 %d 个\n", len(approvals)-5)
+			msg += i18n.T(ctx, "... 还有 %d 个", len(approvals)-5) + "\n"
 			break
 		}
 		msg += fmt.Sprintf("- [%s] %s\n", approval.Type.ToString(), approval.Title)
 	}
 	return msg
 }
+
+// buildDigestMessage 构建通知摘要，逐条列出标题和内容
+func (h *Handlers) buildDigestMessage(ctx context.Context, items []*asynqx.NotifyPayload) string {
+	var msg string
+	for i, item := range items {
+		if i >= 10 {
+			msg += i18n.T(ctx, "... 还有 %d 条", len(items)-10) + "\n"
+			break
+		}
+		msg += fmt.Sprintf("- %s：%s\n", item.Title, item.Content)
+	}
+	return msg
+}
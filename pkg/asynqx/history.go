@@ -0,0 +1,72 @@
+package asynqx
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/hibiken/asynq"
+	"github.com/redis/go-redis/v9"
+)
+
+// asynq 只保存任务最近一次的错误，重试过程中的每次失败由 Worker 另外记录，供监控面板查看
+const (
+	errorHistoryKey = "asynqx:errors:"
+	errorHistoryMax = 20
+	errorHistoryTTL = 7 * 24 * time.Hour
+)
+
+// TaskError 任务的一次执行失败
+type TaskError struct {
+	Error    string    `json:"error"`
+	Retried  int       `json:"retried"` // 失败时已重试的次数，0为首次执行
+	FailedAt time.Time `json:"failed_at"`
+}
+
+type errorHistory struct {
+	client redis.UniversalClient
+}
+
+func newErrorHistory(redisAddr, password string, db int) *errorHistory {
+	return &errorHistory{client: redis.NewClient(&redis.Options{
+		Addr:     redisAddr,
+		Password: password,
+		DB:       db,
+	})}
+}
+
+// record 在 ErrorHandler 中调用，ctx 为任务的 context
+func (h *errorHistory) record(ctx context.Context, err error) {
+	id, ok := asynq.GetTaskID(ctx)
+	if !ok {
+		return
+	}
+	retried, _ := asynq.GetRetryCount(ctx)
+	b, _ := json.Marshal(TaskError{Error: err.Error(), Retried: retried, FailedAt: time.Now()})
+
+	// 任务的 context 在出错后可能已取消，使用独立的超时
+	rctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	key := errorHistoryKey + id
+	pipe := h.client.TxPipeline()
+	pipe.LPush(rctx, key, b)
+	pipe.LTrim(rctx, key, 0, errorHistoryMax-1)
+	pipe.Expire(rctx, key, errorHistoryTTL)
+	pipe.Exec(rctx)
+}
+
+// list 按时间倒序返回任务的失败记录
+func (h *errorHistory) list(ctx context.Context, id string) ([]TaskError, error) {
+	items, err := h.client.LRange(ctx, errorHistoryKey+id, 0, -1).Result()
+	if err != nil {
+		return nil, err
+	}
+	res := make([]TaskError, 0, len(items))
+	for _, item := range items {
+		var v TaskError
+		if json.Unmarshal([]byte(item), &v) == nil {
+			res = append(res, v)
+		}
+	}
+	return res, nil
+}
@@ -0,0 +1,132 @@
+package asynqx
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"time"
+
+	"aiOffice/pkg/metrics"
+	"aiOffice/pkg/tracex"
+
+	"github.com/hibiken/asynq"
+)
+
+// Middleware 任务处理中间件，包装所有注册的 HandlerFunc
+type Middleware func(next HandlerFunc) HandlerFunc
+
+// TaskMeta 任务的元数据，从 asynq 写入的 context 中提取
+type TaskMeta struct {
+	ID       string
+	Queue    string
+	Retry    int // 已重试次数
+	MaxRetry int
+}
+
+// GetTaskMeta 获取当前任务的元数据，不在任务处理中时为零值
+func GetTaskMeta(ctx context.Context) TaskMeta {
+	var meta TaskMeta
+	meta.ID, _ = asynq.GetTaskID(ctx)
+	meta.Queue, _ = asynq.GetQueueName(ctx)
+	meta.Retry, _ = asynq.GetRetryCount(ctx)
+	meta.MaxRetry, _ = asynq.GetMaxRetry(ctx)
+	return meta
+}
+
+// Logging 记录任务的开始、结束、耗时和错误
+func Logging() Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx context.Context, task *asynq.Task) error {
+			meta := GetTaskMeta(ctx)
+			start := time.Now()
+			fmt.Printf("[Asynq] %s %s 开始, queue: %s, retry: %d/%d, payload: %s\n",
+				task.Type(), meta.ID, meta.Queue, meta.Retry, meta.MaxRetry, truncate(task.Payload(), 200))
+
+			err := next(ctx, task)
+			if err != nil {
+				fmt.Printf("[Asynq] %s %s 失败, 耗时: %v, err: %v\n", task.Type(), meta.ID, time.Since(start), err)
+			} else {
+				fmt.Printf("[Asynq] %s %s 完成, 耗时: %v\n", task.Type(), meta.ID, time.Since(start))
+			}
+			return err
+		}
+	}
+}
+
+// PanicHandler 任务panic时调用，在recover所在的defer中执行，可以取到完整堆栈
+type PanicHandler func(ctx context.Context, task *asynq.Task, v any)
+
+// Recovery 将 panic 转为任务错误，外层的日志和指标中间件能记录到失败；
+// 指定 handlers 时交给其上报，否则打印堆栈
+func Recovery(handlers ...PanicHandler) Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx context.Context, task *asynq.Task) (err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					if len(handlers) == 0 {
+						fmt.Printf("[Asynq] %s panic: %v\n%s", task.Type(), r, debug.Stack())
+					}
+					for _, h := range handlers {
+						h(ctx, task, r)
+					}
+					err = fmt.Errorf("panic: %v", r)
+				}
+			}()
+			return next(ctx, task)
+		}
+	}
+}
+
+// Metrics 按任务类型记录执行次数和耗时
+func Metrics() Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx context.Context, task *asynq.Task) error {
+			start := time.Now()
+			err := next(ctx, task)
+
+			status := "ok"
+			if err != nil {
+				status = "error"
+			}
+			metrics.AsynqTasksTotal.WithLabelValues(task.Type(), status).Inc()
+			metrics.AsynqTaskDuration.WithLabelValues(task.Type()).Observe(time.Since(start).Seconds())
+			return err
+		}
+	}
+}
+
+// Trace 为每个任务开启链路追踪，start 如 tlog.TraceStart
+func Trace(start func(ctx context.Context) context.Context) Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx context.Context, task *asynq.Task) error {
+			return next(start(ctx), task)
+		}
+	}
+}
+
+// Tracing 为每个任务记录链路追踪跨度，任务内的mongo、模型调用作为其子跨度
+func Tracing() Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx context.Context, task *asynq.Task) error {
+			ctx, span := tracex.Start(ctx, "asynq "+task.Type(), tracex.KindConsumer)
+			defer span.End()
+
+			meta := GetTaskMeta(ctx)
+			span.SetAttr("asynq.task_type", task.Type())
+			span.SetAttr("asynq.task_id", meta.ID)
+			span.SetAttr("asynq.queue", meta.Queue)
+			span.SetAttr("asynq.retry", meta.Retry)
+
+			err := next(ctx, task)
+			span.SetError(err)
+			return err
+		}
+	}
+}
+
+func truncate(b []byte, n int) string {
+	if len(b) <= n {
+		return string(b)
+	}
+	return string(b[:n]) + "..."
+}
@@ -1,9 +1,13 @@
 package asynqx
 
 import (
+	"context"
+	"crypto/subtle"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/hibiken/asynq"
@@ -12,13 +16,16 @@ import (
 // Monitor Asynq 监控面板（API 模式）
 type Monitor struct {
 	inspector *asynq.Inspector
+	history   *errorHistory
 	addr      string
+	user      string // Basic认证的用户名和密码，未配置密码时只能查看
+	pass      string
 	enabled   bool
-	isRunning bool
+	server    *http.Server
 }
 
-// NewMonitor 创建监控面板
-func NewMonitor(redisAddr, password string, db int, monitorAddr string, enabled bool) *Monitor {
+// NewMonitor 创建监控面板，monitorPass 为空时不认证且不允许操作任务和队列
+func NewMonitor(redisAddr, password string, db int, monitorAddr, monitorUser, monitorPass string, enabled bool) *Monitor {
 	if !enabled || monitorAddr == "" {
 		return &Monitor{enabled: false}
 	}
@@ -29,11 +36,16 @@ func NewMonitor(redisAddr, password string, db int, monitorAddr string, enabled
 		DB:       db,
 	})
 
-	return &Monitor{
+	m := &Monitor{
 		inspector: inspector,
+		history:   newErrorHistory(redisAddr, password, db),
 		addr:      monitorAddr,
+		user:      monitorUser,
+		pass:      monitorPass,
 		enabled:   true,
 	}
+	m.server = &http.Server{Addr: monitorAddr, Handler: m.auth(m.routes())}
+	return m
 }
 
 // IsEnabled 是否启用
@@ -51,6 +63,7 @@ type QueueInfo struct {
 	Retry     int    `json:"retry"`
 	Archived  int    `json:"archived"`
 	Completed int    `json:"completed"`
+	Paused    bool   `json:"paused"`
 }
 
 // ServerInfo 服务器信息
@@ -71,20 +84,75 @@ func (m *Monitor) Run() error {
 		return nil
 	}
 
+	fmt.Printf("[AsynqMon] Monitor API starting at http://%s\n", m.addr)
+	if m.pass == "" {
+		fmt.Println("[AsynqMon] 未配置 MonitorPassword，监控面板只读且不需要认证，请只监听本机地址")
+	}
+	if err := m.server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// Shutdown 停止监控 API
+func (m *Monitor) Shutdown(ctx context.Context) error {
+	if m.server == nil {
+		return nil
+	}
+	defer m.inspector.Close()
+	return m.server.Shutdown(ctx)
+}
+
+func (m *Monitor) routes() *http.ServeMux {
 	mux := http.NewServeMux()
 
 	// 队列列表
 	mux.HandleFunc("/api/queues", m.handleQueues)
+	// 队列中某个状态的任务列表，?queue=default&state=pending&page=1&size=20
+	mux.HandleFunc("/api/tasks", m.handleTasks)
+	// 任务详情，含payload和失败记录，?queue=default&id=xxx
+	mux.HandleFunc("/api/task", m.handleTask)
+	// 任务操作 run/retry/archive/delete，?queue=default&id=xxx
+	mux.HandleFunc("POST /api/task/{action}", m.handleTaskAction)
+	// 队列操作 pause/unpause，?queue=default
+	mux.HandleFunc("POST /api/queue/{action}", m.handleQueueAction)
 	// 服务器列表
 	mux.HandleFunc("/api/servers", m.handleServers)
 	// 健康检查
 	mux.HandleFunc("/health", m.handleHealth)
 	// 简单的 HTML 页面
 	mux.HandleFunc("/", m.handleIndex)
+	return mux
+}
 
-	m.isRunning = true
-	fmt.Printf("[AsynqMon] Monitor API starting at http://%s\n", m.addr)
-	return http.ListenAndServe(m.addr, mux)
+// auth 除健康检查外都需要Basic认证；操作任务和队列需要配置密码，并要求带上页面发出的请求头，防止跨站提交
+func (m *Monitor) auth(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/health" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if m.pass != "" {
+			user, pass, ok := r.BasicAuth()
+			if !ok || subtle.ConstantTimeCompare([]byte(user), []byte(m.user)) != 1 ||
+				subtle.ConstantTimeCompare([]byte(pass), []byte(m.pass)) != 1 {
+				w.Header().Set("WWW-Authenticate", `Basic realm="asynq monitor"`)
+				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				return
+			}
+		}
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			if m.pass == "" {
+				http.Error(w, "MonitorPassword is required for task and queue actions", http.StatusForbidden)
+				return
+			}
+			if r.Header.Get("X-Requested-With") != "XMLHttpRequest" {
+				http.Error(w, "X-Requested-With header is required", http.StatusForbidden)
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
 }
 
 func (m *Monitor) handleQueues(w http.ResponseWriter, r *http.Request) {
@@ -109,6 +177,7 @@ func (m *Monitor) handleQueues(w http.ResponseWriter, r *http.Request) {
 			Retry:     info.Retry,
 			Archived:  info.Archived,
 			Completed: info.Completed,
+			Paused:    info.Paused,
 		})
 	}
 
@@ -116,6 +185,242 @@ func (m *Monitor) handleQueues(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(result)
 }
 
+// TaskInfo 任务信息
+type TaskInfo struct {
+	ID            string          `json:"id"`
+	Queue         string          `json:"queue"`
+	Type          string          `json:"type"`
+	State         string          `json:"state"`
+	Payload       json.RawMessage `json:"payload,omitempty"`
+	MaxRetry      int             `json:"max_retry"`
+	Retried       int             `json:"retried"`
+	LastErr       string          `json:"last_err,omitempty"`
+	LastFailedAt  *time.Time      `json:"last_failed_at,omitempty"`
+	NextProcessAt *time.Time      `json:"next_process_at,omitempty"`
+	CompletedAt   *time.Time      `json:"completed_at,omitempty"`
+	Result        json.RawMessage `json:"result,omitempty"` // 任务写入的执行结果
+	Errors        []TaskError     `json:"errors,omitempty"` // 每次失败的记录，仅任务详情返回
+}
+
+// TaskPage 分页的任务列表
+type TaskPage struct {
+	Queue string      `json:"queue"`
+	State string      `json:"state"`
+	Page  int         `json:"page"`
+	Size  int         `json:"size"`
+	Total int         `json:"total"`
+	List  []*TaskInfo `json:"list"`
+}
+
+func (m *Monitor) handleTasks(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	queue := query.Get("queue")
+	if queue == "" {
+		queue = "default"
+	}
+	state := query.Get("state")
+	if state == "" {
+		state = "pending"
+	}
+	page, size := pageParams(query.Get("page"), query.Get("size"))
+
+	info, err := m.inspector.GetQueueInfo(queue)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	var (
+		tasks []*asynq.TaskInfo
+		total int
+		opts  = []asynq.ListOption{asynq.Page(page), asynq.PageSize(size)}
+	)
+	switch state {
+	case "pending":
+		tasks, err = m.inspector.ListPendingTasks(queue, opts...)
+		total = info.Pending
+	case "active":
+		tasks, err = m.inspector.ListActiveTasks(queue, opts...)
+		total = info.Active
+	case "scheduled":
+		tasks, err = m.inspector.ListScheduledTasks(queue, opts...)
+		total = info.Scheduled
+	case "retry":
+		tasks, err = m.inspector.ListRetryTasks(queue, opts...)
+		total = info.Retry
+	case "archived":
+		tasks, err = m.inspector.ListArchivedTasks(queue, opts...)
+		total = info.Archived
+	case "completed":
+		tasks, err = m.inspector.ListCompletedTasks(queue, opts...)
+		total = info.Completed
+	default:
+		http.Error(w, "unknown state: "+state, http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	result := TaskPage{Queue: queue, State: state, Page: page, Size: size, Total: total, List: make([]*TaskInfo, 0, len(tasks))}
+	for _, t := range tasks {
+		result.List = append(result.List, toTaskInfo(t))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+func (m *Monitor) handleTask(w http.ResponseWriter, r *http.Request) {
+	queue, id := r.URL.Query().Get("queue"), r.URL.Query().Get("id")
+	if queue == "" || id == "" {
+		http.Error(w, "queue and id are required", http.StatusBadRequest)
+		return
+	}
+
+	t, err := m.inspector.GetTaskInfo(queue, id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	result := toTaskInfo(t)
+	if result.Errors, err = m.history.list(r.Context(), id); err != nil {
+		fmt.Printf("[AsynqMon] 查询任务失败记录失败: %v\n", err)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// handleTaskAction run=立即执行计划中的任务 retry=立即重试失败或归档的任务 archive=归档 delete=删除，执行中的任务不能归档或删除
+func (m *Monitor) handleTaskAction(w http.ResponseWriter, r *http.Request) {
+	queue, id := r.URL.Query().Get("queue"), r.URL.Query().Get("id")
+	if queue == "" || id == "" {
+		http.Error(w, "queue and id are required", http.StatusBadRequest)
+		return
+	}
+
+	action := r.PathValue("action")
+	var err error
+	switch action {
+	case "run":
+		err = m.inspector.RunTask(queue, id)
+	case "retry":
+		var t *asynq.TaskInfo
+		if t, err = m.inspector.GetTaskInfo(queue, id); err == nil {
+			if t.State != asynq.TaskStateRetry && t.State != asynq.TaskStateArchived {
+				http.Error(w, "only retry or archived tasks can be retried", http.StatusConflict)
+				return
+			}
+			err = m.inspector.RunTask(queue, id)
+		}
+	case "archive":
+		err = m.inspector.ArchiveTask(queue, id)
+	case "delete":
+		err = m.inspector.DeleteTask(queue, id)
+	default:
+		http.Error(w, "unknown action: "+action, http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), actionStatus(err))
+		return
+	}
+
+	fmt.Printf("[AsynqMon] %s task %s/%s\n", action, queue, id)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
+}
+
+// handleQueueAction 暂停的队列不再被 Worker 拉取，已在执行的任务不受影响
+func (m *Monitor) handleQueueAction(w http.ResponseWriter, r *http.Request) {
+	queue := r.URL.Query().Get("queue")
+	if queue == "" {
+		http.Error(w, "queue is required", http.StatusBadRequest)
+		return
+	}
+
+	action := r.PathValue("action")
+	var err error
+	switch action {
+	case "pause":
+		err = m.inspector.PauseQueue(queue)
+	case "unpause":
+		err = m.inspector.UnpauseQueue(queue)
+	default:
+		http.Error(w, "unknown action: "+action, http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), actionStatus(err))
+		return
+	}
+
+	fmt.Printf("[AsynqMon] %s queue %s\n", action, queue)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
+}
+
+// actionStatus 任务或队列不存在时返回404，状态不允许该操作时返回409
+func actionStatus(err error) int {
+	switch {
+	case errors.Is(err, asynq.ErrQueueNotFound), errors.Is(err, asynq.ErrTaskNotFound):
+		return http.StatusNotFound
+	default:
+		return http.StatusConflict
+	}
+}
+
+// pageParams 页码从1开始，每页默认20条，最多100条
+func pageParams(page, size string) (int, int) {
+	p, _ := strconv.Atoi(page)
+	s, _ := strconv.Atoi(size)
+	if p <= 0 {
+		p = 1
+	}
+	if s <= 0 {
+		s = 20
+	}
+	return p, min(s, 100)
+}
+
+func toTaskInfo(t *asynq.TaskInfo) *TaskInfo {
+	return &TaskInfo{
+		ID:            t.ID,
+		Queue:         t.Queue,
+		Type:          t.Type,
+		State:         t.State.String(),
+		Payload:       payloadJSON(t.Payload),
+		MaxRetry:      t.MaxRetry,
+		Retried:       t.Retried,
+		LastErr:       t.LastErr,
+		LastFailedAt:  timePtr(t.LastFailedAt),
+		NextProcessAt: timePtr(t.NextProcessAt),
+		CompletedAt:   timePtr(t.CompletedAt),
+		Result:        payloadJSON(t.Result),
+	}
+}
+
+// payloadJSON 任务的payload和结果一般为JSON，其他内容按字符串返回
+func payloadJSON(payload []byte) json.RawMessage {
+	if len(payload) == 0 {
+		return nil
+	}
+	if json.Valid(payload) {
+		return payload
+	}
+	b, _ := json.Marshal(string(payload))
+	return b
+}
+
+func timePtr(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
+
 func (m *Monitor) handleServers(w http.ResponseWriter, r *http.Request) {
 	servers, err := m.inspector.Servers()
 	if err != nil {
@@ -184,12 +489,41 @@ func (m *Monitor) handleIndex(w http.ResponseWriter, r *http.Request) {
                     <th>Retry</th>
                     <th>Completed</th>
                     <th>Archived</th>
+                    <th></th>
                 </tr>
             </thead>
             <tbody></tbody>
         </table>
     </div>
     
+    <div class="card">
+        <h2>Tasks</h2>
+        <p>
+            Queue <input id="task-queue" value="default" size="10">
+            <select id="task-state">
+                <option>pending</option><option>active</option><option>scheduled</option>
+                <option>retry</option><option>archived</option><option>completed</option>
+            </select>
+            <button onclick="page = 1; fetchTasks()">Load</button>
+            <button onclick="page > 1 && (page--, fetchTasks())">Prev</button>
+            <span id="task-page"></span>
+            <button onclick="page++; fetchTasks()">Next</button>
+        </p>
+        <table id="tasks">
+            <thead>
+                <tr>
+                    <th>ID</th>
+                    <th>Type</th>
+                    <th>Retried</th>
+                    <th>Last Error</th>
+                    <th></th>
+                </tr>
+            </thead>
+            <tbody></tbody>
+        </table>
+        <pre id="task-detail"></pre>
+    </div>
+
     <div class="card">
         <h2>Servers</h2>
         <table id="servers">
@@ -229,8 +563,12 @@ func (m *Monitor) handleIndex(w http.ResponseWriter, r *http.Request) {
                         <td><span class="badge badge-retry">${q.retry}</span></td>
                         <td>${q.completed}</td>
                         <td>${q.archived}</td>
+                        <td>${q.paused
+                            ? '<button onclick="queueAction(\'unpause\', \'' + q.name + '\')">Resume</button> <span class="badge badge-retry">paused</span>'
+                            : '<button onclick="queueAction(\'pause\', \'' + q.name + '\')">Pause</button>'}
+                            <button onclick="showTasks(\'' + q.name + '\')">Tasks</button></td>
                     </tr>
-                ` + "`" + `).join('') || '<tr><td colspan="7">No queues</td></tr>';
+                ` + "`" + `).join('') || '<tr><td colspan="8">No queues</td></tr>';
                 
                 // Render servers
                 const serversTbody = document.querySelector('#servers tbody');
@@ -248,6 +586,75 @@ func (m *Monitor) handleIndex(w http.ResponseWriter, r *http.Request) {
             }
         }
         
+        let page = 1;
+
+        async function post(url) {
+            const res = await fetch(url, { method: 'POST', headers: { 'X-Requested-With': 'XMLHttpRequest' } });
+            if (!res.ok) {
+                alert(await res.text());
+            }
+            fetchData();
+            fetchTasks();
+        }
+
+        function queueAction(action, queue) {
+            post('/api/queue/' + action + '?queue=' + encodeURIComponent(queue));
+        }
+
+        function taskAction(action, queue, id) {
+            if ((action === 'delete' || action === 'archive') && !confirm(action + ' task ' + id + '?')) {
+                return;
+            }
+            post('/api/task/' + action + '?queue=' + encodeURIComponent(queue) + '&id=' + encodeURIComponent(id));
+        }
+
+        function showTasks(queue) {
+            document.getElementById('task-queue').value = queue;
+            page = 1;
+            fetchTasks();
+        }
+
+        async function fetchTasks() {
+            const queue = document.getElementById('task-queue').value;
+            const state = document.getElementById('task-state').value;
+            const res = await fetch('/api/tasks?queue=' + encodeURIComponent(queue) + '&state=' + state + '&page=' + page);
+            const tbody = document.querySelector('#tasks tbody');
+            if (!res.ok) {
+                tbody.innerHTML = '<tr><td colspan="5">' + escape(await res.text()) + '</td></tr>';
+                return;
+            }
+            const data = await res.json();
+            document.getElementById('task-page').textContent = 'page ' + data.page + ' / ' + Math.max(1, Math.ceil(data.total / data.size));
+
+            const actions = {
+                pending: ['archive', 'delete'],
+                scheduled: ['run', 'archive', 'delete'],
+                retry: ['retry', 'archive', 'delete'],
+                archived: ['retry', 'delete'],
+                completed: ['delete'],
+            }[state] || [];
+            tbody.innerHTML = data.list.map(t => '<tr>'
+                + '<td><a href="#" onclick="showTask(\'' + queue + '\', \'' + t.id + '\'); return false">' + t.id + '</a></td>'
+                + '<td>' + escape(t.type) + '</td>'
+                + '<td>' + t.retried + '/' + t.max_retry + '</td>'
+                + '<td>' + escape(t.last_err || '') + '</td>'
+                + '<td>' + actions.map(a => '<button onclick="taskAction(\'' + a + '\', \'' + queue + '\', \'' + t.id + '\')">' + a + '</button>').join(' ') + '</td>'
+                + '</tr>').join('') || '<tr><td colspan="5">No tasks</td></tr>';
+        }
+
+        async function showTask(queue, id) {
+            const res = await fetch('/api/task?queue=' + encodeURIComponent(queue) + '&id=' + encodeURIComponent(id));
+            document.getElementById('task-detail').textContent = res.ok
+                ? JSON.stringify(await res.json(), null, 2)
+                : await res.text();
+        }
+
+        function escape(s) {
+            const div = document.createElement('div');
+            div.textContent = s;
+            return div.innerHTML;
+        }
+
         fetchData();
         setInterval(fetchData, 5000);
     </script>
@@ -261,10 +668,5 @@ func (m *Monitor) Handler() http.Handler {
 	if !m.enabled {
 		return http.NotFoundHandler()
 	}
-	mux := http.NewServeMux()
-	mux.HandleFunc("/api/queues", m.handleQueues)
-	mux.HandleFunc("/api/servers", m.handleServers)
-	mux.HandleFunc("/health", m.handleHealth)
-	mux.HandleFunc("/", m.handleIndex)
-	return mux
+	return m.auth(m.routes())
 }
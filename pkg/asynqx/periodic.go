@@ -0,0 +1,116 @@
+package asynqx
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/hibiken/asynq"
+	"github.com/robfig/cron/v3"
+)
+
+// PeriodicTypes 允许在运行时添加定时任务的任务类型及其默认队列
+var PeriodicTypes = map[string]string{
+	TypeReminderTodo:     "reminder",
+	TypeReminderApproval: "reminder",
+	TypeDailySummary:     "reminder",
+	TypeCalendarSync:     "default",
+	TypeKnowledgeSync:    "default",
+}
+
+// ValidatePeriodic 检查动态定时任务的 cron 表达式、任务类型和载荷
+func ValidatePeriodic(cronSpec, taskType, payload string) error {
+	if _, ok := PeriodicTypes[taskType]; !ok {
+		return fmt.Errorf("unsupported task type %q", taskType)
+	}
+	if _, err := cron.ParseStandard(cronSpec); err != nil {
+		return fmt.Errorf("invalid cron %q: %w", cronSpec, err)
+	}
+	if !json.Valid([]byte(payload)) {
+		return fmt.Errorf("payload is not valid json")
+	}
+	return nil
+}
+
+// PeriodicConfigFunc 将函数适配为 asynq.PeriodicTaskConfigProvider
+type PeriodicConfigFunc func() ([]*asynq.PeriodicTaskConfig, error)
+
+func (f PeriodicConfigFunc) GetConfigs() ([]*asynq.PeriodicTaskConfig, error) {
+	return f()
+}
+
+// Periodic 动态定时任务：定期从 provider 读取任务配置，与已注册的任务对比后增删，
+// 与 Scheduler 中固定注册的任务互不影响
+type Periodic struct {
+	manager  *asynq.PeriodicTaskManager
+	enabled  bool
+	done     chan struct{}
+	shutdown sync.Once
+}
+
+// NewPeriodic syncInterval 为同步配置的间隔，修改任务后最迟在该间隔后生效，默认1分钟
+func NewPeriodic(redisAddr, password string, db int, provider asynq.PeriodicTaskConfigProvider, schedules Schedules,
+	syncInterval time.Duration, enabled bool) (*Periodic, error) {
+	if !enabled {
+		return &Periodic{enabled: false}, nil
+	}
+
+	if syncInterval <= 0 {
+		syncInterval = time.Minute
+	}
+	loc, err := schedules.Location()
+	if err != nil {
+		loc = time.Local
+	}
+	manager, err := asynq.NewPeriodicTaskManager(asynq.PeriodicTaskManagerOpts{
+		RedisConnOpt: asynq.RedisClientOpt{
+			Addr:     redisAddr,
+			Password: password,
+			DB:       db,
+		},
+		PeriodicTaskConfigProvider: provider,
+		SyncInterval:               syncInterval,
+		SchedulerOpts:              &asynq.SchedulerOpts{Location: loc},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &Periodic{
+		manager: manager,
+		enabled: true,
+		done:    make(chan struct{}),
+	}, nil
+}
+
+// IsEnabled 是否启用
+func (p *Periodic) IsEnabled() bool {
+	return p.enabled
+}
+
+// Run 启动（阻塞），直到调用 Shutdown
+func (p *Periodic) Run() error {
+	if !p.enabled {
+		return nil
+	}
+
+	fmt.Println("[Periodic] Periodic task manager starting...")
+	if err := p.manager.Start(); err != nil {
+		return err
+	}
+	<-p.done
+	return nil
+}
+
+// Shutdown 停止同步并注销动态注册的任务
+func (p *Periodic) Shutdown() {
+	if p.manager == nil {
+		return
+	}
+	p.shutdown.Do(func() {
+		p.manager.Shutdown()
+		close(p.done)
+		fmt.Println("[Periodic] Periodic task manager stopped")
+	})
+}
@@ -0,0 +1,114 @@
+package asynqx
+
+import (
+	"cmp"
+	"fmt"
+	"math/rand/v2"
+	"time"
+
+	"github.com/hibiken/asynq"
+)
+
+// 重试间隔策略
+const (
+	BackoffExponential = "exponential" // 指数退避
+	BackoffFixed       = "fixed"       // 固定间隔
+)
+
+// RetryPolicy 任务的重试策略，零值字段使用默认策略，都未配置时使用代码中的默认值
+type RetryPolicy struct {
+	MaxRetry  int           // 最大重试次数
+	Timeout   time.Duration // 单次执行超时
+	Backoff   string        // 重试间隔 exponential/fixed，为空使用asynq默认（约n^4秒）
+	BaseDelay time.Duration // exponential为首次重试间隔，fixed为固定间隔，默认10秒
+	MaxDelay  time.Duration // exponential的最大间隔，默认1小时
+	Jitter    float64       // 随机抖动比例 0~1，避免大量任务同时重试
+}
+
+// Delay 第n次重试（从0开始）前等待的时间
+func (p RetryPolicy) Delay(n int, err error, task *asynq.Task) time.Duration {
+	base := cmp.Or(p.BaseDelay, 10*time.Second)
+	maxDelay := cmp.Or(p.MaxDelay, time.Hour)
+
+	var d time.Duration
+	switch p.Backoff {
+	case BackoffFixed:
+		d = base
+	case BackoffExponential:
+		d = maxDelay
+		if n < 32 && base<<n > 0 && base<<n < maxDelay {
+			d = base << n
+		}
+	default:
+		return asynq.DefaultRetryDelayFunc(n, err, task)
+	}
+
+	if p.Jitter > 0 {
+		d += time.Duration(float64(d) * p.Jitter * (2*rand.Float64() - 1))
+	}
+	return d
+}
+
+// RetryPolicies 按任务类型配置的重试策略
+type RetryPolicies struct {
+	Default RetryPolicy
+	Types   map[string]RetryPolicy // 任务类型 -> 策略
+}
+
+// Validate 启动时检查配置
+func (p RetryPolicies) Validate() error {
+	check := func(name string, v RetryPolicy) error {
+		if v.Backoff != "" && v.Backoff != BackoffExponential && v.Backoff != BackoffFixed {
+			return fmt.Errorf("invalid retry backoff %q for %s", v.Backoff, name)
+		}
+		if v.Jitter < 0 || v.Jitter > 1 {
+			return fmt.Errorf("invalid retry jitter %v for %s, must be between 0 and 1", v.Jitter, name)
+		}
+		if v.MaxRetry < 0 || v.Timeout < 0 || v.BaseDelay < 0 || v.MaxDelay < 0 {
+			return fmt.Errorf("invalid retry policy for %s, values must not be negative", name)
+		}
+		return nil
+	}
+
+	if err := check("default", p.Default); err != nil {
+		return err
+	}
+	for name, v := range p.Types {
+		if err := check(name, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Get 任务类型的策略，未配置的字段使用默认策略
+func (p RetryPolicies) Get(taskType string) RetryPolicy {
+	v := p.Types[taskType]
+	return RetryPolicy{
+		MaxRetry:  cmp.Or(v.MaxRetry, p.Default.MaxRetry),
+		Timeout:   cmp.Or(v.Timeout, p.Default.Timeout),
+		Backoff:   cmp.Or(v.Backoff, p.Default.Backoff),
+		BaseDelay: cmp.Or(v.BaseDelay, p.Default.BaseDelay),
+		MaxDelay:  cmp.Or(v.MaxDelay, p.Default.MaxDelay),
+		Jitter:    cmp.Or(v.Jitter, p.Default.Jitter),
+	}
+}
+
+// Options 配置的重试次数和超时，追加在代码中的默认选项之后以覆盖默认值
+func (p RetryPolicies) Options(taskType string) []asynq.Option {
+	v := p.Get(taskType)
+
+	var opts []asynq.Option
+	if v.MaxRetry > 0 {
+		opts = append(opts, asynq.MaxRetry(v.MaxRetry))
+	}
+	if v.Timeout > 0 {
+		opts = append(opts, asynq.Timeout(v.Timeout))
+	}
+	return opts
+}
+
+// RetryDelay 作为 Server 的 RetryDelayFunc，按任务类型计算重试间隔
+func (p RetryPolicies) RetryDelay(n int, err error, task *asynq.Task) time.Duration {
+	return p.Get(task.Type()).Delay(n, err, task)
+}
@@ -1,36 +1,86 @@
 package asynqx
 
 import (
+	"cmp"
 	"fmt"
+	"sync"
+	"time"
 
 	"github.com/hibiken/asynq"
+	"github.com/robfig/cron/v3"
 )
 
 // Scheduler 定时任务调度器
 type Scheduler struct {
 	scheduler *asynq.Scheduler
+	schedules Schedules
+	policies  RetryPolicies
 	enabled   bool
-	isRunning bool
+	done      chan struct{}
+	shutdown  sync.Once
 }
 
-// NewScheduler 创建定时任务调度器
-func NewScheduler(redisAddr, password string, db int, enabled bool) *Scheduler {
+// Schedules 提醒类定时任务的cron表达式，为空时使用默认值
+type Schedules struct {
+	Timezone         string // 为空使用服务器本地时区
+	TodoReminder     string
+	ApprovalReminder string
+	DailySummary     string
+}
+
+// Validate 检查时区和cron表达式，启动时调用，避免配置错误的任务被静默跳过
+func (s Schedules) Validate() error {
+	if _, err := s.Location(); err != nil {
+		return fmt.Errorf("invalid schedule timezone %q: %w", s.Timezone, err)
+	}
+	for name, spec := range map[string]string{
+		"TodoReminder":     s.TodoReminder,
+		"ApprovalReminder": s.ApprovalReminder,
+		"DailySummary":     s.DailySummary,
+	} {
+		if spec == "" {
+			continue
+		}
+		if _, err := cron.ParseStandard(spec); err != nil {
+			return fmt.Errorf("invalid schedule %s %q: %w", name, spec, err)
+		}
+	}
+	return nil
+}
+
+// Location 定时任务的时区，用户设置的提醒时间同样按该时区计算
+func (s Schedules) Location() (*time.Location, error) {
+	if s.Timezone == "" {
+		return time.Local, nil
+	}
+	return time.LoadLocation(s.Timezone)
+}
+
+// NewScheduler 创建定时任务调度器，schedules 需先通过 Validate 检查
+func NewScheduler(redisAddr, password string, db int, schedules Schedules, policies RetryPolicies, enabled bool) *Scheduler {
 	if !enabled {
 		return &Scheduler{enabled: false}
 	}
 
+	loc, err := schedules.Location()
+	if err != nil {
+		loc = time.Local
+	}
 	scheduler := asynq.NewScheduler(
 		asynq.RedisClientOpt{
 			Addr:     redisAddr,
 			Password: password,
 			DB:       db,
 		},
-		nil,
+		&asynq.SchedulerOpts{Location: loc},
 	)
 
 	return &Scheduler{
 		scheduler: scheduler,
+		schedules: schedules,
+		policies:  policies,
 		enabled:   true,
+		done:      make(chan struct{}),
 	}
 }
 
@@ -47,7 +97,7 @@ func (s *Scheduler) Register(cronSpec, taskType string, payload []byte, opts ...
 	}
 
 	task := asynq.NewTask(taskType, payload)
-	entryID, err := s.scheduler.Register(cronSpec, task, opts...)
+	entryID, err := s.scheduler.Register(cronSpec, task, append(opts, s.policies.Options(taskType)...)...)
 	if err != nil {
 		return "", fmt.Errorf("register task failed: %w", err)
 	}
@@ -56,54 +106,143 @@ func (s *Scheduler) Register(cronSpec, taskType string, payload []byte, opts ...
 	return entryID, nil
 }
 
-// RegisterTodoReminder 注册待办提醒（每天 9:00）
+// UniqueWindow 提醒类定时任务的去重窗口：窗口内同一任务只入队一次（多实例或重启后重复注册时），
+// 执行时再按窗口加锁，保证已执行完的任务也不会被重复发送
+const UniqueWindow = 10 * time.Minute
+
+// RegisterTodoReminder 注册待办提醒（默认每天 9:00）
 func (s *Scheduler) RegisterTodoReminder() (string, error) {
 	return s.Register(
-		"0 9 * * *", // 每天 9:00
+		cmp.Or(s.schedules.TodoReminder, "0 9 * * *"),
 		TypeReminderTodo,
 		[]byte("{}"),
 		asynq.Queue("reminder"),
+		asynq.Unique(UniqueWindow),
+	)
+}
+
+// RegisterReminderDispatch 每分钟检查设置了提醒时间的用户，到点为其单独投递待办提醒
+func (s *Scheduler) RegisterReminderDispatch() (string, error) {
+	return s.Register(
+		"* * * * *",
+		TypeReminderDispatch,
+		[]byte("{}"),
+		asynq.Queue("reminder"),
+		asynq.Unique(time.Minute),
+	)
+}
+
+// RegisterEventReminder 每分钟发送到期的日程提醒
+func (s *Scheduler) RegisterEventReminder() (string, error) {
+	return s.Register(
+		"* * * * *",
+		TypeReminderEvent,
+		[]byte("{}"),
+		asynq.Queue("reminder"),
+		asynq.Unique(time.Minute),
+	)
+}
+
+// RegisterAnnouncementPublish 每分钟推送发布时间已到的公告
+func (s *Scheduler) RegisterAnnouncementPublish() (string, error) {
+	return s.Register(
+		"* * * * *",
+		TypeAnnouncementPublish,
+		[]byte("{}"),
+		asynq.Queue("reminder"),
+		asynq.Unique(time.Minute),
 	)
 }
 
-// RegisterApprovalReminder 注册审批超时提醒（每天 10:00 和 15:00）
+// RegisterApprovalReminder 注册审批超时提醒（默认每天 10:00 和 15:00）
 func (s *Scheduler) RegisterApprovalReminder() (string, error) {
 	return s.Register(
-		"0 10,15 * * *", // 每天 10:00 和 15:00
+		cmp.Or(s.schedules.ApprovalReminder, "0 10,15 * * *"),
 		TypeReminderApproval,
 		[]byte("{}"),
 		asynq.Queue("reminder"),
+		asynq.Unique(UniqueWindow),
 	)
 }
 
-// RegisterDailySummary 注册每日总结（每天 18:00）
+// RegisterDailySummary 注册每日总结（默认每天 18:00）
 func (s *Scheduler) RegisterDailySummary() (string, error) {
 	return s.Register(
-		"0 18 * * *", // 每天 18:00
+		cmp.Or(s.schedules.DailySummary, "0 18 * * *"),
 		TypeDailySummary,
 		[]byte("{}"),
 		asynq.Queue("reminder"),
+		asynq.Unique(UniqueWindow),
+	)
+}
+
+// RegisterCalendarSync 注册外部日历同步，cronSpec 为空时每15分钟一次
+func (s *Scheduler) RegisterCalendarSync(cronSpec string) (string, error) {
+	if cronSpec == "" {
+		cronSpec = "*/15 * * * *"
+	}
+	return s.Register(
+		cronSpec,
+		TypeCalendarSync,
+		[]byte("{}"),
+		asynq.Queue("default"),
 	)
 }
 
-// Run 启动调度器（阻塞）
+// RegisterKnowledgeSync 注册外部知识库同步，cronSpec 为空时每小时一次
+func (s *Scheduler) RegisterKnowledgeSync(cronSpec string) (string, error) {
+	if cronSpec == "" {
+		cronSpec = "0 * * * *"
+	}
+	return s.Register(
+		cronSpec,
+		TypeKnowledgeSync,
+		[]byte("{}"),
+		asynq.Queue("default"),
+	)
+}
+
+// RegisterRetentionPurge 注册过期数据清理，cronSpec 为空时每天 3:00；
+// 完成的任务保留7天，便于在监控面板查看每次清理的数量
+func (s *Scheduler) RegisterRetentionPurge(cronSpec string) (string, error) {
+	if cronSpec == "" {
+		cronSpec = "0 3 * * *"
+	}
+	return s.Register(
+		cronSpec,
+		TypeRetentionPurge,
+		[]byte("{}"),
+		asynq.Queue("default"),
+		asynq.Unique(time.Hour),
+		asynq.Timeout(time.Hour),
+		asynq.Retention(7*24*time.Hour),
+	)
+}
+
+// Run 启动调度器（阻塞），直到调用 Shutdown；退出信号由 main 统一处理
 func (s *Scheduler) Run() error {
 	if !s.enabled {
 		fmt.Println("[Scheduler] Scheduler is disabled, skip starting")
 		return nil
 	}
 
-	s.isRunning = true
 	fmt.Println("[Scheduler] Scheduler starting...")
-	return s.scheduler.Run()
+	if err := s.scheduler.Start(); err != nil {
+		return err
+	}
+	<-s.done
+	return nil
 }
 
-// Shutdown 关闭调度器
+// Shutdown 关闭调度器，不再投递新的定时任务
 func (s *Scheduler) Shutdown() error {
-	if s.scheduler != nil && s.isRunning {
+	if s.scheduler == nil {
+		return nil
+	}
+	s.shutdown.Do(func() {
 		s.scheduler.Shutdown()
-		s.isRunning = false
+		close(s.done)
 		fmt.Println("[Scheduler] Scheduler stopped")
-	}
+	})
 	return nil
 }
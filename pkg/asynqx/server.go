@@ -3,6 +3,9 @@ package asynqx
 import (
 	"context"
 	"fmt"
+	"runtime/debug"
+	"sync"
+	"time"
 
 	"github.com/hibiken/asynq"
 )
@@ -12,14 +15,18 @@ type HandlerFunc func(ctx context.Context, task *asynq.Task) error
 
 // Server Asynq Worker 服务
 type Server struct {
-	server    *asynq.Server
-	mux       *asynq.ServeMux
-	enabled   bool
-	isRunning bool
+	server   *asynq.Server
+	mux      *asynq.ServeMux
+	enabled  bool
+	done     chan struct{}
+	shutdown sync.Once
+	onPanic  PanicHandler
 }
 
-// NewServer 创建 Worker 服务
-func NewServer(redisAddr, password string, db int, concurrency int, enabled bool) *Server {
+// NewServer 创建 Worker 服务，shutdownTimeout 为关闭时等待执行中任务的时间，超时未完成的任务会重新入队；
+// 失败任务的重试间隔按 policies 中任务类型的策略计算；batch 开启时按用户聚合通知，需与 Client 的配置一致
+func NewServer(redisAddr, password string, db int, concurrency int, shutdownTimeout time.Duration,
+	policies RetryPolicies, batch NotifyBatch, enabled bool) *Server {
 	if !enabled {
 		return &Server{enabled: false}
 	}
@@ -27,31 +34,68 @@ func NewServer(redisAddr, password string, db int, concurrency int, enabled bool
 	if concurrency <= 0 {
 		concurrency = 10
 	}
+	if shutdownTimeout <= 0 {
+		shutdownTimeout = 30 * time.Second
+	}
 
+	history := newErrorHistory(redisAddr, password, db)
+	conf := asynq.Config{
+		Concurrency:     concurrency,
+		ShutdownTimeout: shutdownTimeout,
+		RetryDelayFunc:  policies.RetryDelay,
+		Queues: map[string]int{
+			"critical":  6, // 高优先级
+			"default":   3, // 默认
+			"knowledge": 2, // 知识库处理
+			"reminder":  1, // 提醒任务
+		},
+		// 失败日志由 Logging 中间件输出，这里只记录错误历史
+		ErrorHandler: asynq.ErrorHandlerFunc(func(ctx context.Context, task *asynq.Task, err error) {
+			history.record(ctx, err)
+		}),
+	}
+	batch.apply(&conf)
 	server := asynq.NewServer(
 		asynq.RedisClientOpt{
 			Addr:     redisAddr,
 			Password: password,
 			DB:       db,
 		},
-		asynq.Config{
-			Concurrency: concurrency,
-			Queues: map[string]int{
-				"critical":  6, // 高优先级
-				"default":   3, // 默认
-				"knowledge": 2, // 知识库处理
-				"reminder":  1, // 提醒任务
-			},
-			ErrorHandler: asynq.ErrorHandlerFunc(func(ctx context.Context, task *asynq.Task, err error) {
-				fmt.Printf("[Asynq] Task %s failed: %v\n", task.Type(), err)
-			}),
-		},
+		conf,
 	)
 
-	return &Server{
+	s := &Server{
 		server:  server,
 		mux:     asynq.NewServeMux(),
 		enabled: true,
+		done:    make(chan struct{}),
+	}
+	s.Use(Logging(), Metrics(), Recovery(s.handlePanic))
+	return s
+}
+
+// OnPanic 设置任务panic时的处理函数，如上报到错误监控，需在 Run 之前调用
+func (s *Server) OnPanic(h PanicHandler) {
+	s.onPanic = h
+}
+
+func (s *Server) handlePanic(ctx context.Context, task *asynq.Task, v any) {
+	if s.onPanic == nil {
+		fmt.Printf("[Asynq] %s panic: %v\n%s", task.Type(), v, debug.Stack())
+		return
+	}
+	s.onPanic(ctx, task, v)
+}
+
+// Use 添加中间件，先添加的在外层，对所有任务生效（包括之后注册的）
+func (s *Server) Use(mws ...Middleware) {
+	if s.mux == nil {
+		return
+	}
+	for _, mw := range mws {
+		s.mux.Use(func(next asynq.Handler) asynq.Handler {
+			return asynq.HandlerFunc(mw(next.ProcessTask))
+		})
 	}
 }
 
@@ -67,23 +111,29 @@ func (s *Server) HandleFunc(taskType string, handler HandlerFunc) {
 	}
 }
 
-// Run 启动 Worker（阻塞）
+// Run 启动 Worker（阻塞），直到调用 Shutdown；退出信号由 main 统一处理
 func (s *Server) Run() error {
 	if !s.enabled {
 		fmt.Println("[Asynq] Worker is disabled, skip starting")
 		return nil
 	}
 
-	s.isRunning = true
 	fmt.Println("[Asynq] Worker starting...")
-	return s.server.Run(s.mux)
+	if err := s.server.Start(s.mux); err != nil {
+		return err
+	}
+	<-s.done
+	return nil
 }
 
-// Shutdown 优雅关闭
+// Shutdown 优雅关闭：停止拉取新任务，等待执行中的任务完成后返回
 func (s *Server) Shutdown() {
-	if s.server != nil && s.isRunning {
+	if s.server == nil {
+		return
+	}
+	s.shutdown.Do(func() {
 		s.server.Shutdown()
-		s.isRunning = false
+		close(s.done)
 		fmt.Println("[Asynq] Worker stopped")
-	}
+	})
 }
@@ -4,18 +4,36 @@ package asynqx
 const (
 	// 知识库相关
 	TypeKnowledgeProcess = "knowledge:process" // 知识库文档处理
+	TypeKnowledgeSync    = "knowledge:sync"    // 外部知识库同步
 
 	// 定时任务相关
 	TypeReminderTodo     = "reminder:todo"     // 待办提醒
 	TypeReminderApproval = "reminder:approval" // 审批超时提醒
 	TypeDailySummary     = "reminder:daily"    // 每日工作总结
+	TypeReminderDispatch = "reminder:dispatch" // 按用户设置的提醒时间投递待办提醒
+	TypeNotifyDelayed    = "notify:delayed"    // 免打扰结束后补发的通知
+	TypeReminderEvent    = "reminder:event"    // 日程提醒
+
+	// 公告
+	TypeAnnouncementPublish = "announcement:publish" // 推送发布时间已到的公告
+
+	// 通知
+	TypeNotify       = "notify:send"   // 业务通知（新待办、审批结果等），开启聚合时按用户分组
+	TypeNotifyDigest = "notify:digest" // 同一用户窗口内多条通知聚合成的摘要
+
+	// 外部日历
+	TypeCalendarSync = "calendar:sync" // 待办与外部日历双向同步
+
+	// 数据清理
+	TypeRetentionPurge = "retention:purge" // 清理过期的聊天记录、待办和审批
 )
 
 // KnowledgeProcessPayload 知识库处理任务载荷
 type KnowledgeProcessPayload struct {
-	UserID   string `json:"user_id"`
-	FilePath string `json:"file_path"`
-	FileName string `json:"file_name"`
+	DocumentID string `json:"document_id"` // 知识库文档记录id
+	UserID     string `json:"user_id"`
+	FilePath   string `json:"file_path"`
+	FileName   string `json:"file_name"`
 }
 
 // ReminderTodoPayload 待办提醒任务载荷
@@ -23,6 +41,32 @@ type ReminderTodoPayload struct {
 	UserID string `json:"user_id,omitempty"` // 空表示全部用户
 }
 
+// NotifyDelayedPayload 延迟发送的通知
+type NotifyDelayedPayload struct {
+	UserID  string            `json:"user_id"`
+	Type    string            `json:"type"`
+	Title   string            `json:"title"`
+	Content string            `json:"content"`
+	Data    map[string]string `json:"data,omitempty"`
+	Urgent  bool              `json:"urgent,omitempty"`
+}
+
+// NotifyPayload 业务通知
+type NotifyPayload struct {
+	UserID  string            `json:"user_id"`
+	Type    string            `json:"type"`
+	Title   string            `json:"title"`
+	Content string            `json:"content"`
+	Data    map[string]string `json:"data,omitempty"`
+	Urgent  bool              `json:"urgent,omitempty"`
+}
+
+// NotifyDigestPayload 聚合后的通知，按入队顺序排列
+type NotifyDigestPayload struct {
+	UserID string           `json:"user_id"`
+	Items  []*NotifyPayload `json:"items"`
+}
+
 // ReminderApprovalPayload 审批提醒任务载荷
 type ReminderApprovalPayload struct {
 	UserID string `json:"user_id,omitempty"` // 空表示全部用户
@@ -0,0 +1,207 @@
+package calendar
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const _calDavQuery = `<?xml version="1.0" encoding="utf-8" ?>
+<C:calendar-query xmlns:D="DAV:" xmlns:C="urn:ietf:params:xml:ns:caldav">
+  <D:prop><D:getetag/><C:calendar-data/></D:prop>
+  <C:filter>
+    <C:comp-filter name="VCALENDAR">
+      <C:comp-filter name="VEVENT">
+        <C:time-range start="%s" end="%s"/>
+      </C:comp-filter>
+    </C:comp-filter>
+  </C:filter>
+</C:calendar-query>`
+
+// multistatus CalDAV REPORT 的响应
+type multistatus struct {
+	Responses []struct {
+		Href     string `xml:"href"`
+		Propstat []struct {
+			Status string `xml:"status"`
+			Prop   struct {
+				CalendarData string `xml:"calendar-data"`
+			} `xml:"prop"`
+		} `xml:"propstat"`
+	} `xml:"response"`
+}
+
+// ErrUnsafeUrl 日历地址由用户填写，只允许访问公网的https地址
+var ErrUnsafeUrl = errors.New("日历地址必须是https且不能指向内网地址")
+
+// _sharedNet 运营商级NAT地址段，部分云厂商的元数据服务在其中
+var _sharedNet = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+// CalDav CalDAV日历，事件以 <集合地址>/<uid>.ics 存储
+type CalDav struct {
+	client *http.Client
+}
+
+func NewCalDav() *CalDav {
+	// 连接时校验解析后的地址，防止域名解析到内网；不走代理，否则校验的是代理地址
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = (&net.Dialer{Timeout: 10 * time.Second, Control: dialControl}).DialContext
+	return &CalDav{
+		client: &http.Client{
+			Timeout:   15 * time.Second,
+			Transport: transport,
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				if len(via) >= 10 {
+					return errors.New("caldav: too many redirects")
+				}
+				return CheckUrl(req.URL.String())
+			},
+		},
+	}
+}
+
+// CheckUrl 校验CalDAV地址：只允许https，主机为IP时不能是内网地址，域名在连接时校验
+func CheckUrl(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || u.Scheme != "https" || u.Hostname() == "" {
+		return ErrUnsafeUrl
+	}
+	if ip := net.ParseIP(u.Hostname()); ip != nil && !publicIP(ip) {
+		return ErrUnsafeUrl
+	}
+	return nil
+}
+
+func dialControl(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	if ip := net.ParseIP(host); ip == nil || !publicIP(ip) {
+		return ErrUnsafeUrl
+	}
+	return nil
+}
+
+// publicIP 排除回环、内网、链路本地（含169.254.169.254元数据地址）、组播等地址
+func publicIP(ip net.IP) bool {
+	return !(ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified() || _sharedNet.Contains(ip))
+}
+
+func (c *CalDav) Name() string {
+	return ProviderCalDav
+}
+
+func (c *CalDav) List(ctx context.Context, cred *Credential, start, end time.Time) ([]*Event, error) {
+	body := fmt.Sprintf(_calDavQuery, start.UTC().Format(icalUtcLayout), end.UTC().Format(icalUtcLayout))
+	res, err := c.do(ctx, cred, "REPORT", collectionUrl(cred), "application/xml; charset=utf-8",
+		[]byte(body), map[string]string{"Depth": "1"})
+	if err != nil {
+		return nil, err
+	}
+
+	var ms multistatus
+	if err := xml.Unmarshal(res, &ms); err != nil {
+		return nil, fmt.Errorf("caldav: parse multistatus: %w", err)
+	}
+
+	var events []*Event
+	for _, r := range ms.Responses {
+		for _, ps := range r.Propstat {
+			if ps.Prop.CalendarData == "" {
+				continue
+			}
+			evs, err := ParseICal(ps.Prop.CalendarData)
+			if err != nil {
+				return nil, err
+			}
+			events = append(events, evs...)
+		}
+	}
+	return events, nil
+}
+
+func (c *CalDav) Put(ctx context.Context, cred *Credential, ev *Event) (string, error) {
+	if ev.Id == "" {
+		ev.Id = uuid.NewString()
+	}
+	_, err := c.do(ctx, cred, http.MethodPut, eventUrl(cred, ev.Id), "text/calendar; charset=utf-8",
+		[]byte(EncodeICal(ev)), nil)
+	if err != nil {
+		return "", err
+	}
+	return ev.Id, nil
+}
+
+func (c *CalDav) Delete(ctx context.Context, cred *Credential, id string) error {
+	_, err := c.do(ctx, cred, http.MethodDelete, eventUrl(cred, id), "", nil, nil)
+	if err == ErrEventNotFound {
+		return nil
+	}
+	return err
+}
+
+func (c *CalDav) do(ctx context.Context, cred *Credential, method, url, contentType string, body []byte,
+	headers map[string]string) ([]byte, error) {
+
+	if err := CheckUrl(url); err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	if cred.Username != "" {
+		req.SetBasicAuth(cred.Username, cred.Password)
+	} else if cred.AccessToken != "" {
+		req.Header.Set("Authorization", "Bearer "+cred.AccessToken)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("caldav: %s %s: %w", method, url, err)
+	}
+	defer resp.Body.Close()
+
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		return nil, ErrUnauthorized
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, ErrEventNotFound
+	case resp.StatusCode >= 300:
+		// 响应内容来自用户填写的地址，不放入错误信息
+		return nil, fmt.Errorf("caldav: %s %s: status %d", method, url, resp.StatusCode)
+	}
+	return b, nil
+}
+
+func collectionUrl(cred *Credential) string {
+	return strings.TrimRight(cred.Url, "/") + "/"
+}
+
+func eventUrl(cred *Credential, id string) string {
+	return collectionUrl(cred) + id + ".ics"
+}
@@ -0,0 +1,53 @@
+package calendar
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestCheckUrl(t *testing.T) {
+	cases := []struct {
+		url string
+		ok  bool
+	}{
+		{"https://caldav.icloud.com/123/calendars/home/", true},
+		{"https://8.8.8.8/dav/", true},
+		{"http://caldav.icloud.com/", false},
+		{"file:///etc/passwd", false},
+		{"https://127.0.0.1/dav/", false},
+		{"https://10.0.0.8/dav/", false},
+		{"https://192.168.1.1/dav/", false},
+		{"https://169.254.169.254/latest/meta-data/", false},
+		{"https://100.100.100.200/latest/meta-data/", false},
+		{"https://[::1]/dav/", false},
+		{"https://[fd00::1]/dav/", false},
+		{"https://[::ffff:127.0.0.1]/dav/", false},
+		{"https://0.0.0.0/dav/", false},
+	}
+	for _, c := range cases {
+		if err := CheckUrl(c.url); (err == nil) != c.ok {
+			t.Errorf("%s: got %v, want ok=%v", c.url, err, c.ok)
+		}
+	}
+}
+
+func TestCalDavDialGuard(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("<multistatus/>"))
+	}))
+	defer srv.Close()
+
+	// 域名解析到回环地址时在连接阶段拒绝
+	_, port, _ := net.SplitHostPort(srv.Listener.Addr().String())
+	c := NewCalDav()
+	cred := &Credential{Url: "https://localhost:" + port + "/dav"}
+	now := time.Now()
+	if _, err := c.List(context.Background(), cred, now, now.Add(time.Hour)); !errors.Is(err, ErrUnsafeUrl) {
+		t.Fatalf("dial to loopback: got %v, want ErrUnsafeUrl", err)
+	}
+}
@@ -0,0 +1,83 @@
+package calendar
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// 日历供应商
+const (
+	ProviderCalDav   = "caldav"   // CalDAV（iCloud、Nextcloud、飞书等）
+	ProviderExchange = "exchange" // Exchange Online / Outlook（Microsoft Graph）
+)
+
+var (
+	ErrUnknownProvider = errors.New("不支持的日历类型")
+	ErrUnauthorized    = errors.New("日历授权已失效，请重新绑定")
+	ErrEventNotFound   = errors.New("日历事件不存在")
+)
+
+// Event 日历事件
+type Event struct {
+	Id       string    `json:"id"` // 供应商侧的事件ID，为空时Put创建新事件
+	Title    string    `json:"title"`
+	Desc     string    `json:"desc,omitempty"`
+	Start    time.Time `json:"start"`
+	End      time.Time `json:"end"`
+	Updated  time.Time `json:"updated,omitempty"`  // 最后修改时间
+	Location string    `json:"location,omitempty"` // 会议地点
+}
+
+// Credential 用户的日历凭证，CalDAV使用账号密码（应用专用密码），Exchange使用OAuth令牌
+// 整体加密后落库，Provider刷新令牌时会直接修改该结构，调用方需要在同步结束后保存
+type Credential struct {
+	Url          string `json:"url,omitempty"` // CalDAV日历集合地址
+	Username     string `json:"username,omitempty"`
+	Password     string `json:"password,omitempty"`
+	AccessToken  string `json:"accessToken,omitempty"`
+	RefreshToken string `json:"refreshToken,omitempty"`
+	ExpireAt     int64  `json:"expireAt,omitempty"`
+}
+
+// Provider 日历供应商
+type Provider interface {
+	Name() string
+	// List 查询时间范围内的事件
+	List(ctx context.Context, cred *Credential, start, end time.Time) ([]*Event, error)
+	// Put 创建或更新事件，返回事件ID
+	Put(ctx context.Context, cred *Credential, ev *Event) (string, error)
+	// Delete 删除事件，事件不存在时不返回错误
+	Delete(ctx context.Context, cred *Credential, id string) error
+}
+
+// Registry 已配置的日历供应商
+type Registry struct {
+	providers map[string]Provider
+}
+
+func NewRegistry(providers ...Provider) *Registry {
+	r := &Registry{providers: make(map[string]Provider, len(providers))}
+	for _, p := range providers {
+		r.providers[p.Name()] = p
+	}
+	return r
+}
+
+// Get 按名称获取供应商
+func (r *Registry) Get(name string) (Provider, error) {
+	p, ok := r.providers[name]
+	if !ok {
+		return nil, ErrUnknownProvider
+	}
+	return p, nil
+}
+
+// Names 已配置的供应商名称
+func (r *Registry) Names() []string {
+	names := make([]string, 0, len(r.providers))
+	for name := range r.providers {
+		names = append(names, name)
+	}
+	return names
+}
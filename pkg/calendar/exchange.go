@@ -0,0 +1,296 @@
+package calendar
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	graphUrl       = "https://graph.microsoft.com/v1.0"
+	msAuthorizeUrl = "https://login.microsoftonline.com/%s/oauth2/v2.0/authorize"
+	msTokenUrl     = "https://login.microsoftonline.com/%s/oauth2/v2.0/token"
+	exchangeScope  = "offline_access Calendars.ReadWrite"
+
+	graphTimeLayout = "2006-01-02T15:04:05.9999999" // 小数部分可选
+)
+
+// ExchangeConf Azure AD 应用配置
+type ExchangeConf struct {
+	Tenant       string // 租户ID，为空时使用 common
+	ClientId     string
+	ClientSecret string
+	RedirectUrl  string // 授权回调地址（前端页面），需要与Azure AD中配置的一致
+}
+
+// graphEvent Microsoft Graph 的事件结构
+type graphEvent struct {
+	Id      string `json:"id,omitempty"`
+	Subject string `json:"subject"`
+	Body    *struct {
+		ContentType string `json:"contentType"`
+		Content     string `json:"content"`
+	} `json:"body,omitempty"`
+	BodyPreview string         `json:"bodyPreview,omitempty"`
+	Start       graphDateTime  `json:"start"`
+	End         graphDateTime  `json:"end"`
+	Location    *graphLocation `json:"location,omitempty"`
+	Modified    string         `json:"lastModifiedDateTime,omitempty"`
+}
+
+type graphDateTime struct {
+	DateTime string `json:"dateTime"`
+	TimeZone string `json:"timeZone"`
+}
+
+type graphLocation struct {
+	DisplayName string `json:"displayName"`
+}
+
+// tokenResp OAuth 令牌响应
+type tokenResp struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int64  `json:"expires_in"`
+	Error        string `json:"error"`
+	ErrorDesc    string `json:"error_description"`
+}
+
+// Exchange Exchange Online / Outlook 日历，通过 Microsoft Graph 访问
+type Exchange struct {
+	conf   ExchangeConf
+	client *http.Client
+
+	// 同一用户并发同步时只刷新一次令牌
+	refreshMu sync.Mutex
+}
+
+func NewExchange(conf ExchangeConf) *Exchange {
+	if conf.Tenant == "" {
+		conf.Tenant = "common"
+	}
+	return &Exchange{
+		conf:   conf,
+		client: &http.Client{Timeout: 15 * time.Second},
+	}
+}
+
+func (e *Exchange) Name() string {
+	return ProviderExchange
+}
+
+// AuthUrl 用户授权地址，前端跳转后携带code回到RedirectUrl
+func (e *Exchange) AuthUrl(state string) string {
+	q := url.Values{
+		"client_id":     {e.conf.ClientId},
+		"response_type": {"code"},
+		"redirect_uri":  {e.conf.RedirectUrl},
+		"response_mode": {"query"},
+		"scope":         {exchangeScope},
+		"state":         {state},
+	}
+	return fmt.Sprintf(msAuthorizeUrl, e.conf.Tenant) + "?" + q.Encode()
+}
+
+// ExchangeCode 用授权码换取令牌
+func (e *Exchange) ExchangeCode(ctx context.Context, code string) (*Credential, error) {
+	cred := &Credential{}
+	err := e.token(ctx, cred, url.Values{
+		"grant_type":   {"authorization_code"},
+		"code":         {code},
+		"redirect_uri": {e.conf.RedirectUrl},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return cred, nil
+}
+
+func (e *Exchange) List(ctx context.Context, cred *Credential, start, end time.Time) ([]*Event, error) {
+	q := url.Values{
+		"startDateTime": {start.UTC().Format(time.RFC3339)},
+		"endDateTime":   {end.UTC().Format(time.RFC3339)},
+		"$top":          {"200"},
+		"$select":       {"id,subject,bodyPreview,start,end,location,lastModifiedDateTime"},
+	}
+
+	var res struct {
+		Value []*graphEvent `json:"value"`
+	}
+	if err := e.do(ctx, cred, http.MethodGet, graphUrl+"/me/calendarView?"+q.Encode(), nil, &res); err != nil {
+		return nil, err
+	}
+
+	events := make([]*Event, 0, len(res.Value))
+	for _, v := range res.Value {
+		ev := &Event{
+			Id:    v.Id,
+			Title: v.Subject,
+			Desc:  v.BodyPreview,
+			Start: parseGraphTime(v.Start),
+			End:   parseGraphTime(v.End),
+		}
+		if v.Location != nil {
+			ev.Location = v.Location.DisplayName
+		}
+		ev.Updated, _ = time.Parse(time.RFC3339, v.Modified)
+		events = append(events, ev)
+	}
+	return events, nil
+}
+
+func (e *Exchange) Put(ctx context.Context, cred *Credential, ev *Event) (string, error) {
+	body := &graphEvent{
+		Subject: ev.Title,
+		Start:   graphDateTime{DateTime: ev.Start.UTC().Format(graphTimeLayout), TimeZone: "UTC"},
+		End:     graphDateTime{DateTime: ev.End.UTC().Format(graphTimeLayout), TimeZone: "UTC"},
+	}
+	if ev.Desc != "" {
+		body.Body = &struct {
+			ContentType string `json:"contentType"`
+			Content     string `json:"content"`
+		}{ContentType: "text", Content: ev.Desc}
+	}
+	if ev.Location != "" {
+		body.Location = &graphLocation{DisplayName: ev.Location}
+	}
+
+	var res graphEvent
+	if ev.Id == "" {
+		if err := e.do(ctx, cred, http.MethodPost, graphUrl+"/me/events", body, &res); err != nil {
+			return "", err
+		}
+		return res.Id, nil
+	}
+	if err := e.do(ctx, cred, http.MethodPatch, graphUrl+"/me/events/"+url.PathEscape(ev.Id), body, &res); err != nil {
+		return "", err
+	}
+	return ev.Id, nil
+}
+
+func (e *Exchange) Delete(ctx context.Context, cred *Credential, id string) error {
+	err := e.do(ctx, cred, http.MethodDelete, graphUrl+"/me/events/"+url.PathEscape(id), nil, nil)
+	if err == ErrEventNotFound {
+		return nil
+	}
+	return err
+}
+
+func (e *Exchange) do(ctx context.Context, cred *Credential, method, u string, body, v any) error {
+	if err := e.refresh(ctx, cred); err != nil {
+		return err
+	}
+
+	var reader io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(b)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, u, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+cred.AccessToken)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Prefer", `outlook.timezone="UTC"`)
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("exchange: %s %s: %w", method, u, err)
+	}
+	defer resp.Body.Close()
+
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		return ErrUnauthorized
+	case resp.StatusCode == http.StatusNotFound:
+		return ErrEventNotFound
+	case resp.StatusCode >= 300:
+		return fmt.Errorf("exchange: %s %s: status %d: %s", method, u, resp.StatusCode, string(b))
+	}
+
+	if v == nil || len(b) == 0 {
+		return nil
+	}
+	return json.Unmarshal(b, v)
+}
+
+// refresh 访问令牌即将过期时用刷新令牌换新，结果写回cred
+func (e *Exchange) refresh(ctx context.Context, cred *Credential) error {
+	e.refreshMu.Lock()
+	defer e.refreshMu.Unlock()
+
+	if cred.AccessToken != "" && time.Now().Unix() < cred.ExpireAt-60 {
+		return nil
+	}
+	if cred.RefreshToken == "" {
+		return ErrUnauthorized
+	}
+	return e.token(ctx, cred, url.Values{
+		"grant_type":    {"refresh_token"},
+		"refresh_token": {cred.RefreshToken},
+	})
+}
+
+func (e *Exchange) token(ctx context.Context, cred *Credential, form url.Values) error {
+	form.Set("client_id", e.conf.ClientId)
+	form.Set("client_secret", e.conf.ClientSecret)
+	form.Set("scope", exchangeScope)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, fmt.Sprintf(msTokenUrl, e.conf.Tenant),
+		strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("exchange: request token: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var res tokenResp
+	if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
+		return fmt.Errorf("exchange: parse token: %w", err)
+	}
+	if res.AccessToken == "" {
+		if res.Error == "invalid_grant" {
+			return ErrUnauthorized
+		}
+		return fmt.Errorf("exchange: request token: %s %s", res.Error, res.ErrorDesc)
+	}
+
+	cred.AccessToken = res.AccessToken
+	if res.RefreshToken != "" {
+		cred.RefreshToken = res.RefreshToken
+	}
+	cred.ExpireAt = time.Now().Unix() + res.ExpiresIn
+	return nil
+}
+
+func parseGraphTime(v graphDateTime) time.Time {
+	loc := time.UTC
+	if v.TimeZone != "" && v.TimeZone != "UTC" {
+		if l, err := time.LoadLocation(v.TimeZone); err == nil {
+			loc = l
+		}
+	}
+	t, _ := time.ParseInLocation(graphTimeLayout, v.DateTime, loc)
+	return t
+}
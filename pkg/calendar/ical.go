@@ -0,0 +1,178 @@
+package calendar
+
+import (
+	"bufio"
+	"fmt"
+	"strings"
+	"time"
+
+	"aiOffice/pkg/timeutils"
+)
+
+const (
+	icalUtcLayout   = "20060102T150405Z"
+	icalLocalLayout = "20060102T150405"
+	icalDateLayout  = "20060102"
+)
+
+var icalEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+
+var icalUnescaper = strings.NewReplacer(`\\`, `\`, `\;`, ";", `\,`, ",", `\n`, "\n", `\N`, "\n")
+
+// EncodeICal 将事件编码为只包含一个VEVENT的iCalendar文本（RFC 5545）
+func EncodeICal(ev *Event) string {
+	var sb strings.Builder
+	line := func(s string) {
+		sb.WriteString(foldLine(s))
+		sb.WriteString("\r\n")
+	}
+
+	line("BEGIN:VCALENDAR")
+	line("VERSION:2.0")
+	line("PRODID:-//aiOffice//calendar//CN")
+	line("BEGIN:VEVENT")
+	line("UID:" + ev.Id)
+	line("DTSTAMP:" + time.Now().UTC().Format(icalUtcLayout))
+	line("DTSTART:" + ev.Start.UTC().Format(icalUtcLayout))
+	line("DTEND:" + ev.End.UTC().Format(icalUtcLayout))
+	line("SUMMARY:" + icalEscaper.Replace(ev.Title))
+	if ev.Desc != "" {
+		line("DESCRIPTION:" + icalEscaper.Replace(ev.Desc))
+	}
+	if ev.Location != "" {
+		line("LOCATION:" + icalEscaper.Replace(ev.Location))
+	}
+	line("END:VEVENT")
+	line("END:VCALENDAR")
+	return sb.String()
+}
+
+// ParseICal 解析iCalendar文本中的全部VEVENT，忽略不认识的属性
+func ParseICal(data string) ([]*Event, error) {
+	var (
+		events []*Event
+		cur    *Event
+	)
+
+	for _, l := range unfoldLines(data) {
+		name, params, value, ok := splitProperty(l)
+		if !ok {
+			continue
+		}
+
+		switch {
+		case name == "BEGIN" && value == "VEVENT":
+			cur = &Event{}
+			continue
+		case name == "END" && value == "VEVENT":
+			if cur == nil {
+				return nil, fmt.Errorf("ical: unexpected END:VEVENT")
+			}
+			if cur.End.IsZero() {
+				cur.End = cur.Start
+			}
+			events = append(events, cur)
+			cur = nil
+			continue
+		}
+		if cur == nil {
+			continue
+		}
+
+		var err error
+		switch name {
+		case "UID":
+			cur.Id = value
+		case "SUMMARY":
+			cur.Title = icalUnescaper.Replace(value)
+		case "DESCRIPTION":
+			cur.Desc = icalUnescaper.Replace(value)
+		case "LOCATION":
+			cur.Location = icalUnescaper.Replace(value)
+		case "DTSTART":
+			cur.Start, err = parseICalTime(value, params)
+		case "DTEND":
+			cur.End, err = parseICalTime(value, params)
+		case "LAST-MODIFIED":
+			cur.Updated, err = parseICalTime(value, params)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("ical: %s: %w", name, err)
+		}
+	}
+	return events, nil
+}
+
+// unfoldLines 按行拆分并合并折叠行（以空格或制表符开头的行是上一行的延续）
+func unfoldLines(data string) []string {
+	var lines []string
+	sc := bufio.NewScanner(strings.NewReader(data))
+	sc.Buffer(make([]byte, 64*1024), 1024*1024)
+	for sc.Scan() {
+		l := strings.TrimRight(sc.Text(), "\r")
+		if len(l) > 0 && (l[0] == ' ' || l[0] == '\t') && len(lines) > 0 {
+			lines[len(lines)-1] += l[1:]
+			continue
+		}
+		lines = append(lines, l)
+	}
+	return lines
+}
+
+// foldLine 超过75字节的行折叠，不拆开多字节字符
+func foldLine(s string) string {
+	const limit = 75
+	if len(s) <= limit {
+		return s
+	}
+
+	var sb strings.Builder
+	n := 0
+	for _, r := range s {
+		size := len(string(r))
+		if n+size > limit {
+			sb.WriteString("\r\n ")
+			n = 1
+		}
+		sb.WriteRune(r)
+		n += size
+	}
+	return sb.String()
+}
+
+// splitProperty 拆分 NAME;PARAM=V:VALUE
+func splitProperty(l string) (name string, params map[string]string, value string, ok bool) {
+	i := strings.Index(l, ":")
+	if i < 0 {
+		return "", nil, "", false
+	}
+	head, value := l[:i], l[i+1:]
+
+	parts := strings.Split(head, ";")
+	name = strings.ToUpper(parts[0])
+	params = make(map[string]string, len(parts)-1)
+	for _, p := range parts[1:] {
+		if kv := strings.SplitN(p, "=", 2); len(kv) == 2 {
+			params[strings.ToUpper(kv[0])] = strings.Trim(kv[1], `"`)
+		}
+	}
+	return name, params, value, true
+}
+
+// parseICalTime 支持UTC时间、带TZID的本地时间和全天日期，未指定时区的按服务时区解析
+func parseICalTime(value string, params map[string]string) (time.Time, error) {
+	if params["VALUE"] == "DATE" || len(value) == len(icalDateLayout) {
+		return time.ParseInLocation(icalDateLayout, value, timeutils.Location())
+	}
+	if strings.HasSuffix(value, "Z") {
+		return time.Parse(icalUtcLayout, value)
+	}
+
+	loc := timeutils.Location()
+	if tz := params["TZID"]; tz != "" {
+		if l, err := time.LoadLocation(tz); err == nil {
+			loc = l
+		}
+	}
+	return time.ParseInLocation(icalLocalLayout, value, loc)
+}
@@ -0,0 +1,75 @@
+package calendar
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestICalRoundTrip(t *testing.T) {
+	ev := &Event{
+		Id:       "aioffice-todo-1",
+		Title:    "评审会议; 第一轮, 需求",
+		Desc:     "第一行\n第二行，" + strings.Repeat("很长的描述", 20),
+		Location: "3楼会议室",
+		Start:    time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC),
+		End:      time.Date(2024, 5, 1, 11, 0, 0, 0, time.UTC),
+	}
+
+	text := EncodeICal(ev)
+	for _, l := range strings.Split(text, "\r\n") {
+		if len(l) > 75 {
+			t.Fatalf("line not folded: %q", l)
+		}
+	}
+
+	events, err := ParseICal(text)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 1 {
+		t.Fatalf("expected 1 event, got %d", len(events))
+	}
+	got := events[0]
+	if got.Id != ev.Id || got.Title != ev.Title || got.Desc != ev.Desc || got.Location != ev.Location {
+		t.Fatalf("unexpected event: %+v", got)
+	}
+	if !got.Start.Equal(ev.Start) || !got.End.Equal(ev.End) {
+		t.Fatalf("unexpected time: %v - %v", got.Start, got.End)
+	}
+}
+
+func TestParseICalTimeForms(t *testing.T) {
+	data := "BEGIN:VCALENDAR\r\n" +
+		"BEGIN:VEVENT\r\n" +
+		"UID:a\r\n" +
+		"DTSTART;TZID=Asia/Shanghai:20240501T090000\r\n" +
+		"DTEND;TZID=Asia/Shanghai:20240501T100000\r\n" +
+		"LAST-MODIFIED:20240430T010203Z\r\n" +
+		"SUMMARY:站会\r\n" +
+		"END:VEVENT\r\n" +
+		"BEGIN:VEVENT\r\n" +
+		"UID:b\r\n" +
+		"DTSTART;VALUE=DATE:20240502\r\n" +
+		"SUMMARY:全天\r\n" +
+		"END:VEVENT\r\n" +
+		"END:VCALENDAR\r\n"
+
+	events, err := ParseICal(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 2 {
+		t.Fatalf("expected 2 events, got %d", len(events))
+	}
+
+	if want := time.Date(2024, 5, 1, 1, 0, 0, 0, time.UTC); !events[0].Start.Equal(want) {
+		t.Fatalf("tzid start: got %v, want %v", events[0].Start, want)
+	}
+	if want := time.Date(2024, 4, 30, 1, 2, 3, 0, time.UTC); !events[0].Updated.Equal(want) {
+		t.Fatalf("last-modified: got %v, want %v", events[0].Updated, want)
+	}
+	if events[1].Start.Day() != 2 || !events[1].End.Equal(events[1].Start) {
+		t.Fatalf("all-day event: %v - %v", events[1].Start, events[1].End)
+	}
+}
@@ -0,0 +1,108 @@
+package calendar
+
+import (
+	"errors"
+	"time"
+)
+
+// 重复频率
+const (
+	FreqDaily   = "daily"
+	FreqWeekly  = "weekly"
+	FreqMonthly = "monthly"
+	FreqYearly  = "yearly"
+)
+
+// maxIterations 展开重复事件时最多计算的次数，避免规则或查询范围异常时耗时过长
+const maxIterations = 20000
+
+var ErrInvalidRecurrence = errors.New("不支持的重复规则")
+
+// Recurrence 重复规则，Freq为空表示不重复；每次发生的时刻与第一次相同，
+// 按月、按年重复时跳过没有该日期的月份（如31日、2月29日），与RFC 5545一致
+type Recurrence struct {
+	Freq     string
+	Interval int       // 间隔，默认1
+	Until    time.Time // 最后一次不晚于该时间，零值不限
+	Count    int       // 共发生几次（含第一次），0不限
+}
+
+// Validate 检查重复规则
+func (r Recurrence) Validate() error {
+	switch r.Freq {
+	case "", FreqDaily, FreqWeekly, FreqMonthly, FreqYearly:
+	default:
+		return ErrInvalidRecurrence
+	}
+	if r.Interval < 0 || r.Count < 0 {
+		return ErrInvalidRecurrence
+	}
+	return nil
+}
+
+// Occurrences 第一次在start开始、每次持续duration的事件，与 [from, to) 有交集的各次开始时间
+func (r Recurrence) Occurrences(start time.Time, duration time.Duration, from, to time.Time) []time.Time {
+	var res []time.Time
+	r.each(start, func(t time.Time) bool {
+		if !t.Before(to) {
+			return false
+		}
+		if t.Add(duration).After(from) || (duration == 0 && !t.Before(from)) {
+			res = append(res, t)
+		}
+		return true
+	})
+	return res
+}
+
+// Next 晚于after的第一次开始时间，没有时返回false
+func (r Recurrence) Next(start, after time.Time) (next time.Time, ok bool) {
+	r.each(start, func(t time.Time) bool {
+		if t.After(after) {
+			next, ok = t, true
+			return false
+		}
+		return true
+	})
+	return next, ok
+}
+
+// each 按顺序遍历每次的开始时间，fn返回false时停止
+func (r Recurrence) each(start time.Time, fn func(t time.Time) bool) {
+	if r.Freq == "" {
+		fn(start)
+		return
+	}
+	interval := max(r.Interval, 1)
+
+	n := 0
+	for i := 0; i < maxIterations; i++ {
+		var t time.Time
+		switch r.Freq {
+		case FreqDaily:
+			t = start.AddDate(0, 0, i*interval)
+		case FreqWeekly:
+			t = start.AddDate(0, 0, 7*i*interval)
+		case FreqMonthly:
+			t = start.AddDate(0, i*interval, 0)
+		case FreqYearly:
+			t = start.AddDate(i*interval, 0, 0)
+		default:
+			return
+		}
+		// 按月、按年重复时目标月份没有该日期，AddDate会顺延到下个月，跳过这一次
+		if (r.Freq == FreqMonthly || r.Freq == FreqYearly) && t.Day() != start.Day() {
+			continue
+		}
+		if !r.Until.IsZero() && t.After(r.Until) {
+			return
+		}
+		if r.Count > 0 && n >= r.Count {
+			return
+		}
+		n++
+		if !fn(t) {
+			return
+		}
+	}
+}
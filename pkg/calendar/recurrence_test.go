@@ -0,0 +1,60 @@
+package calendar
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRecurrence(t *testing.T) {
+	loc := time.FixedZone("CST", 8*3600)
+	start := time.Date(2024, 1, 31, 10, 0, 0, 0, loc)
+	day := func(m time.Month, d int) time.Time { return time.Date(2024, m, d, 10, 0, 0, 0, loc) }
+
+	cases := []struct {
+		name     string
+		r        Recurrence
+		from, to time.Time
+		want     []time.Time
+	}{
+		{"once", Recurrence{}, day(1, 1), day(12, 31), []time.Time{start}},
+		{"once out of range", Recurrence{}, day(2, 1), day(12, 31), nil},
+		{"daily", Recurrence{Freq: FreqDaily, Interval: 2}, day(2, 1), day(2, 6),
+			[]time.Time{day(2, 2), day(2, 4)}},
+		{"weekly count", Recurrence{Freq: FreqWeekly, Count: 2}, day(1, 1), day(12, 31),
+			[]time.Time{start, day(2, 7)}},
+		{"monthly skips short months", Recurrence{Freq: FreqMonthly}, day(1, 1), day(6, 1),
+			[]time.Time{start, day(3, 31), day(5, 31)}},
+		{"until", Recurrence{Freq: FreqDaily, Until: day(2, 2)}, day(1, 1), day(12, 31),
+			[]time.Time{start, day(2, 1), day(2, 2)}},
+	}
+	for _, c := range cases {
+		got := c.r.Occurrences(start, time.Hour, c.from, c.to)
+		if len(got) != len(c.want) {
+			t.Errorf("%s: got %v, want %v", c.name, got, c.want)
+			continue
+		}
+		for i := range got {
+			if !got[i].Equal(c.want[i]) {
+				t.Errorf("%s: got %v, want %v", c.name, got, c.want)
+				break
+			}
+		}
+	}
+
+	// 正在进行中的一次也算在范围内
+	if got := (Recurrence{}).Occurrences(start, time.Hour, start.Add(30*time.Minute), day(12, 31)); len(got) != 1 {
+		t.Errorf("ongoing occurrence should be included, got %v", got)
+	}
+
+	next, ok := Recurrence{Freq: FreqWeekly}.Next(start, start)
+	if !ok || !next.Equal(day(2, 7)) {
+		t.Errorf("next: got %v %v", next, ok)
+	}
+	if _, ok := (Recurrence{}).Next(start, start); ok {
+		t.Error("single event should have no next occurrence")
+	}
+
+	if (Recurrence{Freq: "hourly"}).Validate() == nil {
+		t.Error("unknown freq should be invalid")
+	}
+}
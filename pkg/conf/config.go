@@ -10,6 +10,7 @@ package conf
 
 import (
 	"fmt"
+	"os"
 	"path"
 	"strings"
 
@@ -21,34 +22,55 @@ type Loadhandler func(string, any) error
 var (
 	loaders = map[string]Loadhandler{
 		".yaml": LoadFromYamlBytes,
+		".yml":  LoadFromYamlBytes,
+		".json": LoadFromJsonBytes,
+		".toml": LoadFromTomlBytes,
 	}
 )
 
+// MustLoad 加载并校验配置，失败时输出原因后退出
 func MustLoad(file string, v any) {
-	Load(file, v)
+	if err := Load(file, v); err != nil {
+		fmt.Fprintf(os.Stderr, "加载配置 %s 失败: %v\n", file, err)
+		os.Exit(1)
+	}
 }
 
-// Load loads config into v from file, .json, .yaml and .yml are acceptable.
+// Load loads config into v from file, .json, .toml, .yaml and .yml are acceptable,
+// then checks the fields tagged with validate.
 func Load(file string, v any) error {
 	loader, ok := loaders[strings.ToLower(path.Ext(file))]
 	if !ok {
 		return fmt.Errorf("unrecognized file type: %s", file)
 	}
 
-	return loader(file, v)
+	if err := loader(file, v); err != nil {
+		return err
+	}
+	return Validate(v)
 }
 
 func LoadFromYamlBytes(file string, v any) error {
-	viper.SetConfigType("yaml")
-	file = strings.Replace(file, "\\", "/", -1)
-	viper.AddConfigPath(file[:strings.LastIndex(file, "/")+1])
-	filename := file[strings.LastIndex(file, "/")+1 : strings.LastIndex(file, ".")]
-	viper.SetConfigName(filename)
+	return loadFile(file, "yaml", v)
+}
+
+func LoadFromJsonBytes(file string, v any) error {
+	return loadFile(file, "json", v)
+}
+
+func LoadFromTomlBytes(file string, v any) error {
+	return loadFile(file, "toml", v)
+}
+
+func loadFile(file, configType string, v any) error {
+	vp := viper.New()
+	vp.SetConfigType(configType)
+	vp.SetConfigFile(strings.Replace(file, "\\", "/", -1))
 
-	if err := viper.ReadInConfig(); err != nil {
+	if err := vp.ReadInConfig(); err != nil {
 		return err
 	}
-	if err := viper.Unmarshal(v); err != nil {
+	if err := vp.Unmarshal(v); err != nil {
 		return err
 	}
 
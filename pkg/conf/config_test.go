@@ -0,0 +1,88 @@
+package conf
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type testConf struct {
+	Name  string `validate:"required"`
+	Mongo struct {
+		Host []string `validate:"required_without=Uri"`
+		Uri  string
+	}
+	Jwt struct {
+		Secret string
+		Expire int64
+	}
+	Headers map[string]string
+}
+
+func TestLoadFormats(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"c.yaml": "Name: a\nMongo:\n  Host: [h1]\nJwt:\n  Secret: s\n  Expire: 60\n",
+		"c.json": `{"Name": "a", "Mongo": {"Host": ["h1"]}, "Jwt": {"Secret": "s", "Expire": 60}}`,
+		"c.toml": "Name = \"a\"\n[Mongo]\nHost = [\"h1\"]\n[Jwt]\nSecret = \"s\"\nExpire = 60\n",
+	}
+	for name, content := range files {
+		file := filepath.Join(dir, name)
+		if err := os.WriteFile(file, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		var c testConf
+		if err := Load(file, &c); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if c.Name != "a" || len(c.Mongo.Host) != 1 || c.Jwt.Secret != "s" || c.Jwt.Expire != 60 {
+			t.Errorf("%s: got %+v", name, c)
+		}
+	}
+
+	if err := Load(filepath.Join(dir, "c.ini"), &testConf{}); err == nil {
+		t.Error("unknown extension should fail")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	err := Validate(&testConf{})
+	if err == nil {
+		t.Fatal("missing required fields should fail")
+	}
+	for _, want := range []string{"Name 未配置", "Mongo.Host 未配置（未配置 Uri 时必填）"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q should contain %q", err, want)
+		}
+	}
+
+	c := testConf{Name: "a"}
+	c.Mongo.Uri = "mongodb://u:p@h1/db"
+	if err := Validate(&c); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestMasked(t *testing.T) {
+	c := testConf{Name: "a", Headers: map[string]string{"Authorization": "Bearer x", "X-Env": "dev"}}
+	c.Mongo.Uri = "mongodb://u:pass@h1/db"
+	c.Jwt.Secret = "jwt-secret"
+	c.Jwt.Expire = 60
+
+	out, err := Masked(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := string(out)
+	for _, leaked := range []string{"jwt-secret", "pass@", "Bearer x"} {
+		if strings.Contains(s, leaked) {
+			t.Errorf("secret %q leaked:\n%s", leaked, s)
+		}
+	}
+	for _, want := range []string{"Name: a", "Secret: '******'", "Expire: 60", "X-Env: dev", "mongodb://u:xxxxx@h1/db"} {
+		if !strings.Contains(s, want) {
+			t.Errorf("output should contain %q:\n%s", want, s)
+		}
+	}
+}
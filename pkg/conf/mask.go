@@ -0,0 +1,113 @@
+package conf
+
+import (
+	"bytes"
+	"fmt"
+	"net/url"
+	"reflect"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const maskValue = "******"
+
+// secretNames 字段名（小写）包含这些词的字符串配置视为密钥
+var secretNames = []string{"secret", "password", "token", "apikey", "dsn", "datasource", "authorization"}
+
+// Masked 将配置输出为yaml，密钥类字段替换为 ******，连接串中的密码同样隐藏；字段顺序与结构体一致
+func Masked(v any) ([]byte, error) {
+	node, err := maskNode(reflect.ValueOf(v), false)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(node); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func isSecret(name string) bool {
+	name = strings.ToLower(name)
+	for _, s := range secretNames {
+		if strings.Contains(name, s) {
+			return true
+		}
+	}
+	return false
+}
+
+func maskNode(v reflect.Value, secret bool) (*yaml.Node, error) {
+	switch v.Kind() {
+	case reflect.Invalid:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null", Value: "null"}, nil
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return maskNode(reflect.Value{}, secret)
+		}
+		return maskNode(v.Elem(), secret)
+	case reflect.Struct:
+		node := &yaml.Node{Kind: yaml.MappingNode}
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if !f.IsExported() {
+				continue
+			}
+			name := f.Name
+			if tag := strings.Split(f.Tag.Get("yaml"), ",")[0]; tag != "" && tag != "-" {
+				name = tag
+			}
+			value, err := maskNode(v.Field(i), isSecret(f.Name))
+			if err != nil {
+				return nil, err
+			}
+			node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: name}, value)
+		}
+		return node, nil
+	case reflect.Map:
+		node := &yaml.Node{Kind: yaml.MappingNode}
+		keys := v.MapKeys()
+		sort.Slice(keys, func(i, j int) bool { return fmt.Sprint(keys[i]) < fmt.Sprint(keys[j]) })
+		for _, k := range keys {
+			name := fmt.Sprint(k)
+			value, err := maskNode(v.MapIndex(k), secret || isSecret(name))
+			if err != nil {
+				return nil, err
+			}
+			node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: name}, value)
+		}
+		return node, nil
+	case reflect.Slice, reflect.Array:
+		node := &yaml.Node{Kind: yaml.SequenceNode}
+		for i := 0; i < v.Len(); i++ {
+			value, err := maskNode(v.Index(i), secret)
+			if err != nil {
+				return nil, err
+			}
+			node.Content = append(node.Content, value)
+		}
+		return node, nil
+	case reflect.String:
+		s := v.String()
+		if secret && s != "" {
+			s = maskValue
+		} else if u, err := url.Parse(s); err == nil && u.User != nil {
+			s = u.Redacted()
+		}
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: s}, nil
+	}
+
+	node := &yaml.Node{}
+	if err := node.Encode(v.Interface()); err != nil {
+		return nil, err
+	}
+	return node, nil
+}
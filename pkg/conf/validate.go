@@ -0,0 +1,50 @@
+package conf
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+)
+
+var validate = validator.New()
+
+// Validate 按字段的 validate 标签校验配置，如 `validate:"required"`，返回全部不合法的配置项
+func Validate(v any) error {
+	err := validate.Struct(v)
+	var ves validator.ValidationErrors
+	if !errors.As(err, &ves) {
+		return err
+	}
+
+	errs := make([]error, 0, len(ves))
+	for _, fe := range ves {
+		errs = append(errs, fmt.Errorf("%s %s", fieldPath(fe.Namespace()), message(fe)))
+	}
+	return errors.Join(errs...)
+}
+
+// fieldPath 去掉最外层的结构体名，如 Config.Mongo.Database -> Mongo.Database
+func fieldPath(namespace string) string {
+	if i := strings.Index(namespace, "."); i >= 0 {
+		return namespace[i+1:]
+	}
+	return namespace
+}
+
+func message(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return "未配置"
+	case "required_without":
+		return fmt.Sprintf("未配置（未配置 %s 时必填）", fe.Param())
+	case "oneof":
+		return fmt.Sprintf("只能是 %s 之一，当前为 %v", fe.Param(), fe.Value())
+	case "min", "gte":
+		return fmt.Sprintf("不能小于 %s，当前为 %v", fe.Param(), fe.Value())
+	case "max", "lte":
+		return fmt.Sprintf("不能大于 %s，当前为 %v", fe.Param(), fe.Value())
+	}
+	return fmt.Sprintf("不满足校验规则 %s", fe.Tag())
+}
@@ -2,29 +2,115 @@ package curl
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"net"
 	"net/http"
 	"net/url"
+	"sync/atomic"
+	"time"
+
+	"aiOffice/pkg/requestid"
+)
+
+// Options 客户端配置，零值使用默认值
+type Options struct {
+	Timeout         time.Duration // 单次请求超时，默认30s
+	DialTimeout     time.Duration // 建立连接超时，默认5s
+	Retries         int           // 失败后的重试次数，默认2，小于0不重试
+	Backoff         time.Duration // 首次重试等待时间，之后每次翻倍，默认200ms
+	MaxIdleConns    int           // 每个主机保持的空闲连接数，默认32
+	IdleConnTimeout time.Duration // 空闲连接保持时间，默认90s
+}
+
+const (
+	defaultTimeout      = 30 * time.Second
+	defaultDialTimeout  = 5 * time.Second
+	defaultRetries      = 2
+	defaultBackoff      = 200 * time.Millisecond
+	defaultMaxIdleConns = 32
+	defaultIdleTimeout  = 90 * time.Second
+	maxBackoff          = 5 * time.Second
 )
 
+// Client 复用连接池的http客户端，GET/PUT/DELETE在网络错误、429和502/503/504时按退避重试；
+// POST不是幂等的，只在请求未发出（连接失败）时重试
+type Client struct {
+	http    *http.Client
+	retries int
+	backoff time.Duration
+}
+
+func NewClient(opt Options) *Client {
+	if opt.Timeout <= 0 {
+		opt.Timeout = defaultTimeout
+	}
+	if opt.DialTimeout <= 0 {
+		opt.DialTimeout = defaultDialTimeout
+	}
+	if opt.Retries == 0 {
+		opt.Retries = defaultRetries
+	}
+	if opt.Backoff <= 0 {
+		opt.Backoff = defaultBackoff
+	}
+	if opt.MaxIdleConns <= 0 {
+		opt.MaxIdleConns = defaultMaxIdleConns
+	}
+	if opt.IdleConnTimeout <= 0 {
+		opt.IdleConnTimeout = defaultIdleTimeout
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = (&net.Dialer{Timeout: opt.DialTimeout, KeepAlive: 30 * time.Second}).DialContext
+	transport.MaxIdleConns = opt.MaxIdleConns * 4
+	transport.MaxIdleConnsPerHost = opt.MaxIdleConns
+	transport.IdleConnTimeout = opt.IdleConnTimeout
+	transport.ResponseHeaderTimeout = opt.Timeout
+
+	return &Client{
+		http:    &http.Client{Timeout: opt.Timeout, Transport: transport},
+		retries: max(opt.Retries, 0),
+		backoff: opt.Backoff,
+	}
+}
+
+var std atomic.Pointer[Client]
+
+func init() {
+	std.Store(NewClient(Options{}))
+}
+
+// SetDefault 替换包级函数使用的客户端，启动时根据配置调用
+func SetDefault(c *Client) {
+	std.Store(c)
+}
+
+// Default 包级函数使用的客户端
+func Default() *Client {
+	return std.Load()
+}
+
 // PostRequest 发送POST请求
-func PostRequest(tokenStr, url string, requestBody any) ([]byte, error) {
-	return sendRequest(tokenStr, url, "POST", requestBody)
+func PostRequest(ctx context.Context, tokenStr, url string, requestBody any) ([]byte, error) {
+	return Default().Do(ctx, tokenStr, url, http.MethodPost, requestBody)
 }
 
 // DeleteRequest 发送DELETE请求
-func DeleteRequest(tokenStr, url string, requestBody any) ([]byte, error) {
-	return sendRequest(tokenStr, url, "DELETE", nil)
+func DeleteRequest(ctx context.Context, tokenStr, url string, requestBody any) ([]byte, error) {
+	return Default().Do(ctx, tokenStr, url, http.MethodDelete, requestBody)
 }
 
 // PutRequest 发送PUT请求
-func PutRequest(tokenStr, url string, requestBody any) ([]byte, error) {
-	return sendRequest(tokenStr, url, "PUT", requestBody)
+func PutRequest(ctx context.Context, tokenStr, url string, requestBody any) ([]byte, error) {
+	return Default().Do(ctx, tokenStr, url, http.MethodPut, requestBody)
 }
 
 // GetRequest 发送GET请求，支持查询参数
-func GetRequest(tokenStr, urls string, queryParams map[string]any) ([]byte, error) {
+func GetRequest(ctx context.Context, tokenStr, urls string, queryParams map[string]any) ([]byte, error) {
 	// 拼接查询参数
 	if len(queryParams) > 0 {
 		values := url.Values{}
@@ -33,11 +119,11 @@ func GetRequest(tokenStr, urls string, queryParams map[string]any) ([]byte, erro
 		}
 		urls = urls + "?" + values.Encode()
 	}
-	return sendRequest(tokenStr, urls, "GET", nil)
+	return Default().Do(ctx, tokenStr, urls, http.MethodGet, nil)
 }
 
-// sendRequest 统一的HTTP请求发送方法
-func sendRequest(tokenStr, url, method string, requestBody any) ([]byte, error) {
+// Do 统一的HTTP请求发送方法，传递ctx中的请求标识；重试用尽后返回最后一次的响应体或错误
+func (c *Client) Do(ctx context.Context, tokenStr, url, method string, requestBody any) ([]byte, error) {
 	var (
 		body []byte
 		err  error
@@ -51,10 +137,27 @@ func sendRequest(tokenStr, url, method string, requestBody any) ([]byte, error)
 		}
 	}
 
+	backoff := c.backoff
+	for attempt := 0; ; attempt++ {
+		data, status, err := c.send(ctx, tokenStr, url, method, body)
+		if attempt >= c.retries || !retryable(ctx, method, status, err) {
+			return data, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, maxBackoff)
+	}
+}
+
+func (c *Client) send(ctx context.Context, tokenStr, url, method string, body []byte) ([]byte, int, error) {
 	// 创建HTTP请求
-	req, err := http.NewRequest(method, url, bytes.NewBuffer(body))
+	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(body))
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 
 	// 设置请求头
@@ -62,21 +165,36 @@ func sendRequest(tokenStr, url, method string, requestBody any) ([]byte, error)
 	if len(tokenStr) > 0 {
 		req.Header.Set("Authorization", tokenStr)
 	}
+	requestid.Inject(ctx, req.Header)
 
 	// 发送请求
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	resp, err := c.http.Do(req)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 	defer resp.Body.Close()
 
 	// 读取响应体
-	responseBuffer := new(bytes.Buffer)
-	_, err = responseBuffer.ReadFrom(resp.Body)
+	data, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, err
+		return nil, resp.StatusCode, err
 	}
+	return data, resp.StatusCode, nil
+}
 
-	return responseBuffer.Bytes(), nil
+// retryable 请求是否可以重试，调用方取消或超时后不再重试
+func retryable(ctx context.Context, method string, status int, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	if err != nil {
+		var opErr *net.OpError
+		// POST 只重试连接阶段的错误，此时请求还未发送到服务端
+		return method != http.MethodPost || errors.As(err, &opErr) && opErr.Op == "dial"
+	}
+	if method == http.MethodPost {
+		return false
+	}
+	return status == http.StatusBadGateway || status == http.StatusServiceUnavailable ||
+		status == http.StatusGatewayTimeout || status == http.StatusTooManyRequests
 }
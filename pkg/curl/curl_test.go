@@ -0,0 +1,67 @@
+package curl
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRetryGet(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"code":200}`))
+	}))
+	defer srv.Close()
+
+	c := NewClient(Options{Retries: 2, Backoff: time.Millisecond})
+	data, err := c.Do(context.Background(), "", srv.URL, http.MethodGet, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `{"code":200}` || calls.Load() != 3 {
+		t.Errorf("got %s after %d calls", data, calls.Load())
+	}
+}
+
+func TestNoRetryPost(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	c := NewClient(Options{Retries: 2, Backoff: time.Millisecond})
+	if _, err := c.Do(context.Background(), "", srv.URL, http.MethodPost, map[string]string{"a": "b"}); err != nil {
+		t.Fatal(err)
+	}
+	if calls.Load() != 1 {
+		t.Errorf("post should not be retried, got %d calls", calls.Load())
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(2 * time.Second):
+		}
+	}))
+	defer srv.Close()
+
+	c := NewClient(Options{Timeout: 50 * time.Millisecond, Retries: -1})
+	start := time.Now()
+	if _, err := c.Do(context.Background(), "", srv.URL, http.MethodGet, nil); err == nil {
+		t.Fatal("expected timeout error")
+	}
+	if time.Since(start) > time.Second {
+		t.Errorf("request not timed out, took %v", time.Since(start))
+	}
+}
@@ -0,0 +1,155 @@
+package encrypt
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+// 密文格式: enc:v1:<keyId>:<base64(nonce+ciphertext)>，没有前缀的视为明文（兼容历史数据）
+const cipherPrefix = "enc:v1:"
+
+var (
+	ErrKeyNotFound   = errors.New("encrypt key not found")
+	ErrInvalidCipher = errors.New("invalid ciphertext")
+)
+
+// KeyProvider 密钥来源，可以对接配置文件、环境变量或KMS
+type KeyProvider interface {
+	Key(id string) ([]byte, error)
+}
+
+// StaticKeys 配置文件中的密钥 keyId -> base64(key)
+type StaticKeys map[string]string
+
+func (s StaticKeys) Key(id string) ([]byte, error) {
+	v, ok := s[id]
+	if !ok {
+		return nil, ErrKeyNotFound
+	}
+	return base64.StdEncoding.DecodeString(v)
+}
+
+// EnvKeys 从环境变量读取密钥，变量名为 Prefix+keyId，值为 base64(key)
+type EnvKeys struct {
+	Prefix string
+}
+
+func (e EnvKeys) Key(id string) ([]byte, error) {
+	v := os.Getenv(e.Prefix + id)
+	if v == "" {
+		return nil, ErrKeyNotFound
+	}
+	return base64.StdEncoding.DecodeString(v)
+}
+
+// AesGcm AES-GCM 加解密，支持按 keyId 轮换密钥
+type AesGcm struct {
+	provider    KeyProvider
+	activeKeyId string
+
+	sync.RWMutex
+	aeads map[string]cipher.AEAD
+}
+
+func NewAesGcm(provider KeyProvider, activeKeyId string) (*AesGcm, error) {
+	if strings.Contains(activeKeyId, ":") {
+		return nil, fmt.Errorf("invalid key id %q", activeKeyId)
+	}
+
+	c := &AesGcm{
+		provider:    provider,
+		activeKeyId: activeKeyId,
+		aeads:       make(map[string]cipher.AEAD),
+	}
+	// 启动时校验当前密钥可用
+	if _, err := c.aead(activeKeyId); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Encrypt 使用当前密钥加密
+func (c *AesGcm) Encrypt(plaintext string) (string, error) {
+	aead, err := c.aead(c.activeKeyId)
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	sealed := aead.Seal(nonce, nonce, []byte(plaintext), []byte(c.activeKeyId))
+	return cipherPrefix + c.activeKeyId + ":" + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt 解密，非密文原样返回
+func (c *AesGcm) Decrypt(text string) (string, error) {
+	if !IsEncrypted(text) {
+		return text, nil
+	}
+
+	keyId, data, ok := strings.Cut(strings.TrimPrefix(text, cipherPrefix), ":")
+	if !ok {
+		return "", ErrInvalidCipher
+	}
+
+	aead, err := c.aead(keyId)
+	if err != nil {
+		return "", err
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(data)
+	if err != nil || len(sealed) < aead.NonceSize() {
+		return "", ErrInvalidCipher
+	}
+
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, ciphertext, []byte(keyId))
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
+
+// IsEncrypted 判断是否为密文
+func IsEncrypted(text string) bool {
+	return strings.HasPrefix(text, cipherPrefix)
+}
+
+func (c *AesGcm) aead(keyId string) (cipher.AEAD, error) {
+	c.RLock()
+	aead, ok := c.aeads[keyId]
+	c.RUnlock()
+	if ok {
+		return aead, nil
+	}
+
+	key, err := c.provider.Key(keyId)
+	if err != nil {
+		return nil, fmt.Errorf("load key %s failed: %w", keyId, err)
+	}
+
+	// key 长度必须是 16/24/32 字节，对应 AES-128/192/256
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err = cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	c.Lock()
+	c.aeads[keyId] = aead
+	c.Unlock()
+	return aead, nil
+}
@@ -0,0 +1,46 @@
+package encrypt
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+)
+
+func TestAesGcm(t *testing.T) {
+	keys := StaticKeys{
+		"k1": base64.StdEncoding.EncodeToString([]byte("0123456789abcdef0123456789abcdef")),
+		"k2": base64.StdEncoding.EncodeToString([]byte("fedcba9876543210fedcba9876543210")),
+	}
+
+	c1, err := NewAesGcm(keys, "k1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	enc, err := c1.Encrypt("你好")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !IsEncrypted(enc) || strings.Contains(enc, "你好") {
+		t.Fatalf("unexpected ciphertext %s", enc)
+	}
+
+	// 轮换密钥后旧数据仍可解密
+	c2, err := NewAesGcm(keys, "k2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	plain, err := c2.Decrypt(enc)
+	if err != nil || plain != "你好" {
+		t.Fatalf("decrypt fail %v %s", err, plain)
+	}
+
+	// 明文原样返回
+	if plain, _ := c2.Decrypt("hello"); plain != "hello" {
+		t.Errorf("plaintext should be returned as is, got %s", plain)
+	}
+
+	if _, err := NewAesGcm(keys, "k3"); err == nil {
+		t.Error("missing key should fail")
+	}
+}
@@ -0,0 +1,104 @@
+package encrypt
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// 密码哈希算法
+const (
+	Bcrypt   = "bcrypt"
+	Argon2id = "argon2id"
+)
+
+const argon2Prefix = "$argon2id$"
+
+var ErrInvalidHash = errors.New("invalid argon2id hash")
+
+// Argon2Params argon2id参数，零值使用 DefaultArgon2Params 中的值
+type Argon2Params struct {
+	Memory      uint32 // 内存（KiB）
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+}
+
+// DefaultArgon2Params OWASP推荐的参数之一：64MiB、3次迭代
+var DefaultArgon2Params = Argon2Params{
+	Memory:      64 * 1024,
+	Iterations:  3,
+	Parallelism: 2,
+	SaltLength:  16,
+	KeyLength:   32,
+}
+
+func (p Argon2Params) withDefaults() Argon2Params {
+	d := DefaultArgon2Params
+	if p.Memory > 0 {
+		d.Memory = p.Memory
+	}
+	if p.Iterations > 0 {
+		d.Iterations = p.Iterations
+	}
+	if p.Parallelism > 0 {
+		d.Parallelism = p.Parallelism
+	}
+	if p.SaltLength > 0 {
+		d.SaltLength = p.SaltLength
+	}
+	if p.KeyLength > 0 {
+		d.KeyLength = p.KeyLength
+	}
+	return d
+}
+
+// hashArgon2 生成PHC格式的哈希，如 $argon2id$v=19$m=65536,t=3,p=2$<salt>$<hash>
+func hashArgon2(password []byte, p Argon2Params) ([]byte, error) {
+	salt := make([]byte, p.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	key := argon2.IDKey(password, salt, p.Iterations, p.Memory, p.Parallelism, p.KeyLength)
+	return []byte(fmt.Sprintf("%sv=%d$m=%d,t=%d,p=%d$%s$%s", argon2Prefix, argon2.Version,
+		p.Memory, p.Iterations, p.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key))), nil
+}
+
+// parseArgon2 解析哈希中的参数、盐和密钥
+func parseArgon2(hash string) (p Argon2Params, salt, key []byte, err error) {
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 || parts[1] != Argon2id {
+		return p, nil, nil, ErrInvalidHash
+	}
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return p, nil, nil, ErrInvalidHash
+	}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &p.Memory, &p.Iterations, &p.Parallelism); err != nil {
+		return p, nil, nil, ErrInvalidHash
+	}
+	if salt, err = base64.RawStdEncoding.DecodeString(parts[4]); err != nil {
+		return p, nil, nil, ErrInvalidHash
+	}
+	if key, err = base64.RawStdEncoding.DecodeString(parts[5]); err != nil {
+		return p, nil, nil, ErrInvalidHash
+	}
+	p.SaltLength, p.KeyLength = uint32(len(salt)), uint32(len(key))
+	return p, salt, key, nil
+}
+
+func compareArgon2(password, hash string) bool {
+	p, salt, key, err := parseArgon2(hash)
+	if err != nil {
+		return false
+	}
+	other := argon2.IDKey([]byte(password), salt, p.Iterations, p.Memory, p.Parallelism, p.KeyLength)
+	return subtle.ConstantTimeCompare(key, other) == 1
+}
@@ -0,0 +1,47 @@
+package encrypt
+
+import (
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestArgon2Password(t *testing.T) {
+	legacy, _ := bcrypt.GenerateFromPassword([]byte("123456"), bcrypt.MinCost)
+
+	params := Argon2Params{Memory: 1024, Iterations: 1, Parallelism: 1}
+	if err := SetPasswordAlgorithm(Argon2id, params); err != nil {
+		t.Fatal(err)
+	}
+	defer SetPasswordAlgorithm(Bcrypt, Argon2Params{})
+
+	hash, err := GenPasswordHash([]byte("123456"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(hash), "$argon2id$v=19$m=1024,t=1,p=1$") {
+		t.Fatalf("unexpected hash %s", hash)
+	}
+	if !ValidatePasswordHash("123456", string(hash)) || ValidatePasswordHash("654321", string(hash)) {
+		t.Error("argon2id validate failed")
+	}
+
+	// 旧的bcrypt密码仍可校验，并需要重新生成
+	if !ValidatePasswordHash("123456", string(legacy)) {
+		t.Error("bcrypt validate failed")
+	}
+	if !NeedsRehash(string(legacy)) || NeedsRehash(string(hash)) {
+		t.Error("unexpected rehash result")
+	}
+
+	// 参数调整后需要重新生成
+	SetPasswordAlgorithm(Argon2id, Argon2Params{Memory: 2048, Iterations: 1, Parallelism: 1})
+	if !NeedsRehash(string(hash)) {
+		t.Error("hash with old params should be rehashed")
+	}
+
+	if err := SetPasswordAlgorithm("md5", params); err == nil {
+		t.Error("expected unsupported algorithm error")
+	}
+}
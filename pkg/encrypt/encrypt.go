@@ -1,16 +1,75 @@
 package encrypt
 
-import "golang.org/x/crypto/bcrypt"
+import (
+	"fmt"
+	"strings"
+	"sync"
 
-//hash加密
+	"golang.org/x/crypto/bcrypt"
+)
+
+var (
+	passwordLock   sync.RWMutex
+	passwordAlgo   = Bcrypt
+	passwordParams = DefaultArgon2Params
+)
+
+// SetPasswordAlgorithm 设置新密码使用的哈希算法，启动时根据配置调用；已有的哈希按格式识别，不受影响
+func SetPasswordAlgorithm(algo string, params Argon2Params) error {
+	if algo == "" {
+		algo = Bcrypt
+	}
+	if algo != Bcrypt && algo != Argon2id {
+		return fmt.Errorf("unsupported password algorithm %q", algo)
+	}
+	passwordLock.Lock()
+	defer passwordLock.Unlock()
+	passwordAlgo, passwordParams = algo, params.withDefaults()
+	return nil
+}
+
+// PasswordAlgorithm 当前新密码使用的哈希算法
+func PasswordAlgorithm() string {
+	passwordLock.RLock()
+	defer passwordLock.RUnlock()
+	return passwordAlgo
+}
+
+// hash加密
 func GenPasswordHash(password []byte) ([]byte, error) {
+	passwordLock.RLock()
+	algo, params := passwordAlgo, passwordParams
+	passwordLock.RUnlock()
+
+	if algo == Argon2id {
+		return hashArgon2(password, params)
+	}
 	return bcrypt.GenerateFromPassword(password, bcrypt.DefaultCost)
 }
 
-//hash校验
+// hash校验，按哈希格式识别bcrypt和argon2id
 func ValidatePasswordHash(password string, hashd string) bool {
+	if strings.HasPrefix(hashd, argon2Prefix) {
+		return compareArgon2(password, hashd)
+	}
 	if err := bcrypt.CompareHashAndPassword([]byte(hashd), []byte(password)); err != nil {
 		return false
 	}
 	return true
 }
+
+// NeedsRehash 哈希的算法或参数与当前配置不一致，登录校验成功后应使用明文重新生成
+func NeedsRehash(hashd string) bool {
+	passwordLock.RLock()
+	algo, params := passwordAlgo, passwordParams
+	passwordLock.RUnlock()
+
+	if !strings.HasPrefix(hashd, argon2Prefix) {
+		return algo != Bcrypt
+	}
+	if algo != Argon2id {
+		return true
+	}
+	p, _, _, err := parseArgon2(hashd)
+	return err != nil || p != params
+}
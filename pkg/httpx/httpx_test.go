@@ -0,0 +1,98 @@
+package httpx
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"aiOffice/pkg/pagex"
+	"aiOffice/pkg/xerr"
+
+	"github.com/gin-gonic/gin"
+)
+
+type testPeriod struct {
+	StartTime int64 `json:"startTime" binding:"timestamp"`
+	EndTime   int64 `json:"endTime" binding:"timestamp,gtefield=StartTime"`
+}
+
+type testReq struct {
+	Id     string      `uri:"id" binding:"required"`
+	Name   string      `json:"name" binding:"required,max=4"`
+	Status int         `json:"status" binding:"oneof=2 3"`
+	Period *testPeriod `json:"period"`
+}
+
+func bind(t *testing.T, body string) (*testReq, error) {
+	t.Helper()
+	w := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(w)
+	ctx.Request = httptest.NewRequest(http.MethodPost, "/v1/test/1", strings.NewReader(body))
+	ctx.Request.Header.Set("Content-Type", "application/json")
+	ctx.Params = gin.Params{{Key: "id", Value: "abc"}}
+
+	var req testReq
+	return &req, BindAndValidate(ctx, &req)
+}
+
+func TestBindAndValidate(t *testing.T) {
+	req, err := bind(t, `{"name":"张三","status":2,"period":{"startTime":1700000000,"endTime":1700003600}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if req.Id != "abc" || req.Name != "张三" {
+		t.Errorf("unexpected req %+v", req)
+	}
+}
+
+func TestBindAndValidateFields(t *testing.T) {
+	_, err := bind(t, `{"name":"","status":1,"period":{"startTime":1700000000000,"endTime":1}}`)
+	if xerr.CodeOf(err) != xerr.Invalid {
+		t.Fatalf("expected invalid, got %v", err)
+	}
+
+	rules := map[string]string{}
+	for _, f := range xerr.Fields(err) {
+		rules[f.Field] = f.Rule
+	}
+	want := map[string]string{
+		"name":             "required",
+		"status":           "oneof",
+		"period.startTime": "timestamp",
+		"period.endTime":   "timestamp",
+	}
+	for field, rule := range want {
+		if rules[field] != rule {
+			t.Errorf("%s: got rule %q, want %q (all: %v)", field, rules[field], rule, rules)
+		}
+	}
+	if err.Error() != "name不能为空" {
+		t.Errorf("unexpected message %q", err.Error())
+	}
+}
+
+func TestFailWithFields(t *testing.T) {
+	_, err := bind(t, `{"name":"toolong","status":2}`)
+	w := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(w)
+	ctx.Request = httptest.NewRequest(http.MethodPost, "/", nil)
+	FailWithErr(ctx, err)
+
+	if !strings.Contains(w.Body.String(), `"fields":[{"field":"name","rule":"max","param":"4"`) {
+		t.Errorf("fields missing in response: %s", w.Body.String())
+	}
+}
+
+func TestOkWithPage(t *testing.T) {
+	w := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(w)
+	ctx.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+	OkWithPage(ctx, []int{1, 2}, 5, pagex.FromRequest(2, 2, ""))
+
+	body := w.Body.String()
+	if !strings.Contains(body, `"data":{"count":5,"data":[1,2],"page":2,"pageSize":2,"hasMore":true}`) ||
+		!strings.Contains(body, `"time":`) {
+		t.Errorf("unexpected response %s", body)
+	}
+}
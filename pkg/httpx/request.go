@@ -1,15 +1,28 @@
 package httpx
 
-import "github.com/gin-gonic/gin"
+import (
+	"aiOffice/pkg/xerr"
 
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+)
+
+// BindAndValidate 绑定路径参数和请求参数，并按字段的 binding 标签校验；
+// 校验失败时返回 xerr.Invalid，并附带每个字段的失败原因
 func BindAndValidate(ctx *gin.Context, v any) error {
-	if err := ctx.ShouldBind(v); err != nil {
-		return err
+	// 先绑定路径参数，校验在绑定完请求参数后统一进行
+	if len(ctx.Params) > 0 {
+		params := make(map[string][]string, len(ctx.Params))
+		for _, p := range ctx.Params {
+			params[p.Key] = []string{p.Value}
+		}
+		if err := binding.MapFormWithTag(v, params, "uri"); err != nil {
+			return xerr.WithCode(err, xerr.Invalid)
+		}
 	}
 
-	if err := ctx.ShouldBindUri(v); err != nil {
-		return err
+	if err := ctx.ShouldBind(v); err != nil {
+		return validateError(ctx.Request.Context(), err)
 	}
-
 	return nil
 }
@@ -2,7 +2,14 @@ package httpx
 
 import (
 	"errors"
+	"math"
+	"strconv"
 	"sync"
+	"time"
+
+	"aiOffice/pkg/pagex"
+	"aiOffice/pkg/requestid"
+	"aiOffice/pkg/xerr"
 
 	"github.com/gin-gonic/gin"
 )
@@ -38,14 +45,33 @@ type Response struct {
 	Code int         `json:"code"`
 	Data interface{} `json:"data"`
 	Msg  string      `json:"msg"`
+
+	ErrCode   string            `json:"errCode,omitempty"`   // 失败时的错误码，见 xerr.Code
+	RequestId string            `json:"requestId,omitempty"` // 请求标识，便于按请求标识排查日志
+	Time      int64             `json:"time"`                // 服务器时间（毫秒），客户端可据此校准时间
+	Fields    []xerr.FieldError `json:"fields,omitempty"`    // 参数校验失败的字段
+}
+
+// PageData 页码分页列表的统一结构，count和data与各列表接口原有的字段保持一致
+type PageData struct {
+	Count    int64 `json:"count"`    // 总记录数
+	List     any   `json:"data"`     // 当前页的数据
+	Page     int   `json:"page"`     // 当前页码，从1开始
+	PageSize int   `json:"pageSize"` // 每页数量
+	HasMore  bool  `json:"hasMore"`  // 是否还有下一页
 }
 
 func Result(ctx *gin.Context, code int, data interface{}, msg string) {
-	ctx.JSON(200, &Response{
-		code,
-		data,
-		msg,
-	})
+	ctx.JSON(200, newResponse(ctx, code, data, msg))
+}
+
+// newResponse 填充请求标识和服务器时间
+func newResponse(ctx *gin.Context, code int, data any, msg string) *Response {
+	res := &Response{Code: code, Data: data, Msg: msg, Time: time.Now().UnixMilli()}
+	if ctx.Request != nil {
+		res.RequestId = requestid.FromContext(ctx.Request.Context())
+	}
+	return res
 }
 
 func Ok(ctx *gin.Context) {
@@ -62,6 +88,17 @@ func OkWithData(ctx *gin.Context, data interface{}) {
 	Result(ctx, SUCCESS, data, SUCCESSMSG)
 }
 
+// OkWithPage 返回页码分页的列表，page为查询使用的分页参数
+func OkWithPage(ctx *gin.Context, list any, total int64, page pagex.Page) {
+	OkWithData(ctx, &PageData{
+		Count:    total,
+		List:     list,
+		Page:     max(page.Page, 1),
+		PageSize: int(page.Limit()),
+		HasMore:  page.Skip()+page.Limit() < total,
+	})
+}
+
 func Fail(ctx *gin.Context) {
 	FailWithErr(ctx, ERRORMSG)
 }
@@ -71,9 +108,25 @@ func FailWithErr(ctx *gin.Context, err error) {
 	handler := errorHandler
 	errorLock.RUnlock()
 
+	// 限流等错误告知客户端多久后重试，至少1秒
+	if after, ok := xerr.RetryAfter(err); ok {
+		ctx.Header("Retry-After", strconv.Itoa(max(int(math.Ceil(after.Seconds())), 1)))
+	}
+
+	// 错误处理可能替换错误，先取出字段详情
+	fields := xerr.Fields(err)
+
 	code := ERROR
 	if handler != nil {
 		code, err = handler(ctx, err)
 	}
-	Result(ctx, code, NULL, err.Error())
+	res := newResponse(ctx, code, NULL, err.Error())
+	res.ErrCode = string(xerr.CodeOf(err))
+	res.Fields = fields
+	// code为合法的http错误状态时同时作为响应状态
+	status := code
+	if status < 400 || status > 599 {
+		status = 200
+	}
+	ctx.JSON(status, res)
 }
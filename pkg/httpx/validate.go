@@ -0,0 +1,112 @@
+package httpx
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"time"
+
+	"aiOffice/pkg/i18n"
+	"aiOffice/pkg/xerr"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+// 秒级时间戳的合理范围，超出时多半是传了毫秒或未初始化的值
+var (
+	minTimestamp = time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC).Unix()
+	maxTimestamp = time.Date(2100, 1, 1, 0, 0, 0, 0, time.UTC).Unix()
+)
+
+func init() {
+	v, ok := binding.Validator.Engine().(*validator.Validate)
+	if !ok {
+		return
+	}
+	// 错误中的字段名使用请求中的名称
+	v.RegisterTagNameFunc(fieldName)
+	v.RegisterValidation("timestamp", func(fl validator.FieldLevel) bool {
+		n := fl.Field().Int()
+		return n >= minTimestamp && n < maxTimestamp
+	})
+}
+
+// fieldName 按 json、form、uri 的顺序取字段在请求中的名称
+func fieldName(f reflect.StructField) string {
+	for _, tag := range []string{"json", "form", "uri"} {
+		name := strings.TrimSpace(strings.Split(f.Tag.Get(tag), ",")[0])
+		if name == "-" {
+			return ""
+		}
+		if name != "" {
+			return name
+		}
+	}
+	return f.Name
+}
+
+// validateError 将校验失败的字段转为带字段详情的错误，错误信息为第一个字段的原因
+func validateError(ctx context.Context, err error) error {
+	var ves validator.ValidationErrors
+	if !errors.As(err, &ves) || len(ves) == 0 {
+		return xerr.WithCode(err, xerr.Invalid)
+	}
+
+	fields := make([]xerr.FieldError, 0, len(ves))
+	for _, fe := range ves {
+		field := fe.Namespace()
+		// 去掉最外层的结构体名，如 Approval.leave.startTime -> leave.startTime
+		if i := strings.Index(field, "."); i >= 0 {
+			field = field[i+1:]
+		}
+		fields = append(fields, xerr.FieldError{
+			Field:   field,
+			Rule:    fe.Tag(),
+			Param:   fe.Param(),
+			Message: fieldMessage(ctx, field, fe),
+		})
+	}
+	return xerr.WithFields(xerr.NewCode(xerr.Invalid, fields[0].Message), fields)
+}
+
+func fieldMessage(ctx context.Context, field string, fe validator.FieldError) string {
+	kind := fe.Kind()
+	isLen := kind == reflect.String || kind == reflect.Slice || kind == reflect.Map || kind == reflect.Array
+	switch fe.Tag() {
+	case "required", "required_if", "required_with", "required_without":
+		return i18n.T(ctx, "%s不能为空", field)
+	case "min", "gte":
+		if kind == reflect.String {
+			return i18n.T(ctx, "%s长度不能少于%s", field, fe.Param())
+		}
+		if isLen {
+			return i18n.T(ctx, "%s至少需要%s项", field, fe.Param())
+		}
+		return i18n.T(ctx, "%s不能小于%s", field, fe.Param())
+	case "max", "lte":
+		if kind == reflect.String {
+			return i18n.T(ctx, "%s长度不能超过%s", field, fe.Param())
+		}
+		if isLen {
+			return i18n.T(ctx, "%s最多%s项", field, fe.Param())
+		}
+		return i18n.T(ctx, "%s不能大于%s", field, fe.Param())
+	case "oneof":
+		return i18n.T(ctx, "%s只能是 %s 之一", field, fe.Param())
+	case "timestamp":
+		return i18n.T(ctx, "%s不是有效的秒级时间戳", field)
+	case "gtefield", "gtfield":
+		// 参数为结构体字段名，转为请求中的小驼峰名称
+		param := fe.Param()
+		if param != "" {
+			param = strings.ToLower(param[:1]) + param[1:]
+		}
+		return i18n.T(ctx, "%s不能早于%s", field, param)
+	case "datetime":
+		return i18n.T(ctx, "%s格式应为%s", field, fe.Param())
+	default:
+		return i18n.T(ctx, "%s格式不正确", field)
+	}
+}
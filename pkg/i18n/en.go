@@ -0,0 +1,140 @@
+package i18n
+
+// en 英文译文，新增面向用户的中文文案时在此补充
+var en = map[string]string{
+	// 通用错误
+	"invalid objectId":  "invalid id",
+	"找不到该用户":            "User not found",
+	"找不到该部门":            "Department not found",
+	"待办事项不存在":           "Todo not found",
+	"该部门下还有子部门，无法删除":    "The department has sub-departments and cannot be deleted",
+	"审批已处理":             "The approval has already been processed",
+	"密码错误":              "Incorrect password",
+	"用户名已存在":            "Username already exists",
+	"原密码错误":             "The current password is incorrect",
+	"登录已失效，请重新登录":       "Session expired, please log in again",
+	"请选择要上传的文件":         "Please choose a file to upload",
+	"今日AI使用额度已用完，请明天再试": "You have used up today's AI quota, please try again tomorrow",
+	"AI请求过于频繁，请稍后再试":    "Too many AI requests, please try again later",
+	"请求过于频繁，请稍后再试":      "Too many requests, please try again later",
+	"未启用外部日历":           "External calendars are not enabled",
+	"未绑定该日历":            "This calendar is not linked",
+	"不支持的模型":            "Unsupported model",
+	"输入内容包含不当信息，请修改后重试": "Your input contains inappropriate content, please revise it and try again",
+	"回复内容未通过审核，请换个问法":   "The answer did not pass moderation, please rephrase your question",
+	"知识库文档不存在":          "Knowledge document not found",
+	"只有上传人或管理员可以删除该文档":  "Only the uploader or an administrator can delete this document",
+	"不是该部门成员，无权访问部门知识库": "You are not a member of this department and cannot access its knowledge base",
+	"文档正在处理中，请稍后再删除":    "The document is being processed, please delete it later",
+	"同名文档正在处理中，请稍后再上传":  "A document with the same name is being processed, please upload later",
+	"知识库中已有同名文档，只有上传人或管理员可以更新": "A document with the same name already exists; only the uploader or an administrator can update it",
+	"文档的原始文件不存在":               "The original file of the document no longer exists",
+	"不支持的推送平台":                 "Unsupported push platform",
+	"不支持的推送渠道":                 "Unsupported push channel",
+	"免打扰开始和结束时间需同时设置且不能相同":     "Quiet hours start and end must both be set and must differ",
+	"提醒时间不能在免打扰时段内":            "The reminder time cannot fall within quiet hours",
+	"只有管理员可以管理定时任务":            "Only administrators can manage scheduled tasks",
+	"需要管理员权限":                  "Administrator permission required",
+	"服务器内部错误":                  "Internal server error",
+	"定时任务不存在":                  "Scheduled task not found",
+	"未开启语音功能":                  "Speech is not enabled",
+	"不支持的音频格式":                 "Unsupported audio format",
+	"无效的分页游标":                  "Invalid pagination cursor",
+	"请指定会话":                    "Please specify a conversation",
+	"文件大小超过限制":                 "The file exceeds the size limit",
+	"不支持的文件类型":                 "Unsupported file type",
+	"文件内容与允许的类型不符":             "The file content does not match an allowed type",
+	"存储空间不足":                   "Storage quota exceeded",
+	"文件未通过安全扫描":                "The file failed the security scan",
+	"不支持的图片格式":                 "Unsupported image format",
+	"图片尺寸过大":                   "The image dimensions are too large",
+	"无权查看该会话":                  "You are not allowed to view this conversation",
+
+	// 提醒和通知
+	"待办提醒":                  "Todo reminder",
+	"审批提醒":                  "Approval reminder",
+	"今日工作总结":                "Today's work summary",
+	"知识库入库失败":               "Knowledge import failed",
+	"知识库入库完成":               "Knowledge import completed",
+	"文档 %s 入库失败: %v":        "Failed to import document %s: %v",
+	"文档 %s 已加入知识库":          "Document %s has been added to the knowledge base",
+	"您有 %d 条新通知":            "You have %d new notifications",
+	"您有 %d 个待办今天到期：":        "You have %d todos due today:",
+	"您有 %d 个审批待处理（超过24小时）：": "You have %d approvals pending for over 24 hours:",
+	"... 还有 %d 个":           "... and %d more",
+	"... 还有 %d 条":           "... and %d more",
+
+	// 工具输出
+	"待确认的%s:\n%s\n\n请向用户确认以上信息，用户确认后以confirm=true调用%s；用户要修改时只传入修改的字段。": "%s to confirm:\n%s\n\nAsk the user to confirm the above. After confirmation call %s with confirm=true; if the user wants changes, pass only the changed fields.",
+	"未设置":         "Not set",
+	"待办":          "Todo",
+	"内容: ":        "Title: ",
+	"截止时间: ":      "Deadline: ",
+	"、":           ", ",
+	"执行人: ":       "Assignees: ",
+	"描述: ":        "Description: ",
+	"您当前没有待办事项。":  "You have no todos.",
+	"您的待办事项:":     "Your todos:",
+	"状态: ":        "Status: ",
+	"未完成":         "Open",
+	"已完成":         "Done",
+	"未知状态":        "Unknown",
+	"类型: ":        "Type: ",
+	"请假类型: ":      "Leave type: ",
+	"开始时间: ":      "Start: ",
+	"结束时间: ":      "End: ",
+	"补卡日期: ":      "Date: ",
+	"补卡类型: ":      "Check type: ",
+	"理由: ":        "Reason: ",
+	"当天考勤: ":      "Attendance: ",
+	"上班 ":         "Check-in ",
+	"下班 ":         "Check-out ",
+	"，":           ", ",
+	"缺卡":          "missing",
+	"未打卡":         "not yet",
+	"（补卡）":        " (corrected)",
+	"（迟到）":        " (late)",
+	"（早退）":        " (left early)",
+	"缺卡可以发起补卡审批。": "Missing checks can be fixed with an attendance correction request.",
+	"上班卡":         "Check-in",
+	"下班卡":         "Check-out",
+	"请假审批已创建成功！\n理由: %s": "Leave request created!\nReason: %s",
+	"补卡审批已创建成功！\n理由: %s": "Attendance correction request created!\nReason: %s",
+	"外出审批已创建成功！\n理由: %s": "Business trip request created!\nReason: %s",
+	"审批已创建成功！":           "Approval created!",
+	"通用审批":               "General approval",
+	"请假审批":               "Leave approval",
+	"补卡审批":               "Attendance correction",
+	"外出审批":               "Business trip approval",
+	"报销审批":               "Expense approval",
+	"付款审批":               "Payment approval",
+	"采购审批":               "Purchase approval",
+	"收款审批":               "Receipt approval",
+	"转正审批":               "Probation approval",
+	"离职审批":               "Resignation approval",
+	"加班审批":               "Overtime approval",
+	"合同审批":               "Contract approval",
+	"未知类型":               "Unknown type",
+	"事假":                 "Personal leave",
+	"调休":                 "Compensatory leave",
+	"病假":                 "Sick leave",
+	"年假":                 "Annual leave",
+	"产假":                 "Maternity leave",
+	"陪产假":                "Paternity leave",
+	"婚假":                 "Marriage leave",
+	"丧假":                 "Bereavement leave",
+	"哺乳假":                "Nursing leave",
+	"请假":                 "Leave",
+	"%s不能为空":             "%s is required",
+	"%s长度不能少于%s":         "%s must be at least %s characters",
+	"%s至少需要%s项":          "%s must contain at least %s items",
+	"%s不能小于%s":           "%s must not be less than %s",
+	"%s长度不能超过%s":         "%s must be at most %s characters",
+	"%s最多%s项":            "%s must contain at most %s items",
+	"%s不能大于%s":           "%s must not be greater than %s",
+	"%s只能是 %s 之一":        "%s must be one of %s",
+	"%s不是有效的秒级时间戳":       "%s is not a valid unix timestamp in seconds",
+	"%s不能早于%s":           "%s must not be earlier than %s",
+	"%s格式应为%s":           "%s must be in format %s",
+	"%s格式不正确":            "%s is invalid",
+}
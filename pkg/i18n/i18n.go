@@ -0,0 +1,113 @@
+package i18n
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Lang 语言，文案以简体中文编写，其他语言在目录中按中文原文查找译文
+type Lang string
+
+const (
+	ZhCN Lang = "zh-CN"
+	En   Lang = "en"
+)
+
+// catalogs 各语言的译文，key为中文原文（含格式化占位符）
+var catalogs = map[Lang]map[string]string{
+	En: en,
+}
+
+var defaultLang = ZhCN
+
+// SetDefault 设置默认语言，用于没有请求语言的场景（定时提醒、异步任务等），启动时调用
+func SetDefault(lang Lang) {
+	if Supported(lang) {
+		defaultLang = lang
+	}
+}
+
+// Default 默认语言
+func Default() Lang {
+	return defaultLang
+}
+
+// Supported 是否支持该语言
+func Supported(lang Lang) bool {
+	_, ok := catalogs[lang]
+	return lang == ZhCN || ok
+}
+
+// Parse 按 Accept-Language 的权重选择支持的语言，都不支持时返回默认语言
+func Parse(header string) Lang {
+	type candidate struct {
+		lang Lang
+		q    float64
+	}
+	var list []candidate
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if f, err := strconv.ParseFloat(v, 64); err == nil {
+				q = f
+			}
+		}
+		if lang, ok := match(tag); ok && q > 0 {
+			list = append(list, candidate{lang: lang, q: q})
+		}
+	}
+	if len(list) == 0 {
+		return defaultLang
+	}
+	sort.SliceStable(list, func(i, j int) bool { return list[i].q > list[j].q })
+	return list[0].lang
+}
+
+// match 将语言标签匹配到支持的语言，如 en-US -> en，zh、zh-Hans -> zh-CN
+func match(tag string) (Lang, bool) {
+	primary, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(tag)), "-")
+	switch primary {
+	case "zh":
+		return ZhCN, true
+	case "en":
+		return En, true
+	}
+	return "", false
+}
+
+type langKey struct{}
+
+// WithLang 将语言写入ctx
+func WithLang(ctx context.Context, lang Lang) context.Context {
+	return context.WithValue(ctx, langKey{}, lang)
+}
+
+// FromContext ctx中的语言，没有时返回默认语言
+func FromContext(ctx context.Context) Lang {
+	if ctx != nil {
+		if lang, ok := ctx.Value(langKey{}).(Lang); ok {
+			return lang
+		}
+	}
+	return defaultLang
+}
+
+// T 按ctx中的语言翻译文案，有参数时按格式化占位符填充；没有译文时使用原文
+func T(ctx context.Context, msg string, args ...any) string {
+	return Translate(FromContext(ctx), msg, args...)
+}
+
+// Translate 翻译为指定语言，见 T
+func Translate(lang Lang, msg string, args ...any) string {
+	if v, ok := catalogs[lang][msg]; ok {
+		msg = v
+	}
+	if len(args) == 0 {
+		return msg
+	}
+	return fmt.Sprintf(msg, args...)
+}
@@ -0,0 +1,70 @@
+package i18n
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	cases := map[string]Lang{
+		"":                           ZhCN,
+		"en-US,en;q=0.9":             En,
+		"zh-CN,zh;q=0.9,en;q=0.8":    ZhCN,
+		"fr-FR,en;q=0.5,zh;q=0.4":    En,
+		"zh-Hans;q=0.3, EN-GB;q=0.7": En,
+		"fr, de":                     ZhCN,
+		"en;q=0, zh":                 ZhCN,
+	}
+	for header, want := range cases {
+		if got := Parse(header); got != want {
+			t.Errorf("Parse(%q) = %s, want %s", header, got, want)
+		}
+	}
+}
+
+func TestT(t *testing.T) {
+	ctx := context.Background()
+	if got := T(ctx, "您有 %d 条新通知", 3); got != "您有 3 条新通知" {
+		t.Fatalf("default zh: %q", got)
+	}
+
+	ctx = WithLang(ctx, En)
+	if got := T(ctx, "您有 %d 条新通知", 3); got != "You have 3 new notifications" {
+		t.Fatalf("en: %q", got)
+	}
+	if got := T(ctx, "没有译文的文案"); got != "没有译文的文案" {
+		t.Fatalf("fallback: %q", got)
+	}
+	// 没有参数时不做格式化
+	if got := T(ctx, "100%完成"); got != "100%完成" {
+		t.Fatalf("no args: %q", got)
+	}
+
+	SetDefault(En)
+	defer SetDefault(ZhCN)
+	if got := T(context.Background(), "待办提醒"); got != "Todo reminder" {
+		t.Fatalf("default en: %q", got)
+	}
+}
+
+// 译文的占位符需与原文一致
+func TestCatalogVerbs(t *testing.T) {
+	verbs := func(s string) string {
+		var b strings.Builder
+		for i := 0; i < len(s)-1; i++ {
+			if s[i] == '%' {
+				b.WriteByte(s[i+1])
+				i++
+			}
+		}
+		return b.String()
+	}
+	for lang, catalog := range catalogs {
+		for src, dst := range catalog {
+			if verbs(src) != verbs(dst) {
+				t.Errorf("%s: %q and %q have different verbs", lang, src, dst)
+			}
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package imagex
+
+import (
+	"bytes"
+	"image"
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+
+	"aiOffice/pkg/xerr"
+)
+
+// MaxPixels 解码的像素上限，防止超大尺寸图片耗尽内存
+const MaxPixels = 40_000_000
+
+var (
+	ErrUnsupported = xerr.NewCode(xerr.Invalid, "不支持的图片格式")
+	ErrTooLarge    = xerr.NewCode(xerr.Invalid, "图片尺寸过大")
+)
+
+// Result 处理后的图片
+type Result struct {
+	Data     []byte // 去除元数据后的原图，按EXIF方向摆正
+	Thumb    []byte // 缩略图，未生成时为nil
+	ThumbExt string // 缩略图扩展名 .jpg/.png
+	Width    int    // 摆正后的原图尺寸
+	Height   int
+}
+
+// IsImage 是否为支持处理的图片类型
+func IsImage(contentType string) bool {
+	switch contentType {
+	case "image/jpeg", "image/png", "image/gif":
+		return true
+	}
+	return false
+}
+
+// Process 去除EXIF等元数据，并生成最长边不超过thumbSize的缩略图，thumbSize为0时不生成
+func Process(data []byte, thumbSize int) (*Result, error) {
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrUnsupported
+	}
+	if cfg.Width*cfg.Height > MaxPixels {
+		return nil, ErrTooLarge
+	}
+
+	res := &Result{Data: data, Width: cfg.Width, Height: cfg.Height}
+	orientation := 1
+	switch format {
+	case "jpeg":
+		orientation = jpegOrientation(data)
+		if res.Data, err = stripJPEG(data); err != nil {
+			return nil, err
+		}
+	case "png":
+		if res.Data, err = stripPNG(data); err != nil {
+			return nil, err
+		}
+	case "gif":
+		// gif没有EXIF，保留动画
+	default:
+		return nil, ErrUnsupported
+	}
+
+	if thumbSize <= 0 && orientation == 1 {
+		return res, nil
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrUnsupported
+	}
+
+	// 去掉EXIF后方向信息丢失，需要把像素摆正后重新编码
+	if orientation != 1 {
+		img = orient(img, orientation)
+		b := img.Bounds()
+		res.Width, res.Height = b.Dx(), b.Dy()
+		var buf bytes.Buffer
+		if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: 90}); err != nil {
+			return nil, err
+		}
+		res.Data = buf.Bytes()
+	}
+
+	if thumbSize > 0 {
+		var buf bytes.Buffer
+		thumb := Fit(img, thumbSize)
+		res.ThumbExt = ".jpg"
+		if format == "jpeg" {
+			err = jpeg.Encode(&buf, thumb, &jpeg.Options{Quality: 85})
+		} else {
+			// png和gif可能有透明通道
+			res.ThumbExt = ".png"
+			err = png.Encode(&buf, thumb)
+		}
+		if err != nil {
+			return nil, err
+		}
+		res.Thumb = buf.Bytes()
+	}
+	return res, nil
+}
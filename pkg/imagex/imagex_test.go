@@ -0,0 +1,111 @@
+package imagex
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+func newImage(w, h int) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for i := range img.Pix {
+		img.Pix[i] = 200
+	}
+	return img
+}
+
+// withExif 在SOI之后插入带方向标签的APP1段
+func withExif(data []byte, orientation uint16) []byte {
+	tiff := []byte("MM\x00\x2a\x00\x00\x00\x08\x00\x01\x01\x12\x00\x03\x00\x00\x00\x01\x00\x00\x00\x00\x00\x00\x00\x00")
+	binary.BigEndian.PutUint16(tiff[18:], orientation)
+	seg := append([]byte("Exif\x00\x00"), tiff...)
+
+	out := []byte{0xFF, 0xD8, 0xFF, 0xE1, 0, 0}
+	binary.BigEndian.PutUint16(out[4:], uint16(len(seg)+2))
+	out = append(out, seg...)
+	return append(out, data[2:]...)
+}
+
+func TestProcessJPEG(t *testing.T) {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, newImage(400, 200), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	data := withExif(buf.Bytes(), 6)
+	if jpegOrientation(data) != 6 {
+		t.Fatal("orientation not parsed")
+	}
+
+	res, err := Process(data, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(res.Data, []byte("Exif")) {
+		t.Error("exif not stripped")
+	}
+	if res.Width != 200 || res.Height != 400 {
+		t.Errorf("not rotated: %dx%d", res.Width, res.Height)
+	}
+	thumb, err := jpeg.Decode(bytes.NewReader(res.Thumb))
+	if err != nil || res.ThumbExt != ".jpg" {
+		t.Fatal(err, res.ThumbExt)
+	}
+	if b := thumb.Bounds(); b.Dx() != 50 || b.Dy() != 100 {
+		t.Errorf("thumb size %v", b)
+	}
+
+	// 没有方向信息时只去掉元数据，不重新编码
+	plain, err := Process(withExif(buf.Bytes(), 1), 0)
+	if err != nil || !bytes.Equal(plain.Data, buf.Bytes()) || plain.Thumb != nil {
+		t.Errorf("lossless strip failed: %v", err)
+	}
+}
+
+func TestProcessPNG(t *testing.T) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, newImage(10, 10)); err != nil {
+		t.Fatal(err)
+	}
+	// 在IHDR之后插入tEXt块
+	text := []byte("tEXtAuthor\x00someone")
+	chunk := binary.BigEndian.AppendUint32(nil, uint32(len(text)-4))
+	chunk = append(chunk, text...)
+	chunk = binary.BigEndian.AppendUint32(chunk, crc32.ChecksumIEEE(text))
+	data := append(append(append([]byte{}, buf.Bytes()[:33]...), chunk...), buf.Bytes()[33:]...)
+
+	res, err := Process(data, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(res.Data, buf.Bytes()) {
+		t.Error("tEXt not stripped")
+	}
+	thumb, err := png.Decode(bytes.NewReader(res.Thumb))
+	if err != nil || thumb.Bounds().Dx() != 4 || res.ThumbExt != ".png" {
+		t.Fatalf("thumb: %v %v", err, thumb.Bounds())
+	}
+	if c := color.NRGBAModel.Convert(thumb.At(1, 1)).(color.NRGBA); c.R != 200 || c.A != 200 {
+		t.Errorf("thumb color %v", c)
+	}
+
+	if _, err := Process([]byte("not an image"), 4); err != ErrUnsupported {
+		t.Errorf("got %v", err)
+	}
+}
+
+func TestOrient(t *testing.T) {
+	src := image.NewNRGBA(image.Rect(0, 0, 2, 1))
+	src.Pix[3] = 255 // 左上角像素不透明
+	for o, want := range map[int]image.Point{2: {1, 0}, 3: {1, 0}, 6: {0, 0}, 8: {0, 1}} {
+		dst := orient(src, o).(*image.NRGBA)
+		if dst.NRGBAAt(want.X, want.Y).A != 255 {
+			t.Errorf("orientation %d: pixel not at %v", o, want)
+		}
+	}
+}
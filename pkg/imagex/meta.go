@@ -0,0 +1,128 @@
+package imagex
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+)
+
+var errCorrupt = errors.New("图片数据损坏")
+
+// stripJPEG 去掉EXIF/XMP(APP1)、IPTC(APP13)等元数据段和注释，保留JFIF和ICC色彩配置，图像数据不重新编码
+func stripJPEG(data []byte) ([]byte, error) {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return nil, errCorrupt
+	}
+	out := make([]byte, 0, len(data))
+	out = append(out, 0xFF, 0xD8)
+
+	for i := 2; ; {
+		// 段之间允许填充的0xFF
+		for i < len(data) && data[i] == 0xFF && i+1 < len(data) && data[i+1] == 0xFF {
+			i++
+		}
+		if i+4 > len(data) || data[i] != 0xFF {
+			return nil, errCorrupt
+		}
+		marker := data[i+1]
+		// SOS之后为图像数据，原样保留
+		if marker == 0xDA {
+			return append(out, data[i:]...), nil
+		}
+		size := int(binary.BigEndian.Uint16(data[i+2:]))
+		end := i + 2 + size
+		if size < 2 || end > len(data) {
+			return nil, errCorrupt
+		}
+		drop := marker == 0xFE || // COM
+			(marker >= 0xE1 && marker <= 0xEF && marker != 0xE2) // APP1-APP15，保留APP2(ICC)
+		if !drop {
+			out = append(out, data[i:end]...)
+		}
+		i = end
+	}
+}
+
+// jpegOrientation 读取EXIF中的方向，没有时返回1
+func jpegOrientation(data []byte) int {
+	for i := 2; i+4 <= len(data) && data[i] == 0xFF; {
+		marker := data[i+1]
+		if marker == 0xDA {
+			break
+		}
+		size := int(binary.BigEndian.Uint16(data[i+2:]))
+		end := i + 2 + size
+		if size < 2 || end > len(data) {
+			break
+		}
+		if seg := data[i+4 : end]; marker == 0xE1 && bytes.HasPrefix(seg, []byte("Exif\x00\x00")) {
+			return exifOrientation(seg[6:])
+		}
+		i = end
+	}
+	return 1
+}
+
+// exifOrientation 在TIFF结构的IFD0中查找方向标签0x0112
+func exifOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 1
+	}
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 1
+	}
+	ifd := int(order.Uint32(tiff[4:]))
+	if ifd+2 > len(tiff) {
+		return 1
+	}
+	n := int(order.Uint16(tiff[ifd:]))
+	for k := 0; k < n; k++ {
+		e := ifd + 2 + k*12
+		if e+12 > len(tiff) {
+			break
+		}
+		if order.Uint16(tiff[e:]) == 0x0112 {
+			if v := int(order.Uint16(tiff[e+8:])); v >= 1 && v <= 8 {
+				return v
+			}
+			break
+		}
+	}
+	return 1
+}
+
+// pngSignature png文件头
+var pngSignature = []byte("\x89PNG\r\n\x1a\n")
+
+// stripPNG 去掉EXIF、文本和时间等辅助块
+func stripPNG(data []byte) ([]byte, error) {
+	if !bytes.HasPrefix(data, pngSignature) {
+		return nil, errCorrupt
+	}
+	out := make([]byte, 0, len(data))
+	out = append(out, pngSignature...)
+
+	for i := len(pngSignature); i < len(data); {
+		if i+12 > len(data) {
+			return nil, errCorrupt
+		}
+		size := int(binary.BigEndian.Uint32(data[i:]))
+		end := i + 12 + size
+		if size < 0 || end > len(data) {
+			return nil, errCorrupt
+		}
+		switch string(data[i+4 : i+8]) {
+		case "eXIf", "tEXt", "zTXt", "iTXt", "tIME":
+		default:
+			out = append(out, data[i:end]...)
+		}
+		i = end
+	}
+	return out, nil
+}
@@ -0,0 +1,100 @@
+package imagex
+
+import (
+	"image"
+	"image/draw"
+)
+
+// toNRGBA 转换为NRGBA，坐标从(0,0)开始
+func toNRGBA(img image.Image) *image.NRGBA {
+	b := img.Bounds()
+	dst := image.NewNRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(dst, dst.Bounds(), img, b.Min, draw.Src)
+	return dst
+}
+
+// Fit 等比缩小到最长边不超过size，原图不超过时只转换格式
+func Fit(img image.Image, size int) *image.NRGBA {
+	src := toNRGBA(img)
+	w, h := src.Rect.Dx(), src.Rect.Dy()
+	if w <= size && h <= size {
+		return src
+	}
+
+	dw, dh := size, h*size/w
+	if h > w {
+		dw, dh = w*size/h, size
+	}
+	return boxResize(src, max(dw, 1), max(dh, 1))
+}
+
+// boxResize 区域平均缩小，每个目标像素取对应源区域内像素的平均值
+func boxResize(src *image.NRGBA, dw, dh int) *image.NRGBA {
+	sw, sh := src.Rect.Dx(), src.Rect.Dy()
+	dst := image.NewNRGBA(image.Rect(0, 0, dw, dh))
+
+	for y := 0; y < dh; y++ {
+		y0, y1 := y*sh/dh, max((y+1)*sh/dh, y*sh/dh+1)
+		for x := 0; x < dw; x++ {
+			x0, x1 := x*sw/dw, max((x+1)*sw/dw, x*sw/dw+1)
+
+			// 按alpha加权，避免透明像素的颜色渗到边缘
+			var r, g, b, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				p := src.Pix[sy*src.Stride+x0*4 : sy*src.Stride+x1*4]
+				for k := 0; k < len(p); k += 4 {
+					pa := uint64(p[k+3])
+					r += uint64(p[k]) * pa
+					g += uint64(p[k+1]) * pa
+					b += uint64(p[k+2]) * pa
+					a += pa
+					n++
+				}
+			}
+			d := dst.Pix[y*dst.Stride+x*4:]
+			if a > 0 {
+				d[0], d[1], d[2] = uint8(r/a), uint8(g/a), uint8(b/a)
+			}
+			d[3] = uint8(a / n)
+		}
+	}
+	return dst
+}
+
+// orient 按EXIF方向(1-8)旋转/翻转为正常方向
+func orient(img image.Image, orientation int) image.Image {
+	if orientation < 2 || orientation > 8 {
+		return img
+	}
+	src := toNRGBA(img)
+	w, h := src.Rect.Dx(), src.Rect.Dy()
+
+	dw, dh := w, h
+	if orientation >= 5 {
+		dw, dh = h, w
+	}
+	dst := image.NewNRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			var dx, dy int
+			switch orientation {
+			case 2: // 水平翻转
+				dx, dy = w-1-x, y
+			case 3: // 旋转180度
+				dx, dy = w-1-x, h-1-y
+			case 4: // 垂直翻转
+				dx, dy = x, h-1-y
+			case 5: // 沿主对角线翻转
+				dx, dy = y, x
+			case 6: // 顺时针90度
+				dx, dy = h-1-y, x
+			case 7: // 沿副对角线翻转
+				dx, dy = h-1-y, w-1-x
+			case 8: // 逆时针90度
+				dx, dy = y, w-1-x
+			}
+			copy(dst.Pix[dy*dst.Stride+dx*4:dy*dst.Stride+dx*4+4], src.Pix[y*src.Stride+x*4:y*src.Stride+x*4+4])
+		}
+	}
+	return dst
+}
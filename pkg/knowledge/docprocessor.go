@@ -17,6 +17,9 @@ import (
 type DocProcessor struct {
 	ChunkSize    int
 	ChunkOverlap int
+
+	semantic      *SemanticSplitter
+	semanticTypes map[string]bool
 }
 
 // NewDocProcessor 创建文档处理器
@@ -33,8 +36,23 @@ func NewDocProcessor(chunkSize, chunkOverlap int) *DocProcessor {
 	}
 }
 
+// WithSemantic 指定的文件类型（如 .docx .txt）使用语义分块代替固定长度分块，Excel按行分块不受影响
+func (p *DocProcessor) WithSemantic(splitter *SemanticSplitter, exts ...string) *DocProcessor {
+	p.semantic = splitter
+	p.semanticTypes = make(map[string]bool, len(exts))
+	for _, ext := range exts {
+		p.semanticTypes[strings.ToLower(ext)] = true
+	}
+	return p
+}
+
 // Process 处理文档，根据文件类型选择不同的解析和分块策略
 func (p *DocProcessor) Process(filePath string) ([]schema.Document, error) {
+	return p.ProcessContext(context.Background(), filePath)
+}
+
+// ProcessContext 同Process，语义分块需要调用向量化接口
+func (p *DocProcessor) ProcessContext(ctx context.Context, filePath string) ([]schema.Document, error) {
 	// 检查文件是否存在
 	if _, err := os.Stat(filePath); os.IsNotExist(err) {
 		return nil, fmt.Errorf("文件不存在: %s", filePath)
@@ -44,6 +62,13 @@ func (p *DocProcessor) Process(filePath string) ([]schema.Document, error) {
 	var text string
 	var err error
 
+	if p.semantic != nil && p.semanticTypes[ext] && ext != ".xlsx" {
+		if text, err = p.Extract(filePath); err != nil {
+			return nil, err
+		}
+		return p.splitSemantic(ctx, text, filePath)
+	}
+
 	switch ext {
 	case ".md", ".markdown":
 		text, err = p.extractMarkdown(filePath)
@@ -69,11 +94,33 @@ func (p *DocProcessor) Process(filePath string) ([]schema.Document, error) {
 		}
 		return p.splitRecursive(text, filePath)
 
+	case ".xlsx":
+		return p.splitExcel(filePath)
+
+	case ".xls":
+		return nil, fmt.Errorf("旧版Excel格式暂不支持，请另存为 .xlsx 后再上传")
+
 	default:
 		return nil, fmt.Errorf("不支持的文件格式: %s", ext)
 	}
 }
 
+// Extract 提取文档的全部文本（不分块），用于预览；Excel按工作表逐行展开
+func (p *DocProcessor) Extract(filePath string) (string, error) {
+	switch strings.ToLower(filepath.Ext(filePath)) {
+	case ".md", ".markdown":
+		return p.extractMarkdown(filePath)
+	case ".docx":
+		return p.extractWord(filePath)
+	case ".txt":
+		return p.extractText(filePath)
+	case ".xlsx":
+		return p.extractExcel(filePath)
+	default:
+		return "", fmt.Errorf("不支持的文件格式: %s", filepath.Ext(filePath))
+	}
+}
+
 // extractMarkdown 读取 Markdown 文件
 func (p *DocProcessor) extractMarkdown(filePath string) (string, error) {
 	content, err := os.ReadFile(filePath)
@@ -153,6 +200,16 @@ func (p *DocProcessor) splitRecursive(text, filePath string) ([]schema.Document,
 	return p.chunksToDocuments(chunks, filePath, "recursive"), nil
 }
 
+// splitSemantic 使用语义分块器，按话题转换切分
+func (p *DocProcessor) splitSemantic(ctx context.Context, text, filePath string) ([]schema.Document, error) {
+	chunks, err := p.semantic.Split(ctx, text, p.ChunkSize)
+	if err != nil {
+		return nil, fmt.Errorf("语义分块失败: %v", err)
+	}
+
+	return p.chunksToDocuments(chunks, filePath, "semantic"), nil
+}
+
 // chunksToDocuments 将文本块转换为 LangChain 文档格式
 func (p *DocProcessor) chunksToDocuments(chunks []string, filePath, splitType string) []schema.Document {
 	docs := make([]schema.Document, 0, len(chunks))
@@ -207,7 +264,7 @@ func (p *DocProcessor) cleanText(text string) string {
 
 // SupportedFormats 返回支持的文件格式
 func SupportedFormats() []string {
-	return []string{".md", ".markdown", ".docx", ".txt"}
+	return []string{".md", ".markdown", ".docx", ".txt", ".xlsx"}
 }
 
 // IsSupportedFormat 检查文件格式是否支持
@@ -221,15 +278,38 @@ func IsSupportedFormat(filePath string) bool {
 	return false
 }
 
+// IndexName 公共知识库的向量索引名称
+const IndexName = "knowledge"
+
+// DepartmentIndex 部门知识库的向量索引名称。
+// 向量块的key前缀为 doc:{索引名}，RediSearch按字符串前缀匹配，
+// 部门索引不能以 knowledge 开头，否则会被公共索引收录
+func DepartmentIndex(depId string) string {
+	if depId == "" {
+		return IndexName
+	}
+	return "dept_" + depId
+}
+
 // VectorStore 向量存储接口
 type VectorStore interface {
 	AddDocuments(ctx context.Context, docs []schema.Document, options ...vectorstores.Option) ([]string, error)
 }
 
-// AddToVectorStore 将文档添加到向量存储（分批处理）
-func AddToVectorStore(ctx context.Context, store VectorStore, docs []schema.Document) error {
+// AddToVectorStore 将文档添加到向量存储（分批处理），返回已写入的文档块id；
+// 中途失败时同样返回已写入的部分，便于调用方清理
+func AddToVectorStore(ctx context.Context, store VectorStore, docs []schema.Document) ([]string, error) {
+	// 写入分词结果，供混合检索的关键词部分使用
+	for i := range docs {
+		if docs[i].Metadata == nil {
+			docs[i].Metadata = make(map[string]any)
+		}
+		docs[i].Metadata[TermsField] = strings.Join(Terms(docs[i].PageContent), " ")
+	}
+
 	// 分批添加文档（阿里云 DashScope 限制每批最多 10 个）
 	batchSize := 10
+	ids := make([]string, 0, len(docs))
 	for i := 0; i < len(docs); i += batchSize {
 		end := i + batchSize
 		if end > len(docs) {
@@ -237,11 +317,12 @@ func AddToVectorStore(ctx context.Context, store VectorStore, docs []schema.Docu
 		}
 		batch := docs[i:end]
 
-		_, err := store.AddDocuments(ctx, batch)
+		batchIds, err := store.AddDocuments(ctx, batch)
+		ids = append(ids, batchIds...)
 		if err != nil {
-			return fmt.Errorf("添加文档失败(批次 %d): %v", i/batchSize+1, err)
+			return ids, fmt.Errorf("添加文档失败(批次 %d): %v", i/batchSize+1, err)
 		}
 		fmt.Printf("[Knowledge] 已添加第 %d 批，共 %d 个文档块\n", i/batchSize+1, len(batch))
 	}
-	return nil
+	return ids, nil
 }
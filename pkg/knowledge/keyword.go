@@ -0,0 +1,147 @@
+package knowledge
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/tmc/langchaingo/schema"
+)
+
+// TermsField 分词结果的metadata字段，写入时生成，供关键词检索
+const TermsField = "terms"
+
+// maxQueryTerms 关键词检索最多使用的词数
+const maxQueryTerms = 32
+
+// Terms 将文本切分为检索词：中文按相邻两字切分（不依赖词典），英文和数字按单词切分并转小写，
+// RediSearch默认分词器不支持中文，入库和检索都使用这种方式保证一致
+func Terms(text string) []string {
+	var (
+		terms []string
+		word  []rune
+		han   []rune
+	)
+	flushWord := func() {
+		if len(word) > 0 {
+			terms = append(terms, strings.ToLower(string(word)))
+			word = word[:0]
+		}
+	}
+	flushHan := func() {
+		switch {
+		case len(han) == 1:
+			terms = append(terms, string(han))
+		case len(han) > 1:
+			for i := 0; i+1 < len(han); i++ {
+				terms = append(terms, string(han[i:i+2]))
+			}
+		}
+		han = han[:0]
+	}
+
+	for _, r := range text {
+		switch {
+		case unicode.Is(unicode.Han, r):
+			flushWord()
+			han = append(han, r)
+		case r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)):
+			flushHan()
+			word = append(word, r)
+		default:
+			flushWord()
+			flushHan()
+		}
+	}
+	flushWord()
+	flushHan()
+	return terms
+}
+
+// KeywordSearcher 基于RediSearch BM25的关键词检索，与向量检索使用同一个索引
+type KeywordSearcher struct {
+	client redis.UniversalClient
+}
+
+// NewKeywordSearcher url与向量存储的连接地址相同；固定使用RESP2，便于解析FT.SEARCH的返回
+func NewKeywordSearcher(url string) (*KeywordSearcher, error) {
+	opt, err := redis.ParseURL(url)
+	if err != nil {
+		return nil, err
+	}
+	opt.Protocol = 2
+	return &KeywordSearcher{client: redis.NewClient(opt)}, nil
+}
+
+// Search 按BM25得分返回最相关的k个文档块，Score为BM25得分（越大越相关）
+func (s *KeywordSearcher) Search(ctx context.Context, index, query string, k int) ([]schema.Document, error) {
+	q := keywordQuery(query)
+	if q == "" {
+		return nil, nil
+	}
+
+	res, err := s.client.Do(ctx, "FT.SEARCH", index, q,
+		"SCORER", "BM25", "WITHSCORES",
+		"RETURN", 3, "content", "source", "filename",
+		"LIMIT", 0, k,
+		"DIALECT", 2,
+	).Result()
+	if err != nil {
+		return nil, err
+	}
+	return parseSearchReply(res)
+}
+
+// keywordQuery 检索词去重后以“或”连接
+func keywordQuery(query string) string {
+	seen := make(map[string]bool)
+	var terms []string
+	for _, t := range Terms(query) {
+		if seen[t] {
+			continue
+		}
+		seen[t] = true
+		terms = append(terms, t)
+		if len(terms) == maxQueryTerms {
+			break
+		}
+	}
+	if len(terms) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("@%s:(%s)", TermsField, strings.Join(terms, "|"))
+}
+
+// parseSearchReply 解析 [总数, key, 得分, [字段, 值...], ...]
+func parseSearchReply(res any) ([]schema.Document, error) {
+	list, ok := res.([]any)
+	if !ok || len(list) == 0 {
+		return nil, fmt.Errorf("unexpected FT.SEARCH reply: %T", res)
+	}
+
+	docs := make([]schema.Document, 0, (len(list)-1)/3)
+	for i := 1; i+2 < len(list); i += 3 {
+		key, _ := list[i].(string)
+		score, _ := strconv.ParseFloat(fmt.Sprint(list[i+1]), 32)
+		fields, _ := list[i+2].([]any)
+
+		doc := schema.Document{
+			Score:    float32(score),
+			Metadata: map[string]any{"id": key},
+		}
+		for j := 0; j+1 < len(fields); j += 2 {
+			name, _ := fields[j].(string)
+			value, _ := fields[j+1].(string)
+			if name == "content" {
+				doc.PageContent = value
+			} else {
+				doc.Metadata[name] = value
+			}
+		}
+		docs = append(docs, doc)
+	}
+	return docs, nil
+}
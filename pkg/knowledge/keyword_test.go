@@ -0,0 +1,77 @@
+package knowledge
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/tmc/langchaingo/schema"
+)
+
+func TestTerms(t *testing.T) {
+	got := Terms("制度编号HR-2024-03：请假流程")
+	want := []string{"制度", "度编", "编号", "hr", "2024", "03", "请假", "假流", "流程"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected terms: %v", got)
+	}
+	if got := Terms("张 三"); !reflect.DeepEqual(got, []string{"张", "三"}) {
+		t.Fatalf("unexpected terms: %v", got)
+	}
+}
+
+func TestKeywordQuery(t *testing.T) {
+	if q := keywordQuery("请假 请假"); q != "@terms:(请假)" {
+		t.Fatalf("unexpected query: %s", q)
+	}
+	if q := keywordQuery("？！"); q != "" {
+		t.Fatalf("expected empty query, got %s", q)
+	}
+}
+
+func TestParseSearchReply(t *testing.T) {
+	docs, err := parseSearchReply([]any{
+		int64(2),
+		"doc:knowledge:1", "3.5", []any{"content", "差旅报销标准", "filename", "报销.docx"},
+		"doc:knowledge:2", "1.2", []any{"content", "请假流程"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(docs) != 2 || docs[0].PageContent != "差旅报销标准" || docs[0].Score != 3.5 ||
+		docs[0].Metadata["id"] != "doc:knowledge:1" || docs[0].Metadata["filename"] != "报销.docx" {
+		t.Fatalf("unexpected docs: %+v", docs)
+	}
+}
+
+type fakeKeyword map[string][]schema.Document
+
+func (f fakeKeyword) Search(_ context.Context, index, _ string, _ int) ([]schema.Document, error) {
+	return f[index], nil
+}
+
+func TestHybridRetriever(t *testing.T) {
+	doc := func(id, content string) schema.Document {
+		return schema.Document{PageContent: content, Metadata: map[string]any{"id": id, TermsField: "x"}}
+	}
+	public := &fakeStore{docs: []schema.Document{
+		doc("1", "员工手册"),
+		doc("2", "制度HR-2024-03 请假"),
+	}}
+	keyword := fakeKeyword{"knowledge": {
+		doc("2", "制度HR-2024-03 请假"),
+		doc("3", "HR-2024-03 附件"),
+	}}
+
+	docs, err := NewMultiRetriever(2, public).WithKeyword(keyword, "knowledge").
+		GetRelevantDocuments(context.Background(), "HR-2024-03")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 两路都命中的文档排在最前
+	if len(docs) != 2 || docs[0].Metadata["id"] != "2" || docs[1].Metadata["id"] != "1" {
+		t.Fatalf("unexpected docs: %v", docs)
+	}
+	if _, ok := docs[0].Metadata[TermsField]; ok {
+		t.Fatal("terms should be stripped")
+	}
+}
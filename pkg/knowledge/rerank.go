@@ -0,0 +1,218 @@
+package knowledge
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"aiOffice/pkg/langchain/promptx"
+
+	"github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/schema"
+)
+
+// Reranker 对检索到的候选文档块按与问题的相关性重新排序
+type Reranker interface {
+	Rerank(ctx context.Context, query string, docs []schema.Document) ([]schema.Document, error)
+}
+
+var _ schema.Retriever = (*RerankRetriever)(nil)
+
+// RerankRetriever 先由base检索出较多候选，重排后取前numDocuments个；重排失败时按原顺序返回
+type RerankRetriever struct {
+	base         schema.Retriever
+	reranker     Reranker
+	numDocuments int
+}
+
+func NewRerankRetriever(base schema.Retriever, reranker Reranker, numDocuments int) *RerankRetriever {
+	return &RerankRetriever{
+		base:         base,
+		reranker:     reranker,
+		numDocuments: numDocuments,
+	}
+}
+
+func (r *RerankRetriever) GetRelevantDocuments(ctx context.Context, query string) ([]schema.Document, error) {
+	docs, err := r.base.GetRelevantDocuments(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(docs) > 1 {
+		reranked, err := r.reranker.Rerank(ctx, query, docs)
+		if err != nil {
+			fmt.Printf("[Knowledge] 重排失败，使用原检索顺序: %v\n", err)
+		} else {
+			docs = reranked
+		}
+	}
+	if len(docs) > r.numDocuments {
+		docs = docs[:r.numDocuments]
+	}
+	return docs, nil
+}
+
+// APIReranker 调用cross-encoder重排接口（Jina/Cohere格式的 /rerank，bge-reranker等本地部署服务同样适用）
+type APIReranker struct {
+	url    string
+	apiKey string
+	model  string
+	http   *http.Client
+}
+
+// NewAPIReranker url为完整的接口地址，如 https://api.jina.ai/v1/rerank
+func NewAPIReranker(url, apiKey, model string, timeout time.Duration) *APIReranker {
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	return &APIReranker{
+		url:    url,
+		apiKey: apiKey,
+		model:  model,
+		http:   &http.Client{Timeout: timeout},
+	}
+}
+
+// Rerank 返回的Score为接口给出的相关性得分（越大越相关）
+func (r *APIReranker) Rerank(ctx context.Context, query string, docs []schema.Document) ([]schema.Document, error) {
+	documents := make([]string, 0, len(docs))
+	for _, doc := range docs {
+		documents = append(documents, doc.PageContent)
+	}
+	b, err := json.Marshal(map[string]any{
+		"model":     r.model,
+		"query":     query,
+		"documents": documents,
+		"top_n":     len(docs),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.url, bytes.NewReader(b))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if r.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+r.apiKey)
+	}
+
+	resp, err := r.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("rerank status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	var res struct {
+		Results []struct {
+			Index          int     `json:"index"`
+			RelevanceScore float64 `json:"relevance_score"`
+		} `json:"results"`
+	}
+	if err := json.Unmarshal(body, &res); err != nil {
+		return nil, fmt.Errorf("rerank: %w", err)
+	}
+
+	order := make([]int, 0, len(res.Results))
+	scores := make(map[int]float32, len(res.Results))
+	for _, v := range res.Results {
+		order = append(order, v.Index)
+		scores[v.Index] = float32(v.RelevanceScore)
+	}
+	reranked := make([]schema.Document, 0, len(docs))
+	for _, i := range reorder(len(docs), order) {
+		doc := docs[i]
+		if score, ok := scores[i]; ok {
+			doc.Score = score
+		}
+		reranked = append(reranked, doc)
+	}
+	return reranked, nil
+}
+
+// RerankPrompt LLM重排的默认提示词，%s依次为问题和编号后的文档片段，可通过提示词rerank覆盖
+const RerankPrompt = `请根据与问题的相关程度，对下面编号的文档片段从高到低排序。
+只输出片段编号，用英文逗号分隔，例如：2,0,1。与问题无关的片段不要输出。
+
+问题：%s
+
+%s`
+
+// LLMReranker 由大模型判断相关性并给出排序，不需要额外部署重排模型
+type LLMReranker struct {
+	llm     llms.Model
+	prompts *promptx.Store
+}
+
+// NewLLMReranker store为nil时使用RerankPrompt
+func NewLLMReranker(llm llms.Model, store *promptx.Store) *LLMReranker {
+	return &LLMReranker{llm: llm, prompts: store}
+}
+
+var indexPattern = regexp.MustCompile(`\d+`)
+
+// Rerank 模型未列出的片段视为不相关，排在最后；模型一个编号都没给出时返回错误
+func (r *LLMReranker) Rerank(ctx context.Context, query string, docs []schema.Document) ([]schema.Document, error) {
+	var b strings.Builder
+	for i, doc := range docs {
+		fmt.Fprintf(&b, "[%d] %s\n\n", i, strings.TrimSpace(doc.PageContent))
+	}
+
+	out, err := llms.GenerateFromSinglePrompt(ctx, r.llm, fmt.Sprintf(r.prompts.Get(ctx, promptx.KeyRerank, RerankPrompt), query, b.String()),
+		llms.WithTemperature(0))
+	if err != nil {
+		return nil, err
+	}
+
+	var order []int
+	for _, s := range indexPattern.FindAllString(out, -1) {
+		if i, err := strconv.Atoi(s); err == nil && i < len(docs) {
+			order = append(order, i)
+		}
+	}
+	if len(order) == 0 {
+		return nil, fmt.Errorf("rerank: 无法解析模型输出: %s", out)
+	}
+
+	reranked := make([]schema.Document, 0, len(docs))
+	for _, i := range reorder(len(docs), order) {
+		reranked = append(reranked, docs[i])
+	}
+	return reranked, nil
+}
+
+// reorder 返回n个文档按order重排后的下标，忽略越界和重复的下标，未出现的保持原顺序追加到末尾
+func reorder(n int, order []int) []int {
+	seen := make(map[int]bool, n)
+	res := make([]int, 0, n)
+	for _, i := range order {
+		if i < 0 || i >= n || seen[i] {
+			continue
+		}
+		seen[i] = true
+		res = append(res, i)
+	}
+	for i := 0; i < n; i++ {
+		if !seen[i] {
+			res = append(res, i)
+		}
+	}
+	return res
+}
@@ -0,0 +1,84 @@
+package knowledge
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/schema"
+)
+
+type fakeRetriever []schema.Document
+
+func (r fakeRetriever) GetRelevantDocuments(context.Context, string) ([]schema.Document, error) {
+	return r, nil
+}
+
+type fakeLLM struct {
+	out string
+	err error
+}
+
+func (m *fakeLLM) GenerateContent(context.Context, []llms.MessageContent, ...llms.CallOption) (*llms.ContentResponse, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	return &llms.ContentResponse{Choices: []*llms.ContentChoice{{Content: m.out}}}, nil
+}
+
+func (m *fakeLLM) Call(ctx context.Context, prompt string, options ...llms.CallOption) (string, error) {
+	return llms.GenerateFromSinglePrompt(ctx, m, prompt, options...)
+}
+
+var candidates = fakeRetriever{
+	{PageContent: "员工手册"},
+	{PageContent: "差旅报销标准"},
+	{PageContent: "请假流程"},
+}
+
+func TestAPIReranker(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Model     string   `json:"model"`
+			Query     string   `json:"query"`
+			Documents []string `json:"documents"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		if req.Model != "bge-reranker" || len(req.Documents) != 3 || r.Header.Get("Authorization") != "Bearer key" {
+			t.Errorf("unexpected request: %+v", req)
+		}
+		w.Write([]byte(`{"results":[{"index":2,"relevance_score":0.9},{"index":0,"relevance_score":0.2}]}`))
+	}))
+	defer srv.Close()
+
+	reranker := NewAPIReranker(srv.URL, "key", "bge-reranker", 0)
+	docs, err := NewRerankRetriever(candidates, reranker, 2).GetRelevantDocuments(context.Background(), "怎么请假")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(docs) != 2 || docs[0].PageContent != "请假流程" || docs[0].Score != 0.9 || docs[1].PageContent != "员工手册" {
+		t.Fatalf("unexpected docs: %+v", docs)
+	}
+}
+
+func TestLLMReranker(t *testing.T) {
+	docs, err := NewRerankRetriever(candidates, NewLLMReranker(&fakeLLM{out: "1, 1, 7"}, nil), 2).
+		GetRelevantDocuments(context.Background(), "报销标准")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(docs) != 2 || docs[0].PageContent != "差旅报销标准" || docs[1].PageContent != "员工手册" {
+		t.Fatalf("unexpected docs: %+v", docs)
+	}
+
+	// 重排失败时按原顺序返回
+	docs, err = NewRerankRetriever(candidates, NewLLMReranker(&fakeLLM{err: errors.New("timeout")}, nil), 2).
+		GetRelevantDocuments(context.Background(), "报销标准")
+	if err != nil || len(docs) != 2 || docs[0].PageContent != "员工手册" {
+		t.Fatalf("unexpected fallback: %+v %v", docs, err)
+	}
+}
@@ -0,0 +1,160 @@
+package knowledge
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/tmc/langchaingo/schema"
+	"github.com/tmc/langchaingo/vectorstores"
+)
+
+var _ schema.Retriever = (*MultiRetriever)(nil)
+
+// rrfK RRF融合的平滑常数，取论文中的经验值
+const rrfK = 60
+
+// KeywordSearch 关键词检索，index为向量存储的索引名
+type KeywordSearch interface {
+	Search(ctx context.Context, index, query string, k int) ([]schema.Document, error)
+}
+
+// MultiRetriever 在多个向量索引中检索，按距离合并后取最相近的结果；
+// 开启关键词检索后为混合检索，向量和关键词结果按RRF融合
+type MultiRetriever struct {
+	stores       []vectorstores.VectorStore
+	numDocuments int
+
+	keyword KeywordSearch
+	indexes []string // 与stores一一对应
+}
+
+func NewMultiRetriever(numDocuments int, stores ...vectorstores.VectorStore) *MultiRetriever {
+	return &MultiRetriever{
+		stores:       stores,
+		numDocuments: numDocuments,
+	}
+}
+
+// WithKeyword 开启混合检索，indexes为stores对应的索引名
+func (r *MultiRetriever) WithKeyword(keyword KeywordSearch, indexes ...string) *MultiRetriever {
+	r.keyword = keyword
+	r.indexes = indexes
+	return r
+}
+
+// GetRelevantDocuments 单个索引失败（如部门尚未建立索引）时跳过，全部失败才返回错误
+func (r *MultiRetriever) GetRelevantDocuments(ctx context.Context, query string) ([]schema.Document, error) {
+	if r.keyword != nil {
+		return r.hybrid(ctx, query)
+	}
+
+	var (
+		docs []schema.Document
+		errs []error
+	)
+	for _, store := range r.stores {
+		res, err := store.SimilaritySearch(ctx, query, r.numDocuments)
+		if err != nil {
+			errs = append(errs, err)
+			fmt.Printf("[Knowledge] 检索失败: %v\n", err)
+			continue
+		}
+		docs = append(docs, res...)
+	}
+	if len(errs) > 0 && len(errs) == len(r.stores) {
+		return nil, errors.Join(errs...)
+	}
+
+	// redis返回的Score为向量距离，越小越相近
+	sort.SliceStable(docs, func(i, j int) bool {
+		return docs[i].Score < docs[j].Score
+	})
+	if len(docs) > r.numDocuments {
+		docs = docs[:r.numDocuments]
+	}
+	return stripTerms(docs), nil
+}
+
+// hybrid 每个索引分别进行向量检索和关键词检索，所有结果列表按RRF融合，
+// 返回文档的Score为融合得分（越大越相关）。关键词检索失败（如旧索引没有分词字段）只记录日志
+func (r *MultiRetriever) hybrid(ctx context.Context, query string) ([]schema.Document, error) {
+	// 每路多取一些候选，融合后再截断
+	candidates := r.numDocuments * 2
+
+	var (
+		lists [][]schema.Document
+		errs  []error
+	)
+	for i, store := range r.stores {
+		res, err := store.SimilaritySearch(ctx, query, candidates)
+		if err != nil {
+			errs = append(errs, err)
+			fmt.Printf("[Knowledge] 检索失败: %v\n", err)
+			continue
+		}
+		lists = append(lists, res)
+
+		if i >= len(r.indexes) {
+			continue
+		}
+		res, err = r.keyword.Search(ctx, r.indexes[i], query, candidates)
+		if err != nil {
+			fmt.Printf("[Knowledge] 关键词检索失败(%s): %v\n", r.indexes[i], err)
+			continue
+		}
+		lists = append(lists, res)
+	}
+	if len(errs) > 0 && len(errs) == len(r.stores) {
+		return nil, errors.Join(errs...)
+	}
+
+	docs := fuseRRF(lists)
+	if len(docs) > r.numDocuments {
+		docs = docs[:r.numDocuments]
+	}
+	return stripTerms(docs), nil
+}
+
+// fuseRRF 倒数排名融合：文档得分为其在各列表中 1/(rrfK+名次) 之和，按文档块id去重；
+// 同一文档块优先保留先出现的版本（向量检索结果带有完整metadata）
+func fuseRRF(lists [][]schema.Document) []schema.Document {
+	var (
+		docs   []schema.Document
+		scores []float64
+		pos    = make(map[string]int)
+	)
+	for _, list := range lists {
+		for rank, doc := range list {
+			key, _ := doc.Metadata["id"].(string)
+			if key == "" {
+				key = doc.PageContent
+			}
+			i, ok := pos[key]
+			if !ok {
+				i = len(docs)
+				pos[key] = i
+				docs = append(docs, doc)
+				scores = append(scores, 0)
+			}
+			scores[i] += 1 / float64(rrfK+rank+1)
+		}
+	}
+
+	for i := range docs {
+		docs[i].Score = float32(scores[i])
+	}
+	sort.SliceStable(docs, func(i, j int) bool {
+		return docs[i].Score > docs[j].Score
+	})
+	return docs
+}
+
+// stripTerms 去掉检索用的分词字段，避免带入上下文
+func stripTerms(docs []schema.Document) []schema.Document {
+	for _, doc := range docs {
+		delete(doc.Metadata, TermsField)
+	}
+	return docs
+}
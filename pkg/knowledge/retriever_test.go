@@ -0,0 +1,68 @@
+package knowledge
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/tmc/langchaingo/schema"
+	"github.com/tmc/langchaingo/vectorstores"
+)
+
+type fakeStore struct {
+	docs []schema.Document
+	err  error
+}
+
+func (s *fakeStore) AddDocuments(context.Context, []schema.Document, ...vectorstores.Option) ([]string, error) {
+	return nil, nil
+}
+
+func (s *fakeStore) SimilaritySearch(context.Context, string, int, ...vectorstores.Option) ([]schema.Document, error) {
+	return s.docs, s.err
+}
+
+func TestMultiRetriever(t *testing.T) {
+	public := &fakeStore{docs: []schema.Document{
+		{PageContent: "员工手册", Score: 0.3},
+		{PageContent: "考勤制度", Score: 0.5},
+	}}
+	dept := &fakeStore{docs: []schema.Document{
+		{PageContent: "销售提成", Score: 0.1},
+	}}
+	missing := &fakeStore{err: errors.New("no such index")}
+
+	docs, err := NewMultiRetriever(2, public, dept, missing).GetRelevantDocuments(context.Background(), "提成怎么算")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(docs) != 2 || docs[0].PageContent != "销售提成" || docs[1].PageContent != "员工手册" {
+		t.Fatalf("unexpected docs: %v", docs)
+	}
+
+	if _, err := NewMultiRetriever(2, missing).GetRelevantDocuments(context.Background(), "q"); err == nil {
+		t.Fatal("expected error when all stores fail")
+	}
+}
+
+func TestDepartmentIndex(t *testing.T) {
+	if DepartmentIndex("") != IndexName {
+		t.Fatal("empty department should use the public index")
+	}
+	// 部门索引的key前缀不能落在公共索引的前缀下
+	if idx := DepartmentIndex("65f1c2a9e4b0a1b2c3d4e5f6"); len(idx) >= len(IndexName) && idx[:len(IndexName)] == IndexName {
+		t.Fatalf("department index %q shares the public prefix", idx)
+	}
+}
+
+func TestCosine(t *testing.T) {
+	if v := cosine([]float32{1, 0}, []float32{1, 0}); v != 1 {
+		t.Fatalf("expected 1, got %v", v)
+	}
+	if v := cosine([]float32{1, 0}, []float32{-1, 0}); v != 0 {
+		t.Fatalf("opposite vectors should be 0, got %v", v)
+	}
+	if v := cosine([]float32{1, 0}, []float32{1}); v != 0 {
+		t.Fatalf("mismatched dimensions should be 0, got %v", v)
+	}
+}
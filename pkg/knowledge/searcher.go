@@ -0,0 +1,159 @@
+package knowledge
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"path"
+	"sync"
+
+	"github.com/tmc/langchaingo/embeddings"
+	"github.com/tmc/langchaingo/schema"
+	"github.com/tmc/langchaingo/vectorstores"
+	"github.com/tmc/langchaingo/vectorstores/redisvector"
+)
+
+// SearcherConf 知识库检索配置
+type SearcherConf struct {
+	Url           string   // 向量库连接地址
+	Hybrid        bool     // 是否同时进行关键词检索
+	Reranker      Reranker // 为nil不重排
+	RerankIndexes []string // 开启重排的索引，支持通配符如 dept_*，为空时全部索引都重排
+	Candidates    int      // 参与重排的候选数，默认10
+}
+
+// Searcher 按索引缓存向量存储，根据配置组合向量、关键词检索和重排，供知识库工具和查询接口共用
+type Searcher struct {
+	embedder embeddings.Embedder
+	conf     SearcherConf
+	keyword  *KeywordSearcher // 未开启混合检索时为nil
+
+	mu     sync.Mutex
+	stores map[string]*redisvector.Store
+}
+
+func NewSearcher(embedder embeddings.Embedder, conf SearcherConf) *Searcher {
+	s := &Searcher{
+		embedder: embedder,
+		conf:     conf,
+		stores:   make(map[string]*redisvector.Store),
+	}
+	if conf.Candidates <= 0 {
+		s.conf.Candidates = 10
+	}
+	if conf.Hybrid {
+		keyword, err := NewKeywordSearcher(conf.Url)
+		if err != nil {
+			fmt.Printf("[Knowledge] 关键词检索初始化失败，仅使用向量检索: %v\n", err)
+		} else {
+			s.keyword = keyword
+		}
+	}
+	return s
+}
+
+// Store 获取指定索引的向量存储
+func (s *Searcher) Store(ctx context.Context, index string) (*redisvector.Store, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if store, ok := s.stores[index]; ok {
+		return store, nil
+	}
+	store, err := redisvector.New(ctx,
+		redisvector.WithEmbedder(s.embedder),
+		redisvector.WithConnectionURL(s.conf.Url),
+		redisvector.WithIndexName(index, true),
+	)
+	if err != nil {
+		return nil, err
+	}
+	s.stores[index] = store
+	return store, nil
+}
+
+// Retriever 在indexes中检索最相关的numDocuments个文档块；查询的索引中有任一开启了重排时，
+// 先取Candidates个候选，重排后再截断
+func (s *Searcher) Retriever(ctx context.Context, indexes []string, numDocuments int) (schema.Retriever, error) {
+	stores := make([]vectorstores.VectorStore, 0, len(indexes))
+	for _, index := range indexes {
+		store, err := s.Store(ctx, index)
+		if err != nil {
+			return nil, fmt.Errorf("获取向量存储失败: %v", err)
+		}
+		stores = append(stores, store)
+	}
+
+	rerank := s.conf.Reranker != nil && s.rerankEnabled(indexes)
+	n := numDocuments
+	if rerank {
+		n = max(s.conf.Candidates, numDocuments)
+	}
+
+	retriever := NewMultiRetriever(n, stores...)
+	if s.keyword != nil {
+		retriever.WithKeyword(s.keyword, indexes...)
+	}
+	if !rerank {
+		return retriever, nil
+	}
+	return NewRerankRetriever(retriever, s.conf.Reranker, numDocuments), nil
+}
+
+// Similarity 问题与每个文档块的余弦相似度，与检索方式无关，可用于衡量回答的可信度
+func (s *Searcher) Similarity(ctx context.Context, query string, docs []schema.Document) ([]float64, error) {
+	if len(docs) == 0 {
+		return nil, nil
+	}
+	q, err := s.embedder.EmbedQuery(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	texts := make([]string, 0, len(docs))
+	for _, doc := range docs {
+		texts = append(texts, doc.PageContent)
+	}
+	vectors, err := s.embedder.EmbedDocuments(ctx, texts)
+	if err != nil {
+		return nil, err
+	}
+
+	scores := make([]float64, len(docs))
+	for i := range scores {
+		if i < len(vectors) {
+			scores[i] = cosine(q, vectors[i])
+		}
+	}
+	return scores, nil
+}
+
+// cosine 余弦相似度，负数按0处理
+func cosine(a, b []float32) float64 {
+	if len(a) != len(b) || len(a) == 0 {
+		return 0
+	}
+	var dot, na, nb float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		na += float64(a[i]) * float64(a[i])
+		nb += float64(b[i]) * float64(b[i])
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return max(dot/(math.Sqrt(na)*math.Sqrt(nb)), 0)
+}
+
+func (s *Searcher) rerankEnabled(indexes []string) bool {
+	if len(s.conf.RerankIndexes) == 0 {
+		return true
+	}
+	for _, index := range indexes {
+		for _, pattern := range s.conf.RerankIndexes {
+			if ok, _ := path.Match(pattern, index); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
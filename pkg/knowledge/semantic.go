@@ -0,0 +1,148 @@
+package knowledge
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/tmc/langchaingo/embeddings"
+)
+
+// SemanticSplitter 按语义分块：相邻句子的向量差异明显时视为话题转换并在此处切分，
+// 适合没有明确段落结构的长篇制度、规范类文档
+type SemanticSplitter struct {
+	embedder embeddings.Embedder
+	// 断点百分位，相邻句子的差异超过该百分位时切分，默认95；越小块越多
+	percentile float64
+	// 嵌入句子时附带前后各buffer句作为上下文，减少短句带来的噪声
+	buffer int
+}
+
+func NewSemanticSplitter(embedder embeddings.Embedder, percentile float64) *SemanticSplitter {
+	if percentile <= 0 || percentile >= 100 {
+		percentile = 95
+	}
+	return &SemanticSplitter{
+		embedder:   embedder,
+		percentile: percentile,
+		buffer:     1,
+	}
+}
+
+// embedBatchSize 每次嵌入的句子数（阿里云 DashScope 限制每批最多 10 个）
+const embedBatchSize = 10
+
+// Split 块的长度不超过maxSize（按字符），且不小于maxSize/5，避免话题频繁变化时切出过碎的块
+func (s *SemanticSplitter) Split(ctx context.Context, text string, maxSize int) ([]string, error) {
+	sentences := splitSentences(text, maxSize)
+	if len(sentences) < 3 {
+		return sentences, nil
+	}
+
+	windows := make([]string, len(sentences))
+	for i := range sentences {
+		lo, hi := max(i-s.buffer, 0), min(i+s.buffer+1, len(sentences))
+		windows[i] = strings.Join(sentences[lo:hi], "")
+	}
+
+	vectors := make([][]float32, 0, len(windows))
+	for i := 0; i < len(windows); i += embedBatchSize {
+		batch, err := s.embedder.EmbedDocuments(ctx, windows[i:min(i+embedBatchSize, len(windows))])
+		if err != nil {
+			return nil, fmt.Errorf("句子向量化失败: %v", err)
+		}
+		vectors = append(vectors, batch...)
+	}
+	if len(vectors) != len(sentences) {
+		return nil, fmt.Errorf("句子向量化失败: 返回 %d 个向量，预期 %d 个", len(vectors), len(sentences))
+	}
+
+	distances := make([]float64, len(sentences)-1)
+	for i := range distances {
+		distances[i] = 1 - cosine(vectors[i], vectors[i+1])
+	}
+	return groupSentences(sentences, distances, percentile(distances, s.percentile), maxSize), nil
+}
+
+// groupSentences distances[i]为第i句和第i+1句的差异，超过threshold时切分
+func groupSentences(sentences []string, distances []float64, threshold float64, maxSize int) []string {
+	minSize := maxSize / 5
+
+	var (
+		chunks []string
+		cur    strings.Builder
+		size   int
+	)
+	for i, sentence := range sentences {
+		n := len([]rune(sentence))
+		if size > 0 && size+n > maxSize {
+			chunks = append(chunks, cur.String())
+			cur.Reset()
+			size = 0
+		}
+		cur.WriteString(sentence)
+		size += n
+
+		if i < len(distances) && distances[i] > threshold && size >= minSize {
+			chunks = append(chunks, cur.String())
+			cur.Reset()
+			size = 0
+		}
+	}
+	if size > 0 {
+		chunks = append(chunks, cur.String())
+	}
+	return chunks
+}
+
+// percentile 线性插值计算第p百分位数
+func percentile(values []float64, p float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+
+	pos := p / 100 * float64(len(sorted)-1)
+	lo := int(pos)
+	if lo+1 >= len(sorted) {
+		return sorted[len(sorted)-1]
+	}
+	return sorted[lo] + (sorted[lo+1]-sorted[lo])*(pos-float64(lo))
+}
+
+// splitSentences 按中英文句末标点和换行切分句子，保留标点；超过maxSize的句子按长度截断
+func splitSentences(text string, maxSize int) []string {
+	var (
+		sentences []string
+		cur       []rune
+	)
+	flush := func() {
+		if s := strings.TrimSpace(string(cur)); s != "" {
+			runes := []rune(s)
+			for len(runes) > maxSize {
+				sentences = append(sentences, string(runes[:maxSize]))
+				runes = runes[maxSize:]
+			}
+			sentences = append(sentences, string(runes))
+		}
+		cur = cur[:0]
+	}
+
+	runes := []rune(text)
+	for i, r := range runes {
+		cur = append(cur, r)
+		switch r {
+		case '。', '！', '？', '；', '!', '?', '\n':
+			flush()
+		case '.':
+			// 英文句号后跟空白才算句末，避免切开小数和编号
+			if i+1 == len(runes) || runes[i+1] == ' ' || runes[i+1] == '\n' {
+				flush()
+			}
+		}
+	}
+	flush()
+	return sentences
+}
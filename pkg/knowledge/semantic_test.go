@@ -0,0 +1,75 @@
+package knowledge
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+// topicEmbedder 按句子包含的关键词生成向量，模拟话题
+type topicEmbedder struct{}
+
+func (topicEmbedder) EmbedDocuments(_ context.Context, texts []string) ([][]float32, error) {
+	res := make([][]float32, 0, len(texts))
+	for _, text := range texts {
+		v := make([]float32, 2)
+		v[0] = float32(strings.Count(text, "考勤"))
+		v[1] = float32(strings.Count(text, "报销"))
+		res = append(res, v)
+	}
+	return res, nil
+}
+
+func (e topicEmbedder) EmbedQuery(ctx context.Context, text string) ([]float32, error) {
+	v, err := e.EmbedDocuments(ctx, []string{text})
+	return v[0], err
+}
+
+func TestSplitSentences(t *testing.T) {
+	got := splitSentences("第一句。第二句！\nVersion 1.2 is out. Next", 100)
+	want := []string{"第一句。", "第二句！", "Version 1.2 is out.", "Next"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Fatalf("got %q", got)
+	}
+
+	got = splitSentences("一二三四五", 2)
+	if strings.Join(got, "|") != "一二|三四|五" {
+		t.Fatalf("got %q", got)
+	}
+}
+
+func TestSemanticSplit(t *testing.T) {
+	text := "考勤时间为九点。迟到按考勤扣分。考勤每月汇总。报销需附发票。报销每月提交。报销由财务审核。"
+	chunks, err := NewSemanticSplitter(topicEmbedder{}, 50).Split(context.Background(), text, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(chunks) != 2 || !strings.HasPrefix(chunks[1], "报销需附发票") {
+		t.Fatalf("chunks = %q", chunks)
+	}
+}
+
+func TestGroupSentences(t *testing.T) {
+	sentences := []string{"aaaa", "bbbb", "cccc", "dddd"}
+
+	// 超过最大长度时强制切分
+	got := groupSentences(sentences, []float64{0, 0, 0}, 1, 8)
+	if strings.Join(got, "|") != "aaaabbbb|ccccdddd" {
+		t.Fatalf("got %q", got)
+	}
+
+	// 块小于最大长度的1/5时不在断点处切分
+	got = groupSentences(sentences, []float64{1, 0, 0}, 0.5, 40)
+	if strings.Join(got, "|") != "aaaabbbbccccdddd" {
+		t.Fatalf("got %q", got)
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	if p := percentile([]float64{4, 1, 3, 2}, 50); p != 2.5 {
+		t.Fatalf("p50 = %v", p)
+	}
+	if p := percentile([]float64{1, 2}, 100); p != 2 {
+		t.Fatalf("p100 = %v", p)
+	}
+}
@@ -0,0 +1,399 @@
+package knowledge
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/tmc/langchaingo/schema"
+)
+
+// sheet 工作表内容，rows[0]为表头
+type sheet struct {
+	name   string
+	rows   [][]string
+	offset int // 表头之前跳过的空行数
+}
+
+// splitExcel 按工作表分块，每块包含若干整行，行内容以“表头: 值”展开，表头同时写入metadata
+func (p *DocProcessor) splitExcel(filePath string) ([]schema.Document, error) {
+	sheets, err := readXlsx(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("读取Excel文件失败: %v", err)
+	}
+
+	var docs []schema.Document
+	for _, s := range sheets {
+		if len(s.rows) < 2 {
+			continue
+		}
+		header := s.rows[0]
+
+		var (
+			buf   strings.Builder
+			start int
+		)
+		flush := func(end int) {
+			if buf.Len() == 0 {
+				return
+			}
+			docs = append(docs, schema.Document{
+				PageContent: fmt.Sprintf("工作表: %s\n%s", s.name, strings.TrimSpace(buf.String())),
+				Metadata: map[string]any{
+					"source":     filePath,
+					"filename":   filepath.Base(filePath),
+					"chunk_id":   len(docs),
+					"split_type": "excel",
+					"sheet":      s.name,
+					"header":     strings.Join(header, " | "),
+					"row_start":  s.offset + start + 1, // Excel中的行号
+					"row_end":    s.offset + end + 1,
+				},
+			})
+			buf.Reset()
+		}
+
+		for i, row := range s.rows[1:] {
+			line := formatRow(header, row)
+			if line == "" {
+				continue
+			}
+			rowNum := i + 1
+			if buf.Len() > 0 && utf8.RuneCountInString(buf.String())+utf8.RuneCountInString(line) > p.ChunkSize {
+				flush(rowNum - 1)
+			}
+			if buf.Len() == 0 {
+				start = rowNum
+			}
+			buf.WriteString(line)
+			buf.WriteString("\n")
+		}
+		flush(len(s.rows) - 1)
+	}
+
+	if len(docs) == 0 {
+		return nil, fmt.Errorf("Excel文件中没有提取到有效数据")
+	}
+	fmt.Printf("[DocProcessor] Excel分块完成，%d 个工作表，共 %d 个文档块\n", len(sheets), len(docs))
+	return docs, nil
+}
+
+// extractExcel 全部工作表的文本，每行转换为“表头: 值”
+func (p *DocProcessor) extractExcel(filePath string) (string, error) {
+	sheets, err := readXlsx(filePath)
+	if err != nil {
+		return "", fmt.Errorf("读取Excel文件失败: %v", err)
+	}
+
+	var b strings.Builder
+	for _, s := range sheets {
+		if len(s.rows) == 0 {
+			continue
+		}
+		fmt.Fprintf(&b, "工作表: %s\n", s.name)
+		for _, row := range s.rows[1:] {
+			if line := formatRow(s.rows[0], row); line != "" {
+				b.WriteString(line)
+				b.WriteString("\n")
+			}
+		}
+		b.WriteString("\n")
+	}
+	return strings.TrimSpace(b.String()), nil
+}
+
+// formatRow 将一行转换为“表头: 值”，空单元格跳过
+func formatRow(header, row []string) string {
+	var parts []string
+	for i, v := range row {
+		v = strings.TrimSpace(v)
+		if v == "" {
+			continue
+		}
+		name := ""
+		if i < len(header) {
+			name = strings.TrimSpace(header[i])
+		}
+		if name == "" {
+			name = fmt.Sprintf("第%d列", i+1)
+		}
+		parts = append(parts, name+": "+v)
+	}
+	return strings.Join(parts, "; ")
+}
+
+// readXlsx 读取xlsx中全部工作表，跳过开头的空行，首个非空行作为表头
+func readXlsx(filePath string) ([]sheet, error) {
+	zr, err := zip.OpenReader(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+
+	files := make(map[string]*zip.File, len(zr.File))
+	for _, f := range zr.File {
+		files[f.Name] = f
+	}
+
+	var wb struct {
+		Sheets []struct {
+			Name string `xml:"name,attr"`
+			Id   string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+		} `xml:"sheets>sheet"`
+	}
+	if err := decodeXML(files["xl/workbook.xml"], &wb); err != nil {
+		return nil, fmt.Errorf("不是有效的xlsx文件: %v", err)
+	}
+
+	var rels struct {
+		List []struct {
+			Id     string `xml:"Id,attr"`
+			Target string `xml:"Target,attr"`
+		} `xml:"Relationship"`
+	}
+	if err := decodeXML(files["xl/_rels/workbook.xml.rels"], &rels); err != nil {
+		return nil, err
+	}
+	targets := make(map[string]string, len(rels.List))
+	for _, r := range rels.List {
+		target := strings.TrimPrefix(r.Target, "/")
+		if !strings.HasPrefix(target, "xl/") {
+			target = path.Join("xl", target)
+		}
+		targets[r.Id] = target
+	}
+
+	shared, err := readSharedStrings(files["xl/sharedStrings.xml"])
+	if err != nil {
+		return nil, err
+	}
+	dates, err := readDateStyles(files["xl/styles.xml"])
+	if err != nil {
+		return nil, err
+	}
+
+	sheets := make([]sheet, 0, len(wb.Sheets))
+	for _, s := range wb.Sheets {
+		rows, err := readSheet(files[targets[s.Id]], shared, dates)
+		if err != nil {
+			return nil, fmt.Errorf("工作表%s: %v", s.Name, err)
+		}
+		offset := 0
+		for offset < len(rows) && isEmptyRow(rows[offset]) {
+			offset++
+		}
+		sheets = append(sheets, sheet{name: s.Name, rows: rows[offset:], offset: offset})
+	}
+	return sheets, nil
+}
+
+func decodeXML(f *zip.File, v any) error {
+	if f == nil {
+		return fmt.Errorf("缺少文件")
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	return xml.NewDecoder(rc).Decode(v)
+}
+
+// richText 共享字符串和行内字符串，富文本由多个r组成
+type richText struct {
+	T string `xml:"t"`
+	R []struct {
+		T string `xml:"t"`
+	} `xml:"r"`
+}
+
+func (r richText) String() string {
+	if len(r.R) == 0 {
+		return r.T
+	}
+	var b strings.Builder
+	for _, v := range r.R {
+		b.WriteString(v.T)
+	}
+	return b.String()
+}
+
+func readSharedStrings(f *zip.File) ([]string, error) {
+	if f == nil {
+		return nil, nil
+	}
+	var sst struct {
+		Items []richText `xml:"si"`
+	}
+	if err := decodeXML(f, &sst); err != nil {
+		return nil, err
+	}
+	list := make([]string, 0, len(sst.Items))
+	for _, v := range sst.Items {
+		list = append(list, v.String())
+	}
+	return list, nil
+}
+
+// readDateStyles 返回日期格式的单元格样式下标
+func readDateStyles(f *zip.File) (map[int]bool, error) {
+	dates := make(map[int]bool)
+	if f == nil {
+		return dates, nil
+	}
+	var styles struct {
+		NumFmts []struct {
+			Id   int    `xml:"numFmtId,attr"`
+			Code string `xml:"formatCode,attr"`
+		} `xml:"numFmts>numFmt"`
+		Xfs []struct {
+			NumFmtId int `xml:"numFmtId,attr"`
+		} `xml:"cellXfs>xf"`
+	}
+	if err := decodeXML(f, &styles); err != nil {
+		return nil, err
+	}
+
+	custom := make(map[int]bool)
+	for _, v := range styles.NumFmts {
+		code := strings.ToLower(v.Code)
+		custom[v.Id] = strings.Contains(code, "yy") || strings.Contains(code, "d") && strings.Contains(code, "m")
+	}
+	for i, xf := range styles.Xfs {
+		id := xf.NumFmtId
+		if (id >= 14 && id <= 22) || (id >= 45 && id <= 47) || custom[id] {
+			dates[i] = true
+		}
+	}
+	return dates, nil
+}
+
+func readSheet(f *zip.File, shared []string, dates map[int]bool) ([][]string, error) {
+	if f == nil {
+		return nil, fmt.Errorf("缺少工作表文件")
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	type cell struct {
+		Ref   string   `xml:"r,attr"`
+		Type  string   `xml:"t,attr"`
+		Style int      `xml:"s,attr"`
+		V     string   `xml:"v"`
+		Is    richText `xml:"is"`
+	}
+	type row struct {
+		Num   int    `xml:"r,attr"`
+		Cells []cell `xml:"c"`
+	}
+
+	var rows [][]string
+	dec := xml.NewDecoder(rc)
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		se, ok := tok.(xml.StartElement)
+		if !ok || se.Name.Local != "row" {
+			continue
+		}
+
+		var r row
+		if err := dec.DecodeElement(&r, &se); err != nil {
+			return nil, err
+		}
+		// 未写出的空行按行号补齐，保证行号与Excel一致
+		if r.Num > len(rows)+1 {
+			rows = append(rows, make([][]string, r.Num-len(rows)-1)...)
+		}
+
+		var values []string
+		for _, c := range r.Cells {
+			col := columnIndex(c.Ref)
+			if col < 0 {
+				col = len(values)
+			}
+			for len(values) <= col {
+				values = append(values, "")
+			}
+			values[col] = cellValue(c.Type, c.V, c.Is, c.Style, shared, dates)
+		}
+		rows = append(rows, values)
+	}
+	return rows, nil
+}
+
+func cellValue(typ, v string, is richText, style int, shared []string, dates map[int]bool) string {
+	switch typ {
+	case "s":
+		i, err := strconv.Atoi(v)
+		if err != nil || i < 0 || i >= len(shared) {
+			return ""
+		}
+		return shared[i]
+	case "inlineStr":
+		return is.String()
+	case "b":
+		if v == "1" {
+			return "是"
+		}
+		return "否"
+	case "str", "e":
+		return v
+	}
+
+	if dates[style] {
+		if n, err := strconv.ParseFloat(v, 64); err == nil {
+			return excelTime(n)
+		}
+	}
+	return v
+}
+
+// excelTime Excel日期序列号转换为日期，带时间部分时保留到分钟
+func excelTime(serial float64) string {
+	days := math.Floor(serial)
+	t := time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC).
+		AddDate(0, 0, int(days)).
+		Add(time.Duration(math.Round((serial-days)*86400)) * time.Second)
+	if serial == days {
+		return t.Format("2006-01-02")
+	}
+	return t.Format("2006-01-02 15:04")
+}
+
+// columnIndex 单元格引用的列下标，如 A1=0、AB3=27
+func columnIndex(ref string) int {
+	col := 0
+	for _, c := range ref {
+		if c < 'A' || c > 'Z' {
+			break
+		}
+		col = col*26 + int(c-'A'+1)
+	}
+	return col - 1
+}
+
+func isEmptyRow(row []string) bool {
+	for _, v := range row {
+		if strings.TrimSpace(v) != "" {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,106 @@
+package knowledge
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeXlsx 生成最小的xlsx文件
+func writeXlsx(t *testing.T, files map[string]string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "price.xlsx")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	zw := zip.NewWriter(f)
+	for name, content := range files {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(content))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestSplitExcel(t *testing.T) {
+	path := writeXlsx(t, map[string]string{
+		"xl/workbook.xml": `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+<sheets><sheet name="价目表" sheetId="1" r:id="rId1"/><sheet name="空表" sheetId="2" r:id="rId2"/></sheets></workbook>`,
+		"xl/_rels/workbook.xml.rels": `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Target="worksheets/sheet1.xml"/><Relationship Id="rId2" Target="/xl/worksheets/sheet2.xml"/></Relationships>`,
+		"xl/sharedStrings.xml": `<sst><si><t>名称</t></si><si><t>单价</t></si><si><t>生效日期</t></si><si><r><t>A4</t></r><r><t>打印纸</t></r></si></sst>`,
+		"xl/styles.xml":        `<styleSheet><cellXfs><xf numFmtId="0"/><xf numFmtId="14"/></cellXfs></styleSheet>`,
+		"xl/worksheets/sheet1.xml": `<worksheet><sheetData>
+<row r="2"><c r="A2" t="s"><v>0</v></c><c r="B2" t="s"><v>1</v></c><c r="C2" t="s"><v>2</v></c></row>
+<row r="3"><c r="A3" t="s"><v>3</v></c><c r="B3"><v>25.5</v></c><c r="C3" s="1"><v>45658</v></c></row>
+<row r="5"><c r="A5" t="inlineStr"><is><t>订书机</t></is></c><c r="C5" s="1"><v>45659</v></c><c r="D5" t="str"><v>备注</v></c></row>
+</sheetData></worksheet>`,
+		"xl/worksheets/sheet2.xml": `<worksheet><sheetData/></worksheet>`,
+	})
+
+	docs, err := NewDocProcessor(500, 50).Process(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(docs) != 1 {
+		t.Fatalf("expected 1 chunk, got %d", len(docs))
+	}
+
+	content := docs[0].PageContent
+	for _, want := range []string{
+		"工作表: 价目表",
+		"名称: A4打印纸; 单价: 25.5; 生效日期: 2025-01-01",
+		"名称: 订书机; 生效日期: 2025-01-02; 第4列: 备注",
+	} {
+		if !strings.Contains(content, want) {
+			t.Fatalf("content missing %q:\n%s", want, content)
+		}
+	}
+
+	meta := docs[0].Metadata
+	if meta["sheet"] != "价目表" || meta["header"] != "名称 | 单价 | 生效日期" || meta["row_start"] != 3 || meta["row_end"] != 5 {
+		t.Fatalf("unexpected metadata: %v", meta)
+	}
+
+	text, err := NewDocProcessor(500, 50).Extract(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(text, "工作表: 价目表\n名称: A4打印纸") || strings.Contains(text, "空表") {
+		t.Fatalf("unexpected extracted text:\n%s", text)
+	}
+}
+
+func TestSplitExcelChunks(t *testing.T) {
+	rows := `<row r="1"><c r="A1" t="inlineStr"><is><t>条款</t></is></c></row>`
+	for i := 2; i <= 21; i++ {
+		rows += `<row><c t="inlineStr"><is><t>` + strings.Repeat("报销", 10) + `</t></is></c></row>`
+	}
+	path := writeXlsx(t, map[string]string{
+		"xl/workbook.xml":            `<workbook xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets><sheet name="制度" r:id="rId1"/></sheets></workbook>`,
+		"xl/_rels/workbook.xml.rels": `<Relationships><Relationship Id="rId1" Target="worksheets/sheet1.xml"/></Relationships>`,
+		"xl/worksheets/sheet1.xml":   `<worksheet><sheetData>` + rows + `</sheetData></worksheet>`,
+	})
+
+	docs, err := NewDocProcessor(100, 0).Process(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 每行24字，每块最多4行
+	if len(docs) != 5 {
+		t.Fatalf("expected 5 chunks, got %d", len(docs))
+	}
+	if docs[1].Metadata["row_start"] != 6 || docs[1].Metadata["row_end"] != 9 {
+		t.Fatalf("unexpected rows: %v", docs[1].Metadata)
+	}
+}
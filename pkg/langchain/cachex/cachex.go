@@ -0,0 +1,112 @@
+package cachex
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/redis/go-redis/v9"
+	"golang.org/x/text/width"
+)
+
+// Cache 基于Redis的AI回复缓存，键为 规范化后的问题 + 知识库版本，
+// 知识库更新时递增版本，旧版本的缓存不再命中并随TTL过期
+type Cache struct {
+	client redis.UniversalClient
+	prefix string
+	ttl    time.Duration
+}
+
+func New(client redis.UniversalClient, prefix string, ttl time.Duration) *Cache {
+	return &Cache{
+		client: client,
+		prefix: prefix,
+		ttl:    ttl,
+	}
+}
+
+// Get 查询缓存，未命中或Redis异常时返回false，nil上调用始终未命中
+func (c *Cache) Get(ctx context.Context, query string) (string, bool) {
+	if c == nil {
+		return "", false
+	}
+
+	key, err := c.key(ctx, query)
+	if err != nil {
+		fmt.Printf("[Cache] 获取缓存版本失败: %v\n", err)
+		return "", false
+	}
+
+	answer, err := c.client.Get(ctx, key).Result()
+	if err != nil {
+		if !errors.Is(err, redis.Nil) {
+			fmt.Printf("[Cache] 查询缓存失败: %v\n", err)
+		}
+		return "", false
+	}
+	return answer, true
+}
+
+// Set 写入缓存
+func (c *Cache) Set(ctx context.Context, query, answer string) error {
+	if c == nil || answer == "" {
+		return nil
+	}
+
+	key, err := c.key(ctx, query)
+	if err != nil {
+		return err
+	}
+	return c.client.Set(ctx, key, answer, c.ttl).Err()
+}
+
+// Invalidate 递增知识库版本，使已有缓存全部失效
+func (c *Cache) Invalidate(ctx context.Context) error {
+	if c == nil {
+		return nil
+	}
+	return c.client.Incr(ctx, c.prefix+"version").Err()
+}
+
+func (c *Cache) key(ctx context.Context, query string) (string, error) {
+	version, err := c.client.Get(ctx, c.prefix+"version").Int64()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return "", err
+	}
+
+	sum := sha1.Sum([]byte(Scope(ctx) + "\x00" + Normalize(query)))
+	return fmt.Sprintf("%sv%d:%s", c.prefix, version, hex.EncodeToString(sum[:])), nil
+}
+
+const scopeKey = "llms.cache.scope" // 缓存范围的上下文键名
+
+// WithScope 设置缓存范围，不同范围的相同问题互不命中，如可检索的知识库不同的用户
+func WithScope(ctx context.Context, scope string) context.Context {
+	return context.WithValue(ctx, scopeKey, scope)
+}
+
+// Scope 获取context中的缓存范围
+func Scope(ctx context.Context) string {
+	scope, _ := ctx.Value(scopeKey).(string)
+	return scope
+}
+
+// Normalize 规范化问题：全角转半角、转小写，去掉空白和标点，
+// 使 "请假流程是什么？" 与 "请假流程是什么" 命中同一缓存
+func Normalize(query string) string {
+	query = strings.ToLower(width.Narrow.String(query))
+
+	var b strings.Builder
+	for _, r := range query {
+		if unicode.IsSpace(r) || unicode.IsPunct(r) || unicode.IsSymbol(r) {
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
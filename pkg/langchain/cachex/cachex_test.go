@@ -0,0 +1,33 @@
+package cachex
+
+import (
+	"context"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	cases := map[string]string{
+		"请假流程是什么？":        "请假流程是什么",
+		" 请假 流程是什么? ":     "请假流程是什么",
+		"ＯＡ系统怎么登录！":       "oa系统怎么登录",
+		"报销流程, 需要哪些材料...": "报销流程需要哪些材料",
+	}
+	for in, want := range cases {
+		if got := Normalize(in); got != want {
+			t.Errorf("Normalize(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestNilCache(t *testing.T) {
+	var c *Cache
+	if _, ok := c.Get(context.Background(), "q"); ok {
+		t.Error("nil cache should miss")
+	}
+	if err := c.Set(context.Background(), "q", "a"); err != nil {
+		t.Error(err)
+	}
+	if err := c.Invalidate(context.Background()); err != nil {
+		t.Error(err)
+	}
+}
@@ -4,6 +4,9 @@ import (
 	"context"
 	"encoding/json"
 
+	"aiOffice/pkg/redact"
+	"aiOffice/pkg/requestid"
+
 	"gitee.com/dn-jinmin/tlog"
 	"github.com/tmc/langchaingo/llms"
 	"github.com/tmc/langchaingo/schema"
@@ -19,6 +22,24 @@ func NewLogHandler(logger tlog.Logger) *LogHandle {
 	return &LogHandle{Logger: logger}
 }
 
+// InfoCtx 记录日志时附带请求标识，关联同一请求的http日志和AI执行过程
+func (l *LogHandle) InfoCtx(ctx context.Context, msg string, fields ...any) {
+	l.Logger.InfoCtx(ctx, msg, requestid.Fields(ctx, fields...)...)
+}
+
+// ErrorCtx 同InfoCtx
+func (l *LogHandle) ErrorCtx(ctx context.Context, msg string, fields ...any) {
+	l.Logger.ErrorCtx(ctx, msg, requestid.Fields(ctx, fields...)...)
+}
+
+// InfofCtx 同InfoCtx
+func (l *LogHandle) InfofCtx(ctx context.Context, msg string, format string, fields ...any) {
+	if id := requestid.FromContext(ctx); id != "" {
+		format, fields = format+", "+requestid.Field+" %s", append(fields, id)
+	}
+	l.Logger.InfofCtx(ctx, msg, format, fields...)
+}
+
 // HandleText 处理文本输出事件，记录生成的文本内容
 func (l *LogHandle) HandleText(ctx context.Context, text string) {
 	l.InfoCtx(ctx, "text", text)
@@ -59,14 +80,14 @@ func (l *LogHandle) HandleChainError(ctx context.Context, err error) {
 	l.ErrorCtx(ctx, "chain_error", err.Error())
 }
 
-// HandleToolStart 处理工具调用开始事件，记录输入内容
+// HandleToolStart 处理工具调用开始事件，记录脱敏后的输入内容
 func (l *LogHandle) HandleToolStart(ctx context.Context, input string) {
-	l.InfoCtx(ctx, "tool_start", input)
+	l.InfoCtx(ctx, "tool_start", redact.Text(input, 0))
 }
 
-// HandleToolEnd 处理工具调用结束事件，记录输出内容
+// HandleToolEnd 处理工具调用结束事件，记录脱敏后的输出内容
 func (l *LogHandle) HandleToolEnd(ctx context.Context, output string) {
-	l.InfoCtx(ctx, "tool_end", output)
+	l.InfoCtx(ctx, "tool_end", redact.Text(output, 0))
 }
 
 // HandleToolError 处理工具调用错误事件，记录错误信息
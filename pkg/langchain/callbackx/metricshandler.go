@@ -0,0 +1,74 @@
+package callbackx
+
+import (
+	"context"
+	"time"
+
+	"aiOffice/pkg/metrics"
+
+	"github.com/tmc/langchaingo/callbacks"
+	"github.com/tmc/langchaingo/llms"
+)
+
+type llmCallKey struct{}
+
+// llmCall 一次模型调用的供应商、模型和开始时间
+type llmCall struct {
+	provider string
+	model    string
+	start    time.Time
+}
+
+// WithLLMCall 标记一次模型调用，由调用方在请求供应商前设置，
+// 回调中没有供应商和模型信息，MetricsHandle据此打标签并计算耗时
+func WithLLMCall(ctx context.Context, provider, model string) context.Context {
+	return context.WithValue(ctx, llmCallKey{}, &llmCall{
+		provider: provider,
+		model:    model,
+		start:    time.Now(),
+	})
+}
+
+// MetricsHandle 模型调用指标处理器，按供应商和模型记录调用次数、耗时、token和错误
+type MetricsHandle struct {
+	callbacks.SimpleHandler
+}
+
+// NewMetricsHandler 创建模型调用指标处理器
+func NewMetricsHandler() *MetricsHandle {
+	return &MetricsHandle{}
+}
+
+// HandleLLMGenerateContentEnd 记录成功的调用和token用量
+func (m *MetricsHandle) HandleLLMGenerateContentEnd(ctx context.Context, res *llms.ContentResponse) {
+	call, ok := ctx.Value(llmCallKey{}).(*llmCall)
+	if !ok {
+		return
+	}
+	m.observe(call, "ok")
+
+	if res == nil {
+		return
+	}
+	var prompt, completion int
+	for _, choice := range res.Choices {
+		prompt = max(prompt, intValue(choice.GenerationInfo["PromptTokens"]))
+		completion += intValue(choice.GenerationInfo["CompletionTokens"])
+	}
+	metrics.LLMTokensTotal.WithLabelValues(call.provider, call.model, "prompt").Add(float64(prompt))
+	metrics.LLMTokensTotal.WithLabelValues(call.provider, call.model, "completion").Add(float64(completion))
+}
+
+// HandleLLMError 记录失败的调用
+func (m *MetricsHandle) HandleLLMError(ctx context.Context, _ error) {
+	call, ok := ctx.Value(llmCallKey{}).(*llmCall)
+	if !ok {
+		return
+	}
+	m.observe(call, "error")
+}
+
+func (m *MetricsHandle) observe(call *llmCall, status string) {
+	metrics.LLMRequestsTotal.WithLabelValues(call.provider, call.model, status).Inc()
+	metrics.LLMRequestDuration.WithLabelValues(call.provider, call.model).Observe(time.Since(call.start).Seconds())
+}
@@ -0,0 +1,47 @@
+package callbackx
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"aiOffice/pkg/metrics"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/tmc/langchaingo/llms"
+)
+
+func counterValue(t *testing.T, c prometheus.Counter) float64 {
+	var m dto.Metric
+	if err := c.Write(&m); err != nil {
+		t.Fatal(err)
+	}
+	return m.GetCounter().GetValue()
+}
+
+func TestMetricsHandle(t *testing.T) {
+	h := NewMetricsHandler()
+	ctx := WithLLMCall(context.Background(), "qwen", "qwen3-max")
+
+	h.HandleLLMGenerateContentEnd(ctx, &llms.ContentResponse{Choices: []*llms.ContentChoice{
+		{GenerationInfo: map[string]any{"PromptTokens": 100, "CompletionTokens": 20}},
+	}})
+	h.HandleLLMError(ctx, errors.New("503"))
+
+	// 未标记的调用不计入
+	h.HandleLLMError(context.Background(), errors.New("503"))
+
+	if n := counterValue(t, metrics.LLMRequestsTotal.WithLabelValues("qwen", "qwen3-max", "ok")); n != 1 {
+		t.Errorf("ok requests = %v, want 1", n)
+	}
+	if n := counterValue(t, metrics.LLMRequestsTotal.WithLabelValues("qwen", "qwen3-max", "error")); n != 1 {
+		t.Errorf("error requests = %v, want 1", n)
+	}
+	if n := counterValue(t, metrics.LLMTokensTotal.WithLabelValues("qwen", "qwen3-max", "prompt")); n != 100 {
+		t.Errorf("prompt tokens = %v, want 100", n)
+	}
+	if n := counterValue(t, metrics.LLMTokensTotal.WithLabelValues("qwen", "qwen3-max", "completion")); n != 20 {
+		t.Errorf("completion tokens = %v, want 20", n)
+	}
+}
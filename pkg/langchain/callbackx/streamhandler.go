@@ -0,0 +1,84 @@
+package callbackx
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"sync"
+
+	"aiOffice/pkg/langchain"
+
+	"github.com/tmc/langchaingo/callbacks"
+)
+
+// MrklFinalKeyword mrkl agent最终回答的前缀
+const MrklFinalKeyword = "Final Answer:"
+
+// StreamHandle 将模型的流式输出转发给context中的输出目标（langchain.WithStream 设置的WS连接、SSE等），
+// 未设置输出目标时不做处理。作为agent的回调使用时过滤中间步骤：工具调用参数不转发，
+// 设置了keyword时（mrkl）只转发keyword之后的最终回答。保存了一次请求的输出状态，每次请求创建一个
+type StreamHandle struct {
+	callbacks.SimpleHandler
+	sync.Mutex
+	keyword string
+	buf     string // 检测到keyword前的输出
+	final   bool   // 已进入最终回答
+	err     error  // 输出目标返回的错误（如连接已断开），之后不再转发
+}
+
+// NewStreamHandler 创建流式输出处理器，keyword为空时转发全部文本
+func NewStreamHandler(keyword string) *StreamHandle {
+	return &StreamHandle{keyword: keyword}
+}
+
+// HandleStreamingFunc 转发一段输出
+func (s *StreamHandle) HandleStreamingFunc(ctx context.Context, chunk []byte) {
+	stream := langchain.GetStream(ctx)
+	if stream == nil || len(chunk) == 0 || isToolCall(chunk) {
+		return
+	}
+
+	s.Lock()
+	defer s.Unlock()
+	if s.err != nil {
+		return
+	}
+
+	text := string(chunk)
+	if s.keyword != "" && !s.final {
+		s.buf += text
+		i := strings.Index(s.buf, s.keyword)
+		if i < 0 {
+			// 只保留可能是keyword开头的部分
+			if n := len(s.buf) - len(s.keyword); n > 0 {
+				s.buf = s.buf[n:]
+			}
+			return
+		}
+		s.final = true
+		text = strings.TrimLeft(s.buf[i+len(s.keyword):], " ")
+		s.buf = ""
+		if text == "" {
+			return
+		}
+	}
+	s.err = stream(ctx, []byte(text))
+}
+
+// isToolCall function calling的流式输出中，工具调用是JSON编码的参数增量
+func isToolCall(chunk []byte) bool {
+	if chunk[0] != '[' && chunk[0] != '{' {
+		return false
+	}
+	var calls []struct {
+		Function json.RawMessage `json:"function"`
+	}
+	if json.Unmarshal(chunk, &calls) == nil {
+		return len(calls) > 0 && calls[0].Function != nil
+	}
+	var call struct {
+		Name      *string `json:"name"`
+		Arguments *string `json:"arguments"`
+	}
+	return json.Unmarshal(chunk, &call) == nil && call.Name != nil && call.Arguments != nil
+}
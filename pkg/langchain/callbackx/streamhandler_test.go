@@ -0,0 +1,56 @@
+package callbackx
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"aiOffice/pkg/langchain"
+)
+
+func TestStreamHandle(t *testing.T) {
+	var out string
+	ctx := langchain.WithStream(context.Background(), func(_ context.Context, chunk []byte) error {
+		out += string(chunk)
+		return nil
+	})
+
+	// function calling：工具调用参数不转发
+	h := NewStreamHandler("")
+	for _, chunk := range []string{
+		`[{"id":"call_1","type":"function","function":{"name":"todo_add","arguments":""}}]`,
+		`[{"function":{"arguments":"{\"title\""}}]`,
+		"", "已为您", "创建待办。",
+	} {
+		h.HandleStreamingFunc(ctx, []byte(chunk))
+	}
+	if out != "已为您创建待办。" {
+		t.Errorf("unexpected output: %q", out)
+	}
+
+	// mrkl：只转发最终回答
+	out = ""
+	h = NewStreamHandler(MrklFinalKeyword)
+	for _, chunk := range []string{"I should query.\nAction: todo_query", "\nThought: done\nFinal An", "swer: 您有", "2个待办"} {
+		h.HandleStreamingFunc(ctx, []byte(chunk))
+	}
+	if out != "您有2个待办" {
+		t.Errorf("unexpected output: %q", out)
+	}
+
+	// 输出目标出错后不再转发
+	calls := 0
+	ctx = langchain.WithStream(context.Background(), func(context.Context, []byte) error {
+		calls++
+		return errors.New("closed")
+	})
+	h = NewStreamHandler("")
+	h.HandleStreamingFunc(ctx, []byte("a"))
+	h.HandleStreamingFunc(ctx, []byte("b"))
+	if calls != 1 {
+		t.Errorf("expected 1 call, got %d", calls)
+	}
+
+	// 未设置输出目标时不处理
+	NewStreamHandler("").HandleStreamingFunc(context.Background(), []byte("a"))
+}
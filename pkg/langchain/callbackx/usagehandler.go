@@ -0,0 +1,51 @@
+package callbackx
+
+import (
+	"context"
+
+	"github.com/tmc/langchaingo/callbacks"
+	"github.com/tmc/langchaingo/llms"
+)
+
+// UsageRecorder 记录一次LLM调用消耗的token
+type UsageRecorder func(ctx context.Context, promptTokens, completionTokens int)
+
+// UsageHandle token用量处理器，从LLM响应中提取token数并交给recorder记录
+type UsageHandle struct {
+	callbacks.SimpleHandler
+	record UsageRecorder
+}
+
+// NewUsageHandler 创建token用量处理器
+func NewUsageHandler(record UsageRecorder) *UsageHandle {
+	return &UsageHandle{record: record}
+}
+
+// HandleLLMGenerateContentEnd 汇总本次调用所有choice的token数
+func (u *UsageHandle) HandleLLMGenerateContentEnd(ctx context.Context, res *llms.ContentResponse) {
+	if res == nil {
+		return
+	}
+
+	var prompt, completion int
+	for _, choice := range res.Choices {
+		prompt = max(prompt, intValue(choice.GenerationInfo["PromptTokens"]))
+		completion += intValue(choice.GenerationInfo["CompletionTokens"])
+	}
+	if prompt == 0 && completion == 0 {
+		return
+	}
+	u.record(ctx, prompt, completion)
+}
+
+func intValue(v any) int {
+	switch n := v.(type) {
+	case int:
+		return n
+	case int64:
+		return int(n)
+	case float64:
+		return int(n)
+	}
+	return 0
+}
@@ -7,3 +7,8 @@ type Handler interface {
 	Description() string  // 描述，让LLM理解这个处理器干什么
 	Chains() chains.Chain // 返回实际处理的Chain
 }
+
+// Ruler 可选实现，提供路由规则，router据此生成选择处理器的提示词
+type Ruler interface {
+	Rules() []string // 每条规则描述一类用户意图，例如"用户要创建待办、查询待办等"
+}
@@ -1,5 +1,11 @@
 package langchain
 
+import (
+	"context"
+	"sync"
+	"time"
+)
+
 const (
 	Input  = "input"        // 输入参数的键名，用于传递用户输入内容
 	Output = "text"         // 输出参数的键名，用于返回AI生成的文本内容
@@ -10,3 +16,93 @@ const (
 	DefaultHandler = iota
 	TodoHandler
 )
+
+// StreamFunc 流式输出回调，每生成一段内容调用一次
+type StreamFunc func(ctx context.Context, chunk []byte) error
+
+const streamKey = "llms.chat.stream" // 流式回调的上下文键名
+
+// WithStream 在context中设置流式回调，由router透传给最终处理的handler
+func WithStream(ctx context.Context, fn StreamFunc) context.Context {
+	return context.WithValue(ctx, streamKey, fn)
+}
+
+// GetStream 获取context中的流式回调，未设置时返回nil
+func GetStream(ctx context.Context) StreamFunc {
+	fn, _ := ctx.Value(streamKey).(StreamFunc)
+	return fn
+}
+
+// CallParams 单次请求指定的模型参数，为空的字段使用默认值
+type CallParams struct {
+	Model       string
+	Temperature *float64
+	MaxTokens   int
+}
+
+const callParamsKey = "llms.chat.params" // 模型参数的上下文键名
+
+// WithCallParams 在context中设置模型参数，router、agent内部的每次LLM调用都会生效
+func WithCallParams(ctx context.Context, params *CallParams) context.Context {
+	return context.WithValue(ctx, callParamsKey, params)
+}
+
+// GetCallParams 获取context中的模型参数，未设置时返回nil
+func GetCallParams(ctx context.Context) *CallParams {
+	params, _ := ctx.Value(callParamsKey).(*CallParams)
+	return params
+}
+
+// ToolCall 一次工具调用
+type ToolCall struct {
+	Name     string
+	Input    string
+	Output   string
+	Error    string
+	Duration time.Duration
+}
+
+// Trace 一轮AI对话的执行过程，router写入路由结果，agent写入工具调用
+type Trace struct {
+	sync.Mutex
+	Handler        string        // 最终使用的handler
+	RouterOutput   string        // 路由模型的原始输出
+	RouterDuration time.Duration // 路由耗时
+	ToolCalls      []ToolCall
+}
+
+const traceKey = "llms.chat.trace" // 执行过程的上下文键名
+
+// WithTrace 在context中设置执行过程记录
+func WithTrace(ctx context.Context) (context.Context, *Trace) {
+	t := &Trace{}
+	return context.WithValue(ctx, traceKey, t), t
+}
+
+// GetTrace 获取context中的执行过程记录，未设置时返回nil，nil上的方法调用不做任何事
+func GetTrace(ctx context.Context) *Trace {
+	t, _ := ctx.Value(traceKey).(*Trace)
+	return t
+}
+
+// SetRoute 记录路由结果，output为路由模型的原始输出，与handler不同时说明使用了default
+func (t *Trace) SetRoute(handler, output string, cost time.Duration) {
+	if t == nil {
+		return
+	}
+	t.Lock()
+	defer t.Unlock()
+	t.Handler = handler
+	t.RouterOutput = output
+	t.RouterDuration = cost
+}
+
+// AddToolCall 记录一次工具调用
+func (t *Trace) AddToolCall(call ToolCall) {
+	if t == nil {
+		return
+	}
+	t.Lock()
+	defer t.Unlock()
+	t.ToolCalls = append(t.ToolCalls, call)
+}
@@ -0,0 +1,177 @@
+package llmx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"aiOffice/pkg/langchain"
+	"aiOffice/pkg/langchain/callbackx"
+	"aiOffice/pkg/tracex"
+
+	"github.com/tmc/langchaingo/callbacks"
+	"github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/llms/openai"
+)
+
+var ErrNoProvider = errors.New("no llm provider")
+
+// Provider 已初始化的模型供应商
+type Provider struct {
+	Name           string
+	LLM            *openai.LLM
+	Embedding      *openai.LLM // 向量化客户端，未单独配置地址时与LLM相同
+	EmbeddingModel string
+	Timeout        time.Duration
+	Models         []string // 支持的模型，第一个为默认模型
+
+	model llms.Model        // 测试时替换
+	cb    callbacks.Handler // openai客户端不回调错误，由generate补充
+}
+
+// Supports 是否支持指定模型
+func (p *Provider) Supports(model string) bool {
+	for _, m := range p.Models {
+		if m == model {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *Provider) llm() llms.Model {
+	if p.model != nil {
+		return p.model
+	}
+	return p.LLM
+}
+
+var _ llms.Model = (*Fallback)(nil)
+
+// Fallback 按顺序调用供应商，失败或超时自动切换到下一个
+type Fallback struct {
+	providers []*Provider
+}
+
+func NewFallback(providers ...*Provider) *Fallback {
+	return &Fallback{providers: providers}
+}
+
+// Providers 返回供应商名称列表
+func (f *Fallback) Providers() []string {
+	names := make([]string, 0, len(f.providers))
+	for _, p := range f.providers {
+		names = append(names, p.Name)
+	}
+	return names
+}
+
+// Models 所有供应商支持的模型，作为请求指定模型的白名单
+func (f *Fallback) Models() []string {
+	var models []string
+	for _, p := range f.providers {
+		models = append(models, p.Models...)
+	}
+	return models
+}
+
+func (f *Fallback) GenerateContent(ctx context.Context, messages []llms.MessageContent, options ...llms.CallOption) (*llms.ContentResponse, error) {
+	// 请求指定的模型参数覆盖chain的默认值，agent内部的调用同样生效
+	options = append(options, callParamsOptions(ctx)...)
+
+	var opts llms.CallOptions
+	for _, opt := range options {
+		opt(&opts)
+	}
+
+	// 指定了模型时只使用支持该模型的供应商
+	providers := f.providers
+	if opts.Model != "" {
+		providers = nil
+		for _, p := range f.providers {
+			if p.Supports(opts.Model) {
+				providers = append(providers, p)
+			}
+		}
+	}
+	if len(providers) == 0 {
+		return nil, ErrNoProvider
+	}
+
+	// 已经向调用方推送过内容的不能再切换，否则会重复输出
+	streamed := false
+	if stream := opts.StreamingFunc; stream != nil {
+		options = append(options, llms.WithStreamingFunc(func(ctx context.Context, chunk []byte) error {
+			streamed = true
+			return stream(ctx, chunk)
+		}))
+	}
+
+	var errs []error
+	for _, p := range providers {
+		resp, err := p.generate(ctx, opts.Model, messages, options...)
+		if err == nil {
+			return resp, nil
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", p.Name, err))
+
+		// 调用方取消或已经开始输出，直接返回
+		if ctx.Err() != nil || streamed {
+			break
+		}
+		fmt.Printf("[LLM] %s 调用失败，切换下一个供应商: %v\n", p.Name, err)
+	}
+	return nil, errors.Join(errs...)
+}
+
+func (f *Fallback) Call(ctx context.Context, prompt string, options ...llms.CallOption) (string, error) {
+	return llms.GenerateFromSinglePrompt(ctx, f, prompt, options...)
+}
+
+func (p *Provider) generate(ctx context.Context, model string, messages []llms.MessageContent, options ...llms.CallOption) (*llms.ContentResponse, error) {
+	if p.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, p.Timeout)
+		defer cancel()
+	}
+
+	// 标记供应商和模型，供回调统计指标
+	if model == "" && len(p.Models) > 0 {
+		model = p.Models[0]
+	}
+	ctx = callbackx.WithLLMCall(ctx, p.Name, model)
+
+	ctx, span := tracex.Start(ctx, "llm.generate", tracex.KindClient)
+	defer span.End()
+	span.SetAttr("llm.provider", p.Name)
+	span.SetAttr("llm.model", model)
+
+	resp, err := p.llm().GenerateContent(ctx, messages, options...)
+	if err != nil {
+		span.SetError(err)
+		if p.cb != nil {
+			p.cb.HandleLLMError(ctx, err)
+		}
+	}
+	return resp, err
+}
+
+func callParamsOptions(ctx context.Context) []llms.CallOption {
+	params := langchain.GetCallParams(ctx)
+	if params == nil {
+		return nil
+	}
+
+	var opts []llms.CallOption
+	if params.Model != "" {
+		opts = append(opts, llms.WithModel(params.Model))
+	}
+	if params.Temperature != nil {
+		opts = append(opts, llms.WithTemperature(*params.Temperature))
+	}
+	if params.MaxTokens > 0 {
+		opts = append(opts, llms.WithMaxTokens(params.MaxTokens))
+	}
+	return opts
+}
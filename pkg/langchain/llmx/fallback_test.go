@@ -0,0 +1,67 @@
+package llmx
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"aiOffice/pkg/langchain"
+
+	"github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/llms/fake"
+)
+
+type errLLM struct {
+	err   error
+	delay time.Duration
+}
+
+func (e *errLLM) GenerateContent(ctx context.Context, _ []llms.MessageContent, _ ...llms.CallOption) (*llms.ContentResponse, error) {
+	select {
+	case <-time.After(e.delay):
+		return nil, e.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (e *errLLM) Call(ctx context.Context, prompt string, options ...llms.CallOption) (string, error) {
+	return llms.GenerateFromSinglePrompt(ctx, e, prompt, options...)
+}
+
+func TestFallback(t *testing.T) {
+	f := NewFallback(
+		&Provider{Name: "down", model: &errLLM{err: errors.New("503")}},
+		&Provider{Name: "slow", model: &errLLM{delay: time.Second}, Timeout: 10 * time.Millisecond},
+		&Provider{Name: "ok", model: fake.NewFakeLLM([]string{"hello"})},
+	)
+
+	out, err := f.Call(context.Background(), "hi")
+	if err != nil || out != "hello" {
+		t.Fatalf("should fallback to ok provider, got %q %v", out, err)
+	}
+
+	f = NewFallback(&Provider{Name: "down", model: &errLLM{err: errors.New("503")}})
+	if _, err := f.Call(context.Background(), "hi"); err == nil {
+		t.Error("all providers failed should return error")
+	}
+}
+
+func TestFallbackModel(t *testing.T) {
+	f := NewFallback(
+		&Provider{Name: "qwen", Models: []string{"qwen3-max"}, model: &errLLM{err: errors.New("unknown model")}},
+		&Provider{Name: "deepseek", Models: []string{"deepseek-chat"}, model: fake.NewFakeLLM([]string{"hello"})},
+	)
+
+	// 指定模型时跳过不支持的供应商
+	ctx := langchain.WithCallParams(context.Background(), &langchain.CallParams{Model: "deepseek-chat"})
+	if out, err := f.Call(ctx, "hi"); err != nil || out != "hello" {
+		t.Fatalf("should use deepseek, got %q %v", out, err)
+	}
+
+	ctx = langchain.WithCallParams(context.Background(), &langchain.CallParams{Model: "gpt-4o"})
+	if _, err := f.Call(ctx, "hi"); !errors.Is(err, ErrNoProvider) {
+		t.Errorf("unsupported model should fail, got %v", err)
+	}
+}
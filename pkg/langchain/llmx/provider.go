@@ -0,0 +1,116 @@
+package llmx
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/tmc/langchaingo/callbacks"
+	"github.com/tmc/langchaingo/llms/openai"
+)
+
+// 支持的模型供应商，除azure外都走OpenAI兼容接口
+const (
+	TypeQwen     = "qwen"
+	TypeOpenAI   = "openai"
+	TypeDeepSeek = "deepseek"
+	TypeAzure    = "azure"
+	TypeOllama   = "ollama" // 本地部署
+	TypeVLLM     = "vllm"   // 本地部署，模型为启动时的 --served-model-name，需在配置中指定
+)
+
+// 各供应商默认的接口地址和模型
+var defaults = map[string]struct{ url, model, embedding string }{
+	TypeQwen:     {"https://dashscope.aliyuncs.com/compatible-mode/v1", "qwen3-max", "text-embedding-v3"},
+	TypeOpenAI:   {"https://api.openai.com/v1", "gpt-4o-mini", "text-embedding-3-small"},
+	TypeDeepSeek: {"https://api.deepseek.com/v1", "deepseek-chat", ""},
+	TypeOllama:   {"http://localhost:11434/v1", "qwen2.5:7b", "nomic-embed-text"},
+	TypeVLLM:     {"http://localhost:8000/v1", "", ""},
+}
+
+// 本地服务不校验密钥，openai客户端要求非空
+const localApiKey = "local"
+
+// ProviderConf 单个模型供应商的配置
+type ProviderConf struct {
+	Name           string
+	Type           string // qwen/openai/deepseek/azure
+	Url            string // 为空时使用默认地址，azure为资源endpoint
+	ApiKey         string
+	Model          string   // azure为部署名称
+	Models         []string // 同一供应商下允许按请求切换的其他模型
+	EmbeddingModel string
+	EmbeddingUrl   string        // 向量化接口地址，为空时与Url相同；本地部署时常由单独的服务提供
+	ApiVersion     string        // 仅azure
+	Timeout        time.Duration // 单次调用超时，0为不限制
+}
+
+// NewProvider 根据配置创建模型供应商
+func NewProvider(conf ProviderConf, cb callbacks.Handler) (*Provider, error) {
+	if conf.Name == "" {
+		conf.Name = conf.Type
+	}
+
+	d, ok := defaults[conf.Type]
+	if !ok && conf.Type != TypeAzure {
+		return nil, fmt.Errorf("unsupported llm provider type %q", conf.Type)
+	}
+	if conf.Url == "" {
+		conf.Url = d.url
+	}
+	if conf.Model == "" {
+		conf.Model = d.model
+	}
+	if conf.EmbeddingModel == "" {
+		conf.EmbeddingModel = d.embedding
+	}
+	if conf.Model == "" {
+		return nil, fmt.Errorf("llm provider %s: model is required", conf.Name)
+	}
+	if conf.ApiKey == "" && (conf.Type == TypeOllama || conf.Type == TypeVLLM) {
+		conf.ApiKey = localApiKey
+	}
+
+	opts := []openai.Option{
+		openai.WithBaseURL(conf.Url),
+		openai.WithToken(conf.ApiKey),
+		openai.WithModel(conf.Model),
+	}
+	if conf.EmbeddingModel != "" {
+		opts = append(opts, openai.WithEmbeddingModel(conf.EmbeddingModel))
+	}
+	if conf.Type == TypeAzure {
+		opts = append(opts, openai.WithAPIType(openai.APITypeAzure), openai.WithAPIVersion(conf.ApiVersion))
+	}
+	if cb != nil {
+		opts = append(opts, openai.WithCallback(cb))
+	}
+
+	llm, err := openai.New(opts...)
+	if err != nil {
+		return nil, fmt.Errorf("init llm provider %s: %w", conf.Name, err)
+	}
+
+	// 向量化接口地址不同时单独创建客户端
+	embedding := llm
+	if conf.EmbeddingUrl != "" && conf.EmbeddingUrl != conf.Url {
+		embedding, err = openai.New(
+			openai.WithBaseURL(conf.EmbeddingUrl),
+			openai.WithToken(conf.ApiKey),
+			openai.WithModel(conf.Model),
+			openai.WithEmbeddingModel(conf.EmbeddingModel),
+		)
+		if err != nil {
+			return nil, fmt.Errorf("init embedding client %s: %w", conf.Name, err)
+		}
+	}
+
+	return &Provider{
+		Name:           conf.Name,
+		LLM:            llm,
+		Embedding:      embedding,
+		EmbeddingModel: conf.EmbeddingModel,
+		Timeout:        conf.Timeout,
+		Models:         append([]string{conf.Model}, conf.Models...),
+		cb:             cb,
+	}, nil
+}
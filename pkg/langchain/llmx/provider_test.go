@@ -0,0 +1,34 @@
+package llmx
+
+import "testing"
+
+func TestNewLocalProvider(t *testing.T) {
+	p, err := NewProvider(ProviderConf{Type: TypeOllama}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Name != TypeOllama || p.Models[0] != "qwen2.5:7b" || p.EmbeddingModel != "nomic-embed-text" {
+		t.Fatalf("unexpected ollama defaults: %+v", p)
+	}
+	if p.Embedding != p.LLM {
+		t.Error("embedding should share the chat client without EmbeddingUrl")
+	}
+
+	// vLLM 没有默认模型
+	if _, err := NewProvider(ProviderConf{Type: TypeVLLM}, nil); err == nil {
+		t.Error("vllm without model should fail")
+	}
+
+	p, err = NewProvider(ProviderConf{
+		Type:           TypeVLLM,
+		Model:          "Qwen2.5-7B-Instruct",
+		EmbeddingUrl:   "http://localhost:11434/v1",
+		EmbeddingModel: "bge-m3",
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Embedding == p.LLM {
+		t.Error("embedding should use a separate client with EmbeddingUrl")
+	}
+}
@@ -0,0 +1,59 @@
+package memoryx
+
+import (
+	"context"
+
+	"github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/schema"
+)
+
+// HistoryStore 对话历史的持久化存储（Mongo、Redis等）
+type HistoryStore interface {
+	Append(ctx context.Context, sessionId string, msgs ...llms.ChatMessage) error
+	List(ctx context.Context, sessionId string, limit int) ([]llms.ChatMessage, error)
+	Clear(ctx context.Context, sessionId string) error
+}
+
+var _ schema.ChatMessageHistory = (*History)(nil)
+
+// History 基于HistoryStore的对话历史，不在进程内缓存，多实例共享同一份上下文
+type History struct {
+	store     HistoryStore
+	sessionId string
+	limit     int // 加载最近的消息条数，<=0 不限制
+}
+
+func NewHistory(store HistoryStore, sessionId string, limit int) *History {
+	return &History{
+		store:     store,
+		sessionId: sessionId,
+		limit:     limit,
+	}
+}
+
+func (h *History) AddMessage(ctx context.Context, message llms.ChatMessage) error {
+	return h.store.Append(ctx, h.sessionId, message)
+}
+
+func (h *History) AddUserMessage(ctx context.Context, message string) error {
+	return h.AddMessage(ctx, llms.HumanChatMessage{Content: message})
+}
+
+func (h *History) AddAIMessage(ctx context.Context, message string) error {
+	return h.AddMessage(ctx, llms.AIChatMessage{Content: message})
+}
+
+func (h *History) Clear(ctx context.Context) error {
+	return h.store.Clear(ctx, h.sessionId)
+}
+
+func (h *History) Messages(ctx context.Context) ([]llms.ChatMessage, error) {
+	return h.store.List(ctx, h.sessionId, h.limit)
+}
+
+func (h *History) SetMessages(ctx context.Context, messages []llms.ChatMessage) error {
+	if err := h.store.Clear(ctx, h.sessionId); err != nil {
+		return err
+	}
+	return h.store.Append(ctx, h.sessionId, messages...)
+}
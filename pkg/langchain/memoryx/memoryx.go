@@ -4,16 +4,19 @@ import (
 	"container/list"
 	"context"
 	"sync"
+	"time"
 
 	"aiOffice/pkg/langchain"
 
+	"github.com/tmc/langchaingo/memory"
 	"github.com/tmc/langchaingo/schema"
 )
 
 // lruEntry LRU缓存条目
 type lruEntry struct {
-	chatId string
-	memory schema.Memory
+	chatId   string
+	memory   schema.Memory
+	lastUsed time.Time
 }
 
 type Memoryx struct {
@@ -21,7 +24,8 @@ type Memoryx struct {
 	memorys       map[string]*list.Element // chatId -> list.Element
 	lruList       *list.List               // LRU双向链表，最近使用的在前面
 	maxSize       int                      // 最大会话数量
-	createMemory  func() schema.Memory
+	idleTTL       time.Duration            // 会话空闲超过该时间后释放，0为不过期
+	createMemory  func(chatId string) schema.Memory
 	defaultMemory schema.Memory
 }
 
@@ -37,13 +41,34 @@ func WithMaxSize(size int) MemoryxOption {
 	}
 }
 
+// WithIdleTTL 设置会话的空闲过期时间，过期的会话在下次访问时重建，并由 RunSweeper 定时释放
+func WithIdleTTL(ttl time.Duration) MemoryxOption {
+	return func(m *Memoryx) {
+		if ttl > 0 {
+			m.idleTTL = ttl
+		}
+	}
+}
+
 func NewMemoryx(createFunc func() schema.Memory, opts ...MemoryxOption) *Memoryx {
+	return newMemoryx(func(string) schema.Memory {
+		return createFunc()
+	}, createFunc(), opts...)
+}
+
+// NewMemoryxByChatId 按会话ID创建内存，用于对接持久化的对话历史
+// 未携带会话ID的请求使用进程内的默认内存，不落库
+func NewMemoryxByChatId(createFunc func(chatId string) schema.Memory, opts ...MemoryxOption) *Memoryx {
+	return newMemoryx(createFunc, memory.NewConversationBuffer(), opts...)
+}
+
+func newMemoryx(createFunc func(chatId string) schema.Memory, defaultMemory schema.Memory, opts ...MemoryxOption) *Memoryx {
 	m := &Memoryx{
 		memorys:       make(map[string]*list.Element),
 		lruList:       list.New(),
 		maxSize:       100, // 默认最多100个会话
 		createMemory:  createFunc,
-		defaultMemory: createFunc(),
+		defaultMemory: defaultMemory,
 	}
 
 	for _, opt := range opts {
@@ -63,15 +88,23 @@ func (m *Memoryx) GetMemory(chatId string) schema.Memory {
 
 // getOrCreate 获取或创建会话内存（内部方法，需要在锁内调用）
 func (m *Memoryx) getOrCreate(chatId string) schema.Memory {
+	now := time.Now()
 	if elem, ok := m.memorys[chatId]; ok {
-		// 存在则移到链表头部（最近使用）
-		m.lruList.MoveToFront(elem)
-		return elem.Value.(*lruEntry).memory
+		entry := elem.Value.(*lruEntry)
+		if !m.expired(entry, now) {
+			// 存在则移到链表头部（最近使用）
+			entry.lastUsed = now
+			m.lruList.MoveToFront(elem)
+			return entry.memory
+		}
+		// 已过期则丢弃后重建
+		delete(m.memorys, chatId)
+		m.lruList.Remove(elem)
 	}
 
 	// 不存在则创建新的
-	mem := m.createMemory()
-	entry := &lruEntry{chatId: chatId, memory: mem}
+	mem := m.createMemory(chatId)
+	entry := &lruEntry{chatId: chatId, memory: mem, lastUsed: now}
 	elem := m.lruList.PushFront(entry)
 	m.memorys[chatId] = elem
 
@@ -95,6 +128,49 @@ func (m *Memoryx) evictIfNeeded() {
 	}
 }
 
+// expired 会话是否空闲超时
+func (m *Memoryx) expired(entry *lruEntry, now time.Time) bool {
+	return m.idleTTL > 0 && now.Sub(entry.lastUsed) > m.idleTTL
+}
+
+// Sweep 释放空闲超时的会话，返回释放的数量
+func (m *Memoryx) Sweep() int {
+	m.Lock()
+	defer m.Unlock()
+
+	// 链表按最近使用排序，从尾部开始遇到未过期的即可停止
+	now := time.Now()
+	n := 0
+	for oldest := m.lruList.Back(); oldest != nil; oldest = m.lruList.Back() {
+		entry := oldest.Value.(*lruEntry)
+		if !m.expired(entry, now) {
+			break
+		}
+		delete(m.memorys, entry.chatId)
+		m.lruList.Remove(oldest)
+		n++
+	}
+	return n
+}
+
+// RunSweeper 定时释放空闲超时的会话，ctx取消后退出；未设置过期时间时直接返回
+func (m *Memoryx) RunSweeper(ctx context.Context, interval time.Duration) {
+	if m.idleTTL <= 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			m.Sweep()
+		}
+	}
+}
+
 // GetMemoryKey 获取当前会话的内存键名
 func (s *Memoryx) GetMemoryKey(ctx context.Context) string {
 	return s.memory(ctx).GetMemoryKey(ctx)
@@ -2,8 +2,14 @@ package memoryx
 
 import (
 	"context"
+	"strings"
 	"testing"
+	"time"
 
+	"aiOffice/pkg/langchain"
+
+	"github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/llms/fake"
 	"github.com/tmc/langchaingo/memory"
 	"github.com/tmc/langchaingo/schema"
 )
@@ -106,6 +112,31 @@ func TestMemoryxRemove(t *testing.T) {
 	}
 }
 
+func TestMemoryxIdleTTL(t *testing.T) {
+	m := NewMemoryx(func() schema.Memory {
+		return memory.NewConversationBuffer()
+	}, WithMaxSize(10), WithIdleTTL(50*time.Millisecond))
+
+	old := m.GetMemory("chat1")
+	m.GetMemory("chat2")
+	time.Sleep(60 * time.Millisecond)
+	m.GetMemory("chat2") // 访问后重新计时
+
+	if n := m.Sweep(); n != 1 {
+		t.Errorf("expected 1 swept, got %d", n)
+	}
+	if m.Size() != 1 {
+		t.Errorf("expected size 1 after sweep, got %d", m.Size())
+	}
+
+	// 过期未清理的会话在访问时重建
+	time.Sleep(60 * time.Millisecond)
+	m.GetMemory("chat1")
+	if m.GetMemory("chat1") == old {
+		t.Error("chat1 should have been recreated")
+	}
+}
+
 func TestMemoryxContextAccess(t *testing.T) {
 	m := NewMemoryx(func() schema.Memory {
 		return memory.NewConversationBuffer()
@@ -125,3 +156,71 @@ func TestMemoryxContextAccess(t *testing.T) {
 		t.Errorf("default memory should not be counted, got size %d", m.Size())
 	}
 }
+
+type mapStore map[string][]llms.ChatMessage
+
+func (s mapStore) Append(_ context.Context, sessionId string, msgs ...llms.ChatMessage) error {
+	s[sessionId] = append(s[sessionId], msgs...)
+	return nil
+}
+
+func (s mapStore) List(_ context.Context, sessionId string, limit int) ([]llms.ChatMessage, error) {
+	msgs := s[sessionId]
+	if limit > 0 && len(msgs) > limit {
+		msgs = msgs[len(msgs)-limit:]
+	}
+	return msgs, nil
+}
+
+func (s mapStore) Clear(_ context.Context, sessionId string) error {
+	delete(s, sessionId)
+	return nil
+}
+
+func TestMemoryxByChatId(t *testing.T) {
+	store := mapStore{}
+	create := func(chatId string) schema.Memory {
+		return memory.NewConversationBuffer(memory.WithChatHistory(NewHistory(store, chatId, 0)))
+	}
+
+	ctx := context.WithValue(context.Background(), langchain.ChatId, "u1")
+	m := NewMemoryxByChatId(create)
+	if err := m.SaveContext(ctx, map[string]any{"input": "你好"}, map[string]any{"text": "你好，有什么可以帮你"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// 模拟重启：新的Memoryx从存储中恢复上下文
+	m = NewMemoryxByChatId(create)
+	vars, err := m.LoadMemoryVariables(ctx, map[string]any{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if history, _ := vars["history"].(string); !strings.Contains(history, "有什么可以帮你") {
+		t.Errorf("history should survive restart, got %q", history)
+	}
+	if len(store["u2"]) != 0 {
+		t.Error("sessions should be isolated")
+	}
+}
+
+func TestSummaryBuffer(t *testing.T) {
+	ctx := context.Background()
+	m := NewSummaryBuffer(fake.NewFakeLLM([]string{"用户在咨询请假流程"}), 20)
+
+	for i := 0; i < 3; i++ {
+		if err := m.SaveContext(ctx, map[string]any{"input": "请假怎么申请"}, map[string]any{"text": "在审批里提交请假单"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	msgs, err := m.ChatHistory.Messages(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(msgs) == 0 || msgs[0].GetType() != llms.ChatMessageTypeSystem || msgs[0].GetContent() != "用户在咨询请假流程" {
+		t.Fatalf("older messages should be summarized, got %v", msgs)
+	}
+	if estimateTokens(msgs[1:]) > 10 {
+		t.Errorf("recent messages should fit in half of the limit, got %v", msgs[1:])
+	}
+}
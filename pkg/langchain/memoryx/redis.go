@@ -0,0 +1,97 @@
+package memoryx
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/tmc/langchaingo/llms"
+)
+
+var _ HistoryStore = (*RedisStore)(nil)
+
+// RedisStore 基于Redis列表的对话历史，每个会话一个键，多实例共享、重启不丢失；
+// 每次写入刷新过期时间，长时间无对话的会话自动清理
+type RedisStore struct {
+	client redis.UniversalClient
+	prefix string
+	ttl    time.Duration // <=0 不过期
+}
+
+func NewRedisStore(client redis.UniversalClient, prefix string, ttl time.Duration) *RedisStore {
+	return &RedisStore{
+		client: client,
+		prefix: prefix,
+		ttl:    ttl,
+	}
+}
+
+// redisMessage 列表中保存的消息
+type redisMessage struct {
+	Type    string `json:"type"`
+	Content string `json:"content"`
+}
+
+func (s *RedisStore) Append(ctx context.Context, sessionId string, msgs ...llms.ChatMessage) error {
+	if len(msgs) == 0 {
+		return nil
+	}
+
+	values := make([]any, 0, len(msgs))
+	for _, msg := range msgs {
+		b, err := json.Marshal(&redisMessage{Type: string(msg.GetType()), Content: msg.GetContent()})
+		if err != nil {
+			return err
+		}
+		values = append(values, b)
+	}
+
+	key := s.prefix + sessionId
+	pipe := s.client.TxPipeline()
+	pipe.RPush(ctx, key, values...)
+	if s.ttl > 0 {
+		pipe.Expire(ctx, key, s.ttl)
+	}
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// List 返回最近的limit条消息（按时间正序），limit<=0时返回全部
+func (s *RedisStore) List(ctx context.Context, sessionId string, limit int) ([]llms.ChatMessage, error) {
+	start := int64(0)
+	if limit > 0 {
+		start = -int64(limit)
+	}
+	values, err := s.client.LRange(ctx, s.prefix+sessionId, start, -1).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	msgs := make([]llms.ChatMessage, 0, len(values))
+	for _, v := range values {
+		var m redisMessage
+		if err := json.Unmarshal([]byte(v), &m); err != nil {
+			return nil, err
+		}
+		msgs = append(msgs, chatMessage(m.Type, m.Content))
+	}
+	return msgs, nil
+}
+
+func (s *RedisStore) Clear(ctx context.Context, sessionId string) error {
+	return s.client.Del(ctx, s.prefix+sessionId).Err()
+}
+
+func chatMessage(typ, content string) llms.ChatMessage {
+	switch llms.ChatMessageType(typ) {
+	case llms.ChatMessageTypeAI:
+		return llms.AIChatMessage{Content: content}
+	case llms.ChatMessageTypeSystem:
+		return llms.SystemChatMessage{Content: content}
+	case llms.ChatMessageTypeHuman:
+		return llms.HumanChatMessage{Content: content}
+	default:
+		return llms.GenericChatMessage{Role: typ, Content: content}
+	}
+}
@@ -0,0 +1,113 @@
+package memoryx
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/memory"
+	"github.com/tmc/langchaingo/schema"
+)
+
+const _summaryPrompt = `请将以下对话内容逐步总结为一段简洁的摘要，保留用户的关键信息、诉求和已确认的结论。
+
+已有摘要:
+%s
+
+新的对话:
+%s
+
+新的摘要:`
+
+var _ schema.Memory = (*SummaryBuffer)(nil)
+
+// SummaryBuffer 摘要记忆：对话超过 maxTokens 时，用LLM把较早的消息压缩为一条摘要，只保留最近的原文
+type SummaryBuffer struct {
+	*memory.ConversationBuffer
+	llm       llms.Model
+	maxTokens int
+}
+
+func NewSummaryBuffer(llm llms.Model, maxTokens int, opts ...memory.ConversationBufferOption) *SummaryBuffer {
+	if maxTokens <= 0 {
+		maxTokens = 2000
+	}
+	return &SummaryBuffer{
+		ConversationBuffer: memory.NewConversationBuffer(opts...),
+		llm:                llm,
+		maxTokens:          maxTokens,
+	}
+}
+
+// SaveContext 保存本轮对话，超出上限时压缩历史
+func (s *SummaryBuffer) SaveContext(ctx context.Context, inputs map[string]any, outputs map[string]any) error {
+	if err := s.ConversationBuffer.SaveContext(ctx, inputs, outputs); err != nil {
+		return err
+	}
+
+	msgs, err := s.ChatHistory.Messages(ctx)
+	if err != nil {
+		return err
+	}
+	if estimateTokens(msgs) <= s.maxTokens {
+		return nil
+	}
+
+	// 从最新的消息往前保留，直到占用一半的额度，其余的压缩为摘要
+	keep, used := len(msgs), 0
+	for keep > 0 {
+		n := estimateTokens(msgs[keep-1 : keep])
+		if used+n > s.maxTokens/2 {
+			break
+		}
+		used += n
+		keep--
+	}
+	if keep == 0 {
+		return nil
+	}
+
+	summary, err := s.summarize(ctx, msgs[:keep])
+	if err != nil {
+		// 摘要失败不影响本轮对话，下次保存时重试
+		fmt.Printf("[Memoryx] 生成对话摘要失败: %v\n", err)
+		return nil
+	}
+
+	return s.ChatHistory.SetMessages(ctx, append([]llms.ChatMessage{
+		llms.SystemChatMessage{Content: summary},
+	}, msgs[keep:]...))
+}
+
+// summarize 将已有摘要（system消息）与新的对话合并生成新摘要
+func (s *SummaryBuffer) summarize(ctx context.Context, msgs []llms.ChatMessage) (string, error) {
+	var (
+		summary  []string
+		dialogue []llms.ChatMessage
+	)
+	for _, m := range msgs {
+		if m.GetType() == llms.ChatMessageTypeSystem {
+			summary = append(summary, m.GetContent())
+			continue
+		}
+		dialogue = append(dialogue, m)
+	}
+
+	text, err := llms.GetBufferString(dialogue, s.HumanPrefix, s.AIPrefix)
+	if err != nil {
+		return "", err
+	}
+
+	prompt := fmt.Sprintf(_summaryPrompt, strings.Join(summary, "\n"), text)
+	return llms.GenerateFromSinglePrompt(ctx, s.llm, prompt)
+}
+
+// estimateTokens 粗略估算token数，中文约一字一token，避免依赖在线分词表
+func estimateTokens(msgs []llms.ChatMessage) int {
+	n := 0
+	for _, m := range msgs {
+		n += len([]rune(m.GetContent()))
+	}
+	return n
+}
@@ -0,0 +1,180 @@
+package moderation
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"aiOffice/pkg/langchain/promptx"
+
+	"github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/prompts"
+)
+
+// 审核阶段
+const (
+	StageInput  = "input"  // 用户输入
+	StageOutput = "output" // 模型回复
+)
+
+// 命中后的处理方式
+const (
+	ActionBlock = "block" // 拦截
+	ActionFlag  = "flag"  // 放行并记录
+)
+
+// Result 审核命中结果
+type Result struct {
+	Stage   string
+	Action  string
+	Checker string // 命中的审核方式
+	Reason  string
+}
+
+// Blocked 是否需要拦截
+func (r *Result) Blocked() bool {
+	return r != nil && r.Action == ActionBlock
+}
+
+// Checker 审核方式，hit为true表示内容不合规
+type Checker interface {
+	Name() string
+	Check(ctx context.Context, text string) (reason string, hit bool, err error)
+}
+
+// Auditor 记录命中的内容
+type Auditor func(ctx context.Context, text string, r *Result)
+
+// Moderator 依次执行各审核方式，命中第一个即返回
+type Moderator struct {
+	checkers []Checker
+	action   string
+	audit    Auditor
+}
+
+func New(action string, audit Auditor, checkers ...Checker) *Moderator {
+	if action != ActionFlag {
+		action = ActionBlock
+	}
+	return &Moderator{
+		checkers: checkers,
+		action:   action,
+		audit:    audit,
+	}
+}
+
+// Check 审核内容，通过时返回nil；审核方式出错时跳过，审核服务不可用不影响正常对话
+func (m *Moderator) Check(ctx context.Context, stage, text string) *Result {
+	if m == nil || strings.TrimSpace(text) == "" {
+		return nil
+	}
+
+	for _, c := range m.checkers {
+		reason, hit, err := c.Check(ctx, text)
+		if err != nil {
+			fmt.Printf("[Moderation] %s 审核失败: %v\n", c.Name(), err)
+			continue
+		}
+		if !hit {
+			continue
+		}
+
+		r := &Result{
+			Stage:   stage,
+			Action:  m.action,
+			Checker: c.Name(),
+			Reason:  reason,
+		}
+		if m.audit != nil {
+			m.audit(ctx, text, r)
+		}
+		return r
+	}
+	return nil
+}
+
+// Keywords 敏感词审核，不区分大小写
+type Keywords struct {
+	words []string
+}
+
+func NewKeywords(words ...string) *Keywords {
+	k := &Keywords{words: make([]string, 0, len(words))}
+	for _, w := range words {
+		if w = strings.ToLower(strings.TrimSpace(w)); w != "" {
+			k.words = append(k.words, w)
+		}
+	}
+	return k
+}
+
+func (k *Keywords) Name() string {
+	return "keywords"
+}
+
+func (k *Keywords) Check(_ context.Context, text string) (string, bool, error) {
+	text = strings.ToLower(text)
+	for _, w := range k.words {
+		if strings.Contains(text, w) {
+			return "命中敏感词: " + w, true, nil
+		}
+	}
+	return "", false, nil
+}
+
+const _defaultModerationPrompt = `你是内容安全审核员，判断下面的内容是否包含违法、暴力、色情、歧视、辱骂或泄露他人隐私等不当信息。
+如果内容安全，只回复 SAFE；否则回复 UNSAFE: 原因。
+
+内容：
+{{.text}}`
+
+// Model 使用大模型审核，提示词可通过promptx的moderation覆盖
+type Model struct {
+	llm     llms.Model
+	prompts *promptx.Store
+}
+
+func NewModel(llm llms.Model, store *promptx.Store) *Model {
+	return &Model{
+		llm:     llm,
+		prompts: store,
+	}
+}
+
+func (m *Model) Name() string {
+	return "model"
+}
+
+func (m *Model) Check(ctx context.Context, text string) (string, bool, error) {
+	prompt, err := prompts.NewPromptTemplate(
+		m.prompts.Get(ctx, promptx.KeyModeration, _defaultModerationPrompt),
+		[]string{"text"},
+	).Format(map[string]any{"text": text})
+	if err != nil {
+		return "", false, err
+	}
+
+	out, err := llms.GenerateFromSinglePrompt(ctx, m.llm, prompt, llms.WithTemperature(0))
+	if err != nil {
+		return "", false, err
+	}
+	return parseVerdict(out)
+}
+
+// parseVerdict 解析审核模型的输出
+func parseVerdict(out string) (string, bool, error) {
+	out = strings.TrimSpace(out)
+	upper := strings.ToUpper(out)
+	switch {
+	case strings.HasPrefix(upper, "UNSAFE"):
+		reason := strings.TrimSpace(strings.TrimLeft(out[len("UNSAFE"):], ":： "))
+		if reason == "" {
+			reason = "模型判定不合规"
+		}
+		return reason, true, nil
+	case strings.HasPrefix(upper, "SAFE"):
+		return "", false, nil
+	default:
+		return "", false, fmt.Errorf("unexpected verdict: %q", out)
+	}
+}
@@ -0,0 +1,60 @@
+package moderation
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/tmc/langchaingo/llms/fake"
+)
+
+type errChecker struct{}
+
+func (errChecker) Name() string { return "err" }
+
+func (errChecker) Check(context.Context, string) (string, bool, error) {
+	return "", false, errors.New("unavailable")
+}
+
+func TestModerator(t *testing.T) {
+	var audited []*Result
+	m := New("", func(_ context.Context, _ string, r *Result) {
+		audited = append(audited, r)
+	}, errChecker{}, NewKeywords("Secret", " "))
+
+	if r := m.Check(context.Background(), StageInput, "今天的待办"); r != nil {
+		t.Fatalf("should pass, got %+v", r)
+	}
+
+	r := m.Check(context.Background(), StageOutput, "the SECRET is 42")
+	if !r.Blocked() || r.Checker != "keywords" || r.Stage != StageOutput {
+		t.Fatalf("should block by keywords, got %+v", r)
+	}
+	if len(audited) != 1 {
+		t.Fatalf("should audit once, got %d", len(audited))
+	}
+
+	m = New(ActionFlag, nil, NewKeywords("secret"))
+	if r := m.Check(context.Background(), StageInput, "secret"); r == nil || r.Blocked() {
+		t.Fatalf("flag should not block, got %+v", r)
+	}
+
+	var nilModerator *Moderator
+	if r := nilModerator.Check(context.Background(), StageInput, "secret"); r != nil {
+		t.Fatal("nil moderator should pass")
+	}
+}
+
+func TestModel(t *testing.T) {
+	m := NewModel(fake.NewFakeLLM([]string{"SAFE", "UNSAFE：包含辱骂", "不确定"}), nil)
+
+	if _, hit, err := m.Check(context.Background(), "你好"); hit || err != nil {
+		t.Fatalf("should be safe, got %v %v", hit, err)
+	}
+	if reason, hit, err := m.Check(context.Background(), "..."); !hit || err != nil || reason != "包含辱骂" {
+		t.Fatalf("should be unsafe, got %q %v %v", reason, hit, err)
+	}
+	if _, _, err := m.Check(context.Background(), "..."); err == nil {
+		t.Fatal("unexpected verdict should return error")
+	}
+}
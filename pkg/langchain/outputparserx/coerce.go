@@ -0,0 +1,158 @@
+package outputparserx
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// FieldError 单个字段的解析错误
+type FieldError struct {
+	Field   string
+	Message string
+}
+
+// ParseError 字段校验失败，包含全部出错字段，反馈给模型修正时可一次改完
+type ParseError struct {
+	Fields []FieldError
+}
+
+func (e *ParseError) Error() string {
+	msgs := make([]string, 0, len(e.Fields))
+	for _, f := range e.Fields {
+		msgs = append(msgs, f.Field+": "+f.Message)
+	}
+	return "invalid fields: " + strings.Join(msgs, "; ")
+}
+
+// coerce 按声明的类型转换并校验字段，结果保持JSON解码的表示（数值为float64、数组为[]any），
+// 与草稿等经过JSON序列化的数据一致；值为null或空字符串视为未填写，不做转换
+func coerce(parsed map[string]any, schemas []ResponseSchema, prefix string, errs *[]FieldError) {
+	for _, rs := range schemas {
+		field := prefix + rs.Name
+		v, ok := parsed[rs.Name]
+		if !ok {
+			if rs.Require {
+				*errs = append(*errs, FieldError{Field: field, Message: "is required"})
+			}
+			continue
+		}
+		if v == nil || v == "" {
+			continue
+		}
+		if v, ok := coerceField(v, rs, field, errs); ok {
+			parsed[rs.Name] = v
+		}
+	}
+}
+
+// coerceField 转换单个值，嵌套对象和数组元素递归校验，出错时记录错误并返回false
+func coerceField(v any, rs ResponseSchema, field string, errs *[]FieldError) (any, bool) {
+	n := len(*errs)
+	switch {
+	case len(rs.Schemas) > 0:
+		obj, ok := v.(map[string]any)
+		if !ok {
+			*errs = append(*errs, FieldError{Field: field, Message: fmt.Sprintf("expected object, got %T", v)})
+			return nil, false
+		}
+		coerce(obj, rs.Schemas, field+".", errs)
+		return obj, len(*errs) == n
+	case rs.Items != nil:
+		items, ok := v.([]any)
+		if !ok {
+			*errs = append(*errs, FieldError{Field: field, Message: fmt.Sprintf("expected array, got %T", v)})
+			return nil, false
+		}
+		for i, item := range items {
+			if item, ok := coerceField(item, *rs.Items, fmt.Sprintf("%s[%d]", field, i), errs); ok {
+				items[i] = item
+			}
+		}
+		return items, len(*errs) == n
+	}
+
+	v, err := coerceValue(v, rs.Type)
+	if err != nil {
+		*errs = append(*errs, FieldError{Field: field, Message: err.Error()})
+		return nil, false
+	}
+	if len(rs.Enum) > 0 && !slices.Contains(rs.Enum, enumKey(v)) {
+		*errs = append(*errs, FieldError{Field: field, Message: fmt.Sprintf("must be one of %v", rs.Enum)})
+		return nil, false
+	}
+	return v, true
+}
+
+func coerceValue(v any, typ string) (any, error) {
+	switch typ {
+	case "", "string":
+		switch v := v.(type) {
+		case string:
+			return v, nil
+		case float64, bool:
+			return enumKey(v), nil
+		}
+	case "int", "int64":
+		switch v := v.(type) {
+		case float64:
+			if v == math.Trunc(v) {
+				return v, nil
+			}
+			return nil, fmt.Errorf("expected integer, got %v", v)
+		case string:
+			n, err := strconv.ParseInt(strings.TrimSpace(v), 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("expected integer, got %q", v)
+			}
+			return float64(n), nil
+		}
+	case "float", "float64", "number":
+		switch v := v.(type) {
+		case float64:
+			return v, nil
+		case string:
+			f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+			if err != nil {
+				return nil, fmt.Errorf("expected number, got %q", v)
+			}
+			return f, nil
+		}
+	case "bool":
+		switch v := v.(type) {
+		case bool:
+			return v, nil
+		case string:
+			b, err := strconv.ParseBool(strings.TrimSpace(v))
+			if err != nil {
+				return nil, fmt.Errorf("expected bool, got %q", v)
+			}
+			return b, nil
+		}
+	case "[]string":
+		items, ok := v.([]any)
+		if !ok {
+			break
+		}
+		for i, item := range items {
+			if _, ok := item.(string); !ok {
+				return nil, fmt.Errorf("item %d: expected string, got %T", i, item)
+			}
+		}
+		return items, nil
+	default:
+		// 未知类型不做转换
+		return v, nil
+	}
+	return nil, fmt.Errorf("expected %s, got %T", typ, v)
+}
+
+// enumKey 值的字符串形式，用于枚举比较
+func enumKey(v any) string {
+	if f, ok := v.(float64); ok {
+		return strconv.FormatFloat(f, 'f', -1, 64)
+	}
+	return fmt.Sprint(v)
+}
@@ -1,14 +1,23 @@
 package outputparserx
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"strings"
+
+	"github.com/tmc/langchaingo/llms"
 )
 
 const (
 	_structuredFormatInstructionTemplate = "The output should be a markdown code snippet formatted in the following schema: \n```json\n%s\n```"
 	_structuredLineTemplate              = "\"%s\": %s // %s\n"
+	_structuredFixTemplate               = `上一次的输出无法解析: %v
+
+上一次的输出:
+%s
+
+请修正后重新输出，只返回JSON，不要包含其他内容。%s`
 )
 
 // ResponseSchema 结构化输出解析器的响应模式定义
@@ -17,7 +26,9 @@ type ResponseSchema struct {
 	Description string           // 字段描述
 	Type        string           // 字段类型
 	Require     bool             // 是否必填
-	Schemas     []ResponseSchema // 嵌套模式
+	Enum        []string         // 可选值，为空时不限制
+	Schemas     []ResponseSchema // 嵌套模式，字段为对象
+	Items       *ResponseSchema  // 数组元素的模式，如对象数组
 }
 
 // Structured 结构化输出解析器
@@ -32,7 +43,8 @@ func NewStructured(schema []ResponseSchema) Structured {
 	}
 }
 
-// Parse 解析LLM输出为map
+// Parse 解析LLM输出为map，按 ResponseSchema 的类型转换字段（如 "1700000000" 转为数值），
+// 类型不符、枚举不匹配或缺少必填字段时返回 *ParseError
 func (p Structured) Parse(text string) (any, error) {
 	var jsonString string
 
@@ -55,21 +67,33 @@ func (p Structured) Parse(text string) (any, error) {
 		return nil, fmt.Errorf("parse error: invalid JSON: %v", err)
 	}
 
-	// 验证必填字段
-	missingKeys := make([]string, 0)
-	for _, rs := range p.ResponseSchemas {
-		if _, ok := parsed[rs.Name]; !ok && rs.Require {
-			missingKeys = append(missingKeys, rs.Name)
-		}
-	}
-
-	if len(missingKeys) > 0 {
-		return nil, fmt.Errorf("output is missing fields: %v", missingKeys)
+	// 验证必填字段和类型
+	var errs []FieldError
+	coerce(parsed, p.ResponseSchemas, "", &errs)
+	if len(errs) > 0 {
+		return nil, &ParseError{Fields: errs}
 	}
 
 	return parsed, nil
 }
 
+// Fix 解析失败时将错误反馈给模型要求修正，最多重试retries次，仍失败时返回最后一次的解析错误
+func (p Structured) Fix(ctx context.Context, llm llms.Model, text string, retries int) (any, error) {
+	out, err := p.Parse(text)
+	for i := 0; err != nil && i < retries; i++ {
+		fmt.Printf("[OutputParser] 第%d次修正，解析错误: %v\n", i+1, err)
+
+		prompt := fmt.Sprintf(_structuredFixTemplate, err, text, p.GetFormatInstructions())
+		fixed, genErr := llms.GenerateFromSinglePrompt(ctx, llm, prompt, llms.WithTemperature(0))
+		if genErr != nil {
+			return nil, genErr
+		}
+		text = fixed
+		out, err = p.Parse(text)
+	}
+	return out, err
+}
+
 // GetFormatInstructions 返回格式化指令
 func (p Structured) GetFormatInstructions() string {
 	return fmt.Sprintf(_structuredFormatInstructionTemplate, p.jsonMarshal(p.ResponseSchemas, 0))
@@ -88,21 +112,43 @@ func (p Structured) jsonMarshal(schemas []ResponseSchema, level int) string {
 
 	jsonLines := "{"
 	for _, rs := range schemas {
-		if len(rs.Schemas) > 0 {
-			rs.Type = p.jsonMarshal(rs.Schemas, level)
-		}
-
 		blank := fieldBlank
 		if len(jsonLines) == 1 {
 			blank = "\t"
 		}
 
-		if len(rs.Type) == 0 {
-			rs.Type = "string"
-		}
-
-		jsonLines += blank + fmt.Sprintf(_structuredLineTemplate, rs.Name, rs.Type, rs.Description)
+		jsonLines += blank + fmt.Sprintf(_structuredLineTemplate, rs.Name, p.typeOf(rs, level), describe(rs))
 	}
 
 	return jsonLines + endBlank + "}"
 }
+
+// typeOf 字段在格式说明中的类型，对象展开为嵌套结构，对象数组为 [{...}]
+func (p Structured) typeOf(rs ResponseSchema, level int) string {
+	switch {
+	case len(rs.Schemas) > 0:
+		return p.jsonMarshal(rs.Schemas, level)
+	case rs.Items != nil && len(rs.Items.Schemas) > 0:
+		return "[" + p.jsonMarshal(rs.Items.Schemas, level) + "]"
+	case rs.Items != nil:
+		return "[]" + p.typeOf(*rs.Items, level)
+	case len(rs.Type) > 0:
+		return rs.Type
+	}
+	return "string"
+}
+
+// describe 字段说明，附加必填和可选值
+func describe(rs ResponseSchema) string {
+	desc := rs.Description
+	if rs.Require {
+		desc += " (required)"
+	}
+	if len(rs.Enum) > 0 {
+		desc += " (one of: " + strings.Join(rs.Enum, ", ") + ")"
+	}
+	if rs.Items != nil && len(rs.Items.Enum) > 0 {
+		desc += " (items one of: " + strings.Join(rs.Items.Enum, ", ") + ")"
+	}
+	return desc
+}
@@ -0,0 +1,106 @@
+package outputparserx
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/tmc/langchaingo/llms/fake"
+)
+
+func TestStructuredFix(t *testing.T) {
+	p := NewStructured([]ResponseSchema{
+		{Name: "title", Require: true},
+		{Name: "deadlineAt", Type: "int"},
+	})
+
+	// 第一次修正仍缺少字段，第二次修正成功
+	llm := fake.NewFakeLLM([]string{
+		`{"deadlineAt": 1700000000}`,
+		"```json\n{\"title\": \"周报\", \"deadlineAt\": 1700000000}\n```",
+	})
+	out, err := p.Fix(context.Background(), llm, `{title: 周报}`, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data := out.(map[string]any); data["title"] != "周报" {
+		t.Fatalf("unexpected output: %v", data)
+	}
+
+	// 不重试时直接返回解析错误
+	if _, err := p.Fix(context.Background(), llm, `{title: 周报}`, 0); err == nil {
+		t.Fatal("should return parse error without retry")
+	}
+}
+
+func TestStructuredParseCoerce(t *testing.T) {
+	p := NewStructured([]ResponseSchema{
+		{Name: "deadlineAt", Type: "int64"},
+		{Name: "confirm", Type: "bool"},
+		{Name: "executors", Type: "[]string"},
+		{Name: "checkType", Type: "int", Enum: []string{"1", "2"}},
+		{Name: "title"},
+	})
+
+	out, err := p.Parse(`{"deadlineAt": "1700000000", "confirm": "true", "executors": ["u1"], "checkType": 2, "title": 1}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := out.(map[string]any)
+	if data["deadlineAt"] != float64(1700000000) || data["confirm"] != true || data["title"] != "1" {
+		t.Fatalf("unexpected output: %v", data)
+	}
+
+	// 空值视为未填写
+	if _, err := p.Parse(`{"deadlineAt": "", "checkType": null}`); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = p.Parse(`{"deadlineAt": "明天", "executors": "u1", "checkType": 3}`)
+	var perr *ParseError
+	if !errors.As(err, &perr) {
+		t.Fatalf("expected ParseError, got %v", err)
+	}
+	var fields []string
+	for _, f := range perr.Fields {
+		fields = append(fields, f.Field)
+	}
+	if !slices.Equal(fields, []string{"deadlineAt", "executors", "checkType"}) {
+		t.Fatalf("unexpected field errors: %v", perr.Fields)
+	}
+}
+
+func TestStructuredNested(t *testing.T) {
+	p := NewStructured([]ResponseSchema{
+		{Name: "items", Items: &ResponseSchema{Schemas: []ResponseSchema{
+			{Name: "name", Require: true},
+			{Name: "amount", Type: "int"},
+		}}},
+		{Name: "tags", Items: &ResponseSchema{Type: "string", Enum: []string{"a", "b"}}},
+	})
+
+	out, err := p.Parse(`{"items": [{"name": "x", "amount": "3"}], "tags": ["a"]}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	item := out.(map[string]any)["items"].([]any)[0].(map[string]any)
+	if item["amount"] != float64(3) {
+		t.Fatalf("unexpected item: %v", item)
+	}
+
+	_, err = p.Parse(`{"items": [{"amount": 1}], "tags": ["c"]}`)
+	var perr *ParseError
+	if !errors.As(err, &perr) || len(perr.Fields) != 2 ||
+		perr.Fields[0].Field != "items[0].name" || perr.Fields[1].Field != "tags[0]" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	instructions := p.GetFormatInstructions()
+	for _, s := range []string{`"items": [{`, `(required)`, `"tags": []string`, `(items one of: a, b)`} {
+		if !strings.Contains(instructions, s) {
+			t.Errorf("instructions missing %q:\n%s", s, instructions)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package promptx
+
+import (
+	"context"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// fileConf 提示词文件格式
+//
+//	prompts:
+//	  router: |
+//	    ...
+//	tenants:
+//	  <租户ID>:
+//	    default: |
+//	      ...
+type fileConf struct {
+	Prompts map[string]string            `yaml:"prompts"`
+	Tenants map[string]map[string]string `yaml:"tenants"`
+}
+
+// File 从yaml文件加载提示词
+type File struct {
+	path string
+}
+
+func NewFile(path string) *File {
+	return &File{path: path}
+}
+
+func (f *File) Name() string {
+	return "file:" + f.path
+}
+
+func (f *File) Load(ctx context.Context) (Templates, error) {
+	b, err := os.ReadFile(f.path)
+	if err != nil {
+		return nil, err
+	}
+
+	var conf fileConf
+	if err := yaml.Unmarshal(b, &conf); err != nil {
+		return nil, err
+	}
+
+	ts := Templates{"": conf.Prompts}
+	for tenant, kv := range conf.Tenants {
+		if tenant != "" {
+			ts[tenant] = kv
+		}
+	}
+	return ts, nil
+}
@@ -0,0 +1,145 @@
+package promptx
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"text/template"
+	"time"
+)
+
+// 提示词名称
+const (
+	KeyRouter      = "router"         // 路由选择处理器
+	KeyClarify     = "router.clarify" // 路由不确定时追问用户的话术
+	KeyDefault     = "default"        // 默认对话
+	KeyAgentSystem = "agent.system"   // function calling agent 的系统提示
+	KeyAgentMrkl   = "agent.mrkl"     // mrkl agent 的前缀
+	KeyModeration  = "moderation"     // 内容审核
+	KeyVision      = "vision"         // 图片理解的系统提示
+	KeyRerank      = "rerank"         // 知识库检索结果的LLM重排
+	KeyDaily       = "daily"          // 每日工作总结
+)
+
+// Templates 租户 -> 提示词名称 -> 模板，租户为空表示全局
+type Templates map[string]map[string]string
+
+// Source 提示词来源
+type Source interface {
+	Name() string
+	Load(ctx context.Context) (Templates, error)
+}
+
+// SourceFunc 以函数实现Source
+type SourceFunc struct {
+	SourceName string
+	LoadFunc   func(ctx context.Context) (Templates, error)
+}
+
+func (f SourceFunc) Name() string { return f.SourceName }
+
+func (f SourceFunc) Load(ctx context.Context) (Templates, error) { return f.LoadFunc(ctx) }
+
+// Store 提示词存储，按来源顺序合并（后面的覆盖前面的），未配置的提示词使用代码中的默认值
+type Store struct {
+	sync.RWMutex
+	reloadMu  sync.Mutex
+	sources   []Source
+	loaded    []Templates // 各来源最近一次成功加载的结果
+	templates Templates   // 合并后的结果
+}
+
+func NewStore(sources ...Source) *Store {
+	return &Store{
+		sources:   sources,
+		loaded:    make([]Templates, len(sources)),
+		templates: Templates{},
+	}
+}
+
+// Get 获取提示词，依次查找context中的租户、全局配置，都没有时返回def
+func (s *Store) Get(ctx context.Context, key, def string) string {
+	if s == nil {
+		return def
+	}
+
+	s.RLock()
+	defer s.RUnlock()
+	for _, tenant := range Tenants(ctx) {
+		if tpl, ok := s.templates[tenant][key]; ok {
+			return tpl
+		}
+	}
+	if tpl, ok := s.templates[""][key]; ok {
+		return tpl
+	}
+	return def
+}
+
+// Reload 重新加载全部来源，加载失败的来源沿用上次的结果，返回最后一个错误
+func (s *Store) Reload(ctx context.Context) error {
+	s.reloadMu.Lock()
+	defer s.reloadMu.Unlock()
+
+	var lastErr error
+	for i, src := range s.sources {
+		ts, err := src.Load(ctx)
+		if err != nil {
+			lastErr = fmt.Errorf("%s: %w", src.Name(), err)
+			continue
+		}
+		s.loaded[i] = ts
+	}
+
+	merged := Templates{}
+	for _, ts := range s.loaded {
+		for tenant, kv := range ts {
+			for key, tpl := range kv {
+				// 模板有语法错误时跳过，避免线上对话全部失败
+				if _, err := template.New(key).Parse(tpl); err != nil {
+					fmt.Printf("[Prompt] 模板 %s/%s 解析失败, 已忽略: %v\n", tenant, key, err)
+					continue
+				}
+				if merged[tenant] == nil {
+					merged[tenant] = make(map[string]string)
+				}
+				merged[tenant][key] = tpl
+			}
+		}
+	}
+
+	s.Lock()
+	s.templates = merged
+	s.Unlock()
+	return lastErr
+}
+
+// Watch 定时重新加载，ctx取消后退出
+func (s *Store) Watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.Reload(ctx); err != nil {
+				fmt.Printf("[Prompt] 重新加载提示词失败: %v\n", err)
+			}
+		}
+	}
+}
+
+const tenantKey = "llms.prompt.tenants" // 租户的上下文键名
+
+// WithTenants 在context中设置租户，靠前的优先
+func WithTenants(ctx context.Context, tenants ...string) context.Context {
+	return context.WithValue(ctx, tenantKey, tenants)
+}
+
+// Tenants 获取context中的租户
+func Tenants(ctx context.Context) []string {
+	tenants, _ := ctx.Value(tenantKey).([]string)
+	return tenants
+}
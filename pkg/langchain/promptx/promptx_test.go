@@ -0,0 +1,72 @@
+package promptx
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestStoreGet(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "prompts.yaml")
+	data := `prompts:
+  router: "global router {{.input}}"
+tenants:
+  dep1:
+    router: "dep1 router {{.input}}"
+    default: "bad {{.input"
+`
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	s := NewStore(NewFile(path))
+	if err := s.Reload(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	if got := s.Get(ctx, KeyRouter, "def"); got != "global router {{.input}}" {
+		t.Fatalf("global: %q", got)
+	}
+	if got := s.Get(WithTenants(ctx, "dep2", "dep1"), KeyRouter, "def"); got != "dep1 router {{.input}}" {
+		t.Fatalf("tenant: %q", got)
+	}
+	// 语法错误的模板被忽略
+	if got := s.Get(WithTenants(ctx, "dep1"), KeyDefault, "def"); got != "def" {
+		t.Fatalf("invalid template: %q", got)
+	}
+
+	var nilStore *Store
+	if got := nilStore.Get(ctx, KeyRouter, "def"); got != "def" {
+		t.Fatalf("nil store: %q", got)
+	}
+}
+
+func TestStoreReloadKeepsLastOnError(t *testing.T) {
+	fail := false
+	src := SourceFunc{
+		SourceName: "test",
+		LoadFunc: func(ctx context.Context) (Templates, error) {
+			if fail {
+				return nil, errors.New("unavailable")
+			}
+			return Templates{"": {KeyDefault: "v1"}}, nil
+		},
+	}
+
+	s := NewStore(src)
+	if err := s.Reload(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	fail = true
+	if err := s.Reload(context.Background()); err == nil {
+		t.Fatal("expected error")
+	}
+	if got := s.Get(context.Background(), KeyDefault, "def"); got != "v1" {
+		t.Fatalf("expected last loaded value, got %q", got)
+	}
+}
@@ -0,0 +1,62 @@
+package router
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 模型选择不确定（置信度低于阈值或选择了不存在的handler）时的处理方式
+const (
+	FallbackDefault = "default" // 使用default处理器
+	FallbackClarify = "clarify" // 追问用户，不调用处理器
+)
+
+const _defaultClarify = "抱歉，我不太确定您想做什么，能再具体描述一下吗？"
+
+// Decision 一次路由决策，记录下来用于离线评估误路由
+type Decision struct {
+	Input      string
+	Output     string  // 模型的原始输出，关键词命中时为 keyword:<关键词>
+	Handler    string  // 最终使用的handler，追问时为空
+	Confidence float64 // 模型给出的置信度，未给出或关键词命中时为1
+	Fallback   string  // 不确定时的处理方式，确定时为空
+	Duration   time.Duration
+}
+
+// DecisionFunc 每次路由后调用，如保存到mongo
+type DecisionFunc func(ctx context.Context, d *Decision)
+
+// WithConfidence 模型给出的置信度低于threshold，或选择了不存在的handler时按fallback处理，
+// 未设置时使用default处理器
+func WithConfidence(threshold float64, fallback string) Option {
+	return func(r *Router) {
+		r.threshold = threshold
+		r.fallback = fallback
+	}
+}
+
+// WithDecision 设置路由决策的回调
+func WithDecision(fn DecisionFunc) Option {
+	return func(r *Router) {
+		r.onDecision = fn
+	}
+}
+
+// parseRoute 解析模型输出的 "handler 置信度"，未给出置信度时视为确定
+func parseRoute(output string) (string, float64) {
+	fields := strings.Fields(strings.ToLower(output))
+	if len(fields) == 0 {
+		return "", 0
+	}
+	name := strings.Trim(fields[0], "`'\",.:：，。")
+	if len(fields) < 2 {
+		return name, 1
+	}
+	confidence, err := strconv.ParseFloat(strings.Trim(fields[1], "()（）"), 64)
+	if err != nil {
+		return name, 1
+	}
+	return name, confidence
+}
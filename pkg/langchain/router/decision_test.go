@@ -0,0 +1,52 @@
+package router
+
+import (
+	"context"
+	"testing"
+
+	"aiOffice/pkg/langchain"
+	"aiOffice/pkg/langchain/handler"
+
+	"github.com/tmc/langchaingo/llms/fake"
+)
+
+func TestParseRoute(t *testing.T) {
+	cases := map[string]struct {
+		name       string
+		confidence float64
+	}{
+		"todo 0.85":    {"todo", 0.85},
+		" Approval\n":  {"approval", 1},
+		"`todo` (0.4)": {"todo", 0.4},
+		"default 高":    {"default", 1},
+		"":             {"", 0},
+	}
+	for output, want := range cases {
+		name, confidence := parseRoute(output)
+		if name != want.name || confidence != want.confidence {
+			t.Errorf("parseRoute(%q) = %q %v, want %q %v", output, name, confidence, want.name, want.confidence)
+		}
+	}
+}
+
+func TestRouterClarify(t *testing.T) {
+	llm := fake.NewFakeLLM([]string{"todo 0.3", "unknown 0.9"})
+	var decisions []*Decision
+	r := NewRouter(llm, []handler.Handler{testHandler{name: "todo"}}, nil,
+		WithConfidence(0.6, FallbackClarify),
+		WithDecision(func(_ context.Context, d *Decision) { decisions = append(decisions, d) }),
+	)
+
+	for range 2 {
+		out, err := r.Call(context.Background(), map[string]any{langchain.Input: "帮我弄一下"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if out[langchain.Output] != _defaultClarify {
+			t.Errorf("should ask a clarifying question, got %v", out)
+		}
+	}
+	if len(decisions) != 2 || decisions[0].Confidence != 0.3 || decisions[0].Fallback != FallbackClarify || decisions[1].Handler != "" {
+		t.Errorf("unexpected decisions: %+v", decisions)
+	}
+}
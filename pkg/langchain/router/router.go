@@ -2,10 +2,16 @@ package router
 
 import (
 	"aiOffice/internal/model"
+	"aiOffice/pkg/langchain"
 	"aiOffice/pkg/langchain/handler"
+	"aiOffice/pkg/langchain/promptx"
+	"aiOffice/pkg/metrics"
+	"aiOffice/pkg/tracex"
+	"cmp"
 	"context"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/tmc/langchaingo/chains"
 	"github.com/tmc/langchaingo/llms"
@@ -13,62 +19,115 @@ import (
 	"github.com/tmc/langchaingo/schema"
 )
 
+const _defaultRouterPrompt = `根据用户输入，选择最合适的处理器。
+
+可选的处理器:
+{{.handlers}}
+
+用户输入: {{.input}}
+
+规则：
+{{.rules}}
+
+请返回处理器名称和你的把握（0到1之间的小数），用空格分隔，例如: default 0.8
+不要返回其他内容。`
+
 type Router struct {
 	handlers     map[string]handler.Handler
 	handlerNames []string
 	handlerDescs []string
-	chain        chains.Chain
+	rules        map[string][]string // 由各handler的Rules生成的路由规则
+	ruleStore    *RuleStore          // 可配置的路由规则，配置了的handler不再使用代码中的规则
+	dynamicRules RulesFunc
+	threshold    float64      // 模型选择的置信度低于该值时按fallback处理
+	fallback     string       // 不确定时的处理方式，见 FallbackDefault、FallbackClarify
+	onDecision   DecisionFunc // 路由决策的回调
+	llm          llms.Model
+	prompts      *promptx.Store // 为空时使用默认提示词
 	memory       schema.Memory
 	emptyHandle  handler.Handler // 默认处理器，当没有合适处理器时使用
 }
 
-func NewRouter(llm llms.Model, handlers []handler.Handler, mem schema.Memory) *Router {
+// Option 路由配置项
+type Option func(*Router)
+
+// Rule 路由规则，满足条件时选择handler
+type Rule struct {
+	Handler string
+	Rule    string
+}
+
+// RulesFunc 每次调用时生成的路由规则，优先于handler的静态规则
+type RulesFunc func(ctx context.Context) []Rule
+
+// WithRules 设置动态路由规则，如用户正在补充某个handler需要的信息时优先路由回该handler
+func WithRules(fn RulesFunc) Option {
+	return func(r *Router) {
+		r.dynamicRules = fn
+	}
+}
+
+// WithPrompts 从提示词存储中读取路由提示词，每次调用时获取，支持热加载和按租户覆盖
+func WithPrompts(store *promptx.Store) Option {
+	return func(r *Router) {
+		r.prompts = store
+	}
+}
+
+func NewRouter(llm llms.Model, handlers []handler.Handler, mem schema.Memory, opts ...Option) *Router {
 
 	hs := make(map[string]handler.Handler)
 	for _, v := range handlers {
 		hs[v.Name()] = v
 	}
 
-	// 构建handler名称和描述列表用于路由提示
+	// 构建handler名称、描述和路由规则用于路由提示
 	var handlerDescs []string
 	var handlerNames []string
+	rules := make(map[string][]string)
 	for _, h := range handlers {
 		handlerNames = append(handlerNames, h.Name())
 		handlerDescs = append(handlerDescs, fmt.Sprintf("- %s: %s", h.Name(), h.Description()))
+		if r, ok := h.(handler.Ruler); ok {
+			for _, rule := range r.Rules() {
+				rules[h.Name()] = append(rules[h.Name()], ruleLine(RouteRule{Handler: h.Name(), Description: rule}))
+			}
+		}
 	}
 
-	// 创建路由提示模板
-	prompt := prompts.NewPromptTemplate(
-		`根据用户输入，选择最合适的处理器。
-
-可选的处理器:
-{{.handlers}}
-
-用户输入: {{.input}}
-
-规则：
-1. 如果用户要创建待办、任务、提醒、查询待办等，选择 todo
-2. 如果用户要请假、补卡、外出、查询审批等，选择 approval
-3. 如果用户询问公司制度、员工手册、考勤规则、请假流程、报销流程等知识库内容，选择 knowledge
-4. 如果用户要更新知识库、添加文档到知识库，选择 knowledge
-5. 其他情况选择 default
-
-请只返回处理器名称，不要返回其他内容。`,
-		[]string{"input", "handlers"},
-	)
-
-	return &Router{
+	r := &Router{
 		handlers:     hs,
 		handlerNames: handlerNames,
 		handlerDescs: handlerDescs,
-		chain:        chains.NewLLMChain(llm, prompt),
+		rules:        rules,
+		llm:          llm,
 		memory:       mem,
 	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// chain 创建路由选择的LLM链
+func (r *Router) chain(ctx context.Context) chains.Chain {
+	prompt := prompts.NewPromptTemplate(
+		r.prompts.Get(ctx, promptx.KeyRouter, _defaultRouterPrompt),
+		[]string{"input", "handlers", "rules"},
+	)
+	return chains.NewLLMChain(r.llm, prompt)
 }
 
 func (r *Router) Call(ctx context.Context, inputs map[string]any, opts ...chains.ChainCallOption) (map[string]any, error) {
+	var dynamic []Rule
+	if r.dynamicRules != nil {
+		dynamic = r.dynamicRules(ctx)
+	}
+	configured := r.ruleStore.Rules()
+
 	// 添加handlers参数（使用描述信息）
 	inputs["handlers"] = strings.Join(r.handlerDescs, "\n")
+	inputs["rules"] = r.buildRules(dynamic, configured)
 
 	// 如果没有注册任何处理器，使用默认处理器或返回错误
 	if len(r.handlers) == 0 {
@@ -79,29 +138,118 @@ func (r *Router) Call(ctx context.Context, inputs map[string]any, opts ...chains
 		}
 	}
 
-	// 1. 用LLM分析应该用哪个Handler
-	result, err := chains.Call(ctx, r.chain, inputs, opts...)
-	if err != nil {
-		return nil, err
-	}
-
-	// 2. 解析LLM输出，获取目标Handler名称
-	handlerName := strings.TrimSpace(result["text"].(string))
-	handlerName = strings.ToLower(handlerName)
+	// 1. 先按关键词匹配，未命中再用LLM分析应该用哪个Handler；
+	// 有未完成的草稿时关键词可能只是补充的信息，交给LLM结合动态规则判断
+	routeStart := time.Now()
+	input, _ := inputs[langchain.Input].(string)
+	decision := &Decision{Input: input, Confidence: 1}
+	var handlerName string
+	if name, kw, ok := matchKeyword(configured, input); ok && len(dynamic) == 0 && r.handlers[name] != nil {
+		fmt.Printf("[Router] 关键词 %q 命中handler: %q\n", kw, name)
+		handlerName, decision.Output = name, "keyword:"+kw
+	} else {
+		routeCtx, routeSpan := tracex.Start(ctx, "ai.route", tracex.KindInternal)
+		result, err := chains.Call(routeCtx, r.chain(ctx), inputs, opts...)
+		if err != nil {
+			routeSpan.SetError(err)
+		} else {
+			routeSpan.SetAttr("ai.route.output", fmt.Sprint(result["text"]))
+		}
+		routeSpan.End()
+		if err != nil {
+			return nil, err
+		}
 
-	fmt.Printf("[Router] LLM选择的handler: %q\n", handlerName)
+		// 2. 解析LLM输出，获取目标Handler名称和置信度
+		decision.Output, _ = result["text"].(string)
+		handlerName, decision.Confidence = parseRoute(decision.Output)
+		fmt.Printf("[Router] LLM选择的handler: %q, 置信度: %.2f\n", handlerName, decision.Confidence)
+	}
+	decision.Duration = time.Since(routeStart)
 
-	// 3. 调用对应的Handler
+	// 3. 调用对应的Handler，不确定时追问或使用default handler
 	h, ok := r.handlers[handlerName]
-	if !ok {
-		// 如果找不到匹配的handler，使用default handler
-		fmt.Printf("[Router] 未找到handler %q，使用default\n", handlerName)
+	if !ok || decision.Confidence < r.threshold {
+		decision.Fallback = cmp.Or(r.fallback, FallbackDefault)
+		fmt.Printf("[Router] handler %q 不确定，%s\n", handlerName, decision.Fallback)
+	}
+	if decision.Fallback == FallbackClarify {
+		r.decide(ctx, decision)
+		langchain.GetTrace(ctx).SetRoute(FallbackClarify, decision.Output, decision.Duration)
+		return map[string]any{
+			langchain.Output: r.prompts.Get(ctx, promptx.KeyClarify, _defaultClarify),
+		}, nil
+	}
+	if decision.Fallback != "" {
 		h, ok = r.handlers["default"]
 		if !ok {
 			return nil, model.ErrNotHandles
 		}
 	}
-	return chains.Call(ctx, h.Chains(), inputs)
+	decision.Handler = h.Name()
+	r.decide(ctx, decision)
+
+	langchain.GetTrace(ctx).SetRoute(h.Name(), decision.Output, decision.Duration)
+
+	// 4. 只对最终handler开启流式输出，路由选择的结果不推送给前端
+	var handlerOpts []chains.ChainCallOption
+	if stream := langchain.GetStream(ctx); stream != nil {
+		handlerOpts = append(handlerOpts, chains.WithStreamingFunc(stream))
+	}
+
+	start := time.Now()
+	ctx, span := tracex.Start(ctx, "ai.handler "+h.Name(), tracex.KindInternal)
+	defer span.End()
+	outputs, err := chains.Call(ctx, h.Chains(), inputs, handlerOpts...)
+	status := "ok"
+	if err != nil {
+		status = "error"
+		span.SetError(err)
+	}
+	metrics.AIHandlerDuration.WithLabelValues(h.Name(), status).Observe(time.Since(start).Seconds())
+	return outputs, err
+}
+
+// decide 回调路由决策
+func (r *Router) decide(ctx context.Context, d *Decision) {
+	if r.onDecision != nil {
+		r.onDecision(ctx, d)
+	}
+}
+
+// buildRules 按优先级编号路由规则：动态规则、handler规则（有配置时使用配置的规则）、default
+func (r *Router) buildRules(dynamic []Rule, configured []RouteRule) string {
+	var rules []string
+	for _, v := range dynamic {
+		if _, ok := r.handlers[v.Handler]; ok {
+			rules = append(rules, fmt.Sprintf("如果%s，选择 %s", v.Rule, v.Handler))
+		}
+	}
+
+	// 配置了规则的handler替换代码中的规则，只配置了关键词的规则不写入提示词
+	replaced := make(map[string]bool)
+	lines := make(map[string][]string)
+	for _, v := range configured {
+		replaced[v.Handler] = true
+		if v.Description != "" {
+			lines[v.Handler] = append(lines[v.Handler], ruleLine(v))
+		}
+	}
+	for _, name := range r.handlerNames {
+		if replaced[name] {
+			rules = append(rules, lines[name]...)
+		} else {
+			rules = append(rules, r.rules[name]...)
+		}
+	}
+	if _, ok := r.handlers["default"]; ok {
+		rules = append(rules, "其他情况选择 default")
+	}
+
+	for i := range rules {
+		rules[i] = fmt.Sprintf("%d. %s", i+1, rules[i])
+	}
+	return strings.Join(rules, "\n")
 }
 
 // GetMemory 实现chains.Chain接口
@@ -0,0 +1,107 @@
+package router
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RouteRule 可配置的路由规则，配置了某个handler的规则后替换该handler代码中的Rules，新增handler无需修改提示词
+type RouteRule struct {
+	Handler     string
+	Description string   // 用户意图，写入提示词，如"用户要创建待办、查询待办等"
+	Keywords    []string // 输入包含关键词时直接选择该handler，不调用模型
+	Examples    []string // 示例输入，写入提示词
+}
+
+// RuleSource 路由规则来源，如配置文件、mongo
+type RuleSource func(ctx context.Context) ([]RouteRule, error)
+
+// RuleStore 可热加载的路由规则，多个来源的规则按顺序合并
+type RuleStore struct {
+	sync.RWMutex
+	sources []RuleSource
+	rules   []RouteRule
+}
+
+func NewRuleStore(sources ...RuleSource) *RuleStore {
+	return &RuleStore{
+		sources: sources,
+	}
+}
+
+// Reload 重新加载全部来源，某个来源失败时保留其他来源的规则并返回最后一个错误
+func (s *RuleStore) Reload(ctx context.Context) error {
+	var rules []RouteRule
+	var lastErr error
+	for _, source := range s.sources {
+		list, err := source(ctx)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		rules = append(rules, list...)
+	}
+
+	s.Lock()
+	s.rules = rules
+	s.Unlock()
+	return lastErr
+}
+
+// Watch 定时重新加载，ctx取消后退出
+func (s *RuleStore) Watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.Reload(ctx); err != nil {
+				fmt.Printf("[Router] 重新加载路由规则失败: %v\n", err)
+			}
+		}
+	}
+}
+
+// Rules 当前的路由规则，nil上调用返回空
+func (s *RuleStore) Rules() []RouteRule {
+	if s == nil {
+		return nil
+	}
+	s.RLock()
+	defer s.RUnlock()
+	return s.rules
+}
+
+// WithRuleStore 使用可配置的路由规则，每次调用时读取
+func WithRuleStore(store *RuleStore) Option {
+	return func(r *Router) {
+		r.ruleStore = store
+	}
+}
+
+// matchKeyword 按规则顺序查找输入包含的关键词，返回对应的handler
+func matchKeyword(rules []RouteRule, input string) (handler, keyword string, ok bool) {
+	input = strings.ToLower(input)
+	for _, rule := range rules {
+		for _, kw := range rule.Keywords {
+			if kw != "" && strings.Contains(input, strings.ToLower(kw)) {
+				return rule.Handler, kw, true
+			}
+		}
+	}
+	return "", "", false
+}
+
+// ruleLine 规则在提示词中的描述，附带示例输入
+func ruleLine(rule RouteRule) string {
+	if len(rule.Examples) == 0 {
+		return fmt.Sprintf("如果%s，选择 %s", rule.Description, rule.Handler)
+	}
+	return fmt.Sprintf("如果%s（例如: %s），选择 %s", rule.Description, strings.Join(rule.Examples, "、"), rule.Handler)
+}
@@ -0,0 +1,57 @@
+package router
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"aiOffice/pkg/langchain/handler"
+
+	"github.com/tmc/langchaingo/chains"
+)
+
+type testHandler struct {
+	name  string
+	rules []string
+}
+
+func (h testHandler) Name() string         { return h.name }
+func (h testHandler) Description() string  { return h.name }
+func (h testHandler) Chains() chains.Chain { return nil }
+func (h testHandler) Rules() []string      { return h.rules }
+
+func TestBuildRules(t *testing.T) {
+	store := NewRuleStore(func(context.Context) ([]RouteRule, error) {
+		return []RouteRule{
+			{Handler: "todo", Description: "用户要创建待办", Examples: []string{"提醒我开会"}, Keywords: []string{"待办"}},
+			{Handler: "unknown", Description: "未注册的handler"},
+		}, nil
+	})
+	if err := store.Reload(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	r := NewRouter(nil, []handler.Handler{
+		testHandler{name: "todo", rules: []string{"代码中的待办规则"}},
+		testHandler{name: "approval", rules: []string{"用户要请假"}},
+		testHandler{name: "default"},
+	}, nil, WithRuleStore(store))
+
+	got := r.buildRules([]Rule{{Handler: "approval", Rule: "用户正在补充审批"}}, store.Rules())
+	want := strings.Join([]string{
+		"1. 如果用户正在补充审批，选择 approval",
+		"2. 如果用户要创建待办（例如: 提醒我开会），选择 todo",
+		"3. 如果用户要请假，选择 approval",
+		"4. 其他情况选择 default",
+	}, "\n")
+	if got != want {
+		t.Errorf("unexpected rules:\n%s", got)
+	}
+
+	if name, _, ok := matchKeyword(store.Rules(), "帮我建个待办"); !ok || name != "todo" {
+		t.Errorf("keyword should match todo, got %q", name)
+	}
+	if _, _, ok := matchKeyword(store.Rules(), "你好"); ok {
+		t.Error("keyword should not match")
+	}
+}
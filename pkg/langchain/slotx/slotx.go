@@ -0,0 +1,124 @@
+package slotx
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Slot 创建业务数据需要的字段
+type Slot struct {
+	Name     string // 字段名，与工具输入的字段一致
+	Question string // 缺失时向用户询问的内容
+}
+
+// Draft 多轮对话中尚未完成的业务数据，每个用户同时只保留一份
+type Draft struct {
+	Handler string         `json:"handler"` // 继续补充信息时路由到的handler
+	Tool    string         `json:"tool"`
+	Title   string         `json:"title"`   // 如 "请假审批"
+	Missing []string       `json:"missing"` // 仍需向用户询问的内容
+	Data    map[string]any `json:"data"`
+}
+
+// Store 基于Redis保存草稿，超过ttl未补充完成自动丢弃
+type Store struct {
+	client redis.UniversalClient
+	prefix string
+	ttl    time.Duration
+}
+
+func NewStore(client redis.UniversalClient, prefix string, ttl time.Duration) *Store {
+	return &Store{
+		client: client,
+		prefix: prefix,
+		ttl:    ttl,
+	}
+}
+
+// Get 获取用户的草稿，没有时返回nil
+func (s *Store) Get(ctx context.Context, uid string) (*Draft, error) {
+	if s == nil || uid == "" {
+		return nil, nil
+	}
+
+	b, err := s.client.Get(ctx, s.prefix+uid).Bytes()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var d Draft
+	if err := json.Unmarshal(b, &d); err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+// Save 保存草稿并刷新过期时间
+func (s *Store) Save(ctx context.Context, uid string, d *Draft) error {
+	if s == nil || uid == "" {
+		return nil
+	}
+
+	b, err := json.Marshal(d)
+	if err != nil {
+		return err
+	}
+	return s.client.Set(ctx, s.prefix+uid, b, s.ttl).Err()
+}
+
+// Clear 删除草稿
+func (s *Store) Clear(ctx context.Context, uid string) error {
+	if s == nil || uid == "" {
+		return nil
+	}
+	return s.client.Del(ctx, s.prefix+uid).Err()
+}
+
+// Merge 将本轮输入合并到草稿，输入中的空值不覆盖已有内容
+func Merge(draft, input map[string]any) map[string]any {
+	res := make(map[string]any, len(draft)+len(input))
+	for k, v := range draft {
+		res[k] = v
+	}
+	for k, v := range input {
+		if !IsEmpty(v) {
+			res[k] = v
+		}
+	}
+	return res
+}
+
+// Missing 返回数据中仍为空的字段
+func Missing(data map[string]any, slots []Slot) []Slot {
+	var res []Slot
+	for _, s := range slots {
+		if IsEmpty(data[s.Name]) {
+			res = append(res, s)
+		}
+	}
+	return res
+}
+
+// IsEmpty 字段是否为空，模型未填写的字段常以 0、"" 或 [] 给出
+func IsEmpty(v any) bool {
+	switch v := v.(type) {
+	case nil:
+		return true
+	case string:
+		return v == ""
+	case float64:
+		return v == 0
+	case []any:
+		return len(v) == 0
+	case []string:
+		return len(v) == 0
+	}
+	return false
+}
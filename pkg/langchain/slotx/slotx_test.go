@@ -0,0 +1,41 @@
+package slotx
+
+import (
+	"context"
+	"testing"
+)
+
+func TestMergeAndMissing(t *testing.T) {
+	slots := []Slot{
+		{Name: "type", Question: "审批类型"},
+		{Name: "startTime", Question: "开始时间"},
+		{Name: "endTime", Question: "结束时间"},
+		{Name: "reason", Question: "理由"},
+	}
+
+	// 第一轮只给出类型和理由，模型把未知的时间填成0
+	data := Merge(nil, map[string]any{"type": float64(2), "startTime": float64(0), "reason": "看病"})
+	missing := Missing(data, slots)
+	if len(missing) != 2 || missing[0].Name != "startTime" || missing[1].Name != "endTime" {
+		t.Fatalf("unexpected missing: %v", missing)
+	}
+
+	// 第二轮补充时间，没有重复给出的理由不应被清空
+	data = Merge(data, map[string]any{"startTime": float64(1700000000), "endTime": float64(1700028800), "reason": ""})
+	if missing := Missing(data, slots); len(missing) != 0 {
+		t.Fatalf("should be complete, missing: %v", missing)
+	}
+	if data["reason"] != "看病" {
+		t.Fatalf("reason should be kept, got %v", data["reason"])
+	}
+}
+
+func TestNilStore(t *testing.T) {
+	var s *Store
+	if d, err := s.Get(context.Background(), "u1"); d != nil || err != nil {
+		t.Fatalf("nil store should return nothing, got %v %v", d, err)
+	}
+	if err := s.Save(context.Background(), "u1", &Draft{}); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,56 @@
+package limiter
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// 固定窗口计数：第一次请求时设置过期时间，窗口内超过 limit 即拒绝；返回计数和窗口剩余毫秒数
+var windowScript = redis.NewScript(`
+local n = redis.call("INCR", KEYS[1])
+local ttl = redis.call("PTTL", KEYS[1])
+if n == 1 or ttl < 0 then
+	redis.call("PEXPIRE", KEYS[1], ARGV[1])
+	ttl = tonumber(ARGV[1])
+end
+return {n, ttl}
+`)
+
+// RedisLimiter 基于Redis的固定窗口限流，多实例共享计数
+type RedisLimiter struct {
+	client redis.UniversalClient
+	prefix string
+	limit  int
+	window time.Duration
+}
+
+func NewRedisLimiter(client redis.UniversalClient, prefix string, limit int, window time.Duration) *RedisLimiter {
+	return &RedisLimiter{
+		client: client,
+		prefix: prefix,
+		limit:  limit,
+		window: window,
+	}
+}
+
+// Allow 尝试为 key 计数一次，Redis 异常时放行，避免限流组件故障影响业务
+func (l *RedisLimiter) Allow(ctx context.Context, key string) bool {
+	ok, _ := l.Reserve(ctx, key)
+	return ok
+}
+
+// Reserve 同 Allow，超出限制时同时返回当前窗口的剩余时间，即客户端需要等待的时间
+func (l *RedisLimiter) Reserve(ctx context.Context, key string) (bool, time.Duration) {
+	res, err := windowScript.Run(ctx, l.client, []string{l.prefix + key}, l.window.Milliseconds()).Int64Slice()
+	if err != nil || len(res) != 2 {
+		fmt.Printf("[Limiter] redis 限流失败，放行: %v\n", err)
+		return true, 0
+	}
+	if res[0] <= int64(l.limit) {
+		return true, 0
+	}
+	return false, time.Duration(res[1]) * time.Millisecond
+}
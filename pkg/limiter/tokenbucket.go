@@ -0,0 +1,102 @@
+package limiter
+
+import (
+	"sync"
+	"time"
+)
+
+// TokenBucket 令牌桶限流器，按固定速率补充令牌，最多积累 burst 个
+type TokenBucket struct {
+	sync.Mutex
+	rate   float64 // 每秒补充的令牌数
+	burst  float64 // 桶容量
+	tokens float64
+	last   time.Time
+}
+
+func NewTokenBucket(rate float64, burst int) *TokenBucket {
+	if burst < 1 {
+		burst = 1
+	}
+	return &TokenBucket{
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// Allow 尝试获取一个令牌
+func (b *TokenBucket) Allow() bool {
+	return b.AllowAt(time.Now())
+}
+
+// AllowAt 以指定时间尝试获取一个令牌
+func (b *TokenBucket) AllowAt(now time.Time) bool {
+	b.Lock()
+	defer b.Unlock()
+
+	if elapsed := now.Sub(b.last).Seconds(); elapsed > 0 {
+		b.tokens += elapsed * b.rate
+		if b.tokens > b.burst {
+			b.tokens = b.burst
+		}
+		b.last = now
+	}
+
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// idle 令牌桶是否已满（长时间未使用）
+func (b *TokenBucket) idle(now time.Time) bool {
+	b.Lock()
+	defer b.Unlock()
+	return b.tokens+now.Sub(b.last).Seconds()*b.rate >= b.burst
+}
+
+// KeyedLimiter 按 key（用户ID、IP 等）分别限流
+type KeyedLimiter struct {
+	sync.Mutex
+	rate    float64
+	burst   int
+	buckets map[string]*TokenBucket
+	calls   int
+}
+
+func NewKeyedLimiter(rate float64, burst int) *KeyedLimiter {
+	return &KeyedLimiter{
+		rate:    rate,
+		burst:   burst,
+		buckets: make(map[string]*TokenBucket),
+	}
+}
+
+// Allow 尝试为 key 获取一个令牌
+func (l *KeyedLimiter) Allow(key string) bool {
+	now := time.Now()
+
+	l.Lock()
+	b, ok := l.buckets[key]
+	if !ok {
+		b = NewTokenBucket(l.rate, l.burst)
+		l.buckets[key] = b
+	}
+
+	// 每1000次调用清理一次已经回满的桶，避免 map 无限增长
+	l.calls++
+	if l.calls >= 1000 {
+		l.calls = 0
+		for k, v := range l.buckets {
+			if k != key && v.idle(now) {
+				delete(l.buckets, k)
+			}
+		}
+	}
+	l.Unlock()
+
+	return b.AllowAt(now)
+}
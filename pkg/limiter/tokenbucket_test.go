@@ -0,0 +1,40 @@
+package limiter
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTokenBucket(t *testing.T) {
+	b := NewTokenBucket(1, 2)
+	now := b.last
+
+	if !b.AllowAt(now) || !b.AllowAt(now) {
+		t.Fatal("burst tokens should be available")
+	}
+	if b.AllowAt(now) {
+		t.Error("bucket should be empty")
+	}
+
+	// 一秒后补充一个令牌
+	if !b.AllowAt(now.Add(time.Second)) {
+		t.Error("token should be refilled after 1s")
+	}
+	if b.AllowAt(now.Add(time.Second)) {
+		t.Error("only one token should be refilled")
+	}
+}
+
+func TestKeyedLimiter(t *testing.T) {
+	l := NewKeyedLimiter(0.001, 1)
+
+	if !l.Allow("u1") {
+		t.Error("first call of u1 should be allowed")
+	}
+	if l.Allow("u1") {
+		t.Error("second call of u1 should be limited")
+	}
+	if !l.Allow("u2") {
+		t.Error("u2 should have its own bucket")
+	}
+}
@@ -0,0 +1,183 @@
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrNoRecipient = errors.New("没有收件人")
+	ErrBadAddress  = errors.New("邮箱地址格式错误")
+)
+
+// Conf SMTP 配置
+type Conf struct {
+	Host     string
+	Port     int    // 465 为隐式TLS，其余端口在服务器支持时使用STARTTLS
+	Username string // 为空则不认证
+	Password string
+	From     string // 发件地址，为空时使用 Username
+	FromName string // 发件人名称
+}
+
+// Mailer SMTP 邮件发送
+type Mailer struct {
+	conf    Conf
+	timeout time.Duration
+}
+
+func New(conf Conf) *Mailer {
+	if conf.Port == 0 {
+		conf.Port = 465
+	}
+	if conf.From == "" {
+		conf.From = conf.Username
+	}
+	return &Mailer{
+		conf:    conf,
+		timeout: 15 * time.Second,
+	}
+}
+
+// Send 发送纯文本邮件
+func (m *Mailer) Send(ctx context.Context, to []string, subject, body string) error {
+	if len(to) == 0 {
+		return ErrNoRecipient
+	}
+	for _, addr := range to {
+		if !ValidAddress(addr) {
+			return fmt.Errorf("%w: %s", ErrBadAddress, addr)
+		}
+	}
+
+	msg, err := m.build(to, subject, body)
+	if err != nil {
+		return err
+	}
+
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, m.timeout)
+		defer cancel()
+	}
+
+	c, err := m.dial(ctx)
+	if err != nil {
+		return fmt.Errorf("smtp: connect %s: %w", m.conf.Host, err)
+	}
+	defer c.Close()
+
+	if m.conf.Username != "" {
+		if err := c.Auth(smtp.PlainAuth("", m.conf.Username, m.conf.Password, m.conf.Host)); err != nil {
+			return fmt.Errorf("smtp: auth: %w", err)
+		}
+	}
+	if err := c.Mail(m.conf.From); err != nil {
+		return fmt.Errorf("smtp: mail from: %w", err)
+	}
+	for _, addr := range to {
+		if err := c.Rcpt(addr); err != nil {
+			return fmt.Errorf("smtp: rcpt %s: %w", addr, err)
+		}
+	}
+
+	w, err := c.Data()
+	if err != nil {
+		return fmt.Errorf("smtp: data: %w", err)
+	}
+	if _, err := w.Write(msg); err != nil {
+		return fmt.Errorf("smtp: write: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("smtp: write: %w", err)
+	}
+	return c.Quit()
+}
+
+func (m *Mailer) dial(ctx context.Context) (*smtp.Client, error) {
+	addr := net.JoinHostPort(m.conf.Host, strconv.Itoa(m.conf.Port))
+	tlsConf := &tls.Config{ServerName: m.conf.Host}
+
+	var (
+		conn net.Conn
+		err  error
+	)
+	if m.conf.Port == 465 {
+		d := &tls.Dialer{Config: tlsConf}
+		conn, err = d.DialContext(ctx, "tcp", addr)
+	} else {
+		var d net.Dialer
+		conn, err = d.DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	c, err := smtp.NewClient(conn, m.conf.Host)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if m.conf.Port != 465 {
+		if ok, _ := c.Extension("STARTTLS"); ok {
+			if err := c.StartTLS(tlsConf); err != nil {
+				c.Close()
+				return nil, err
+			}
+		}
+	}
+	return c, nil
+}
+
+// build 组装邮件，标题和发件人名称按 RFC 2047 编码，正文 base64
+func (m *Mailer) build(to []string, subject, body string) ([]byte, error) {
+	id := make([]byte, 12)
+	if _, err := rand.Read(id); err != nil {
+		return nil, err
+	}
+	from := (&mail.Address{Name: m.conf.FromName, Address: m.conf.From}).String()
+
+	var buf bytes.Buffer
+	header := func(k, v string) {
+		buf.WriteString(k + ": " + v + "\r\n")
+	}
+	header("From", from)
+	header("To", strings.Join(to, ", "))
+	header("Subject", mime.BEncoding.Encode("UTF-8", subject))
+	header("Date", time.Now().Format(time.RFC1123Z))
+	header("Message-ID", fmt.Sprintf("<%s@%s>", hex.EncodeToString(id), m.conf.Host))
+	header("MIME-Version", "1.0")
+	header("Content-Type", `text/plain; charset="UTF-8"`)
+	header("Content-Transfer-Encoding", "base64")
+	buf.WriteString("\r\n")
+
+	encoded := base64.StdEncoding.EncodeToString([]byte(body))
+	for len(encoded) > 76 {
+		buf.WriteString(encoded[:76] + "\r\n")
+		encoded = encoded[76:]
+	}
+	buf.WriteString(encoded + "\r\n")
+	return buf.Bytes(), nil
+}
+
+// ValidAddress 是否为单个合法的邮箱地址（不含名称部分）
+func ValidAddress(addr string) bool {
+	a, err := mail.ParseAddress(addr)
+	return err == nil && a.Address == addr
+}
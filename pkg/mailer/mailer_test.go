@@ -0,0 +1,58 @@
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"mime"
+	"net/mail"
+	"strings"
+	"testing"
+)
+
+func TestBuild(t *testing.T) {
+	m := New(Conf{Host: "smtp.example.com", Username: "bot@example.com", FromName: "智能办公"})
+	body := "今日工作总结\n" + strings.Repeat("完成待办 3 项，", 20)
+
+	msg, err := m.build([]string{"a@example.com", "b@example.com"}, "每日总结", body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parsed, err := mail.ReadMessage(bytes.NewReader(msg))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var dec mime.WordDecoder
+	subject, err := dec.DecodeHeader(parsed.Header.Get("Subject"))
+	if err != nil || subject != "每日总结" {
+		t.Fatalf("unexpected subject: %q, %v", subject, err)
+	}
+	from, err := parsed.Header.AddressList("From")
+	if err != nil || from[0].Name != "智能办公" || from[0].Address != "bot@example.com" {
+		t.Fatalf("unexpected from: %v, %v", from, err)
+	}
+	if got := parsed.Header.Get("To"); got != "a@example.com, b@example.com" {
+		t.Fatalf("unexpected to: %q", got)
+	}
+
+	raw, _ := io.ReadAll(parsed.Body)
+	for _, l := range strings.Split(string(raw), "\r\n") {
+		if len(l) > 76 {
+			t.Fatalf("body line too long: %d", len(l))
+		}
+	}
+}
+
+func TestSendValidation(t *testing.T) {
+	m := New(Conf{Host: "smtp.example.com"})
+
+	if err := m.Send(context.Background(), nil, "s", "b"); !errors.Is(err, ErrNoRecipient) {
+		t.Fatalf("expected ErrNoRecipient, got %v", err)
+	}
+	if err := m.Send(context.Background(), []string{"张三 <a@example.com>"}, "s", "b"); !errors.Is(err, ErrBadAddress) {
+		t.Fatalf("expected ErrBadAddress, got %v", err)
+	}
+}
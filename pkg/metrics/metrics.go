@@ -44,6 +44,146 @@ var (
 			Help: "Number of active WebSocket connections",
 		},
 	)
+
+	// WebSocket 消息数（in=收到 out=下发 dropped=被限流丢弃）
+	WebsocketMessagesTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "websocket_messages_total",
+			Help: "Total number of WebSocket messages",
+		},
+		[]string{"direction"},
+	)
+
+	// WebSocket 消息处理耗时
+	WebsocketHandleDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "websocket_handle_duration_seconds",
+			Help:    "WebSocket message handle duration in seconds",
+			Buckets: []float64{0.005, 0.01, 0.05, 0.1, 0.25, 0.5, 1, 2.5},
+		},
+		[]string{"handler"},
+	)
+
+	// AI 处理器耗时
+	AIHandlerDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "ai_handler_duration_seconds",
+			Help:    "AI handler duration in seconds",
+			Buckets: []float64{0.5, 1, 2.5, 5, 10, 20, 30, 60},
+		},
+		[]string{"handler", "status"},
+	)
+
+	// 模型调用次数（status: ok/error），错误率 = error / 全部
+	LLMRequestsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "llm_requests_total",
+			Help: "Total number of LLM requests",
+		},
+		[]string{"provider", "model", "status"},
+	)
+
+	// 模型调用耗时，流式调用为输出完成的时间
+	LLMRequestDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "llm_request_duration_seconds",
+			Help:    "LLM request duration in seconds",
+			Buckets: []float64{0.25, 0.5, 1, 2.5, 5, 10, 20, 30, 60},
+		},
+		[]string{"provider", "model"},
+	)
+
+	// 模型token用量（type: prompt/completion）
+	LLMTokensTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "llm_tokens_total",
+			Help: "Total number of LLM tokens",
+		},
+		[]string{"provider", "model", "type"},
+	)
+
+	// 异步任务执行次数（status: ok/error）
+	AsynqTasksTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "asynq_tasks_total",
+			Help: "Total number of processed asynq tasks",
+		},
+		[]string{"type", "status"},
+	)
+
+	// 异步任务执行耗时
+	AsynqTaskDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "asynq_task_duration_seconds",
+			Help:    "Asynq task duration in seconds",
+			Buckets: []float64{0.1, 0.5, 1, 2.5, 5, 10, 30, 60, 300},
+		},
+		[]string{"type"},
+	)
+
+	// 队列中各状态的任务数（state: pending/active/scheduled/retry/archived/completed）
+	AsynqQueueSize = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "asynq_queue_size",
+			Help: "Number of tasks in the asynq queue by state",
+		},
+		[]string{"queue", "state"},
+	)
+
+	// 队列当天处理的任务数（含失败）
+	AsynqQueueProcessed = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "asynq_queue_processed_today",
+			Help: "Number of tasks processed in the asynq queue today",
+		},
+		[]string{"queue"},
+	)
+
+	// 队列当天失败的任务数
+	AsynqQueueFailed = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "asynq_queue_failed_today",
+			Help: "Number of tasks failed in the asynq queue today",
+		},
+		[]string{"queue"},
+	)
+
+	// 队列中最早的待处理任务已等待的时间，积压时持续增长
+	AsynqQueueLatency = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "asynq_queue_latency_seconds",
+			Help: "Time the oldest pending task has been waiting in the asynq queue",
+		},
+		[]string{"queue"},
+	)
+
+	// 队列是否暂停（1=暂停）
+	AsynqQueuePaused = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "asynq_queue_paused",
+			Help: "Whether the asynq queue is paused",
+		},
+		[]string{"queue"},
+	)
+
+	// mongo命令执行次数（status: ok/error）
+	MongoCommandsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "mongo_commands_total",
+			Help: "Total number of MongoDB commands",
+		},
+		[]string{"command", "collection", "status"},
+	)
+
+	// mongo命令耗时，慢查询（如未命中索引的扫描）体现在高分位上
+	MongoCommandDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "mongo_command_duration_seconds",
+			Help:    "MongoDB command duration in seconds",
+			Buckets: []float64{0.001, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5},
+		},
+		[]string{"command", "collection"},
+	)
 )
 
 func init() {
@@ -52,6 +192,21 @@ func init() {
 		HttpRequestDuration,
 		ActiveConnections,
 		WebsocketConnections,
+		WebsocketMessagesTotal,
+		WebsocketHandleDuration,
+		AIHandlerDuration,
+		LLMRequestsTotal,
+		LLMRequestDuration,
+		LLMTokensTotal,
+		AsynqTasksTotal,
+		AsynqTaskDuration,
+		AsynqQueueSize,
+		AsynqQueueProcessed,
+		AsynqQueueFailed,
+		AsynqQueueLatency,
+		AsynqQueuePaused,
+		MongoCommandsTotal,
+		MongoCommandDuration,
 	)
 }
 
@@ -0,0 +1,71 @@
+package mongoutils
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Health 定期ping mongo，记录最近一次的结果供就绪检查使用；
+// 断开后驱动会自动重连，这里只记录状态变化，不重建客户端
+type Health struct {
+	client  *mongo.Client
+	timeout time.Duration
+
+	mu  sync.RWMutex
+	err error
+}
+
+func NewHealth(client *mongo.Client, timeout time.Duration) *Health {
+	if timeout <= 0 {
+		timeout = DefaultPingTimeout
+	}
+	return &Health{
+		client:  client,
+		timeout: timeout,
+	}
+}
+
+// Check 按连接的读偏好ping并记录结果，连接断开或恢复时打印日志
+func (h *Health) Check(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, h.timeout)
+	defer cancel()
+	err := h.client.Ping(ctx, nil)
+
+	h.mu.Lock()
+	prev := h.err
+	h.err = err
+	h.mu.Unlock()
+
+	switch {
+	case err != nil && prev == nil:
+		fmt.Printf("[Mongo] 连接检查失败: %v\n", err)
+	case err == nil && prev != nil:
+		fmt.Println("[Mongo] 连接已恢复")
+	}
+	return err
+}
+
+// Err 最近一次检查的结果，尚未检查时为nil
+func (h *Health) Err() error {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.err
+}
+
+// Run 按间隔检查，直到ctx取消
+func (h *Health) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			h.Check(ctx)
+		}
+	}
+}
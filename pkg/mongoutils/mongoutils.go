@@ -3,63 +3,185 @@ package mongoutils
 import (
 	"context"
 	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
 
+	"go.mongodb.org/mongo-driver/event"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/x/mongo/driver/connstring"
 )
 
 type MongodbConfig struct {
-	User        string
-	Password    string
-	Host        []string
-	Port        int
-	Database    string
-	Params      string
-	MaxPoolSize uint64
+	Uri      string   // 完整连接串（mongodb:// 或 mongodb+srv://），配置后不再使用下面的地址和账号字段拼接
+	Srv      bool     // 使用 mongodb+srv:// 通过DNS发现节点，Host只配置一个域名，不带端口
+	User     string   // 用户名和密码会做URL转义
+	Password string   //
+	Host     []string // 副本集配置多个节点，可带端口，如 10.0.0.1:27018
+	Port     int      // Host未带端口时使用
+	Database string   // 为空时使用 Uri 中的数据库
+	Params   string   // 其他连接参数，如 w=majority&retryWrites=true
+
+	AuthSource     string // 认证数据库，默认为 Database，Atlas等通常为 admin
+	ReplicaSet     string // 副本集名称
+	ReadPreference string // primary/primaryPreferred/secondary/secondaryPreferred/nearest
+	TLS            bool
+	TLSCAFile      string // 自签名证书的CA文件
+	TLSInsecure    bool   // 不校验服务端证书，仅用于测试
+
+	MaxPoolSize            uint64
+	MinPoolSize            uint64
+	ConnectTimeout         time.Duration // 为空且连接串未指定时使用 DefaultConnectTimeout
+	ServerSelectionTimeout time.Duration // 选择可用节点的超时，副本集切换主节点期间的请求在此时间内等待，默认 DefaultServerSelectionTimeout
+	SocketTimeout          time.Duration
+	PingTimeout            time.Duration // 启动时检查连通性的超时，默认 DefaultPingTimeout
+
+	Monitor *event.CommandMonitor // 命令监听，如链路追踪、指标，多个时用 ChainMonitors 合并
 }
 
-// 创建数据库链接
+// 驱动默认的选择节点超时为30秒，mongo不可用时请求会挂起这么久，默认缩短以便尽快失败
+const (
+	DefaultConnectTimeout         = 10 * time.Second
+	DefaultServerSelectionTimeout = 5 * time.Second
+	DefaultPingTimeout            = 10 * time.Second
+)
+
+// 创建数据库链接，连接后ping一次，mongo不可用时启动失败而不是在首个请求时才发现
 func MongoDatabase(cfg *MongodbConfig) (*mongo.Database, error) {
+	database, err := cfg.database()
+	if err != nil {
+		return nil, err
+	}
 	client, err := mongo.Connect(context.TODO(), cfg.BuildUri()...)
 	if err != nil {
 		return nil, err
 	}
 
-	return client.Database(cfg.Database), nil
+	timeout := cfg.PingTimeout
+	if timeout <= 0 {
+		timeout = DefaultPingTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	if err := client.Ping(ctx, nil); err != nil {
+		client.Disconnect(context.Background())
+		return nil, fmt.Errorf("ping mongo %s: %w", redactUri(cfg.ConnString()), err)
+	}
+
+	return client.Database(database), nil
 }
 
+// BuildUri 连接串和连接池、超时等选项
 func (cfg *MongodbConfig) BuildUri() []*options.ClientOptions {
-	var opt []*options.ClientOptions
-	uri := "mongodb://"
+	uri := cfg.ConnString()
+	opt := []*options.ClientOptions{options.Client().ApplyURI(uri)}
 
-	if len(cfg.User) > 0 && len(cfg.Password) > 0 {
-		uri = fmt.Sprintf("%v%v:%v@", uri, cfg.User, cfg.Password)
+	if cfg.MaxPoolSize > 0 {
+		opt = append(opt, options.Client().SetMaxPoolSize(cfg.MaxPoolSize))
 	}
-
-	for idx, v := range cfg.Host {
-		var host string
-		if cfg.Port != 0 {
-			host += v + fmt.Sprintf(":%d", cfg.Port)
-		} else {
-			host = v
+	if cfg.MinPoolSize > 0 {
+		opt = append(opt, options.Client().SetMinPoolSize(cfg.MinPoolSize))
+	}
+	// 连接串中指定的超时优先于默认值
+	connectTimeout, selectionTimeout := cfg.ConnectTimeout, cfg.ServerSelectionTimeout
+	if cs, err := connstring.Parse(uri); err == nil {
+		if connectTimeout <= 0 && !cs.ConnectTimeoutSet {
+			connectTimeout = DefaultConnectTimeout
+		}
+		if selectionTimeout <= 0 && !cs.ServerSelectionTimeoutSet {
+			selectionTimeout = DefaultServerSelectionTimeout
 		}
-		if idx < len(cfg.Host)-1 {
-			host += ", "
+	}
+	if connectTimeout > 0 {
+		opt = append(opt, options.Client().SetConnectTimeout(connectTimeout))
+	}
+	if selectionTimeout > 0 {
+		opt = append(opt, options.Client().SetServerSelectionTimeout(selectionTimeout))
+	}
+	if cfg.SocketTimeout > 0 {
+		opt = append(opt, options.Client().SetSocketTimeout(cfg.SocketTimeout))
+	}
+	if cfg.Monitor != nil {
+		opt = append(opt, options.Client().SetMonitor(cfg.Monitor))
+	}
+	fmt.Println("uri:", redactUri(uri))
+	return opt
+}
+
+// ConnString 连接串，配置了 Uri 时直接使用，否则由地址、账号和连接参数拼接；单独配置的参数优先于 Params 中的同名参数
+func (cfg *MongodbConfig) ConnString() string {
+	if len(cfg.Uri) > 0 {
+		return cfg.Uri
+	}
+
+	u := url.URL{Scheme: "mongodb", Path: "/" + cfg.Database}
+	hosts := make([]string, 0, len(cfg.Host))
+	for _, v := range cfg.Host {
+		v = strings.TrimSpace(v)
+		// srv 记录中已包含端口
+		if !cfg.Srv && cfg.Port != 0 && !strings.Contains(v, ":") {
+			v += ":" + strconv.Itoa(cfg.Port)
 		}
-		uri += host
+		hosts = append(hosts, v)
+	}
+	u.Host = strings.Join(hosts, ",")
+	if cfg.Srv {
+		u.Scheme = "mongodb+srv"
 	}
 
-	uri += fmt.Sprintf("/%s", cfg.Database)
+	if len(cfg.User) > 0 {
+		if len(cfg.Password) > 0 {
+			u.User = url.UserPassword(cfg.User, cfg.Password)
+		} else {
+			u.User = url.User(cfg.User)
+		}
+	}
 
-	if len(cfg.Params) > 0 {
-		uri = fmt.Sprintf("%v?%v", uri, cfg.Params)
+	query, _ := url.ParseQuery(cfg.Params)
+	set := func(key, value string) {
+		if len(value) > 0 {
+			query.Set(key, value)
+		}
+	}
+	set("authSource", cfg.AuthSource)
+	set("replicaSet", cfg.ReplicaSet)
+	set("readPreference", cfg.ReadPreference)
+	if cfg.TLS {
+		set("tls", "true")
+		set("tlsCAFile", cfg.TLSCAFile)
+		if cfg.TLSInsecure {
+			set("tlsInsecure", "true")
+		}
 	}
+	u.RawQuery = query.Encode()
+	return u.String()
+}
 
-	opt = append(opt, options.Client().ApplyURI(uri))
+// database 配置的数据库，未配置时取连接串中的数据库
+func (cfg *MongodbConfig) database() (string, error) {
+	if len(cfg.Database) > 0 {
+		return cfg.Database, nil
+	}
+	cs, err := connstring.Parse(cfg.ConnString())
+	if err != nil {
+		return "", err
+	}
+	if len(cs.Database) == 0 {
+		return "", fmt.Errorf("mongo database not configured")
+	}
+	return cs.Database, nil
+}
 
-	if cfg.MaxPoolSize > 0 {
-		opt = append(opt, options.Client().SetMaxPoolSize(cfg.MaxPoolSize))
+// redactUri 隐藏连接串中的密码，用于打印
+func redactUri(uri string) string {
+	u, err := url.Parse(uri)
+	if err != nil || u.User == nil {
+		return uri
 	}
-	fmt.Println("uri:", uri)
-	return opt
+	if _, ok := u.User.Password(); ok {
+		u.User = url.UserPassword(u.User.Username(), "***")
+	}
+	return u.String()
 }
@@ -0,0 +1,72 @@
+package mongoutils
+
+import (
+	"context"
+	"sync"
+
+	"aiOffice/pkg/metrics"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/event"
+)
+
+// MetricsMonitor 按命令和集合记录mongo命令的次数和耗时
+func MetricsMonitor() *event.CommandMonitor {
+	var colls sync.Map // RequestID -> 集合名
+	finish := func(evt event.CommandFinishedEvent, status string) {
+		coll := ""
+		if v, ok := colls.LoadAndDelete(evt.RequestID); ok {
+			coll = v.(string)
+		}
+		metrics.MongoCommandsTotal.WithLabelValues(evt.CommandName, coll, status).Inc()
+		metrics.MongoCommandDuration.WithLabelValues(evt.CommandName, coll).Observe(evt.Duration.Seconds())
+	}
+
+	return &event.CommandMonitor{
+		Started: func(_ context.Context, evt *event.CommandStartedEvent) {
+			colls.Store(evt.RequestID, commandCollection(evt.CommandName, evt.Command))
+		},
+		Succeeded: func(_ context.Context, evt *event.CommandSucceededEvent) {
+			finish(evt.CommandFinishedEvent, "ok")
+		},
+		Failed: func(_ context.Context, evt *event.CommandFailedEvent) {
+			finish(evt.CommandFinishedEvent, "error")
+		},
+	}
+}
+
+// commandCollection 命令操作的集合，getMore的集合在collection字段中；ping等命令没有集合
+func commandCollection(name string, cmd bson.Raw) string {
+	if name == "getMore" {
+		name = "collection"
+	}
+	coll, _ := cmd.Lookup(name).StringValueOK()
+	return coll
+}
+
+// ChainMonitors 合并多个命令监听，按顺序调用
+func ChainMonitors(monitors ...*event.CommandMonitor) *event.CommandMonitor {
+	return &event.CommandMonitor{
+		Started: func(ctx context.Context, evt *event.CommandStartedEvent) {
+			for _, m := range monitors {
+				if m != nil && m.Started != nil {
+					m.Started(ctx, evt)
+				}
+			}
+		},
+		Succeeded: func(ctx context.Context, evt *event.CommandSucceededEvent) {
+			for _, m := range monitors {
+				if m != nil && m.Succeeded != nil {
+					m.Succeeded(ctx, evt)
+				}
+			}
+		},
+		Failed: func(ctx context.Context, evt *event.CommandFailedEvent) {
+			for _, m := range monitors {
+				if m != nil && m.Failed != nil {
+					m.Failed(ctx, evt)
+				}
+			}
+		},
+	}
+}
@@ -0,0 +1,53 @@
+package mongoutils
+
+import (
+	"context"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// 各连接是否支持事务，首次使用时探测
+var txnSupported sync.Map // *mongo.Client -> bool
+
+// WithTransaction 在事务中执行fn，fn内的读写须使用传入的ctx；
+// 单机部署不支持事务，此时直接执行fn
+func WithTransaction(ctx context.Context, db *mongo.Database, fn func(ctx context.Context) error) error {
+	client := db.Client()
+	if !supportsTransaction(ctx, db) {
+		return fn(ctx)
+	}
+
+	sess, err := client.StartSession()
+	if err != nil {
+		return err
+	}
+	defer sess.EndSession(ctx)
+
+	// 遇到瞬时错误时驱动会重试整个回调，fn须可重复执行
+	_, err = sess.WithTransaction(ctx, func(sc mongo.SessionContext) (interface{}, error) {
+		return nil, fn(sc)
+	})
+	return err
+}
+
+// supportsTransaction 副本集和分片集群支持事务
+func supportsTransaction(ctx context.Context, db *mongo.Database) bool {
+	client := db.Client()
+	if v, ok := txnSupported.Load(client); ok {
+		return v.(bool)
+	}
+
+	var res struct {
+		SetName string `bson:"setName"`
+		Msg     string `bson:"msg"`
+	}
+	if err := db.RunCommand(ctx, bson.D{{Key: "hello", Value: 1}}).Decode(&res); err != nil {
+		// 探测失败不缓存，下次重试
+		return false
+	}
+	ok := res.SetName != "" || res.Msg == "isdbgrid"
+	txnSupported.Store(client, ok)
+	return ok
+}
@@ -0,0 +1,169 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+)
+
+const (
+	apnsProductionHost  = "https://api.push.apple.com"
+	apnsDevelopmentHost = "https://api.sandbox.push.apple.com"
+)
+
+// Apns Apple Push Notification service 推送渠道（基于 .p8 密钥的 token 认证）
+type Apns struct {
+	key    *ecdsa.PrivateKey
+	keyId  string
+	teamId string
+	topic  string
+	host   string
+	tokens TokenStore
+	client *http.Client
+
+	mu       sync.Mutex
+	bearer   string
+	issuedAt time.Time
+}
+
+func NewApns(keyFile, keyId, teamId, topic string, production bool, tokens TokenStore) (*Apns, error) {
+	b, err := os.ReadFile(keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("read apns key failed: %w", err)
+	}
+
+	key, err := jwt.ParseECPrivateKeyFromPEM(b)
+	if err != nil {
+		return nil, fmt.Errorf("parse apns key failed: %w", err)
+	}
+
+	host := apnsDevelopmentHost
+	if production {
+		host = apnsProductionHost
+	}
+
+	return &Apns{
+		key:    key,
+		keyId:  keyId,
+		teamId: teamId,
+		topic:  topic,
+		host:   host,
+		tokens: tokens,
+		// APNs 只支持 HTTP/2，默认 Transport 会在 TLS 握手时自动协商
+		client: &http.Client{Timeout: 10 * time.Second},
+	}, nil
+}
+
+func (a *Apns) Name() string {
+	return PlatformApns
+}
+
+func (a *Apns) Send(ctx context.Context, uid string, msg *Message) error {
+	tokens, err := a.tokens.FindTokens(ctx, uid, PlatformApns)
+	if err != nil {
+		return err
+	}
+	if len(tokens) == 0 {
+		return ErrNoDevice
+	}
+
+	bearer, err := a.token()
+	if err != nil {
+		return err
+	}
+
+	payload := map[string]any{
+		"aps": map[string]any{
+			"alert": map[string]string{
+				"title": msg.Title,
+				"body":  msg.Content,
+			},
+			"sound": "default",
+		},
+		"type": msg.Type,
+	}
+	for k, v := range msg.Data {
+		payload[k] = v
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	var lastErr error
+	sent := 0
+	for _, tok := range tokens {
+		unregistered, err := a.sendOne(ctx, bearer, tok, body)
+		if unregistered {
+			_ = a.tokens.RemoveToken(ctx, tok)
+		}
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		sent++
+	}
+	if sent == 0 {
+		return lastErr
+	}
+	return nil
+}
+
+func (a *Apns) sendOne(ctx context.Context, bearer, deviceToken string, body []byte) (unregistered bool, err error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.host+"/3/device/"+deviceToken, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("authorization", "bearer "+bearer)
+	req.Header.Set("apns-topic", a.topic)
+	req.Header.Set("apns-push-type", "alert")
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusOK {
+		return false, nil
+	}
+
+	b, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	// 410 表示设备令牌已失效
+	return resp.StatusCode == http.StatusGone, fmt.Errorf("apns status %d: %s", resp.StatusCode, string(b))
+}
+
+// token 生成 provider token，苹果要求20~60分钟内刷新一次
+func (a *Apns) token() (string, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.bearer != "" && time.Since(a.issuedAt) < 50*time.Minute {
+		return a.bearer, nil
+	}
+
+	now := time.Now()
+	t := jwt.NewWithClaims(jwt.SigningMethodES256, jwt.MapClaims{
+		"iss": a.teamId,
+		"iat": now.Unix(),
+	})
+	t.Header["kid"] = a.keyId
+
+	bearer, err := t.SignedString(a.key)
+	if err != nil {
+		return "", err
+	}
+
+	a.bearer = bearer
+	a.issuedAt = now
+	return bearer, nil
+}
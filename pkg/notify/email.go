@@ -0,0 +1,54 @@
+package notify
+
+import (
+	"context"
+
+	"aiOffice/pkg/mailer"
+)
+
+// PlatformEmail 邮件渠道
+const PlatformEmail = "email"
+
+// AddressBook 用户邮箱查询
+type AddressBook interface {
+	FindEmail(ctx context.Context, userId string) (string, error)
+}
+
+// Email 邮件推送渠道，只投递指定类型的消息，避免提醒类通知刷屏邮箱
+type Email struct {
+	mailer *mailer.Mailer
+	book   AddressBook
+	types  map[string]bool
+}
+
+func NewEmail(m *mailer.Mailer, book AddressBook, types []string) *Email {
+	e := &Email{
+		mailer: m,
+		book:   book,
+		types:  make(map[string]bool, len(types)),
+	}
+	for _, t := range types {
+		e.types[t] = true
+	}
+	return e
+}
+
+func (e *Email) Name() string {
+	return PlatformEmail
+}
+
+func (e *Email) Send(ctx context.Context, uid string, msg *Message) error {
+	// 未配置类型时投递全部消息
+	if len(e.types) > 0 && !e.types[msg.Type] {
+		return ErrNoDevice
+	}
+
+	addr, err := e.book.FindEmail(ctx, uid)
+	if err != nil {
+		return err
+	}
+	if addr == "" {
+		return ErrNoDevice
+	}
+	return e.mailer.Send(ctx, []string{addr}, msg.Title, msg.Content)
+}
@@ -0,0 +1,207 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+)
+
+const (
+	fcmScope   = "https://www.googleapis.com/auth/firebase.messaging"
+	fcmSendUrl = "https://fcm.googleapis.com/v1/projects/%s/messages:send"
+)
+
+// serviceAccount Firebase 服务账号凭证（从控制台下载的 json 文件）
+type serviceAccount struct {
+	ProjectId   string `json:"project_id"`
+	ClientEmail string `json:"client_email"`
+	PrivateKey  string `json:"private_key"`
+	TokenUri    string `json:"token_uri"`
+}
+
+// Fcm Firebase Cloud Messaging（HTTP v1）推送渠道
+type Fcm struct {
+	account *serviceAccount
+	tokens  TokenStore
+	client  *http.Client
+
+	mu          sync.Mutex
+	accessToken string
+	expireAt    time.Time
+}
+
+func NewFcm(credentialsFile string, tokens TokenStore) (*Fcm, error) {
+	b, err := os.ReadFile(credentialsFile)
+	if err != nil {
+		return nil, fmt.Errorf("read fcm credentials failed: %w", err)
+	}
+
+	var account serviceAccount
+	if err := json.Unmarshal(b, &account); err != nil {
+		return nil, fmt.Errorf("parse fcm credentials failed: %w", err)
+	}
+	if account.TokenUri == "" {
+		account.TokenUri = "https://oauth2.googleapis.com/token"
+	}
+
+	return &Fcm{
+		account: &account,
+		tokens:  tokens,
+		client:  &http.Client{Timeout: 10 * time.Second},
+	}, nil
+}
+
+func (f *Fcm) Name() string {
+	return PlatformFcm
+}
+
+func (f *Fcm) Send(ctx context.Context, uid string, msg *Message) error {
+	tokens, err := f.tokens.FindTokens(ctx, uid, PlatformFcm)
+	if err != nil {
+		return err
+	}
+	if len(tokens) == 0 {
+		return ErrNoDevice
+	}
+
+	accessToken, err := f.token(ctx)
+	if err != nil {
+		return err
+	}
+
+	var lastErr error
+	sent := 0
+	for _, tok := range tokens {
+		unregistered, err := f.sendOne(ctx, accessToken, tok, msg)
+		if unregistered {
+			// 设备已卸载或令牌失效，清理掉
+			_ = f.tokens.RemoveToken(ctx, tok)
+		}
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		sent++
+	}
+	if sent == 0 {
+		return lastErr
+	}
+	return nil
+}
+
+func (f *Fcm) sendOne(ctx context.Context, accessToken, deviceToken string, msg *Message) (unregistered bool, err error) {
+	body, err := json.Marshal(map[string]any{
+		"message": map[string]any{
+			"token": deviceToken,
+			"notification": map[string]string{
+				"title": msg.Title,
+				"body":  msg.Content,
+			},
+			"data": fcmData(msg),
+		},
+	})
+	if err != nil {
+		return false, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, fmt.Sprintf(fcmSendUrl, f.account.ProjectId), bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusOK {
+		return false, nil
+	}
+
+	b, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	unregistered = resp.StatusCode == http.StatusNotFound || strings.Contains(string(b), "UNREGISTERED")
+	return unregistered, fmt.Errorf("fcm status %d: %s", resp.StatusCode, string(b))
+}
+
+// token 获取 OAuth2 access token，过期前复用
+func (f *Fcm) token(ctx context.Context) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.accessToken != "" && time.Now().Before(f.expireAt) {
+		return f.accessToken, nil
+	}
+
+	key, err := jwt.ParseRSAPrivateKeyFromPEM([]byte(f.account.PrivateKey))
+	if err != nil {
+		return "", fmt.Errorf("parse fcm private key failed: %w", err)
+	}
+
+	now := time.Now().Unix()
+	assertion, err := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"iss":   f.account.ClientEmail,
+		"scope": fcmScope,
+		"aud":   f.account.TokenUri,
+		"iat":   now,
+		"exp":   now + 3600,
+	}).SignedString(key)
+	if err != nil {
+		return "", err
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "urn:ietf:params:oauth:grant-type:jwt-bearer")
+	form.Set("assertion", assertion)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, f.account.TokenUri, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var res struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int64  `json:"expires_in"`
+		Error       string `json:"error"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
+		return "", err
+	}
+	if res.AccessToken == "" {
+		return "", fmt.Errorf("fcm oauth failed: %s", res.Error)
+	}
+
+	// 提前一分钟刷新
+	f.accessToken = res.AccessToken
+	f.expireAt = time.Now().Add(time.Duration(res.ExpiresIn-60) * time.Second)
+	return f.accessToken, nil
+}
+
+// fcmData FCM 的 data 字段只允许字符串值
+func fcmData(msg *Message) map[string]string {
+	data := make(map[string]string, len(msg.Data)+1)
+	for k, v := range msg.Data {
+		data[k] = v
+	}
+	data["type"] = msg.Type
+	return data
+}
@@ -0,0 +1,225 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+)
+
+// 推送渠道平台
+const (
+	PlatformFcm  = "fcm"  // Android / Firebase
+	PlatformApns = "apns" // iOS
+)
+
+var (
+	ErrNoChannel = errors.New("没有可用的推送渠道")
+	ErrNoDevice  = errors.New("用户没有注册推送设备")
+
+	// ErrRateLimited 渠道限流，与 ErrNoDevice 一样不视为发送失败
+	ErrRateLimited = errors.New("发送过于频繁")
+)
+
+// Message 推送消息
+type Message struct {
+	Type    string            `json:"type"`             // 消息类型，如 reminder:todo
+	Title   string            `json:"title"`            // 标题
+	Content string            `json:"content"`          // 内容
+	Data    map[string]string `json:"data,omitempty"`   // 附加数据
+	Time    int64             `json:"time"`             // 发送时间戳
+	Urgent  bool              `json:"urgent,omitempty"` // 紧急通知（审批被拒、超时未处理等），短信渠道只发送紧急通知
+}
+
+// Sender 离线推送渠道
+type Sender interface {
+	Name() string
+	Send(ctx context.Context, uid string, msg *Message) error
+}
+
+// Presence 在线通道（WebSocket），用户在线时直接推送
+type Presence interface {
+	IsOnline(uid string) bool
+	Push(ctx context.Context, uid string, msg *Message) error
+	// OnlineUsers 当前在线的用户
+	OnlineUsers() []string
+}
+
+// TokenStore 设备令牌存储，FCM/APNs 渠道通过它查找用户设备
+type TokenStore interface {
+	FindTokens(ctx context.Context, userId, platform string) ([]string, error)
+	RemoveToken(ctx context.Context, token string) error
+}
+
+// Notifier 通知网关：在线走 WebSocket，离线走配置的推送渠道
+type Notifier struct {
+	sync.RWMutex
+	presence Presence
+	relay    Remote
+	senders  []Sender
+}
+
+func NewNotifier(senders ...Sender) *Notifier {
+	return &Notifier{
+		senders: senders,
+	}
+}
+
+// SetPresence 设置在线通道
+func (n *Notifier) SetPresence(p Presence) {
+	n.Lock()
+	defer n.Unlock()
+	n.presence = p
+}
+
+// SetRelay 设置转发通道，WS网关与api、worker分开部署时在线推送经它转发
+func (n *Notifier) SetRelay(r Remote) {
+	n.Lock()
+	defer n.Unlock()
+	n.relay = r
+}
+
+// SetOnline WS网关的用户上线或下线时调用，记录到转发通道供其他进程查询
+func (n *Notifier) SetOnline(ctx context.Context, uid string, online bool) {
+	n.RLock()
+	relay := n.relay
+	n.RUnlock()
+	if relay != nil {
+		relay.SetOnline(ctx, uid, online)
+	}
+}
+
+// onlineUsers 本进程的在线用户
+func (n *Notifier) onlineUsers() []string {
+	n.RLock()
+	presence := n.presence
+	n.RUnlock()
+	if presence == nil {
+		return nil
+	}
+	return presence.OnlineUsers()
+}
+
+// Subscribe 接收其他进程转发的消息并推送给本进程的在线用户（阻塞），未设置转发通道时直接返回
+func (n *Notifier) Subscribe(ctx context.Context) error {
+	n.RLock()
+	relay := n.relay
+	n.RUnlock()
+	if relay == nil {
+		return nil
+	}
+	return relay.Run(ctx, n)
+}
+
+// Senders 返回已配置的离线推送渠道名称
+func (n *Notifier) Senders() []string {
+	names := make([]string, 0, len(n.senders))
+	for _, s := range n.senders {
+		names = append(names, s.Name())
+	}
+	return names
+}
+
+// Notify 向用户发送通知
+func (n *Notifier) Notify(ctx context.Context, uid string, msg *Message) error {
+	return n.NotifyChannels(ctx, uid, msg, nil)
+}
+
+// NotifyChannels 同 Notify，离线时只使用 channels 中的渠道，为空使用全部渠道；在线推送不受影响
+func (n *Notifier) NotifyChannels(ctx context.Context, uid string, msg *Message, channels []string) error {
+	n.RLock()
+	presence, relay := n.presence, n.relay
+	n.RUnlock()
+
+	// 用户在线，直接通过WebSocket推送；连接在其他进程的WS网关时经转发通道推送
+	switch {
+	case presence != nil && presence.IsOnline(uid):
+		err := presence.Push(ctx, uid, msg)
+		if err == nil {
+			return nil
+		}
+		fmt.Printf("[Notify] 在线推送失败, 改用离线渠道, uid: %s, err: %v\n", uid, err)
+	case relay != nil && relay.IsOnline(ctx, uid):
+		err := relay.Publish(ctx, []string{uid}, msg)
+		if err == nil {
+			return nil
+		}
+		fmt.Printf("[Notify] 转发在线推送失败, 改用离线渠道, uid: %s, err: %v\n", uid, err)
+	}
+
+	senders := n.senders
+	if len(channels) > 0 {
+		senders = make([]Sender, 0, len(n.senders))
+		for _, s := range n.senders {
+			if slices.Contains(channels, s.Name()) {
+				senders = append(senders, s)
+			}
+		}
+	}
+	if len(senders) == 0 {
+		return ErrNoChannel
+	}
+
+	// 离线则通过所有渠道推送（用户可能同时有多台设备），任一成功即视为送达
+	var (
+		errs      []error
+		delivered bool
+	)
+	for _, s := range senders {
+		err := s.Send(ctx, uid, msg)
+		switch {
+		case err == nil:
+			delivered = true
+		case errors.Is(err, ErrNoDevice), errors.Is(err, ErrRateLimited):
+		default:
+			errs = append(errs, fmt.Errorf("%s: %w", s.Name(), err))
+		}
+	}
+	if delivered {
+		return nil
+	}
+	if len(errs) == 0 {
+		return ErrNoDevice
+	}
+	return errors.Join(errs...)
+}
+
+// Broadcast 只推送给在线用户，不使用离线渠道，uids为nil时推送给全部在线用户；
+// 设置了转发通道时发布给所有WS网关推送，否则推送给本进程的在线用户
+func (n *Notifier) Broadcast(ctx context.Context, uids []string, msg *Message) error {
+	n.RLock()
+	relay := n.relay
+	n.RUnlock()
+	if relay != nil {
+		return relay.Publish(ctx, uids, msg)
+	}
+	n.BroadcastLocal(ctx, uids, msg)
+	return nil
+}
+
+// BroadcastLocal 推送给本进程的在线用户，返回推送成功的人数
+func (n *Notifier) BroadcastLocal(ctx context.Context, uids []string, msg *Message) int {
+	n.RLock()
+	presence := n.presence
+	n.RUnlock()
+	if presence == nil {
+		return 0
+	}
+
+	if uids == nil {
+		uids = presence.OnlineUsers()
+	}
+	sent := 0
+	for _, uid := range uids {
+		if !presence.IsOnline(uid) {
+			continue
+		}
+		if err := presence.Push(ctx, uid, msg); err != nil {
+			fmt.Printf("[Notify] 在线推送失败, uid: %s, err: %v\n", uid, err)
+			continue
+		}
+		sent++
+	}
+	return sent
+}
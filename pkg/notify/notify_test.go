@@ -0,0 +1,315 @@
+package notify
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"aiOffice/pkg/sms"
+)
+
+type fakePresence struct {
+	online map[string]bool
+	pushed []string
+}
+
+func (p *fakePresence) IsOnline(uid string) bool { return p.online[uid] }
+
+func (p *fakePresence) OnlineUsers() []string {
+	var res []string
+	for uid, ok := range p.online {
+		if ok {
+			res = append(res, uid)
+		}
+	}
+	return res
+}
+
+func (p *fakePresence) Push(ctx context.Context, uid string, msg *Message) error {
+	p.pushed = append(p.pushed, uid)
+	return nil
+}
+
+type fakeSender struct {
+	err  error
+	sent []string
+}
+
+func (s *fakeSender) Name() string { return "fake" }
+
+func (s *fakeSender) Send(ctx context.Context, uid string, msg *Message) error {
+	if s.err != nil {
+		return s.err
+	}
+	s.sent = append(s.sent, uid)
+	return nil
+}
+
+func TestNotifierOnline(t *testing.T) {
+	sender := &fakeSender{}
+	presence := &fakePresence{online: map[string]bool{"u1": true}}
+
+	n := NewNotifier(sender)
+	n.SetPresence(presence)
+
+	if err := n.Notify(context.Background(), "u1", &Message{Title: "t"}); err != nil {
+		t.Fatal(err)
+	}
+	if len(presence.pushed) != 1 || len(sender.sent) != 0 {
+		t.Errorf("online user should only be pushed via presence, pushed %v sent %v", presence.pushed, sender.sent)
+	}
+}
+
+func TestNotifierBroadcast(t *testing.T) {
+	sender := &fakeSender{}
+	presence := &fakePresence{online: map[string]bool{"u1": true, "u2": true}}
+
+	n := NewNotifier(sender)
+	if got := n.BroadcastLocal(context.Background(), nil, &Message{}); got != 0 {
+		t.Errorf("no presence should push nothing, got %d", got)
+	}
+
+	n.SetPresence(presence)
+	if got := n.BroadcastLocal(context.Background(), []string{"u1", "u3"}, &Message{}); got != 1 {
+		t.Errorf("only online users should be pushed, got %d", got)
+	}
+	if got := n.BroadcastLocal(context.Background(), nil, &Message{}); got != 2 {
+		t.Errorf("nil uids should push all online users, got %d", got)
+	}
+	if len(sender.sent) != 0 {
+		t.Errorf("broadcast should not use offline senders, got %v", sender.sent)
+	}
+}
+
+func TestRelayHandle(t *testing.T) {
+	presence := &fakePresence{online: map[string]bool{"u1": true, "u2": true}}
+	n := NewNotifier()
+	n.SetPresence(presence)
+
+	r := NewRelay(nil, "test")
+	r.handle(context.Background(), `{"uids":["u2","u3"],"msg":{"type":"announcement:publish","title":"t"}}`, n)
+	r.handle(context.Background(), `not json`, n)
+	if len(presence.pushed) != 1 || presence.pushed[0] != "u2" {
+		t.Errorf("relayed broadcast should push online target users, got %v", presence.pushed)
+	}
+}
+
+// fakeRemote 模拟Redis转发，在线状态和消息在各进程的Notifier之间共享
+type fakeRemote struct {
+	online   map[string]bool
+	gateways []*Notifier
+}
+
+func (r *fakeRemote) IsOnline(ctx context.Context, uid string) bool { return r.online[uid] }
+
+func (r *fakeRemote) Publish(ctx context.Context, uids []string, msg *Message) error {
+	for _, g := range r.gateways {
+		g.BroadcastLocal(ctx, uids, msg)
+	}
+	return nil
+}
+
+func (r *fakeRemote) SetOnline(ctx context.Context, uid string, online bool) { r.online[uid] = online }
+
+func (r *fakeRemote) Run(ctx context.Context, n *Notifier) error { return nil }
+
+// api、worker与WS网关分开部署：api进程没有在线通道，在线用户的通知经转发推送到网关
+func TestNotifierRelay(t *testing.T) {
+	presence := &fakePresence{online: map[string]bool{"u1": true}}
+	gateway := NewNotifier()
+	gateway.SetPresence(presence)
+	remote := &fakeRemote{online: map[string]bool{}, gateways: []*Notifier{gateway}}
+	gateway.SetRelay(remote)
+	gateway.SetOnline(context.Background(), "u1", true)
+
+	sender := &fakeSender{}
+	api := NewNotifier(sender)
+	api.SetRelay(remote)
+
+	if err := api.Notify(context.Background(), "u1", &Message{Title: "t"}); err != nil {
+		t.Fatal(err)
+	}
+	if len(presence.pushed) != 1 || len(sender.sent) != 0 {
+		t.Errorf("online user should be pushed via gateway, pushed %v sent %v", presence.pushed, sender.sent)
+	}
+
+	if err := api.Notify(context.Background(), "u2", &Message{Title: "t"}); err != nil {
+		t.Fatal(err)
+	}
+	if len(sender.sent) != 1 || sender.sent[0] != "u2" {
+		t.Errorf("offline user should use offline senders, got %v", sender.sent)
+	}
+
+	gateway.SetOnline(context.Background(), "u1", false)
+	api.Notify(context.Background(), "u1", &Message{Title: "t"})
+	if len(presence.pushed) != 1 || len(sender.sent) != 2 {
+		t.Errorf("user gone offline should use offline senders, pushed %v sent %v", presence.pushed, sender.sent)
+	}
+}
+
+func TestNotifierOffline(t *testing.T) {
+	failed := &fakeSender{err: errors.New("boom")}
+	noDevice := &fakeSender{err: ErrNoDevice}
+	ok := &fakeSender{}
+
+	n := NewNotifier(failed, noDevice, ok)
+	n.SetPresence(&fakePresence{})
+
+	if err := n.Notify(context.Background(), "u1", &Message{}); err != nil {
+		t.Fatalf("should be delivered when one channel succeeds, got %v", err)
+	}
+	if len(ok.sent) != 1 {
+		t.Errorf("expected ok sender to be used, got %v", ok.sent)
+	}
+
+	n = NewNotifier(noDevice)
+	if err := n.Notify(context.Background(), "u1", &Message{}); !errors.Is(err, ErrNoDevice) {
+		t.Errorf("expected ErrNoDevice, got %v", err)
+	}
+
+	n = NewNotifier()
+	if err := n.Notify(context.Background(), "u1", &Message{}); !errors.Is(err, ErrNoChannel) {
+		t.Errorf("expected ErrNoChannel, got %v", err)
+	}
+}
+
+func TestWebhookSignature(t *testing.T) {
+	var (
+		body []byte
+		sign string
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ = io.ReadAll(r.Body)
+		sign = r.Header.Get("X-Signature")
+	}))
+	defer srv.Close()
+
+	w := NewWebhook(srv.URL, "secret")
+	if err := w.Send(context.Background(), "u1", &Message{Title: "t", Content: "c"}); err != nil {
+		t.Fatal(err)
+	}
+
+	mac := hmac.New(sha256.New, []byte("secret"))
+	mac.Write(body)
+	if sign != hex.EncodeToString(mac.Sum(nil)) {
+		t.Errorf("invalid signature %s", sign)
+	}
+}
+
+type namedSender struct {
+	fakeSender
+	name string
+}
+
+func (s *namedSender) Name() string { return s.name }
+
+func TestNotifyChannels(t *testing.T) {
+	email := &namedSender{name: PlatformEmail}
+	fcm := &namedSender{name: PlatformFcm}
+	n := NewNotifier(email, fcm)
+
+	if err := n.NotifyChannels(context.Background(), "u1", &Message{}, []string{PlatformFcm}); err != nil {
+		t.Fatal(err)
+	}
+	if len(email.sent) != 0 || len(fcm.sent) != 1 {
+		t.Errorf("only fcm should be used, email %v fcm %v", email.sent, fcm.sent)
+	}
+
+	if err := n.NotifyChannels(context.Background(), "u1", &Message{}, []string{"sms"}); !errors.Is(err, ErrNoChannel) {
+		t.Errorf("expected ErrNoChannel, got %v", err)
+	}
+}
+
+type fakeSmsProvider struct {
+	sent []string
+}
+
+func (p *fakeSmsProvider) Name() string { return "fake" }
+
+func (p *fakeSmsProvider) Send(ctx context.Context, phone string, tpl sms.Template, params map[string]string) error {
+	p.sent = append(p.sent, phone+" "+tpl.Code+" "+params["title"]+" "+params["approvalId"])
+	return nil
+}
+
+type fakePhoneBook map[string]string
+
+func (b fakePhoneBook) FindPhone(ctx context.Context, userId string) (string, error) {
+	return b[userId], nil
+}
+
+// fakeLimiter 每个key只放行一次
+type fakeLimiter map[string]bool
+
+func (l fakeLimiter) Allow(ctx context.Context, key string) bool {
+	if l[key] {
+		return false
+	}
+	l[key] = true
+	return true
+}
+
+func TestSms(t *testing.T) {
+	provider := &fakeSmsProvider{}
+	templates := map[string]sms.Template{"approval:result": {Code: "SMS_1"}}
+	s := NewSms(provider, fakePhoneBook{"u1": "13800138000"}, templates, fakeLimiter{})
+	n := NewNotifier(s)
+
+	urgent := &Message{Type: "approval:result", Title: "审批结果", Data: map[string]string{"approvalId": "a1"}, Urgent: true}
+	if err := n.Notify(context.Background(), "u1", urgent); err != nil {
+		t.Fatal(err)
+	}
+	if len(provider.sent) != 1 || provider.sent[0] != "13800138000 SMS_1 审批结果 a1" {
+		t.Fatalf("unexpected sms: %v", provider.sent)
+	}
+
+	// 非紧急、没有模板、没有手机号和超过频率限制的都不发送
+	cases := []struct {
+		uid string
+		msg *Message
+	}{
+		{"u1", &Message{Type: "approval:result"}},
+		{"u1", &Message{Type: "reminder:todo", Urgent: true}},
+		{"u2", urgent},
+		{"u1", urgent},
+	}
+	for _, c := range cases {
+		if err := n.Notify(context.Background(), c.uid, c.msg); !errors.Is(err, ErrNoDevice) {
+			t.Errorf("%s %+v: expected ErrNoDevice, got %v", c.uid, c.msg, err)
+		}
+	}
+	if len(provider.sent) != 1 {
+		t.Errorf("unexpected sms: %v", provider.sent)
+	}
+}
+
+func TestQuietUntil(t *testing.T) {
+	at := func(hour, min int) time.Time { return time.Date(2024, 5, 1, hour, min, 0, 0, time.UTC) }
+
+	cases := []struct {
+		start, end string
+		now        time.Time
+		quiet      bool
+		until      time.Time
+	}{
+		{"12:00", "14:00", at(12, 30), true, at(14, 0)},
+		{"12:00", "14:00", at(14, 0), false, time.Time{}},
+		{"22:00", "08:00", at(23, 0), true, at(8, 0).AddDate(0, 0, 1)},
+		{"22:00", "08:00", at(7, 59), true, at(8, 0)},
+		{"22:00", "08:00", at(9, 0), false, time.Time{}},
+		{"", "08:00", at(7, 0), false, time.Time{}},
+		{"bad", "08:00", at(7, 0), false, time.Time{}},
+	}
+	for _, c := range cases {
+		until, quiet := QuietUntil(c.start, c.end, c.now)
+		if quiet != c.quiet || !until.Equal(c.until) {
+			t.Errorf("QuietUntil(%s, %s, %s) = %s, %v", c.start, c.end, c.now.Format("15:04"), until, quiet)
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package notify
+
+import (
+	"fmt"
+	"time"
+)
+
+// ParseClock 解析 HH:MM，返回零点起的分钟数
+func ParseClock(s string) (int, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("时间格式应为 HH:MM: %s", s)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// QuietUntil now 处于 [start, end) 免打扰时段时返回时段结束的时间；start 晚于 end 表示跨零点，如 22:00-08:00。
+// start、end 任一为空或格式错误时视为没有免打扰
+func QuietUntil(start, end string, now time.Time) (time.Time, bool) {
+	if start == "" || end == "" {
+		return time.Time{}, false
+	}
+	s, err1 := ParseClock(start)
+	e, err2 := ParseClock(end)
+	if err1 != nil || err2 != nil || s == e {
+		return time.Time{}, false
+	}
+
+	cur := now.Hour()*60 + now.Minute()
+	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	switch {
+	case s < e && cur >= s && cur < e:
+		return midnight.Add(time.Duration(e) * time.Minute), true
+	case s > e && cur >= s:
+		return midnight.AddDate(0, 0, 1).Add(time.Duration(e) * time.Minute), true
+	case s > e && cur < e:
+		return midnight.Add(time.Duration(e) * time.Minute), true
+	}
+	return time.Time{}, false
+}
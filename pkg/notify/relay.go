@@ -0,0 +1,114 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// relayOnlineTTL 在线标记的有效期，WS网关每 relayOnlineTTL/3 刷新一次，网关异常退出后标记自动过期
+const relayOnlineTTL = 90 * time.Second
+
+// Remote 其他进程中的在线通道：api、worker进程没有WebSocket连接，通过它推送给在线用户
+type Remote interface {
+	// IsOnline 用户是否连接在某个WS网关
+	IsOnline(ctx context.Context, uid string) bool
+	// Publish 转发给所有WS网关，由持有连接的网关推送，uids为nil表示全部在线用户
+	Publish(ctx context.Context, uids []string, msg *Message) error
+	// SetOnline 记录或清除本网关用户的在线状态
+	SetOnline(ctx context.Context, uid string, online bool)
+	// Run 接收转发的消息并推送给本进程的在线用户（阻塞），ctx结束时返回
+	Run(ctx context.Context, n *Notifier) error
+}
+
+// Relay 通过Redis发布订阅把消息转发给所有WS网关，在线状态记录在Redis中；
+// 多个WS网关时每个网关只持有部分用户的连接
+type Relay struct {
+	rds     redis.UniversalClient
+	channel string
+}
+
+func NewRelay(rds redis.UniversalClient, channel string) *Relay {
+	return &Relay{
+		rds:     rds,
+		channel: channel,
+	}
+}
+
+// relayMessage 转发的消息，Uids为nil表示全部在线用户
+type relayMessage struct {
+	Uids []string `json:"uids"`
+	Msg  *Message `json:"msg"`
+}
+
+func (r *Relay) onlineKey(uid string) string {
+	return r.channel + ":online:" + uid
+}
+
+func (r *Relay) IsOnline(ctx context.Context, uid string) bool {
+	n, err := r.rds.Exists(ctx, r.onlineKey(uid)).Result()
+	if err != nil {
+		fmt.Printf("[Notify] 查询在线状态失败, uid: %s, err: %v\n", uid, err)
+		return false
+	}
+	return n > 0
+}
+
+// Publish 发布消息，没有WS网关订阅时消息丢弃
+func (r *Relay) Publish(ctx context.Context, uids []string, msg *Message) error {
+	b, err := json.Marshal(&relayMessage{Uids: uids, Msg: msg})
+	if err != nil {
+		return err
+	}
+	return r.rds.Publish(ctx, r.channel, b).Err()
+}
+
+func (r *Relay) SetOnline(ctx context.Context, uid string, online bool) {
+	var err error
+	if online {
+		err = r.rds.Set(ctx, r.onlineKey(uid), 1, relayOnlineTTL).Err()
+	} else {
+		err = r.rds.Del(ctx, r.onlineKey(uid)).Err()
+	}
+	if err != nil {
+		fmt.Printf("[Notify] 更新在线状态失败, uid: %s, err: %v\n", uid, err)
+	}
+}
+
+// Run 订阅转发的消息并推送给本进程的在线用户，同时定期刷新本进程用户的在线标记
+func (r *Relay) Run(ctx context.Context, n *Notifier) error {
+	sub := r.rds.Subscribe(ctx, r.channel)
+	defer sub.Close()
+
+	ticker := time.NewTicker(relayOnlineTTL / 3)
+	defer ticker.Stop()
+
+	ch := sub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			for _, uid := range n.onlineUsers() {
+				r.SetOnline(ctx, uid, true)
+			}
+		case m, ok := <-ch:
+			if !ok {
+				return nil
+			}
+			r.handle(ctx, m.Payload, n)
+		}
+	}
+}
+
+func (r *Relay) handle(ctx context.Context, payload string, n *Notifier) {
+	var m relayMessage
+	if err := json.Unmarshal([]byte(payload), &m); err != nil || m.Msg == nil {
+		fmt.Printf("[Notify] 转发消息格式错误: %v\n", err)
+		return
+	}
+	n.BroadcastLocal(ctx, m.Uids, m.Msg)
+}
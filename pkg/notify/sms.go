@@ -0,0 +1,67 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"maps"
+
+	"aiOffice/pkg/sms"
+)
+
+// PlatformSms 短信渠道
+const PlatformSms = "sms"
+
+// PhoneBook 用户手机号查询
+type PhoneBook interface {
+	FindPhone(ctx context.Context, userId string) (string, error)
+}
+
+// RateLimiter 按key限流
+type RateLimiter interface {
+	Allow(ctx context.Context, key string) bool
+}
+
+// Sms 短信推送渠道，只发送标记为紧急且配置了模板的消息，每个用户每种消息按limiter限流
+type Sms struct {
+	provider  sms.Provider
+	book      PhoneBook
+	templates map[string]sms.Template // 消息类型 -> 模板
+	limiter   RateLimiter
+}
+
+// NewSms limiter 为nil时不限流
+func NewSms(provider sms.Provider, book PhoneBook, templates map[string]sms.Template, limiter RateLimiter) *Sms {
+	return &Sms{
+		provider:  provider,
+		book:      book,
+		templates: templates,
+		limiter:   limiter,
+	}
+}
+
+func (s *Sms) Name() string {
+	return PlatformSms
+}
+
+func (s *Sms) Send(ctx context.Context, uid string, msg *Message) error {
+	tpl, ok := s.templates[msg.Type]
+	if !msg.Urgent || !ok {
+		return ErrNoDevice
+	}
+
+	phone, err := s.book.FindPhone(ctx, uid)
+	if err != nil {
+		return err
+	}
+	if phone == "" {
+		return ErrNoDevice
+	}
+	if s.limiter != nil && !s.limiter.Allow(ctx, uid+":"+msg.Type) {
+		fmt.Printf("[Notify] 短信发送过于频繁，跳过, uid: %s, type: %s\n", uid, msg.Type)
+		return ErrRateLimited
+	}
+
+	params := map[string]string{"title": msg.Title, "content": msg.Content}
+	maps.Copy(params, msg.Data)
+	return s.provider.Send(ctx, phone, tpl, params)
+}
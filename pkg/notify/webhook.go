@@ -0,0 +1,71 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// Webhook 通用 webhook 推送渠道，将通知以 JSON POST 到配置的地址
+type Webhook struct {
+	url    string
+	secret string
+	client *http.Client
+}
+
+// webhookBody webhook 请求体
+type webhookBody struct {
+	UserId string `json:"userId"`
+	*Message
+}
+
+func NewWebhook(url, secret string) *Webhook {
+	return &Webhook{
+		url:    url,
+		secret: secret,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (w *Webhook) Name() string {
+	return "webhook"
+}
+
+func (w *Webhook) Send(ctx context.Context, uid string, msg *Message) error {
+	body, err := json.Marshal(&webhookBody{UserId: uid, Message: msg})
+	if err != nil {
+		return fmt.Errorf("marshal webhook body failed: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	// 配置了密钥时对请求体做 HMAC-SHA256 签名，接收方据此校验来源
+	if len(w.secret) > 0 {
+		mac := hmac.New(sha256.New, []byte(w.secret))
+		mac.Write(body)
+		req.Header.Set("X-Signature", hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		b, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("webhook status %d: %s", resp.StatusCode, string(b))
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+package pagex
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+
+	"aiOffice/pkg/xerr"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	DefaultSize = 10  // 未传每页数量时的默认值
+	MaxSize     = 100 // 接口请求的每页数量上限
+)
+
+var ErrInvalidCursor = xerr.NewCode(xerr.Invalid, "无效的分页游标")
+
+// Page 页码分页和排序
+type Page struct {
+	Page  int    // 页码，从1开始
+	Count int    // 每页数量
+	Sort  string // 排序字段，前缀-为倒序，如 -createAt；为空使用查询的默认排序
+}
+
+// FromRequest 由接口参数创建，每页数量不超过 MaxSize；内部查询（如按id列表批量查询）直接构造 Page
+func FromRequest(page, count int, sort string) Page {
+	return Page{Page: page, Count: min(count, MaxSize), Sort: sort}
+}
+
+// Skip 跳过的记录数
+func (p Page) Skip() int64 {
+	return int64(max(p.Page, 1)-1) * p.Limit()
+}
+
+// Limit 每页数量，未传时为 DefaultSize
+func (p Page) Limit() int64 {
+	if p.Count < 1 {
+		return DefaultSize
+	}
+	return int64(p.Count)
+}
+
+// Options 分页和排序的查询参数，Sort不在allowed中时使用def（如 -createAt），并以_id保证排序稳定
+func (p Page) Options(def string, allowed ...string) *options.FindOptions {
+	return options.Find().
+		SetSkip(p.Skip()).
+		SetLimit(p.Limit()).
+		SetSort(SortBy(p.Sort, def, allowed...))
+}
+
+// SortBy 将排序参数转换为mongo排序，field不在allowed中时使用def
+func SortBy(field, def string, allowed ...string) bson.D {
+	name := strings.TrimPrefix(field, "-")
+	valid := false
+	for _, v := range allowed {
+		if v == name {
+			valid = true
+			break
+		}
+	}
+	if !valid {
+		field, name = def, strings.TrimPrefix(def, "-")
+	}
+	if name == "" {
+		return bson.D{{Key: "_id", Value: -1}}
+	}
+
+	order := 1
+	if strings.HasPrefix(field, "-") {
+		order = -1
+	}
+	sort := bson.D{{Key: name, Value: order}}
+	if name != "_id" {
+		sort = append(sort, bson.E{Key: "_id", Value: order})
+	}
+	return sort
+}
+
+// Cursor 游标分页的位置，按(时间, _id)倒序翻页，新写入的数据不会导致重复或遗漏
+type Cursor struct {
+	Time int64              `json:"t"`
+	Id   primitive.ObjectID `json:"i"`
+}
+
+// Encode 编码为不透明的字符串，返回给客户端用于查询下一页
+func (c Cursor) Encode() string {
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// IsZero 是否为第一页
+func (c Cursor) IsZero() bool {
+	return c.Time == 0 && c.Id.IsZero()
+}
+
+// Before 查询游标之前（更早）的记录，timeField为时间字段名；第一页时返回空条件
+func (c Cursor) Before(timeField string) bson.M {
+	if c.IsZero() {
+		return bson.M{}
+	}
+	return bson.M{"$or": []bson.M{
+		{timeField: bson.M{"$lt": c.Time}},
+		{timeField: c.Time, "_id": bson.M{"$lt": c.Id}},
+	}}
+}
+
+// DecodeCursor 解析客户端传入的游标，为空时返回第一页
+func DecodeCursor(s string) (Cursor, error) {
+	var c Cursor
+	if s == "" {
+		return c, nil
+	}
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return c, ErrInvalidCursor
+	}
+	if err := json.Unmarshal(b, &c); err != nil {
+		return c, ErrInvalidCursor
+	}
+	return c, nil
+}
@@ -0,0 +1,61 @@
+package pagex
+
+import (
+	"reflect"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestPage(t *testing.T) {
+	cases := []struct {
+		page        Page
+		skip, limit int64
+	}{
+		{Page{}, 0, DefaultSize},
+		{Page{Page: 3, Count: 20}, 40, 20},
+		{FromRequest(2, 1000, ""), MaxSize, MaxSize},
+		{FromRequest(-1, -5, ""), 0, DefaultSize},
+	}
+	for i, c := range cases {
+		if c.page.Skip() != c.skip || c.page.Limit() != c.limit {
+			t.Errorf("case %d: got skip %d limit %d, want %d %d", i, c.page.Skip(), c.page.Limit(), c.skip, c.limit)
+		}
+	}
+}
+
+func TestSortBy(t *testing.T) {
+	cases := []struct {
+		field, def string
+		want       bson.D
+	}{
+		{"name", "_id", bson.D{{Key: "name", Value: 1}, {Key: "_id", Value: 1}}},
+		{"-createAt", "_id", bson.D{{Key: "createAt", Value: -1}, {Key: "_id", Value: -1}}},
+		{"password", "-createAt", bson.D{{Key: "createAt", Value: -1}, {Key: "_id", Value: -1}}},
+		{"", "_id", bson.D{{Key: "_id", Value: 1}}},
+		{"", "", bson.D{{Key: "_id", Value: -1}}},
+	}
+	for i, c := range cases {
+		if got := SortBy(c.field, c.def, "name", "createAt"); !reflect.DeepEqual(got, c.want) {
+			t.Errorf("case %d: got %v, want %v", i, got, c.want)
+		}
+	}
+}
+
+func TestCursor(t *testing.T) {
+	c := Cursor{Time: 1700000000, Id: primitive.NewObjectID()}
+	got, err := DecodeCursor(c.Encode())
+	if err != nil || got != c {
+		t.Fatalf("round trip: %v %v", got, err)
+	}
+
+	if c, err := DecodeCursor(""); err != nil || !c.IsZero() || len(c.Before("SendTime")) != 0 {
+		t.Fatal("empty cursor should be first page")
+	}
+	for _, s := range []string{"!!", "bm90IGpzb24"} {
+		if _, err := DecodeCursor(s); err != ErrInvalidCursor {
+			t.Errorf("%q: got %v", s, err)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+// Package panicx 捕获http、ws、异步任务中的panic，记录堆栈并上报到Sentry、webhook等
+package panicx
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"time"
+
+	"aiOffice/pkg/requestid"
+	"aiOffice/pkg/token"
+
+	"gitee.com/dn-jinmin/tlog"
+)
+
+// 来源
+const (
+	SourceHttp  = "http"
+	SourceWs    = "ws"
+	SourceAsynq = "asynq"
+)
+
+// Event 一次panic
+type Event struct {
+	Source    string    `json:"source"`  // http/ws/asynq
+	Name      string    `json:"name"`    // 路由、任务类型等
+	Message   string    `json:"message"` // panic的值
+	Stack     string    `json:"stack"`
+	RequestId string    `json:"requestId,omitempty"`
+	UserId    string    `json:"userId,omitempty"`
+	Time      time.Time `json:"time"`
+}
+
+// Sink 上报渠道
+type Sink interface {
+	Name() string
+	Send(ctx context.Context, e *Event) error
+}
+
+// Reporter 记录panic日志并异步上报，没有上报渠道时只记录日志
+type Reporter struct {
+	sinks   []Sink
+	timeout time.Duration
+}
+
+func NewReporter(sinks ...Sink) *Reporter {
+	return &Reporter{
+		sinks:   sinks,
+		timeout: 10 * time.Second,
+	}
+}
+
+// Sinks 已配置的上报渠道名称
+func (r *Reporter) Sinks() []string {
+	names := make([]string, 0, len(r.sinks))
+	for _, s := range r.sinks {
+		names = append(names, s.Name())
+	}
+	return names
+}
+
+// Recover 直接用于defer，捕获panic并上报后正常返回，如 defer reporter.Recover(ctx, panicx.SourceWs, "aiChat")
+func (r *Reporter) Recover(ctx context.Context, source, name string) {
+	if v := recover(); v != nil {
+		r.Report(ctx, source, name, v)
+	}
+}
+
+// Report 在recover之后调用，堆栈取自当前goroutine，需在defer中调用才包含panic位置
+func (r *Reporter) Report(ctx context.Context, source, name string, v any) {
+	e := &Event{
+		Source:    source,
+		Name:      name,
+		Message:   fmt.Sprint(v),
+		Stack:     string(debug.Stack()),
+		RequestId: requestid.FromContext(ctx),
+		UserId:    token.GetUid(ctx),
+		Time:      time.Now(),
+	}
+	tlog.ErrorCtx(ctx, "panic", requestid.Fields(ctx, "source", source, "name", name, "uid", e.UserId, "panic", e.Message, "stack", e.Stack)...)
+	if r == nil || len(r.sinks) == 0 {
+		return
+	}
+
+	// 上报不阻塞请求，原请求结束后仍需完成
+	go func() {
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), r.timeout)
+		defer cancel()
+		for _, s := range r.sinks {
+			if err := s.Send(ctx, e); err != nil {
+				fmt.Printf("[Panic] 上报到 %s 失败: %v\n", s.Name(), err)
+			}
+		}
+	}()
+}
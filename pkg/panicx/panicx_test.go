@@ -0,0 +1,95 @@
+package panicx
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+type fakeSink struct {
+	events chan *Event
+}
+
+func (s *fakeSink) Name() string { return "fake" }
+
+func (s *fakeSink) Send(ctx context.Context, e *Event) error {
+	s.events <- e
+	return nil
+}
+
+func TestRecover(t *testing.T) {
+	sink := &fakeSink{events: make(chan *Event, 1)}
+	r := NewReporter(sink)
+
+	func() {
+		defer r.Recover(context.Background(), SourceWs, "aiChat")
+		panic("boom")
+	}()
+
+	select {
+	case e := <-sink.events:
+		if e.Message != "boom" || e.Source != SourceWs || e.Name != "aiChat" {
+			t.Errorf("unexpected event %+v", e)
+		}
+		if !strings.Contains(e.Stack, "TestRecover") {
+			t.Errorf("stack should contain panic location: %s", e.Stack)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("event not reported")
+	}
+
+	// 没有上报渠道时只记录日志
+	var nilReporter *Reporter
+	func() {
+		defer nilReporter.Recover(context.Background(), SourceHttp, "/")
+		panic("boom")
+	}()
+}
+
+func TestSinks(t *testing.T) {
+	got := make(chan *http.Request, 2)
+	bodies := make(chan map[string]any, 2)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		json.NewDecoder(r.Body).Decode(&body)
+		got <- r
+		bodies <- body
+	}))
+	defer srv.Close()
+
+	e := &Event{Source: SourceAsynq, Name: "task", Message: "boom", Stack: "stack", UserId: "u1", Time: time.Now()}
+
+	if err := NewWebhook(srv.URL+"/hook", "secret").Send(context.Background(), e); err != nil {
+		t.Fatal(err)
+	}
+	if r := <-got; r.URL.Path != "/hook" || r.Header.Get("X-Signature") == "" {
+		t.Errorf("webhook request %s %v", r.URL.Path, r.Header)
+	}
+	if b := <-bodies; b["message"] != "boom" {
+		t.Errorf("webhook body %v", b)
+	}
+
+	dsn := strings.Replace(srv.URL, "://", "://pubkey@", 1) + "/sentry/42"
+	s, err := NewSentry(dsn, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Send(context.Background(), e); err != nil {
+		t.Fatal(err)
+	}
+	r := <-got
+	if r.URL.Path != "/sentry/api/42/store/" || !strings.Contains(r.Header.Get("X-Sentry-Auth"), "sentry_key=pubkey") {
+		t.Errorf("sentry request %s %v", r.URL.Path, r.Header)
+	}
+	if b := <-bodies; b["message"] != "panic: boom" || b["environment"] != "test" || len(b["event_id"].(string)) != 32 {
+		t.Errorf("sentry body %v", b)
+	}
+
+	if _, err := NewSentry("https://sentry.io/1", ""); err == nil {
+		t.Error("dsn without key should fail")
+	}
+}
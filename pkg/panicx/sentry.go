@@ -0,0 +1,96 @@
+package panicx
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Sentry 通过 store 接口上报到 Sentry，不依赖SDK
+type Sentry struct {
+	endpoint    string
+	key         string
+	environment string
+	client      *http.Client
+}
+
+// NewSentry dsn 格式为 https://<key>@<host>/<project>
+func NewSentry(dsn, environment string) (*Sentry, error) {
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("invalid sentry dsn: %w", err)
+	}
+	project := strings.Trim(u.Path, "/")
+	if u.User == nil || u.User.Username() == "" || project == "" {
+		return nil, fmt.Errorf("invalid sentry dsn: missing key or project")
+	}
+	// 自建Sentry部署在子路径下时，项目id为最后一段
+	prefix := ""
+	if i := strings.LastIndex(project, "/"); i >= 0 {
+		prefix, project = "/"+project[:i], project[i+1:]
+	}
+	return &Sentry{
+		endpoint:    fmt.Sprintf("%s://%s%s/api/%s/store/", u.Scheme, u.Host, prefix, project),
+		key:         u.User.Username(),
+		environment: environment,
+		client:      &http.Client{Timeout: 10 * time.Second},
+	}, nil
+}
+
+func (s *Sentry) Name() string {
+	return "sentry"
+}
+
+type sentryEvent struct {
+	EventId     string            `json:"event_id"`
+	Timestamp   string            `json:"timestamp"`
+	Level       string            `json:"level"`
+	Platform    string            `json:"platform"`
+	Logger      string            `json:"logger"`
+	Transaction string            `json:"transaction,omitempty"`
+	Environment string            `json:"environment,omitempty"`
+	Message     string            `json:"message"`
+	Tags        map[string]string `json:"tags"`
+	User        map[string]string `json:"user,omitempty"`
+	Extra       map[string]string `json:"extra"`
+}
+
+func (s *Sentry) Send(ctx context.Context, e *Event) error {
+	event := &sentryEvent{
+		EventId:     strings.ReplaceAll(uuid.NewString(), "-", ""),
+		Timestamp:   e.Time.UTC().Format(time.RFC3339),
+		Level:       "fatal",
+		Platform:    "go",
+		Logger:      e.Source,
+		Transaction: e.Name,
+		Environment: s.environment,
+		Message:     "panic: " + e.Message,
+		Tags:        map[string]string{"source": e.Source},
+		Extra:       map[string]string{"stack": e.Stack},
+	}
+	if e.RequestId != "" {
+		event.Tags["request_id"] = e.RequestId
+	}
+	if e.UserId != "" {
+		event.User = map[string]string{"id": e.UserId}
+	}
+
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("marshal sentry event failed: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Sentry-Auth", fmt.Sprintf("Sentry sentry_version=7, sentry_client=aiOffice/1.0, sentry_key=%s", s.key))
+	return do(s.client, req)
+}
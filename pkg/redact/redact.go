@@ -0,0 +1,113 @@
+// Package redact 日志和审计记录的脱敏：密码、令牌等字段替换为***，文件内容替换为长度
+package redact
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"unicode/utf8"
+)
+
+// Mask 敏感字段的替换值
+const Mask = "***"
+
+// 字段名包含以下内容时视为敏感字段，不区分大小写
+var sensitiveKeys = []string{"password", "pwd", "secret", "token", "credential", "apikey", "api_key", "authorization"}
+
+// IsSensitive 字段是否需要脱敏
+func IsSensitive(key string) bool {
+	key = strings.ToLower(key)
+	for _, s := range sensitiveKeys {
+		if strings.Contains(key, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// Value 脱敏json解码后的值（map[string]any、[]any等），原地修改；
+// maxLen>0 时超长的字符串截断，用于日志
+func Value(v any, maxLen int) any {
+	switch val := v.(type) {
+	case map[string]any:
+		for k, item := range val {
+			if IsSensitive(k) {
+				val[k] = Mask
+			} else {
+				val[k] = Value(item, maxLen)
+			}
+		}
+	case []any:
+		for i, item := range val {
+			val[i] = Value(item, maxLen)
+		}
+	case string:
+		return String(val, maxLen)
+	}
+	return v
+}
+
+// String 内嵌的文件内容（data URI）替换为长度，maxLen>0 时截断超长字符串
+func String(s string, maxLen int) string {
+	if strings.HasPrefix(s, "data:") && strings.Contains(s, ";base64,") {
+		return fmt.Sprintf("[file %d bytes]", len(s))
+	}
+	if maxLen <= 0 || len(s) <= maxLen {
+		return s
+	}
+	cut := maxLen
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+	return fmt.Sprintf("%s...(%d bytes)", s[:cut], len(s))
+}
+
+// JSON 脱敏json文本，不是json时只记录长度
+func JSON(data []byte, maxLen int) string {
+	if len(data) == 0 {
+		return ""
+	}
+	var v any
+	if json.Unmarshal(data, &v) != nil {
+		return fmt.Sprintf("[%d bytes]", len(data))
+	}
+	b, _ := json.Marshal(Value(v, maxLen))
+	return string(b)
+}
+
+// Text 脱敏工具输入等文本，json按字段脱敏，其他文本只截断
+func Text(s string, maxLen int) string {
+	trimmed := strings.TrimSpace(s)
+	if strings.HasPrefix(trimmed, "{") || strings.HasPrefix(trimmed, "[") {
+		var v any
+		if json.Unmarshal([]byte(trimmed), &v) == nil {
+			b, _ := json.Marshal(Value(v, maxLen))
+			return string(b)
+		}
+	}
+	return String(s, maxLen)
+}
+
+// Values 脱敏查询参数、表单等，单个值的参数展开为字符串
+func Values(values url.Values, maxLen int) map[string]any {
+	if len(values) == 0 {
+		return nil
+	}
+	res := make(map[string]any, len(values))
+	for k, v := range values {
+		switch {
+		case IsSensitive(k):
+			res[k] = Mask
+		case len(v) == 1:
+			res[k] = String(v[0], maxLen)
+		default:
+			list := make([]any, 0, len(v))
+			for _, item := range v {
+				list = append(list, String(item, maxLen))
+			}
+			res[k] = list
+		}
+	}
+	return res
+}
@@ -0,0 +1,40 @@
+package redact
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestJSON(t *testing.T) {
+	in := `{"name":"root","password":"123","data":{"apiKey":"k","list":[{"Token":"t"}]},"img":"data:image/png;base64,AAAA"}`
+	out := JSON([]byte(in), 0)
+	for _, secret := range []string{`"123"`, `"k"`, `"t"`, "AAAA"} {
+		if strings.Contains(out, secret) {
+			t.Errorf("%s not redacted: %s", secret, out)
+		}
+	}
+	if !strings.Contains(out, `"name":"root"`) || !strings.Contains(out, "[file 26 bytes]") {
+		t.Errorf("unexpected output: %s", out)
+	}
+	if JSON([]byte("not json"), 0) != "[8 bytes]" {
+		t.Error("non json should only log size")
+	}
+}
+
+func TestText(t *testing.T) {
+	if out := Text(` {"title":"周报","secret":"x"}`, 0); out != `{"secret":"***","title":"周报"}` {
+		t.Errorf("got %s", out)
+	}
+	// 按字符截断，不拆开多字节字符
+	if out := Text("你好世界", 4); out != "你...(12 bytes)" {
+		t.Errorf("got %s", out)
+	}
+}
+
+func TestValues(t *testing.T) {
+	res := Values(url.Values{"token": {"t"}, "id": {"1"}, "tag": {"a", "b"}}, 0)
+	if res["token"] != Mask || res["id"] != "1" || len(res["tag"].([]any)) != 2 {
+		t.Errorf("got %v", res)
+	}
+}
@@ -0,0 +1,67 @@
+package requestid
+
+import (
+	"context"
+	"net/http"
+	"regexp"
+
+	"github.com/google/uuid"
+)
+
+// Header 请求标识的http头，客户端传入时沿用，否则由服务端生成
+const Header = "X-Request-Id"
+
+// Field 日志中请求标识的字段名
+const Field = "requestId"
+
+type ctxKey struct{}
+
+// valid 只接受常见格式的外部请求标识，避免日志注入
+var valid = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+// New 生成新的请求标识
+func New() string {
+	return uuid.NewString()
+}
+
+// Valid 是否为可沿用的请求标识
+func Valid(id string) bool {
+	return valid.MatchString(id)
+}
+
+// WithContext 将请求标识写入ctx
+func WithContext(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, ctxKey{}, id)
+}
+
+// FromContext 读取ctx中的请求标识，不存在时返回空
+func FromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	id, _ := ctx.Value(ctxKey{}).(string)
+	return id
+}
+
+// Ensure ctx中没有请求标识时生成一个，用于ws消息、异步任务等非http入口
+func Ensure(ctx context.Context) context.Context {
+	if FromContext(ctx) != "" {
+		return ctx
+	}
+	return WithContext(ctx, New())
+}
+
+// Fields 在日志字段后追加请求标识
+func Fields(ctx context.Context, fields ...any) []any {
+	if id := FromContext(ctx); id != "" {
+		return append(fields, Field, id)
+	}
+	return fields
+}
+
+// Inject 将ctx中的请求标识写入下游请求的http头
+func Inject(ctx context.Context, h http.Header) {
+	if id := FromContext(ctx); id != "" {
+		h.Set(Header, id)
+	}
+}
@@ -0,0 +1,38 @@
+package requestid
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+func TestRequestId(t *testing.T) {
+	ctx := context.Background()
+	if FromContext(ctx) != "" || len(Fields(ctx, "a", 1)) != 2 {
+		t.Fatal("empty ctx should have no request id")
+	}
+
+	ctx = Ensure(ctx)
+	id := FromContext(ctx)
+	if !Valid(id) {
+		t.Fatalf("generated id %q invalid", id)
+	}
+	if FromContext(Ensure(ctx)) != id {
+		t.Fatal("Ensure should keep existing id")
+	}
+	if f := Fields(ctx, "a", 1); len(f) != 4 || f[2] != Field || f[3] != id {
+		t.Fatalf("fields: %v", f)
+	}
+
+	h := http.Header{}
+	Inject(ctx, h)
+	if h.Get(Header) != id {
+		t.Fatalf("header: %q", h.Get(Header))
+	}
+
+	for _, v := range []string{"", "a b", "x\ny", string(make([]byte, 129))} {
+		if Valid(v) {
+			t.Fatalf("%q should be invalid", v)
+		}
+	}
+}
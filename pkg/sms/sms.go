@@ -0,0 +1,55 @@
+package sms
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+)
+
+// 短信供应商
+const (
+	ProviderAliyun = "aliyun"
+	ProviderTwilio = "twilio"
+)
+
+var (
+	ErrBadPhone    = errors.New("手机号格式错误")
+	ErrNoTemplate  = errors.New("没有配置短信模板")
+	ErrBadProvider = errors.New("不支持的短信供应商")
+)
+
+// phonePattern 国内号码可以不带区号，国际号码为 E.164 格式
+var phonePattern = regexp.MustCompile(`^\+?[0-9]{6,15}$`)
+
+// DefaultText 未配置模板内容时使用的短信内容
+const DefaultText = "${title}：${content}"
+
+// Template 短信模板：阿里云使用在控制台审核通过的模板编号，Twilio 等直接发送文本的供应商使用 Text
+type Template struct {
+	Code string // 模板编号，参数按 ${name} 填写
+	Text string // 模板内容，如 "${title}：${content}"，为空时使用 DefaultText
+}
+
+// Provider 短信供应商
+type Provider interface {
+	Name() string
+	Send(ctx context.Context, phone string, tpl Template, params map[string]string) error
+}
+
+// ValidPhone 检查手机号格式
+func ValidPhone(phone string) bool {
+	return phonePattern.MatchString(phone)
+}
+
+// Render 用参数替换模板内容中的 ${name}
+func Render(text string, params map[string]string) string {
+	if text == "" {
+		text = DefaultText
+	}
+	pairs := make([]string, 0, len(params)*2)
+	for k, v := range params {
+		pairs = append(pairs, "${"+k+"}", v)
+	}
+	return strings.NewReplacer(pairs...).Replace(text)
+}
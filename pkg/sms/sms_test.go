@@ -0,0 +1,106 @@
+package sms
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRender(t *testing.T) {
+	params := map[string]string{"title": "审批结果", "content": "已被拒绝"}
+	if got := Render("", params); got != "审批结果：已被拒绝" {
+		t.Errorf("default text: got %q", got)
+	}
+	if got := Render("[${title}] ${missing}", params); got != "[审批结果] ${missing}" {
+		t.Errorf("custom text: got %q", got)
+	}
+}
+
+func TestValidPhone(t *testing.T) {
+	for phone, want := range map[string]bool{"13800138000": true, "+8613800138000": true, "138-0013": false, "": false} {
+		if ValidPhone(phone) != want {
+			t.Errorf("%q: want %v", phone, want)
+		}
+	}
+}
+
+func TestAliyunSign(t *testing.T) {
+	// 阿里云文档中的签名示例
+	query := map[string]string{
+		"AccessKeyId":      "testId",
+		"Action":           "SendSms",
+		"Format":           "XML",
+		"OutId":            "123",
+		"PhoneNumbers":     "15300000001",
+		"RegionId":         "cn-hangzhou",
+		"SignName":         "阿里云短信测试专用",
+		"SignatureMethod":  "HMAC-SHA1",
+		"SignatureNonce":   "45e25e9b-0a6f-4070-8c85-2956eda1b466",
+		"SignatureVersion": "1.0",
+		"TemplateCode":     "SMS_71390007",
+		"TemplateParam":    `{"customer":"test"}`,
+		"Timestamp":        "2017-07-12T02:42:19Z",
+		"Version":          "2017-05-25",
+	}
+	if got := aliyunSign("testSecret", http.MethodGet, canonicalQuery(query)); got != "zJDF+Lrzhj/ThnlvIToysFRq6t4=" {
+		t.Errorf("signature: got %s", got)
+	}
+}
+
+func TestAliyunSend(t *testing.T) {
+	var got map[string]string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		got = map[string]string{"phone": q.Get("PhoneNumbers"), "code": q.Get("TemplateCode"), "param": q.Get("TemplateParam")}
+		if q.Get("Signature") == "" {
+			w.Write([]byte(`{"Code":"SignatureDoesNotMatch","Message":"bad"}`))
+			return
+		}
+		w.Write([]byte(`{"Code":"OK","Message":"OK"}`))
+	}))
+	defer srv.Close()
+
+	a := NewAliyun(AliyunConf{AccessKeyId: "id", AccessKeySecret: "secret", SignName: "sign", Endpoint: srv.URL})
+	if err := a.Send(context.Background(), "13800138000", Template{}, nil); err != ErrNoTemplate {
+		t.Errorf("missing code should fail, got %v", err)
+	}
+	if err := a.Send(context.Background(), "13800138000", Template{Code: "SMS_1"}, map[string]string{"title": "t"}); err != nil {
+		t.Fatal(err)
+	}
+	var param map[string]string
+	json.Unmarshal([]byte(got["param"]), &param)
+	if got["phone"] != "13800138000" || got["code"] != "SMS_1" || param["title"] != "t" {
+		t.Errorf("unexpected request: %v", got)
+	}
+}
+
+func TestTwilioSend(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, pass, _ := r.BasicAuth()
+		r.ParseForm()
+		if user != "AC1" || pass != "token" || r.URL.Path != "/2010-04-01/Accounts/AC1/Messages.json" {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"code":20003,"message":"Authenticate"}`))
+			return
+		}
+		if r.Form.Get("Body") != "t：c" || r.Form.Get("From") != "+15005550006" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer srv.Close()
+
+	params := map[string]string{"title": "t", "content": "c"}
+	tw := NewTwilio(TwilioConf{AccountSid: "AC1", AuthToken: "token", From: "+15005550006", Endpoint: srv.URL})
+	if err := tw.Send(context.Background(), "+8613800138000", Template{}, params); err != nil {
+		t.Fatal(err)
+	}
+
+	tw = NewTwilio(TwilioConf{AccountSid: "AC1", AuthToken: "wrong", Endpoint: srv.URL})
+	if err := tw.Send(context.Background(), "+8613800138000", Template{}, params); err == nil {
+		t.Error("expected auth error")
+	}
+}
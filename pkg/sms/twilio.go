@@ -0,0 +1,80 @@
+package sms
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const twilioEndpoint = "https://api.twilio.com"
+
+// TwilioConf Twilio 短信配置
+type TwilioConf struct {
+	AccountSid string
+	AuthToken  string
+	From       string // 发送号码，或以 MG 开头的 Messaging Service SID
+	Endpoint   string // 默认 https://api.twilio.com
+}
+
+// Twilio 直接发送按模板渲染后的文本
+type Twilio struct {
+	conf   TwilioConf
+	client *http.Client
+}
+
+func NewTwilio(conf TwilioConf) *Twilio {
+	if conf.Endpoint == "" {
+		conf.Endpoint = twilioEndpoint
+	}
+	return &Twilio{
+		conf:   conf,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (t *Twilio) Name() string {
+	return ProviderTwilio
+}
+
+func (t *Twilio) Send(ctx context.Context, phone string, tpl Template, params map[string]string) error {
+	form := url.Values{}
+	form.Set("To", phone)
+	form.Set("Body", Render(tpl.Text, params))
+	if strings.HasPrefix(t.conf.From, "MG") {
+		form.Set("MessagingServiceSid", t.conf.From)
+	} else {
+		form.Set("From", t.conf.From)
+	}
+
+	reqUrl := fmt.Sprintf("%s/2010-04-01/Accounts/%s/Messages.json", t.conf.Endpoint, t.conf.AccountSid)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, reqUrl, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth(t.conf.AccountSid, t.conf.AuthToken)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	var res struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	}
+	if json.Unmarshal(body, &res) == nil && res.Message != "" {
+		return fmt.Errorf("twilio sms %d: %s", res.Code, res.Message)
+	}
+	return fmt.Errorf("twilio sms status %d: %s", resp.StatusCode, string(body))
+}
@@ -0,0 +1,161 @@
+package speech
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"strings"
+	"time"
+)
+
+var (
+	ErrAsrDisabled = errors.New("未配置语音识别模型")
+	ErrTtsDisabled = errors.New("未配置语音合成模型")
+	ErrEmptyAudio  = errors.New("音频内容为空")
+	ErrEmptyText   = errors.New("合成文本为空")
+)
+
+// Conf 语音服务配置，使用OpenAI兼容的 /audio/transcriptions 和 /audio/speech 接口
+type Conf struct {
+	Url      string // 如 https://api.openai.com/v1，本地whisper、vLLM等兼容服务同样适用
+	ApiKey   string
+	AsrModel string // 为空不支持语音识别
+	TtsModel string // 为空不支持语音合成
+	Voice    string // 默认音色
+	Timeout  time.Duration
+}
+
+// Client 语音识别与合成
+type Client struct {
+	conf Conf
+	http *http.Client
+}
+
+func New(conf Conf) *Client {
+	if conf.Timeout <= 0 {
+		conf.Timeout = 60 * time.Second
+	}
+	if conf.Voice == "" {
+		conf.Voice = "alloy"
+	}
+	conf.Url = strings.TrimRight(conf.Url, "/")
+	return &Client{
+		conf: conf,
+		http: &http.Client{Timeout: conf.Timeout},
+	}
+}
+
+// Transcribe 语音转文字，filename用于服务端识别音频格式
+func (c *Client) Transcribe(ctx context.Context, filename string, audio io.Reader) (string, error) {
+	if c.conf.AsrModel == "" {
+		return "", ErrAsrDisabled
+	}
+
+	body := new(bytes.Buffer)
+	w := multipart.NewWriter(body)
+	fw, err := w.CreateFormFile("file", filename)
+	if err != nil {
+		return "", err
+	}
+	n, err := io.Copy(fw, audio)
+	if err != nil {
+		return "", err
+	}
+	if n == 0 {
+		return "", ErrEmptyAudio
+	}
+	_ = w.WriteField("model", c.conf.AsrModel)
+	_ = w.WriteField("response_format", "json")
+	if err := w.Close(); err != nil {
+		return "", err
+	}
+
+	b, _, err := c.do(ctx, "/audio/transcriptions", w.FormDataContentType(), body)
+	if err != nil {
+		return "", fmt.Errorf("asr: %w", err)
+	}
+
+	var res struct {
+		Text string `json:"text"`
+	}
+	if err := json.Unmarshal(b, &res); err != nil {
+		return "", fmt.Errorf("asr: %w", err)
+	}
+	return strings.TrimSpace(res.Text), nil
+}
+
+// Synthesize 文字转语音，返回音频内容和Content-Type；voice为空使用默认音色，format为空返回mp3
+func (c *Client) Synthesize(ctx context.Context, text, voice, format string) ([]byte, string, error) {
+	if c.conf.TtsModel == "" {
+		return nil, "", ErrTtsDisabled
+	}
+	if strings.TrimSpace(text) == "" {
+		return nil, "", ErrEmptyText
+	}
+	if voice == "" {
+		voice = c.conf.Voice
+	}
+	if format == "" {
+		format = "mp3"
+	}
+
+	b, err := json.Marshal(map[string]string{
+		"model":           c.conf.TtsModel,
+		"input":           text,
+		"voice":           voice,
+		"response_format": format,
+	})
+	if err != nil {
+		return nil, "", err
+	}
+
+	audio, contentType, err := c.do(ctx, "/audio/speech", "application/json", bytes.NewReader(b))
+	if err != nil {
+		return nil, "", fmt.Errorf("tts: %w", err)
+	}
+	if contentType == "" || strings.HasPrefix(contentType, "application/octet-stream") {
+		contentType = contentTypes[format]
+	}
+	return audio, contentType, nil
+}
+
+// contentTypes 服务端未返回具体类型时按格式补充
+var contentTypes = map[string]string{
+	"mp3":  "audio/mpeg",
+	"wav":  "audio/wav",
+	"opus": "audio/ogg",
+	"aac":  "audio/aac",
+	"flac": "audio/flac",
+	"pcm":  "audio/pcm",
+}
+
+func (c *Client) do(ctx context.Context, path, contentType string, body io.Reader) ([]byte, string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.conf.Url+path, body)
+	if err != nil {
+		return nil, "", err
+	}
+	req.Header.Set("Content-Type", contentType)
+	if c.conf.ApiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.conf.ApiKey)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("status %d: %s", resp.StatusCode, strings.TrimSpace(string(b)))
+	}
+	return b, resp.Header.Get("Content-Type"), nil
+}
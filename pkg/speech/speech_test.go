@@ -0,0 +1,73 @@
+package speech
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestTranscribe(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/audio/transcriptions" || r.Header.Get("Authorization") != "Bearer key" {
+			t.Errorf("unexpected request: %s %s", r.URL.Path, r.Header.Get("Authorization"))
+		}
+		f, h, err := r.FormFile("file")
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, _ := io.ReadAll(f)
+		if h.Filename != "a.m4a" || string(b) != "audio" || r.FormValue("model") != "whisper-1" {
+			t.Errorf("unexpected form: %s %q %s", h.Filename, b, r.FormValue("model"))
+		}
+		w.Write([]byte(`{"text":" 帮我请两天假 "}`))
+	}))
+	defer srv.Close()
+
+	c := New(Conf{Url: srv.URL + "/v1/", ApiKey: "key", AsrModel: "whisper-1"})
+	text, err := c.Transcribe(context.Background(), "a.m4a", strings.NewReader("audio"))
+	if err != nil || text != "帮我请两天假" {
+		t.Fatalf("unexpected result: %q, %v", text, err)
+	}
+
+	if _, err := c.Transcribe(context.Background(), "a.m4a", strings.NewReader("")); !errors.Is(err, ErrEmptyAudio) {
+		t.Fatalf("expected ErrEmptyAudio, got %v", err)
+	}
+	if _, err := New(Conf{Url: srv.URL}).Transcribe(context.Background(), "a.m4a", strings.NewReader("audio")); !errors.Is(err, ErrAsrDisabled) {
+		t.Fatalf("expected ErrAsrDisabled, got %v", err)
+	}
+}
+
+func TestSynthesize(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req map[string]string
+		json.NewDecoder(r.Body).Decode(&req)
+		if req["voice"] == "bad" {
+			http.Error(w, "invalid voice", http.StatusBadRequest)
+			return
+		}
+		if req["model"] != "tts-1" || req["voice"] != "nova" || req["response_format"] != "mp3" {
+			t.Errorf("unexpected body: %v", req)
+		}
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Write([]byte("mp3data"))
+	}))
+	defer srv.Close()
+
+	c := New(Conf{Url: srv.URL, TtsModel: "tts-1", Voice: "nova"})
+	audio, ct, err := c.Synthesize(context.Background(), "已为你提交请假申请", "", "")
+	if err != nil || string(audio) != "mp3data" || ct != "audio/mpeg" {
+		t.Fatalf("unexpected result: %q %s %v", audio, ct, err)
+	}
+
+	if _, _, err := c.Synthesize(context.Background(), "hi", "bad", ""); err == nil || !strings.Contains(err.Error(), "invalid voice") {
+		t.Fatalf("expected status error, got %v", err)
+	}
+	if _, _, err := c.Synthesize(context.Background(), " ", "", ""); !errors.Is(err, ErrEmptyText) {
+		t.Fatalf("expected ErrEmptyText, got %v", err)
+	}
+}
@@ -1,10 +1,49 @@
 package timeutils
 
-import "time"
+import (
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+// location 服务时区，日期、"今天"的范围和提醒时间均按该时区计算，默认服务器本地时区
+var location atomic.Pointer[time.Location]
+
+// SetTimezone 设置服务时区，如 Asia/Shanghai，为空使用服务器本地时区；启动时调用
+func SetTimezone(name string) error {
+	if name == "" {
+		SetLocation(time.Local)
+		return nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return err
+	}
+	SetLocation(loc)
+	return nil
+}
+
+// SetLocation 设置服务时区
+func SetLocation(loc *time.Location) {
+	location.Store(loc)
+}
+
+// Location 获取服务时区
+func Location() *time.Location {
+	if loc := location.Load(); loc != nil {
+		return loc
+	}
+	return time.Local
+}
+
+// Unix 时间戳转换为服务时区的时间
+func Unix(timestamp int64) time.Time {
+	return time.Unix(timestamp, 0).In(Location())
+}
 
 // Format 格式化时间戳为日期字符串
 func Format(date int64) string {
-	return time.Unix(date, 0).Format("2006-01-02")
+	return Unix(date).Format("2006-01-02")
 }
 
 // Now 获取当前时间戳
@@ -12,28 +51,55 @@ func Now() int64 {
 	return time.Now().Unix()
 }
 
-// NowTime 获取当前时间
+// NowTime 获取服务时区的当前时间
 func NowTime() time.Time {
-	return time.Now()
+	return time.Now().In(Location())
+}
+
+// StartOfDay 获取时间所在日期的零点，按时间自身的时区计算
+func StartOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+// DayRange 获取时间所在日期的起止时间戳（含首尾）
+func DayRange(t time.Time) (start, end int64) {
+	day := StartOfDay(t)
+	return day.Unix(), day.AddDate(0, 0, 1).Unix() - 1
+}
+
+// TodayRange 获取服务时区今天的起止时间戳（含首尾）
+func TodayRange() (start, end int64) {
+	return DayRange(NowTime())
+}
+
+// DayNumber 日期的数字形式，如 20240530
+func DayNumber(t time.Time) int64 {
+	t = t.In(Location())
+	return int64(t.Year()*10000 + int(t.Month())*100 + t.Day())
+}
+
+// ParseDayNumber 将 20240530 形式的日期解析为当天0点
+func ParseDayNumber(day int64) (time.Time, error) {
+	return time.ParseInLocation("20060102", strconv.FormatInt(day, 10), Location())
 }
 
 // Day 获取时间戳对应的日
 func Day(timestamp int64) int64 {
-	return int64(time.Unix(timestamp, 0).Day())
+	return int64(Unix(timestamp).Day())
 }
 
 // Month 获取时间戳对应的月
 func Month(timestamp int64) int64 {
-	return int64(time.Unix(timestamp, 0).Month())
+	return int64(Unix(timestamp).Month())
 }
 
 // Year 获取时间戳对应的年
 func Year(timestamp int64) int64 {
-	return int64(time.Unix(timestamp, 0).Year())
+	return int64(Unix(timestamp).Year())
 }
 
 // FinishTime 返回完成时间相关的字段
 func FinishTime() (finishAt, finishDay, finishMonth, finishYear int64) {
-	now := time.Now()
+	now := NowTime()
 	return now.Unix(), int64(now.Day()), int64(now.Month()), int64(now.Year())
 }
@@ -0,0 +1,48 @@
+package timeutils
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTimezone(t *testing.T) {
+	defer SetLocation(time.Local)
+
+	if err := SetTimezone("Not/Exist"); err == nil {
+		t.Fatal("invalid timezone should fail")
+	}
+	if err := SetTimezone("America/New_York"); err != nil {
+		t.Fatal(err)
+	}
+	if Location().String() != "America/New_York" || NowTime().Location() != Location() {
+		t.Fatalf("location not set: %v", Location())
+	}
+
+	// UTC 2024-03-01 02:00 在纽约是 2024-02-29 21:00
+	ts := time.Date(2024, 3, 1, 2, 0, 0, 0, time.UTC).Unix()
+	if Format(ts) != "2024-02-29" || Day(ts) != 29 || Month(ts) != 2 || Year(ts) != 2024 {
+		t.Errorf("got %s %d/%d/%d", Format(ts), Year(ts), Month(ts), Day(ts))
+	}
+
+	start, end := DayRange(Unix(ts))
+	wantStart := time.Date(2024, 2, 29, 0, 0, 0, 0, Location()).Unix()
+	if start != wantStart || end != wantStart+86399 {
+		t.Errorf("got range %d-%d, want start %d", start, end, wantStart)
+	}
+
+	if DayNumber(Unix(ts)) != 20240229 {
+		t.Errorf("got day number %d", DayNumber(Unix(ts)))
+	}
+	if day, err := ParseDayNumber(20240229); err != nil || day.Unix() != wantStart {
+		t.Errorf("parse day number: %v %v", day, err)
+	}
+
+	start, end = TodayRange()
+	if now := time.Now().Unix(); now < start || now > end {
+		t.Errorf("now %d not in today %d-%d", now, start, end)
+	}
+
+	if err := SetTimezone(""); err != nil || Location() != time.Local {
+		t.Errorf("empty timezone should use local, got %v %v", Location(), err)
+	}
+}
@@ -0,0 +1,76 @@
+package tlsx
+
+import (
+	"cmp"
+	"crypto/tls"
+	"fmt"
+	"net/http"
+
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// Conf TLS配置，证书文件优先，其次自动申请证书，都未配置时不启用
+type Conf struct {
+	CertFile string
+	KeyFile  string
+	Autocert AutocertConf
+}
+
+// AutocertConf 通过 Let's Encrypt 自动申请和续期证书，使用 TLS-ALPN 验证，需对外监听443端口
+type AutocertConf struct {
+	Domains  []string // 允许申请证书的域名
+	CacheDir string   // 证书缓存目录，默认 ./certs
+	Email    string   // 证书到期等通知的联系邮箱
+}
+
+// Enabled 是否启用TLS
+func (c Conf) Enabled() bool {
+	return (c.CertFile != "" && c.KeyFile != "") || len(c.Autocert.Domains) > 0
+}
+
+// NewConfig 创建服务端TLS配置，未启用时返回nil
+func NewConfig(c Conf) (*tls.Config, error) {
+	if c.CertFile != "" || c.KeyFile != "" {
+		if c.CertFile == "" || c.KeyFile == "" {
+			return nil, fmt.Errorf("tls: CertFile and KeyFile must be set together")
+		}
+		cert, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("tls: load certificate failed: %w", err)
+		}
+		return &tls.Config{
+			MinVersion:   tls.VersionTLS12,
+			Certificates: []tls.Certificate{cert},
+		}, nil
+	}
+
+	if len(c.Autocert.Domains) > 0 {
+		m := &autocert.Manager{
+			Prompt:     autocert.AcceptTOS,
+			HostPolicy: autocert.HostWhitelist(c.Autocert.Domains...),
+			Cache:      autocert.DirCache(cmp.Or(c.Autocert.CacheDir, "./certs")),
+			Email:      c.Autocert.Email,
+		}
+		conf := m.TLSConfig()
+		conf.MinVersion = tls.VersionTLS12
+		return conf, nil
+	}
+	return nil, nil
+}
+
+// ListenAndServe conf不为nil时以TLS监听，否则为普通http
+func ListenAndServe(srv *http.Server, conf *tls.Config) error {
+	if conf == nil {
+		return srv.ListenAndServe()
+	}
+	srv.TLSConfig = conf
+	return srv.ListenAndServeTLS("", "")
+}
+
+// Scheme 服务地址的协议，用于启动日志
+func Scheme(conf *tls.Config, plain, secure string) string {
+	if conf == nil {
+		return plain
+	}
+	return secure
+}
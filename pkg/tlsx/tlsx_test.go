@@ -0,0 +1,82 @@
+package tlsx
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeCert 生成自签名证书写入临时目录
+func writeCert(t *testing.T, dir, name string) (string, string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		DNSNames:     []string{"localhost"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tpl, tpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	certFile, keyFile := filepath.Join(dir, name+".crt"), filepath.Join(dir, name+".key")
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return certFile, keyFile
+}
+
+func TestNewConfig(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := writeCert(t, dir, "a")
+	_, otherKey := writeCert(t, dir, "b")
+
+	conf, err := NewConfig(Conf{})
+	if err != nil || conf != nil {
+		t.Fatalf("disabled: conf=%v err=%v", conf, err)
+	}
+
+	conf, err = NewConfig(Conf{CertFile: certFile, KeyFile: keyFile})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(conf.Certificates) != 1 || conf.MinVersion == 0 {
+		t.Fatalf("unexpected config: %+v", conf)
+	}
+
+	if _, err := NewConfig(Conf{CertFile: certFile}); err == nil {
+		t.Fatal("expected error when KeyFile missing")
+	}
+	if _, err := NewConfig(Conf{CertFile: certFile, KeyFile: otherKey}); err == nil {
+		t.Fatal("expected error for mismatched key")
+	}
+
+	conf, err = NewConfig(Conf{Autocert: AutocertConf{Domains: []string{"example.com"}, CacheDir: dir}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if conf.GetCertificate == nil {
+		t.Fatal("autocert config without GetCertificate")
+	}
+}
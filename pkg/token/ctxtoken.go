@@ -4,19 +4,35 @@ import (
 	"context"
 
 	"github.com/golang-jwt/jwt"
+	"github.com/google/uuid"
 )
 
 const Identify = "wsj666"
 
+// Key 签名密钥，Kid写入token头部，解析时按Kid选择密钥；Kid为空的密钥用于校验轮换前签发的token
+type Key struct {
+	Kid    string
+	Secret string
+}
+
 func GetJwtToken(secretyKey string, iat, second int64, uid string) (string, error) {
+	return SignToken(Key{Secret: secretyKey}, iat, second, uid)
+}
+
+// SignToken 使用指定密钥签发token，轮换密钥时始终使用最新的密钥
+func SignToken(key Key, iat, second int64, uid string) (string, error) {
 	claims := make(jwt.MapClaims)
-	claims["exp"] = iat + second //过期时间
-	claims["iat"] = iat          //签发时间
-	claims[Identify] = uid       //用户标识
+	claims["exp"] = iat + second     //过期时间
+	claims["iat"] = iat              //签发时间
+	claims["jti"] = uuid.NewString() //token标识，用于注销
+	claims[Identify] = uid           //用户标识
 
 	token := jwt.New(jwt.SigningMethodHS256)
 	token.Claims = claims
-	return token.SignedString([]byte(secretyKey))
+	if len(key.Kid) > 0 {
+		token.Header["kid"] = key.Kid
+	}
+	return token.SignedString([]byte(key.Secret))
 }
 
 func GetUid(ctx context.Context) string {
@@ -0,0 +1,67 @@
+package token
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+func TestSignature(t *testing.T) {
+	// 不可用的Redis：签名校验通过后才会记录nonce，返回连接错误说明签名已通过
+	rds := redis.NewClient(&redis.Options{Addr: "127.0.0.1:1", MaxRetries: -1, DialTimeout: 100 * time.Millisecond})
+	s := NewSignature([]App{{Key: "erp", Secret: "s1", UserId: "u1"}}, rds, "test:", time.Minute)
+	body := []byte(`{"title":"t"}`)
+
+	newReq := func() *http.Request {
+		r, _ := http.NewRequest(http.MethodPost, "http://x/v1/todo?b=2&a=1", bytes.NewReader(body))
+		SignRequest(r, body, "erp", "s1")
+		return r
+	}
+
+	r := newReq()
+	if _, err := s.Verify(context.Background(), r, body); err == nil || errors.Is(err, ErrSignature) {
+		t.Fatalf("valid signature should pass to nonce check, got %v", err)
+	}
+
+	cases := map[string]func(r *http.Request){
+		"missing": func(r *http.Request) { r.Header.Del(HeaderNonce) },
+		"app":     func(r *http.Request) { r.Header.Set(HeaderAppKey, "other") },
+		"expired": func(r *http.Request) {
+			r.Header.Set(HeaderTimestamp, strconv.FormatInt(time.Now().Add(-2*time.Minute).Unix(), 10))
+		},
+		"mismatch": func(r *http.Request) { r.URL.RawQuery = "a=1&b=3" },
+		"secret": func(r *http.Request) {
+			ts, nonce := r.Header.Get(HeaderTimestamp), r.Header.Get(HeaderNonce)
+			r.Header.Set(HeaderSignature, Sign("wrong", StringToSign(r, body, ts, nonce)))
+		},
+	}
+	for name, modify := range cases {
+		r := newReq()
+		modify(r)
+		if _, err := s.Verify(context.Background(), r, body); !errors.Is(err, ErrSignature) {
+			t.Errorf("%s: got %v", name, err)
+		}
+	}
+
+	// 请求体被篡改
+	if _, err := s.Verify(context.Background(), newReq(), []byte(`{"title":"x"}`)); !errors.Is(err, ErrSignature) {
+		t.Errorf("tampered body: got %v", err)
+	}
+
+	// 查询参数顺序不影响签名
+	r = newReq()
+	r.URL.RawQuery = "a=1&b=2"
+	if _, err := s.Verify(context.Background(), r, body); errors.Is(err, ErrSignature) {
+		t.Errorf("query order should not matter: %v", err)
+	}
+
+	if NewSignature(nil, rds, "test:", 0).Enabled() {
+		t.Error("no apps should be disabled")
+	}
+}
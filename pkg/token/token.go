@@ -21,21 +21,35 @@ const (
 	Authorization = "Authorization" //http 字段
 )
 
+type claimsKey struct{}
+
 // token解析相关错误定义
 var (
 	ErrTokenNotFind  = errors.New("token不存在")
 	ErrTokenInvalid  = errors.New("token invalid")
 	ErrClaimsInvalid = errors.New("invalid token claim")
+	ErrKeyNotFound   = errors.New("token signing key not found")
 )
 
 //Parse jwt 解析
 
 type Parse struct {
-	AccessSecret string // jwt 签名密钥
+	keys map[string]string // kid -> 签名密钥
 }
 
 func NewTokenParse(secret string) *Parse {
-	return &Parse{AccessSecret: secret}
+	return NewKeysParse([]Key{{Secret: secret}})
+}
+
+// NewKeysParse 支持多个密钥，密钥轮换期间旧密钥签发的token仍然有效
+func NewKeysParse(keys []Key) *Parse {
+	p := &Parse{keys: make(map[string]string, len(keys))}
+	for _, k := range keys {
+		if len(k.Secret) > 0 {
+			p.keys[k.Kid] = k.Secret
+		}
+	}
+	return p
 }
 
 // 从http中解析出token
@@ -49,9 +63,7 @@ func (p *Parse) Parse(r *http.Request) (jwt.MapClaims, string, error) {
 
 func (p *Parse) ParseToken(tokenStr string) (jwt.MapClaims, string, error) {
 	// 解析token
-	token, err := jwt.Parse(tokenStr, func(token *jwt.Token) (interface{}, error) {
-		return []byte(p.AccessSecret), nil
-	})
+	token, err := jwt.Parse(tokenStr, p.keyFunc)
 	if err != nil {
 		return nil, tokenStr, err
 	}
@@ -71,13 +83,30 @@ func (p *Parse) ParseToken(tokenStr string) (jwt.MapClaims, string, error) {
 	return claims, tokenStr, nil
 }
 
+// keyFunc 按token头部的kid选择密钥，没有kid的token使用Kid为空的密钥
+func (p *Parse) keyFunc(token *jwt.Token) (interface{}, error) {
+	if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+		return nil, ErrTokenInvalid
+	}
+	kid, _ := token.Header["kid"].(string)
+	secret, ok := p.keys[kid]
+	if !ok {
+		return nil, ErrKeyNotFound
+	}
+	return []byte(secret), nil
+}
+
 func (p *Parse) ParseWithContext(r *http.Request) (*http.Request, error) {
 	//解析token
 	claims, tokenStr, err := p.Parse(r)
 	if err != nil {
 		return r, err
 	}
-	ctx := r.Context()
+	return r.WithContext(NewContext(r.Context(), claims, tokenStr)), nil
+}
+
+// NewContext 将自定义声明和原始token保存到上下文
+func NewContext(ctx context.Context, claims jwt.MapClaims, tokenStr string) context.Context {
 	for k, v := range claims {
 		switch k {
 		case jwtAudience, jwtExpire, jwtId, jwtIssueAt, jwtIssuer, jwtNotBefore, jwtSubject:
@@ -85,9 +114,15 @@ func (p *Parse) ParseWithContext(r *http.Request) (*http.Request, error) {
 			ctx = context.WithValue(ctx, k, v)
 		}
 	}
-	//保存原始token到上下文
+	//保存原始token和全部声明到上下文
 	ctx = context.WithValue(ctx, Authorization, tokenStr)
-	return r.WithContext(ctx), nil
+	return context.WithValue(ctx, claimsKey{}, claims)
+}
+
+// GetClaims 当前请求token的全部声明，用于注销token
+func GetClaims(ctx context.Context) jwt.MapClaims {
+	claims, _ := ctx.Value(claimsKey{}).(jwt.MapClaims)
+	return claims
 }
 
 func (p *Parse) ExtractTokenFromHeader(r *http.Request) string {
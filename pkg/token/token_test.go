@@ -0,0 +1,56 @@
+package token
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestKeyRotation(t *testing.T) {
+	now := time.Now().Unix()
+	legacy, _ := GetJwtToken("legacy", now, 60, "u1")
+	old, _ := SignToken(Key{Kid: "k1", Secret: "s1"}, now, 60, "u2")
+	current, _ := SignToken(Key{Kid: "k2", Secret: "s2"}, now, 60, "u3")
+
+	p := NewKeysParse([]Key{{Kid: "k2", Secret: "s2"}, {Kid: "k1", Secret: "s1"}, {Secret: "legacy"}})
+	for uid, tok := range map[string]string{"u1": legacy, "u2": old, "u3": current} {
+		claims, _, err := p.ParseToken(tok)
+		if err != nil {
+			t.Fatalf("%s: %v", uid, err)
+		}
+		if claims[Identify] != uid {
+			t.Errorf("got uid %v, want %s", claims[Identify], uid)
+		}
+	}
+
+	// 移除旧密钥后，旧密钥签发的token失效
+	p = NewKeysParse([]Key{{Kid: "k2", Secret: "s2"}})
+	if _, _, err := p.ParseToken(old); err == nil {
+		t.Error("token signed with removed key should be rejected")
+	}
+	if _, _, err := p.ParseToken(legacy); err == nil {
+		t.Error("token without kid should be rejected when no legacy secret")
+	}
+
+	// kid相同但密钥不同
+	forged, _ := SignToken(Key{Kid: "k2", Secret: "other"}, now, 60, "u3")
+	if _, _, err := p.ParseToken(forged); err == nil {
+		t.Error("forged token should be rejected")
+	}
+}
+
+func TestNewContext(t *testing.T) {
+	tok, _ := SignToken(Key{Secret: "s"}, time.Now().Unix(), 60, "u1")
+	claims, _, err := NewTokenParse("s").ParseToken(tok)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claims[jwtId] == "" || tokenId(claims) != claims[jwtId] {
+		t.Errorf("token should carry jti: %v", claims)
+	}
+
+	ctx := NewContext(context.Background(), claims, tok)
+	if GetUid(ctx) != "u1" || GetTokenStr(ctx) != tok || GetClaims(ctx)[jwtId] != claims[jwtId] {
+		t.Error("claims not saved in context")
+	}
+}
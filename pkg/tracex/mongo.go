@@ -0,0 +1,47 @@
+package tracex
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/event"
+)
+
+// MongoMonitor 为每条mongo命令记录跨度，作为ctx中跨度的子跨度
+func MongoMonitor() *event.CommandMonitor {
+	var spans sync.Map // RequestID -> *Span
+	finish := func(id int64, err error) {
+		if v, ok := spans.LoadAndDelete(id); ok {
+			s := v.(*Span)
+			s.SetError(err)
+			s.End()
+		}
+	}
+
+	return &event.CommandMonitor{
+		Started: func(ctx context.Context, evt *event.CommandStartedEvent) {
+			if _, ok := ctx.Value(spanKey{}).(spanContext); !ok {
+				// 不在链路中的命令（心跳、后台任务外的初始化等）不记录
+				return
+			}
+			_, s := Start(ctx, "mongo."+evt.CommandName, KindClient)
+			if s == nil {
+				return
+			}
+			s.SetAttr("db.system", "mongodb")
+			s.SetAttr("db.name", evt.DatabaseName)
+			s.SetAttr("db.operation", evt.CommandName)
+			if coll, ok := evt.Command.Lookup(evt.CommandName).StringValueOK(); ok {
+				s.SetAttr("db.mongodb.collection", coll)
+			}
+			spans.Store(evt.RequestID, s)
+		},
+		Succeeded: func(_ context.Context, evt *event.CommandSucceededEvent) {
+			finish(evt.RequestID, nil)
+		},
+		Failed: func(_ context.Context, evt *event.CommandFailedEvent) {
+			finish(evt.RequestID, errors.New(evt.Failure))
+		},
+	}
+}
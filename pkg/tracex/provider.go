@@ -0,0 +1,250 @@
+package tracex
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	queueSize     = 2048 // 待导出跨度的队列长度，满了丢弃
+	batchSize     = 512  // 攒够后立即导出
+	flushEvery    = 5 * time.Second
+	exportTimeout = 10 * time.Second
+)
+
+// Conf 链路追踪配置，通过 OTLP/HTTP（JSON编码）导出到 collector、Jaeger、Tempo 等
+type Conf struct {
+	Endpoint    string            // 如 http://localhost:4318，为空不启用
+	ServiceName string            // 服务名
+	SampleRatio float64           // 新链路的采样比例 0~1，默认1；加入上游链路时沿用上游的采样决定
+	Headers     map[string]string // 导出请求附加的http头，如鉴权
+}
+
+// Provider 收集结束的跨度，按批导出
+type Provider struct {
+	url     string
+	conf    Conf
+	client  *http.Client
+	queue   chan *Span
+	stop    chan struct{}
+	done    chan struct{}
+	closeMu sync.Once
+}
+
+// NewProvider 创建Provider并开始后台导出，未配置Endpoint时返回nil
+func NewProvider(c Conf) *Provider {
+	if c.Endpoint == "" {
+		return nil
+	}
+	if c.SampleRatio <= 0 || c.SampleRatio > 1 {
+		c.SampleRatio = 1
+	}
+	url := strings.TrimRight(c.Endpoint, "/")
+	if !strings.HasSuffix(url, "/v1/traces") {
+		url += "/v1/traces"
+	}
+
+	p := &Provider{
+		url:    url,
+		conf:   c,
+		client: &http.Client{Timeout: exportTimeout},
+		queue:  make(chan *Span, queueSize),
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	go p.run()
+	return p
+}
+
+// Shutdown 导出剩余的跨度后停止，nil时为空操作
+func (p *Provider) Shutdown(ctx context.Context) error {
+	if p == nil {
+		return nil
+	}
+	if global.Load() == p {
+		SetProvider(nil)
+	}
+	p.closeMu.Do(func() { close(p.stop) })
+	select {
+	case <-p.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (p *Provider) sample() bool {
+	return p.conf.SampleRatio >= 1 || rand.Float64() < p.conf.SampleRatio
+}
+
+func (p *Provider) export(s *Span) {
+	select {
+	case p.queue <- s:
+	default:
+	}
+}
+
+func (p *Provider) run() {
+	defer close(p.done)
+	ticker := time.NewTicker(flushEvery)
+	defer ticker.Stop()
+
+	batch := make([]*Span, 0, batchSize)
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		if err := p.send(batch); err != nil {
+			fmt.Printf("[Trace] 导出 %d 个跨度失败: %v\n", len(batch), err)
+		}
+		batch = batch[:0]
+	}
+
+	for {
+		select {
+		case s := <-p.queue:
+			if batch = append(batch, s); len(batch) >= batchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		case <-p.stop:
+			for {
+				select {
+				case s := <-p.queue:
+					batch = append(batch, s)
+				default:
+					flush()
+					return
+				}
+			}
+		}
+	}
+}
+
+func (p *Provider) send(spans []*Span) error {
+	body, err := json.Marshal(p.encode(spans))
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, p.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range p.conf.Headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		b, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("status %d: %s", resp.StatusCode, b)
+	}
+	return nil
+}
+
+// OTLP JSON 编码，字段见 opentelemetry-proto 的 trace/v1
+type (
+	otlpRequest struct {
+		ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+	}
+	otlpResourceSpans struct {
+		Resource   otlpResource     `json:"resource"`
+		ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+	}
+	otlpResource struct {
+		Attributes []otlpAttr `json:"attributes"`
+	}
+	otlpScopeSpans struct {
+		Scope struct {
+			Name string `json:"name"`
+		} `json:"scope"`
+		Spans []otlpSpan `json:"spans"`
+	}
+	otlpSpan struct {
+		TraceId           string     `json:"traceId"`
+		SpanId            string     `json:"spanId"`
+		ParentSpanId      string     `json:"parentSpanId,omitempty"`
+		Name              string     `json:"name"`
+		Kind              Kind       `json:"kind"`
+		StartTimeUnixNano string     `json:"startTimeUnixNano"`
+		EndTimeUnixNano   string     `json:"endTimeUnixNano"`
+		Attributes        []otlpAttr `json:"attributes,omitempty"`
+		Status            otlpStatus `json:"status"`
+	}
+	otlpStatus struct {
+		Code    int    `json:"code,omitempty"` // 0=未设置 2=错误
+		Message string `json:"message,omitempty"`
+	}
+	otlpAttr struct {
+		Key   string         `json:"key"`
+		Value map[string]any `json:"value"`
+	}
+)
+
+func (p *Provider) encode(spans []*Span) otlpRequest {
+	scope := otlpScopeSpans{Spans: make([]otlpSpan, 0, len(spans))}
+	scope.Scope.Name = "aiOffice"
+	for _, s := range spans {
+		v := otlpSpan{
+			TraceId:           hex.EncodeToString(s.sc.traceId[:]),
+			SpanId:            hex.EncodeToString(s.sc.spanId[:]),
+			Name:              s.name,
+			Kind:              s.kind,
+			StartTimeUnixNano: strconv.FormatInt(s.start.UnixNano(), 10),
+			EndTimeUnixNano:   strconv.FormatInt(s.end.UnixNano(), 10),
+		}
+		if s.parentId != [8]byte{} {
+			v.ParentSpanId = hex.EncodeToString(s.parentId[:])
+		}
+		for _, a := range s.attrs {
+			v.Attributes = append(v.Attributes, encodeAttr(a.key, a.value))
+		}
+		if s.failed {
+			v.Status = otlpStatus{Code: 2, Message: s.errMsg}
+		}
+		scope.Spans = append(scope.Spans, v)
+	}
+
+	return otlpRequest{ResourceSpans: []otlpResourceSpans{{
+		Resource: otlpResource{Attributes: []otlpAttr{
+			encodeAttr("service.name", cmp.Or(p.conf.ServiceName, "aiOffice")),
+		}},
+		ScopeSpans: []otlpScopeSpans{scope},
+	}}}
+}
+
+func encodeAttr(key string, value any) otlpAttr {
+	var v map[string]any
+	switch x := value.(type) {
+	case string:
+		v = map[string]any{"stringValue": x}
+	case bool:
+		v = map[string]any{"boolValue": x}
+	case int:
+		v = map[string]any{"intValue": strconv.Itoa(x)}
+	case int64:
+		v = map[string]any{"intValue": strconv.FormatInt(x, 10)}
+	case float64:
+		v = map[string]any{"doubleValue": x}
+	default:
+		v = map[string]any{"stringValue": fmt.Sprint(x)}
+	}
+	return otlpAttr{Key: key, Value: v}
+}
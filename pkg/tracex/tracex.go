@@ -0,0 +1,162 @@
+package tracex
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// Kind 跨度类型，取值与OTLP一致
+type Kind int
+
+const (
+	KindInternal Kind = 1
+	KindServer   Kind = 2
+	KindClient   Kind = 3
+	KindConsumer Kind = 5
+)
+
+// TraceparentHeader W3C Trace Context 的http头
+const TraceparentHeader = "traceparent"
+
+var global atomic.Pointer[Provider]
+
+// SetProvider 设置全局的Provider，为nil时不再记录跨度
+func SetProvider(p *Provider) {
+	global.Store(p)
+}
+
+type spanKey struct{}
+
+// spanContext 跨度标识，来自本进程的跨度或上游请求的traceparent
+type spanContext struct {
+	traceId [16]byte
+	spanId  [8]byte
+	sampled bool
+}
+
+// Span 一段耗时操作，End后导出，nil时所有方法为空操作
+type Span struct {
+	sc       spanContext
+	parentId [8]byte
+	name     string
+	kind     Kind
+	start    time.Time
+	end      time.Time
+	attrs    []attr
+	errMsg   string
+	failed   bool
+	provider *Provider
+	ended    atomic.Bool
+}
+
+type attr struct {
+	key   string
+	value any
+}
+
+// Start 开启跨度，ctx中有跨度时作为其子跨度；未启用追踪时返回原ctx和nil
+func Start(ctx context.Context, name string, kind Kind) (context.Context, *Span) {
+	p := global.Load()
+	if p == nil {
+		return ctx, nil
+	}
+
+	s := &Span{name: name, kind: kind, start: time.Now(), provider: p}
+	if parent, ok := ctx.Value(spanKey{}).(spanContext); ok {
+		s.sc.traceId = parent.traceId
+		s.sc.sampled = parent.sampled
+		s.parentId = parent.spanId
+	} else {
+		fillRandom(s.sc.traceId[:])
+		s.sc.sampled = p.sample()
+	}
+	fillRandom(s.sc.spanId[:])
+	return context.WithValue(ctx, spanKey{}, s.sc), s
+}
+
+// SetAttr 设置属性，value支持 string、bool、整数和浮点数，其他类型按字符串记录
+func (s *Span) SetAttr(key string, value any) {
+	if s == nil {
+		return
+	}
+	s.attrs = append(s.attrs, attr{key: key, value: value})
+}
+
+// SetError 记录错误，err为nil时忽略
+func (s *Span) SetError(err error) {
+	if s == nil || err == nil {
+		return
+	}
+	s.failed = true
+	s.errMsg = err.Error()
+}
+
+// End 结束跨度并提交导出，重复调用只生效一次
+func (s *Span) End() {
+	if s == nil || !s.ended.CompareAndSwap(false, true) {
+		return
+	}
+	s.end = time.Now()
+	if s.sc.sampled {
+		s.provider.export(s)
+	}
+}
+
+// TraceId 跨度所属链路的标识，用于在日志中关联
+func (s *Span) TraceId() string {
+	if s == nil {
+		return ""
+	}
+	return hex.EncodeToString(s.sc.traceId[:])
+}
+
+// Inject 将ctx中的跨度写入下游请求的traceparent
+func Inject(ctx context.Context, h http.Header) {
+	sc, ok := ctx.Value(spanKey{}).(spanContext)
+	if !ok {
+		return
+	}
+	flags := "00"
+	if sc.sampled {
+		flags = "01"
+	}
+	h.Set(TraceparentHeader, fmt.Sprintf("00-%s-%s-%s",
+		hex.EncodeToString(sc.traceId[:]), hex.EncodeToString(sc.spanId[:]), flags))
+}
+
+// Extract 读取上游请求的traceparent，之后开启的跨度加入该链路；格式不合法时忽略
+func Extract(ctx context.Context, h http.Header) context.Context {
+	parts := strings.Split(h.Get(TraceparentHeader), "-")
+	if len(parts) != 4 || parts[0] != "00" || len(parts[1]) != 32 || len(parts[2]) != 16 || len(parts[3]) != 2 {
+		return ctx
+	}
+	var sc spanContext
+	if _, err := hex.Decode(sc.traceId[:], []byte(parts[1])); err != nil || sc.traceId == [16]byte{} {
+		return ctx
+	}
+	if _, err := hex.Decode(sc.spanId[:], []byte(parts[2])); err != nil || sc.spanId == [8]byte{} {
+		return ctx
+	}
+	flags, err := hex.DecodeString(parts[3])
+	if err != nil {
+		return ctx
+	}
+	sc.sampled = flags[0]&1 == 1
+	return context.WithValue(ctx, spanKey{}, sc)
+}
+
+func fillRandom(b []byte) {
+	for i := 0; i < len(b); i += 8 {
+		v := rand.Uint64()
+		for j := i; j < len(b) && j < i+8; j++ {
+			b[j] = byte(v)
+			v >>= 8
+		}
+	}
+}
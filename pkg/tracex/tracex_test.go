@@ -0,0 +1,98 @@
+package tracex
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestTrace(t *testing.T) {
+	var (
+		mu    sync.Mutex
+		spans []otlpSpan
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/traces" || r.Header.Get("Authorization") != "token" {
+			t.Errorf("unexpected request %s %v", r.URL.Path, r.Header)
+		}
+		var req otlpRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Error(err)
+		}
+		mu.Lock()
+		for _, rs := range req.ResourceSpans {
+			for _, ss := range rs.ScopeSpans {
+				spans = append(spans, ss.Spans...)
+			}
+		}
+		mu.Unlock()
+	}))
+	defer srv.Close()
+
+	// 未启用时为空操作
+	ctx, span := Start(context.Background(), "noop", KindInternal)
+	span.SetAttr("k", "v")
+	span.End()
+	if span != nil || ctx.Value(spanKey{}) != nil {
+		t.Fatal("span should be nil without provider")
+	}
+
+	p := NewProvider(Conf{Endpoint: srv.URL, ServiceName: "test", Headers: map[string]string{"Authorization": "token"}})
+	SetProvider(p)
+
+	// 加入上游链路
+	h := http.Header{}
+	h.Set(TraceparentHeader, "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01")
+	ctx = Extract(context.Background(), h)
+
+	ctx, root := Start(ctx, "GET /v1/chat", KindServer)
+	_, child := Start(ctx, "mongo.find", KindClient)
+	child.SetAttr("db.name", "office")
+	child.SetError(errors.New("boom"))
+	child.End()
+	child.End()
+	root.End()
+
+	out := http.Header{}
+	Inject(ctx, out)
+	if !strings.HasPrefix(out.Get(TraceparentHeader), "00-0af7651916cd43dd8448eb211c80319c-") {
+		t.Fatalf("inject: %q", out.Get(TraceparentHeader))
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := p.Shutdown(shutdownCtx); err != nil {
+		t.Fatal(err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(spans) != 2 {
+		t.Fatalf("expected 2 spans, got %d", len(spans))
+	}
+	c, r := spans[0], spans[1]
+	if r.TraceId != "0af7651916cd43dd8448eb211c80319c" || r.ParentSpanId != "b7ad6b7169203331" {
+		t.Fatalf("root not joined upstream: %+v", r)
+	}
+	if c.TraceId != r.TraceId || c.ParentSpanId != r.SpanId {
+		t.Fatalf("child not nested: %+v", c)
+	}
+	if c.Status.Code != 2 || c.Status.Message != "boom" || len(c.Attributes) != 1 {
+		t.Fatalf("child status/attrs: %+v", c)
+	}
+
+	// 未采样的上游链路不导出
+	h.Set(TraceparentHeader, "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-00")
+	SetProvider(p)
+	_, s := Start(Extract(context.Background(), h), "x", KindInternal)
+	if s.sc.sampled {
+		t.Fatal("should follow upstream sampling decision")
+	}
+	SetProvider(nil)
+}
@@ -0,0 +1,82 @@
+package uploadx
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+)
+
+// clamd INSTREAM 每块的大小，需小于 clamd 的 StreamMaxLength
+const clamdChunkSize = 64 << 10
+
+// Clamd 通过 clamd 的 INSTREAM 命令扫描文件
+type Clamd struct {
+	network string
+	addr    string
+	timeout time.Duration
+}
+
+// NewClamd addr为 host:port 或 unix socket 路径
+func NewClamd(addr string, timeout time.Duration) *Clamd {
+	network := "tcp"
+	if strings.HasPrefix(addr, "/") {
+		network = "unix"
+	}
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+	return &Clamd{network: network, addr: addr, timeout: timeout}
+}
+
+func (c *Clamd) Scan(ctx context.Context, name string, data []byte) error {
+	d := net.Dialer{Timeout: c.timeout}
+	conn, err := d.DialContext(ctx, c.network, c.addr)
+	if err != nil {
+		return fmt.Errorf("clamd dial: %w", err)
+	}
+	defer conn.Close()
+	deadline := time.Now().Add(c.timeout)
+	if dl, ok := ctx.Deadline(); ok && dl.Before(deadline) {
+		deadline = dl
+	}
+	_ = conn.SetDeadline(deadline)
+
+	if _, err := conn.Write([]byte("zINSTREAM\x00")); err != nil {
+		return fmt.Errorf("clamd write: %w", err)
+	}
+	var size [4]byte
+	for len(data) > 0 {
+		n := min(len(data), clamdChunkSize)
+		binary.BigEndian.PutUint32(size[:], uint32(n))
+		if _, err := conn.Write(size[:]); err != nil {
+			return fmt.Errorf("clamd write: %w", err)
+		}
+		if _, err := conn.Write(data[:n]); err != nil {
+			return fmt.Errorf("clamd write: %w", err)
+		}
+		data = data[n:]
+	}
+	// 长度为0的块表示结束
+	binary.BigEndian.PutUint32(size[:], 0)
+	if _, err := conn.Write(size[:]); err != nil {
+		return fmt.Errorf("clamd write: %w", err)
+	}
+
+	reply, err := bufio.NewReader(conn).ReadString(0)
+	if err != nil && reply == "" {
+		return fmt.Errorf("clamd read: %w", err)
+	}
+	reply = strings.TrimRight(reply, "\x00\n")
+	switch {
+	case strings.HasSuffix(reply, "OK"):
+		return nil
+	case strings.HasSuffix(reply, "FOUND"):
+		return ErrInfected
+	default:
+		return fmt.Errorf("clamd scan %s: %s", name, reply)
+	}
+}
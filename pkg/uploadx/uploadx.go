@@ -0,0 +1,113 @@
+package uploadx
+
+import (
+	"context"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"aiOffice/pkg/xerr"
+)
+
+var (
+	ErrTooLarge       = xerr.NewCode(xerr.Invalid, "文件大小超过限制")
+	ErrExtNotAllowed  = xerr.NewCode(xerr.Invalid, "不支持的文件类型")
+	ErrTypeNotAllowed = xerr.NewCode(xerr.Invalid, "文件内容与允许的类型不符")
+	ErrQuotaExceeded  = xerr.NewCode(xerr.Forbidden, "存储空间不足")
+	ErrInfected       = xerr.NewCode(xerr.Invalid, "文件未通过安全扫描")
+)
+
+// Conf 上传校验配置，各项为零值时不限制
+type Conf struct {
+	MaxSize    int64    // 单个文件大小上限（字节）
+	Extensions []string // 允许的扩展名，如 .pdf
+	MimeTypes  []string // 允许的内容类型，按文件内容识别，支持 image/* 形式
+	UserQuota  int64    // 每个用户的存储空间上限（字节）
+}
+
+// Scanner 杀毒扫描，发现病毒时返回 ErrInfected
+type Scanner interface {
+	Scan(ctx context.Context, name string, data []byte) error
+}
+
+// Validator 文件写入磁盘前的校验
+type Validator struct {
+	conf    Conf
+	exts    map[string]bool
+	scanner Scanner
+}
+
+// New scanner 为 nil 时不扫描
+func New(conf Conf, scanner Scanner) *Validator {
+	v := &Validator{conf: conf, scanner: scanner}
+	if len(conf.Extensions) > 0 {
+		v.exts = make(map[string]bool, len(conf.Extensions))
+		for _, ext := range conf.Extensions {
+			ext = strings.ToLower(ext)
+			if !strings.HasPrefix(ext, ".") {
+				ext = "." + ext
+			}
+			v.exts[ext] = true
+		}
+	}
+	return v
+}
+
+// MaxSize 单个文件大小上限，0为不限制
+func (v *Validator) MaxSize() int64 {
+	return v.conf.MaxSize
+}
+
+// CheckHeader 读取内容前按文件名和声明的大小快速校验
+func (v *Validator) CheckHeader(name string, size int64) error {
+	if v.conf.MaxSize > 0 && size > v.conf.MaxSize {
+		return ErrTooLarge
+	}
+	if v.exts != nil && !v.exts[strings.ToLower(filepath.Ext(name))] {
+		return ErrExtNotAllowed
+	}
+	return nil
+}
+
+// Check 校验文件内容，包括实际大小、内容类型和杀毒扫描
+func (v *Validator) Check(ctx context.Context, name string, data []byte) error {
+	if err := v.CheckHeader(name, int64(len(data))); err != nil {
+		return err
+	}
+	if len(v.conf.MimeTypes) > 0 && !v.allowType(http.DetectContentType(data)) {
+		return ErrTypeNotAllowed
+	}
+	if v.scanner != nil {
+		return v.scanner.Scan(ctx, name, data)
+	}
+	return nil
+}
+
+// CheckQuota used为用户已占用的空间，size为本次上传的总大小
+func (v *Validator) CheckQuota(used, size int64) error {
+	if v.conf.UserQuota > 0 && used+size > v.conf.UserQuota {
+		return ErrQuotaExceeded
+	}
+	return nil
+}
+
+// QuotaEnabled 是否限制用户存储空间，未启用时无需统计已用空间
+func (v *Validator) QuotaEnabled() bool {
+	return v.conf.UserQuota > 0
+}
+
+func (v *Validator) allowType(contentType string) bool {
+	// 去掉 charset 等参数
+	if i := strings.IndexByte(contentType, ';'); i >= 0 {
+		contentType = contentType[:i]
+	}
+	for _, t := range v.conf.MimeTypes {
+		if t == contentType {
+			return true
+		}
+		if prefix, ok := strings.CutSuffix(t, "*"); ok && strings.HasPrefix(contentType, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,100 @@
+package uploadx
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"testing"
+	"time"
+)
+
+var png = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+
+func TestValidator(t *testing.T) {
+	v := New(Conf{
+		MaxSize:    16,
+		Extensions: []string{".png", "PDF"},
+		MimeTypes:  []string{"image/*", "application/pdf"},
+		UserQuota:  100,
+	}, nil)
+
+	cases := []struct {
+		name string
+		data []byte
+		err  error
+	}{
+		{"a.png", png, nil},
+		{"a.PDF", []byte("%PDF-1.4"), nil},
+		{"a.png", bytes.Repeat([]byte("x"), 17), ErrTooLarge},
+		{"a.exe", png, ErrExtNotAllowed},
+		{"a.png", []byte("hello"), ErrTypeNotAllowed},
+	}
+	for i, c := range cases {
+		if err := v.Check(context.Background(), c.name, c.data); err != c.err {
+			t.Errorf("case %d: got %v, want %v", i, err, c.err)
+		}
+	}
+
+	if v.CheckQuota(90, 10) != nil || v.CheckQuota(90, 11) != ErrQuotaExceeded {
+		t.Error("quota check")
+	}
+	if New(Conf{}, nil).Check(context.Background(), "a.exe", bytes.Repeat([]byte("x"), 1024)) != nil {
+		t.Error("zero conf should allow everything")
+	}
+}
+
+func TestClamd(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skip(err)
+	}
+	defer ln.Close()
+
+	// 模拟clamd：内容包含EICAR时报毒
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func(conn net.Conn) {
+				defer conn.Close()
+				r := bufio.NewReader(conn)
+				if _, err := r.ReadString(0); err != nil {
+					return
+				}
+				var data []byte
+				for {
+					var size uint32
+					if err := binary.Read(r, binary.BigEndian, &size); err != nil {
+						return
+					}
+					if size == 0 {
+						break
+					}
+					chunk := make([]byte, size)
+					if _, err := io.ReadFull(r, chunk); err != nil {
+						return
+					}
+					data = append(data, chunk...)
+				}
+				if bytes.Contains(data, []byte("EICAR")) {
+					conn.Write([]byte("stream: Eicar-Signature FOUND\x00"))
+				} else {
+					conn.Write([]byte("stream: OK\x00"))
+				}
+			}(conn)
+		}
+	}()
+
+	c := NewClamd(ln.Addr().String(), time.Second)
+	if err := c.Scan(context.Background(), "a.txt", bytes.Repeat([]byte("x"), clamdChunkSize*2+1)); err != nil {
+		t.Fatalf("clean file: %v", err)
+	}
+	if err := c.Scan(context.Background(), "b.txt", []byte("X5O!P%@AP EICAR test")); err != ErrInfected {
+		t.Fatalf("infected file: %v", err)
+	}
+}
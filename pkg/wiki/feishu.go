@@ -0,0 +1,216 @@
+package wiki
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const feishuUrl = "https://open.feishu.cn/open-apis"
+
+// FeishuConf 飞书自建应用，需要开通知识库和云文档的读取权限，并将应用添加为知识空间成员
+type FeishuConf struct {
+	AppId     string
+	AppSecret string
+	SpaceId   string // 知识空间ID
+	Url       string // 为空使用飞书地址，Lark国际版为 https://open.larksuite.com/open-apis
+}
+
+// Feishu 遍历知识空间的节点，同步修改时间晚于游标的新版文档，游标为修改时间（unix秒）
+type Feishu struct {
+	conf   FeishuConf
+	client *http.Client
+
+	mu       sync.Mutex
+	token    string
+	expireAt time.Time
+}
+
+func NewFeishu(conf FeishuConf) *Feishu {
+	if conf.Url == "" {
+		conf.Url = feishuUrl
+	}
+	conf.Url = strings.TrimRight(conf.Url, "/")
+	return &Feishu{
+		conf:   conf,
+		client: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+func (f *Feishu) Name() string {
+	return SourceFeishu
+}
+
+type feishuNode struct {
+	NodeToken   string `json:"node_token"`
+	ObjToken    string `json:"obj_token"`
+	ObjType     string `json:"obj_type"`
+	Title       string `json:"title"`
+	HasChild    bool   `json:"has_child"`
+	ObjEditTime string `json:"obj_edit_time"`
+}
+
+func (f *Feishu) Changes(ctx context.Context, cursor string) ([]*Page, string, error) {
+	since, _ := strconv.ParseInt(cursor, 10, 64)
+
+	nodes, err := f.nodes(ctx, "")
+	if err != nil {
+		return nil, "", fmt.Errorf("feishu: %w", err)
+	}
+
+	var (
+		changed []*feishuNode
+		latest  = since
+	)
+	for _, node := range nodes {
+		edit, _ := strconv.ParseInt(node.ObjEditTime, 10, 64)
+		// 只同步新版文档，表格、思维导图等跳过
+		if node.ObjType != "docx" || edit < since {
+			continue
+		}
+		changed = append(changed, node)
+	}
+	// 按修改时间顺序处理，超过maxPages时游标停在已处理的位置
+	sortNodes(changed)
+	if len(changed) > maxPages {
+		changed = changed[:maxPages]
+	}
+
+	pages := make([]*Page, 0, len(changed))
+	for _, node := range changed {
+		content, err := f.rawContent(ctx, node.ObjToken)
+		if err != nil {
+			return nil, "", fmt.Errorf("feishu: %w", err)
+		}
+		edit, _ := strconv.ParseInt(node.ObjEditTime, 10, 64)
+		pages = append(pages, &Page{
+			Id:      node.ObjToken,
+			Title:   node.Title,
+			Content: content,
+			Url:     "https://feishu.cn/wiki/" + node.NodeToken,
+			Updated: time.Unix(edit, 0),
+		})
+		latest = max(latest, edit)
+	}
+
+	if latest == 0 {
+		return pages, cursor, nil
+	}
+	return pages, strconv.FormatInt(latest, 10), nil
+}
+
+// nodes 递归列出知识空间中的全部节点
+func (f *Feishu) nodes(ctx context.Context, parent string) ([]*feishuNode, error) {
+	var (
+		list      []*feishuNode
+		pageToken string
+	)
+	for {
+		q := url.Values{"page_size": {"50"}}
+		if parent != "" {
+			q.Set("parent_node_token", parent)
+		}
+		if pageToken != "" {
+			q.Set("page_token", pageToken)
+		}
+		var res struct {
+			Items     []*feishuNode `json:"items"`
+			HasMore   bool          `json:"has_more"`
+			PageToken string        `json:"page_token"`
+		}
+		u := f.conf.Url + "/wiki/v2/spaces/" + url.PathEscape(f.conf.SpaceId) + "/nodes?" + q.Encode()
+		if err := f.do(ctx, http.MethodGet, u, &res); err != nil {
+			return nil, err
+		}
+
+		for _, node := range res.Items {
+			list = append(list, node)
+			if node.HasChild {
+				children, err := f.nodes(ctx, node.NodeToken)
+				if err != nil {
+					return nil, err
+				}
+				list = append(list, children...)
+			}
+		}
+		if !res.HasMore {
+			return list, nil
+		}
+		pageToken = res.PageToken
+	}
+}
+
+func (f *Feishu) rawContent(ctx context.Context, docId string) (string, error) {
+	var res struct {
+		Content string `json:"content"`
+	}
+	err := f.do(ctx, http.MethodGet, f.conf.Url+"/docx/v1/documents/"+url.PathEscape(docId)+"/raw_content", &res)
+	return strings.TrimSpace(res.Content), err
+}
+
+// do 飞书接口统一返回 {code, msg, data}，code非0为失败
+func (f *Feishu) do(ctx context.Context, method, u string, data any) error {
+	token, err := f.tenantToken(ctx)
+	if err != nil {
+		return err
+	}
+
+	var res struct {
+		Code int    `json:"code"`
+		Msg  string `json:"msg"`
+		Data any    `json:"data"`
+	}
+	res.Data = data
+	header := http.Header{}
+	header.Set("Authorization", "Bearer "+token)
+	if err := request(ctx, f.client, method, u, header, nil, &res); err != nil {
+		return err
+	}
+	if res.Code != 0 {
+		return fmt.Errorf("code %d: %s", res.Code, res.Msg)
+	}
+	return nil
+}
+
+// tenantToken 应用访问凭证，有效期2小时，提前5分钟刷新
+func (f *Feishu) tenantToken(ctx context.Context) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.token != "" && time.Now().Before(f.expireAt) {
+		return f.token, nil
+	}
+
+	var res struct {
+		Code   int    `json:"code"`
+		Msg    string `json:"msg"`
+		Token  string `json:"tenant_access_token"`
+		Expire int64  `json:"expire"`
+	}
+	err := request(ctx, f.client, http.MethodPost, f.conf.Url+"/auth/v3/tenant_access_token/internal", nil,
+		map[string]string{"app_id": f.conf.AppId, "app_secret": f.conf.AppSecret}, &res)
+	if err != nil {
+		return "", err
+	}
+	if res.Code != 0 {
+		return "", fmt.Errorf("获取tenant_access_token失败: code %d: %s", res.Code, res.Msg)
+	}
+
+	f.token = res.Token
+	f.expireAt = time.Now().Add(time.Duration(res.Expire)*time.Second - 5*time.Minute)
+	return f.token, nil
+}
+
+func sortNodes(nodes []*feishuNode) {
+	sort.SliceStable(nodes, func(i, j int) bool {
+		a, _ := strconv.ParseInt(nodes[i].ObjEditTime, 10, 64)
+		b, _ := strconv.ParseInt(nodes[j].ObjEditTime, 10, 64)
+		return a < b
+	})
+}
@@ -0,0 +1,207 @@
+package wiki
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	notionUrl     = "https://api.notion.com/v1"
+	notionVersion = "2022-06-28"
+
+	// notionMaxDepth 读取嵌套块的最大层数
+	notionMaxDepth = 3
+)
+
+// NotionConf Notion内部集成的令牌，只能读取已分享给该集成的页面
+type NotionConf struct {
+	Token string
+	Url   string // 为空使用官方地址，测试时替换
+}
+
+// Notion 按最后编辑时间倒序搜索页面，游标为最后编辑时间（RFC3339）
+type Notion struct {
+	conf   NotionConf
+	client *http.Client
+}
+
+func NewNotion(conf NotionConf) *Notion {
+	if conf.Url == "" {
+		conf.Url = notionUrl
+	}
+	conf.Url = strings.TrimRight(conf.Url, "/")
+	return &Notion{
+		conf:   conf,
+		client: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+func (n *Notion) Name() string {
+	return SourceNotion
+}
+
+type notionPage struct {
+	Id             string                     `json:"id"`
+	Url            string                     `json:"url"`
+	LastEditedTime string                     `json:"last_edited_time"`
+	Properties     map[string]json.RawMessage `json:"properties"`
+}
+
+// Changes 搜索接口只支持倒序，遇到早于游标的页面即停止；无法从中间继续，因此不受maxPages限制
+func (n *Notion) Changes(ctx context.Context, cursor string) ([]*Page, string, error) {
+	since, _ := time.Parse(time.RFC3339, cursor)
+
+	var (
+		pages  []*Page
+		newest time.Time
+		next   string
+	)
+	for {
+		body := map[string]any{
+			"filter":    map[string]string{"property": "object", "value": "page"},
+			"sort":      map[string]string{"direction": "descending", "timestamp": "last_edited_time"},
+			"page_size": 50,
+		}
+		if next != "" {
+			body["start_cursor"] = next
+		}
+		var res struct {
+			Results    []*notionPage `json:"results"`
+			HasMore    bool          `json:"has_more"`
+			NextCursor string        `json:"next_cursor"`
+		}
+		if err := request(ctx, n.client, http.MethodPost, n.conf.Url+"/search", n.header(), body, &res); err != nil {
+			return nil, "", fmt.Errorf("notion: %w", err)
+		}
+
+		done := !res.HasMore
+		for _, v := range res.Results {
+			updated, _ := time.Parse(time.RFC3339, v.LastEditedTime)
+			// 编辑时间精确到分钟，与游标相同的页面重新拉取，由调用方按内容去重
+			if !since.IsZero() && updated.Before(since) {
+				done = true
+				break
+			}
+			if updated.After(newest) {
+				newest = updated
+			}
+
+			content, err := n.text(ctx, v.Id, 0)
+			if err != nil {
+				return nil, "", fmt.Errorf("notion: %w", err)
+			}
+			pages = append(pages, &Page{
+				Id:      v.Id,
+				Title:   notionTitle(v.Properties),
+				Content: content,
+				Url:     v.Url,
+				Updated: updated,
+			})
+		}
+		if done {
+			break
+		}
+		next = res.NextCursor
+	}
+
+	if newest.IsZero() {
+		return pages, cursor, nil
+	}
+	return pages, newest.UTC().Format(time.RFC3339), nil
+}
+
+// text 读取页面下全部块的文本，子块缩进一级
+func (n *Notion) text(ctx context.Context, blockId string, depth int) (string, error) {
+	var (
+		b    strings.Builder
+		next string
+	)
+	for {
+		q := url.Values{"page_size": {"100"}}
+		if next != "" {
+			q.Set("start_cursor", next)
+		}
+		var res struct {
+			Results []map[string]json.RawMessage `json:"results"`
+			HasMore bool                         `json:"has_more"`
+			Next    string                       `json:"next_cursor"`
+		}
+		u := n.conf.Url + "/blocks/" + url.PathEscape(blockId) + "/children?" + q.Encode()
+		if err := request(ctx, n.client, http.MethodGet, u, n.header(), nil, &res); err != nil {
+			return "", err
+		}
+
+		for _, block := range res.Results {
+			var (
+				id, typ     string
+				hasChildren bool
+			)
+			_ = json.Unmarshal(block["id"], &id)
+			_ = json.Unmarshal(block["type"], &typ)
+			_ = json.Unmarshal(block["has_children"], &hasChildren)
+
+			var content struct {
+				RichText []struct {
+					PlainText string `json:"plain_text"`
+				} `json:"rich_text"`
+			}
+			_ = json.Unmarshal(block[typ], &content)
+			var line strings.Builder
+			for _, t := range content.RichText {
+				line.WriteString(t.PlainText)
+			}
+			if line.Len() > 0 {
+				b.WriteString(strings.Repeat("  ", depth))
+				b.WriteString(line.String())
+				b.WriteString("\n")
+			}
+
+			// 子页面单独同步，不展开
+			if hasChildren && typ != "child_page" && typ != "child_database" && depth+1 < notionMaxDepth {
+				child, err := n.text(ctx, id, depth+1)
+				if err != nil {
+					return "", err
+				}
+				b.WriteString(child)
+			}
+		}
+		if !res.HasMore {
+			break
+		}
+		next = res.Next
+	}
+	return b.String(), nil
+}
+
+func (n *Notion) header() http.Header {
+	h := http.Header{}
+	h.Set("Authorization", "Bearer "+n.conf.Token)
+	h.Set("Notion-Version", notionVersion)
+	return h
+}
+
+// notionTitle 页面属性中类型为title的属性
+func notionTitle(props map[string]json.RawMessage) string {
+	for _, raw := range props {
+		var p struct {
+			Type  string `json:"type"`
+			Title []struct {
+				PlainText string `json:"plain_text"`
+			} `json:"title"`
+		}
+		if err := json.Unmarshal(raw, &p); err != nil || p.Type != "title" {
+			continue
+		}
+		var b strings.Builder
+		for _, t := range p.Title {
+			b.WriteString(t.PlainText)
+		}
+		return b.String()
+	}
+	return ""
+}
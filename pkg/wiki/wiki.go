@@ -0,0 +1,100 @@
+package wiki
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// 外部知识库
+const (
+	SourceConfluence = "confluence"
+	SourceNotion     = "notion"
+	SourceFeishu     = "feishu" // 飞书知识库（wiki空间中的新版文档）
+)
+
+// maxPages 单次同步最多拉取的页面数，剩余的在下次同步时继续
+const maxPages = 200
+
+// Page 外部知识库中的页面
+type Page struct {
+	Id      string    // 外部系统中的页面ID
+	Title   string    // 标题
+	Content string    // 纯文本内容
+	Url     string    // 页面地址
+	Updated time.Time // 最后修改时间
+}
+
+// Connector 外部知识库连接器
+type Connector interface {
+	Name() string
+	// Changes 返回游标之后修改过的页面和新的游标，cursor为空时全量拉取；
+	// 游标格式由连接器决定，调用方只负责保存
+	Changes(ctx context.Context, cursor string) ([]*Page, string, error)
+}
+
+// request 发送JSON请求，状态码非2xx时返回响应内容
+func request(ctx context.Context, client *http.Client, method, u string, header http.Header, body, v any) error {
+	var reader io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(b)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, u, reader)
+	if err != nil {
+		return err
+	}
+	for k, vs := range header {
+		req.Header[k] = vs
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("status %d: %s", resp.StatusCode, strings.TrimSpace(string(b)))
+	}
+	if v == nil {
+		return nil
+	}
+	return json.Unmarshal(b, v)
+}
+
+var (
+	blockTag  = regexp.MustCompile(`(?i)<(br|/p|/div|/li|/tr|/h[1-6]|/table|/blockquote)[^>]*>`)
+	cellTag   = regexp.MustCompile(`(?i)</t[dh]>`)
+	anyTag    = regexp.MustCompile(`<[^>]*>`)
+	blankLine = regexp.MustCompile(`\n\s*\n+`)
+)
+
+// htmlText 将HTML（Confluence存储格式）转换为纯文本，保留段落和表格单元格的分隔
+func htmlText(s string) string {
+	s = blockTag.ReplaceAllString(s, "\n")
+	s = cellTag.ReplaceAllString(s, " | ")
+	s = anyTag.ReplaceAllString(s, "")
+	s = html.UnescapeString(s)
+	s = blankLine.ReplaceAllString(s, "\n\n")
+	return strings.TrimSpace(s)
+}
@@ -0,0 +1,134 @@
+package wiki
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestHtmlText(t *testing.T) {
+	got := htmlText(`<h1>报销制度</h1><p>差旅费&amp;住宿费</p><table><tr><td>城市</td><td>标准</td></tr></table>`)
+	want := "报销制度\n差旅费&住宿费\n城市 | 标准 |"
+	if got != want {
+		t.Fatalf("unexpected text: %q", got)
+	}
+}
+
+func TestConfluence(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, pass, ok := r.BasicAuth()
+		if !ok || user != "a@b.com" || pass != "token" {
+			t.Errorf("unexpected auth: %s %s", user, pass)
+		}
+		cql := r.URL.Query().Get("cql")
+		if !strings.Contains(cql, `space="HR"`) || !strings.Contains(cql, `lastmodified >= "2024/03/01 08:00"`) {
+			t.Errorf("unexpected cql: %s", cql)
+		}
+		w.Write([]byte(`{"results":[{"id":"101","title":"请假制度","version":{"when":"2024-03-02T10:00:00.000Z"},
+			"body":{"storage":{"value":"<p>年假5天</p>"}},"_links":{"webui":"/spaces/HR/pages/101"}}],"size":1}`))
+	}))
+	defer srv.Close()
+
+	c := NewConfluence(ConfluenceConf{Url: srv.URL + "/", Username: "a@b.com", Token: "token", Space: "HR"})
+	pages, cursor, err := c.Changes(context.Background(), "2024-03-01T08:00:00Z")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pages) != 1 || pages[0].Title != "请假制度" || pages[0].Content != "年假5天" || pages[0].Url != srv.URL+"/spaces/HR/pages/101" {
+		t.Fatalf("unexpected pages: %+v", pages)
+	}
+	if cursor != "2024-03-02T10:00:00Z" {
+		t.Fatalf("unexpected cursor: %s", cursor)
+	}
+}
+
+func TestNotion(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Notion-Version") == "" || r.Header.Get("Authorization") != "Bearer secret" {
+			t.Errorf("unexpected header: %v", r.Header)
+		}
+		switch r.URL.Path {
+		case "/search":
+			w.Write([]byte(`{"results":[
+				{"id":"p2","url":"https://notion.so/p2","last_edited_time":"2024-03-03T00:00:00.000Z",
+				 "properties":{"Name":{"type":"title","title":[{"plain_text":"报销流程"}]}}},
+				{"id":"p1","last_edited_time":"2024-02-01T00:00:00.000Z","properties":{}}
+			],"has_more":true,"next_cursor":"c2"}`))
+		case "/blocks/p2/children":
+			w.Write([]byte(`{"results":[
+				{"id":"b1","type":"paragraph","has_children":true,"paragraph":{"rich_text":[{"plain_text":"提交发票"}]}},
+				{"id":"b2","type":"child_page","has_children":true,"child_page":{"title":"子页面"}}
+			]}`))
+		case "/blocks/b1/children":
+			w.Write([]byte(`{"results":[{"id":"b3","type":"bulleted_list_item","bulleted_list_item":{"rich_text":[{"plain_text":"需要抬头"}]}}]}`))
+		default:
+			t.Errorf("unexpected path: %s", r.URL.Path)
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	n := NewNotion(NotionConf{Token: "secret", Url: srv.URL})
+	pages, cursor, err := n.Changes(context.Background(), "2024-03-01T00:00:00Z")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// p1早于游标，搜索在此停止，不再请求下一页
+	if len(pages) != 1 || pages[0].Title != "报销流程" || pages[0].Content != "提交发票\n  需要抬头\n" {
+		t.Fatalf("unexpected pages: %+v", pages)
+	}
+	if cursor != "2024-03-03T00:00:00Z" {
+		t.Fatalf("unexpected cursor: %s", cursor)
+	}
+}
+
+func TestFeishu(t *testing.T) {
+	var tokenCalls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/auth/v3/tenant_access_token/internal" {
+			tokenCalls++
+			var req map[string]string
+			json.NewDecoder(r.Body).Decode(&req)
+			if req["app_id"] != "cli_a" {
+				t.Errorf("unexpected app: %v", req)
+			}
+			w.Write([]byte(`{"code":0,"tenant_access_token":"t-1","expire":7200}`))
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer t-1" {
+			t.Errorf("unexpected token: %s", r.Header.Get("Authorization"))
+		}
+		switch {
+		case r.URL.Path == "/wiki/v2/spaces/s1/nodes" && r.URL.Query().Get("parent_node_token") == "":
+			w.Write([]byte(`{"code":0,"data":{"items":[
+				{"node_token":"n1","obj_token":"d1","obj_type":"docx","title":"员工手册","has_child":true,"obj_edit_time":"1709500000"},
+				{"node_token":"n2","obj_token":"s2","obj_type":"sheet","title":"表格","obj_edit_time":"1709500000"}
+			]}}`))
+		case r.URL.Path == "/wiki/v2/spaces/s1/nodes":
+			w.Write([]byte(`{"code":0,"data":{"items":[
+				{"node_token":"n3","obj_token":"d3","obj_type":"docx","title":"旧文档","obj_edit_time":"1600000000"}
+			]}}`))
+		case r.URL.Path == "/docx/v1/documents/d1/raw_content":
+			w.Write([]byte(`{"code":0,"data":{"content":"第一章 总则\n"}}`))
+		default:
+			t.Errorf("unexpected path: %s", r.URL.Path)
+			w.Write([]byte(`{"code":99991663,"msg":"not found"}`))
+		}
+	}))
+	defer srv.Close()
+
+	f := NewFeishu(FeishuConf{AppId: "cli_a", AppSecret: "secret", SpaceId: "s1", Url: srv.URL})
+	pages, cursor, err := f.Changes(context.Background(), "1700000000")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pages) != 1 || pages[0].Id != "d1" || pages[0].Content != "第一章 总则" {
+		t.Fatalf("unexpected pages: %+v", pages)
+	}
+	if cursor != "1709500000" || tokenCalls != 1 {
+		t.Fatalf("unexpected cursor %s or token calls %d", cursor, tokenCalls)
+	}
+}
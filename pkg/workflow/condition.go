@@ -0,0 +1,121 @@
+package workflow
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// 条件运算符
+const (
+	OpEq     = "eq"
+	OpNe     = "ne"
+	OpGt     = "gt"
+	OpGte    = "gte"
+	OpLt     = "lt"
+	OpLte    = "lte"
+	OpIn     = "in"     // Value为数组，表单值等于其中之一
+	OpExists = "exists" // 表单中填写了该字段
+)
+
+// Condition 按表单字段判断的条件，如 {"field": "amount", "op": "gt", "value": 5000}
+type Condition struct {
+	Field string `json:"field" bson:"field"`
+	Op    string `json:"op" bson:"op"`
+	Value any    `json:"value,omitempty" bson:"value,omitempty"`
+}
+
+func (c *Condition) validate() error {
+	if c.Field == "" {
+		return errors.New("条件缺少字段")
+	}
+	switch c.Op {
+	case OpEq, OpNe, OpExists:
+	case OpGt, OpGte, OpLt, OpLte:
+		if _, ok := number(c.Value); !ok {
+			return fmt.Errorf("条件 %s 的值需为数字", c.Field)
+		}
+	case OpIn:
+		if c.Value == nil || reflect.ValueOf(c.Value).Kind() != reflect.Slice {
+			return fmt.Errorf("条件 %s 的值需为数组", c.Field)
+		}
+	default:
+		return fmt.Errorf("条件运算符 %s 不支持", c.Op)
+	}
+	return nil
+}
+
+// Match 表单是否满足条件，未填写的字段只满足 ne
+func (c *Condition) Match(form map[string]any) bool {
+	v, ok := form[c.Field]
+	if !ok || v == nil {
+		return c.Op == OpNe
+	}
+
+	switch c.Op {
+	case OpExists:
+		return true
+	case OpEq:
+		return equal(v, c.Value)
+	case OpNe:
+		return !equal(v, c.Value)
+	case OpIn:
+		list := reflect.ValueOf(c.Value)
+		if list.Kind() != reflect.Slice {
+			return false
+		}
+		for i := 0; i < list.Len(); i++ {
+			if equal(v, list.Index(i).Interface()) {
+				return true
+			}
+		}
+		return false
+	}
+
+	a, ok1 := number(v)
+	b, ok2 := number(c.Value)
+	if !ok1 || !ok2 {
+		return false
+	}
+	switch c.Op {
+	case OpGt:
+		return a > b
+	case OpGte:
+		return a >= b
+	case OpLt:
+		return a < b
+	case OpLte:
+		return a <= b
+	}
+	return false
+}
+
+// equal 数字按数值比较（表单来自JSON或数据库时整数类型可能不同），其余按字符串比较
+func equal(a, b any) bool {
+	x, ok1 := number(a)
+	y, ok2 := number(b)
+	if ok1 && ok2 {
+		return x == y
+	}
+	return fmt.Sprint(a) == fmt.Sprint(b)
+}
+
+func number(v any) (float64, bool) {
+	switch n := v.(type) {
+	case int:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case float32:
+		return float64(n), true
+	case float64:
+		return n, true
+	case json.Number:
+		f, err := n.Float64()
+		return f, err == nil
+	}
+	return 0, false
+}
@@ -0,0 +1,209 @@
+package workflow
+
+import (
+	"errors"
+	"fmt"
+)
+
+// 节点类型
+const (
+	NodeApprove   = "approve"   // 审批
+	NodeCondition = "condition" // 条件分支，走第一个满足条件的分支
+	NodeParallel  = "parallel"  // 并行分支，全部分支到达Join后继续
+)
+
+// 多人审批的方式
+const (
+	ModeAll = "all" // 会签，全部通过
+	ModeAny = "any" // 或签，一人通过即可
+)
+
+// 表单字段类型
+const (
+	FieldString = "string"
+	FieldNumber = "number"
+	FieldBool   = "bool"
+)
+
+var ErrInvalidDefinition = errors.New("流程定义不正确")
+
+// Definition 流程定义：表单字段和从Start开始的节点图，节点之间不能有环
+type Definition struct {
+	Fields []*Field `json:"fields,omitempty" bson:"fields,omitempty"`
+	Start  string   `json:"start" bson:"start"`
+	Nodes  []*Node  `json:"nodes" bson:"nodes"`
+}
+
+// Field 表单字段
+type Field struct {
+	Name     string   `json:"name" bson:"name"`
+	Label    string   `json:"label,omitempty" bson:"label,omitempty"`
+	Type     string   `json:"type" bson:"type"` // string number bool
+	Required bool     `json:"required,omitempty" bson:"required,omitempty"`
+	Options  []string `json:"options,omitempty" bson:"options,omitempty"` // 字符串字段的可选值，为空不限
+}
+
+// Node 流程节点
+type Node struct {
+	Id        string    `json:"id" bson:"id"`
+	Type      string    `json:"type" bson:"type"` // approve condition parallel
+	Name      string    `json:"name,omitempty" bson:"name,omitempty"`
+	Approvers []string  `json:"approvers,omitempty" bson:"approvers,omitempty"` // 审批人，由调用方的 Resolver 解析
+	Mode      string    `json:"mode,omitempty" bson:"mode,omitempty"`           // 多人审批的方式，默认会签
+	Branches  []*Branch `json:"branches,omitempty" bson:"branches,omitempty"`   // 条件或并行分支
+	Join      string    `json:"join,omitempty" bson:"join,omitempty"`           // 并行分支汇合的节点，为空表示全部分支结束后流程结束
+	Next      string    `json:"next,omitempty" bson:"next,omitempty"`           // 审批节点的下一个节点，为空表示结束
+}
+
+// Branch 分支
+type Branch struct {
+	When *Condition `json:"when,omitempty" bson:"when,omitempty"` // 条件分支的条件，为空表示默认分支
+	Next string     `json:"next" bson:"next"`
+}
+
+// Validate 检查节点引用、类型和条件，并确认每个并行分支都会到达汇合节点
+func (d *Definition) Validate() error {
+	nodes := make(map[string]*Node, len(d.Nodes))
+	for _, n := range d.Nodes {
+		if n.Id == "" {
+			return fmt.Errorf("%w: 节点id不能为空", ErrInvalidDefinition)
+		}
+		if nodes[n.Id] != nil {
+			return fmt.Errorf("%w: 节点 %s 重复", ErrInvalidDefinition, n.Id)
+		}
+		nodes[n.Id] = n
+	}
+
+	for _, n := range d.Nodes {
+		if err := n.validate(); err != nil {
+			return fmt.Errorf("%w: 节点 %s %v", ErrInvalidDefinition, n.Id, err)
+		}
+	}
+	for _, f := range d.Fields {
+		if f.Name == "" {
+			return fmt.Errorf("%w: 字段名不能为空", ErrInvalidDefinition)
+		}
+		switch f.Type {
+		case FieldString, FieldNumber, FieldBool:
+		default:
+			return fmt.Errorf("%w: 字段 %s 类型不支持", ErrInvalidDefinition, f.Name)
+		}
+	}
+
+	if d.Start == "" {
+		return fmt.Errorf("%w: 缺少开始节点", ErrInvalidDefinition)
+	}
+	return walk(nodes, d.Start, "", map[string]bool{})
+}
+
+func (n *Node) validate() error {
+	switch n.Type {
+	case NodeApprove:
+		if len(n.Approvers) == 0 {
+			return errors.New("缺少审批人")
+		}
+		if n.Mode != "" && n.Mode != ModeAll && n.Mode != ModeAny {
+			return errors.New("审批方式不支持")
+		}
+	case NodeCondition:
+		if len(n.Branches) == 0 {
+			return errors.New("缺少分支")
+		}
+		for _, b := range n.Branches {
+			if b.When != nil {
+				if err := b.When.validate(); err != nil {
+					return err
+				}
+			}
+		}
+	case NodeParallel:
+		if len(n.Branches) < 2 {
+			return errors.New("并行节点至少需要两个分支")
+		}
+	default:
+		return errors.New("类型不支持")
+	}
+	return nil
+}
+
+// walk 从id开始的每条路径都应先到达join（为空表示结束），visiting 用于检测环
+func walk(nodes map[string]*Node, id, join string, visiting map[string]bool) error {
+	if id == join {
+		return nil
+	}
+	if id == "" {
+		return fmt.Errorf("%w: 分支没有汇合到节点 %s", ErrInvalidDefinition, join)
+	}
+	n := nodes[id]
+	if n == nil {
+		return fmt.Errorf("%w: 节点 %s 不存在", ErrInvalidDefinition, id)
+	}
+	if visiting[id] {
+		return fmt.Errorf("%w: 节点 %s 形成了环", ErrInvalidDefinition, id)
+	}
+	visiting[id] = true
+	defer delete(visiting, id)
+
+	switch n.Type {
+	case NodeApprove:
+		return walk(nodes, n.Next, join, visiting)
+	case NodeCondition:
+		for _, b := range n.Branches {
+			if err := walk(nodes, b.Next, join, visiting); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		for _, b := range n.Branches {
+			if err := walk(nodes, b.Next, n.Join, visiting); err != nil {
+				return err
+			}
+		}
+		return walk(nodes, n.Join, join, visiting)
+	}
+}
+
+// ValidateForm 检查必填字段和字段类型，数字字段接受整数和浮点数
+func (d *Definition) ValidateForm(form map[string]any) error {
+	for _, f := range d.Fields {
+		v, ok := form[f.Name]
+		if !ok || v == nil || v == "" {
+			if f.Required {
+				return fmt.Errorf("请填写%s", fieldLabel(f))
+			}
+			continue
+		}
+
+		valid := true
+		switch f.Type {
+		case FieldString:
+			s, isStr := v.(string)
+			valid = isStr && (len(f.Options) == 0 || contains(f.Options, s))
+		case FieldNumber:
+			_, valid = number(v)
+		case FieldBool:
+			_, valid = v.(bool)
+		}
+		if !valid {
+			return fmt.Errorf("%s格式不正确", fieldLabel(f))
+		}
+	}
+	return nil
+}
+
+func fieldLabel(f *Field) string {
+	if f.Label != "" {
+		return f.Label
+	}
+	return f.Name
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,255 @@
+package workflow
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// Status 流程实例状态，取值与审批状态一致
+type Status int
+
+const (
+	Running  Status = 1 // 进行中
+	Passed   Status = 2 // 通过
+	Refused  Status = 3 // 拒绝
+	Canceled Status = 4 // 撤销
+)
+
+// 处理记录的动作
+const (
+	ActionPass   = "pass"
+	ActionRefuse = "refuse"
+	ActionSkip   = "skip"   // 审批节点没有审批人，自动通过
+	ActionCancel = "cancel" // 申请人撤销
+)
+
+// maxSteps 一次流转最多经过的节点数，定义已校验无环，仅作保护
+const maxSteps = 1000
+
+var (
+	ErrFinished    = errors.New("流程已结束")
+	ErrNotApprover = errors.New("不是当前节点的审批人")
+	ErrNoBranch    = errors.New("没有满足条件的分支")
+)
+
+// Instance 流程实例的运行状态，由调用方保存
+type Instance struct {
+	Status  Status         `json:"status" bson:"status"`
+	Tasks   []*Task        `json:"tasks,omitempty" bson:"tasks,omitempty"`     // 待处理的审批节点，并行分支时有多个
+	Arrived map[string]int `json:"arrived,omitempty" bson:"arrived,omitempty"` // 并行节点已汇合的分支数
+	Records []*Record      `json:"records,omitempty" bson:"records,omitempty"` // 处理记录
+}
+
+// Task 待处理的审批节点
+type Task struct {
+	NodeId    string   `json:"nodeId" bson:"nodeId"`
+	Approvers []string `json:"approvers" bson:"approvers"`
+	Passed    []string `json:"passed,omitempty" bson:"passed,omitempty"` // 已通过的审批人
+	Scope     []string `json:"scope,omitempty" bson:"scope,omitempty"`   // 所在的并行节点，由外到内
+}
+
+// Record 处理记录
+type Record struct {
+	NodeId  string `json:"nodeId,omitempty" bson:"nodeId,omitempty"`
+	UserId  string `json:"userId,omitempty" bson:"userId,omitempty"`
+	Action  string `json:"action" bson:"action"`
+	Comment string `json:"comment,omitempty" bson:"comment,omitempty"`
+	At      int64  `json:"at" bson:"at"`
+}
+
+// Resolver 解析审批节点的审批人，如部门负责人、指定用户
+type Resolver func(node *Node) ([]string, error)
+
+// Engine 按流程定义和表单推进实例
+type Engine struct {
+	def     *Definition
+	nodes   map[string]*Node
+	form    map[string]any
+	resolve Resolver
+}
+
+// New 校验流程定义后创建
+func New(def *Definition, form map[string]any, resolve Resolver) (*Engine, error) {
+	if err := def.Validate(); err != nil {
+		return nil, err
+	}
+	nodes := make(map[string]*Node, len(def.Nodes))
+	for _, n := range def.Nodes {
+		nodes[n.Id] = n
+	}
+	return &Engine{def: def, nodes: nodes, form: form, resolve: resolve}, nil
+}
+
+// Start 从开始节点流转到第一批审批节点，没有需要审批的节点时直接通过
+func (e *Engine) Start(now int64) (*Instance, error) {
+	inst := &Instance{Status: Running}
+	steps := 0
+	if err := e.advance(inst, e.def.Start, nil, now, &steps); err != nil {
+		return nil, err
+	}
+	e.finish(inst)
+	return inst, nil
+}
+
+// Act 审批人处理当前节点：拒绝时流程结束，通过时节点完成后继续流转
+func (e *Engine) Act(inst *Instance, userId string, pass bool, comment string, now int64) error {
+	if inst.Status != Running {
+		return ErrFinished
+	}
+	idx := slices.IndexFunc(inst.Tasks, func(t *Task) bool {
+		return slices.Contains(t.Approvers, userId) && !slices.Contains(t.Passed, userId)
+	})
+	if idx < 0 {
+		return ErrNotApprover
+	}
+	task := inst.Tasks[idx]
+
+	if !pass {
+		inst.Records = append(inst.Records, &Record{NodeId: task.NodeId, UserId: userId, Action: ActionRefuse, Comment: comment, At: now})
+		inst.Status, inst.Tasks = Refused, nil
+		return nil
+	}
+
+	inst.Records = append(inst.Records, &Record{NodeId: task.NodeId, UserId: userId, Action: ActionPass, Comment: comment, At: now})
+	task.Passed = append(task.Passed, userId)
+	node := e.nodes[task.NodeId]
+	if node == nil {
+		return fmt.Errorf("%w: 节点 %s 不存在", ErrInvalidDefinition, task.NodeId)
+	}
+	if node.Mode != ModeAny && len(task.Passed) < len(task.Approvers) {
+		return nil
+	}
+
+	inst.Tasks = slices.Delete(inst.Tasks, idx, idx+1)
+	steps := 0
+	if err := e.advance(inst, node.Next, task.Scope, now, &steps); err != nil {
+		return err
+	}
+	e.finish(inst)
+	return nil
+}
+
+// Cancel 申请人撤销
+func (inst *Instance) Cancel(userId string, now int64) error {
+	if inst.Status != Running {
+		return ErrFinished
+	}
+	inst.Records = append(inst.Records, &Record{UserId: userId, Action: ActionCancel, At: now})
+	inst.Status, inst.Tasks = Canceled, nil
+	return nil
+}
+
+// Pending 当前待处理的审批人
+func (inst *Instance) Pending() []string {
+	var res []string
+	for _, t := range inst.Tasks {
+		for _, uid := range t.Approvers {
+			if !slices.Contains(t.Passed, uid) && !slices.Contains(res, uid) {
+				res = append(res, uid)
+			}
+		}
+	}
+	return res
+}
+
+// advance 从id开始流转，直到遇到审批节点、分支汇合未完成或流程结束；scope 为所在的并行节点
+func (e *Engine) advance(inst *Instance, id string, scope []string, now int64, steps *int) error {
+	for {
+		if *steps++; *steps > maxSteps {
+			return fmt.Errorf("%w: 流转次数过多", ErrInvalidDefinition)
+		}
+
+		// 到达所在并行节点的汇合点，全部分支到达后从汇合点继续
+		if len(scope) > 0 {
+			parallel := e.nodes[scope[len(scope)-1]]
+			if id == parallel.Join {
+				if inst.Arrived == nil {
+					inst.Arrived = map[string]int{}
+				}
+				inst.Arrived[parallel.Id]++
+				if inst.Arrived[parallel.Id] < len(parallel.Branches) {
+					return nil
+				}
+				delete(inst.Arrived, parallel.Id)
+				scope = scope[:len(scope)-1]
+				continue
+			}
+		}
+		if id == "" {
+			return nil
+		}
+
+		node := e.nodes[id]
+		if node == nil {
+			return fmt.Errorf("%w: 节点 %s 不存在", ErrInvalidDefinition, id)
+		}
+		switch node.Type {
+		case NodeApprove:
+			approvers, err := e.resolve(node)
+			if err != nil {
+				return err
+			}
+			approvers = compact(approvers)
+			if len(approvers) == 0 {
+				inst.Records = append(inst.Records, &Record{NodeId: node.Id, Action: ActionSkip, At: now})
+				id = node.Next
+				continue
+			}
+			inst.Tasks = append(inst.Tasks, &Task{NodeId: node.Id, Approvers: approvers, Scope: slices.Clone(scope)})
+			return nil
+		case NodeCondition:
+			next, ok := e.branch(node)
+			if !ok {
+				return fmt.Errorf("%w: 节点 %s", ErrNoBranch, node.Id)
+			}
+			id = next
+		case NodeParallel:
+			inner := append(slices.Clone(scope), node.Id)
+			for _, b := range node.Branches {
+				if err := e.advance(inst, b.Next, inner, now, steps); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+	}
+}
+
+// branch 第一个满足条件的分支，没有时走默认分支
+func (e *Engine) branch(node *Node) (string, bool) {
+	var def *Branch
+	for _, b := range node.Branches {
+		if b.When == nil {
+			if def == nil {
+				def = b
+			}
+			continue
+		}
+		if b.When.Match(e.form) {
+			return b.Next, true
+		}
+	}
+	if def != nil {
+		return def.Next, true
+	}
+	return "", false
+}
+
+// finish 没有待处理的节点时流程通过
+func (e *Engine) finish(inst *Instance) {
+	if inst.Status == Running && len(inst.Tasks) == 0 {
+		inst.Status = Passed
+	}
+}
+
+// compact 去掉空值和重复的审批人，保持顺序
+func compact(list []string) []string {
+	res := make([]string, 0, len(list))
+	for _, v := range list {
+		if v != "" && !slices.Contains(res, v) {
+			res = append(res, v)
+		}
+	}
+	return res
+}
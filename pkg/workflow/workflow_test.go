@@ -0,0 +1,175 @@
+package workflow
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+// 资产申领：金额超过5000时经理审批后再由财务和IT并行审批，否则只需IT审批
+func assetDefinition() *Definition {
+	return &Definition{
+		Fields: []*Field{
+			{Name: "amount", Label: "金额", Type: FieldNumber, Required: true},
+			{Name: "kind", Type: FieldString, Options: []string{"laptop", "phone"}},
+		},
+		Start: "route",
+		Nodes: []*Node{
+			{Id: "route", Type: NodeCondition, Branches: []*Branch{
+				{When: &Condition{Field: "amount", Op: OpGt, Value: 5000}, Next: "manager"},
+				{Next: "it"},
+			}},
+			{Id: "manager", Type: NodeApprove, Approvers: []string{"manager"}, Next: "split"},
+			{Id: "split", Type: NodeParallel, Branches: []*Branch{{Next: "finance"}, {Next: "it2"}}, Join: "archive"},
+			{Id: "finance", Type: NodeApprove, Approvers: []string{"f1", "f2"}, Mode: ModeAny, Next: "archive"},
+			{Id: "it2", Type: NodeApprove, Approvers: []string{"it"}, Next: "archive"},
+			{Id: "archive", Type: NodeApprove, Approvers: []string{"nobody"}},
+			{Id: "it", Type: NodeApprove, Approvers: []string{"it"}},
+		},
+	}
+}
+
+// resolver 审批人原样返回，nobody 表示找不到审批人
+func resolver(node *Node) ([]string, error) {
+	var res []string
+	for _, v := range node.Approvers {
+		if v != "nobody" {
+			res = append(res, v)
+		}
+	}
+	return res, nil
+}
+
+func TestEngine(t *testing.T) {
+	def := assetDefinition()
+
+	e, err := New(def, map[string]any{"amount": 300.0}, resolver)
+	if err != nil {
+		t.Fatal(err)
+	}
+	inst, err := e.Start(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := inst.Pending(); !slices.Equal(got, []string{"it"}) {
+		t.Fatalf("small amount should go to it, got %v", got)
+	}
+	if err := e.Act(inst, "manager", true, "", 2); !errors.Is(err, ErrNotApprover) {
+		t.Errorf("non approver should be rejected, got %v", err)
+	}
+	if err := e.Act(inst, "it", true, "", 2); err != nil || inst.Status != Passed {
+		t.Fatalf("expected passed, got %v %v", inst.Status, err)
+	}
+	if err := e.Act(inst, "it", true, "", 3); !errors.Is(err, ErrFinished) {
+		t.Errorf("finished instance should reject actions, got %v", err)
+	}
+
+	e, _ = New(def, map[string]any{"amount": int64(8000)}, resolver)
+	inst, _ = e.Start(1)
+	if err := e.Act(inst, "manager", true, "", 2); err != nil {
+		t.Fatal(err)
+	}
+	if got := inst.Pending(); !slices.Equal(got, []string{"f1", "f2", "it"}) {
+		t.Fatalf("parallel branches should be pending, got %v", got)
+	}
+	if err := e.Act(inst, "f2", true, "", 3); err != nil {
+		t.Fatal(err)
+	}
+	if inst.Status != Running || !slices.Equal(inst.Pending(), []string{"it"}) {
+		t.Fatalf("join should wait for all branches, got %v %v", inst.Status, inst.Pending())
+	}
+	if err := e.Act(inst, "it", true, "", 4); err != nil {
+		t.Fatal(err)
+	}
+	// 汇合节点没有审批人，自动跳过后流程结束
+	if inst.Status != Passed || inst.Records[len(inst.Records)-1].Action != ActionSkip {
+		t.Fatalf("expected passed after skip, got %v %+v", inst.Status, inst.Records)
+	}
+
+	e, _ = New(def, map[string]any{"amount": 8000}, resolver)
+	inst, _ = e.Start(1)
+	e.Act(inst, "manager", true, "", 2)
+	if err := e.Act(inst, "it", false, "不需要", 3); err != nil {
+		t.Fatal(err)
+	}
+	if inst.Status != Refused || len(inst.Pending()) != 0 {
+		t.Errorf("refuse should end the instance, got %v %v", inst.Status, inst.Pending())
+	}
+}
+
+func TestCountersign(t *testing.T) {
+	def := &Definition{Start: "a", Nodes: []*Node{{Id: "a", Type: NodeApprove, Approvers: []string{"u1", "u2", "u1"}}}}
+	e, _ := New(def, nil, resolver)
+	inst, _ := e.Start(1)
+	e.Act(inst, "u1", true, "", 2)
+	if inst.Status != Running || !slices.Equal(inst.Pending(), []string{"u2"}) {
+		t.Fatalf("countersign should wait for all approvers, got %v %v", inst.Status, inst.Pending())
+	}
+	e.Act(inst, "u2", true, "", 3)
+	if inst.Status != Passed {
+		t.Errorf("expected passed, got %v", inst.Status)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	cases := map[string]*Definition{
+		"missing node": {Start: "a", Nodes: []*Node{{Id: "a", Type: NodeApprove, Approvers: []string{"u"}, Next: "b"}}},
+		"cycle": {Start: "a", Nodes: []*Node{
+			{Id: "a", Type: NodeApprove, Approvers: []string{"u"}, Next: "b"},
+			{Id: "b", Type: NodeApprove, Approvers: []string{"u"}, Next: "a"},
+		}},
+		"branch not joined": {Start: "p", Nodes: []*Node{
+			{Id: "p", Type: NodeParallel, Branches: []*Branch{{Next: "a"}, {Next: "j"}}, Join: "j"},
+			{Id: "a", Type: NodeApprove, Approvers: []string{"u"}},
+			{Id: "j", Type: NodeApprove, Approvers: []string{"u"}},
+		}},
+		"bad operator": {Start: "c", Nodes: []*Node{
+			{Id: "c", Type: NodeCondition, Branches: []*Branch{{When: &Condition{Field: "x", Op: "like"}}}},
+		}},
+		"no approvers": {Start: "a", Nodes: []*Node{{Id: "a", Type: NodeApprove}}},
+	}
+	for name, def := range cases {
+		if err := def.Validate(); !errors.Is(err, ErrInvalidDefinition) {
+			t.Errorf("%s: expected invalid definition, got %v", name, err)
+		}
+	}
+	if err := assetDefinition().Validate(); err != nil {
+		t.Errorf("asset definition should be valid, got %v", err)
+	}
+}
+
+func TestValidateForm(t *testing.T) {
+	def := assetDefinition()
+	if err := def.ValidateForm(map[string]any{"amount": 1.5, "kind": "laptop"}); err != nil {
+		t.Errorf("valid form rejected: %v", err)
+	}
+	if def.ValidateForm(map[string]any{}) == nil {
+		t.Error("missing required field should fail")
+	}
+	if def.ValidateForm(map[string]any{"amount": "100"}) == nil {
+		t.Error("string amount should fail")
+	}
+	if def.ValidateForm(map[string]any{"amount": 1, "kind": "car"}) == nil {
+		t.Error("option outside list should fail")
+	}
+}
+
+func TestCondition(t *testing.T) {
+	form := map[string]any{"dep": "it", "amount": int32(10)}
+	cases := []struct {
+		c    Condition
+		want bool
+	}{
+		{Condition{Field: "dep", Op: OpEq, Value: "it"}, true},
+		{Condition{Field: "dep", Op: OpIn, Value: []any{"hr", "it"}}, true},
+		{Condition{Field: "amount", Op: OpEq, Value: 10.0}, true},
+		{Condition{Field: "amount", Op: OpLte, Value: 9}, false},
+		{Condition{Field: "missing", Op: OpNe, Value: "x"}, true},
+		{Condition{Field: "missing", Op: OpExists}, false},
+	}
+	for _, c := range cases {
+		if got := c.c.Match(form); got != c.want {
+			t.Errorf("%+v: got %v, want %v", c.c, got, c.want)
+		}
+	}
+}
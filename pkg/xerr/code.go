@@ -0,0 +1,147 @@
+package xerr
+
+import (
+	"errors"
+	"net/http"
+	"time"
+)
+
+// Code 错误码，对应http状态，并作为响应中的errCode供客户端判断
+type Code string
+
+const (
+	Internal        Code = "INTERNAL"
+	NotFound        Code = "NOT_FOUND"
+	Invalid         Code = "INVALID"
+	Conflict        Code = "CONFLICT"
+	Unauthorized    Code = "UNAUTHORIZED"
+	Forbidden       Code = "FORBIDDEN"
+	TooManyRequests Code = "TOO_MANY_REQUESTS"
+)
+
+// Status 错误码对应的http状态
+func (c Code) Status() int {
+	switch c {
+	case NotFound:
+		return http.StatusNotFound
+	case Invalid:
+		return http.StatusBadRequest
+	case Conflict:
+		return http.StatusConflict
+	case Unauthorized:
+		return http.StatusUnauthorized
+	case Forbidden:
+		return http.StatusForbidden
+	case TooManyRequests:
+		return http.StatusTooManyRequests
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+type codeError struct {
+	code Code
+	err  error
+}
+
+// NewCode 创建带错误码的错误，用于定义业务错误
+func NewCode(code Code, msg string) error {
+	return &codeError{code: code, err: errors.New(msg)}
+}
+
+// WithCode 为已有错误标记错误码，错误信息不变
+func WithCode(err error, code Code) error {
+	if err == nil {
+		return nil
+	}
+	return &codeError{code: code, err: err}
+}
+
+// CodeOf 错误链上最近的错误码，没有标记时为Internal
+func CodeOf(err error) Code {
+	var ce *codeError
+	if errors.As(err, &ce) {
+		return ce.code
+	}
+	return Internal
+}
+
+func (e *codeError) Error() string {
+	return e.err.Error()
+}
+
+func (e *codeError) Unwrap() error {
+	return e.err
+}
+
+type retryAfterError struct {
+	err   error
+	after time.Duration
+}
+
+// WithRetryAfter 标记客户端可在 after 之后重试，如限流、额度用完，http响应中返回 Retry-After
+func WithRetryAfter(err error, after time.Duration) error {
+	if err == nil {
+		return nil
+	}
+	return &retryAfterError{err: err, after: after}
+}
+
+// RetryAfter 错误链上标记的重试等待时间
+func RetryAfter(err error) (time.Duration, bool) {
+	var re *retryAfterError
+	if errors.As(err, &re) {
+		return re.after, true
+	}
+	return 0, false
+}
+
+func (e *retryAfterError) Error() string {
+	return e.err.Error()
+}
+
+func (e *retryAfterError) Cause() error {
+	return e.err
+}
+
+func (e *retryAfterError) Unwrap() error {
+	return e.err
+}
+
+// FieldError 单个字段的校验失败原因，随错误响应返回给客户端
+type FieldError struct {
+	Field   string `json:"field"`           // 请求中的字段名，嵌套字段用.连接，如 leave.startTime
+	Rule    string `json:"rule"`            // 未通过的规则，如 required、max
+	Param   string `json:"param,omitempty"` // 规则参数，如 max=100 中的100
+	Message string `json:"message"`
+}
+
+type fieldsError struct {
+	err    error
+	fields []FieldError
+}
+
+// WithFields 附加字段级的校验失败原因
+func WithFields(err error, fields []FieldError) error {
+	if err == nil {
+		return nil
+	}
+	return &fieldsError{err: err, fields: fields}
+}
+
+// Fields 错误链上附加的字段校验失败原因
+func Fields(err error) []FieldError {
+	var fe *fieldsError
+	if errors.As(err, &fe) {
+		return fe.fields
+	}
+	return nil
+}
+
+func (e *fieldsError) Error() string {
+	return e.err.Error()
+}
+
+func (e *fieldsError) Unwrap() error {
+	return e.err
+}
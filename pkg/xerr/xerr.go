@@ -1,40 +1,47 @@
 package xerr
 
 import (
+	"errors"
 	"fmt"
-	"github.com/pkg/errors"
+	"io"
 	"runtime"
-	"strconv"
+
+	pkgerrors "github.com/pkg/errors"
 )
 
-const callerSkipOffset = 3
+// callerSkipOffset 跳过 runtime.Callers、callers、withCallers 和 New/WithMessage
+const callerSkipOffset = 4
+
+const maxStackDepth = 32
+
+// withStack 记录错误创建时的调用栈，错误信息不变
+type withStack struct {
+	error
+	stack []uintptr
+}
 
 type withMessage struct {
 	cause error
 	msg   string
 }
 
+// New 记录调用栈，错误链上已有调用栈时原样返回
 func New(err error) error {
-	var path string
-	f, ok := getCallerFrame(0)
-	if ok {
-		path = f.File + ":" + strconv.Itoa(f.Line) + ":" + err.Error()
+	if err == nil {
+		return nil
 	}
-	return errors.WithMessage(err, path)
+	return withCallers(err)
 }
 
+// WithMessage 附加说明，调用栈在错误链上第一次经过xerr时记录；
+// 日志中的错误为 说明: 原因，返回给客户端的错误信息见 Message
 func WithMessage(err error, message string) error {
 	if err == nil {
 		return nil
 	}
-	var path string
-	f, ok := getCallerFrame(0)
-	if ok {
-		path = f.File + ":" + strconv.Itoa(f.Line)
-	}
 	return &withMessage{
-		cause: err,
-		msg:   path + ":" + message,
+		cause: withCallers(err),
+		msg:   message,
 	}
 }
 
@@ -42,33 +49,103 @@ func WithMessagef(err error, format string, v ...any) error {
 	if err == nil {
 		return nil
 	}
-	var path string
-	f, ok := getCallerFrame(0)
-	if ok {
-		path = f.File + ":" + strconv.Itoa(f.Line)
-	}
 	return &withMessage{
-		cause: err,
-		msg:   path + ":" + fmt.Sprintf(format, v...),
+		cause: withCallers(err),
+		msg:   fmt.Sprintf(format, v...),
 	}
 }
 
+func withCallers(err error) error {
+	if hasStack(err) {
+		return err
+	}
+	return &withStack{error: err, stack: callers()}
+}
+
 func (w *withMessage) Error() string {
-	return w.msg
+	return w.msg + ": " + w.cause.Error()
 }
 
 func (w *withMessage) Cause() error {
 	return w.cause
 }
 
-// 获取代码的执行行数
-func getCallerFrame(skip int) (frame runtime.Frame, ok bool) {
-	pc := make([]uintptr, 1)
-	numFrames := runtime.Callers(skip+callerSkipOffset, pc)
-	if numFrames < 1 {
+func (w *withMessage) Unwrap() error {
+	return w.cause
+}
+
+// Format %+v 输出完整的错误链和调用栈
+func (w *withMessage) Format(s fmt.State, verb rune) {
+	if verb == 'v' && s.Flag('+') {
+		fmt.Fprintf(s, "%s: %+v", w.msg, w.cause)
+		return
+	}
+	io.WriteString(s, w.Error())
+}
+
+func (w *withStack) Cause() error {
+	return w.error
+}
+
+func (w *withStack) Unwrap() error {
+	return w.error
+}
+
+// StackTrace 与 github.com/pkg/errors 的格式兼容
+func (w *withStack) StackTrace() pkgerrors.StackTrace {
+	st := make(pkgerrors.StackTrace, len(w.stack))
+	for i, pc := range w.stack {
+		st[i] = pkgerrors.Frame(pc)
+	}
+	return st
+}
+
+func (w *withStack) Format(s fmt.State, verb rune) {
+	if verb == 'v' && s.Flag('+') {
+		fmt.Fprintf(s, "%+v%+v", w.error, w.StackTrace())
 		return
 	}
+	io.WriteString(s, w.Error())
+}
+
+// Stack 错误链上记录的调用栈，每行一个函数和代码位置，没有记录时为空
+func Stack(err error) string {
+	var st interface{ StackTrace() pkgerrors.StackTrace }
+	if !errors.As(err, &st) {
+		return ""
+	}
+	return fmt.Sprintf("%+v", st.StackTrace())
+}
+
+// Message 返回给客户端的错误信息：去掉说明和调用栈，只保留最内层的错误信息
+func Message(err error) string {
+	for err != nil {
+		switch e := err.(type) {
+		case *withMessage:
+			err = e.cause
+		case *withStack:
+			err = e.error
+		case *codeError:
+			err = e.err
+		case *retryAfterError:
+			err = e.err
+		case *fieldsError:
+			err = e.err
+		default:
+			return err.Error()
+		}
+	}
+	return ""
+}
+
+func hasStack(err error) bool {
+	var st interface{ StackTrace() pkgerrors.StackTrace }
+	return errors.As(err, &st)
+}
 
-	frame, _ = runtime.CallersFrames(pc).Next()
-	return frame, frame.PC != 0
+// callers 获取创建错误的代码及其调用方
+func callers() []uintptr {
+	pc := make([]uintptr, maxStackDepth)
+	n := runtime.Callers(callerSkipOffset, pc)
+	return pc[:n]
 }
@@ -2,7 +2,10 @@ package xerr
 
 import (
 	"errors"
+	"fmt"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestWithMessage(t *testing.T) {
@@ -16,3 +19,87 @@ func TestWithMessage(t *testing.T) {
 		t.Log(e.Cause().Error())
 	}
 }
+
+func TestCode(t *testing.T) {
+	notFound := NewCode(NotFound, "不存在")
+	cases := []struct {
+		err    error
+		code   Code
+		status int
+	}{
+		{errors.New("x"), Internal, 500},
+		{notFound, NotFound, 404},
+		{WithMessage(notFound, "查询失败"), NotFound, 404},
+		{New(notFound), NotFound, 404},
+		{WithCode(errors.New("参数错误"), Invalid), Invalid, 400},
+		{WithCode(WithCode(notFound, Conflict), Invalid), Invalid, 400},
+	}
+	for i, c := range cases {
+		if code := CodeOf(c.err); code != c.code || code.Status() != c.status {
+			t.Errorf("case %d: got %s/%d, want %s/%d", i, code, code.Status(), c.code, c.status)
+		}
+	}
+	if !errors.Is(WithMessage(notFound, "查询失败"), notFound) {
+		t.Error("WithMessage should unwrap to cause")
+	}
+	if WithCode(nil, Invalid) != nil {
+		t.Error("WithCode(nil) should be nil")
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	limited := NewCode(TooManyRequests, "请求过于频繁")
+	err := WithRetryAfter(limited, 3*time.Second)
+	if d, ok := RetryAfter(WithMessage(err, "限流")); !ok || d != 3*time.Second {
+		t.Errorf("got %v %v", d, ok)
+	}
+	if CodeOf(err) != TooManyRequests || !errors.Is(err, limited) || err.Error() != limited.Error() {
+		t.Error("WithRetryAfter should keep code and message")
+	}
+	if _, ok := RetryAfter(limited); ok {
+		t.Error("unexpected retry after")
+	}
+}
+
+func TestStack(t *testing.T) {
+	base := errors.New("no documents")
+	err := WithMessagef(WithMessage(New(base), "查询用户失败"), "登录失败: %s", "u1")
+
+	if err.Error() != "登录失败: u1: 查询用户失败: no documents" {
+		t.Errorf("got %q", err.Error())
+	}
+	if !errors.Is(err, base) {
+		t.Error("should unwrap to base error")
+	}
+
+	// 调用栈只在第一次经过xerr时记录，第一帧为调用方
+	stack := Stack(err)
+	if !strings.HasPrefix(stack, "\naiOffice/pkg/xerr.TestStack\n") || strings.Count(stack, "TestStack") != 1 {
+		t.Errorf("unexpected stack:\n%s", stack)
+	}
+	if full := fmt.Sprintf("%+v", err); !strings.Contains(full, "查询用户失败: no documents") || !strings.Contains(full, "xerr_test.go") {
+		t.Errorf("%%+v should print chain and stack:\n%s", full)
+	}
+	if Stack(base) != "" || New(nil) != nil {
+		t.Error("unexpected stack")
+	}
+}
+
+func TestMessage(t *testing.T) {
+	notFound := NewCode(NotFound, "不存在")
+	cases := []struct {
+		err  error
+		want string
+	}{
+		{errors.New("x"), "x"},
+		{WithMessage(notFound, "查询失败"), "不存在"},
+		{WithMessage(WithRetryAfter(New(notFound), time.Second), "限流"), "不存在"},
+		{WithFields(WithCode(errors.New("参数错误"), Invalid), nil), "参数错误"},
+		{WithMessage(fmt.Errorf("wrap: %w", notFound), "查询失败"), "wrap: 不存在"},
+	}
+	for i, c := range cases {
+		if got := Message(c.err); got != c.want {
+			t.Errorf("case %d: got %q, want %q", i, got, c.want)
+		}
+	}
+}